@@ -31,6 +31,7 @@ import (
 
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
@@ -648,7 +649,7 @@ func (diff *EthStateOverride) Apply(state *state.StateDB) error {
 		}
 		// Override account balance.
 		if account.Balance != nil {
-			state.SetBalance(addr, (*big.Int)(*account.Balance))
+			state.SetBalance(addr, (*big.Int)(*account.Balance), tracing.BalanceChangeUnspecified)
 		}
 		if account.State != nil && account.StateDiff != nil {
 			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
@@ -1423,7 +1424,7 @@ func EthDoCall(ctx context.Context, b Backend, args EthTransactionArgs, blockNrO
 	}
 
 	// Add gas fee to sender for estimating gasLimit/computing cost or calling a function by insufficient balance sender.
-	state.AddBalance(msg.ValidatedSender(), new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), msg.EffectiveGasPrice(header, b.ChainConfig())))
+	state.AddBalance(msg.ValidatedSender(), new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), msg.EffectiveGasPrice(header, b.ChainConfig())), tracing.BalanceChangeUnspecified)
 
 	// The intrinsicGas is checked again later in the blockchain.ApplyMessage function,
 	// but we check in advance here in order to keep StateTransition.TransactionDb method as unchanged as possible
@@ -1564,36 +1565,35 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 	if rpcGasCap := b.RPCGasCap(); rpcGasCap != nil {
 		gasCap = rpcGasCap.Uint64()
 	}
+	to, toMsg, err := args.accessListMsgBuilder(ctx, b, header, gasCap)
+	if err != nil {
+		return nil, 0, nil, err
+	}
 
-	// Retrieve the precompiles since they don't need to be added to the access list
-	rules := b.ChainConfig().Rules(header.Number)
-	precompiles := vm.ActivePrecompiles(rules)
+	acl, res, err := accessList(ctx, b, db, header, args.from(), to, nil, args.AccessList, toMsg)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return acl, res.UsedGas, res.Unwrap(), nil
+}
 
-	toMsg := func() (*types.Transaction, error) {
-		intrinsicGas, err := types.IntrinsicGas(args.data(), nil, nil, args.To == nil, rules)
-		if err != nil {
-			return nil, err
-		}
-		return args.ToMessage(gasCap, header.BaseFee, intrinsicGas)
+// accessListMsgBuilder fills in the default values for unspecified fields, and returns the
+// recipient and the builder of the message carrying the given access list.
+func (args *EthTransactionArgs) accessListMsgBuilder(ctx context.Context, b Backend, header *types.Header, gasCap uint64) (common.Address, func(accessList *types.AccessList) (*types.Transaction, error), error) {
+	baseFee := new(big.Int).SetUint64(params.ZeroBaseFee)
+	if header.BaseFee != nil {
+		baseFee = header.BaseFee
 	}
+	rules := b.ChainConfig().Rules(header.Number)
 
 	if args.Gas == nil {
 		// Set gaslimit to maximum if the gas is not specified
 		upperGasLimit := hexutil.Uint64(params.UpperGasLimit)
 		args.Gas = &upperGasLimit
 	}
-	if msg, err := toMsg(); err == nil {
-		baseFee := new(big.Int).SetUint64(params.ZeroBaseFee)
-		if header.BaseFee != nil {
-			baseFee = header.BaseFee
-		}
-		// Add gas fee to sender for estimating gasLimit/computing cost or calling a function by insufficient balance sender.
-		db.AddBalance(msg.ValidatedSender(), new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), baseFee))
-	}
-
 	// Ensure any missing fields are filled, extract the recipient and input data
 	if err := args.setDefaults(ctx, b); err != nil {
-		return nil, 0, nil, err
+		return common.Address{}, nil, err
 	}
 	var to common.Address
 	if args.To != nil {
@@ -1601,41 +1601,74 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 	} else {
 		to = crypto.CreateAddress(args.from(), uint64(*args.Nonce))
 	}
+	toMsg := func(accessList *types.AccessList) (*types.Transaction, error) {
+		args.AccessList = accessList
+		intrinsicGas, err := types.IntrinsicGas(args.data(), args.GetAccessList(), nil, args.To == nil, rules)
+		if err != nil {
+			return nil, err
+		}
+		return args.ToMessage(gasCap, baseFee, intrinsicGas)
+	}
+	return to, toMsg, nil
+}
+
+// accessList repeatedly applies the message built by toMsg with the access list collected so
+// far on top of db, until the access list converges. The sender, the recipient, the active
+// precompiles and the addresses in excl are warm already, so they are not listed.
+func accessList(ctx context.Context, b Backend, db *state.StateDB, header *types.Header, from, to common.Address, excl []common.Address, initial *types.AccessList, toMsg func(accessList *types.AccessList) (*types.Transaction, error)) (types.AccessList, *blockchain.ExecutionResult, error) {
+	// Retrieve the precompiles since they don't need to be added to the access list
+	excl = append(vm.ActivePrecompiles(b.ChainConfig().Rules(header.Number)), excl...)
+
+	// Add gas fee to the sender and the fee payer for calling a function by insufficient balance accounts.
+	msg, err := toMsg(initial)
+	if err != nil {
+		return nil, nil, err
+	}
+	addCallFee(db, msg, header, b.ChainConfig())
 
 	// Create an initial tracer
-	prevTracer := vm.NewAccessListTracer(nil, args.from(), to, precompiles)
-	if args.AccessList != nil {
-		prevTracer = vm.NewAccessListTracer(*args.AccessList, args.from(), to, precompiles)
+	prevTracer := vm.NewAccessListTracer(nil, from, to, excl)
+	if initial != nil {
+		prevTracer = vm.NewAccessListTracer(*initial, from, to, excl)
 	}
 	for {
 		if err := ctx.Err(); err != nil {
-			return nil, 0, nil, err
+			return nil, nil, err
 		}
 		// Retrieve the current access list to expand
 		accessList := prevTracer.AccessList()
 		logger.Trace("Creating access list", "input", accessList)
 
-		// Copy the original db so we don't modify it
-		statedb := db.Copy()
 		// Set the accesslist to the last al
-		args.AccessList = &accessList
-		msg, err := toMsg()
+		msg, err := toMsg(&accessList)
 		if err != nil {
-			return nil, 0, nil, err
+			return nil, nil, err
 		}
 
 		// Apply the transaction with the access list tracer
-		tracer := vm.NewAccessListTracer(accessList, args.from(), to, precompiles)
-		config := vm.Config{Tracer: tracer, Debug: true}
-		vmenv, _, err := b.GetEVM(ctx, msg, statedb, header, config)
-		res, err := blockchain.ApplyMessage(vmenv, msg)
+		tracer := vm.NewAccessListTracer(accessList, from, to, excl)
+		res, err := applyAccessListMsg(ctx, b, db, header, msg, vm.Config{Tracer: tracer, Debug: true})
 		if err != nil {
-			tx, _ := args.toTransaction()
-			return nil, 0, nil, fmt.Errorf("failed to apply transaction: %v err: %v", tx.Hash().Hex(), err)
+			return nil, nil, err
 		}
 		if tracer.Equal(prevTracer) {
-			return accessList, res.UsedGas, res.Unwrap(), nil
+			return accessList, res, nil
 		}
 		prevTracer = tracer
 	}
 }
+
+// applyAccessListMsg applies the message on a copy of db.
+func applyAccessListMsg(ctx context.Context, b Backend, db *state.StateDB, header *types.Header, msg *types.Transaction, vmCfg vm.Config) (*blockchain.ExecutionResult, error) {
+	// Copy the original db so we don't modify it
+	statedb := db.Copy()
+	vmenv, _, err := b.GetEVM(ctx, msg, statedb, header, vmCfg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := blockchain.ApplyMessage(vmenv, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply transaction: %v err: %v", msg.Hash().Hex(), err)
+	}
+	return res, nil
+}
@@ -31,6 +31,8 @@ import (
 	"time"
 
 	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/types/account"
 	"github.com/kaiachain/kaia/blockchain/types/accountkey"
@@ -38,6 +40,8 @@ import (
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/common/hexutil"
 	"github.com/kaiachain/kaia/common/math"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/kerrors"
 	"github.com/kaiachain/kaia/log"
 	"github.com/kaiachain/kaia/networks/rpc"
 	"github.com/kaiachain/kaia/node/cn/filters"
@@ -288,6 +292,16 @@ type CallArgs struct {
 	// Introduced by AccessListTxType transaction.
 	AccessList *types.AccessList `json:"accessList,omitempty"`
 	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+
+	// If TypeInt is set, the call is executed as a transaction of the given type.
+	// The fields below are used by the corresponding transaction types.
+	TypeInt           *types.TxType            `json:"typeInt,omitempty"`
+	AuthorizationList *types.AuthorizationList `json:"authorizationList,omitempty"`
+	FeePayer          *common.Address          `json:"feePayer,omitempty"`
+	FeeRatio          *types.FeeRatio          `json:"feeRatio,omitempty"`
+	Key               *hexutil.Bytes           `json:"key,omitempty"`
+	CodeFormat        *params.CodeFormat       `json:"codeFormat,omitempty"`
+	HumanReadable     *bool                    `json:"humanReadable,omitempty"`
 }
 
 func (args *CallArgs) InputData() []byte {
@@ -327,25 +341,13 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 	// this makes sure resources are cleaned up.
 	defer cancel()
 
-	intrinsicGas, err := types.IntrinsicGas(args.InputData(), args.GetAccessList(), nil, args.To == nil, b.ChainConfig().Rules(header.Number))
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// header.BaseFee != nil means magma hardforked
-	var baseFee *big.Int
-	if header.BaseFee != nil {
-		baseFee = header.BaseFee
-	} else {
-		baseFee = new(big.Int).SetUint64(params.ZeroBaseFee)
-	}
-	msg, err := args.ToMessage(globalGasCap.Uint64(), baseFee, intrinsicGas)
+	msg, intrinsicGas, err := args.toCallMessage(b.ChainConfig(), state, header, globalGasCap.Uint64())
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Add gas fee to sender for estimating gasLimit/computing cost or calling a function by insufficient balance sender.
-	state.AddBalance(msg.ValidatedSender(), new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), msg.EffectiveGasPrice(header, b.ChainConfig())))
+	addCallFee(state, msg, header, b.ChainConfig())
 
 	// The intrinsicGas is checked again later in the blockchain.ApplyMessage function,
 	// but we check in advance here in order to keep StateTransition.TransactionDb method as unchanged as possible
@@ -436,6 +438,12 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 		return 0, err
 	}
 	balance := state.GetBalance(args.From) // from can't be nil
+	if feeCap.BitLen() != 0 && args.TypeInt != nil && args.TypeInt.IsFeeDelegatedTransaction() && args.FeePayer != nil {
+		balance, err = feeDelegatedBalance(balance, state.GetBalance(*args.FeePayer), args.Value.ToInt(), args.FeeRatio)
+		if err != nil {
+			return 0, err
+		}
+	}
 
 	// Create a helper to check if a gas allowance results in an executable transaction
 	executable := func(gas uint64) (bool, *blockchain.ExecutionResult, error) {
@@ -453,6 +461,30 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 	return blockchain.DoEstimateGas(ctx, uint64(args.Gas), gasCap.Uint64(), args.Value.ToInt(), feeCap, balance, executable)
 }
 
+// feeDelegatedBalance returns the balance bounding the gas of a fee-delegated transaction in
+// blockchain.DoEstimateGas, which subtracts the value from it. The sender pays the value and
+// its share of the fee while the fee payer pays the rest of the fee, so the fee budget is
+// computed from each balance separately and the value is added back to it.
+func feeDelegatedBalance(senderBalance, feePayerBalance, value *big.Int, feeRatio *types.FeeRatio) (*big.Int, error) {
+	senderFunds := new(big.Int).Sub(senderBalance, value)
+	if senderFunds.Sign() < 0 {
+		return nil, errors.New("insufficient funds for transfer")
+	}
+	feeBudget := feePayerBalance
+	if feeRatio != nil {
+		ratio := uint64(*feeRatio)
+		if ratio == 0 || ratio >= uint64(types.MaxFeeRatio) {
+			// The invalid ratio is rejected when the transaction is executed.
+			return senderBalance, nil
+		}
+		// The fee is split by the ratio, so the budget is bound by the one who runs out first.
+		feePayerBudget := new(big.Int).Div(new(big.Int).Mul(feePayerBalance, big.NewInt(100)), new(big.Int).SetUint64(ratio))
+		senderBudget := new(big.Int).Div(new(big.Int).Mul(senderFunds, big.NewInt(100)), new(big.Int).SetUint64(100-ratio))
+		feeBudget = math.BigMin(feePayerBudget, senderBudget)
+	}
+	return new(big.Int).Add(value, feeBudget), nil
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
@@ -464,18 +496,175 @@ type ExecutionResult struct {
 }
 
 // AccessListResult returns an optional accesslist
-// Its the result of the `debug_createAccessList` RPC call.
+// Its the result of the `kaia_createAccessList` RPC call.
 // It contains an error if the transaction itself failed.
 type AccessListResult struct {
 	Accesslist *types.AccessList `json:"accessList"`
 	Error      string            `json:"error,omitempty"`
 	GasUsed    hexutil.Uint64    `json:"gasUsed"`
+
+	// GasUsedWithoutAccessList is the gas used when the transaction is executed without
+	// the access list, and GasDelta is GasUsed minus it. A negative delta is the gas saved.
+	GasUsedWithoutAccessList hexutil.Uint64 `json:"gasUsedWithoutAccessList"`
+	GasDelta                 *hexutil.Big   `json:"gasDelta"`
+
+	// FeePayer is set for fee-delegated transactions. Like the sender, the fee payer is
+	// warm during the execution, so its address is not listed in the access list.
+	FeePayer *common.Address `json:"feePayer,omitempty"`
+}
+
+// AccessListArgs represents the arguments of kaia_createAccessList. The requests without
+// TypeInt are handled in the same way as eth_createAccessList, and the Kaia fields are used
+// by the transaction types given by TypeInt as in CallArgs.
+type AccessListArgs struct {
+	EthTransactionArgs
+
+	TypeInt           *types.TxType            `json:"typeInt,omitempty"`
+	AuthorizationList *types.AuthorizationList `json:"authorizationList,omitempty"`
+	FeePayer          *common.Address          `json:"feePayer,omitempty"`
+	FeeRatio          *types.FeeRatio          `json:"feeRatio,omitempty"`
+	Key               *hexutil.Bytes           `json:"key,omitempty"`
+	CodeFormat        *params.CodeFormat       `json:"codeFormat,omitempty"`
+	HumanReadable     *bool                    `json:"humanReadable,omitempty"`
 }
 
-// CreateAccessList creates a EIP-2930 type AccessList for the given transaction.
-// Reexec and BlockNrOrHash can be specified to create the accessList on top of a certain state.
-func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args EthTransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (interface{}, error) {
-	return doCreateAccessList(ctx, s.b, args, blockNrOrHash)
+// toCallArgs converts the arguments to CallArgs to execute the transaction of the given type.
+func (args *AccessListArgs) toCallArgs() CallArgs {
+	callArgs := CallArgs{
+		From:                 args.from(),
+		To:                   args.To,
+		GasPrice:             args.GasPrice,
+		MaxFeePerGas:         args.MaxFeePerGas,
+		MaxPriorityFeePerGas: args.MaxPriorityFeePerGas,
+		Input:                args.data(),
+		AccessList:           args.AccessList,
+		ChainID:              args.ChainID,
+		TypeInt:              args.TypeInt,
+		AuthorizationList:    args.AuthorizationList,
+		FeePayer:             args.FeePayer,
+		FeeRatio:             args.FeeRatio,
+		Key:                  args.Key,
+		CodeFormat:           args.CodeFormat,
+		HumanReadable:        args.HumanReadable,
+	}
+	if args.Gas != nil {
+		callArgs.Gas = *args.Gas
+	}
+	if args.Value != nil {
+		callArgs.Value = *args.Value
+	}
+	return callArgs
+}
+
+// CreateAccessList creates a EIP-2930 type AccessList for the given transaction of any type.
+// BlockNrOrHash can be specified to create the accessList on top of a certain state.
+func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args AccessListArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*AccessListResult, error) {
+	bNrOrHash := rpc.NewBlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	return doCreateKaiaAccessList(ctx, s.b, args, bNrOrHash)
+}
+
+// doCreateKaiaAccessList repeatedly executes the transaction with the access list collected
+// so far until it converges, and then executes it once more without the access list to
+// report the gas delta. Kaia transaction types can't carry an access list, so their access
+// list is informational and does not change the gas used.
+func doCreateKaiaAccessList(ctx context.Context, b Backend, args AccessListArgs, blockNrOrHash rpc.BlockNumberOrHash) (*AccessListResult, error) {
+	db, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	gasCap := uint64(0)
+	if rpcGasCap := b.RPCGasCap(); rpcGasCap != nil {
+		gasCap = rpcGasCap.Uint64()
+	}
+
+	// The fee payer and the authorities are warm already as well as the sender and the recipient.
+	var (
+		from     = args.from()
+		to       common.Address
+		excl     []common.Address
+		feePayer *common.Address
+		toMsg    func(accessList *types.AccessList) (*types.Transaction, error)
+	)
+	if args.TypeInt == nil {
+		// The requests without typeInt are filled in as eth_createAccessList does.
+		if to, toMsg, err = args.EthTransactionArgs.accessListMsgBuilder(ctx, b, header, gasCap); err != nil {
+			return nil, err
+		}
+	} else {
+		if args.Gas == nil {
+			// Set gaslimit to maximum if the gas is not specified
+			upperGasLimit := hexutil.Uint64(params.UpperGasLimit)
+			args.Gas = &upperGasLimit
+		}
+		callArgs := args.toCallArgs()
+		carriesAccessList := args.TypeInt.IsEthTypedTransaction()
+		toMsg = func(accessList *types.AccessList) (*types.Transaction, error) {
+			if carriesAccessList {
+				callArgs.AccessList = accessList
+			}
+			msg, _, err := callArgs.toCallMessage(b.ChainConfig(), db, header, gasCap)
+			return msg, err
+		}
+		if args.To != nil {
+			to = *args.To
+		} else {
+			nonce := db.GetNonce(from)
+			if args.Nonce != nil {
+				nonce = uint64(*args.Nonce)
+			}
+			to = crypto.CreateAddress(from, nonce)
+		}
+		if args.TypeInt.IsFeeDelegatedTransaction() && args.FeePayer != nil {
+			feePayer = args.FeePayer
+			excl = append(excl, *feePayer)
+		}
+		if args.AuthorizationList != nil {
+			for _, auth := range *args.AuthorizationList {
+				// Invalid authorizations are skipped during the execution as well.
+				if authority, err := auth.Authority(); err == nil {
+					excl = append(excl, authority)
+				}
+			}
+		}
+	}
+
+	accessList, res, err := accessList(ctx, b, db, header, from, to, excl, args.AccessList, toMsg)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := toMsg(nil)
+	if err != nil {
+		return nil, err
+	}
+	resWithout, err := applyAccessListMsg(ctx, b, db, header, msg, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	delta := new(big.Int).Sub(new(big.Int).SetUint64(res.UsedGas), new(big.Int).SetUint64(resWithout.UsedGas))
+	result := &AccessListResult{
+		Accesslist:               &accessList,
+		GasUsed:                  hexutil.Uint64(res.UsedGas),
+		GasUsedWithoutAccessList: hexutil.Uint64(resWithout.UsedGas),
+		GasDelta:                 (*hexutil.Big)(delta),
+		FeePayer:                 feePayer,
+	}
+	if vmErr := res.Unwrap(); vmErr != nil {
+		result.Error = vmErr.Error()
+	}
+	return result, nil
+}
+
+// addCallFee adds the gas fee of the message to the sender and the fee payer, so that
+// the message can be executed regardless of their balances.
+func addCallFee(state *state.StateDB, msg *types.Transaction, header *types.Header, config *params.ChainConfig) {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), msg.EffectiveGasPrice(header, config))
+	state.AddBalance(msg.ValidatedSender(), fee, tracing.BalanceChangeUnspecified)
+	if msg.ValidatedFeePayer() != msg.ValidatedSender() {
+		state.AddBalance(msg.ValidatedFeePayer(), fee, tracing.BalanceChangeUnspecified)
+	}
 }
 
 func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*EthAccountResult, error) {
@@ -739,3 +928,137 @@ func (args *CallArgs) ToMessage(globalGasCap uint64, baseFee *big.Int, intrinsic
 	}
 	return types.NewMessage(addr, args.To, 0, value, gas, gasPrice, nil, nil, args.InputData(), false, intrinsicGas, accessList, nil), nil
 }
+
+// toCallMessage converts the arguments to a message executed on top of the given state.
+// It returns the intrinsic gas of the message as well.
+func (args *CallArgs) toCallMessage(config *params.ChainConfig, state *state.StateDB, header *types.Header, globalGasCap uint64) (*types.Transaction, uint64, error) {
+	// header.BaseFee != nil means magma hardforked
+	baseFee := new(big.Int).SetUint64(params.ZeroBaseFee)
+	if header.BaseFee != nil {
+		baseFee = header.BaseFee
+	}
+	if args.TypeInt != nil {
+		return args.toTypedMessage(config, state, header, globalGasCap, baseFee)
+	}
+	intrinsicGas, err := types.IntrinsicGas(args.InputData(), args.GetAccessList(), nil, args.To == nil, config.Rules(header.Number))
+	if err != nil {
+		return nil, 0, err
+	}
+	msg, err := args.ToMessage(globalGasCap, baseFee, intrinsicGas)
+	if err != nil {
+		return nil, 0, err
+	}
+	return msg, intrinsicGas, nil
+}
+
+// toTypedMessage converts the arguments to a message of the transaction type given by TypeInt.
+// Since the transaction is not signed, the account keys of the sender and the fee payer are
+// checked against the transaction instead, and the signature validation gas is added to the
+// intrinsic gas.
+func (args *CallArgs) toTypedMessage(config *params.ChainConfig, state *state.StateDB, header *types.Header, globalGasCap uint64, baseFee *big.Int) (*types.Transaction, uint64, error) {
+	// Resolve the gas limit and the gas price in the same way as the legacy call.
+	base, err := args.ToMessage(globalGasCap, baseFee, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	var (
+		txType   = *args.TypeInt
+		gas      = hexutil.Uint64(base.Gas())
+		nonce    = hexutil.Uint64(state.GetNonce(args.From))
+		gasPrice = (*hexutil.Big)(base.GasPrice())
+	)
+	txArgs := SendTxArgs{
+		TypeInt:           args.TypeInt,
+		From:              args.From,
+		Recipient:         args.To,
+		GasLimit:          &gas,
+		AccountNonce:      &nonce,
+		CodeFormat:        args.CodeFormat,
+		HumanReadable:     args.HumanReadable,
+		Key:               args.Key,
+		AccessList:        args.AccessList,
+		AuthorizationList: args.AuthorizationList,
+		ChainID:           args.ChainID,
+		FeePayer:          args.FeePayer,
+		FeeRatio:          args.FeeRatio,
+	}
+	if txType == types.TxTypeEthereumDynamicFee || txType == types.TxTypeEthereumSetCode {
+		txArgs.MaxFeePerGas = gasPrice
+		txArgs.MaxPriorityFeePerGas = gasPrice
+		if args.MaxPriorityFeePerGas != nil {
+			txArgs.MaxPriorityFeePerGas = args.MaxPriorityFeePerGas
+		}
+	} else {
+		txArgs.Price = gasPrice
+	}
+	if txType.IsEthTypedTransaction() && txArgs.ChainID == nil {
+		txArgs.ChainID = (*hexutil.Big)(config.ChainID)
+	}
+	if txType.IsEthereumTransaction() || isTxField[txType]["Amount"] {
+		txArgs.Amount = &args.Value
+	}
+	if input := hexutil.Bytes(args.InputData()); input != nil {
+		txArgs.Payload = &input
+	} else if isTxField[txType]["Payload"] {
+		txArgs.Payload = &hexutil.Bytes{}
+	}
+	if txType.IsEthTypedTransaction() && txArgs.AccessList == nil {
+		txArgs.AccessList = &types.AccessList{}
+	}
+	tx, err := txArgs.toTransaction()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	blockNumber := header.Number.Uint64()
+	intrinsicGas, err := tx.IntrinsicGas(blockNumber)
+	if err != nil {
+		return nil, 0, err
+	}
+	if txType.IsEthereumTransaction() {
+		// Ethereum transactions cannot be executed unless the account has a legacy key.
+		if !state.GetKey(args.From).Type().IsLegacyAccountKey() {
+			return nil, 0, types.ErrSender(kerrors.ErrLegacyTransactionMustBeWithLegacyKey)
+		}
+	} else {
+		gasKey, err := sigValidationGas(state.GetKey(args.From), blockNumber, tx.GetRoleTypeForValidation())
+		if err != nil {
+			return nil, 0, types.ErrSender(err)
+		}
+		intrinsicGas += gasKey
+	}
+	feePayer := args.From
+	if tx.IsFeeDelegatedTransaction() {
+		if feePayer, err = tx.FeePayer(); err != nil {
+			return nil, 0, err
+		}
+		gasKey, err := sigValidationGas(state.GetKey(feePayer), blockNumber, accountkey.RoleFeePayer)
+		if err != nil {
+			return nil, 0, types.ErrFeePayer(err)
+		}
+		intrinsicGas += gasKey
+	}
+	return tx.AsCallMessage(args.From, feePayer, intrinsicGas), intrinsicGas, nil
+}
+
+// sigValidationGas checks whether the account key is able to sign for the given role and
+// returns the gas to validate the signatures. Since the number of signatures is not known
+// for calls, a multisig key is charged as if all of its keys have signed.
+func sigValidationGas(key accountkey.AccountKey, blockNumber uint64, role accountkey.RoleType) (uint64, error) {
+	roleKey := key
+	if roleBased, ok := key.(*accountkey.AccountKeyRoleBased); ok {
+		if len(*roleBased) > int(role) {
+			roleKey = (*roleBased)[role]
+		} else {
+			roleKey = (*roleBased)[accountkey.RoleTransaction]
+		}
+	}
+	numSigs := 1
+	switch k := roleKey.(type) {
+	case *accountkey.AccountKeyFail:
+		return 0, types.ErrInvalidAccountKey
+	case *accountkey.AccountKeyWeightedMultiSig:
+		numSigs = len(k.Keys)
+	}
+	return key.SigValidationGas(blockNumber, role, numSigs)
+}
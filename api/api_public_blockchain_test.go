@@ -20,13 +20,25 @@ package api
 
 import (
 	"context"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	mock_api "github.com/kaiachain/kaia/api/mocks"
 	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/types/accountkey"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/fork"
 	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func testInitForKaiaApi(t *testing.T) (*gomock.Controller, *mock_api.MockBackend, *PublicBlockChainAPI) {
@@ -62,3 +74,239 @@ func TestKaiaAPI_EstimateGas(t *testing.T) {
 		return api.EstimateGas(context.Background(), args, nil, nil)
 	})
 }
+
+// testAuthorityKey signs the authorizations of set code transactions, and testAuthorityReader
+// is a contract reading the balance of its address.
+var (
+	testAuthorityKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAuthorityReader = common.HexToAddress("0xffff")
+)
+
+// testKaiaTxTypeBackend sets up a backend whose state has accounts with various account keys,
+// a contract reading the storage slot 0 and testAuthorityReader.
+func testKaiaTxTypeBackend(t *testing.T, mockBackend *mock_api.MockBackend) (funded, empty, multiSig, failKey, contract common.Address) {
+	chainConfig := &params.ChainConfig{ChainID: big.NewInt(1)}
+	chainConfig.IstanbulCompatibleBlock = common.Big0
+	chainConfig.LondonCompatibleBlock = common.Big0
+	chainConfig.EthTxTypeCompatibleBlock = common.Big0
+	chainConfig.MagmaCompatibleBlock = common.Big0
+	chainConfig.KoreCompatibleBlock = common.Big0
+	chainConfig.ShanghaiCompatibleBlock = common.Big0
+	chainConfig.CancunCompatibleBlock = common.Big0
+	chainConfig.KaiaCompatibleBlock = common.Big0
+	chainConfig.PragueCompatibleBlock = common.Big0
+	// The signature validation gas of account keys depends on the fork rules.
+	require.NoError(t, fork.SetHardForkBlockNumberConfig(chainConfig))
+	t.Cleanup(fork.ClearHardForkBlockNumberConfig)
+
+	funded = common.HexToAddress("0xaaaa")
+	empty = common.HexToAddress("0xbbbb")
+	multiSig = common.HexToAddress("0xcccc")
+	failKey = common.HexToAddress("0xdddd")
+	contract = common.HexToAddress("0xeeee")
+
+	var (
+		gspec = &blockchain.Genesis{Alloc: blockchain.GenesisAlloc{
+			funded:   {Balance: big.NewInt(params.KAIA * 2)},
+			empty:    {Balance: common.Big0},
+			multiSig: {Balance: big.NewInt(params.KAIA * 2)},
+			failKey:  {Balance: big.NewInt(params.KAIA * 2)},
+			contract: {Balance: common.Big0, Code: hexutil.MustDecode("0x60005450")}, // PUSH1 0 SLOAD POP
+			// PUSH20 authority BALANCE POP
+			testAuthorityReader: {Balance: common.Big0, Code: append(append([]byte{0x73}, crypto.PubkeyToAddress(testAuthorityKey.PublicKey).Bytes()...), 0x31, 0x50)},
+		}, Config: chainConfig}
+		dbm    = database.NewMemoryDBManager()
+		db     = state.NewDatabase(dbm)
+		block  = gspec.MustCommit(dbm)
+		header = block.Header()
+		chain  = &testChainContext{header: header}
+	)
+	keys := accountkey.WeightedPublicKeys{}
+	for i := 0; i < 3; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, accountkey.NewWeightedPublicKey(1, (*accountkey.PublicKeySerializable)(&key.PublicKey)))
+	}
+	multiSigKey := accountkey.NewAccountKeyWeightedMultiSigWithValues(2, keys)
+
+	any := gomock.Any()
+	getStateAndHeader := func(...interface{}) (*state.StateDB, *types.Header, error) {
+		state, err := state.New(block.Root(), db, nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		require.NoError(t, state.UpdateKey(multiSig, multiSigKey, 0))
+		require.NoError(t, state.UpdateKey(failKey, accountkey.NewAccountKeyFail(), 0))
+		return state, header, nil
+	}
+	getEVM := func(_ context.Context, msg blockchain.Message, state *state.StateDB, header *types.Header, vmConfig vm.Config) (*vm.EVM, func() error, error) {
+		vmError := func() error { return nil }
+		txContext := blockchain.NewEVMTxContext(msg, header, chainConfig)
+		blockContext := blockchain.NewEVMBlockContext(header, chain, nil)
+		return vm.NewEVM(blockContext, txContext, state, chainConfig, &vmConfig), vmError, nil
+	}
+	mockBackend.EXPECT().ChainConfig().Return(chainConfig).AnyTimes()
+	mockBackend.EXPECT().RPCGasCap().Return(common.Big0).AnyTimes()
+	mockBackend.EXPECT().RPCEVMTimeout().Return(5 * time.Second).AnyTimes()
+	mockBackend.EXPECT().StateAndHeaderByNumberOrHash(any, any).DoAndReturn(getStateAndHeader).AnyTimes()
+	mockBackend.EXPECT().GetEVM(any, any, any, any, any).DoAndReturn(getEVM).AnyTimes()
+	return
+}
+
+func TestKaiaAPI_EstimateGas_TxTypes(t *testing.T) {
+	mockCtrl, mockBackend, api := testInitForKaiaApi(t)
+	defer mockCtrl.Finish()
+	funded, empty, multiSig, failKey, _ := testKaiaTxTypeBackend(t, mockBackend)
+
+	txType := func(t types.TxType) *types.TxType { return &t }
+	ratio := types.FeeRatio(30)
+	KAIA := hexutil.Big(*big.NewInt(params.KAIA))
+	allBalance := hexutil.Big(*big.NewInt(params.KAIA * 2))
+	gasPrice := (*hexutil.Big)(big.NewInt(25 * params.Gkei))
+
+	testcases := []struct {
+		name      string
+		args      CallArgs
+		expectErr string
+		expectGas uint64
+	}{
+		{
+			name:      "value transfer",
+			args:      CallArgs{TypeInt: txType(types.TxTypeValueTransfer), From: funded, To: &empty, Value: KAIA},
+			expectGas: params.TxGasValueTransfer,
+		},
+		{
+			name:      "fee-delegated value transfer paid by the fee payer",
+			args:      CallArgs{TypeInt: txType(types.TxTypeFeeDelegatedValueTransfer), From: empty, To: &funded, FeePayer: &funded},
+			expectGas: params.TxGasValueTransfer + params.TxGasFeeDelegated,
+		},
+		{
+			name:      "fee-delegated value transfer of the whole sender balance",
+			args:      CallArgs{TypeInt: txType(types.TxTypeFeeDelegatedValueTransfer), From: multiSig, To: &empty, Value: allBalance, GasPrice: gasPrice, FeePayer: &funded},
+			expectGas: params.TxGasValueTransfer + params.TxGasFeeDelegated + 2*params.TxValidationGasPerKey,
+		},
+		{
+			name:      "fee-delegated value transfer above the sender balance",
+			args:      CallArgs{TypeInt: txType(types.TxTypeFeeDelegatedValueTransfer), From: empty, To: &funded, Value: KAIA, GasPrice: gasPrice, FeePayer: &funded},
+			expectErr: "insufficient funds for transfer",
+		},
+		{
+			name:      "fee-delegated value transfer with ratio",
+			args:      CallArgs{TypeInt: txType(types.TxTypeFeeDelegatedValueTransferWithRatio), From: funded, To: &empty, FeePayer: &multiSig, FeeRatio: &ratio},
+			expectGas: params.TxGasValueTransfer + params.TxGasFeeDelegatedWithRatio + 2*params.TxValidationGasPerKey,
+		},
+		{
+			name:      "multisig sender is charged for all keys",
+			args:      CallArgs{TypeInt: txType(types.TxTypeValueTransfer), From: multiSig, To: &empty, Value: KAIA},
+			expectGas: params.TxGasValueTransfer + 2*params.TxValidationGasPerKey,
+		},
+		{
+			name:      "sender with a fail key",
+			args:      CallArgs{TypeInt: txType(types.TxTypeValueTransfer), From: failKey, To: &empty, Value: KAIA},
+			expectErr: "invalid account key",
+		},
+		{
+			name:      "fee payer with a fail key",
+			args:      CallArgs{TypeInt: txType(types.TxTypeFeeDelegatedValueTransfer), From: funded, To: &empty, FeePayer: &failKey},
+			expectErr: "invalid account key",
+		},
+		{
+			name:      "ethereum dynamic fee",
+			args:      CallArgs{TypeInt: txType(types.TxTypeEthereumDynamicFee), From: funded, To: &empty, Value: KAIA},
+			expectGas: params.TxGas,
+		},
+		{
+			name:      "ethereum tx type requires a legacy key",
+			args:      CallArgs{TypeInt: txType(types.TxTypeEthereumDynamicFee), From: multiSig, To: &empty},
+			expectErr: "a legacy transaction must be with a legacy account key",
+		},
+		{
+			name:      "missing fee payer",
+			args:      CallArgs{TypeInt: txType(types.TxTypeFeeDelegatedValueTransfer), From: funded, To: &empty},
+			expectErr: "feePayer",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gas, err := api.EstimateGas(context.Background(), tc.args, nil, nil)
+			if tc.expectErr != "" {
+				assert.ErrorContains(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectGas, uint64(gas))
+			}
+		})
+	}
+}
+
+func TestKaiaAPI_CreateAccessList(t *testing.T) {
+	mockCtrl, mockBackend, api := testInitForKaiaApi(t)
+	defer mockCtrl.Finish()
+	funded, empty, _, _, contract := testKaiaTxTypeBackend(t, mockBackend)
+
+	// The requests without typeInt are filled by the defaults of eth_createAccessList.
+	mockBackend.EXPECT().CurrentBlock().Return(types.NewBlockWithHeader(&types.Header{Number: common.Big0})).AnyTimes()
+	mockBackend.EXPECT().SuggestTipCap(gomock.Any()).Return(common.Big0, nil).AnyTimes()
+	mockBackend.EXPECT().SuggestPrice(gomock.Any()).Return(big.NewInt(25*params.Gkei), nil).AnyTimes()
+	mockBackend.EXPECT().GetPoolNonce(gomock.Any(), funded).Return(uint64(0)).AnyTimes()
+
+	txType := func(t types.TxType) *types.TxType { return &t }
+	expectedList := types.AccessList{{Address: contract, StorageKeys: []common.Hash{{}}}}
+
+	// The access list is applied to the legacy call, trading the cold SLOAD for the access list cost.
+	result, err := api.CreateAccessList(context.Background(), AccessListArgs{EthTransactionArgs: EthTransactionArgs{From: &funded, To: &contract}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expectedList, *result.Accesslist)
+	assert.Empty(t, result.Error)
+	assert.Nil(t, result.FeePayer)
+	withoutGas := params.TxGas + 3 + params.ColdSloadCostEIP2929 + 2
+	withGas := params.TxGas + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas + 3 + params.WarmStorageReadCostEIP2929 + 2
+	assert.Equal(t, withGas, uint64(result.GasUsed))
+	assert.Equal(t, withoutGas, uint64(result.GasUsedWithoutAccessList))
+	assert.Equal(t, int64(withGas)-int64(withoutGas), result.GasDelta.ToInt().Int64())
+
+	// eth_createAccessList shares the access list creation with the requests without typeInt.
+	ethResult, err := doCreateAccessList(context.Background(), mockBackend, EthTransactionArgs{From: &funded, To: &contract}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &accessListResult{Accesslist: &expectedList, GasUsed: result.GasUsed}, ethResult)
+
+	// Kaia tx types can't carry the access list, so the gas used is unchanged.
+	result, err = api.CreateAccessList(context.Background(), AccessListArgs{
+		EthTransactionArgs: EthTransactionArgs{From: &empty, To: &contract},
+		TypeInt:            txType(types.TxTypeFeeDelegatedSmartContractExecution),
+		FeePayer:           &funded,
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expectedList, *result.Accesslist)
+	assert.Equal(t, &funded, result.FeePayer)
+	assert.Equal(t, result.GasUsedWithoutAccessList, result.GasUsed)
+	assert.Equal(t, params.TxGasContractExecution+params.TxGasFeeDelegated+3+params.ColdSloadCostEIP2929+2, uint64(result.GasUsed))
+	assert.Zero(t, result.GasDelta.ToInt().Sign())
+
+	// The created contract reading its own balance is warm, as the pool nonce gives its address.
+	input := hexutil.Bytes(hexutil.MustDecode("0x30315000")) // ADDRESS BALANCE POP STOP
+	result, err = api.CreateAccessList(context.Background(), AccessListArgs{EthTransactionArgs: EthTransactionArgs{From: &funded, Input: &input}}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, *result.Accesslist)
+	assert.Empty(t, result.Error)
+
+	// The authorities of the set code transaction are warm, so they are not listed.
+	auth, err := types.SignAuth(&types.Authorization{ChainID: 1, Address: contract}, testAuthorityKey)
+	require.NoError(t, err)
+	args := AccessListArgs{
+		EthTransactionArgs: EthTransactionArgs{From: &funded, To: &testAuthorityReader},
+		TypeInt:            txType(types.TxTypeEthereumSetCode),
+		AuthorizationList:  &types.AuthorizationList{*auth},
+	}
+	result, err = api.CreateAccessList(context.Background(), args, nil)
+	require.NoError(t, err)
+	assert.Empty(t, *result.Accesslist)
+	assert.Empty(t, result.Error)
+
+	// Without the authorization, the authority is listed.
+	args.TypeInt, args.AuthorizationList = txType(types.TxTypeEthereumDynamicFee), nil
+	result, err = api.CreateAccessList(context.Background(), args, nil)
+	require.NoError(t, err)
+	authority := crypto.PubkeyToAddress(testAuthorityKey.PublicKey)
+	assert.Equal(t, types.AccessList{{Address: authority, StorageKeys: []common.Hash{}}}, *result.Accesslist)
+}
@@ -102,6 +102,7 @@ var (
 const (
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
+	stateDiffCacheLimit = 128
 	// TODO-Klaytn-Issue1911  This flag needs to be adjusted to the appropriate value.
 	//  Currently, this value is taken to cache all 10 million accounts
 	//  and should be optimized considering memory size and performance.
@@ -220,6 +221,9 @@ type BlockChain struct {
 	// kaiax modules
 	executionModules  []kaiax.ExecutionModule
 	rewindableModules []kaiax.RewindableModule
+
+	// State diffs of the recently written blocks, non-nil if the state diff is enabled
+	stateDiffs *lru.Cache
 }
 
 // prefetchTx is used to prefetch transactions, when fetcher works.
@@ -756,14 +760,23 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 
 // PrunableStateAt returns a new mutable state based on a particular point in time.
 // If live pruning is enabled on the databse, and num is nonzero, then trie will mark obsolete nodes for pruning.
+// If the state diff is enabled, the returned state records the state diff on commit.
 func (bc *BlockChain) PrunableStateAt(root common.Hash, num uint64) (*state.StateDB, error) {
+	var (
+		stateDB *state.StateDB
+		err     error
+	)
 	if bc.IsLivePruningRequired() {
-		return state.New(root, bc.stateCache, bc.snaps, &statedb.TrieOpts{
+		stateDB, err = state.New(root, bc.stateCache, bc.snaps, &statedb.TrieOpts{
 			PruningBlockNumber: num,
 		})
 	} else {
-		return bc.StateAt(root)
+		stateDB, err = bc.StateAt(root)
+	}
+	if err == nil && bc.stateDiffs != nil {
+		stateDB.EnableStateDiff()
 	}
+	return stateDB, err
 }
 
 // StateAtWithPersistent returns a new mutable state based on a particular point in time with persistent trie nodes.
@@ -1581,6 +1594,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		return status, err
 	}
 
+	if diff := stateDB.StateDiff(); diff != nil && bc.stateDiffs != nil {
+		bc.stateDiffs.Add(block.Hash(), diff)
+	}
+
 	// Publish the committed block to the redis cache of stateDB.
 	// The cache uses the block to distinguish the latest state.
 	if bc.cacheConfig.TrieNodeCacheConfig.RedisPublishBlockEnable {
@@ -2056,8 +2073,10 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		}
 
 		// Process block using the parent state as reference point.
+		bc.traceBlockStart(block, stateDB)
 		receipts, logs, usedGas, internalTxTraces, procStats, err := bc.processor.Process(block, stateDB, bc.vmConfig)
 		if err != nil {
+			bc.traceBlockEnd(err)
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return i, events, coalescedLogs, err
@@ -2065,6 +2084,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 
 		// Validate the state using the default validator
 		err = bc.validator.ValidateState(block, parent, stateDB, receipts, usedGas)
+		bc.traceBlockEnd(err)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
@@ -2758,6 +2778,39 @@ func (bc *BlockChain) SaveTrieNodeCacheToDisk() error {
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
 func (bc *BlockChain) ApplyTransaction(chainConfig *params.ChainConfig, author *common.Address, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, vmConfig *vm.Config) (*types.Receipt, *vm.InternalTxTrace, error) {
+	return applyTransactionWithChain(chainConfig, bc, author, statedb, header, tx, usedGas, vmConfig)
+}
+
+// applyTransactionWithChain is ApplyTransaction on the given chain context, which is not
+// necessarily backed by a database.
+func applyTransactionWithChain(chainConfig *params.ChainConfig, chain ChainContext, author *common.Address, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, vmConfig *vm.Config) (*types.Receipt, *vm.InternalTxTrace, error) {
+	receipt, err := executeTransaction(chainConfig, chain, author, statedb, header, tx, vmConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var internalTrace *vm.InternalTxTrace
+	if vmConfig.EnableInternalTxTracing {
+		internalTrace, err = GetInternalTxTrace(vmConfig.Tracer)
+		if err != nil {
+			logger.Error("failed to get tracing result from a transaction", "txHash", tx.Hash().String(), "err", err)
+		}
+	}
+	// Update the state with pending changes
+	statedb.Finalise(true, false)
+	*usedGas += receipt.GasUsed
+
+	// Set the receipt logs and create a bloom for filtering
+	receipt.Logs = statedb.GetLogs(tx.Hash())
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+	return receipt, internalTrace, err
+}
+
+// executeTransaction executes a transaction on the given state database without
+// finalising the state. It returns the receipt of the transaction whose logs and
+// bloom are not set yet, or an error if the transaction is invalid.
+func executeTransaction(chainConfig *params.ChainConfig, chain ChainContext, author *common.Address, statedb *state.StateDB, header *types.Header, tx *types.Transaction, vmConfig *vm.Config) (*types.Receipt, error) {
 	// TODO-Kaia We reject transactions with unexpected gasPrice and do not put the transaction into TxPool.
 	//         And we run transactions regardless of gasPrice if we push transactions in the TxPool.
 	/*
@@ -2771,15 +2824,15 @@ func (bc *BlockChain) ApplyTransaction(chainConfig *params.ChainConfig, author *
 
 	// validation for each transaction before execution
 	if err := tx.Validate(statedb, blockNumber); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	msg, err := tx.AsMessageWithAccountKeyPicker(types.MakeSigner(chainConfig, header.Number), statedb, blockNumber)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	// Create a new context to be used in the EVM environment
-	blockContext := NewEVMBlockContext(header, bc, author)
+	blockContext := NewEVMBlockContext(header, chain, author)
 	txContext := NewEVMTxContext(msg, header, chainConfig)
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
@@ -2787,28 +2840,50 @@ func (bc *BlockChain) ApplyTransaction(chainConfig *params.ChainConfig, author *
 	// Apply the transaction to the current state (included in the env)
 	result, err := ApplyMessage(vmenv, msg)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	var internalTrace *vm.InternalTxTrace
-	if vmConfig.EnableInternalTxTracing {
-		internalTrace, err = GetInternalTxTrace(vmConfig.Tracer)
-		if err != nil {
-			logger.Error("failed to get tracing result from a transaction", "txHash", tx.Hash().String(), "err", err)
-		}
+		return nil, err
 	}
-	// Update the state with pending changes
-	statedb.Finalise(true, false)
-	*usedGas += result.UsedGas
 
 	receipt := types.NewReceipt(result.VmExecutionStatus, tx.Hash(), result.UsedGas)
 	// if the transaction created a contract, store the creation address in the receipt.
 	msg.FillContractAddress(vmenv.Origin, receipt)
-	// Set the receipt logs and create a bloom for filtering
-	receipt.Logs = statedb.GetLogs(tx.Hash())
-	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	return receipt, nil
+}
 
-	return receipt, internalTrace, err
+// traceBlockStart attaches the live tracer to the state of the block and
+// notifies it of the start of the block processing.
+func (bc *BlockChain) traceBlockStart(block *types.Block, stateDB *state.StateDB) {
+	hooks := bc.vmConfig.LiveTracer
+	if hooks == nil {
+		return
+	}
+	stateDB.SetLogger(hooks)
+	if hooks.OnBlockStart != nil {
+		hooks.OnBlockStart(block)
+	}
+}
+
+// traceBlockEnd notifies the live tracer of the end of the block processing.
+func (bc *BlockChain) traceBlockEnd(err error) {
+	if hooks := bc.vmConfig.LiveTracer; hooks != nil && hooks.OnBlockEnd != nil {
+		hooks.OnBlockEnd(err)
+	}
+}
+
+// EnableStateDiff makes the blockchain record the state diffs of the blocks it writes.
+// It must be called before any block is inserted.
+func (bc *BlockChain) EnableStateDiff() {
+	bc.stateDiffs, _ = lru.New(stateDiffCacheLimit)
+}
+
+// StateDiff returns the state diff of a recently written block, or nil if it is not available.
+func (bc *BlockChain) StateDiff(hash common.Hash) *state.StateDiff {
+	if bc.stateDiffs == nil {
+		return nil
+	}
+	if diff, ok := bc.stateDiffs.Get(hash); ok {
+		return diff.(*state.StateDiff)
+	}
+	return nil
 }
 
 func (bc *BlockChain) RegisterExecutionModule(modules ...kaiax.ExecutionModule) {
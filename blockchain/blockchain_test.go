@@ -37,6 +37,7 @@ import (
 
 	"github.com/kaiachain/kaia/accounts/abi"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
@@ -2487,3 +2488,65 @@ func applyTransaction(chain *BlockChain, state *state.StateDB, tx *types.Transac
 	_, _, err := chain.ApplyTransaction(chain.Config(), &author, state, header, tx, &usedGas, &vm.Config{})
 	return err
 }
+
+// TestLiveTracer tests that the live tracer receives the blocks, transactions and
+// state changes of the imported blocks.
+func TestLiveTracer(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = common.HexToAddress("0x1000")
+		db      = database.NewMemoryDBManager()
+		// this code generates a log
+		code    = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr1: {Balance: big.NewInt(10000000000000)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.LatestSignerForChainID(gspec.Config.ChainID)
+
+		blocks, txs, logs []string
+		reasons           = make(map[tracing.BalanceChangeReason]int)
+		nonces            = make(map[common.Address]uint64)
+	)
+	hooks := &tracing.Hooks{
+		OnBlockStart: func(block *types.Block) { blocks = append(blocks, block.Number().String()) },
+		OnBlockEnd: func(err error) {
+			assert.NoError(t, err)
+			blocks = append(blocks, "end")
+		},
+		OnTxStart: func(tx *types.Transaction) { txs = append(txs, tx.Hash().Hex()) },
+		OnTxEnd: func(receipt *types.Receipt, err error) {
+			assert.NoError(t, err)
+			txs = append(txs, receipt.TxHash.Hex())
+		},
+		OnBalanceChange: func(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+			reasons[reason]++
+		},
+		OnNonceChange: func(addr common.Address, prev, new uint64) { nonces[addr] = new },
+		OnLog:         func(log *types.Log) { logs = append(logs, log.Address.Hex()) },
+	}
+
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, gxhash.NewFaker(), vm.Config{LiveTracer: hooks})
+	defer blockchain.Stop()
+
+	var sent []*types.Transaction
+	chain, _ := GenerateChain(params.TestChainConfig, genesis, gxhash.NewFaker(), db, 2, func(i int, gen *BlockGen) {
+		var tx *types.Transaction
+		if i == 0 {
+			tx, _ = types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(1000), params.TxGas, new(big.Int), nil), signer, key1)
+		} else {
+			tx, _ = types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), 1000000, new(big.Int), code), signer, key1)
+		}
+		gen.AddTx(tx)
+		sent = append(sent, tx)
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+
+	assert.Equal(t, []string{"1", "end", "2", "end"}, blocks)
+	assert.Equal(t, []string{sent[0].Hash().Hex(), sent[0].Hash().Hex(), sent[1].Hash().Hex(), sent[1].Hash().Hex()}, txs)
+	assert.Equal(t, uint64(2), nonces[addr1])
+	assert.Len(t, logs, 1)
+	assert.Equal(t, 2, reasons[tracing.BalanceChangeTransfer], "value transfer and contract creation")
+	assert.Equal(t, 2, reasons[tracing.BalanceIncreaseBlockReward])
+}
@@ -32,7 +32,9 @@ import (
 	"strings"
 
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/common/hexutil"
 	"github.com/kaiachain/kaia/common/math"
@@ -246,6 +248,9 @@ func SetupGenesisBlock(db database.DBManager, genesis *Genesis, networkId uint64
 	if err := newcfg.CheckConfigForkOrder(); err != nil {
 		return newcfg, common.Hash{}, err
 	}
+	if err := vm.ValidateCustomPrecompiles(newcfg); err != nil {
+		return newcfg, common.Hash{}, err
+	}
 	storedcfg := db.ReadChainConfig(stored)
 	if storedcfg == nil {
 		logger.Info("Found genesis block without chain config")
@@ -321,7 +326,7 @@ func (g *Genesis) ToBlock(baseStateRoot common.Hash, db database.DBManager) *typ
 		for key, value := range account.Storage {
 			stateDB.SetState(addr, key, value)
 		}
-		stateDB.AddBalance(addr, account.Balance)
+		stateDB.AddBalance(addr, account.Balance, tracing.BalanceIncreaseGenesisBalance)
 		stateDB.SetNonce(addr, account.Nonce)
 	}
 	root := stateDB.IntermediateRoot(false)
@@ -378,6 +383,9 @@ func (g *Genesis) Commit(baseStateRoot common.Hash, db database.DBManager) (*typ
 	if err := config.CheckConfigForkOrder(); err != nil {
 		return nil, err
 	}
+	if err := vm.ValidateCustomPrecompiles(config); err != nil {
+		return nil, err
+	}
 	db.WriteChainConfig(block.Hash(), config)
 	return block, nil
 }
@@ -0,0 +1,76 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/kaiachain/kaia/blockchain/stateless"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/storage/statedb"
+)
+
+// witnessDatabase wraps a Database and adds every trie node and contract code
+// read through it to the witness.
+type witnessDatabase struct {
+	Database
+	witness *stateless.Witness
+}
+
+// NewWitnessDatabase returns a Database recording the accessed state into the given witness.
+// The StateDB using it should be created without snapshots, so that every read goes through the tries.
+func NewWitnessDatabase(db Database, witness *stateless.Witness) Database {
+	return &witnessDatabase{Database: db, witness: witness}
+}
+
+func (db *witnessDatabase) recordNode(hash common.ExtHash) {
+	if enc, err := db.TrieDB().Node(hash); err == nil {
+		db.witness.AddNode(hash, enc)
+	}
+}
+
+func (db *witnessDatabase) trieOpts(opts *statedb.TrieOpts) *statedb.TrieOpts {
+	recording := statedb.TrieOpts{}
+	if opts != nil {
+		recording = *opts
+	}
+	recording.NodeRecorder = db.recordNode
+	return &recording
+}
+
+// OpenTrie opens the main account trie recording the resolved nodes.
+func (db *witnessDatabase) OpenTrie(root common.Hash, opts *statedb.TrieOpts) (Trie, error) {
+	return db.Database.OpenTrie(root, db.trieOpts(opts))
+}
+
+// OpenStorageTrie opens the storage trie of an account recording the resolved nodes.
+func (db *witnessDatabase) OpenStorageTrie(root common.ExtHash, opts *statedb.TrieOpts) (Trie, error) {
+	return db.Database.OpenStorageTrie(root, db.trieOpts(opts))
+}
+
+// ContractCode retrieves a particular contract's code and records it.
+func (db *witnessDatabase) ContractCode(codeHash common.Hash) ([]byte, error) {
+	code, err := db.Database.ContractCode(codeHash)
+	if err == nil {
+		db.witness.AddCode(code)
+	}
+	return code, err
+}
+
+// ContractCodeSize retrieves a particular contracts code's size and records the code.
+func (db *witnessDatabase) ContractCodeSize(codeHash common.Hash) (int, error) {
+	code, err := db.ContractCode(codeHash)
+	return len(code), err
+}
@@ -25,6 +25,7 @@ package state
 import (
 	"math/big"
 
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/common"
 )
 
@@ -164,7 +165,7 @@ func (ch createObjectChange) dirtied() *common.Address {
 
 func (ch resetObjectChange) revert(s *StateDB) {
 	s.setStateObject(ch.prev)
-	if !ch.prevdestruct && s.snap != nil {
+	if !ch.prevdestruct && s.trackingDiffs() {
 		delete(s.snapDestructs, ch.prev.addrHash)
 	}
 }
@@ -176,6 +177,9 @@ func (ch resetObjectChange) dirtied() *common.Address {
 func (ch selfDestructChange) revert(s *StateDB) {
 	obj := s.getStateObject(*ch.account)
 	if obj != nil {
+		if s.logger != nil && s.logger.OnBalanceChange != nil && obj.Balance().Cmp(ch.prevbalance) != 0 {
+			s.logger.OnBalanceChange(*ch.account, obj.Balance(), ch.prevbalance, tracing.BalanceChangeRevert)
+		}
 		obj.selfDestructed = ch.prev
 		obj.setBalance(ch.prevbalance)
 	}
@@ -195,7 +199,11 @@ func (ch touchChange) dirtied() *common.Address {
 }
 
 func (ch balanceChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setBalance(ch.prev)
+	obj := s.getStateObject(*ch.account)
+	if s.logger != nil && s.logger.OnBalanceChange != nil {
+		s.logger.OnBalanceChange(*ch.account, obj.Balance(), ch.prev, tracing.BalanceChangeRevert)
+	}
+	obj.setBalance(ch.prev)
 }
 
 func (ch balanceChange) dirtied() *common.Address {
@@ -203,7 +211,11 @@ func (ch balanceChange) dirtied() *common.Address {
 }
 
 func (ch nonceChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setNonce(ch.prev)
+	obj := s.getStateObject(*ch.account)
+	if s.logger != nil && s.logger.OnNonceChange != nil {
+		s.logger.OnNonceChange(*ch.account, obj.Nonce(), ch.prev)
+	}
+	obj.setNonce(ch.prev)
 }
 
 func (ch nonceChange) dirtied() *common.Address {
@@ -211,7 +223,11 @@ func (ch nonceChange) dirtied() *common.Address {
 }
 
 func (ch codeChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setCode(common.BytesToHash(ch.prevhash), ch.prevcode)
+	obj := s.getStateObject(*ch.account)
+	if s.logger != nil && s.logger.OnCodeChange != nil {
+		s.logger.OnCodeChange(*ch.account, common.BytesToHash(obj.CodeHash()), obj.Code(s.db), common.BytesToHash(ch.prevhash), ch.prevcode)
+	}
+	obj.setCode(common.BytesToHash(ch.prevhash), ch.prevcode)
 }
 
 func (ch codeChange) dirtied() *common.Address {
@@ -219,7 +235,11 @@ func (ch codeChange) dirtied() *common.Address {
 }
 
 func (ch storageChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setState(ch.key, ch.prevalue)
+	obj := s.getStateObject(*ch.account)
+	if s.logger != nil && s.logger.OnStorageChange != nil {
+		s.logger.OnStorageChange(*ch.account, ch.key, obj.GetState(s.db, ch.key), ch.prevalue)
+	}
+	obj.setState(ch.key, ch.prevalue)
 }
 
 func (ch storageChange) dirtied() *common.Address {
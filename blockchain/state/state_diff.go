@@ -0,0 +1,105 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+)
+
+// StateDiff is the set of state changes committed by StateDB.Commit. It holds the
+// same data the snapshot diff layer is updated with, keyed by addresses and storage
+// slots instead of their hashes.
+type StateDiff struct {
+	// Destructs are the accounts deleted in the block. An account that is destructed
+	// and then recreated is also in Accounts, with the storage written after recreation.
+	Destructs map[common.Address]struct{}
+	// Accounts are the serialized accounts (see account.AccountSerializer) updated in the block.
+	Accounts map[common.Address][]byte
+	// Storage are the RLP encoded storage values updated in the block. Nil means deletion.
+	Storage map[common.Address]map[common.Hash][]byte
+	// Codes are the contract codes deployed in the block, keyed by the code hashes.
+	Codes map[common.Hash][]byte
+}
+
+// EnableStateDiff makes the next Commit produce a StateDiff. It must be called
+// before any change is made to the state.
+func (s *StateDB) EnableStateDiff() {
+	s.recordStateDiff = true
+	if s.snapDestructs == nil {
+		s.snapDestructs = make(map[common.Hash]struct{})
+		s.snapAccounts = make(map[common.Hash][]byte)
+		s.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+}
+
+// StateDiff returns the state changes of the last Commit, or nil if
+// EnableStateDiff was not called or Commit has not succeeded yet.
+func (s *StateDB) StateDiff() *StateDiff {
+	return s.stateDiff
+}
+
+// trackingDiffs returns true if the state changes are collected til commit,
+// either for the snapshot tree or for the state diff.
+func (s *StateDB) trackingDiffs() bool {
+	return s.snapDestructs != nil
+}
+
+// makeStateDiff converts the collected snapshot data to a StateDiff. The hashed keys are
+// resolved with the state objects, which are kept for every account touched in the block.
+func (s *StateDB) makeStateDiff(codes map[common.Hash][]byte) *StateDiff {
+	addrs := make(map[common.Hash]common.Address, len(s.stateObjects))
+	for addr, obj := range s.stateObjects {
+		addrs[obj.addrHash] = addr
+	}
+
+	diff := &StateDiff{
+		Destructs: make(map[common.Address]struct{}, len(s.snapDestructs)),
+		Accounts:  make(map[common.Address][]byte, len(s.snapAccounts)),
+		Storage:   make(map[common.Address]map[common.Hash][]byte, len(s.snapStorage)),
+		Codes:     codes,
+	}
+	for addrHash := range s.snapDestructs {
+		if addr, ok := addrs[addrHash]; ok {
+			diff.Destructs[addr] = struct{}{}
+		}
+	}
+	for addrHash, data := range s.snapAccounts {
+		if addr, ok := addrs[addrHash]; ok {
+			diff.Accounts[addr] = data
+		}
+	}
+	for addrHash, slots := range s.snapStorage {
+		addr, ok := addrs[addrHash]
+		if !ok {
+			continue
+		}
+		// Every updated slot has been cached in originStorage by updateStorageTrie.
+		keys := make(map[common.Hash]common.Hash, len(slots))
+		for key := range s.stateObjects[addr].originStorage {
+			keys[crypto.Keccak256Hash(key[:])] = key
+		}
+		storage := make(map[common.Hash][]byte, len(slots))
+		for slotHash, value := range slots {
+			if key, ok := keys[slotHash]; ok {
+				storage[key] = value
+			}
+		}
+		diff.Storage[addr] = storage
+	}
+	return diff
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/tracing"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateDiff(t *testing.T) {
+	var (
+		addrA    = common.HexToAddress("0xa")
+		addrB    = common.HexToAddress("0xb")
+		contract = common.HexToAddress("0xc")
+		victim   = common.HexToAddress("0xd")
+		deployed = common.HexToAddress("0xe")
+		slot1    = common.HexToHash("0x1")
+		slot2    = common.HexToHash("0x2")
+		code     = []byte{0x60, 0x00}
+	)
+	db := NewDatabase(database.NewMemoryDBManager())
+	base, _ := New(common.Hash{}, db, nil, nil)
+	base.AddBalance(addrA, big.NewInt(100), tracing.BalanceChangeUnspecified)
+	base.SetCode(contract, []byte{0x1})
+	base.SetState(contract, slot1, common.HexToHash("0x1"))
+	base.SetState(contract, slot2, common.HexToHash("0x1"))
+	base.SetCode(victim, []byte{0x2})
+	root, err := base.Commit(true)
+	require.NoError(t, err)
+	assert.Nil(t, base.StateDiff())
+
+	s, _ := New(root, db, nil, nil)
+	s.EnableStateDiff()
+	s.SetState(contract, slot1, common.HexToHash("0x10"))
+	s.SetState(contract, slot2, common.Hash{})
+	s.Finalise(true, true)
+	s.SelfDestruct(victim)
+	s.Finalise(true, true)
+	s.SetCode(deployed, code)
+	s.AddBalance(addrB, big.NewInt(1), tracing.BalanceChangeUnspecified)
+	_, err = s.Commit(true)
+	require.NoError(t, err)
+
+	diff := s.StateDiff()
+	require.NotNil(t, diff)
+	assert.Equal(t, map[common.Address]struct{}{victim: {}}, diff.Destructs)
+	assert.Len(t, diff.Accounts, 3)
+	for _, addr := range []common.Address{contract, deployed, addrB} {
+		assert.Contains(t, diff.Accounts, addr)
+	}
+
+	enc, _ := rlp.EncodeToBytes([]byte{0x10})
+	assert.Equal(t, map[common.Address]map[common.Hash][]byte{
+		contract: {slot1: enc, slot2: nil},
+	}, diff.Storage)
+	assert.Equal(t, map[common.Hash][]byte{crypto.Keccak256Hash(code): code}, diff.Codes)
+}
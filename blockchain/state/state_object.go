@@ -31,6 +31,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types/account"
 	"github.com/kaiachain/kaia/blockchain/types/accountkey"
 	"github.com/kaiachain/kaia/common"
@@ -191,6 +192,9 @@ func (s *stateObject) getStorageTrie(db Database) Trie {
 
 // GetState retrieves a value from the account storage trie.
 func (s *stateObject) GetState(db Database, key common.Hash) common.Hash {
+	if s.db.reads != nil {
+		s.db.reads.addSlot(s.address, key)
+	}
 	// If we have a dirty value for this state entry, return it
 	value, dirty := s.dirtyStorage[key]
 	if dirty {
@@ -202,6 +206,9 @@ func (s *stateObject) GetState(db Database, key common.Hash) common.Hash {
 
 // GetCommittedState retrieves a value from the committed account storage trie.
 func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Hash {
+	if s.db.reads != nil {
+		s.db.reads.addSlot(s.address, key)
+	}
 	// If we have the original value cached, return that
 	value, cached := s.originStorage[key]
 	if cached {
@@ -280,6 +287,9 @@ func (s *stateObject) SetState(db Database, key, value common.Hash) {
 		key:      key,
 		prevalue: prev,
 	})
+	if s.db.logger != nil && s.db.logger.OnStorageChange != nil {
+		s.db.logger.OnStorageChange(s.address, key, prev, value)
+	}
 	s.setState(key, value)
 }
 
@@ -366,8 +376,8 @@ func (s *stateObject) updateStorageTrie(db Database) Trie {
 			v, _ = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
 			s.setError(tr.TryUpdate(key[:], v))
 		}
-		// If state snapshotting is active, cache the data til commit
-		if s.db.snap != nil {
+		// If state snapshotting or state diff is active, cache the data til commit
+		if s.db.trackingDiffs() {
 			if storage == nil {
 				// Retrieve the old storage map, if available, create a new one otherwise
 				if storage = s.db.snapStorage[s.addrHash]; storage == nil {
@@ -432,7 +442,7 @@ func (s *stateObject) CommitStorageTrie(db Database) error {
 
 // AddBalance adds amount to c's balance.
 // It is used to add funds to the destination account of a transfer.
-func (s *stateObject) AddBalance(amount *big.Int) {
+func (s *stateObject) AddBalance(amount *big.Int, reason tracing.BalanceChangeReason) {
 	// EIP158: We must check emptiness for the objects such that the account
 	// clearing (0,0,0 objects) can take effect.
 	if amount.Sign() == 0 {
@@ -442,23 +452,27 @@ func (s *stateObject) AddBalance(amount *big.Int) {
 
 		return
 	}
-	s.SetBalance(new(big.Int).Add(s.Balance(), amount))
+	s.SetBalance(new(big.Int).Add(s.Balance(), amount), reason)
 }
 
 // SubBalance removes amount from c's balance.
 // It is used to remove funds from the origin account of a transfer.
-func (s *stateObject) SubBalance(amount *big.Int) {
+func (s *stateObject) SubBalance(amount *big.Int, reason tracing.BalanceChangeReason) {
 	if amount.Sign() == 0 {
 		return
 	}
-	s.SetBalance(new(big.Int).Sub(s.Balance(), amount))
+	s.SetBalance(new(big.Int).Sub(s.Balance(), amount), reason)
 }
 
-func (s *stateObject) SetBalance(amount *big.Int) {
+func (s *stateObject) SetBalance(amount *big.Int, reason tracing.BalanceChangeReason) {
+	prev := new(big.Int).Set(s.account.GetBalance())
 	s.db.journal.append(balanceChange{
 		account: &s.address,
-		prev:    new(big.Int).Set(s.account.GetBalance()),
+		prev:    prev,
 	})
+	if s.db.logger != nil && s.db.logger.OnBalanceChange != nil {
+		s.db.logger.OnBalanceChange(s.address, prev, amount, reason)
+	}
 	s.setBalance(amount)
 }
 
@@ -527,12 +541,19 @@ func (s *stateObject) CodeSize(db Database) int {
 
 func (s *stateObject) SetCode(codeHash common.Hash, code []byte) error {
 	prevcode := s.Code(s.db.db)
+	prevhash := s.CodeHash()
 	s.db.journal.append(codeChange{
 		account:  &s.address,
-		prevhash: s.CodeHash(),
+		prevhash: prevhash,
 		prevcode: prevcode,
 	})
-	return s.setCode(codeHash, code)
+	if err := s.setCode(codeHash, code); err != nil {
+		return err
+	}
+	if s.db.logger != nil && s.db.logger.OnCodeChange != nil {
+		s.db.logger.OnCodeChange(s.address, common.BytesToHash(prevhash), prevcode, codeHash, code)
+	}
+	return nil
 }
 
 func (s *stateObject) setCode(codeHash common.Hash, code []byte) error {
@@ -554,14 +575,21 @@ func (s *stateObject) IncNonce() {
 		account: &s.address,
 		prev:    nonce,
 	})
+	if s.db.logger != nil && s.db.logger.OnNonceChange != nil {
+		s.db.logger.OnNonceChange(s.address, nonce, nonce+1)
+	}
 	s.setNonce(nonce + 1)
 }
 
 func (s *stateObject) SetNonce(nonce uint64) {
+	prev := s.account.GetNonce()
 	s.db.journal.append(nonceChange{
 		account: &s.address,
-		prev:    s.account.GetNonce(),
+		prev:    prev,
 	})
+	if s.db.logger != nil && s.db.logger.OnNonceChange != nil {
+		s.db.logger.OnNonceChange(s.address, prev, nonce)
+	}
 	s.setNonce(nonce)
 }
 
@@ -30,6 +30,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/types/account"
 	"github.com/kaiachain/kaia/blockchain/types/accountkey"
@@ -73,6 +74,11 @@ type StateDB struct {
 	snapAccounts  map[common.Hash][]byte
 	snapStorage   map[common.Hash]map[common.Hash][]byte
 
+	// If recordStateDiff is set, Commit derives stateDiff from the snap* maps above
+	// even if snapshotting is not active.
+	recordStateDiff bool
+	stateDiff       *StateDiff
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects             map[common.Address]*stateObject
 	stateObjectsDirty        map[common.Address]struct{}
@@ -109,6 +115,12 @@ type StateDB struct {
 
 	prefetching bool
 
+	// logger receives the state changes if live tracing is enabled.
+	logger *tracing.Hooks
+
+	// reads records the accounts and storage slots read, if it is not nil.
+	reads *accessSet
+
 	// Measurements gathered during execution for debugging purposes
 	AccountReads         time.Duration
 	AccountHashes        time.Duration
@@ -207,6 +219,10 @@ func (s *StateDB) AddLog(log *types.Log) {
 	log.Index = s.logSize
 	s.logs[s.thash] = append(s.logs[s.thash], log)
 	s.logSize++
+
+	if s.logger != nil && s.logger.OnLog != nil {
+		s.logger.OnLog(log)
+	}
 }
 
 func (s *StateDB) GetLogs(hash common.Hash) []*types.Log {
@@ -448,25 +464,25 @@ func (s *StateDB) HasSelfDestructed(addr common.Address) bool {
  */
 
 // AddBalance adds amount to the account associated with addr.
-func (s *StateDB) AddBalance(addr common.Address, amount *big.Int) {
+func (s *StateDB) AddBalance(addr common.Address, amount *big.Int, reason tracing.BalanceChangeReason) {
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
-		stateObject.AddBalance(amount)
+		stateObject.AddBalance(amount, reason)
 	}
 }
 
 // SubBalance subtracts amount from the account associated with addr.
-func (s *StateDB) SubBalance(addr common.Address, amount *big.Int) {
+func (s *StateDB) SubBalance(addr common.Address, amount *big.Int, reason tracing.BalanceChangeReason) {
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
-		stateObject.SubBalance(amount)
+		stateObject.SubBalance(amount, reason)
 	}
 }
 
-func (s *StateDB) SetBalance(addr common.Address, amount *big.Int) {
+func (s *StateDB) SetBalance(addr common.Address, amount *big.Int, reason tracing.BalanceChangeReason) {
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
-		stateObject.SetBalance(amount)
+		stateObject.SetBalance(amount, reason)
 	}
 }
 
@@ -550,13 +566,18 @@ func (s *StateDB) SelfDestruct(addr common.Address) {
 	if stateObject == nil {
 		return
 	}
+	prevBalance := new(big.Int).Set(stateObject.Balance())
 	s.journal.append(selfDestructChange{
 		account:     &addr,
 		prev:        stateObject.selfDestructed,
-		prevbalance: new(big.Int).Set(stateObject.Balance()),
+		prevbalance: prevBalance,
 	})
 	stateObject.markSelfdestructed()
 	stateObject.account.SetBalance(new(big.Int))
+
+	if s.logger != nil && s.logger.OnBalanceChange != nil && prevBalance.Sign() > 0 {
+		s.logger.OnBalanceChange(addr, prevBalance, new(big.Int), tracing.BalanceDecreaseSelfdestruct)
+	}
 }
 
 func (s *StateDB) SelfDestruct6780(addr common.Address) {
@@ -633,7 +654,7 @@ func (s *StateDB) updateStateObject(stateObject *stateObject) {
 	// update mechanism is not symmetric to the deletion, because whereas it is
 	// enough to track account updates at commit time, deletions need tracking
 	// at transaction boundary level to ensure we capture state clearing.
-	if s.snap != nil {
+	if s.trackingDiffs() {
 		s.snapAccounts[stateObject.addrHash] = snapshotData
 	}
 }
@@ -664,6 +685,9 @@ func (s *StateDB) getStateObject(addr common.Address) *stateObject {
 // flag set. This is needed by the state journal to revert to the correct s-
 // destructed object instead of wiping all knowledge about the state object.
 func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
+	if s.reads != nil {
+		s.reads.addAccount(addr)
+	}
 	// First, check stateObjects if there is "live" object.
 	if obj := s.stateObjects[addr]; obj != nil {
 		return obj
@@ -757,7 +781,7 @@ func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject)
 	prev = s.getDeletedStateObject(addr) // Note, prev might have been deleted, we need that!
 
 	var prevdestruct bool
-	if s.snap != nil && prev != nil {
+	if s.trackingDiffs() && prev != nil {
 		_, prevdestruct = s.snapDestructs[prev.addrHash]
 		if !prevdestruct {
 			s.snapDestructs[prev.addrHash] = struct{}{}
@@ -791,7 +815,7 @@ func (s *StateDB) createObjectWithMap(addr common.Address, accountType account.A
 	prev = s.getDeletedStateObject(addr) // Note, prev might have been deleted, we need that!
 
 	var prevdestruct bool
-	if s.snap != nil && prev != nil {
+	if s.trackingDiffs() && prev != nil {
 		_, prevdestruct = s.snapDestructs[prev.addrHash]
 		if !prevdestruct {
 			s.snapDestructs[prev.addrHash] = struct{}{}
@@ -952,6 +976,9 @@ func (s *StateDB) Copy() *StateDB {
 		// and force the miner to operate trie-backed only
 		state.snaps = s.snaps
 		state.snap = s.snap
+	}
+	state.recordStateDiff = s.recordStateDiff
+	if s.trackingDiffs() {
 		// deep copy needed
 		state.snapDestructs = make(map[common.Hash]struct{})
 		for k, v := range s.snapDestructs {
@@ -1030,13 +1057,17 @@ func (stateDB *StateDB) Finalise(deleteEmptyObjects bool, setStorageRoot bool) {
 		}
 
 		if so.selfDestructed || (deleteEmptyObjects && so.empty()) {
+			// The balance sent to a self-destructed account after its destruction is burnt.
+			if so.selfDestructed && stateDB.logger != nil && stateDB.logger.OnBalanceChange != nil && so.Balance().Sign() > 0 {
+				stateDB.logger.OnBalanceChange(so.address, so.Balance(), new(big.Int), tracing.BalanceDecreaseSelfdestructBurn)
+			}
 			stateDB.deleteStateObject(so)
 
 			// If state snapshotting is active, also mark the destruction there.
 			// Note, we can't do this only at the end of a block because multiple
 			// transactions within the same block might self destruct and then
 			// ressurrect an account; but the snapshotter needs both events.
-			if stateDB.snap != nil {
+			if stateDB.trackingDiffs() {
 				stateDB.snapDestructs[so.addrHash] = struct{}{} // We need to maintain account deletions explicitly (will remain set indefinitely)
 				delete(stateDB.snapAccounts, so.addrHash)       // Clear out any previously updated account data (may be recreated via a ressurrect)
 				delete(stateDB.snapStorage, so.addrHash)        // Clear out any previously updated storage data (may be recreated via a ressurrect)
@@ -1076,6 +1107,12 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 	return s.trie.Hash()
 }
 
+// SetLogger sets the live tracing hooks which receive the state changes.
+// The hooks are not inherited by copies of the state.
+func (s *StateDB) SetLogger(l *tracing.Hooks) {
+	s.logger = l
+}
+
 // SetTxContext sets the current transaction hash and index and block hash which is
 // used when the EVM emits new state logs.
 func (s *StateDB) SetTxContext(thash, bhash common.Hash, ti int) {
@@ -1102,6 +1139,11 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 		s.stateObjectsDirty[addr] = struct{}{}
 	}
 
+	var codes map[common.Hash][]byte
+	if s.recordStateDiff {
+		codes = make(map[common.Hash][]byte)
+	}
+
 	objectEncoder := getStateObjectEncoder(len(s.stateObjects))
 	var stateObjectsToUpdate []*stateObject
 	// Commit objects to the trie.
@@ -1116,6 +1158,9 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 			// Write any contract code associated with the state object.
 			if stateObject.code != nil && stateObject.dirtyCode {
 				s.db.TrieDB().DiskDB().WriteCode(common.BytesToHash(stateObject.CodeHash()), stateObject.code)
+				if s.recordStateDiff {
+					codes[common.BytesToHash(stateObject.CodeHash())] = stateObject.code
+				}
 				stateObject.dirtyCode = false
 			}
 			// Write any storage changes in the state object to its storage trie.
@@ -1152,6 +1197,10 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 		return nil
 	})
 
+	if s.recordStateDiff && err == nil {
+		s.stateDiff = s.makeStateDiff(codes)
+	}
+
 	// If snapshotting is enabled, update the snapshot tree with this new version
 	if s.snap != nil {
 		if EnabledExpensive {
@@ -1171,8 +1220,8 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 				logger.Warn("Failed to cap snapshot tree", "root", root, "layers", 128, "err", err)
 			}
 		}
-		s.snap, s.snapDestructs, s.snapAccounts, s.snapStorage = nil, nil, nil, nil
 	}
+	s.snap, s.snapDestructs, s.snapAccounts, s.snapStorage = nil, nil, nil, nil
 	return root, err
 }
 
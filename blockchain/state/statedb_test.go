@@ -34,6 +34,7 @@ import (
 	"testing"
 	"testing/quick"
 
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/params"
@@ -59,7 +60,7 @@ func TestUpdateLeaks(t *testing.T) {
 		if i%3 == 0 {
 			state.SetCode(addr, []byte{i, i, i, i, i})
 		}
-		state.AddBalance(addr, big.NewInt(int64(11*i)))
+		state.AddBalance(addr, big.NewInt(int64(11*i)), tracing.BalanceChangeUnspecified)
 		state.SetNonce(addr, uint64(42*i))
 		state.IntermediateRoot(false)
 	}
@@ -92,7 +93,7 @@ func TestIntermediateLeaks(t *testing.T) {
 		if i%3 == 0 {
 			state.SetCode(addr, []byte{i, i, i, i, i, tweak})
 		}
-		state.SetBalance(addr, big.NewInt(int64(11*i)+int64(tweak)))
+		state.SetBalance(addr, big.NewInt(int64(11*i)+int64(tweak)), tracing.BalanceChangeUnspecified)
 		state.SetNonce(addr, uint64(42*i+tweak))
 	}
 
@@ -139,7 +140,7 @@ func TestCopy(t *testing.T) {
 
 	for i := byte(0); i < 255; i++ {
 		obj := orig.GetOrNewStateObject(common.BytesToAddress([]byte{i}))
-		obj.AddBalance(big.NewInt(int64(i)))
+		obj.AddBalance(big.NewInt(int64(i)), tracing.BalanceChangeUnspecified)
 		orig.updateStateObject(obj)
 	}
 	orig.Finalise(false, true)
@@ -151,8 +152,8 @@ func TestCopy(t *testing.T) {
 		origObj := orig.GetOrNewStateObject(common.BytesToAddress([]byte{i}))
 		copyObj := copy.GetOrNewStateObject(common.BytesToAddress([]byte{i}))
 
-		origObj.AddBalance(big.NewInt(2 * int64(i)))
-		copyObj.AddBalance(big.NewInt(3 * int64(i)))
+		origObj.AddBalance(big.NewInt(2*int64(i)), tracing.BalanceChangeUnspecified)
+		copyObj.AddBalance(big.NewInt(3*int64(i)), tracing.BalanceChangeUnspecified)
 
 		orig.updateStateObject(origObj)
 		copy.updateStateObject(copyObj)
@@ -204,7 +205,7 @@ func TestStateObjects(t *testing.T) {
 		addr := common.BytesToAddress([]byte{i})
 		stateObj := stateDB.GetOrNewStateObject(addr)
 
-		stateObj.AddBalance(big.NewInt(int64(i)))
+		stateObj.AddBalance(big.NewInt(int64(i)), tracing.BalanceChangeUnspecified)
 		stateDB.updateStateObject(stateObj)
 	}
 
@@ -303,14 +304,14 @@ func newTestAction(addr common.Address, r *rand.Rand) testAction {
 		{
 			name: "SetBalance",
 			fn: func(a testAction, s *StateDB) {
-				s.SetBalance(addr, big.NewInt(a.args[0]))
+				s.SetBalance(addr, big.NewInt(a.args[0]), tracing.BalanceChangeUnspecified)
 			},
 			args: make([]int64, 1),
 		},
 		{
 			name: "AddBalance",
 			fn: func(a testAction, s *StateDB) {
-				s.AddBalance(addr, big.NewInt(a.args[0]))
+				s.AddBalance(addr, big.NewInt(a.args[0]), tracing.BalanceChangeUnspecified)
 			},
 			args: make([]int64, 1),
 		},
@@ -539,7 +540,7 @@ func (s *StateSuite) TestSnapshotWithJournalDirties(c *check.C) {
 	s.state.Reset(root)
 
 	snapshot := s.state.Snapshot()
-	s.state.AddBalance(common.Address{}, new(big.Int))
+	s.state.AddBalance(common.Address{}, new(big.Int), tracing.BalanceChangeUnspecified)
 
 	if len(s.state.journal.dirties) != 1 {
 		c.Fatal("expected one dirty state object")
@@ -555,7 +556,7 @@ func (s *StateSuite) TestSnapshotWithJournalDirties(c *check.C) {
 func TestCopyOfCopy(t *testing.T) {
 	sdb, _ := New(common.Hash{}, NewDatabase(database.NewMemoryDBManager()), nil, nil)
 	addr := common.HexToAddress("aaaa")
-	sdb.SetBalance(addr, big.NewInt(42))
+	sdb.SetBalance(addr, big.NewInt(42), tracing.BalanceChangeUnspecified)
 
 	if got := sdb.Copy().GetBalance(addr).Uint64(); got != 42 {
 		t.Fatalf("1st copy fail, expected 42, got %v", got)
@@ -592,10 +593,10 @@ func TestMissingTrieNodes(t *testing.T) {
 	state, _ := New(common.Hash{}, db, nil, nil)
 	addr := toAddr([]byte("so"))
 	{
-		state.SetBalance(addr, big.NewInt(1))
+		state.SetBalance(addr, big.NewInt(1), tracing.BalanceChangeUnspecified)
 		state.SetCode(addr, []byte{1, 2, 3})
 		a2 := toAddr([]byte("another"))
-		state.SetBalance(a2, big.NewInt(100))
+		state.SetBalance(a2, big.NewInt(100), tracing.BalanceChangeUnspecified)
 		state.SetCode(a2, []byte{1, 2, 4})
 		root, _ = state.Commit(false)
 		t.Logf("root: %x", root)
@@ -620,7 +621,7 @@ func TestMissingTrieNodes(t *testing.T) {
 		t.Errorf("expected %d, got %d", exp, got)
 	}
 	// Modify the state
-	state.SetBalance(addr, big.NewInt(2))
+	state.SetBalance(addr, big.NewInt(2), tracing.BalanceChangeUnspecified)
 	root, err := state.Commit(false)
 	if err == nil {
 		t.Fatalf("expected error, got root :%x", root)
@@ -834,3 +835,71 @@ func TestStateDBTransientStorage(t *testing.T) {
 		t.Fatalf("transient storage mismatch: have %x, want %x", got, value)
 	}
 }
+
+func TestStateDBHooks(t *testing.T) {
+	var events []string
+	hooks := &tracing.Hooks{
+		OnBalanceChange: func(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+			events = append(events, fmt.Sprintf("balance %x %v->%v %v", addr[19:], prev, new, reason))
+		},
+		OnNonceChange: func(addr common.Address, prev, new uint64) {
+			events = append(events, fmt.Sprintf("nonce %x %d->%d", addr[19:], prev, new))
+		},
+		OnCodeChange: func(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+			events = append(events, fmt.Sprintf("code %x %x->%x", addr[19:], prevCode, code))
+		},
+		OnStorageChange: func(addr common.Address, slot common.Hash, prev, new common.Hash) {
+			events = append(events, fmt.Sprintf("storage %x %x %x->%x", addr[19:], slot[31:], prev[31:], new[31:]))
+		},
+		OnLog: func(log *types.Log) {
+			events = append(events, fmt.Sprintf("log %x", log.Address[19:]))
+		},
+	}
+
+	var (
+		sdb, _   = New(common.Hash{}, NewDatabase(database.NewMemoryDBManager()), nil, nil)
+		eoa      = common.HexToAddress("0x01")
+		contract = common.HexToAddress("0x02")
+		slot     = common.BytesToHash([]byte{1})
+		rules    = params.TestChainConfig.Rules(common.Big0)
+	)
+	sdb.CreateSmartContractAccount(contract, params.CodeFormatEVM, rules)
+	sdb.SetLogger(hooks)
+
+	sdb.AddBalance(eoa, big.NewInt(100), tracing.BalanceChangeTransfer)
+	sdb.IncNonce(eoa)
+	sdb.SetCode(contract, []byte{0x60})
+	sdb.SetState(contract, slot, common.BytesToHash([]byte{1}))
+	sdb.AddLog(&types.Log{Address: contract})
+
+	// The reverted changes are reported again with the restored values.
+	snapshot := sdb.Snapshot()
+	sdb.SubBalance(eoa, big.NewInt(40), tracing.BalanceDecreaseGasBuy)
+	sdb.SetState(contract, slot, common.BytesToHash([]byte{2}))
+	sdb.RevertToSnapshot(snapshot)
+
+	// The copies of the state have no hooks.
+	sdb.Copy().AddBalance(eoa, big.NewInt(1), tracing.BalanceChangeTransfer)
+
+	// The balance sent to a self-destructed contract is burnt at the end of the transaction.
+	sdb.AddBalance(contract, big.NewInt(10), tracing.BalanceChangeTransfer)
+	sdb.SelfDestruct(contract)
+	sdb.AddBalance(contract, big.NewInt(5), tracing.BalanceChangeTransfer)
+	sdb.Finalise(true, false)
+
+	assert.Equal(t, []string{
+		"balance 01 0->100 Transfer",
+		"nonce 01 0->1",
+		"code 02 ->60",
+		"storage 02 01 00->01",
+		"log 02",
+		"balance 01 100->60 GasBuy",
+		"storage 02 01 01->02",
+		"storage 02 01 02->01",
+		"balance 01 60->100 Revert",
+		"balance 02 0->10 Transfer",
+		"balance 02 10->0 DecreaseSelfdestruct",
+		"balance 02 0->5 Transfer",
+		"balance 02 5->0 SelfdestructBurn",
+	}, events)
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+)
+
+// accessSet is a set of accounts and storage slots.
+type accessSet struct {
+	accounts map[common.Address]struct{}
+	storage  map[common.Address]map[common.Hash]struct{}
+}
+
+func newAccessSet() *accessSet {
+	return &accessSet{
+		accounts: make(map[common.Address]struct{}),
+		storage:  make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+func (a *accessSet) addAccount(addr common.Address) {
+	a.accounts[addr] = struct{}{}
+}
+
+func (a *accessSet) addSlot(addr common.Address, slot common.Hash) {
+	slots, ok := a.storage[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		a.storage[addr] = slots
+	}
+	slots[slot] = struct{}{}
+}
+
+// TxChanges holds the changes made by a transaction which is not finalised yet,
+// and the accounts and storage slots read by the transaction if the reads of the
+// state were recorded. It is used to execute transactions in parallel on copies
+// of a state and to merge the results into the state in the original order.
+type TxChanges struct {
+	reads *accessSet // nil if the reads were not recorded
+
+	accounts  map[common.Address]*stateObject // changed, created or deleted accounts
+	dirties   map[common.Address]struct{}     // accounts to be finalised
+	storage   map[common.Address]Storage      // changed slots of otherwise unchanged accounts
+	destructs map[common.Hash]struct{}        // snapshot destructs of the changed accounts
+	logs      []*types.Log
+	preimages map[common.Hash][]byte
+}
+
+// RecordReads starts recording the accounts and storage slots read from the state.
+// The reads are returned by TxChanges. It is not inherited by copies of the state.
+func (s *StateDB) RecordReads() {
+	s.reads = newAccessSet()
+}
+
+// TxChanges returns the changes made by the current transaction. It must be
+// called before the state is finalised, and the state must not be used afterwards
+// except when the changes are only used to detect conflicts.
+func (s *StateDB) TxChanges() *TxChanges {
+	c := &TxChanges{
+		reads:     s.reads,
+		accounts:  make(map[common.Address]*stateObject),
+		dirties:   make(map[common.Address]struct{}, len(s.journal.dirties)),
+		storage:   make(map[common.Address]Storage),
+		destructs: make(map[common.Hash]struct{}),
+		logs:      s.logs[s.thash],
+		preimages: make(map[common.Hash][]byte),
+	}
+	for _, entry := range s.journal.entries {
+		switch ch := entry.(type) {
+		case storageChange:
+			if _, ok := c.storage[*ch.account]; !ok {
+				c.storage[*ch.account] = make(Storage)
+			}
+		case resetObjectChange:
+			// The account is replaced without being marked as dirty.
+			c.accounts[ch.prev.address] = nil
+		case addPreimageChange:
+			c.preimages[ch.hash] = s.preimages[ch.hash]
+		default:
+			if addr := entry.dirtied(); addr != nil {
+				c.accounts[*addr] = nil
+			}
+		}
+	}
+	for addr := range s.journal.dirties {
+		c.dirties[addr] = struct{}{}
+		if _, ok := c.storage[addr]; !ok {
+			c.accounts[addr] = nil // dirtied without an entry, see Finalise
+		}
+	}
+	for addr := range c.accounts {
+		delete(c.storage, addr)
+		obj, exist := s.stateObjects[addr]
+		if !exist {
+			continue
+		}
+		c.accounts[addr] = obj
+		if s.trackingDiffs() {
+			if _, ok := s.snapDestructs[obj.addrHash]; ok {
+				c.destructs[obj.addrHash] = struct{}{}
+			}
+		}
+	}
+	for addr, slots := range c.storage {
+		for key, value := range s.stateObjects[addr].dirtyStorage {
+			slots[key] = value
+		}
+	}
+	return c
+}
+
+// ApplyTxChanges merges the changes of a transaction executed on a copy of the
+// state. The state must be finalised afterwards as if the transaction had been
+// executed on it. The caller must make sure that the changes do not conflict with
+// the changes made to the state since the copy was taken.
+func (s *StateDB) ApplyTxChanges(c *TxChanges) {
+	for addr, obj := range c.accounts {
+		if obj != nil {
+			s.setStateObject(obj.deepCopy(s))
+		}
+		if _, ok := c.dirties[addr]; ok {
+			s.journal.dirty(addr)
+		}
+	}
+	for addr, slots := range c.storage {
+		obj := s.getStateObject(addr)
+		if obj == nil {
+			continue
+		}
+		for key, value := range slots {
+			// Load the original value first as SetState does, to skip no-op changes.
+			obj.GetCommittedState(s.db, key)
+			obj.setState(key, value)
+		}
+		s.journal.dirty(addr)
+	}
+	if s.trackingDiffs() {
+		for addrHash := range c.destructs {
+			s.snapDestructs[addrHash] = struct{}{}
+		}
+	}
+	for _, log := range c.logs {
+		log.Index = s.logSize
+		s.logs[log.TxHash] = append(s.logs[log.TxHash], log)
+		s.logSize++
+	}
+	for hash, preimage := range c.preimages {
+		if _, ok := s.preimages[hash]; !ok {
+			s.preimages[hash] = preimage
+		}
+	}
+}
+
+// WriteSet is the set of accounts and storage slots changed by transactions.
+type WriteSet struct {
+	written *accessSet
+}
+
+// NewWriteSet returns an empty WriteSet.
+func NewWriteSet() *WriteSet {
+	return &WriteSet{written: newAccessSet()}
+}
+
+// Add adds the accounts and storage slots changed by a transaction.
+func (w *WriteSet) Add(c *TxChanges) {
+	for addr := range c.accounts {
+		w.written.addAccount(addr)
+	}
+	for addr, slots := range c.storage {
+		for key := range slots {
+			w.written.addSlot(addr, key)
+		}
+	}
+}
+
+// Conflicts reports whether the transaction of the given changes read a state
+// in the set, or replaced an account whose storage is in the set. If so, the
+// changes are stale and the transaction must be executed again. The changes
+// without recorded reads always conflict.
+func (w *WriteSet) Conflicts(c *TxChanges) bool {
+	if c.reads == nil {
+		return true
+	}
+	for addr := range c.reads.accounts {
+		if _, ok := w.written.accounts[addr]; ok {
+			return true
+		}
+	}
+	for addr, slots := range c.reads.storage {
+		if _, ok := w.written.accounts[addr]; ok {
+			return true
+		}
+		for key := range slots {
+			if _, ok := w.written.storage[addr][key]; ok {
+				return true
+			}
+		}
+	}
+	for addr := range c.accounts {
+		if _, ok := w.written.storage[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/tracing"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTxChanges tests that the changes of transactions executed on copies of a
+// state result in the same state as executing them in order, unless they conflict.
+func TestTxChanges(t *testing.T) {
+	var (
+		addrA    = common.HexToAddress("0xa")
+		addrB    = common.HexToAddress("0xb")
+		contract = common.HexToAddress("0xc")
+		slot1    = common.HexToHash("0x1")
+		slot2    = common.HexToHash("0x2")
+	)
+	base, _ := New(common.Hash{}, NewDatabase(database.NewMemoryDBManager()), nil, nil)
+	base.AddBalance(addrA, big.NewInt(100), tracing.BalanceChangeUnspecified)
+	base.SetCode(contract, []byte{0x1})
+	base.SetState(contract, slot1, common.HexToHash("0x1"))
+	base.SetState(contract, slot2, common.HexToHash("0x1"))
+	base.IntermediateRoot(true)
+
+	txs := []struct {
+		run      func(s *StateDB)
+		conflict bool
+	}{
+		{ // changes slot1 and emits a log
+			run: func(s *StateDB) {
+				s.SetState(contract, slot1, common.HexToHash("0x10"))
+				s.AddLog(&types.Log{Address: contract})
+			},
+		},
+		{ // changes slot2 only, transfers to a new account and emits a log
+			run: func(s *StateDB) {
+				s.SetState(contract, slot2, common.HexToHash("0x20"))
+				s.SubBalance(addrA, big.NewInt(10), tracing.BalanceChangeTransfer)
+				s.AddBalance(addrB, big.NewInt(10), tracing.BalanceChangeTransfer)
+				s.AddLog(&types.Log{Address: contract})
+			},
+		},
+		{ // reads slot1 changed by the first transaction
+			run: func(s *StateDB) {
+				s.SetNonce(addrA, s.GetState(contract, slot1).Big().Uint64())
+			},
+			conflict: true,
+		},
+		{ // replaces the account whose storage was changed
+			run: func(s *StateDB) {
+				s.AddBalance(contract, big.NewInt(1), tracing.BalanceChangeTransfer)
+			},
+			conflict: true,
+		},
+	}
+
+	sequential := base.Copy()
+	for i, tx := range txs {
+		sequential.SetTxContext(common.Hash{byte(i)}, common.Hash{}, i)
+		tx.run(sequential)
+		sequential.Finalise(true, false)
+	}
+
+	var (
+		parallel = base.Copy()
+		written  = NewWriteSet()
+	)
+	for i, tx := range txs {
+		spec := base.Copy()
+		spec.RecordReads()
+		spec.SetTxContext(common.Hash{byte(i)}, common.Hash{}, i)
+		tx.run(spec)
+		changes := spec.TxChanges()
+
+		parallel.SetTxContext(common.Hash{byte(i)}, common.Hash{}, i)
+		conflict := written.Conflicts(changes)
+		assert.Equal(t, tx.conflict, conflict, "tx %d", i)
+		if conflict {
+			tx.run(parallel)
+			changes = parallel.TxChanges()
+			assert.True(t, written.Conflicts(changes), "changes without reads always conflict")
+		} else {
+			parallel.ApplyTxChanges(changes)
+		}
+		written.Add(changes)
+		parallel.Finalise(true, false)
+	}
+
+	require.Equal(t, sequential.IntermediateRoot(true), parallel.IntermediateRoot(true))
+	assert.Equal(t, uint64(0x10), parallel.GetNonce(addrA))
+	for i := 0; i < 2; i++ {
+		logs := parallel.GetLogs(common.Hash{byte(i)})
+		require.Len(t, logs, 1)
+		assert.Equal(t, uint(i), logs[0].Index)
+		assert.Equal(t, uint(i), logs[0].TxIndex)
+	}
+}
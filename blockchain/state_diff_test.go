@@ -0,0 +1,113 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus/gxhash"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/kaiachain/kaia/storage/statedb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStateDiff tests that the blockchain records the state diffs of the inserted blocks,
+// regardless of the snapshot.
+func TestStateDiff(t *testing.T) {
+	for _, snapshot := range []bool{false, true} {
+		t.Run(fmt.Sprintf("snapshot=%v", snapshot), func(t *testing.T) {
+			testStateDiff(t, snapshot)
+		})
+	}
+}
+
+func testStateDiff(t *testing.T, snapshot bool) {
+	var (
+		key, _  = crypto.GenerateKey()
+		from    = crypto.PubkeyToAddress(key.PublicKey)
+		to      = common.HexToAddress("0x2000")
+		toggler = common.HexToAddress("0x1000")
+		// toggler flips the slot of the caller between 0 and 1.
+		togglerCode = []byte{byte(vm.CALLER), byte(vm.SLOAD), byte(vm.ISZERO), byte(vm.CALLER), byte(vm.SSTORE), byte(vm.STOP)}
+
+		config = params.TestChainConfig.Copy()
+		gspec  = &Genesis{Config: config, Alloc: GenesisAlloc{
+			from:    {Balance: new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.KAIA))},
+			toggler: {Code: togglerCode, Balance: common.Big0},
+		}}
+		db      = database.NewMemoryDBManager()
+		genesis = gspec.MustCommit(db)
+		signer  = types.LatestSignerForChainID(config.ChainID)
+		engine  = gxhash.NewFaker()
+	)
+	blocks, _ := GenerateChain(config, genesis, engine, db, 2, func(i int, gen *BlockGen) {
+		for _, recipient := range []common.Address{to, toggler} {
+			tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(from), recipient, big.NewInt(1), 100000, big.NewInt(1), nil), signer, key)
+			require.NoError(t, err)
+			gen.AddTx(tx)
+		}
+	})
+
+	cacheConfig := &CacheConfig{
+		CacheSize:           512,
+		BlockInterval:       DefaultBlockInterval,
+		TriesInMemory:       DefaultTriesInMemory,
+		TrieNodeCacheConfig: statedb.GetEmptyTrieNodeCacheConfig(),
+	}
+	if snapshot {
+		cacheConfig.SnapshotCacheSize = 512
+	}
+	chain, err := NewBlockChain(db, cacheConfig, config, engine, vm.Config{})
+	require.NoError(t, err)
+	defer chain.Stop()
+	assert.Nil(t, chain.StateDiff(genesis.Hash()))
+
+	chain.EnableStateDiff()
+	n, err := chain.InsertChain(blocks)
+	require.NoError(t, err, "block %d", n)
+
+	slot := common.BytesToHash(from.Bytes())
+	set, _ := rlp.EncodeToBytes([]byte{1})
+	for i, block := range blocks {
+		diff := chain.StateDiff(block.Hash())
+		require.NotNil(t, diff)
+		assert.Empty(t, diff.Destructs)
+		assert.Empty(t, diff.Codes)
+		// the test author address receives the block reward
+		assert.Len(t, diff.Accounts, 4)
+		for _, addr := range []common.Address{from, to, toggler, params.AuthorAddressForTesting} {
+			assert.Contains(t, diff.Accounts, addr)
+		}
+
+		// The slot is set in the first block and deleted in the second.
+		expected := set
+		if i == 1 {
+			expected = nil
+		}
+		assert.Len(t, diff.Storage, 1)
+		assert.Equal(t, expected, diff.Storage[toggler][slot])
+	}
+}
@@ -38,9 +38,9 @@ import (
 //
 // StateProcessor implements Processor.
 type StateProcessor struct {
-	config *params.ChainConfig // Chain configuration options
-	bc     *BlockChain         // Canonical block chain
-	engine consensus.Engine    // Consensus engine used for block rewards
+	config *params.ChainConfig   // Chain configuration options
+	bc     consensus.ChainReader // Canonical block chain
+	engine consensus.Engine      // Consensus engine used for block rewards
 }
 
 // ProcessStats includes the time statistics regarding StateProcessor.Process.
@@ -81,16 +81,31 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	author, _ := p.bc.Engine().Author(header) // Ignore error, we're past header validation
 
 	processStats.BeforeApplyTxs = time.Now()
-	// Iterate over and process the individual transactions
-	for i, tx := range block.Transactions() {
-		statedb.SetTxContext(tx.Hash(), block.Hash(), i)
-		receipt, internalTxTrace, err := p.bc.ApplyTransaction(p.config, &author, statedb, header, tx, usedGas, &cfg)
+	if canProcessParallel(block, cfg) {
+		var err error
+		receipts, allLogs, err = p.processParallel(block, statedb, cfg, &author, usedGas)
 		if err != nil {
 			return nil, nil, 0, nil, processStats, err
 		}
-		receipts = append(receipts, receipt)
-		allLogs = append(allLogs, receipt.Logs...)
-		internalTxTraces = append(internalTxTraces, internalTxTrace)
+		internalTxTraces = make([]*vm.InternalTxTrace, len(receipts))
+	} else {
+		// Iterate over and process the individual transactions
+		for i, tx := range block.Transactions() {
+			statedb.SetTxContext(tx.Hash(), block.Hash(), i)
+			if hooks := cfg.LiveTracer; hooks != nil && hooks.OnTxStart != nil {
+				hooks.OnTxStart(tx)
+			}
+			receipt, internalTxTrace, err := applyTransactionWithChain(p.config, p.bc, &author, statedb, header, tx, usedGas, &cfg)
+			if hooks := cfg.LiveTracer; hooks != nil && hooks.OnTxEnd != nil {
+				hooks.OnTxEnd(receipt, err)
+			}
+			if err != nil {
+				return nil, nil, 0, nil, processStats, err
+			}
+			receipts = append(receipts, receipt)
+			allLogs = append(allLogs, receipt.Logs...)
+			internalTxTraces = append(internalTxTraces, internalTxTrace)
+		}
 	}
 	processStats.AfterApplyTxs = time.Now()
 
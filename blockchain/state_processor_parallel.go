@@ -0,0 +1,155 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/rcrowley/go-metrics"
+)
+
+var (
+	parallelTxMeter          = metrics.NewRegisteredMeter("chain/parallel/txs", nil)
+	parallelTxReexecuteMeter = metrics.NewRegisteredMeter("chain/parallel/reexecutions", nil)
+)
+
+// speculation is the result of a transaction executed on a copy of the state
+// at the beginning of the block.
+type speculation struct {
+	receipt *types.Receipt
+	changes *state.TxChanges // nil if the result must not be used
+	err     error
+	done    chan struct{}
+}
+
+// canProcessParallel returns true if the transactions of the block can be
+// executed in parallel. Tracing requires the transactions to be executed in order.
+func canProcessParallel(block *types.Block, cfg vm.Config) bool {
+	return cfg.ParallelExecution && len(block.Transactions()) > 1 &&
+		cfg.Tracer == nil && !cfg.Debug && !cfg.EnableInternalTxTracing && cfg.LiveTracer == nil
+}
+
+// processParallel applies the transactions of the block to the state, producing
+// the same receipts and state as applying them one by one.
+//
+// Every transaction is executed speculatively on its own copy of the state at
+// the beginning of the block, recording the accounts and storage slots it reads.
+// The results are then validated and merged into the state in the original order.
+// A transaction which read a state changed by a preceding transaction of the block
+// is executed again on the state itself.
+func (p *StateProcessor) processParallel(block *types.Block, statedb *state.StateDB, cfg vm.Config, author *common.Address, usedGas *uint64) (types.Receipts, []*types.Log, error) {
+	var (
+		header   = block.Header()
+		txs      = block.Transactions()
+		base     = statedb.Copy()
+		specs    = make([]*speculation, len(txs))
+		receipts = make(types.Receipts, 0, len(txs))
+		allLogs  []*types.Log
+
+		next  int32
+		abort int32
+		wg    sync.WaitGroup
+	)
+	for i := range specs {
+		specs[i] = &speculation{done: make(chan struct{})}
+	}
+	workers := runtime.NumCPU()
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			vmConfig := cfg
+			for {
+				i := int(atomic.AddInt32(&next, 1) - 1)
+				if i >= len(txs) || atomic.LoadInt32(&abort) == 1 {
+					return
+				}
+				p.speculate(specs[i], block, header, author, i, base, &vmConfig)
+			}
+		}()
+	}
+	defer func() {
+		atomic.StoreInt32(&abort, 1)
+		wg.Wait()
+	}()
+
+	written := state.NewWriteSet()
+	for i, tx := range txs {
+		spec := specs[i]
+		<-spec.done
+
+		statedb.SetTxContext(tx.Hash(), block.Hash(), i)
+		receipt, changes, err := spec.receipt, spec.changes, spec.err
+		if changes == nil || written.Conflicts(changes) {
+			parallelTxReexecuteMeter.Mark(1)
+			receipt, err = executeTransaction(p.config, p.bc, author, statedb, header, tx, &cfg)
+			if err == nil {
+				changes = statedb.TxChanges()
+			}
+		} else if err == nil {
+			statedb.ApplyTxChanges(changes)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		written.Add(changes)
+
+		// Update the state with pending changes
+		statedb.Finalise(true, false)
+		*usedGas += receipt.GasUsed
+
+		receipt.Logs = statedb.GetLogs(tx.Hash())
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	parallelTxMeter.Mark(int64(len(txs)))
+
+	return receipts, allLogs, nil
+}
+
+// speculate executes the i-th transaction of the block on a copy of the given state.
+func (p *StateProcessor) speculate(spec *speculation, block *types.Block, header *types.Header, author *common.Address, i int, base *state.StateDB, cfg *vm.Config) {
+	defer close(spec.done)
+	defer func() {
+		// The transaction will be executed again on the state of the block.
+		if r := recover(); r != nil {
+			logger.Warn("Speculative transaction execution panicked", "txHash", block.Transactions()[i].Hash(), "err", fmt.Sprint(r))
+			spec.receipt, spec.changes, spec.err = nil, nil, nil
+		}
+	}()
+	tx := block.Transactions()[i]
+
+	statedb := base.Copy()
+	statedb.RecordReads()
+	statedb.SetTxContext(tx.Hash(), block.Hash(), i)
+
+	spec.receipt, spec.err = executeTransaction(p.config, p.bc, author, statedb, header, tx, cfg)
+	if statedb.Error() == nil {
+		spec.changes = statedb.TxChanges()
+	}
+}
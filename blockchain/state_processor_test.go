@@ -0,0 +1,156 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus/gxhash"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParallelProcessing tests that the blocks processed in parallel result in
+// the same state and receipts as the blocks processed one transaction by one.
+func TestParallelProcessing(t *testing.T) {
+	var (
+		keys  = make([]*ecdsa.PrivateKey, 16)
+		addrs = make([]common.Address, len(keys))
+		funds = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.KAIA))
+
+		// counter increments slot 0, which conflicts between the callers.
+		counter     = common.HexToAddress("0x1000")
+		counterCode = []byte{
+			byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD), byte(vm.PUSH1), 0, byte(vm.SSTORE), byte(vm.STOP),
+		}
+		// perCaller increments the slot of the caller and emits a log.
+		perCaller     = common.HexToAddress("0x1001")
+		perCallerCode = []byte{
+			byte(vm.CALLER), byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD), byte(vm.CALLER), byte(vm.SSTORE),
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG0), byte(vm.STOP),
+		}
+		// destructor self-destructs, sending its balance to the caller.
+		destructor     = common.HexToAddress("0x1002")
+		destructorCode = []byte{byte(vm.CALLER), byte(vm.SELFDESTRUCT)}
+	)
+	alloc := GenesisAlloc{
+		counter:    {Code: counterCode, Balance: common.Big0},
+		perCaller:  {Code: perCallerCode, Balance: common.Big0},
+		destructor: {Code: destructorCode, Balance: big.NewInt(params.KAIA)},
+	}
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = GenesisAccount{Balance: funds}
+	}
+	config := params.TestChainConfig.Copy()
+	config.SetDefaults()
+	config.IstanbulCompatibleBlock = common.Big0
+	config.LondonCompatibleBlock = common.Big0
+	config.EthTxTypeCompatibleBlock = common.Big0
+	config.MagmaCompatibleBlock = common.Big0
+	config.KoreCompatibleBlock = common.Big0
+	config.ShanghaiCompatibleBlock = common.Big0
+	config.CancunCompatibleBlock = common.Big0
+	config.KaiaCompatibleBlock = common.Big0
+	config.PragueCompatibleBlock = common.Big0
+	// The fee of every transaction is paid to the rewardbase unless it is deferred.
+	config.Governance.Reward.DeferredTxFee = true
+
+	var (
+		gspec    = &Genesis{Config: config, Alloc: alloc}
+		db       = database.NewMemoryDBManager()
+		genesis  = gspec.MustCommit(db)
+		signer   = types.LatestSignerForChainID(config.ChainID)
+		gasPrice = newGkei(750)
+	)
+	blocks, _ := GenerateChain(config, genesis, gxhash.NewFaker(), db, 3, func(i int, gen *BlockGen) {
+		send := func(key *ecdsa.PrivateKey, to *common.Address, value *big.Int, data []byte) {
+			from := crypto.PubkeyToAddress(key.PublicKey)
+			var tx *types.Transaction
+			if to == nil {
+				tx = types.NewContractCreation(gen.TxNonce(from), value, 200000, gasPrice, data)
+			} else {
+				tx = types.NewTransaction(gen.TxNonce(from), *to, value, 100000, gasPrice, data)
+			}
+			tx, err := types.SignTx(tx, signer, key)
+			require.NoError(t, err)
+			gen.AddTx(tx)
+		}
+		for j, key := range keys {
+			switch j % 4 {
+			case 0: // independent value transfer
+				to := common.BigToAddress(big.NewInt(int64(0x2000 + i*len(keys) + j)))
+				send(key, &to, big.NewInt(1000), nil)
+			case 1: // independent storage changes of the same contract
+				send(key, &perCaller, common.Big0, nil)
+			case 2: // conflicting storage changes
+				send(key, &counter, common.Big0, nil)
+			case 3: // value transfer to the sender of a following transaction
+				send(key, &addrs[(j+1)%len(keys)], big.NewInt(params.KAIA), nil)
+			}
+		}
+		// A second transaction of the same sender depends on the nonce of the first one.
+		send(keys[0], &perCaller, common.Big0, nil)
+		switch i {
+		case 1:
+			// Contract creation deploying the code of perCaller.
+			initCode := append([]byte{byte(vm.PUSH13)}, perCallerCode...)
+			initCode = append(initCode, byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 13, byte(vm.PUSH1), 19, byte(vm.RETURN))
+			send(keys[1], nil, common.Big0, initCode)
+		case 2:
+			send(keys[2], &destructor, common.Big0, nil)
+		}
+	})
+
+	newChain := func(cfg vm.Config) *BlockChain {
+		db := database.NewMemoryDBManager()
+		gspec.MustCommit(db)
+		chain, err := NewBlockChain(db, nil, config, gxhash.NewFaker(), cfg)
+		require.NoError(t, err)
+		t.Cleanup(chain.Stop)
+		n, err := chain.InsertChain(blocks)
+		require.NoError(t, err, "block %d", n)
+		return chain
+	}
+	sequential := newChain(vm.Config{})
+	parallel := newChain(vm.Config{ParallelExecution: true})
+
+	assert.Equal(t, blocks[len(blocks)-1].Root(), parallel.CurrentBlock().Root())
+	for _, block := range blocks {
+		want := sequential.GetReceiptsByBlockHash(block.Hash())
+		got := parallel.GetReceiptsByBlockHash(block.Hash())
+		require.Len(t, got, len(block.Transactions()))
+		assert.Equal(t, want, got)
+		for i := range got {
+			assert.Equal(t, want[i].Logs, got[i].Logs)
+		}
+	}
+	state, err := parallel.State()
+	require.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(3*4)), state.GetState(counter, common.Hash{}))
+	assert.Equal(t, common.BigToHash(big.NewInt(3)), state.GetState(perCaller, common.BytesToHash(addrs[0].Bytes())))
+	assert.Equal(t, common.BigToHash(big.NewInt(3)), state.GetState(perCaller, common.BytesToHash(addrs[1].Bytes())))
+}
@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
@@ -251,8 +252,8 @@ func (st *StateTransition) buyGas() error {
 			return errInsufficientBalanceForGas
 		}
 
-		st.state.SubBalance(validatedFeePayer, feePayerFee)
-		st.state.SubBalance(validatedSender, senderFee)
+		st.state.SubBalance(validatedFeePayer, feePayerFee, tracing.BalanceDecreaseGasBuy)
+		st.state.SubBalance(validatedSender, senderFee, tracing.BalanceDecreaseGasBuy)
 	} else {
 		// to make a short circuit, process the special case feeRatio == MaxFeeRatio
 		if st.state.GetBalance(validatedFeePayer).Cmp(mgval) < 0 {
@@ -262,7 +263,7 @@ func (st *StateTransition) buyGas() error {
 			return errInsufficientBalanceForGasFeePayer
 		}
 
-		st.state.SubBalance(validatedFeePayer, mgval)
+		st.state.SubBalance(validatedFeePayer, mgval, tracing.BalanceDecreaseGasBuy)
 	}
 
 	st.gas += st.msg.Gas()
@@ -419,9 +420,9 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	// Defer transferring Tx fee when DeferredTxFee is true
 	if st.evm.ChainConfig().Governance == nil || !st.evm.ChainConfig().Governance.DeferredTxFee() {
 		if rules.IsMagma {
-			st.state.AddBalance(st.evm.Context.Rewardbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+			st.state.AddBalance(st.evm.Context.Rewardbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice), tracing.BalanceIncreaseRewardTransactionFee)
 		} else {
-			st.state.AddBalance(st.evm.Context.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+			st.state.AddBalance(st.evm.Context.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice), tracing.BalanceIncreaseRewardTransactionFee)
 		}
 	}
 
@@ -514,11 +515,11 @@ func (st *StateTransition) refundGas(refundQuotient uint64) {
 	if isRatioTx {
 		feePayer, feeSender := types.CalcFeeWithRatio(feeRatio, remaining)
 
-		st.state.AddBalance(validatedFeePayer, feePayer)
-		st.state.AddBalance(validatedSender, feeSender)
+		st.state.AddBalance(validatedFeePayer, feePayer, tracing.BalanceIncreaseGasReturn)
+		st.state.AddBalance(validatedSender, feeSender, tracing.BalanceIncreaseGasReturn)
 	} else {
 		// To make a short circuit, the below routine processes when feeRatio == 100.
-		st.state.AddBalance(validatedFeePayer, remaining)
+		st.state.AddBalance(validatedFeePayer, remaining, tracing.BalanceIncreaseGasReturn)
 	}
 }
 
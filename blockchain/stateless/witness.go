@@ -0,0 +1,143 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package stateless implements the execution witness which allows a block to be
+// re-executed without a database.
+package stateless
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/kaiachain/kaia/storage/statedb"
+)
+
+var errNoParentHeader = errors.New("witness has no parent header")
+
+// Witness holds the trie nodes, contract codes and headers touched while
+// executing a block. Together with the parent state root, it is enough to
+// re-execute the block.
+type Witness struct {
+	// Headers holds the parent header first, followed by the ancestors
+	// accessed during the execution (e.g. by the BLOCKHASH opcode).
+	Headers []*types.Header `json:"headers"`
+	// Codes holds the contract codes read during the execution.
+	Codes []hexutil.Bytes `json:"codes"`
+	// State holds the encoded trie nodes read during the execution, keyed by
+	// the ExtHash they are stored with.
+	State map[common.ExtHash]hexutil.Bytes `json:"state"`
+
+	lock  sync.Mutex
+	codes map[common.Hash]struct{}
+}
+
+// NewWitness creates an empty witness for the block on top of the given parent.
+func NewWitness(parent *types.Header) *Witness {
+	return &Witness{
+		Headers: []*types.Header{parent},
+		Codes:   []hexutil.Bytes{},
+		State:   make(map[common.ExtHash]hexutil.Bytes),
+		codes:   make(map[common.Hash]struct{}),
+	}
+}
+
+// Parent returns the header of the parent block.
+func (w *Witness) Parent() *types.Header {
+	if len(w.Headers) == 0 {
+		return nil
+	}
+	return w.Headers[0]
+}
+
+// Root returns the parent state root the witness is built on.
+func (w *Witness) Root() common.Hash {
+	if parent := w.Parent(); parent != nil {
+		return parent.Root
+	}
+	return common.Hash{}
+}
+
+// AddNode adds an encoded trie node to the witness.
+func (w *Witness) AddNode(hash common.ExtHash, enc []byte) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, ok := w.State[hash]; !ok {
+		w.State[hash] = common.CopyBytes(enc)
+	}
+}
+
+// AddCode adds a contract code to the witness.
+func (w *Witness) AddCode(code []byte) {
+	if len(code) == 0 {
+		return
+	}
+	hash := crypto.Keccak256Hash(code)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.codes == nil {
+		w.codes = make(map[common.Hash]struct{})
+	}
+	if _, ok := w.codes[hash]; !ok {
+		w.codes[hash] = struct{}{}
+		w.Codes = append(w.Codes, common.CopyBytes(code))
+	}
+}
+
+// AddHeader adds an ancestor header to the witness.
+func (w *Witness) AddHeader(header *types.Header) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	hash := header.Hash()
+	for _, h := range w.Headers {
+		if h.Hash() == hash {
+			return
+		}
+	}
+	w.Headers = append(w.Headers, header)
+}
+
+// MakeDB verifies the trie nodes and contract codes of the witness against
+// their hashes and writes them to a new memory database.
+func (w *Witness) MakeDB() (database.DBManager, error) {
+	if w.Parent() == nil {
+		return nil, errNoParentHeader
+	}
+	db := database.NewMemoryDBManager()
+	for hash, enc := range w.State {
+		nodeHash, err := statedb.NodeHash(enc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trie node %x: %w", hash, err)
+		}
+		if nodeHash != hash.Unextend() {
+			return nil, fmt.Errorf("trie node hash mismatch (want %x, have %x)", hash.Unextend(), nodeHash)
+		}
+		db.WriteTrieNode(hash, enc)
+	}
+	for _, code := range w.Codes {
+		db.WriteCode(crypto.Keccak256Hash(code), code)
+	}
+	return db, nil
+}
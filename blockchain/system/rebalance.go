@@ -29,6 +29,7 @@ import (
 	"github.com/kaiachain/kaia/accounts/abi/bind/backends"
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
@@ -306,7 +307,7 @@ func RebalanceTreasury(state *state.StateDB, chain backends.BlockChainForCaller,
 
 	// Execution 1) Clear all balances of zeroeds
 	for addr := range result.Before.Zeroed {
-		state.SetBalance(addr, big.NewInt(0))
+		state.SetBalance(addr, big.NewInt(0), tracing.BalanceDecreaseRebalanceZeroed)
 		result.After.Zeroed[addr] = big.NewInt(0)
 	}
 	// Execution 2) Distribute KAIA to all allocateds
@@ -315,7 +316,7 @@ func RebalanceTreasury(state *state.StateDB, chain backends.BlockChainForCaller,
 		currentBalance := state.GetBalance(addr)
 		result.Burnt.Add(result.Burnt, currentBalance)
 
-		state.SetBalance(addr, balance)
+		state.SetBalance(addr, balance, tracing.BalanceChangeRebalanceAllocated)
 	}
 
 	// Fill the remaining fields of the result
@@ -0,0 +1,152 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracing defines the hooks through which a live tracer observes the
+// blocks processed by the chain and every state change made while processing them.
+//
+// Unlike vm.Tracer, which only sees call frames and opcodes, the hooks receive the
+// balance, nonce, code and storage changes and the logs emitted by the StateDB,
+// including the ones made outside of the EVM such as the gas purchase, the reward
+// distribution and the KIP-103/KIP-160 treasury rebalance.
+package tracing
+
+import (
+	"math/big"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+)
+
+type (
+	// BlockStartHook is called before a block is processed.
+	BlockStartHook func(block *types.Block)
+
+	// BlockEndHook is called after a block has been processed and validated.
+	// The error is not nil if the block is invalid.
+	BlockEndHook func(err error)
+
+	// TxStartHook is called before a transaction of the block is applied.
+	TxStartHook func(tx *types.Transaction)
+
+	// TxEndHook is called after a transaction of the block has been applied.
+	// The receipt is nil if the transaction could not be applied.
+	TxEndHook func(receipt *types.Receipt, err error)
+
+	// BalanceChangeHook is called when the balance of an account changes.
+	BalanceChangeHook func(addr common.Address, prev, new *big.Int, reason BalanceChangeReason)
+
+	// NonceChangeHook is called when the nonce of an account changes.
+	NonceChangeHook func(addr common.Address, prev, new uint64)
+
+	// CodeChangeHook is called when the code of an account changes.
+	CodeChangeHook func(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte)
+
+	// StorageChangeHook is called when a storage slot of an account changes.
+	StorageChangeHook func(addr common.Address, slot common.Hash, prev, new common.Hash)
+
+	// LogHook is called when a log is emitted.
+	LogHook func(log *types.Log)
+)
+
+// Hooks is the set of hooks of a live tracer. Any hook may be nil.
+//
+// The state hooks are called synchronously by the StateDB, so they must not
+// modify the given values nor block for long. A change undone by a reverted
+// call frame is reported again with the restored value, and the balance change
+// of the revert has the reason BalanceChangeRevert.
+type Hooks struct {
+	// Chain events
+	OnBlockStart BlockStartHook
+	OnBlockEnd   BlockEndHook
+	// Transaction events
+	OnTxStart TxStartHook
+	OnTxEnd   TxEndHook
+	// State events
+	OnBalanceChange BalanceChangeHook
+	OnNonceChange   NonceChangeHook
+	OnCodeChange    CodeChangeHook
+	OnStorageChange StorageChangeHook
+	OnLog           LogHook
+}
+
+// BalanceChangeReason is the reason of a balance change.
+type BalanceChangeReason byte
+
+const (
+	BalanceChangeUnspecified BalanceChangeReason = 0
+
+	// BalanceIncreaseGenesisBalance is the balance allocated in the genesis block.
+	BalanceIncreaseGenesisBalance BalanceChangeReason = 1
+	// BalanceIncreaseBlockReward is the block reward minted and the deferred
+	// transaction fees distributed at the end of a block.
+	BalanceIncreaseBlockReward BalanceChangeReason = 2
+	// BalanceIncreaseRewardTransactionFee is the transaction fee paid to the
+	// block proposer right after the transaction if the fee is not deferred.
+	BalanceIncreaseRewardTransactionFee BalanceChangeReason = 3
+
+	// BalanceChangeTransfer is the value transferred by a transaction or a call.
+	BalanceChangeTransfer BalanceChangeReason = 4
+
+	// BalanceDecreaseGasBuy is the gas purchased up front by the sender or the
+	// fee payer of a transaction. The part of the fee that is not refunded or
+	// rewarded afterwards is burnt.
+	BalanceDecreaseGasBuy BalanceChangeReason = 5
+	// BalanceIncreaseGasReturn is the unused gas refunded to the sender or the
+	// fee payer of a transaction.
+	BalanceIncreaseGasReturn BalanceChangeReason = 6
+
+	// BalanceIncreaseSelfdestruct is the balance a self-destructing contract sends
+	// to its beneficiary.
+	BalanceIncreaseSelfdestruct BalanceChangeReason = 7
+	// BalanceDecreaseSelfdestruct is the balance a self-destructing contract sends away.
+	BalanceDecreaseSelfdestruct BalanceChangeReason = 8
+	// BalanceDecreaseSelfdestructBurn is the balance left in a self-destructed
+	// contract, which is burnt.
+	BalanceDecreaseSelfdestructBurn BalanceChangeReason = 9
+
+	// BalanceDecreaseRebalanceZeroed is the balance of a retired treasury account
+	// cleared by the KIP-103/KIP-160 treasury rebalance.
+	BalanceDecreaseRebalanceZeroed BalanceChangeReason = 10
+	// BalanceChangeRebalanceAllocated is the balance of a new treasury account
+	// set by the KIP-103/KIP-160 treasury rebalance. Its previous balance is burnt.
+	BalanceChangeRebalanceAllocated BalanceChangeReason = 11
+
+	// BalanceChangeRevert is the balance restored by reverting a call frame.
+	BalanceChangeRevert BalanceChangeReason = 12
+)
+
+var balanceChangeReasonNames = map[BalanceChangeReason]string{
+	BalanceChangeUnspecified:            "Unspecified",
+	BalanceIncreaseGenesisBalance:       "GenesisBalance",
+	BalanceIncreaseBlockReward:          "BlockReward",
+	BalanceIncreaseRewardTransactionFee: "RewardTransactionFee",
+	BalanceChangeTransfer:               "Transfer",
+	BalanceDecreaseGasBuy:               "GasBuy",
+	BalanceIncreaseGasReturn:            "GasReturn",
+	BalanceIncreaseSelfdestruct:         "IncreaseSelfdestruct",
+	BalanceDecreaseSelfdestruct:         "DecreaseSelfdestruct",
+	BalanceDecreaseSelfdestructBurn:     "SelfdestructBurn",
+	BalanceDecreaseRebalanceZeroed:      "RebalanceZeroed",
+	BalanceChangeRebalanceAllocated:     "RebalanceAllocated",
+	BalanceChangeRevert:                 "Revert",
+}
+
+func (r BalanceChangeReason) String() string {
+	if name, ok := balanceChangeReasonNames[r]; ok {
+		return name
+	}
+	return "Unknown"
+}
@@ -493,3 +493,24 @@ func TestUnextendRLP(t *testing.T) {
 		assert.Equal(t, tc.unextended, hexutil.Encode(unextended))
 	}
 }
+
+// TestExternallyOwnedAccountDeepCopy tests that the code and storage of an EOA
+// with a delegation are kept by DeepCopy.
+func TestExternallyOwnedAccountDeepCopy(t *testing.T) {
+	eoa := &ExternallyOwnedAccount{
+		AccountCommon: &AccountCommon{
+			nonce:         0x1234,
+			balance:       big.NewInt(0x5678),
+			humanReadable: false,
+			key:           accountkey.NewAccountKeyLegacy(),
+		},
+		storageRoot: common.HexToExtHash("00112233445566778899aabbccddeeff00112233445566778899aabbccddeeffccccddddeeee01"),
+		codeHash:    common.HexToHash("aaaaaaaabbbbbbbbccccccccddddddddaaaaaaaabbbbbbbbccccccccdddddddd").Bytes(),
+		codeInfo:    params.CodeInfo(0x10),
+	}
+	cpy := eoa.DeepCopy().(*ExternallyOwnedAccount)
+	assert.Equal(t, eoa, cpy)
+
+	cpy.codeHash[0] = 0xff
+	assert.NotEqual(t, eoa.codeHash, cpy.codeHash)
+}
@@ -613,6 +613,19 @@ func (tx *Transaction) AsMessageWithAccountKeyPicker(s Signer, picker AccountKey
 	return tx, err
 }
 
+// AsCallMessage returns a copy of the transaction as a blockchain.Message whose sender and
+// fee payer are regarded as validated. It is used to execute unsigned transactions for calls,
+// so the given intrinsic gas is expected to include the signature validation gas.
+func (tx *Transaction) AsCallMessage(from, feePayer common.Address, intrinsicGas uint64) *Transaction {
+	return &Transaction{
+		data:                  tx.data,
+		time:                  tx.time,
+		validatedSender:       from,
+		validatedFeePayer:     feePayer,
+		validatedIntrinsicGas: intrinsicGas,
+	}
+}
+
 // WithSignature returns a new transaction with the given signature.
 // This signature needs to be formatted as described in the yellow paper (v+27).
 func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, error) {
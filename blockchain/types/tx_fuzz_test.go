@@ -0,0 +1,359 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/types/accountkey"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/fork"
+	"github.com/kaiachain/kaia/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The fuzz targets below only run their seed inputs in a normal test run. To fuzz, run e.g.
+//
+//	go test -run '^$' -fuzz '^FuzzTxDecode$' ./blockchain/types
+//
+// A failing input is written under testdata/fuzz/<target>. Commit it along with the fix
+// so that it is replayed as a seed input afterwards.
+
+// fuzzTxTypes returns every transaction type that can be created by newFuzzTxInternalData.
+func fuzzTxTypes() []TxType {
+	var txTypes []TxType
+	for i := TxTypeLegacyTransaction; i < TxTypeEthereumLast; i++ {
+		if i == TxTypeKaiaLast {
+			i = TxTypeEthereumAccessList
+		}
+		// TxTypeAccountCreation is not supported now
+		if i == TxTypeAccountCreation {
+			continue
+		}
+		if _, err := NewTxInternalData(i); err == nil {
+			txTypes = append(txTypes, i)
+		}
+	}
+	return txTypes
+}
+
+type fuzzTxArgs struct {
+	nonce    uint64
+	gasLimit uint64
+	price    *big.Int
+	amount   *big.Int
+	data     []byte
+	to       common.Address
+	from     common.Address
+	feePayer common.Address
+	feeRatio FeeRatio
+	chainID  *big.Int
+}
+
+func newFuzzTxArgs(nonce, gasLimit uint64, price, amount, data []byte, feeRatio uint8) *fuzzTxArgs {
+	// Keep the big integers within 256 bits as they are in the state.
+	if len(price) > 32 {
+		price = price[:32]
+	}
+	if len(amount) > 32 {
+		amount = amount[:32]
+	}
+	return &fuzzTxArgs{
+		nonce:    nonce,
+		gasLimit: gasLimit,
+		price:    new(big.Int).SetBytes(price),
+		amount:   new(big.Int).SetBytes(amount),
+		data:     data,
+		to:       common.HexToAddress("0x7b65B75d204aBed71587c9E519a89277766EE1d0"),
+		feeRatio: FeeRatio(feeRatio),
+		chainID:  big.NewInt(2),
+	}
+}
+
+// newFuzzTxInternalData creates a transaction of the given type whose fields are filled with args.
+func newFuzzTxInternalData(txType TxType, args *fuzzTxArgs) (TxInternalData, error) {
+	values := map[TxValueKeyType]interface{}{
+		TxValueKeyNonce:    args.nonce,
+		TxValueKeyGasLimit: args.gasLimit,
+	}
+	if txType == TxTypeEthereumDynamicFee || txType == TxTypeEthereumSetCode {
+		values[TxValueKeyGasFeeCap] = args.price
+		values[TxValueKeyGasTipCap] = args.price
+	} else {
+		values[TxValueKeyGasPrice] = args.price
+	}
+	if !txType.IsEthereumTransaction() {
+		values[TxValueKeyFrom] = args.from
+	}
+	if txType.IsFeeDelegatedTransaction() {
+		values[TxValueKeyFeePayer] = args.feePayer
+	}
+	if txType.IsFeeDelegatedWithRatioTransaction() {
+		values[TxValueKeyFeeRatioOfFeePayer] = args.feeRatio
+	}
+
+	switch basicType := txType &^ ((1 << SubTxTypeBits) - 1); {
+	case txType == TxTypeLegacyTransaction:
+		values[TxValueKeyTo] = args.to
+		values[TxValueKeyAmount] = args.amount
+		values[TxValueKeyData] = args.data
+	case txType == TxTypeEthereumAccessList || txType == TxTypeEthereumDynamicFee:
+		values[TxValueKeyTo] = &args.to
+		values[TxValueKeyAmount] = args.amount
+		values[TxValueKeyData] = args.data
+		values[TxValueKeyAccessList] = AccessList{{Address: args.to, StorageKeys: []common.Hash{{0}}}}
+		values[TxValueKeyChainID] = args.chainID
+	case txType == TxTypeEthereumSetCode:
+		values[TxValueKeyTo] = args.to
+		values[TxValueKeyAmount] = args.amount
+		values[TxValueKeyData] = args.data
+		values[TxValueKeyAccessList] = AccessList{{Address: args.to, StorageKeys: []common.Hash{{0}}}}
+		values[TxValueKeyAuthorizationList] = AuthorizationList{{ChainID: args.chainID.Uint64(), Address: args.to, Nonce: args.nonce, R: new(big.Int), S: new(big.Int)}}
+		values[TxValueKeyChainID] = args.chainID
+	case basicType == TxTypeValueTransfer:
+		values[TxValueKeyTo] = args.to
+		values[TxValueKeyAmount] = args.amount
+	case basicType == TxTypeValueTransferMemo, basicType == TxTypeSmartContractExecution:
+		values[TxValueKeyTo] = args.to
+		values[TxValueKeyAmount] = args.amount
+		values[TxValueKeyData] = args.data
+	case basicType == TxTypeSmartContractDeploy:
+		values[TxValueKeyTo] = (*common.Address)(nil)
+		values[TxValueKeyAmount] = args.amount
+		values[TxValueKeyData] = args.data
+		values[TxValueKeyHumanReadable] = false
+		values[TxValueKeyCodeFormat] = params.CodeFormatEVM
+	case basicType == TxTypeAccountUpdate:
+		values[TxValueKeyAccountKey] = accountkey.NewAccountKeyLegacy()
+	case basicType == TxTypeChainDataAnchoring:
+		values[TxValueKeyAnchoredData] = args.data
+	}
+	return NewTxInternalDataWithMap(txType, values)
+}
+
+// FuzzTxDecode tests that every decodable transaction is re-encoded canonically.
+func FuzzTxDecode(f *testing.F) {
+	senderKey, _ := defaultTestKey()
+	signer := LatestSignerForChainID(big.NewInt(2))
+	for _, txType := range fuzzTxTypes() {
+		args := newFuzzTxArgs(1234, 1000000, []byte{25}, []byte{10}, []byte("1234"), 30)
+		args.from = crypto.PubkeyToAddress(senderKey.PublicKey)
+		args.feePayer = args.from
+		data, err := newFuzzTxInternalData(txType, args)
+		require.NoError(f, err, txType)
+
+		tx := NewTx(data)
+		require.NoError(f, tx.Sign(signer, senderKey))
+		if txType.IsFeeDelegatedTransaction() {
+			require.NoError(f, tx.SignFeePayer(signer, senderKey))
+		}
+		b, err := tx.MarshalBinary()
+		require.NoError(f, err)
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		tx := new(Transaction)
+		if err := tx.UnmarshalBinary(b); err != nil {
+			return
+		}
+
+		enc, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		dec := new(Transaction)
+		require.NoError(t, dec.UnmarshalBinary(enc))
+		assert.True(t, tx.Equal(dec))
+		assert.Equal(t, tx.Type(), dec.Type())
+		assert.Equal(t, tx.Hash(), dec.Hash())
+
+		reenc, err := dec.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, enc, reenc)
+
+		// The JSON encoding must round-trip as well.
+		j, err := tx.MarshalJSON()
+		require.NoError(t, err)
+		dec = new(Transaction)
+		require.NoError(t, dec.UnmarshalJSON(j))
+		assert.True(t, tx.Equal(dec))
+	})
+}
+
+// FuzzTxSender tests that the sender and the fee payer are recovered from the signatures
+// of every transaction type, and only with the chain ID used for signing.
+func FuzzTxSender(f *testing.F) {
+	for i := range fuzzTxTypes() {
+		f.Add(uint8(i), uint64(1234), uint64(1000000), []byte{25}, []byte{10}, []byte("1234"), uint8(30), []byte("sender"), []byte("feePayer"), uint64(2))
+	}
+
+	txTypes := fuzzTxTypes()
+	f.Fuzz(func(t *testing.T, typeIdx uint8, nonce, gasLimit uint64, price, amount, data []byte, feeRatio uint8, senderSeed, feePayerSeed []byte, chainID uint64) {
+		senderKey, err := crypto.ToECDSA(crypto.Keccak256(senderSeed))
+		if err != nil {
+			return
+		}
+		feePayerKey, err := crypto.ToECDSA(crypto.Keccak256(feePayerSeed))
+		if err != nil {
+			return
+		}
+		// Limit the chain ID so that the EIP-155 V value of the legacy transaction fits in uint64.
+		chainID %= 1 << 32
+		if chainID == 0 {
+			return
+		}
+
+		txType := txTypes[int(typeIdx)%len(txTypes)]
+		args := newFuzzTxArgs(nonce, gasLimit, price, amount, data, feeRatio)
+		args.from = crypto.PubkeyToAddress(senderKey.PublicKey)
+		args.feePayer = crypto.PubkeyToAddress(feePayerKey.PublicKey)
+		args.chainID = new(big.Int).SetUint64(chainID)
+		txdata, err := newFuzzTxInternalData(txType, args)
+		if err != nil {
+			// Invalid field values such as the fee ratio out of [1,99] are rejected here.
+			return
+		}
+
+		signer := LatestSignerForChainID(args.chainID)
+		tx := NewTx(txdata)
+		require.NoError(t, tx.Sign(signer, senderKey))
+		if txType.IsFeeDelegatedTransaction() {
+			require.NoError(t, tx.SignFeePayer(signer, feePayerKey))
+		}
+
+		// Recover the signers from a decoded copy so that nothing is cached.
+		b, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		decode := func() *Transaction {
+			dec := new(Transaction)
+			require.NoError(t, dec.UnmarshalBinary(b))
+			return dec
+		}
+
+		checkSender(t, signer, decode(), args.from, true)
+		otherSigner := LatestSignerForChainID(new(big.Int).SetUint64(chainID + 1))
+		checkSender(t, otherSigner, decode(), args.from, false)
+
+		if txType.IsFeeDelegatedTransaction() {
+			pubkeys, err := SenderFeePayerPubkey(signer, decode())
+			require.NoError(t, err)
+			require.Len(t, pubkeys, 1)
+			assert.Equal(t, args.feePayer, crypto.PubkeyToAddress(*pubkeys[0]))
+
+			pubkeys, err = SenderFeePayerPubkey(otherSigner, decode())
+			if err == nil {
+				require.Len(t, pubkeys, 1)
+				assert.NotEqual(t, args.feePayer, crypto.PubkeyToAddress(*pubkeys[0]))
+			}
+		}
+	})
+}
+
+// checkSender checks whether the sender recovered by signer equals from.
+func checkSender(t *testing.T, signer Signer, tx *Transaction, from common.Address, equal bool) {
+	var recovered common.Address
+	if tx.IsEthereumTransaction() {
+		addr, err := Sender(signer, tx)
+		if err != nil {
+			require.False(t, equal, err)
+			return
+		}
+		recovered = addr
+	} else {
+		pubkeys, err := SenderPubkey(signer, tx)
+		if err != nil {
+			require.False(t, equal, err)
+			return
+		}
+		require.Len(t, pubkeys, 1)
+		recovered = crypto.PubkeyToAddress(*pubkeys[0])
+	}
+	if equal {
+		assert.Equal(t, from, recovered)
+	} else {
+		assert.NotEqual(t, from, recovered)
+	}
+}
+
+// FuzzIntrinsicGas compares IntrinsicGas against the gas schedule, and tests that the intrinsic
+// gas of every transaction type does not decrease with more data.
+func FuzzIntrinsicGas(f *testing.F) {
+	f.Add([]byte("1234"), false, true, true, false, uint8(1), uint8(1), uint8(0), uint8(0))
+	f.Add([]byte{0, 1, 0, 2}, true, true, true, true, uint8(2), uint8(3), uint8(1), uint8(0))
+	f.Add([]byte{}, true, false, false, false, uint8(0), uint8(0), uint8(0), uint8(0))
+
+	// The intrinsic gas of the legacy transaction depends on the hard forks.
+	require.NoError(f, fork.SetHardForkBlockNumberConfig(params.TestChainConfig))
+	defer fork.ClearHardForkBlockNumberConfig()
+
+	txTypes := fuzzTxTypes()
+	f.Fuzz(func(t *testing.T, data []byte, contractCreation, istanbul, shanghai, prague bool, numAddrs, numKeys, numAuths, typeIdx uint8) {
+		rules := params.Rules{IsIstanbul: istanbul, IsShanghai: shanghai, IsPrague: prague}
+		accessList := make(AccessList, numAddrs)
+		for i := range accessList {
+			accessList[i].StorageKeys = make([]common.Hash, numKeys)
+		}
+		authorizationList := make(AuthorizationList, numAuths)
+
+		var nz uint64
+		for _, b := range data {
+			if b != 0 {
+				nz++
+			}
+		}
+		z := uint64(len(data)) - nz
+
+		want := params.TxGas
+		if contractCreation {
+			want = params.TxGasContractCreation
+		}
+		switch {
+		case !istanbul:
+			want += nz*params.TxDataNonZeroGasFrontier + z*params.TxDataZeroGas
+		case prague:
+			want += nz*params.TxDataNonZeroGasEIP2028 + z*params.TxDataZeroGas
+		default:
+			want += uint64(len(data)) * params.TxDataGas
+		}
+		if istanbul && contractCreation && shanghai {
+			want += (uint64(len(data)) + 31) / 32 * params.InitCodeWordGas
+		}
+		want += uint64(numAddrs)*params.TxAccessListAddressGas + uint64(numAddrs)*uint64(numKeys)*params.TxAccessListStorageKeyGas
+		want += uint64(numAuths) * params.CallNewAccountGas
+
+		gas, err := IntrinsicGas(data, accessList, authorizationList, contractCreation, rules)
+		require.NoError(t, err)
+		assert.Equal(t, want, gas)
+
+		txType := txTypes[int(typeIdx)%len(txTypes)]
+		args := newFuzzTxArgs(0, 1000000, []byte{25}, nil, data, 30)
+		txdata, err := newFuzzTxInternalData(txType, args)
+		require.NoError(t, err)
+		gas, err = txdata.IntrinsicGas(0)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, gas, params.TxGas)
+
+		args.data = append(append([]byte{}, data...), 0xff)
+		txdata, err = newFuzzTxInternalData(txType, args)
+		require.NoError(t, err)
+		moreGas, err := txdata.IntrinsicGas(0)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, moreGas, gas)
+	})
+}
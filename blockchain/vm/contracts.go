@@ -30,6 +30,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strconv"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -210,11 +211,24 @@ func ActivePrecompiles(rules params.Rules) []common.Address {
 	// VmVersion0 contracts are deployed before istanbulCompatible and they use byzantiumCompatible precompiled contracts.
 	// VmVersion0 contracts are the contracts deployed before istanbulCompatible hf.
 	if rules.IsIstanbul {
-		return append(precompiledContractAddrs,
+		precompiledContractAddrs = append(precompiledContractAddrs,
 			[]common.Address{common.BytesToAddress([]byte{10}), common.BytesToAddress([]byte{11})}...)
-	} else {
-		return precompiledContractAddrs
 	}
+
+	// Custom precompiles of a service chain are appended to a fresh slice
+	// not to touch the backing arrays of the shared address lists.
+	if len(rules.CustomPrecompiles) > 0 {
+		custom := make([]common.Address, 0, len(rules.CustomPrecompiles))
+		for addr := range rules.CustomPrecompiles {
+			custom = append(custom, addr)
+		}
+		sort.Slice(custom, func(i, j int) bool {
+			return bytes.Compare(custom[i].Bytes(), custom[j].Bytes()) < 0
+		})
+		addrs := make([]common.Address, 0, len(precompiledContractAddrs)+len(custom))
+		return append(append(addrs, precompiledContractAddrs...), custom...)
+	}
+	return precompiledContractAddrs
 }
 
 // IsPrecompiledContractAddress returns true if this is used for TestExecutionSpecState and the input address is one of precompiled contract addresses.
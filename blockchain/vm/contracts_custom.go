@@ -0,0 +1,133 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/params"
+)
+
+var (
+	customPrecompilesMu sync.RWMutex
+	customPrecompiles   = make(map[string]PrecompiledContract)
+)
+
+// CustomPrecompile adapts plain functions to a PrecompiledContract so that
+// a service chain can register a precompile without declaring a new type.
+type CustomPrecompile struct {
+	RequiredGas     func(input []byte) uint64
+	ComputationCost func(input []byte) uint64
+	Execute         func(input []byte, contract *Contract, evm *EVM) ([]byte, error)
+}
+
+func (c *CustomPrecompile) GetRequiredGasAndComputationCost(input []byte) (uint64, uint64) {
+	return c.RequiredGas(input), c.ComputationCost(input)
+}
+
+func (c *CustomPrecompile) Run(input []byte, contract *Contract, evm *EVM) ([]byte, error) {
+	return c.Execute(input, contract, evm)
+}
+
+// RegisterPrecompile registers a custom precompiled contract under the given name.
+// The contract is enabled at the address and block configured by
+// params.ChainConfig.CustomPrecompiles. It is meant to be called from an init function
+// and panics if the name is empty or already registered.
+func RegisterPrecompile(name string, p PrecompiledContract) {
+	if name == "" || p == nil {
+		panic("vm: invalid custom precompile registration")
+	}
+	if c, ok := p.(*CustomPrecompile); ok && (c.RequiredGas == nil || c.ComputationCost == nil || c.Execute == nil) {
+		panic(fmt.Sprintf("vm: custom precompile %q has a nil function", name))
+	}
+
+	customPrecompilesMu.Lock()
+	defer customPrecompilesMu.Unlock()
+	if _, ok := customPrecompiles[name]; ok {
+		panic(fmt.Sprintf("vm: custom precompile %q is already registered", name))
+	}
+	customPrecompiles[name] = p
+}
+
+// ValidateCustomPrecompiles checks the custom precompiles of the chain config. In addition
+// to params.ChainConfig.CheckCustomPrecompiles, it checks that every configured precompile
+// is registered and does not overwrite a built-in precompiled contract.
+func ValidateCustomPrecompiles(config *params.ChainConfig) error {
+	if err := config.CheckCustomPrecompiles(); err != nil {
+		return err
+	}
+
+	customPrecompilesMu.RLock()
+	defer customPrecompilesMu.RUnlock()
+	for _, p := range config.CustomPrecompiles {
+		if _, ok := customPrecompiles[p.Name]; !ok {
+			return fmt.Errorf("custom precompile %q is not registered", p.Name)
+		}
+		if isBuiltinPrecompiledContract(p.Address) {
+			return fmt.Errorf("custom precompile %q: address %s is used by a built-in precompiled contract", p.Name, p.Address.Hex())
+		}
+	}
+	return nil
+}
+
+func isBuiltinPrecompiledContract(addr common.Address) bool {
+	for _, m := range []map[common.Address]PrecompiledContract{
+		PrecompiledContractsByzantium,
+		PrecompiledContractsIstanbul,
+		PrecompiledContractsKore,
+		PrecompiledContractsCancun,
+		PrecompiledContractsPrague,
+	} {
+		if _, ok := m[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// activeCustomPrecompiles returns the registered custom precompiled contracts enabled by the rules.
+// A configured precompile that is not registered is ignored; ValidateCustomPrecompiles rejects
+// such a config when the chain is set up.
+func activeCustomPrecompiles(rules params.Rules) map[common.Address]PrecompiledContract {
+	if len(rules.CustomPrecompiles) == 0 {
+		return nil
+	}
+
+	customPrecompilesMu.RLock()
+	defer customPrecompilesMu.RUnlock()
+	active := make(map[common.Address]PrecompiledContract, len(rules.CustomPrecompiles))
+	for addr, name := range rules.CustomPrecompiles {
+		if p, ok := customPrecompiles[name]; ok {
+			active[addr] = p
+		}
+	}
+	return active
+}
+
+// withCustomPrecompiles returns a copy of base extended with the custom precompiled contracts.
+func withCustomPrecompiles(base, custom map[common.Address]PrecompiledContract) map[common.Address]PrecompiledContract {
+	merged := make(map[common.Address]PrecompiledContract, len(base)+len(custom))
+	for addr, p := range base {
+		merged[addr] = p
+	}
+	for addr, p := range custom {
+		merged[addr] = p
+	}
+	return merged
+}
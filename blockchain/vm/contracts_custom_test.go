@@ -0,0 +1,102 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/kerrors"
+	"github.com/kaiachain/kaia/params"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testCustomPrecompileAddr = common.BytesToAddress([]byte{3, 0})
+	errTestCustomPrecompile  = errors.New("empty input")
+)
+
+func init() {
+	RegisterPrecompile("testKeccak", &CustomPrecompile{
+		RequiredGas:     func(input []byte) uint64 { return 100 + uint64(len(input)) },
+		ComputationCost: func(input []byte) uint64 { return 1000 },
+		Execute: func(input []byte, contract *Contract, evm *EVM) ([]byte, error) {
+			if len(input) == 0 {
+				return nil, errTestCustomPrecompile
+			}
+			return crypto.Keccak256(input), nil
+		},
+	})
+}
+
+func testCustomPrecompileConfig(chainID uint64, name string, addr common.Address) *params.ChainConfig {
+	return &params.ChainConfig{
+		ChainID:                 new(big.Int).SetUint64(chainID),
+		IstanbulCompatibleBlock: Block5,
+		CustomPrecompiles:       []*params.CustomPrecompileConfig{{Name: name, Address: addr, Block: Block5}},
+	}
+}
+
+func TestRegisterPrecompile(t *testing.T) {
+	assert.Panics(t, func() { RegisterPrecompile("testKeccak", &ecrecover{}) })
+	assert.Panics(t, func() { RegisterPrecompile("", &ecrecover{}) })
+	assert.Panics(t, func() { RegisterPrecompile("testNil", nil) })
+	assert.Panics(t, func() { RegisterPrecompile("testNilFunc", &CustomPrecompile{}) })
+}
+
+func TestValidateCustomPrecompiles(t *testing.T) {
+	chainID := params.ServiceChainDefaultNetworkId
+	assert.NoError(t, ValidateCustomPrecompiles(testCustomPrecompileConfig(chainID, "testKeccak", testCustomPrecompileAddr)))
+
+	// Not allowed on the mainnet and the testnet
+	assert.Error(t, ValidateCustomPrecompiles(testCustomPrecompileConfig(params.MainnetNetworkId, "testKeccak", testCustomPrecompileAddr)))
+	assert.Error(t, ValidateCustomPrecompiles(testCustomPrecompileConfig(params.KairosNetworkId, "testKeccak", testCustomPrecompileAddr)))
+	// Not registered
+	assert.Error(t, ValidateCustomPrecompiles(testCustomPrecompileConfig(chainID, "unknown", testCustomPrecompileAddr)))
+	// Built-in precompiled contract addresses
+	for _, addr := range []common.Address{common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{0x0b}), common.BytesToAddress([]byte{3, 255})} {
+		assert.Error(t, ValidateCustomPrecompiles(testCustomPrecompileConfig(chainID, "testKeccak", addr)))
+	}
+}
+
+func TestCustomPrecompile(t *testing.T) {
+	var (
+		addr   = testCustomPrecompileAddr.Hex()
+		input  = []byte("Hello")
+		output = common.Bytes2Hex(crypto.Keccak256(input))
+		config = testCustomPrecompileConfig(params.ServiceChainDefaultNetworkId, "testKeccak", testCustomPrecompileAddr)
+	)
+
+	runPrecompiledContractTestWithHFCondition(t, config, []TestData{
+		// Before activation, the address is reserved but not callable.
+		{addr, input, true, Block4, 0, "", kerrors.ErrPrecompiledContractAddress},
+		// After activation, the precompile is available to the contracts deployed before and after istanbul.
+		{addr, input, true, Block5, 105, output, nil},
+		{addr, input, false, Block5, 105, output, nil},
+		// A failed precompile consumes all the given gas.
+		{addr, nil, true, Block5, math.MaxUint64, "", errTestCustomPrecompile},
+	})
+
+	assert.NotContains(t, ActivePrecompiles(config.Rules(Block4)), testCustomPrecompileAddr)
+	active := ActivePrecompiles(config.Rules(Block5))
+	assert.Contains(t, active, testCustomPrecompileAddr)
+	assert.Equal(t, len(ActivePrecompiles(params.Rules{IsIstanbul: true}))+1, len(active))
+}
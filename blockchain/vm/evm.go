@@ -160,6 +160,12 @@ type EVM struct {
 
 	// opcodeComputationCostSum is the sum of computation cost of opcodes.
 	opcodeComputationCostSum uint64
+
+	// customPrecompiles holds the custom precompiled contracts enabled by chainRules.
+	// precompiles and precompilesV0 cache the built-in maps extended with them.
+	customPrecompiles map[common.Address]PrecompiledContract
+	precompiles       map[common.Address]PrecompiledContract
+	precompilesV0     map[common.Address]PrecompiledContract
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -173,6 +179,7 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(blockCtx.BlockNumber),
 	}
+	evm.customPrecompiles = activeCustomPrecompiles(evm.chainRules)
 
 	if vmConfig.RunningEVM != nil {
 		vmConfig.RunningEVM <- evm
@@ -637,21 +644,35 @@ func (evm *EVM) GetPrecompiledContractMap(addr common.Address) map[common.Addres
 	if vmVersion, ok := evm.StateDB.GetVmVersion(addr); ok && vmVersion == params.VmVersion0 {
 		// Without VmVersion0, precompiled contract address 0x09-0x0b won't work properly
 		// with the contracts deployed before istanbulHF
-		return PrecompiledContractsByzantium
+		if evm.customPrecompiles == nil {
+			return PrecompiledContractsByzantium
+		}
+		if evm.precompilesV0 == nil {
+			evm.precompilesV0 = withCustomPrecompiles(PrecompiledContractsByzantium, evm.customPrecompiles)
+		}
+		return evm.precompilesV0
 	}
 
+	var precompiles map[common.Address]PrecompiledContract
 	switch {
 	case evm.chainRules.IsPrague:
-		return PrecompiledContractsPrague
+		precompiles = PrecompiledContractsPrague
 	case evm.chainRules.IsCancun:
-		return PrecompiledContractsCancun
+		precompiles = PrecompiledContractsCancun
 	case evm.chainRules.IsKore:
-		return PrecompiledContractsKore
+		precompiles = PrecompiledContractsKore
 	case evm.chainRules.IsIstanbul:
-		return PrecompiledContractsIstanbul
+		precompiles = PrecompiledContractsIstanbul
 	default:
-		return PrecompiledContractsByzantium
+		precompiles = PrecompiledContractsByzantium
+	}
+	if evm.customPrecompiles == nil {
+		return precompiles
+	}
+	if evm.precompiles == nil {
+		evm.precompiles = withCustomPrecompiles(precompiles, evm.customPrecompiles)
 	}
+	return evm.precompiles
 }
 
 // ChainConfig returns the environment's chain configuration
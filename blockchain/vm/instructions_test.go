@@ -33,6 +33,7 @@ import (
 
 	"github.com/holiman/uint256"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/common/math"
@@ -391,7 +392,7 @@ func initStateDB(db database.DBManager) *state.StateDB {
 	statedb.SetCode(contractAddress, common.Hex2Bytes(code))
 	stateHash := common.HexToHash("7ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe")
 	statedb.SetState(contractAddress, stateHash, stateHash)
-	statedb.SetBalance(contractAddress, big.NewInt(1000))
+	statedb.SetBalance(contractAddress, big.NewInt(1000), tracing.BalanceChangeUnspecified)
 	statedb.SetNonce(contractAddress, uint64(1))
 
 	{
@@ -400,7 +401,7 @@ func initStateDB(db database.DBManager) *state.StateDB {
 		code := "00"
 		statedb.CreateSmartContractAccount(contractAddress, params.CodeFormatEVM, params.Rules{})
 		statedb.SetCode(contractAddress, common.Hex2Bytes(code))
-		statedb.SetBalance(contractAddress, big.NewInt(1000))
+		statedb.SetBalance(contractAddress, big.NewInt(1000), tracing.BalanceChangeUnspecified)
 		statedb.SetNonce(contractAddress, uint64(1))
 	}
 
@@ -28,6 +28,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/common/math"
 	"github.com/kaiachain/kaia/kerrors"
@@ -60,6 +61,12 @@ type Config struct {
 
 	// Additional EIPs that are to be enabled
 	ExtraEips []int
+
+	// LiveTracer receives the state changes of the blocks imported by the chain.
+	LiveTracer *tracing.Hooks
+
+	// ParallelExecution executes the transactions of a block optimistically in parallel.
+	ParallelExecution bool
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	tracing "github.com/kaiachain/kaia/blockchain/tracing"
 	types "github.com/kaiachain/kaia/blockchain/types"
 	account "github.com/kaiachain/kaia/blockchain/types/account"
 	accountkey "github.com/kaiachain/kaia/blockchain/types/accountkey"
@@ -52,15 +53,15 @@ func (mr *MockStateDBMockRecorder) AddAddressToAccessList(addr interface{}) *gom
 }
 
 // AddBalance mocks base method.
-func (m *MockStateDB) AddBalance(arg0 common.Address, arg1 *big.Int) {
+func (m *MockStateDB) AddBalance(arg0 common.Address, arg1 *big.Int, arg2 tracing.BalanceChangeReason) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "AddBalance", arg0, arg1)
+	m.ctrl.Call(m, "AddBalance", arg0, arg1, arg2)
 }
 
 // AddBalance indicates an expected call of AddBalance.
-func (mr *MockStateDBMockRecorder) AddBalance(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockStateDBMockRecorder) AddBalance(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBalance", reflect.TypeOf((*MockStateDB)(nil).AddBalance), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBalance", reflect.TypeOf((*MockStateDB)(nil).AddBalance), arg0, arg1, arg2)
 }
 
 // AddLog mocks base method.
@@ -646,15 +647,15 @@ func (mr *MockStateDBMockRecorder) Snapshot() *gomock.Call {
 }
 
 // SubBalance mocks base method.
-func (m *MockStateDB) SubBalance(arg0 common.Address, arg1 *big.Int) {
+func (m *MockStateDB) SubBalance(arg0 common.Address, arg1 *big.Int, arg2 tracing.BalanceChangeReason) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SubBalance", arg0, arg1)
+	m.ctrl.Call(m, "SubBalance", arg0, arg1, arg2)
 }
 
 // SubBalance indicates an expected call of SubBalance.
-func (mr *MockStateDBMockRecorder) SubBalance(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockStateDBMockRecorder) SubBalance(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubBalance", reflect.TypeOf((*MockStateDB)(nil).SubBalance), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubBalance", reflect.TypeOf((*MockStateDB)(nil).SubBalance), arg0, arg1, arg2)
 }
 
 // SubRefund mocks base method.
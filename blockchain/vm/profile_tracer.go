@@ -0,0 +1,365 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/kaiachain/kaia/common"
+)
+
+var _ Tracer = (*ProfileTracer)(nil)
+
+const (
+	// profileSelectorConstructor is the selector key used for contract creations.
+	profileSelectorConstructor = "constructor"
+	// profileSelectorFallback is the selector key used for calls without a 4-byte selector.
+	profileSelectorFallback = "fallback"
+	// profileStackSeparator separates the frames of a profiling stack.
+	profileStackSeparator = ";"
+)
+
+// ProfileStat is an aggregated cost of an opcode, a precompiled contract or a contract function.
+type ProfileStat struct {
+	Count           uint64 `json:"count"`
+	Gas             uint64 `json:"gas"`
+	ComputationCost uint64 `json:"computationCost"`
+	TimeNs          uint64 `json:"timeNs"`
+}
+
+func (s *ProfileStat) add(o *ProfileStat) {
+	s.Count += o.Count
+	s.Gas += o.Gas
+	s.ComputationCost += o.ComputationCost
+	s.TimeNs += o.TimeNs
+}
+
+// ProfileResult is the output of ProfileTracer.
+//   - Opcodes and Precompiles hold the cost spent by the opcode or the precompiled contract itself.
+//     The gas forwarded by the CALL family opcodes is attributed to the callee.
+//   - Contracts holds the inclusive cost of each call, keyed by the code address and
+//     the function selector ("constructor" for creations, "fallback" if no selector is given).
+//   - GasUsed is the sum of the gas used by the traced transactions including intrinsic gas.
+type ProfileResult struct {
+	Txs             uint64                                     `json:"txs"`
+	GasUsed         uint64                                     `json:"gasUsed"`
+	ComputationCost uint64                                     `json:"computationCost"`
+	TimeNs          uint64                                     `json:"timeNs"`
+	Opcodes         map[string]*ProfileStat                    `json:"opcodes"`
+	Precompiles     map[common.Address]*ProfileStat            `json:"precompiles"`
+	Contracts       map[common.Address]map[string]*ProfileStat `json:"contracts"`
+
+	// samples holds the self cost of every call stack, keyed by the frames joined by profileStackSeparator.
+	samples map[string]*ProfileStat
+}
+
+// NewProfileResult returns an empty ProfileResult.
+func NewProfileResult() *ProfileResult {
+	return &ProfileResult{
+		Opcodes:     make(map[string]*ProfileStat),
+		Precompiles: make(map[common.Address]*ProfileStat),
+		Contracts:   make(map[common.Address]map[string]*ProfileStat),
+		samples:     make(map[string]*ProfileStat),
+	}
+}
+
+func profileStatOf[K comparable](m map[K]*ProfileStat, key K) *ProfileStat {
+	s, ok := m[key]
+	if !ok {
+		s = new(ProfileStat)
+		m[key] = s
+	}
+	return s
+}
+
+func (r *ProfileResult) contractStat(addr common.Address, selector string) *ProfileStat {
+	fns, ok := r.Contracts[addr]
+	if !ok {
+		fns = make(map[string]*ProfileStat)
+		r.Contracts[addr] = fns
+	}
+	return profileStatOf(fns, selector)
+}
+
+// Merge accumulates the given result into r. It is used to build a block-wide profile.
+func (r *ProfileResult) Merge(o *ProfileResult) {
+	r.Txs += o.Txs
+	r.GasUsed += o.GasUsed
+	r.ComputationCost += o.ComputationCost
+	r.TimeNs += o.TimeNs
+	for op, s := range o.Opcodes {
+		profileStatOf(r.Opcodes, op).add(s)
+	}
+	for addr, s := range o.Precompiles {
+		profileStatOf(r.Precompiles, addr).add(s)
+	}
+	for addr, fns := range o.Contracts {
+		for selector, s := range fns {
+			r.contractStat(addr, selector).add(s)
+		}
+	}
+	for stack, s := range o.samples {
+		profileStatOf(r.samples, stack).add(s)
+	}
+}
+
+// Pprof converts the result into a pprof profile. Every sample carries the self gas,
+// computation cost and wall time of an opcode or a precompiled contract along with
+// the call stack of "address:selector" frames leading to it.
+func (r *ProfileResult) Pprof() (*profile.Profile, error) {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "gas", Unit: "count"},
+			{Type: "computation_cost", Unit: "count"},
+			{Type: "time", Unit: "nanoseconds"},
+		},
+		DefaultSampleType: "gas",
+		TimeNanos:         time.Now().UnixNano(),
+		DurationNanos:     int64(r.TimeNs),
+	}
+	locations := make(map[string]*profile.Location)
+	location := func(name string) *profile.Location {
+		if loc, ok := locations[name]; ok {
+			return loc
+		}
+		fn := &profile.Function{ID: uint64(len(p.Function) + 1), Name: name, SystemName: name}
+		loc := &profile.Location{ID: uint64(len(p.Location) + 1), Line: []profile.Line{{Function: fn}}}
+		p.Function = append(p.Function, fn)
+		p.Location = append(p.Location, loc)
+		locations[name] = loc
+		return loc
+	}
+
+	stacks := make([]string, 0, len(r.samples))
+	for stack := range r.samples {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		frames := strings.Split(stack, profileStackSeparator)
+		sample := &profile.Sample{Location: make([]*profile.Location, len(frames))}
+		for i, frame := range frames {
+			// pprof lists the leaf location first
+			sample.Location[len(frames)-1-i] = location(frame)
+		}
+		s := r.samples[stack]
+		sample.Value = []int64{int64(s.Gas), int64(s.ComputationCost), int64(s.TimeNs)}
+		p.Sample = append(p.Sample, sample)
+	}
+	return p, p.CheckValid()
+}
+
+// WritePprof writes the result as a gzipped pprof profile.
+func (r *ProfileResult) WritePprof(w io.Writer) error {
+	p, err := r.Pprof()
+	if err != nil {
+		return err
+	}
+	return p.Write(w)
+}
+
+// profileFrame is a call frame being profiled.
+type profileFrame struct {
+	stack   string       // frames from the top-level call, joined by profileStackSeparator
+	stat    *ProfileStat // inclusive cost of the call; nil if the callee is not a contract
+	pre     *ProfileStat // cost of the precompiled contract; nil if the callee is not a precompiled contract
+	start   time.Time
+	ccStart uint64
+
+	// lastOp and lastSample are the stats of the opcode executed last in this frame.
+	// The wall time until the next event is attributed to them.
+	lastOp, lastSample *ProfileStat
+}
+
+// ProfileTracer aggregates gas, computation cost and wall time per opcode,
+// per precompiled contract and per contract function.
+// Implements vm.Tracer interface
+type ProfileTracer struct {
+	env       *EVM
+	result    *ProfileResult
+	callstack []*profileFrame
+	gasLimit  uint64
+	txStart   time.Time
+	lastTick  time.Time
+
+	interrupt       atomic.Bool
+	interruptReason error
+}
+
+func NewProfileTracer() *ProfileTracer {
+	return &ProfileTracer{result: NewProfileResult()}
+}
+
+// tick attributes the wall time elapsed since the last event to the opcode executed last.
+func (t *ProfileTracer) tick(now time.Time) {
+	if size := len(t.callstack); size > 0 {
+		frame := t.callstack[size-1]
+		if frame.lastOp != nil {
+			elapsed := uint64(now.Sub(t.lastTick).Nanoseconds())
+			frame.lastOp.TimeNs += elapsed
+			frame.lastSample.TimeNs += elapsed
+		}
+	}
+	t.lastTick = now
+}
+
+func (t *ProfileTracer) enter(typ OpCode, from, to common.Address, input []byte) {
+	now := time.Now()
+	t.tick(now)
+
+	frame := &profileFrame{start: now, ccStart: t.env.GetOpCodeComputationCost()}
+	name := to.Hex()
+	if _, ok := t.env.GetPrecompiledContractMap(from)[to]; ok {
+		frame.pre = profileStatOf(t.result.Precompiles, to)
+		frame.pre.Count++
+	} else {
+		selector := profileSelectorFallback
+		if typ == CREATE || typ == CREATE2 {
+			selector = profileSelectorConstructor
+		} else if len(input) >= 4 {
+			selector = fmt.Sprintf("%#x", input[:4])
+		}
+		name += ":" + selector
+		if selector == profileSelectorConstructor || t.env.StateDB.IsProgramAccount(to) {
+			frame.stat = t.result.contractStat(to, selector)
+			frame.stat.Count++
+		}
+	}
+	frame.stack = name
+	if size := len(t.callstack); size > 0 {
+		frame.stack = t.callstack[size-1].stack + profileStackSeparator + name
+	}
+	t.callstack = append(t.callstack, frame)
+}
+
+func (t *ProfileTracer) exit(gasUsed uint64) {
+	size := len(t.callstack)
+	if size == 0 {
+		return
+	}
+	now := time.Now()
+	t.tick(now)
+
+	frame := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+
+	cost := ProfileStat{
+		Gas:             gasUsed,
+		ComputationCost: t.env.GetOpCodeComputationCost() - frame.ccStart,
+		TimeNs:          uint64(now.Sub(frame.start).Nanoseconds()),
+	}
+	if frame.stat != nil {
+		frame.stat.add(&cost)
+	}
+	if frame.pre != nil {
+		frame.pre.add(&cost)
+		profileStatOf(t.result.samples, frame.stack).add(&cost)
+	}
+}
+
+// Transaction start
+func (t *ProfileTracer) CaptureTxStart(gasLimit uint64) {
+	t.gasLimit = gasLimit
+	t.txStart = time.Now()
+}
+
+// Transaction end
+func (t *ProfileTracer) CaptureTxEnd(restGas uint64) {
+	t.result.Txs++
+	t.result.GasUsed += t.gasLimit - restGas
+	t.result.TimeNs += uint64(time.Since(t.txStart).Nanoseconds())
+}
+
+// Enter top-level call frame
+func (t *ProfileTracer) CaptureStart(env *EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	typ := CALL
+	if create {
+		typ = CREATE
+	}
+	t.enter(typ, from, to, input)
+}
+
+// Exit top-level call frame
+func (t *ProfileTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.exit(gasUsed)
+	t.result.ComputationCost += t.env.GetOpCodeComputationCost()
+}
+
+// Enter nested call frame
+func (t *ProfileTracer) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.enter(typ, from, to, input)
+}
+
+// Exit nested call frame
+func (t *ProfileTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit(gasUsed)
+}
+
+// Each opcode
+func (t *ProfileTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost, ccLeft, ccOpcode uint64, scope *ScopeContext, depth int, err error) {
+	if t.interrupt.Load() || len(t.callstack) == 0 {
+		return
+	}
+	t.tick(time.Now())
+	frame := t.callstack[len(t.callstack)-1]
+	if err != nil {
+		// the opcode failed before its execution
+		frame.lastOp, frame.lastSample = nil, nil
+		return
+	}
+	switch op {
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+		// the gas forwarded to the callee is accounted for by the callee
+		if cost >= env.callGasTemp {
+			cost -= env.callGasTemp
+		}
+	}
+	self := ProfileStat{Count: 1, Gas: cost, ComputationCost: ccOpcode}
+	frame.lastOp = profileStatOf(t.result.Opcodes, op.String())
+	frame.lastOp.add(&self)
+	frame.lastSample = profileStatOf(t.result.samples, frame.stack+profileStackSeparator+op.String())
+	frame.lastSample.add(&self)
+}
+
+// Fault during opcode execution
+func (t *ProfileTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost, ccLeft, ccOpcode uint64, scope *ScopeContext, depth int, err error) {
+}
+
+func (t *ProfileTracer) GetResult() (*ProfileResult, error) {
+	if len(t.callstack) != 0 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+
+	// Return with interrupt reason if any
+	return t.result, t.interruptReason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+// For ProfileTracer, it stops at CaptureState, which is the most repetitive operation.
+func (t *ProfileTracer) Stop(err error) {
+	t.interrupt.Store(true)
+	t.interruptReason = err
+}
@@ -0,0 +1,276 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/stateless"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus"
+	"github.com/kaiachain/kaia/params"
+)
+
+// StatePreloader is implemented by the modules reading the state of the parent block
+// while the block is finalized, e.g. the staking module after the Kaia fork.
+type StatePreloader interface {
+	AllocPreloadRef() uint64
+	FreePreloadRef(refId uint64)
+	PreloadFromState(refId uint64, header *types.Header, statedb *state.StateDB) error
+}
+
+// preloadParentState feeds the parent state opened from the given database to the preloaders,
+// so that their reads go through the database instead of the state of their own chain.
+// The returned function releases the preloaded data.
+func preloadParentState(parent *types.Header, db state.Database, preloaders []StatePreloader) (func(), error) {
+	var (
+		refs    = make([]uint64, 0, len(preloaders))
+		release = func() {
+			for i, refId := range refs {
+				preloaders[i].FreePreloadRef(refId)
+			}
+		}
+	)
+	for _, preloader := range preloaders {
+		statedb, err := state.New(parent.Root, db, nil, nil)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		refs = append(refs, preloader.AllocPreloadRef())
+		if err := preloader.PreloadFromState(refs[len(refs)-1], parent, statedb); err != nil {
+			release()
+			return nil, err
+		}
+		if err := statedb.Error(); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// witnessRecordingChain wraps the BlockChain to add the headers and the state accessed
+// during the block execution to the witness. The parent block is served as the current
+// block as it was when the block was inserted, so that the contract calls of the engine,
+// e.g. the treasury rebalance, read the parent state through the witness database.
+type witnessRecordingChain struct {
+	*BlockChain
+	witness *stateless.Witness
+	parent  *types.Block
+	db      state.Database
+}
+
+func (c *witnessRecordingChain) record(header *types.Header) *types.Header {
+	if header != nil {
+		c.witness.AddHeader(header)
+	}
+	return header
+}
+
+func (c *witnessRecordingChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return c.record(c.BlockChain.GetHeader(hash, number))
+}
+
+func (c *witnessRecordingChain) GetHeaderByNumber(number uint64) *types.Header {
+	return c.record(c.BlockChain.GetHeaderByNumber(number))
+}
+
+func (c *witnessRecordingChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return c.record(c.BlockChain.GetHeaderByHash(hash))
+}
+
+func (c *witnessRecordingChain) CurrentBlock() *types.Block   { return c.parent }
+func (c *witnessRecordingChain) CurrentHeader() *types.Header { return c.parent.Header() }
+
+func (c *witnessRecordingChain) State() (*state.StateDB, error) {
+	return c.StateAt(c.parent.Root())
+}
+
+func (c *witnessRecordingChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, c.db, nil, nil)
+}
+
+// ExecutionWitness re-executes the block on top of its parent state and returns
+// the witness holding every trie node, contract code and header accessed while
+// executing the block and computing its state root.
+// After the Kaia fork, the parent state read by the given preloaders, e.g. the staking
+// info used by the block rewards, is added to the witness as well. The other module data,
+// e.g. the governance parameters and the staking info before Kaia, is not part of the
+// witness and is expected to be served by the modules of the stateless executor.
+func (bc *BlockChain) ExecutionWitness(block *types.Block, preloaders ...StatePreloader) (*stateless.Witness, error) {
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis block has no execution witness")
+	}
+	parent := bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	var (
+		witness = stateless.NewWitness(parent.Header())
+		db      = state.NewWitnessDatabase(bc.stateCache, witness)
+	)
+	if bc.chainConfig.IsKaiaForkEnabled(block.Number()) {
+		release, err := preloadParentState(parent.Header(), db, preloaders)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	// Snapshots are not used so that every read goes through the tries.
+	statedb, err := state.New(parent.Root(), db, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	processor := &StateProcessor{
+		config: bc.chainConfig,
+		bc:     &witnessRecordingChain{BlockChain: bc, witness: witness, parent: parent, db: db},
+		engine: bc.engine,
+	}
+	receipts, _, usedGas, _, _, err := processor.Process(block, statedb, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	// Validating the state computes the state root, which resolves the trie nodes
+	// needed to apply the state changes.
+	if err := bc.validator.ValidateState(block, parent, statedb, receipts, usedGas); err != nil {
+		return nil, err
+	}
+	return witness, nil
+}
+
+// witnessChain serves the headers and the state of a witness to the block execution.
+// Only the headers linked to the parent by their parent hashes are served, so that
+// a tampered header can not be served in place of the requested one.
+type witnessChain struct {
+	config  *params.ChainConfig
+	engine  consensus.Engine
+	db      state.Database
+	parent  *types.Header
+	headers map[common.Hash]*types.Header
+	numbers map[uint64]*types.Header
+}
+
+func newWitnessChain(config *params.ChainConfig, engine consensus.Engine, db state.Database, witness *stateless.Witness) *witnessChain {
+	chain := &witnessChain{
+		config:  config,
+		engine:  engine,
+		db:      db,
+		parent:  witness.Parent(),
+		headers: make(map[common.Hash]*types.Header, len(witness.Headers)),
+		numbers: make(map[uint64]*types.Header, len(witness.Headers)),
+	}
+	all := make(map[common.Hash]*types.Header, len(witness.Headers))
+	for _, header := range witness.Headers {
+		all[header.Hash()] = header
+	}
+	// Walk back from the parent, which is verified against the block.
+	for header := chain.parent; header != nil; {
+		num := header.Number.Uint64()
+		chain.headers[header.Hash()] = header
+		chain.numbers[num] = header
+
+		next := all[header.ParentHash]
+		if num == 0 || next == nil || next.Number.Uint64() != num-1 {
+			break
+		}
+		header = next
+	}
+	return chain
+}
+
+func (c *witnessChain) Config() *params.ChainConfig  { return c.config }
+func (c *witnessChain) CurrentHeader() *types.Header { return c.parent }
+func (c *witnessChain) CurrentBlock() *types.Block   { return types.NewBlockWithHeader(c.parent) }
+func (c *witnessChain) Engine() consensus.Engine     { return c.engine }
+
+func (c *witnessChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.headers[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+func (c *witnessChain) GetHeaderByNumber(number uint64) *types.Header {
+	return c.numbers[number]
+}
+
+func (c *witnessChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return c.headers[hash]
+}
+
+func (c *witnessChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return nil
+}
+
+func (c *witnessChain) State() (*state.StateDB, error) {
+	return c.StateAt(c.parent.Root)
+}
+
+func (c *witnessChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, c.db, nil, nil)
+}
+
+// ExecuteStateless re-executes the block using only the state in the witness and
+// validates the gas used, receipts and state root against the block header.
+// The given engine initializes and finalizes the block, e.g. distributes the block rewards.
+// After the Kaia fork, the given preloaders are fed the parent state from the witness.
+func ExecuteStateless(config *params.ChainConfig, engine consensus.Engine, block *types.Block, witness *stateless.Witness, preloaders ...StatePreloader) (types.Receipts, error) {
+	parent := witness.Parent()
+	if parent == nil || parent.Hash() != block.ParentHash() {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	db, err := witness.MakeDB()
+	if err != nil {
+		return nil, err
+	}
+	stateDB := state.NewDatabase(db)
+	if config.IsKaiaForkEnabled(block.Number()) {
+		release, err := preloadParentState(parent, stateDB, preloaders)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	statedb, err := state.New(parent.Root, stateDB, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	processor := &StateProcessor{
+		config: config,
+		bc:     newWitnessChain(config, engine, stateDB, witness),
+		engine: engine,
+	}
+	receipts, _, usedGas, _, _, err := processor.Process(block, statedb, vm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("stateless execution failed: %w", err)
+	}
+	err = NewBlockValidator(config, nil, engine).ValidateState(block, nil, statedb, receipts, usedGas)
+	// A state missing from the witness is reported as a database error
+	if dbErr := statedb.Error(); dbErr != nil {
+		return nil, fmt.Errorf("incomplete witness: %w", dbErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/stateless"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus/gxhash"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/kaiachain/kaia/storage/statedb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutionWitness tests that the blocks can be re-executed with only the
+// witness generated by the chain, with and without the ExtHash of live pruning.
+func TestExecutionWitness(t *testing.T) {
+	for _, pruning := range []bool{false, true} {
+		t.Run(fmt.Sprintf("pruning=%v", pruning), func(t *testing.T) {
+			testExecutionWitness(t, pruning)
+		})
+	}
+}
+
+func testExecutionWitness(t *testing.T, pruning bool) {
+	var (
+		keys  = make([]*ecdsa.PrivateKey, 4)
+		funds = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.KAIA))
+
+		// recorder stores the hash of the block three blocks before in the slot of the caller.
+		// The hash is read from the grandparent header.
+		recorder     = common.HexToAddress("0x1000")
+		recorderCode = []byte{
+			byte(vm.PUSH1), 3, byte(vm.NUMBER), byte(vm.SUB), byte(vm.BLOCKHASH), byte(vm.CALLER), byte(vm.SSTORE), byte(vm.STOP),
+		}
+		// toggler flips the slot of the caller between 0 and 1, so that the slots
+		// are deleted from the storage trie every other block.
+		toggler     = common.HexToAddress("0x1001")
+		togglerCode = []byte{byte(vm.CALLER), byte(vm.SLOAD), byte(vm.ISZERO), byte(vm.CALLER), byte(vm.SSTORE), byte(vm.STOP)}
+	)
+	alloc := GenesisAlloc{
+		recorder: {Code: recorderCode, Balance: common.Big0},
+		toggler:  {Code: togglerCode, Balance: common.Big0},
+	}
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		alloc[crypto.PubkeyToAddress(keys[i].PublicKey)] = GenesisAccount{Balance: funds}
+	}
+	var (
+		config   = params.TestChainConfig.Copy()
+		gspec    = &Genesis{Config: config, Alloc: alloc}
+		gendb    = database.NewMemoryDBManager()
+		genesis  = gspec.MustCommit(gendb)
+		signer   = types.LatestSignerForChainID(config.ChainID)
+		gasPrice = big.NewInt(1)
+		engine   = gxhash.NewFaker()
+	)
+	// The blocks are generated one by one on top of an archive chain, which
+	// serves the ancestor headers to the BLOCKHASH opcode.
+	genchain, err := NewBlockChain(gendb, &CacheConfig{
+		ArchiveMode:         true,
+		CacheSize:           512,
+		BlockInterval:       DefaultBlockInterval,
+		TriesInMemory:       DefaultTriesInMemory,
+		TrieNodeCacheConfig: statedb.GetEmptyTrieNodeCacheConfig(),
+	}, config, engine, vm.Config{})
+	require.NoError(t, err)
+	defer genchain.Stop()
+
+	var blocks types.Blocks
+	for i, parent := 0, genesis; i < 4; i++ {
+		generated, _ := GenerateChain(config, parent, engine, gendb, 1, func(_ int, gen *BlockGen) {
+			send := func(key *ecdsa.PrivateKey, to common.Address, value *big.Int) {
+				from := crypto.PubkeyToAddress(key.PublicKey)
+				tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(from), to, value, 100000, gasPrice, nil), signer, key)
+				require.NoError(t, err)
+				gen.AddTxWithChain(genchain, tx)
+			}
+			send(keys[0], recorder, common.Big0)
+			send(keys[1], recorder, common.Big0)
+			// value transfer to a new account
+			send(keys[2], common.BigToAddress(big.NewInt(int64(0x2000+i))), big.NewInt(1000))
+			send(keys[0], toggler, common.Big0)
+			send(keys[3], toggler, common.Big0)
+		})
+		_, err := genchain.InsertChain(generated)
+		require.NoError(t, err)
+		parent = generated[0]
+		blocks = append(blocks, parent)
+	}
+
+	db := database.NewMemoryDBManager()
+	if pruning {
+		db.WritePruningEnabled()
+	}
+	gspec.MustCommit(db)
+	cacheConfig := &CacheConfig{
+		ArchiveMode:          false,
+		CacheSize:            512,
+		BlockInterval:        DefaultBlockInterval,
+		TriesInMemory:        DefaultTriesInMemory,
+		LivePruningRetention: DefaultPruningRetention,
+		TrieNodeCacheConfig:  statedb.GetEmptyTrieNodeCacheConfig(),
+	}
+	chain, err := NewBlockChain(db, cacheConfig, config, engine, vm.Config{})
+	require.NoError(t, err)
+	defer chain.Stop()
+	n, err := chain.InsertChain(blocks)
+	require.NoError(t, err, "block %d", n)
+
+	for _, block := range blocks {
+		witness, err := chain.ExecutionWitness(block)
+		require.NoError(t, err)
+		assert.Equal(t, block.ParentHash(), witness.Parent().Hash())
+		assert.NotEmpty(t, witness.State)
+		assert.Len(t, witness.Codes, 2)
+		if block.NumberU64() >= 3 {
+			// the grandparent header accessed by BLOCKHASH
+			assert.Len(t, witness.Headers, 2)
+		}
+		if pruning {
+			extended := false
+			for hash := range witness.State {
+				extended = extended || !hash.IsZeroExtended()
+			}
+			assert.True(t, extended, "no ExtHash node in the witness")
+		}
+
+		receipts, err := ExecuteStateless(config, engine, block, witness)
+		require.NoError(t, err)
+		assert.Equal(t, chain.GetReceiptsByBlockHash(block.Hash()), receipts)
+
+		// The witness survives the JSON encoding used by the RPC.
+		enc, err := json.Marshal(witness)
+		require.NoError(t, err)
+		decoded := new(stateless.Witness)
+		require.NoError(t, json.Unmarshal(enc, decoded))
+		_, err = ExecuteStateless(config, engine, block, decoded)
+		require.NoError(t, err)
+
+		// Every trie node is needed for the execution.
+		for hash := range decoded.State {
+			enc := decoded.State[hash]
+			delete(decoded.State, hash)
+			_, err = ExecuteStateless(config, engine, block, decoded)
+			assert.Error(t, err, "node %x", hash)
+			decoded.State[hash] = enc
+		}
+	}
+
+	// A tampered node is rejected.
+	witness, err := chain.ExecutionWitness(blocks[0])
+	require.NoError(t, err)
+	for hash, enc := range witness.State {
+		enc[len(enc)-1] ^= 0xff
+		witness.State[hash] = enc
+		break
+	}
+	_, err = ExecuteStateless(config, engine, blocks[0], witness)
+	assert.ErrorContains(t, err, "trie node")
+
+	// A header off the chain of the parent is not served.
+	last := blocks[len(blocks)-1]
+	witness, err = chain.ExecutionWitness(last)
+	require.NoError(t, err)
+	grandparent := witness.Headers[1]
+	require.Equal(t, witness.Parent().ParentHash, grandparent.Hash())
+	forged := types.CopyHeader(grandparent)
+	forged.Extra = []byte("forged")
+	witness.Headers = []*types.Header{witness.Parent(), forged, grandparent}
+	wchain := newWitnessChain(config, engine, nil, witness)
+	assert.Equal(t, grandparent.Hash(), wchain.GetHeaderByNumber(grandparent.Number.Uint64()).Hash())
+	assert.Nil(t, wchain.GetHeaderByHash(forged.Hash()))
+	assert.Nil(t, wchain.GetHeader(forged.Hash(), forged.Number.Uint64()))
+	_, err = ExecuteStateless(config, engine, last, witness)
+	assert.NoError(t, err)
+
+	// The witness of another parent is rejected.
+	witness, err = chain.ExecutionWitness(blocks[0])
+	require.NoError(t, err)
+	_, err = ExecuteStateless(config, engine, blocks[1], witness)
+	assert.Error(t, err)
+}
@@ -98,6 +98,8 @@ func main() {
 		doTest(os.Args[2:])
 	case "cover":
 		doCover(os.Args[2:])
+	case "fuzz":
+		doFuzz(os.Args[2:])
 	case "lint":
 		doLint(os.Args[2:], true)
 	case "lint-try":
@@ -392,6 +394,55 @@ func doCover(cmdline []string) {
 	build.MustRun(gotest)
 }
 
+// doFuzz runs each fuzz target of the requested packages for the given duration.
+// The go tool fuzzes a single target of a single package at a time.
+func doFuzz(cmdline []string) {
+	fuzztime := flag.String("fuzztime", "1m", "The duration to run each fuzz target for")
+	flag.CommandLine.Parse(cmdline)
+	env := build.Env()
+
+	packages := []string{"./..."}
+	if len(flag.CommandLine.Args()) > 0 {
+		packages = flag.CommandLine.Args()
+	}
+
+	for _, pkg := range listPackages(packages) {
+		for _, target := range listFuzzTargets(pkg) {
+			gotest := goTool("test", buildFlags(env)...)
+			gotest.Args = append(gotest.Args, "-run", "^$", "-fuzz", "^"+target+"$", "-fuzztime", *fuzztime, pkg)
+			build.MustRun(gotest)
+		}
+	}
+}
+
+// listPackages expands the package patterns into import paths.
+func listPackages(patterns []string) []string {
+	list := goTool("list", patterns...)
+	list.Stderr = os.Stderr
+	out, err := list.Output()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return strings.Fields(string(out))
+}
+
+// listFuzzTargets returns the names of the fuzz targets in the package.
+func listFuzzTargets(pkg string) []string {
+	list := goTool("test", "-list", "^Fuzz", pkg)
+	list.Stderr = os.Stderr
+	out, err := list.Output()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var targets []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "Fuzz") {
+			targets = append(targets, line)
+		}
+	}
+	return targets
+}
+
 // runs gometalinter on requested packages and exits immediately when linter warning observed if exitOnError is true
 // if exitOnError is false, prepare a report file for linters and run additional linters without stopping
 func doLint(cmdline []string, exitOnError bool) {
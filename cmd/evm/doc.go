@@ -0,0 +1,31 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+evm is a standalone command line interface to the Kaia EVM.
+
+The run command executes arbitrary bytecode on top of an optional genesis-style
+prestate and prints the struct logs of the execution. The statetest command runs
+execution-spec state test files against the Kaia fork rules. The t8n (transition)
+command applies a list of transactions, including the fee-delegated ones, to an
+alloc in the given block environment and emits the post-state, the receipts and
+the roots.
+
+	evm --debug run 0x6001600055
+	evm --state.fork Prague statetest tests.json
+	evm t8n --input.alloc alloc.json --input.env env.json --input.txs txs.json --output.result stdout
+*/
+package main
@@ -0,0 +1,119 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/cmd/utils/nodecmd"
+	"github.com/kaiachain/kaia/log"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/tests"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	DebugFlag = &cli.BoolFlag{
+		Name:  "debug",
+		Usage: "Output full trace logs",
+	}
+	JSONFlag = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Output trace logs in machine readable format (json)",
+	}
+	DisableMemoryFlag = &cli.BoolFlag{
+		Name:  "nomemory",
+		Value: true,
+		Usage: "Disable memory output",
+	}
+	DisableStackFlag = &cli.BoolFlag{
+		Name:  "nostack",
+		Usage: "Disable stack output",
+	}
+	DisableStorageFlag = &cli.BoolFlag{
+		Name:  "nostorage",
+		Usage: "Disable storage output",
+	}
+	ForkFlag = &cli.StringFlag{
+		Name:  "state.fork",
+		Usage: "Name of the fork whose rules are applied, e.g. Cancun or Prague",
+		Value: "Prague",
+	}
+	ChainIDFlag = &cli.Int64Flag{
+		Name:  "state.chainid",
+		Usage: "Chain ID used to sign and recover the transactions",
+		Value: 1,
+	}
+)
+
+var app = cli.NewApp()
+
+func init() {
+	app.Name = "evm"
+	app.Usage = "The Kaia EVM command line interface to run bytecodes, state tests and state transitions"
+	app.Copyright = "Copyright 2018-2024 The Kaia Authors"
+	app.Flags = []cli.Flag{
+		DebugFlag,
+		JSONFlag,
+		DisableMemoryFlag,
+		DisableStackFlag,
+		DisableStorageFlag,
+		ForkFlag,
+		ChainIDFlag,
+	}
+	app.Commands = []*cli.Command{
+		runCommand,
+		stateTestCommand,
+		transitionCommand,
+		nodecmd.VersionCommand,
+	}
+	app.HideVersion = true
+}
+
+func main() {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlWarn, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// logConfig returns the configuration of the struct loggers from the flags.
+func logConfig(ctx *cli.Context) *vm.LogConfig {
+	return &vm.LogConfig{
+		DisableMemory:  ctx.Bool(DisableMemoryFlag.Name),
+		DisableStack:   ctx.Bool(DisableStackFlag.Name),
+		DisableStorage: ctx.Bool(DisableStorageFlag.Name),
+	}
+}
+
+// chainConfig returns the chain configuration of the fork named by the flags,
+// with the given chain ID.
+func chainConfig(ctx *cli.Context) (*params.ChainConfig, error) {
+	fork := ctx.String(ForkFlag.Name)
+	config, ok := tests.Forks[fork]
+	if !ok {
+		return nil, tests.UnsupportedForkError{Name: fork}
+	}
+	config = config.Copy()
+	config.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
+	return config, nil
+}
@@ -0,0 +1,271 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/blockchain/vm/runtime"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/kaiachain/kaia/tests"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	CodeFileFlag = &cli.StringFlag{
+		Name:  "codefile",
+		Usage: "File containing EVM code. If '-' is specified, code is read from stdin",
+	}
+	CreateFlag = &cli.BoolFlag{
+		Name:  "create",
+		Usage: "Indicates the action should be create rather than call",
+	}
+	GasFlag = &cli.Uint64Flag{
+		Name:  "gas",
+		Usage: "Gas limit for the evm",
+		Value: 10000000000,
+	}
+	PriceFlag = &cli.StringFlag{
+		Name:  "price",
+		Usage: "Price set for the evm",
+		Value: "0",
+	}
+	ValueFlag = &cli.StringFlag{
+		Name:  "value",
+		Usage: "Value set for the evm",
+		Value: "0",
+	}
+	InputFlag = &cli.StringFlag{
+		Name:  "input",
+		Usage: "Input for the EVM",
+	}
+	PrestateFlag = &cli.StringFlag{
+		Name:  "prestate",
+		Usage: "JSON file with prestate (genesis) config",
+	}
+	SenderFlag = &cli.StringFlag{
+		Name:  "sender",
+		Usage: "The transaction origin",
+	}
+	ReceiverFlag = &cli.StringFlag{
+		Name:  "receiver",
+		Usage: "The transaction receiver (execution context)",
+	}
+	DumpFlag = &cli.BoolFlag{
+		Name:  "dump",
+		Usage: "Dumps the state after the run",
+	}
+	StatDumpFlag = &cli.BoolFlag{
+		Name:  "statdump",
+		Usage: "Displays stack and heap memory information",
+	}
+)
+
+var runCommand = &cli.Command{
+	Action:      runCmd,
+	Name:        "run",
+	Usage:       "Run arbitrary evm binary",
+	ArgsUsage:   "<code>",
+	Description: `The run command runs arbitrary EVM code.`,
+	Flags: []cli.Flag{
+		CodeFileFlag,
+		CreateFlag,
+		GasFlag,
+		PriceFlag,
+		ValueFlag,
+		InputFlag,
+		PrestateFlag,
+		SenderFlag,
+		ReceiverFlag,
+		DumpFlag,
+		StatDumpFlag,
+	},
+}
+
+// readGenesis reads the prestate of the run command from the given file.
+func readGenesis(path string) (*blockchain.Genesis, error) {
+	if path == "" {
+		return new(blockchain.Genesis), nil
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	gen := new(blockchain.Genesis)
+	if err := json.Unmarshal(src, gen); err != nil {
+		return nil, fmt.Errorf("invalid prestate %s: %v", path, err)
+	}
+	return gen, nil
+}
+
+// readCode returns the code to be executed, either from the code file, the
+// positional argument or the prestate of the receiver.
+func readCode(ctx *cli.Context) ([]byte, error) {
+	var hexcode []byte
+	switch path := ctx.String(CodeFileFlag.Name); {
+	case path == "-":
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		hexcode = src
+	case path != "":
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		hexcode = src
+	case ctx.Args().Len() > 0:
+		hexcode = []byte(ctx.Args().First())
+	default:
+		return nil, nil
+	}
+	code := common.FromHex(strings.TrimSpace(string(hexcode)))
+	if len(code) == 0 && len(strings.TrimSpace(string(hexcode))) > 0 {
+		return nil, errors.New("invalid code: not a hex string")
+	}
+	return code, nil
+}
+
+// parseBig parses a decimal or 0x-prefixed hexadecimal flag value.
+func parseBig(ctx *cli.Context, name string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(ctx.String(name), 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s: %q", name, ctx.String(name))
+	}
+	return v, nil
+}
+
+func runCmd(ctx *cli.Context) error {
+	config, err := chainConfig(ctx)
+	if err != nil {
+		return err
+	}
+	gen, err := readGenesis(ctx.String(PrestateFlag.Name))
+	if err != nil {
+		return err
+	}
+	price, err := parseBig(ctx, PriceFlag.Name)
+	if err != nil {
+		return err
+	}
+	value, err := parseBig(ctx, ValueFlag.Name)
+	if err != nil {
+		return err
+	}
+	code, err := readCode(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		tracer      vm.Tracer
+		debugLogger *vm.StructLogger
+		sender      = common.BytesToAddress([]byte("sender"))
+		receiver    = common.BytesToAddress([]byte("receiver"))
+		number      = new(big.Int).SetUint64(gen.Number)
+		rules       = config.Rules(number)
+	)
+	if ctx.Bool(JSONFlag.Name) {
+		tracer = vm.NewJSONLogger(logConfig(ctx), os.Stdout)
+	} else if ctx.Bool(DebugFlag.Name) {
+		debugLogger = vm.NewStructLogger(logConfig(ctx))
+		tracer = debugLogger
+	}
+	if s := ctx.String(SenderFlag.Name); s != "" {
+		sender = common.HexToAddress(s)
+	}
+	if s := ctx.String(ReceiverFlag.Name); s != "" {
+		receiver = common.HexToAddress(s)
+	}
+
+	blockchain.InitDeriveSha(config)
+	statedb := tests.MakePreState(database.NewMemoryDBManager(), gen.Alloc, true, rules)
+	statedb.CreateAccount(sender)
+
+	if len(code) == 0 && !ctx.Bool(CreateFlag.Name) {
+		// Without explicit code, run the code of the receiver in the prestate.
+		code = statedb.GetCode(receiver)
+	}
+	if len(code) == 0 {
+		return errors.New("no code to execute: use --codefile, an argument or a prestate receiver")
+	}
+
+	runtimeConfig := &runtime.Config{
+		Origin:      sender,
+		State:       statedb,
+		GasLimit:    ctx.Uint64(GasFlag.Name),
+		GasPrice:    price,
+		Value:       value,
+		BlockScore:  gen.BlockScore,
+		Time:        new(big.Int).SetUint64(gen.Timestamp),
+		Coinbase:    common.BytesToAddress([]byte("coinbase")),
+		BlockNumber: number,
+		ChainConfig: config,
+		EVMConfig: vm.Config{
+			Tracer: tracer,
+			Debug:  tracer != nil,
+		},
+	}
+
+	var (
+		output  []byte
+		leftGas uint64
+		start   = time.Now()
+	)
+	if ctx.Bool(CreateFlag.Name) {
+		input := append(code, common.FromHex(ctx.String(InputFlag.Name))...)
+		output, _, leftGas, err = runtime.Create(input, runtimeConfig)
+	} else {
+		if !statedb.IsProgramAccount(receiver) {
+			statedb.CreateSmartContractAccount(receiver, params.CodeFormatEVM, rules)
+		}
+		statedb.SetCode(receiver, code)
+		output, leftGas, err = runtime.Call(receiver, common.FromHex(ctx.String(InputFlag.Name)), runtimeConfig)
+	}
+	execTime := time.Since(start)
+
+	if ctx.Bool(DumpFlag.Name) {
+		statedb.Commit(true)
+		fmt.Println(string(statedb.Dump()))
+	}
+	if debugLogger != nil {
+		fmt.Fprintln(os.Stderr, "#### TRACE ####")
+		vm.WriteTrace(os.Stderr, debugLogger.StructLogs())
+		fmt.Fprintln(os.Stderr, "#### LOGS ####")
+		vm.WriteLogs(os.Stderr, statedb.Logs())
+	}
+	if ctx.Bool(StatDumpFlag.Name) {
+		fmt.Fprintf(os.Stderr, "EVM gas used:    %d\nexecution time:  %v\n", ctx.Uint64(GasFlag.Name)-leftGas, execTime)
+	}
+	fmt.Printf("0x%x\n", output)
+	if err != nil {
+		fmt.Printf(" error: %v\n", err)
+	}
+	return nil
+}
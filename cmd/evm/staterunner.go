@@ -0,0 +1,154 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/tests"
+	"github.com/urfave/cli/v2"
+)
+
+var stateTestCommand = &cli.Command{
+	Action:    stateTestCmd,
+	Name:      "statetest",
+	Usage:     "Executes the given state tests",
+	ArgsUsage: "<file>...",
+	Description: `The statetest command executes execution-spec state test files against the
+Kaia fork rules. If no file is given, the file names are read from stdin, one per line.
+Only the subtests of the fork given by --state.fork are run unless --state.fork is empty.`,
+}
+
+// StatetestResult contains the execution status after running a state test,
+// and any error that might have occurred.
+type StatetestResult struct {
+	Name  string `json:"name"`
+	Pass  bool   `json:"pass"`
+	Fork  string `json:"fork"`
+	Index int    `json:"index"`
+	Error string `json:"error,omitempty"`
+}
+
+func stateTestCmd(ctx *cli.Context) error {
+	fork := ctx.String(ForkFlag.Name)
+	newTracer := func() vm.Tracer {
+		if ctx.Bool(JSONFlag.Name) {
+			return vm.NewJSONLogger(logConfig(ctx), os.Stderr)
+		}
+		if ctx.Bool(DebugFlag.Name) {
+			return vm.NewStructLogger(logConfig(ctx))
+		}
+		return nil
+	}
+
+	if ctx.Args().Len() > 0 {
+		failed := 0
+		for _, path := range ctx.Args().Slice() {
+			n, err := runStateTestFile(path, fork, newTracer)
+			if err != nil {
+				return err
+			}
+			failed += n
+		}
+		return stateTestError(failed)
+	}
+	// Read the file names from stdin if none were given.
+	var (
+		failed  = 0
+		scanner = bufio.NewScanner(os.Stdin)
+	)
+	for scanner.Scan() {
+		path := scanner.Text()
+		if path == "" {
+			continue
+		}
+		n, err := runStateTestFile(path, fork, newTracer)
+		if err != nil {
+			return err
+		}
+		failed += n
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return stateTestError(failed)
+}
+
+// stateTestError returns the error terminating the command if any subtest failed.
+func stateTestError(failed int) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d state tests failed", failed)
+}
+
+// runStateTestFile executes all the subtests of the given fork in the state
+// test file, prints the results and returns the number of failed subtests.
+func runStateTestFile(path, fork string, newTracer func() vm.Tracer) (int, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var testsByName map[string]tests.StateTest
+	if err := json.Unmarshal(src, &testsByName); err != nil {
+		return 0, fmt.Errorf("invalid state test %s: %v", path, err)
+	}
+	names := make([]string, 0, len(testsByName))
+	for name := range testsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		results []StatetestResult
+		failed  int
+	)
+	for _, name := range names {
+		test := testsByName[name]
+		subtests := test.Subtests()
+		sort.Slice(subtests, func(i, j int) bool {
+			if subtests[i].Fork != subtests[j].Fork {
+				return subtests[i].Fork < subtests[j].Fork
+			}
+			return subtests[i].Index < subtests[j].Index
+		})
+		for _, st := range subtests {
+			if fork != "" && st.Fork != fork {
+				continue
+			}
+			cfg := vm.Config{Tracer: newTracer()}
+			cfg.Debug = cfg.Tracer != nil
+			result := StatetestResult{Name: name, Fork: st.Fork, Index: st.Index, Pass: true}
+			if err := test.Run(st, cfg, true); err != nil {
+				result.Pass, result.Error = false, err.Error()
+				failed++
+			}
+			if logger, ok := cfg.Tracer.(*vm.StructLogger); ok {
+				vm.WriteTrace(os.Stderr, logger.StructLogs())
+			}
+			results = append(results, result)
+		}
+	}
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+	return failed, nil
+}
@@ -0,0 +1,423 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/common/math"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/fork"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/kaiachain/kaia/tests"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	InputAllocFlag = &cli.StringFlag{
+		Name:  "input.alloc",
+		Usage: "`stdin` or file name of where to find the prestate alloc to use.",
+		Value: "alloc.json",
+	}
+	InputEnvFlag = &cli.StringFlag{
+		Name:  "input.env",
+		Usage: "`stdin` or file name of where to find the prestate env to use.",
+		Value: "env.json",
+	}
+	InputTxsFlag = &cli.StringFlag{
+		Name:  "input.txs",
+		Usage: "`stdin` or file name of where to find the transactions to apply.",
+		Value: "txs.json",
+	}
+	OutputBasedir = &cli.StringFlag{
+		Name:  "output.basedir",
+		Usage: "Specifies where output files are placed. Will be created if it does not exist.",
+	}
+	OutputAllocFlag = &cli.StringFlag{
+		Name:  "output.alloc",
+		Usage: "Determines where to put the `alloc` of the post-state.\n\t`stdout` - into the stdout output\n\t`stderr` - into the stderr output\n\t<file> - into the file <file> ",
+		Value: "alloc.json",
+	}
+	OutputResultFlag = &cli.StringFlag{
+		Name:  "output.result",
+		Usage: "Determines where to put the `result` (stateroot, txroot etc) of the post-state.\n\t`stdout` - into the stdout output\n\t`stderr` - into the stderr output\n\t<file> - into the file <file> ",
+		Value: "result.json",
+	}
+)
+
+var transitionCommand = &cli.Command{
+	Action:  transitionCmd,
+	Name:    "transition",
+	Aliases: []string{"t8n"},
+	Usage:   "Executes a full state transition",
+	Description: `The transition command applies the given transactions, including the
+fee-delegated ones, to the prestate alloc in the given block environment and
+emits the post-state alloc, the receipts and the state, transaction and receipt roots.
+The transactions are given as a JSON list of RLP-encoded hex strings or signed
+transaction objects. Block rewards are not applied.`,
+	Flags: []cli.Flag{
+		InputAllocFlag,
+		InputEnvFlag,
+		InputTxsFlag,
+		OutputBasedir,
+		OutputAllocFlag,
+		OutputResultFlag,
+	},
+}
+
+// stEnv is the block environment of the state transition.
+type stEnv struct {
+	Coinbase    common.Address                      `json:"currentCoinbase"`
+	Rewardbase  common.Address                      `json:"currentRewardbase"`
+	BlockScore  *math.HexOrDecimal256               `json:"currentBlockScore"`
+	Number      math.HexOrDecimal64                 `json:"currentNumber"`
+	Timestamp   math.HexOrDecimal64                 `json:"currentTimestamp"`
+	BaseFee     *math.HexOrDecimal256               `json:"currentBaseFee,omitempty"`
+	Random      *common.Hash                        `json:"currentRandom,omitempty"`
+	ParentHash  common.Hash                         `json:"parentHash"`
+	BlockHashes map[math.HexOrDecimal64]common.Hash `json:"blockHashes,omitempty"`
+}
+
+// header returns the header of the block in which the transactions are applied.
+func (env *stEnv) header() *types.Header {
+	header := &types.Header{
+		ParentHash: env.ParentHash,
+		Rewardbase: env.Rewardbase,
+		BlockScore: new(big.Int),
+		Number:     new(big.Int).SetUint64(uint64(env.Number)),
+		Time:       new(big.Int).SetUint64(uint64(env.Timestamp)),
+	}
+	if env.BlockScore != nil {
+		header.BlockScore = (*big.Int)(env.BlockScore)
+	}
+	if env.BaseFee != nil {
+		header.BaseFee = (*big.Int)(env.BaseFee)
+	}
+	if env.Random != nil {
+		header.MixHash = env.Random.Bytes()
+	}
+	return header
+}
+
+// getHash returns the hash of the given ancestor as given in the environment.
+func (env *stEnv) getHash(num uint64) common.Hash {
+	return env.BlockHashes[math.HexOrDecimal64(num)]
+}
+
+// txList is a list of transactions given either as RLP-encoded hex strings
+// or as signed transaction objects.
+type txList []*types.Transaction
+
+func (txs *txList) UnmarshalJSON(input []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(input, &raws); err != nil {
+		return err
+	}
+	list := make(txList, len(raws))
+	for i, raw := range raws {
+		tx := new(types.Transaction)
+		var enc hexutil.Bytes
+		if err := json.Unmarshal(raw, &enc); err == nil {
+			if err := tx.UnmarshalBinary(enc); err != nil {
+				return fmt.Errorf("invalid transaction %d: %v", i, err)
+			}
+		} else if err := json.Unmarshal(raw, tx); err != nil {
+			return fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+		list[i] = tx
+	}
+	*txs = list
+	return nil
+}
+
+// transitionInput is the combined input of the transition read from stdin.
+type transitionInput struct {
+	Alloc blockchain.GenesisAlloc `json:"alloc,omitempty"`
+	Env   *stEnv                  `json:"env,omitempty"`
+	Txs   txList                  `json:"txs,omitempty"`
+}
+
+// rejectedTx is a transaction that could not be applied to the state.
+type rejectedTx struct {
+	Index int    `json:"index"`
+	Err   string `json:"error"`
+}
+
+// ExecutionResult is the result of the state transition.
+type ExecutionResult struct {
+	StateRoot   common.Hash    `json:"stateRoot"`
+	TxRoot      common.Hash    `json:"txRoot"`
+	ReceiptRoot common.Hash    `json:"receiptsRoot"`
+	LogsHash    common.Hash    `json:"logsHash"`
+	Bloom       types.Bloom    `json:"logsBloom"`
+	Receipts    types.Receipts `json:"receipts"`
+	Rejected    []*rejectedTx  `json:"rejected,omitempty"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+}
+
+func transitionCmd(ctx *cli.Context) error {
+	config, err := chainConfig(ctx)
+	if err != nil {
+		return err
+	}
+	input, err := readTransitionInput(ctx)
+	if err != nil {
+		return err
+	}
+	if input.Env == nil {
+		return errors.New("missing block environment")
+	}
+
+	var tracer vm.Tracer
+	if ctx.Bool(JSONFlag.Name) {
+		tracer = vm.NewJSONLogger(logConfig(ctx), os.Stderr)
+	} else if ctx.Bool(DebugFlag.Name) {
+		tracer = vm.NewStructLogger(logConfig(ctx))
+	}
+	vmConfig := vm.Config{
+		Tracer: tracer,
+		Debug:  tracer != nil,
+	}
+
+	statedb, result, err := applyTransition(config, input.Alloc, input.Env, input.Txs, vmConfig)
+	if err != nil {
+		return err
+	}
+	if logger, ok := tracer.(*vm.StructLogger); ok {
+		vm.WriteTrace(os.Stderr, logger.StructLogs())
+	}
+	alloc, err := dumpAlloc(statedb)
+	if err != nil {
+		return err
+	}
+
+	baseDir := ctx.String(OutputBasedir.Name)
+	if baseDir != "" {
+		if err := os.MkdirAll(baseDir, 0o755); err != nil {
+			return err
+		}
+	}
+	if err := writeOutput(baseDir, ctx.String(OutputAllocFlag.Name), alloc); err != nil {
+		return err
+	}
+	return writeOutput(baseDir, ctx.String(OutputResultFlag.Name), result)
+}
+
+// readTransitionInput reads the alloc, env and transactions of the transition.
+// If any of them is given as stdin, stdin is read as a combined JSON object.
+func readTransitionInput(ctx *cli.Context) (*transitionInput, error) {
+	var (
+		input     = new(transitionInput)
+		allocPath = ctx.String(InputAllocFlag.Name)
+		envPath   = ctx.String(InputEnvFlag.Name)
+		txsPath   = ctx.String(InputTxsFlag.Name)
+	)
+	if allocPath == "stdin" || envPath == "stdin" || txsPath == "stdin" {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(src, input); err != nil {
+			return nil, fmt.Errorf("invalid input from stdin: %v", err)
+		}
+	}
+	if allocPath != "stdin" {
+		if err := readJSONFile(allocPath, &input.Alloc); err != nil {
+			return nil, err
+		}
+	}
+	if envPath != "stdin" {
+		input.Env = new(stEnv)
+		if err := readJSONFile(envPath, input.Env); err != nil {
+			return nil, err
+		}
+	}
+	if txsPath != "stdin" {
+		if err := readJSONFile(txsPath, &input.Txs); err != nil {
+			return nil, err
+		}
+	}
+	return input, nil
+}
+
+func readJSONFile(path string, v interface{}) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(src, v); err != nil {
+		return fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return nil
+}
+
+// writeOutput writes the JSON encoding of v to stdout, stderr or the named
+// file in the base directory.
+func writeOutput(baseDir, name string, v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "stdout":
+		_, err = fmt.Fprintln(os.Stdout, string(out))
+	case "stderr":
+		_, err = fmt.Fprintln(os.Stderr, string(out))
+	default:
+		err = os.WriteFile(filepath.Join(baseDir, name), out, 0o644)
+	}
+	return err
+}
+
+// applyTransition applies the transactions to the prestate in the given
+// environment the way the block processor does, skipping the transactions that
+// cannot be applied, and returns the committed post-state with the result.
+func applyTransition(config *params.ChainConfig, alloc blockchain.GenesisAlloc, env *stEnv, txs txList, vmConfig vm.Config) (*state.StateDB, *ExecutionResult, error) {
+	var (
+		header   = env.header()
+		rules    = config.Rules(header.Number)
+		signer   = types.MakeSigner(config, header.Number)
+		coinbase = env.Coinbase
+		usedGas  uint64
+		included types.Transactions
+		receipts = make(types.Receipts, 0, len(txs))
+		rejected []*rejectedTx
+	)
+	// The intrinsic gas of the transactions depends on the global hard fork config.
+	if err := fork.SetHardForkBlockNumberConfig(config); err != nil {
+		return nil, nil, err
+	}
+	blockchain.InitDeriveSha(config)
+	statedb := tests.MakePreState(database.NewMemoryDBManager(), alloc, true, rules)
+
+	blockContext := blockchain.NewEVMBlockContext(header, nil, &coinbase)
+	blockContext.GetHash = env.getHash
+
+	if config.IsPragueForkEnabled(header.Number) {
+		vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, config, &vm.Config{})
+		if err := blockchain.ProcessParentBlockHash(header, vmenv, statedb, rules); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for i, tx := range txs {
+		snapshot := statedb.Snapshot()
+		statedb.SetTxContext(tx.Hash(), common.Hash{}, len(included))
+
+		receipt, err := applyTransaction(config, statedb, header, blockContext, signer, tx, vmConfig)
+		if err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			rejected = append(rejected, &rejectedTx{Index: i, Err: err.Error()})
+			continue
+		}
+		usedGas += receipt.GasUsed
+		included = append(included, tx)
+		receipts = append(receipts, receipt)
+	}
+
+	root, err := statedb.Commit(true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not commit state: %v", err)
+	}
+	var logs []*types.Log
+	for _, receipt := range receipts {
+		logs = append(logs, receipt.Logs...)
+	}
+	result := &ExecutionResult{
+		StateRoot:   root,
+		TxRoot:      types.DeriveSha(included, header.Number),
+		ReceiptRoot: types.DeriveSha(receipts, header.Number),
+		LogsHash:    rlpHash(logs),
+		Bloom:       types.CreateBloom(receipts),
+		Receipts:    receipts,
+		Rejected:    rejected,
+		GasUsed:     hexutil.Uint64(usedGas),
+	}
+	return statedb, result, nil
+}
+
+// applyTransaction applies a single transaction the way BlockChain.ApplyTransaction does.
+func applyTransaction(config *params.ChainConfig, statedb *state.StateDB, header *types.Header, blockContext vm.BlockContext, signer types.Signer, tx *types.Transaction, vmConfig vm.Config) (*types.Receipt, error) {
+	number := header.Number.Uint64()
+	if err := tx.Validate(statedb, number); err != nil {
+		return nil, err
+	}
+	msg, err := tx.AsMessageWithAccountKeyPicker(signer, statedb, number)
+	if err != nil {
+		return nil, err
+	}
+	txContext := blockchain.NewEVMTxContext(msg, header, config)
+	vmenv := vm.NewEVM(blockContext, txContext, statedb, config, &vmConfig)
+	result, err := blockchain.ApplyMessage(vmenv, msg)
+	if err != nil {
+		return nil, err
+	}
+	statedb.Finalise(true, false)
+
+	receipt := types.NewReceipt(result.VmExecutionStatus, tx.Hash(), result.UsedGas)
+	msg.FillContractAddress(vmenv.Origin, receipt)
+	receipt.Logs = statedb.GetLogs(tx.Hash())
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	return receipt, nil
+}
+
+// dumpAlloc converts the committed state into an alloc.
+func dumpAlloc(statedb *state.StateDB) (blockchain.GenesisAlloc, error) {
+	alloc := make(blockchain.GenesisAlloc)
+	for addr, acc := range statedb.RawDump().Accounts {
+		balance, ok := new(big.Int).SetString(acc.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance of %s: %s", addr, acc.Balance)
+		}
+		account := blockchain.GenesisAccount{
+			Code:    common.FromHex(acc.Code),
+			Balance: balance,
+			Nonce:   acc.Nonce,
+		}
+		if len(acc.Storage) > 0 {
+			account.Storage = make(map[common.Hash]common.Hash, len(acc.Storage))
+			for key, enc := range acc.Storage {
+				var value []byte
+				if err := rlp.DecodeBytes(common.FromHex(enc), &value); err != nil {
+					return nil, fmt.Errorf("invalid storage of %s: %v", addr, err)
+				}
+				account.Storage[common.HexToHash(key)] = common.BytesToHash(value)
+			}
+		}
+		alloc[common.HexToAddress(addr)] = account
+	}
+	return alloc, nil
+}
+
+func rlpHash(x interface{}) (h common.Hash) {
+	enc, _ := rlp.EncodeToBytes(x)
+	return crypto.Keccak256Hash(enc)
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/common/math"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	senderKey, _   = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	feePayerKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	sender         = crypto.PubkeyToAddress(senderKey.PublicKey)
+	feePayer       = crypto.PubkeyToAddress(feePayerKey.PublicKey)
+	recipient      = common.HexToAddress("0x0000000000000000000000000000000000002000")
+	contract       = common.HexToAddress("0x0000000000000000000000000000000000001000")
+	testGasPrice   = new(big.Int).SetUint64(25 * params.Gkei)
+	testBalance    = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.KAIA))
+)
+
+func newTestTransition(t *testing.T) (*params.ChainConfig, blockchain.GenesisAlloc, *stEnv, txList) {
+	config := tests.Forks["Prague"].Copy()
+	config.ChainID = big.NewInt(1)
+	signer := types.LatestSignerForChainID(config.ChainID)
+
+	alloc := blockchain.GenesisAlloc{
+		sender:   {Balance: testBalance},
+		feePayer: {Balance: testBalance},
+		// SSTORE(0, 1)
+		contract: {Balance: new(big.Int), Code: common.FromHex("0x600160005500"), Nonce: 1},
+	}
+	env := &stEnv{
+		Coinbase:   common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba"),
+		Number:     1,
+		Timestamp:  1000,
+		BaseFee:    (*math.HexOrDecimal256)(testGasPrice),
+		ParentHash: common.HexToHash("0x01"),
+	}
+
+	legacy, err := types.SignTx(types.NewTransaction(0, recipient, big.NewInt(1000), 21000, testGasPrice, nil), signer, senderKey)
+	require.NoError(t, err)
+
+	delegated, err := types.NewTransactionWithMap(types.TxTypeFeeDelegatedValueTransfer, map[types.TxValueKeyType]interface{}{
+		types.TxValueKeyNonce:    uint64(1),
+		types.TxValueKeyTo:       recipient,
+		types.TxValueKeyAmount:   big.NewInt(100),
+		types.TxValueKeyGasLimit: uint64(100000),
+		types.TxValueKeyGasPrice: testGasPrice,
+		types.TxValueKeyFrom:     sender,
+		types.TxValueKeyFeePayer: feePayer,
+	})
+	require.NoError(t, err)
+	require.NoError(t, delegated.SignWithKeys(signer, []*ecdsa.PrivateKey{senderKey}))
+	require.NoError(t, delegated.SignFeePayerWithKeys(signer, []*ecdsa.PrivateKey{feePayerKey}))
+
+	// The nonce gap makes this transaction invalid.
+	gapped, err := types.SignTx(types.NewTransaction(5, recipient, big.NewInt(1), 21000, testGasPrice, nil), signer, senderKey)
+	require.NoError(t, err)
+
+	call, err := types.SignTx(types.NewTransaction(2, contract, new(big.Int), 100000, testGasPrice, nil), signer, senderKey)
+	require.NoError(t, err)
+
+	return config, alloc, env, txList{legacy, delegated, gapped, call}
+}
+
+func TestTransition(t *testing.T) {
+	config, alloc, env, txs := newTestTransition(t)
+
+	statedb, result, err := applyTransition(config, alloc, env, txs, vm.Config{})
+	require.NoError(t, err)
+
+	// The gapped transaction is rejected while the others are included.
+	require.Len(t, result.Receipts, 3)
+	require.Len(t, result.Rejected, 1)
+	assert.Equal(t, 2, result.Rejected[0].Index)
+
+	var gasUsed uint64
+	for _, receipt := range result.Receipts {
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+		gasUsed += receipt.GasUsed
+	}
+	assert.Equal(t, hexutil.Uint64(gasUsed), result.GasUsed)
+	assert.Equal(t, types.DeriveSha(types.Transactions{txs[0], txs[1], txs[3]}, big.NewInt(1)), result.TxRoot)
+	assert.Equal(t, types.DeriveSha(result.Receipts, big.NewInt(1)), result.ReceiptRoot)
+
+	post, err := dumpAlloc(statedb)
+	require.NoError(t, err)
+
+	assert.Equal(t, big.NewInt(1100), post[recipient].Balance)
+	assert.Equal(t, uint64(3), post[sender].Nonce)
+	assert.Equal(t, common.BigToHash(common.Big1), post[contract].Storage[common.Hash{}])
+	assert.Equal(t, common.FromHex("0x600160005500"), post[contract].Code)
+
+	// The fee payer pays the fee of the fee-delegated transaction only.
+	delegatedFee := new(big.Int).Mul(new(big.Int).SetUint64(result.Receipts[1].GasUsed), testGasPrice)
+	assert.Equal(t, new(big.Int).Sub(testBalance, delegatedFee), post[feePayer].Balance)
+	assert.Zero(t, post[feePayer].Nonce)
+
+	// The post-state alloc reproduces the post-state root.
+	_, replay, err := applyTransition(config, post, env, nil, vm.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, result.StateRoot, replay.StateRoot)
+}
+
+func TestTransitionCommand(t *testing.T) {
+	config, alloc, env, txs := newTestTransition(t)
+	_, want, err := applyTransition(config, alloc, env, txs, vm.Config{})
+	require.NoError(t, err)
+
+	// Give the transactions both as RLP-encoded hex strings and as JSON objects.
+	encTxs := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		if i%2 == 0 {
+			enc, err := tx.MarshalBinary()
+			require.NoError(t, err)
+			encTxs[i] = hexutil.Bytes(enc)
+		} else {
+			encTxs[i] = tx
+		}
+	}
+	dir := t.TempDir()
+	writeJSON := func(name string, v interface{}) string {
+		enc, err := json.Marshal(v)
+		require.NoError(t, err)
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, enc, 0o644))
+		return path
+	}
+	args := []string{
+		"evm", "--state.fork", "Prague", "--state.chainid", "1", "t8n",
+		"--input.alloc", writeJSON("alloc.json", alloc),
+		"--input.env", writeJSON("env.json", env),
+		"--input.txs", writeJSON("txs.json", encTxs),
+		"--output.basedir", filepath.Join(dir, "out"),
+	}
+	require.NoError(t, app.Run(args))
+
+	var result struct {
+		StateRoot   common.Hash `json:"stateRoot"`
+		TxRoot      common.Hash `json:"txRoot"`
+		ReceiptRoot common.Hash `json:"receiptsRoot"`
+		Rejected    []*rejectedTx
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "out", "result.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(src, &result))
+	assert.Equal(t, want.StateRoot, result.StateRoot)
+	assert.Equal(t, want.TxRoot, result.TxRoot)
+	assert.Equal(t, want.ReceiptRoot, result.ReceiptRoot)
+	assert.Len(t, result.Rejected, 1)
+
+	var post blockchain.GenesisAlloc
+	src, err = os.ReadFile(filepath.Join(dir, "out", "alloc.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(src, &post))
+	assert.Equal(t, big.NewInt(1100), post[recipient].Balance)
+}
+
+func TestStateTestCommand(t *testing.T) {
+	require.NoError(t, app.Run([]string{"evm", "statetest", filepath.Join("testdata", "statetest", "sstore.json")}))
+}
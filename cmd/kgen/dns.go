@@ -0,0 +1,157 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/networks/p2p/discover"
+	"github.com/kaiachain/kaia/networks/p2p/dnsdisc"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	dnsNodesFlag = &cli.StringFlag{
+		Name:     "nodes",
+		Usage:    `File containing the kni URLs of the nodes, one per line`,
+		Required: true,
+	}
+	dnsDomainFlag = &cli.StringFlag{
+		Name:     "domain",
+		Usage:    `Domain name where the tree is served`,
+		Required: true,
+	}
+	dnsKeyFlag = &cli.StringFlag{
+		Name:     "key",
+		Usage:    `File containing the hex private key used to sign the tree`,
+		Required: true,
+	}
+	dnsSeqFlag = &cli.UintFlag{
+		Name:  "seq",
+		Usage: `Sequence number of the tree, which must increase on every update`,
+		Value: 1,
+	}
+	dnsLinkFlag = &cli.StringSliceFlag{
+		Name:  "link",
+		Usage: `kaiatree:// URL of another tree to link`,
+	}
+
+	dnsCommand = &cli.Command{
+		Name:  "dns",
+		Usage: "Build, sign and inspect DNS node lists",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "sign",
+				Usage:  "Build a node list and print its signed TXT records",
+				Action: dnsSign,
+				Flags:  []cli.Flag{dnsNodesFlag, dnsDomainFlag, dnsKeyFlag, dnsSeqFlag, dnsLinkFlag},
+			},
+			{
+				Name:      "sync",
+				Usage:     "Download a node list and print its nodes",
+				ArgsUsage: "<kaiatree URL>",
+				Action:    dnsSync,
+			},
+		},
+	}
+)
+
+// dnsTreeOutput is the output of the dns sign command.
+type dnsTreeOutput struct {
+	URL     string            `json:"url"`
+	Seq     uint              `json:"seq"`
+	Records map[string]string `json:"records"`
+}
+
+// dnsSign builds a tree of the given nodes and prints the records to publish.
+func dnsSign(ctx *cli.Context) error {
+	nodes, err := loadNodes(ctx.String(dnsNodesFlag.Name))
+	if err != nil {
+		return err
+	}
+	key, err := crypto.LoadECDSA(ctx.String(dnsKeyFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to load the signing key: %v", err)
+	}
+	tree, err := dnsdisc.MakeTree(ctx.Uint(dnsSeqFlag.Name), nodes, ctx.StringSlice(dnsLinkFlag.Name))
+	if err != nil {
+		return err
+	}
+	domain := ctx.String(dnsDomainFlag.Name)
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		return err
+	}
+	str, err := json.MarshalIndent(dnsTreeOutput{URL: url, Seq: tree.Seq(), Records: tree.ToTXT(domain)}, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(str))
+	return nil
+}
+
+// dnsSync downloads the tree at the given URL and prints its nodes.
+func dnsSync(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("need a kaiatree URL as argument")
+	}
+	client := dnsdisc.NewClient(dnsdisc.Config{})
+	tree, err := client.SyncTree(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	fmt.Println("seq:", tree.Seq())
+	for _, n := range tree.Nodes() {
+		fmt.Println(n)
+	}
+	for _, l := range tree.Links() {
+		fmt.Println("link: kaiatree://" + l)
+	}
+	return nil
+}
+
+// loadNodes reads the kni URLs in the file. Empty lines and lines starting with '#' are skipped.
+func loadNodes(file string) ([]*discover.Node, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var nodes []*discover.Node
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		n, err := discover.ParseNode(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node URL %q: %v", line, err)
+		}
+		if n.NType == discover.NodeTypeUnknown {
+			return nil, fmt.Errorf("node URL %q has no ntype", line)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, scanner.Err()
+}
@@ -27,5 +27,16 @@ All available options are as follows.
 	--ip value    Specify an IP address (default: "0.0.0.0")
 	--port value  Specify a tcp port number (default: 32323)
 	--help, -h    Show help
+
+# DNS node lists
+
+The dns command builds and inspects the signed DNS node lists used by the --dnsdiscovery flag.
+
+	kgen dns sign --nodes nodes.txt --domain nodes.example.org --key signer.key [--seq 2] [--link <kaiatree URL>]
+	kgen dns sync kaiatree://<public key>@nodes.example.org
+
+The sign command reads the kni URLs of the nodes (with their ntype) from a file and prints
+the kaiatree URL of the list and the TXT records to publish under the domain.
+The sequence number must be increased whenever the list is updated.
 */
 package main
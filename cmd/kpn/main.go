@@ -73,6 +73,12 @@ func init() {
 
 		// See utils/nodecmd/snapshot.go:
 		nodecmd.SnapshotCommand,
+
+		// See utils/nodecmd/rewardcmd.go:
+		nodecmd.RewardCommand,
+
+		// See utils/nodecmd/supplycmd.go:
+		nodecmd.SupplyCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -30,6 +30,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -46,13 +47,16 @@ import (
 	"github.com/kaiachain/kaia/crypto"
 	"github.com/kaiachain/kaia/crypto/bls"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/file"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kafka"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kas"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/postgres"
 	"github.com/kaiachain/kaia/datasync/dbsyncer"
 	"github.com/kaiachain/kaia/datasync/downloader"
 	"github.com/kaiachain/kaia/log"
 	"github.com/kaiachain/kaia/networks/p2p"
 	"github.com/kaiachain/kaia/networks/p2p/discover"
+	"github.com/kaiachain/kaia/networks/p2p/dnsdisc"
 	"github.com/kaiachain/kaia/networks/p2p/nat"
 	"github.com/kaiachain/kaia/networks/p2p/netutil"
 	"github.com/kaiachain/kaia/networks/rpc"
@@ -181,6 +185,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 
 	// set bootnodes via this function by check specified parameters
 	setBootstrapNodes(ctx, cfg)
+	setDNSDiscovery(ctx, cfg)
 
 	if ctx.IsSet(MaxConnectionsFlag.Name) {
 		cfg.MaxPhysicalConnections = ctx.Int(MaxConnectionsFlag.Name)
@@ -190,6 +195,12 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.IsSet(MaxPendingPeersFlag.Name) {
 		cfg.MaxPendingPeers = ctx.Int(MaxPendingPeersFlag.Name)
 	}
+	if ctx.IsSet(PeerBanThresholdFlag.Name) {
+		cfg.PeerBanThreshold = ctx.Int(PeerBanThresholdFlag.Name)
+	}
+	if ctx.IsSet(PeerBanDurationFlag.Name) {
+		cfg.PeerBanDuration = ctx.Duration(PeerBanDurationFlag.Name)
+	}
 
 	cfg.NoDiscovery = ctx.Bool(NoDiscoverFlag.Name)
 
@@ -345,6 +356,25 @@ func setBootstrapNodes(ctx *cli.Context, cfg *p2p.Config) {
 	}
 }
 
+// setDNSDiscovery sets the URLs of the DNS node lists from the command line flags.
+func setDNSDiscovery(ctx *cli.Context, cfg *p2p.Config) {
+	if !ctx.IsSet(DNSDiscoveryFlag.Name) {
+		return
+	}
+	cfg.DNSDiscovery = nil
+	for _, url := range strings.Split(ctx.String(DNSDiscoveryFlag.Name), ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		if _, _, err := dnsdisc.ParseURL(url); err != nil {
+			logger.Crit("Invalid DNS discovery URL", "url", url, "err", err)
+		}
+		cfg.DNSDiscovery = append(cfg.DNSDiscovery, url)
+	}
+	logger.Info("DNS node lists are set", "urls", cfg.DNSDiscovery)
+}
+
 // setNodeConfig applies node-related command line flags to the config.
 func (kCfg *KaiaConfig) SetNodeConfig(ctx *cli.Context) {
 	cfg := &kCfg.Node
@@ -521,8 +551,12 @@ func (kCfg *KaiaConfig) SetKaiaConfig(ctx *cli.Context, stack *node.Node) {
 
 	if ctx.IsSet(SyncModeFlag.Name) {
 		cfg.SyncMode = *GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
-		if cfg.SyncMode != downloader.FullSync && cfg.SyncMode != downloader.SnapSync {
-			log.Fatalf("Full Sync or Snap Sync (prototype) is supported only!")
+		if cfg.SyncMode == downloader.LightSync {
+			if NodeTypeFlag.Value != "en" {
+				log.Fatalf("Light Sync is supported only by ken!")
+			}
+		} else if cfg.SyncMode != downloader.FullSync && cfg.SyncMode != downloader.SnapSync {
+			log.Fatalf("Full Sync, Snap Sync (prototype) or Light Sync (ken) is supported only!")
 		}
 		if cfg.SyncMode == downloader.SnapSync {
 			logger.Info("Snap sync requested, enabling --snapshot")
@@ -531,6 +565,12 @@ func (kCfg *KaiaConfig) SetKaiaConfig(ctx *cli.Context, stack *node.Node) {
 			cfg.SnapshotCacheSize = 0 // Disabled
 		}
 	}
+	if ctx.IsSet(CheckpointFileFlag.Name) || ctx.IsSet(CheckpointHashFlag.Name) {
+		if cfg.SyncMode != downloader.SnapSync && cfg.SyncMode != downloader.LightSync {
+			log.Fatalf("The checkpoint is only used by snap or light sync, set --%s snap or light", SyncModeFlag.Name)
+		}
+		cfg.SyncCheckpoint = makeSyncCheckpoint(ctx)
+	}
 
 	if ctx.Bool(KESNodeTypeServiceFlag.Name) {
 		cfg.FetcherDisable = true
@@ -604,6 +644,8 @@ func (kCfg *KaiaConfig) SetKaiaConfig(ctx *cli.Context, stack *node.Node) {
 	cfg.TriesInMemory = ctx.Uint64(TriesInMemoryFlag.Name)
 	cfg.LivePruning = ctx.Bool(LivePruningFlag.Name)
 	cfg.LivePruningRetention = ctx.Uint64(LivePruningRetentionFlag.Name)
+	cfg.StateDiff = ctx.Bool(StateDiffFlag.Name)
+	cfg.StateDiffRetention = ctx.Uint64(StateDiffRetentionFlag.Name)
 	cfg.TxPruning = ctx.Bool(TxPruningFlag.Name)
 	if cfg.TxPruning {
 		cfg.TxPruningRetention = ctx.Uint64(TxPruningRetentionFlag.Name)
@@ -670,6 +712,9 @@ func (kCfg *KaiaConfig) SetKaiaConfig(ctx *cli.Context, stack *node.Node) {
 	}
 	cfg.EnableInternalTxTracing = ctx.Bool(VMTraceInternalTxFlag.Name)
 	cfg.EnableOpDebug = ctx.Bool(VMOpDebugFlag.Name)
+	cfg.ParallelExecution = ctx.Bool(VMParallelExecutionFlag.Name)
+	cfg.VMTrace = ctx.String(VMTraceFlag.Name)
+	cfg.VMTraceJsonConfig = ctx.String(VMTraceJsonConfigFlag.Name)
 
 	cfg.AutoRestartFlag = ctx.Bool(AutoRestartFlag.Name)
 	cfg.RestartTimeOutFlag = ctx.Duration(RestartTimeOutFlag.Name)
@@ -709,10 +754,15 @@ func (kCfg *KaiaConfig) SetKaiaConfig(ctx *cli.Context, stack *node.Node) {
 	} else {
 		cfg.SnapshotCacheSize = 0 // snapshot disabled
 	}
+	cfg.SnapServeBytesPerSec = ctx.Uint64(SnapServeRateFlag.Name)
+	cfg.SnapServeMaxRequests = ctx.Int(SnapServeMaxRequestsFlag.Name)
+	cfg.LightServe = ctx.Bool(LightServeFlag.Name)
 
 	// disable unsafe debug APIs
 	cfg.DisableUnsafeDebug = ctx.Bool(UnsafeDebugDisableFlag.Name)
 	cfg.StateRegenerationTimeLimit = ctx.Duration(StateRegenerationTimeLimitFlag.Name)
+	cfg.StateRegenCacheSize = ctx.Int(StateRegenCacheSizeFlag.Name)
+	cfg.StateRegenReexec = ctx.Uint64(StateRegenReexecFlag.Name)
 	tracers.HeavyAPIRequestLimit = int32(ctx.Int(HeavyDebugRequestLimitFlag.Name))
 
 	// Override any default configs for hard coded network.
@@ -773,6 +823,36 @@ func setRewardbase(ctx *cli.Context, ks *keystore.KeyStore, cfg *cn.Config) {
 	}
 }
 
+// makeSyncCheckpoint returns the trusted checkpoint given either by its number
+// and hash, or by a checkpoint file signed by one of the trusted signers.
+func makeSyncCheckpoint(ctx *cli.Context) *downloader.Checkpoint {
+	if file := ctx.String(CheckpointFileFlag.Name); file != "" {
+		var signers []common.Address
+		for _, signer := range strings.Split(ctx.String(CheckpointSignersFlag.Name), ",") {
+			if signer = strings.TrimSpace(signer); signer == "" {
+				continue
+			}
+			if !common.IsHexAddress(signer) {
+				log.Fatalf("Option %q: invalid address %q", CheckpointSignersFlag.Name, signer)
+			}
+			signers = append(signers, common.HexToAddress(signer))
+		}
+		cp, err := downloader.LoadCheckpoint(file, signers)
+		if err != nil {
+			log.Fatalf("Option %q: %v", CheckpointFileFlag.Name, err)
+		}
+		return cp
+	}
+	hash := ctx.String(CheckpointHashFlag.Name)
+	if len(common.FromHex(hash)) != common.HashLength || !ctx.IsSet(CheckpointNumberFlag.Name) {
+		log.Fatalf("Option %q needs a 32 byte hash and %q", CheckpointHashFlag.Name, CheckpointNumberFlag.Name)
+	}
+	return &downloader.Checkpoint{
+		Number: ctx.Uint64(CheckpointNumberFlag.Name),
+		Hash:   common.HexToHash(hash),
+	}
+}
+
 // makeAddress converts an account specified directly as a hex encoded string or
 // a key index in the key store to an internal account representation.
 func MakeAddress(ks *keystore.KeyStore, account string) (accounts.Account, error) {
@@ -919,8 +999,14 @@ func (kCfg *KaiaConfig) SetChainDataFetcherConfig(ctx *cli.Context) {
 		case "kafka":
 			cfg.Mode = chaindatafetcher.ModeKafka
 			cfg.KafkaConfig = makeKafkaConfig(ctx)
+		case "file":
+			cfg.Mode = chaindatafetcher.ModeFile
+			cfg.FileConfig = makeChainDataFileConfig(ctx)
+		case "postgres":
+			cfg.Mode = chaindatafetcher.ModePostgres
+			cfg.PostgresConfig = makePostgresConfig(ctx)
 		default:
-			logger.Crit("unsupported chaindatafetcher mode (\"kas\", \"kafka\")", "mode", cfg.Mode)
+			logger.Crit("unsupported chaindatafetcher mode (\"kas\", \"kafka\", \"file\", \"postgres\")", "mode", mode)
 		}
 	}
 }
@@ -1001,6 +1087,42 @@ func makeKafkaConfig(ctx *cli.Context) *kafka.KafkaConfig {
 	return kafkaConfig
 }
 
+func makeChainDataFileConfig(ctx *cli.Context) *file.FileConfig {
+	fileConfig := file.GetDefaultFileConfig()
+	if ctx.IsSet(ChainDataFetcherFileDirFlag.Name) {
+		fileConfig.Dir = ctx.String(ChainDataFetcherFileDirFlag.Name)
+	} else {
+		fileConfig.Dir = filepath.Join(ctx.String(DataDirFlag.Name), file.DefaultDirName)
+	}
+	fileConfig.MaxFileSize = ctx.Int64(ChainDataFetcherFileMaxSizeFlag.Name)
+	fileConfig.MaxFileAge = ctx.Duration(ChainDataFetcherFileMaxAgeFlag.Name)
+	fileConfig.Compress = ctx.Bool(ChainDataFetcherFileCompressFlag.Name)
+	if fileConfig.MaxFileSize <= 0 {
+		logger.Crit("The max size of chaindata files must be positive", "given", fileConfig.MaxFileSize)
+	}
+	return fileConfig
+}
+
+func makePostgresConfig(ctx *cli.Context) *postgres.PostgresConfig {
+	postgresConfig := postgres.GetDefaultPostgresConfig()
+	if !ctx.IsSet(ChainDataFetcherPostgresDBHostFlag.Name) {
+		logger.Crit("DBHost must be set !", "key", ChainDataFetcherPostgresDBHostFlag.Name)
+	}
+	if !ctx.IsSet(ChainDataFetcherPostgresDBUserFlag.Name) {
+		logger.Crit("DBUser must be set !", "key", ChainDataFetcherPostgresDBUserFlag.Name)
+	}
+	if !ctx.IsSet(ChainDataFetcherPostgresDBNameFlag.Name) {
+		logger.Crit("DBName must be set !", "key", ChainDataFetcherPostgresDBNameFlag.Name)
+	}
+	postgresConfig.DBHost = ctx.String(ChainDataFetcherPostgresDBHostFlag.Name)
+	postgresConfig.DBPort = ctx.String(ChainDataFetcherPostgresDBPortFlag.Name)
+	postgresConfig.DBUser = ctx.String(ChainDataFetcherPostgresDBUserFlag.Name)
+	postgresConfig.DBPassword = ctx.String(ChainDataFetcherPostgresDBPasswordFlag.Name)
+	postgresConfig.DBName = ctx.String(ChainDataFetcherPostgresDBNameFlag.Name)
+	postgresConfig.SSLMode = ctx.String(ChainDataFetcherPostgresDBSSLModeFlag.Name)
+	return postgresConfig
+}
+
 func (kCfg *KaiaConfig) SetDBSyncerConfig(ctx *cli.Context) {
 	cfg := &kCfg.DB
 	if ctx.Bool(EnableDBSyncerFlag.Name) {
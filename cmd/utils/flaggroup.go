@@ -46,6 +46,10 @@ var FlagGroups = []FlagGroup{
 			ChainDataDirFlag,
 			IdentityFlag,
 			SyncModeFlag,
+			CheckpointNumberFlag,
+			CheckpointHashFlag,
+			CheckpointFileFlag,
+			CheckpointSignersFlag,
 			GCModeFlag,
 			SrvTypeFlag,
 			ExtraDataFlag,
@@ -171,6 +175,16 @@ var FlagGroups = []FlagGroup{
 			ChainDataFetcherKafkaRequiredAcksFlag,
 			ChainDataFetcherKafkaMessageVersionFlag,
 			ChainDataFetcherKafkaProducerIdFlag,
+			ChainDataFetcherFileDirFlag,
+			ChainDataFetcherFileMaxSizeFlag,
+			ChainDataFetcherFileMaxAgeFlag,
+			ChainDataFetcherFileCompressFlag,
+			ChainDataFetcherPostgresDBHostFlag,
+			ChainDataFetcherPostgresDBPortFlag,
+			ChainDataFetcherPostgresDBNameFlag,
+			ChainDataFetcherPostgresDBUserFlag,
+			ChainDataFetcherPostgresDBPasswordFlag,
+			ChainDataFetcherPostgresDBSSLModeFlag,
 		},
 	},
 	{
@@ -206,6 +220,8 @@ var FlagGroups = []FlagGroup{
 			TriesInMemoryFlag,
 			LivePruningFlag,
 			LivePruningRetentionFlag,
+			StateDiffFlag,
+			StateDiffRetentionFlag,
 		},
 	},
 	{
@@ -237,11 +253,14 @@ var FlagGroups = []FlagGroup{
 		Name: "NETWORKING",
 		Flags: []cli.Flag{
 			BootnodesFlag,
+			DNSDiscoveryFlag,
 			ListenPortFlag,
 			SubListenPortFlag,
 			MultiChannelUseFlag,
 			MaxConnectionsFlag,
 			MaxPendingPeersFlag,
+			PeerBanThresholdFlag,
+			PeerBanDurationFlag,
 			TargetGasLimitFlag,
 			NATFlag,
 			NoDiscoverFlag,
@@ -270,6 +289,9 @@ var FlagGroups = []FlagGroup{
 			VMLogTargetFlag,
 			VMTraceInternalTxFlag,
 			VMOpDebugFlag,
+			VMParallelExecutionFlag,
+			VMTraceFlag,
+			VMTraceJsonConfigFlag,
 		},
 	},
 	{
@@ -278,6 +300,8 @@ var FlagGroups = []FlagGroup{
 			RPCEnabledFlag,
 			HeavyDebugRequestLimitFlag,
 			StateRegenerationTimeLimitFlag,
+			StateRegenCacheSizeFlag,
+			StateRegenReexecFlag,
 			RPCListenAddrFlag,
 			RPCPortFlag,
 			RPCCORSDomainFlag,
@@ -367,6 +391,9 @@ var FlagGroups = []FlagGroup{
 			SnapshotFlag,
 			SnapshotCacheSizeFlag,
 			SnapshotAsyncGen,
+			SnapServeRateFlag,
+			SnapServeMaxRequestsFlag,
+			LightServeFlag,
 			DocRootFlag,
 		},
 	},
@@ -32,14 +32,19 @@ import (
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/file"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kafka"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/postgres"
 	"github.com/kaiachain/kaia/datasync/dbsyncer"
+	"github.com/kaiachain/kaia/datasync/downloader"
+	"github.com/kaiachain/kaia/kaiax/statediff"
 	"github.com/kaiachain/kaia/log"
 	metricutils "github.com/kaiachain/kaia/metrics/utils"
 	"github.com/kaiachain/kaia/networks/rpc"
 	"github.com/kaiachain/kaia/node"
 	"github.com/kaiachain/kaia/node/cn"
 	"github.com/kaiachain/kaia/node/cn/filters"
+	"github.com/kaiachain/kaia/node/light"
 	"github.com/kaiachain/kaia/node/sc"
 	"github.com/kaiachain/kaia/params"
 	"github.com/kaiachain/kaia/storage/database"
@@ -167,6 +172,34 @@ var (
 		EnvVars:  []string{"KLAYTN_SYNCMODE", "KAIA_SYNCMODE"},
 		Category: "KAIA",
 	}
+	CheckpointNumberFlag = &cli.Uint64Flag{
+		Name:     "checkpoint.number",
+		Usage:    "Number of the trusted block pinning the snap sync pivot (with --checkpoint.hash)",
+		Aliases:  []string{"common.checkpoint.number"},
+		EnvVars:  []string{"KLAYTN_CHECKPOINT_NUMBER", "KAIA_CHECKPOINT_NUMBER"},
+		Category: "KAIA",
+	}
+	CheckpointHashFlag = &cli.StringFlag{
+		Name:     "checkpoint.hash",
+		Usage:    "Hash of the trusted block pinning the snap sync pivot (with --checkpoint.number)",
+		Aliases:  []string{"common.checkpoint.hash"},
+		EnvVars:  []string{"KLAYTN_CHECKPOINT_HASH", "KAIA_CHECKPOINT_HASH"},
+		Category: "KAIA",
+	}
+	CheckpointFileFlag = &cli.StringFlag{
+		Name:     "checkpoint.file",
+		Usage:    "Signed checkpoint file pinning the snap sync pivot (with --checkpoint.signers)",
+		Aliases:  []string{"common.checkpoint.file"},
+		EnvVars:  []string{"KLAYTN_CHECKPOINT_FILE", "KAIA_CHECKPOINT_FILE"},
+		Category: "KAIA",
+	}
+	CheckpointSignersFlag = &cli.StringFlag{
+		Name:     "checkpoint.signers",
+		Usage:    "Comma separated addresses trusted to sign the checkpoint file",
+		Aliases:  []string{"common.checkpoint.signers"},
+		EnvVars:  []string{"KLAYTN_CHECKPOINT_SIGNERS", "KAIA_CHECKPOINT_SIGNERS"},
+		Category: "KAIA",
+	}
 	GCModeFlag = &cli.StringFlag{
 		Name:     "gcmode",
 		Usage:    `Blockchain garbage collection mode ("full", "archive")`,
@@ -548,6 +581,29 @@ var (
 		EnvVars:  []string{"KLAYTN_SNAPSHOT_BACKGROUND_GENERATION", "KAIA_SNAPSHOT_BACKGROUND_GENERATION"},
 		Category: "MISC",
 	}
+	SnapServeRateFlag = &cli.Uint64Flag{
+		Name:     "snap.serve-rate",
+		Usage:    "Bytes of snap sync responses served to each peer per second, truncating or emptying the responses beyond it (0 = unlimited)",
+		Value:    0,
+		Aliases:  []string{"snap-serving.rate"},
+		EnvVars:  []string{"KLAYTN_SNAP_SERVE_RATE", "KAIA_SNAP_SERVE_RATE"},
+		Category: "MISC",
+	}
+	SnapServeMaxRequestsFlag = &cli.IntFlag{
+		Name:     "snap.serve-max-requests",
+		Usage:    "Snap sync requests served concurrently to each peer, answering the requests beyond it with empty responses (0 = one at a time, in order)",
+		Value:    0,
+		Aliases:  []string{"snap-serving.max-requests"},
+		EnvVars:  []string{"KLAYTN_SNAP_SERVE_MAX_REQUESTS", "KAIA_SNAP_SERVE_MAX_REQUESTS"},
+		Category: "MISC",
+	}
+	LightServeFlag = &cli.BoolFlag{
+		Name:     "light.serve",
+		Usage:    "Serve the headers, Merkle proofs and contract codes to the light nodes (ken --syncmode light)",
+		Aliases:  []string{"light-serving.enable"},
+		EnvVars:  []string{"KLAYTN_LIGHT_SERVE", "KAIA_LIGHT_SERVE"},
+		Category: "MISC",
+	}
 	TrieMemoryCacheSizeFlag = &cli.IntFlag{
 		Name:     "state.cache-size",
 		Usage:    "Size of in-memory cache of the global state (in MiB) to flush matured singleton trie nodes to disk",
@@ -587,6 +643,21 @@ var (
 		EnvVars:  []string{"KLAYTN_STATE_LIVE_PRUNING_RETENTION", "KAIA_STATE_LIVE_PRUNING_RETENTION"},
 		Category: "STATE",
 	}
+	StateDiffFlag = &cli.BoolFlag{
+		Name:     "state.diff",
+		Usage:    "Record the account, storage and code changes of every block for debug_getStateDiff",
+		Aliases:  []string{},
+		EnvVars:  []string{"KLAYTN_STATE_DIFF", "KAIA_STATE_DIFF"},
+		Category: "STATE",
+	}
+	StateDiffRetentionFlag = &cli.Uint64Flag{
+		Name:     "state.diff-retention",
+		Usage:    "Number of recent blocks whose state diffs are kept (0 = keep all)",
+		Value:    statediff.DefaultRetention,
+		Aliases:  []string{},
+		EnvVars:  []string{"KLAYTN_STATE_DIFF_RETENTION", "KAIA_STATE_DIFF_RETENTION"},
+		Category: "STATE",
+	}
 	CacheTypeFlag = &cli.IntFlag{
 		Name:     "cache.type",
 		Usage:    "Cache Type: 0=LRUCache, 1=LRUShardCache, 2=FIFOCache",
@@ -795,6 +866,27 @@ var (
 		EnvVars:  []string{"KLAYTN_VM_OPDEBUG", "KAIA_VM_OPDEBUG"},
 		Category: "VIRTUAL MACHINE",
 	}
+	VMParallelExecutionFlag = &cli.BoolFlag{
+		Name:     "vm.parallel",
+		Usage:    "Execute the transactions of the imported blocks optimistically in parallel",
+		Aliases:  []string{},
+		EnvVars:  []string{"KLAYTN_VM_PARALLEL", "KAIA_VM_PARALLEL"},
+		Category: "VIRTUAL MACHINE",
+	}
+	VMTraceFlag = &cli.StringFlag{
+		Name:     "vmtrace",
+		Usage:    "Name of the live tracer which receives every state change of the imported blocks",
+		Aliases:  []string{"vm.trace"},
+		EnvVars:  []string{"KLAYTN_VMTRACE", "KAIA_VMTRACE"},
+		Category: "VIRTUAL MACHINE",
+	}
+	VMTraceJsonConfigFlag = &cli.StringFlag{
+		Name:     "vmtrace.jsonconfig",
+		Usage:    "JSON configuration of the live tracer (e.g. {\"path\":\"statechanges.jsonl\"})",
+		Aliases:  []string{"vm.trace.jsonconfig"},
+		EnvVars:  []string{"KLAYTN_VMTRACE_JSONCONFIG", "KAIA_VMTRACE_JSONCONFIG"},
+		Category: "VIRTUAL MACHINE",
+	}
 
 	// Logging and debug settings
 	MetricsEnabledFlag = &cli.BoolFlag{
@@ -1092,6 +1184,31 @@ var (
 		EnvVars:  []string{"KLAYTN_API_FILTER_GETLOGS_MAXITEMS", "KAIA_API_FILTER_GETLOGS_MAXITEMS"},
 		Category: "API AND CONSOLE",
 	}
+	RewardExportFromFlag = &cli.Uint64Flag{
+		Name:     "reward.export.from",
+		Usage:    "First block number of the reward export range",
+		EnvVars:  []string{"KLAYTN_REWARD_EXPORT_FROM", "KAIA_REWARD_EXPORT_FROM"},
+		Category: "API AND CONSOLE",
+	}
+	RewardExportToFlag = &cli.Uint64Flag{
+		Name:     "reward.export.to",
+		Usage:    "Last block number of the reward export range",
+		EnvVars:  []string{"KLAYTN_REWARD_EXPORT_TO", "KAIA_REWARD_EXPORT_TO"},
+		Category: "API AND CONSOLE",
+	}
+	RewardExportFormatFlag = &cli.StringFlag{
+		Name:     "reward.export.format",
+		Usage:    "Reward export format (csv, jsonl)",
+		Value:    "csv",
+		EnvVars:  []string{"KLAYTN_REWARD_EXPORT_FORMAT", "KAIA_REWARD_EXPORT_FORMAT"},
+		Category: "API AND CONSOLE",
+	}
+	RewardExportOutputFlag = &cli.PathFlag{
+		Name:     "reward.export.output",
+		Usage:    "Reward export output file. Writes to stdout if not set",
+		EnvVars:  []string{"KLAYTN_REWARD_EXPORT_OUTPUT", "KAIA_REWARD_EXPORT_OUTPUT"},
+		Category: "API AND CONSOLE",
+	}
 	UnsafeDebugDisableFlag = &cli.BoolFlag{
 		Name:     "rpc.unsafe-debug.disable",
 		Usage:    "Disable unsafe debug APIs (traceTransaction, traceChain, ...).",
@@ -1110,12 +1227,26 @@ var (
 	}
 	StateRegenerationTimeLimitFlag = &cli.DurationFlag{
 		Name:     "rpc.unsafe-debug.state-regeneration.time-limit",
-		Usage:    "Limit the state regeneration time. Works with unsafe-debug only.",
+		Usage:    "Limit the state regeneration time. Always applies to state APIs, and to debug APIs with unsafe-debug only.",
 		Value:    60 * time.Second,
 		Aliases:  []string{},
 		EnvVars:  []string{"KLAYTN_RPC_UNSAFE_DEBUG_STATE_REGENERATION_TIME_LIMIT", "KAIA_RPC_UNSAFE_DEBUG_STATE_REGENERATION_TIME_LIMIT"},
 		Category: "API AND CONSOLE",
 	}
+	StateRegenCacheSizeFlag = &cli.IntFlag{
+		Name:     "rpc.state-regeneration.cache-size",
+		Usage:    "Memory budget (MB) of the regenerated historical states shared by tracers and state APIs (0 = disabled)",
+		Value:    cn.GetDefaultConfig().StateRegenCacheSize,
+		EnvVars:  []string{"KLAYTN_RPC_STATE_REGENERATION_CACHE_SIZE", "KAIA_RPC_STATE_REGENERATION_CACHE_SIZE"},
+		Category: "API AND CONSOLE",
+	}
+	StateRegenReexecFlag = &cli.Uint64Flag{
+		Name:     "rpc.state-regeneration.reexec",
+		Usage:    "Maximum number of blocks re-executed to serve state APIs (e.g. call, getBalance) at pruned blocks (0 = disabled)",
+		Value:    cn.GetDefaultConfig().StateRegenReexec,
+		EnvVars:  []string{"KLAYTN_RPC_STATE_REGENERATION_REEXEC", "KAIA_RPC_STATE_REGENERATION_REEXEC"},
+		Category: "API AND CONSOLE",
+	}
 
 	// Network Settings
 	NodeTypeFlag = &cli.StringFlag{
@@ -1141,6 +1272,22 @@ var (
 		EnvVars:  []string{"KLAYTN_MAXPENDPEERS", "KAIA_MAXPENDPEERS"},
 		Category: "NETWORK",
 	}
+	PeerBanThresholdFlag = &cli.IntFlag{
+		Name:     "peerbanthreshold",
+		Usage:    "Accumulated misbehavior penalty above which a peer is banned (defaults used if set to 0)",
+		Value:    0,
+		Aliases:  []string{"p2p.peer-ban-threshold"},
+		EnvVars:  []string{"KLAYTN_PEERBANTHRESHOLD", "KAIA_PEERBANTHRESHOLD"},
+		Category: "NETWORK",
+	}
+	PeerBanDurationFlag = &cli.DurationFlag{
+		Name:     "peerbanduration",
+		Usage:    "Duration of the ban of a misbehaving peer (defaults used if set to 0)",
+		Value:    0,
+		Aliases:  []string{"p2p.peer-ban-duration"},
+		EnvVars:  []string{"KLAYTN_PEERBANDURATION", "KAIA_PEERBANDURATION"},
+		Category: "NETWORK",
+	}
 	ListenPortFlag = &cli.IntFlag{
 		Name:     "port",
 		Usage:    "Network listening port",
@@ -1172,6 +1319,14 @@ var (
 		EnvVars:  []string{"KLAYTN_BOOTNODES", "KAIA_BOOTNODES"},
 		Category: "NETWORK",
 	}
+	DNSDiscoveryFlag = &cli.StringFlag{
+		Name:     "dnsdiscovery",
+		Usage:    "Comma separated kaiatree:// URLs of DNS node lists used as dial candidates",
+		Value:    "",
+		Aliases:  []string{"p2p.dns-discovery"},
+		EnvVars:  []string{"KLAYTN_DNSDISCOVERY", "KAIA_DNSDISCOVERY"},
+		Category: "NETWORK",
+	}
 	NodeKeyFileFlag = &cli.StringFlag{
 		Name:     "nodekey",
 		Usage:    "P2P node key file",
@@ -1478,7 +1633,7 @@ var (
 	}
 	ChainDataFetcherMode = &cli.StringFlag{
 		Name:     "chaindatafetcher.mode",
-		Usage:    "The mode of chaindatafetcher (\"kas\", \"kafka\")",
+		Usage:    "The mode of chaindatafetcher (\"kas\", \"kafka\", \"file\", \"postgres\")",
 		Value:    "kas",
 		Aliases:  []string{"chain-data-fetcher.mode"},
 		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_MODE", "KAIA_CHAINDATAFETCHER_MODE"},
@@ -1666,6 +1821,81 @@ var (
 		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_KAFKA_PRODUCER_ID", "KAIA_CHAINDATAFETCHER_KAFKA_PRODUCER_ID"},
 		Category: "CHAINDATAFETCHER",
 	}
+	ChainDataFetcherFileDirFlag = &cli.PathFlag{
+		Name:     "chaindatafetcher.file.dir",
+		Usage:    "Directory where the chaindata files are stored (default = inside the datadir)",
+		Aliases:  []string{"chain-data-fetcher.file.dir"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_FILE_DIR", "KAIA_CHAINDATAFETCHER_FILE_DIR"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherFileMaxSizeFlag = &cli.Int64Flag{
+		Name:     "chaindatafetcher.file.max.size",
+		Usage:    "The size of uncompressed data (in byte) at which a chaindata file is rotated",
+		Value:    file.DefaultMaxFileSize,
+		Aliases:  []string{"chain-data-fetcher.file.max-size"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_FILE_MAX_SIZE", "KAIA_CHAINDATAFETCHER_FILE_MAX_SIZE"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherFileMaxAgeFlag = &cli.DurationFlag{
+		Name:     "chaindatafetcher.file.max.age",
+		Usage:    "The time since a chaindata file is created at which the file is rotated",
+		Value:    file.DefaultMaxFileAge,
+		Aliases:  []string{"chain-data-fetcher.file.max-age"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_FILE_MAX_AGE", "KAIA_CHAINDATAFETCHER_FILE_MAX_AGE"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherFileCompressFlag = &cli.BoolFlag{
+		Name:     "chaindatafetcher.file.compress",
+		Usage:    "Compress chaindata files with gzip",
+		Value:    file.DefaultCompress,
+		Aliases:  []string{"chain-data-fetcher.file.compress"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_FILE_COMPRESS", "KAIA_CHAINDATAFETCHER_FILE_COMPRESS"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherPostgresDBHostFlag = &cli.StringFlag{
+		Name:     "chaindatafetcher.postgres.db.host",
+		Usage:    "PostgreSQL DB host in chaindatafetcher",
+		Aliases:  []string{"chain-data-fetcher.postgres.db.host"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_POSTGRES_DB_HOST", "KAIA_CHAINDATAFETCHER_POSTGRES_DB_HOST"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherPostgresDBPortFlag = &cli.StringFlag{
+		Name:     "chaindatafetcher.postgres.db.port",
+		Usage:    "PostgreSQL DB port in chaindatafetcher",
+		Value:    postgres.DefaultDBPort,
+		Aliases:  []string{"chain-data-fetcher.postgres.db.port"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_POSTGRES_DB_PORT", "KAIA_CHAINDATAFETCHER_POSTGRES_DB_PORT"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherPostgresDBNameFlag = &cli.StringFlag{
+		Name:     "chaindatafetcher.postgres.db.name",
+		Usage:    "PostgreSQL DB name in chaindatafetcher",
+		Aliases:  []string{"chain-data-fetcher.postgres.db.name"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_POSTGRES_DB_NAME", "KAIA_CHAINDATAFETCHER_POSTGRES_DB_NAME"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherPostgresDBUserFlag = &cli.StringFlag{
+		Name:     "chaindatafetcher.postgres.db.user",
+		Usage:    "PostgreSQL DB user in chaindatafetcher",
+		Aliases:  []string{"chain-data-fetcher.postgres.db.user"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_POSTGRES_DB_USER", "KAIA_CHAINDATAFETCHER_POSTGRES_DB_USER"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherPostgresDBPasswordFlag = &cli.StringFlag{
+		Name:     "chaindatafetcher.postgres.db.password",
+		Usage:    "PostgreSQL DB password in chaindatafetcher",
+		Aliases:  []string{"chain-data-fetcher.postgres.db.password"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_POSTGRES_DB_PASSWORD", "KAIA_CHAINDATAFETCHER_POSTGRES_DB_PASSWORD"},
+		Category: "CHAINDATAFETCHER",
+	}
+	ChainDataFetcherPostgresDBSSLModeFlag = &cli.StringFlag{
+		Name:     "chaindatafetcher.postgres.db.sslmode",
+		Usage:    "PostgreSQL DB sslmode in chaindatafetcher (\"disable\", \"require\", \"verify-ca\", \"verify-full\")",
+		Value:    postgres.DefaultSSLMode,
+		Aliases:  []string{"chain-data-fetcher.postgres.db.sslmode"},
+		EnvVars:  []string{"KLAYTN_CHAINDATAFETCHER_POSTGRES_DB_SSLMODE", "KAIA_CHAINDATAFETCHER_POSTGRES_DB_SSLMODE"},
+		Category: "CHAINDATAFETCHER",
+	}
 	// DBSyncer
 	EnableDBSyncerFlag = &cli.BoolFlag{
 		Name:     "dbsyncer",
@@ -2085,13 +2315,25 @@ func MakePasswordList(ctx *cli.Context) []string {
 	return lines
 }
 
-// RegisterCNService adds a CN client to the stack.
+// RegisterCNService adds a CN client to the stack, or a light client if
+// the light sync is requested.
 func RegisterCNService(stack *node.Node, cfg *cn.Config) {
-	// TODO-Kaia add syncMode.LightSync func and add LesServer
+	if cfg.SyncMode == downloader.LightSync {
+		err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			return light.New(ctx, cfg)
+		})
+		if err != nil {
+			log.Fatalf("Failed to register the light service: %v", err)
+		}
+		return
+	}
 
 	err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		cfg.WsEndpoint = stack.WSEndpoint()
 		fullNode, err := cn.New(ctx, cfg)
+		if err == nil && cfg.LightServe {
+			fullNode.AddLesServer(light.NewServer(fullNode.BlockChain(), cfg.NetworkId))
+		}
 		return fullNode, err
 	})
 	if err != nil {
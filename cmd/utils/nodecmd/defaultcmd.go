@@ -37,6 +37,7 @@ import (
 	metricutils "github.com/kaiachain/kaia/metrics/utils"
 	"github.com/kaiachain/kaia/node"
 	"github.com/kaiachain/kaia/node/cn"
+	"github.com/kaiachain/kaia/node/light"
 	"github.com/kaiachain/kaia/params"
 	"github.com/urfave/cli/v2"
 	"github.com/urfave/cli/v2/altsrc"
@@ -149,6 +150,12 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 }
 
 func startKaiaAuxiliaryService(ctx *cli.Context, stack *node.Node) {
+	// A light node has nothing to mine
+	var lightNode *light.LightKaia
+	if err := stack.Service(&lightNode); err == nil {
+		return
+	}
+
 	var cn *cn.CN
 	if err := stack.Service(&cn); err != nil {
 		log.Fatalf("Kaia service not running: %v", err)
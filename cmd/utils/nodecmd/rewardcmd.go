@@ -0,0 +1,99 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package nodecmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/kaiachain/kaia/cmd/utils"
+	"github.com/kaiachain/kaia/kaiax/reward"
+	reward_impl "github.com/kaiachain/kaia/kaiax/reward/impl"
+	"github.com/kaiachain/kaia/networks/rpc"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// rewardExportBatchSize is the number of blocks requested per governance_getRewardLines call.
+const rewardExportBatchSize = uint64(100)
+
+var RewardCommand = &cli.Command{
+	Name:     "reward",
+	Usage:    "A set of commands for block rewards",
+	Category: "MISCELLANEOUS COMMANDS",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "export",
+			Usage:     "Export per-recipient block rewards of a block range (connect to node)",
+			ArgsUsage: "[endpoint]",
+			Action:    exportRewards,
+			Flags:     utils.RewardExportFlags,
+			Description: `
+This command fetches the reward lines of the blocks in [from, to] from a running node
+and writes one row per block, category and recipient in CSV or JSONL format.
+The categories are minted, burntFee, proposer, staker, kif and kef.
+The endpoint defaults to the IPC endpoint of the data directory.`,
+		},
+	},
+}
+
+func exportRewards(ctx *cli.Context) error {
+	from, to := ctx.Uint64(utils.RewardExportFromFlag.Name), ctx.Uint64(utils.RewardExportToFlag.Name)
+	if from > to {
+		return reward.ErrInvalidBlockRange
+	}
+
+	var out io.Writer = os.Stdout
+	if path := ctx.Path(utils.RewardExportOutputFlag.Name); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w, err := reward_impl.NewRewardLineWriter(out, ctx.String(utils.RewardExportFormatFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	client, err := dialRPC(rpcEndpoint(ctx))
+	if err != nil {
+		return errors.Wrap(err, "unable to attach to remote node")
+	}
+	defer client.Close()
+
+	for lower := from; lower <= to; lower += rewardExportBatchSize {
+		upper := lower + rewardExportBatchSize - 1
+		if upper > to || upper < lower {
+			upper = to
+		}
+
+		var lines []*reward.RewardLine
+		if err := client.Call(&lines, "governance_getRewardLines", rpc.BlockNumber(lower), rpc.BlockNumber(upper)); err != nil {
+			return errors.Wrapf(err, "failed to get reward lines of blocks %d-%d", lower, upper)
+		}
+		if err := w.Write(lines); err != nil {
+			return err
+		}
+		if upper == to {
+			break
+		}
+	}
+	return w.Flush()
+}
@@ -161,6 +161,15 @@ var ConsoleFlags = []cli.Flag{
 	altsrc.NewStringFlag(PreloadJSFlag),
 }
 
+var RewardExportFlags = []cli.Flag{
+	RewardExportFromFlag,
+	RewardExportToFlag,
+	RewardExportFormatFlag,
+	RewardExportOutputFlag,
+	DataDirFlag,
+	KairosFlag,
+}
+
 // Common flags that configure the node
 var CommonNodeFlags = []cli.Flag{
 	ConfFlag,
@@ -168,6 +177,7 @@ var CommonNodeFlags = []cli.Flag{
 	altsrc.NewStringFlag(NtpServerFlag),
 	altsrc.NewPathFlag(DocRootFlag),
 	altsrc.NewStringFlag(BootnodesFlag),
+	altsrc.NewStringFlag(DNSDiscoveryFlag),
 	altsrc.NewStringFlag(IdentityFlag),
 	altsrc.NewStringFlag(UnlockedAccountFlag),
 	altsrc.NewStringFlag(PasswordFileFlag),
@@ -191,6 +201,10 @@ var CommonNodeFlags = []cli.Flag{
 	altsrc.NewDurationFlag(TxPoolLifetimeFlag),
 	altsrc.NewBoolFlag(TxPoolKeepLocalsFlag),
 	NewWrappedTextMarshalerFlag(SyncModeFlag),
+	altsrc.NewUint64Flag(CheckpointNumberFlag),
+	altsrc.NewStringFlag(CheckpointHashFlag),
+	altsrc.NewStringFlag(CheckpointFileFlag),
+	altsrc.NewStringFlag(CheckpointSignersFlag),
 	altsrc.NewStringFlag(GCModeFlag),
 	altsrc.NewBoolFlag(LightKDFFlag),
 	altsrc.NewBoolFlag(SingleDBFlag),
@@ -226,6 +240,8 @@ var CommonNodeFlags = []cli.Flag{
 	altsrc.NewUint64Flag(TriesInMemoryFlag),
 	altsrc.NewBoolFlag(LivePruningFlag),
 	altsrc.NewUint64Flag(LivePruningRetentionFlag),
+	altsrc.NewBoolFlag(StateDiffFlag),
+	altsrc.NewUint64Flag(StateDiffRetentionFlag),
 	altsrc.NewIntFlag(CacheTypeFlag),
 	altsrc.NewIntFlag(CacheScaleFlag),
 	altsrc.NewStringFlag(CacheUsageLevelFlag),
@@ -245,6 +261,8 @@ var CommonNodeFlags = []cli.Flag{
 	altsrc.NewIntFlag(MaxConnectionsFlag),
 	altsrc.NewIntFlag(MaxRequestContentLengthFlag),
 	altsrc.NewIntFlag(MaxPendingPeersFlag),
+	altsrc.NewIntFlag(PeerBanThresholdFlag),
+	altsrc.NewDurationFlag(PeerBanDurationFlag),
 	altsrc.NewUint64Flag(TargetGasLimitFlag),
 	altsrc.NewStringFlag(NATFlag),
 	altsrc.NewBoolFlag(NoDiscoverFlag),
@@ -259,6 +277,9 @@ var CommonNodeFlags = []cli.Flag{
 	altsrc.NewIntFlag(VMLogTargetFlag),
 	altsrc.NewBoolFlag(VMTraceInternalTxFlag),
 	altsrc.NewBoolFlag(VMOpDebugFlag),
+	altsrc.NewBoolFlag(VMParallelExecutionFlag),
+	altsrc.NewStringFlag(VMTraceFlag),
+	altsrc.NewStringFlag(VMTraceJsonConfigFlag),
 	altsrc.NewUint64Flag(NetworkIdFlag),
 	altsrc.NewBoolFlag(MetricsEnabledFlag),
 	altsrc.NewBoolFlag(PrometheusExporterFlag),
@@ -275,6 +296,9 @@ var CommonNodeFlags = []cli.Flag{
 	altsrc.NewBoolFlag(SnapshotFlag),
 	altsrc.NewIntFlag(SnapshotCacheSizeFlag),
 	altsrc.NewBoolFlag(SnapshotAsyncGen),
+	altsrc.NewUint64Flag(SnapServeRateFlag),
+	altsrc.NewIntFlag(SnapServeMaxRequestsFlag),
+	altsrc.NewBoolFlag(LightServeFlag),
 	altsrc.NewIntFlag(GpoBlocksFlag),
 	altsrc.NewIntFlag(GpoPercentileFlag),
 	altsrc.NewInt64Flag(GpoMaxGasPriceFlag),
@@ -314,6 +338,8 @@ var CommonRPCFlags = []cli.Flag{
 	altsrc.NewBoolFlag(UnsafeDebugDisableFlag),
 	altsrc.NewIntFlag(HeavyDebugRequestLimitFlag),
 	altsrc.NewDurationFlag(StateRegenerationTimeLimitFlag),
+	altsrc.NewIntFlag(StateRegenCacheSizeFlag),
+	altsrc.NewUint64Flag(StateRegenReexecFlag),
 	altsrc.NewStringFlag(RPCUpstreamArchiveENFlag),
 }
 
@@ -514,6 +540,9 @@ var SnapshotFlags = []cli.Flag{
 	altsrc.NewBoolFlag(RocksDBCacheIndexAndFilterFlag),
 }
 
+// SupplyAuditFlags are the flags to open the database for the supply audit.
+var SupplyAuditFlags = SnapshotFlags
+
 var DBMigrationSrcFlags = []cli.Flag{
 	altsrc.NewStringFlag(DbTypeFlag),
 	altsrc.NewPathFlag(DataDirFlag),
@@ -590,4 +619,14 @@ var ChainDataFetcherFlags = []cli.Flag{
 	altsrc.NewIntFlag(ChainDataFetcherKafkaRequiredAcksFlag),
 	altsrc.NewStringFlag(ChainDataFetcherKafkaMessageVersionFlag),
 	altsrc.NewStringFlag(ChainDataFetcherKafkaProducerIdFlag),
+	altsrc.NewPathFlag(ChainDataFetcherFileDirFlag),
+	altsrc.NewInt64Flag(ChainDataFetcherFileMaxSizeFlag),
+	altsrc.NewDurationFlag(ChainDataFetcherFileMaxAgeFlag),
+	altsrc.NewBoolFlag(ChainDataFetcherFileCompressFlag),
+	altsrc.NewStringFlag(ChainDataFetcherPostgresDBHostFlag),
+	altsrc.NewStringFlag(ChainDataFetcherPostgresDBPortFlag),
+	altsrc.NewStringFlag(ChainDataFetcherPostgresDBNameFlag),
+	altsrc.NewStringFlag(ChainDataFetcherPostgresDBUserFlag),
+	altsrc.NewStringFlag(ChainDataFetcherPostgresDBPasswordFlag),
+	altsrc.NewStringFlag(ChainDataFetcherPostgresDBSSLModeFlag),
 }
@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/consensus"
@@ -431,5 +432,5 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 	// Accumulate the rewards for the miner
 	reward := new(big.Int).Set(blockReward)
 
-	state.AddBalance(params.AuthorAddressForTesting, reward)
+	state.AddBalance(params.AuthorAddressForTesting, reward, tracing.BalanceIncreaseBlockReward)
 }
@@ -25,6 +25,7 @@ package backend
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -34,7 +35,11 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/kaiachain/kaia/accounts/abi/bind"
+	"github.com/kaiachain/kaia/accounts/abi/bind/backends"
 	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/system"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
@@ -42,6 +47,7 @@ import (
 	"github.com/kaiachain/kaia/consensus"
 	"github.com/kaiachain/kaia/consensus/istanbul"
 	"github.com/kaiachain/kaia/consensus/istanbul/core"
+	"github.com/kaiachain/kaia/contracts/contracts/testing/system_contracts"
 	"github.com/kaiachain/kaia/crypto"
 	"github.com/kaiachain/kaia/kaiax/gov"
 	"github.com/kaiachain/kaia/kaiax/gov/headergov"
@@ -73,6 +79,7 @@ type (
 	shanghaiCompatibleBlock  *big.Int
 	cancunCompatibleBlock    *big.Int
 	kaiaCompatibleBlock      *big.Int
+	kip160CompatibleBlock    *big.Int
 )
 
 type (
@@ -85,6 +92,7 @@ type (
 	epoch                  uint64
 	subGroupSize           uint64
 	blockPeriod            uint64
+	kip160ContractAddress  common.Address
 )
 
 // makeCommittedSeals returns a list of committed seals for the global variable nodeKeys.
@@ -169,6 +177,14 @@ func newBlockChain(n int, items ...interface{}) (*blockchain.BlockChain, *backen
 			genesis.Config.CancunCompatibleBlock = v
 		case kaiaCompatibleBlock:
 			genesis.Config.KaiaCompatibleBlock = v
+		case kip160CompatibleBlock:
+			genesis.Config.Kip160CompatibleBlock = v
+		case kip160ContractAddress:
+			genesis.Config.Kip160ContractAddress = common.Address(v)
+		case blockchain.GenesisAlloc:
+			for addr, account := range v {
+				genesis.Alloc[addr] = account
+			}
 		case proposerPolicy:
 			genesis.Config.Istanbul.ProposerPolicy = uint64(v)
 		case epoch:
@@ -819,6 +835,153 @@ func TestRewardDistribution(t *testing.T) {
 	}
 }
 
+// TestExecutionWitness checks that the witness of an Istanbul block covers the state read by
+// the block rewards through the staking module and by the KIP-160 treasury rebalance.
+func TestExecutionWitness(t *testing.T) {
+	var (
+		kip160Block = uint64(2)
+		kip160Addr  = common.HexToAddress("0xff1600")
+		zeroeds     = []common.Address{common.HexToAddress("0xa000"), common.HexToAddress("0xb000")}
+		alloc       = blockchain.GenesisAlloc{
+			// The AddressBook lists two staking contracts with the balances below.
+			system.AddressBookAddr: {Code: system.AddressBookMockTwoCNCode, Balance: common.Big0},
+			common.HexToAddress("0x0000000000000000000000000000000000000F01"): {
+				Balance: new(big.Int).Mul(big.NewInt(42_000_000), big.NewInt(params.KAIA)),
+			},
+			common.HexToAddress("0x0000000000000000000000000000000000000f04"): {
+				Balance: new(big.Int).Mul(big.NewInt(99_000_000), big.NewInt(params.KAIA)),
+			},
+			zeroeds[0]: {Balance: big.NewInt(params.KAIA)},
+			zeroeds[1]: {Balance: big.NewInt(params.KAIA)},
+			kip160Addr: kip160MockAccount(t, kip160Addr, zeroeds, kip160Block),
+		}
+	)
+
+	configItems := []interface{}{
+		proposerPolicy(istanbul.WeightedRandom),
+		minimumStake(common.Big0),
+		mintingAmount(big.NewInt(params.KAIA)), // distributed to the stakers and the funds in the AddressBook
+		istanbulCompatibleBlock(common.Big0),
+		LondonCompatibleBlock(common.Big0),
+		EthTxTypeCompatibleBlock(common.Big0),
+		magmaCompatibleBlock(common.Big0),
+		koreCompatibleBlock(common.Big0),
+		shanghaiCompatibleBlock(common.Big0),
+		cancunCompatibleBlock(common.Big0),
+		kaiaCompatibleBlock(common.Big0),
+		kip160CompatibleBlock(new(big.Int).SetUint64(kip160Block)),
+		kip160ContractAddress(kip160Addr),
+		alloc,
+		blockPeriod(0), // set block period to 0 to prevent creating future block
+	}
+	chain, engine := newBlockChain(1, configItems...)
+	chain.RegisterExecutionModule(engine.govModule)
+	engine.RegisterConsensusModule(engine.govModule)
+	defer engine.Stop()
+
+	// The staking module can not read the state of its chain once the blocks are inserted,
+	// so that the stateless execution can only get the staking info from the witness.
+	stakingChain := &statelessStakingChain{BlockChain: chain}
+	mStaking := staking_impl.NewStakingModule()
+	require.NoError(t, mStaking.Init(&staking_impl.InitOpts{
+		ChainKv:     chain.StateCache().TrieDB().DiskDB().GetMiscDB(),
+		ChainConfig: chain.Config(),
+		Chain:       stakingChain,
+	}))
+	engine.RegisterStakingModule(mStaking)
+	mReward := reward_impl.NewRewardModule()
+	require.NoError(t, mReward.Init(&reward_impl.InitOpts{
+		ChainConfig:   chain.Config(),
+		Chain:         chain,
+		GovModule:     engine.govModule,
+		StakingModule: mStaking,
+	}))
+	engine.RegisterConsensusModule(mReward)
+
+	var blocks types.Blocks
+	for parent := chain.Genesis(); len(blocks) < 3; parent = blocks[len(blocks)-1] {
+		block := makeBlockWithSeal(chain, engine, parent)
+		_, err := chain.InsertChain(types.Blocks{block})
+		require.NoError(t, err)
+		blocks = append(blocks, block)
+	}
+	state, err := chain.StateAt(blocks[kip160Block-1].Root())
+	require.NoError(t, err)
+	for _, addr := range zeroeds {
+		require.NotEqual(t, big.NewInt(params.KAIA), state.GetBalance(addr), "treasury not rebalanced")
+	}
+	stakingChain.stateless = true
+
+	for _, block := range blocks {
+		witness, err := chain.ExecutionWitness(block, mStaking)
+		require.NoError(t, err)
+		assert.Contains(t, witness.Codes, hexutil.Bytes(system.AddressBookMockTwoCNCode))
+		if block.NumberU64() == kip160Block {
+			assert.Contains(t, witness.Codes, hexutil.Bytes(system.Kip160MockCode))
+		}
+
+		// Purge the staking info cached during the insertion before each execution.
+		require.NoError(t, mStaking.Start())
+		receipts, err := blockchain.ExecuteStateless(chain.Config(), engine, block, witness, mStaking)
+		require.NoError(t, err, "block %d", block.NumberU64())
+		assert.Len(t, receipts, len(chain.GetReceiptsByBlockHash(block.Hash())))
+
+		// The staking info is not available without the parent state from the witness.
+		require.NoError(t, mStaking.Start())
+		_, err = blockchain.ExecuteStateless(chain.Config(), engine, block, witness)
+		assert.Error(t, err, "block %d", block.NumberU64())
+
+		// The contract codes are needed for the execution.
+		codes := witness.Codes
+		witness.Codes = nil
+		require.NoError(t, mStaking.Start())
+		_, err = blockchain.ExecuteStateless(chain.Config(), engine, block, witness, mStaking)
+		assert.Error(t, err, "block %d", block.NumberU64())
+		witness.Codes = codes
+	}
+}
+
+// statelessStakingChain fails the state reads of the staking module once stateless is set.
+type statelessStakingChain struct {
+	*blockchain.BlockChain
+	stateless bool
+}
+
+func (c *statelessStakingChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	if c.stateless {
+		return nil, errors.New("state is not available")
+	}
+	return c.BlockChain.StateAt(root)
+}
+
+// kip160MockAccount returns the KIP-160 mock contract approving the rebalance of
+// the zeroeds at the given block.
+func kip160MockAccount(t *testing.T, addr common.Address, zeroeds []common.Address, blockNum uint64) blockchain.GenesisAccount {
+	key, _ := crypto.GenerateKey()
+	backend := backends.NewSimulatedBackend(blockchain.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(params.KAIA)},
+		addr:                                  {Balance: common.Big0, Code: system.Kip160MockCode},
+	})
+	defer backend.Close()
+
+	contract, err := system_contracts.NewTreasuryRebalanceMockV2Transactor(addr, backend)
+	require.NoError(t, err)
+	amounts := []*big.Int{big.NewInt(params.KAIA / 2), big.NewInt(params.KAIA / 4)}
+	_, err = contract.TestSetAll(bind.NewKeyedTransactor(key), zeroeds, zeroeds, amounts,
+		new(big.Int).SetUint64(blockNum), system.EnumRebalanceStatus_Approved)
+	require.NoError(t, err)
+	backend.Commit()
+
+	storage := make(map[common.Hash]common.Hash)
+	state, err := backend.BlockChain().State()
+	require.NoError(t, err)
+	state.ForEachStorage(addr, func(key, value common.Hash) bool {
+		storage[key] = value
+		return true
+	})
+	return blockchain.GenesisAccount{Balance: common.Big0, Code: system.Kip160MockCode, Storage: storage}
+}
+
 func makeSnapshotTestConfigItems(stakingInterval, proposerInterval uint64) []interface{} {
 	return []interface{}{
 		stakingUpdateInterval(stakingInterval),
@@ -0,0 +1,166 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus/istanbul"
+	"github.com/kaiachain/kaia/crypto/sha3"
+	"github.com/kaiachain/kaia/rlp"
+)
+
+var (
+	// ErrInvalidParent is returned if the header is not linked to the parent.
+	ErrInvalidParent = errors.New("header not linked to the parent")
+	// ErrUnauthorizedProposer is returned if the proposer is not one of the validators.
+	ErrUnauthorizedProposer = errors.New("proposer not in the validator set")
+	// ErrInvalidCommittedSeals is returned if the committed seals don't reach a quorum
+	// of the committee.
+	ErrInvalidCommittedSeals = errors.New("invalid committed seals")
+	// ErrUntrustedValidators is returned if a change of the validator set is not
+	// committed by enough trusted validators.
+	ErrUntrustedValidators = errors.New("validator set change not signed by the trusted validators")
+)
+
+// CommitteeSize returns the size of the committee selected from the validators,
+// the same as the one used by RequiredMessageCount.
+func CommitteeSize(validators int, committeeSize uint64) uint64 {
+	if committeeSize > 0 && uint64(validators) > committeeSize {
+		return committeeSize
+	}
+	return uint64(validators)
+}
+
+// sigHash returns the hash signed by the proposer, the hash of the header
+// without the seals.
+func sigHash(header *types.Header) (hash common.Hash) {
+	hasher := sha3.NewKeccak256()
+	rlp.Encode(hasher, types.IstanbulFilteredHeader(header, false))
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+// HeaderAuthor returns the proposer of the header.
+func HeaderAuthor(header *types.Header) (common.Address, error) {
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return istanbul.GetSignatureAddress(sigHash(header).Bytes(), extra.Seal)
+}
+
+// VerifyCommittedHeader verifies the link to the parent and the Istanbul seals
+// of the header without the state, and returns the validators declared in it.
+//
+// The validators declared in the header are the qualified validators of the
+// block, without the demoted ones, from which its committee is selected. The
+// committed seals must reach the quorum of that committee, as
+// RequiredMessageCount does. If the declared validators differ from the
+// trusted ones, the ones followed from a trusted header, the trusted committee
+// must have committed the header with more seals than it may miss while
+// reaching its quorum.
+func VerifyCommittedHeader(parent, header *types.Header, trusted []common.Address, committeeSize uint64) ([]common.Address, error) {
+	if header.Number.Uint64() != parent.Number.Uint64()+1 || header.ParentHash != parent.Hash() {
+		return nil, fmt.Errorf("%w: header %d (%x), parent %d (%x)", ErrInvalidParent, header.Number, header.ParentHash, parent.Number, parent.Hash())
+	}
+	validators, signers, err := verifySeals(header, committeeSize)
+	if err != nil {
+		return nil, err
+	}
+	trustedSet := make(map[common.Address]bool, len(trusted))
+	for _, addr := range trusted {
+		trustedSet[addr] = true
+	}
+	if !sameValidators(validators, trustedSet) {
+		byTrust := 0
+		for addr := range signers {
+			if trustedSet[addr] {
+				byTrust++
+			}
+		}
+		committee := CommitteeSize(len(trusted), committeeSize)
+		if required := int(committee) - QuorumSize(committee) + 1; byTrust < required {
+			return nil, fmt.Errorf("%w: %d seals, %d required", ErrUntrustedValidators, byTrust, required)
+		}
+	}
+	return validators, nil
+}
+
+// VerifyCommittedSeals verifies the Istanbul seals of the header against the
+// validators declared in it, and returns them. Unlike VerifyCommittedHeader,
+// it doesn't check that the validators follow trusted ones.
+func VerifyCommittedSeals(header *types.Header, committeeSize uint64) ([]common.Address, error) {
+	validators, _, err := verifySeals(header, committeeSize)
+	return validators, err
+}
+
+// verifySeals checks that the header is proposed by one of the validators
+// declared in it and committed by a quorum of their committee. It returns the
+// validators and the signers of the committed seals.
+func verifySeals(header *types.Header, committeeSize uint64) ([]common.Address, map[common.Address]bool, error) {
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	declared := make(map[common.Address]bool, len(extra.Validators))
+	for _, addr := range extra.Validators {
+		declared[addr] = true
+	}
+	// The proposer must be one of the validators
+	proposer, err := HeaderAuthor(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !declared[proposer] {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnauthorizedProposer, proposer.Hex())
+	}
+	// Every committed seal must come from a distinct validator
+	var (
+		proposal = PrepareCommittedSeal(header.Hash())
+		signers  = make(map[common.Address]bool, len(extra.CommittedSeal))
+	)
+	for _, seal := range extra.CommittedSeal {
+		addr, err := istanbul.GetSignatureAddress(proposal, seal)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidCommittedSeals, err)
+		}
+		if !declared[addr] || signers[addr] {
+			return nil, nil, fmt.Errorf("%w: unexpected signer %s", ErrInvalidCommittedSeals, addr.Hex())
+		}
+		signers[addr] = true
+	}
+	if quorum := QuorumSize(CommitteeSize(len(extra.Validators), committeeSize)); len(signers) < quorum {
+		return nil, nil, fmt.Errorf("%w: %d seals, %d required", ErrInvalidCommittedSeals, len(signers), quorum)
+	}
+	return extra.Validators, signers, nil
+}
+
+func sameValidators(validators []common.Address, set map[common.Address]bool) bool {
+	if len(validators) != len(set) {
+		return false
+	}
+	for _, addr := range validators {
+		if !set[addr] {
+			return false
+		}
+	}
+	return true
+}
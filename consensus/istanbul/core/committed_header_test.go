@@ -0,0 +1,197 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testValidators struct {
+	keys  map[common.Address]*ecdsa.PrivateKey
+	addrs []common.Address
+}
+
+func newTestValidators(t *testing.T, n int) *testValidators {
+	vs := &testValidators{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		vs.keys[addr] = key
+		vs.addrs = append(vs.addrs, addr)
+	}
+	sort.Slice(vs.addrs, func(i, j int) bool { return bytes.Compare(vs.addrs[i][:], vs.addrs[j][:]) < 0 })
+	return vs
+}
+
+func (vs *testValidators) signers(addrs ...common.Address) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, 0, len(addrs))
+	for _, addr := range addrs {
+		keys = append(keys, vs.keys[addr])
+	}
+	return keys
+}
+
+func setIstanbulExtra(t *testing.T, header *types.Header, extra *types.IstanbulExtra) {
+	payload, err := rlp.EncodeToBytes(extra)
+	require.NoError(t, err)
+	header.Extra = append(make([]byte, types.IstanbulExtraVanity), payload...)
+}
+
+// makeSealedHeader makes a child of the parent declaring the validators,
+// proposed by the proposer and committed by the committers.
+func makeSealedHeader(t *testing.T, parent *types.Header, validators []common.Address, proposer *ecdsa.PrivateKey, committers []*ecdsa.PrivateKey) *types.Header {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		Time:       new(big.Int).Add(parent.Time, common.Big1),
+		BlockScore: common.Big1,
+	}
+	extra := &types.IstanbulExtra{Validators: validators, Seal: []byte{}, CommittedSeal: [][]byte{}}
+	setIstanbulExtra(t, header, extra)
+
+	seal, err := crypto.Sign(crypto.Keccak256(sigHash(header).Bytes()), proposer)
+	require.NoError(t, err)
+	extra.Seal = seal
+	setIstanbulExtra(t, header, extra)
+
+	proposal := crypto.Keccak256(PrepareCommittedSeal(header.Hash()))
+	for _, key := range committers {
+		seal, err := crypto.Sign(proposal, key)
+		require.NoError(t, err)
+		extra.CommittedSeal = append(extra.CommittedSeal, seal)
+	}
+	setIstanbulExtra(t, header, extra)
+	return header
+}
+
+func TestQuorumSize(t *testing.T) {
+	for size, quorum := range map[uint64]int{1: 1, 2: 2, 3: 3, 4: 3, 6: 4, 7: 5, 22: 15} {
+		assert.Equal(t, quorum, QuorumSize(size), "size %d", size)
+	}
+	assert.Equal(t, uint64(4), CommitteeSize(4, 0))
+	assert.Equal(t, uint64(4), CommitteeSize(4, 22))
+	assert.Equal(t, uint64(3), CommitteeSize(4, 3))
+}
+
+func TestVerifyCommittedHeader(t *testing.T) {
+	vs := newTestValidators(t, 4)
+	genesis := &types.Header{Number: common.Big0, Time: common.Big0, BlockScore: common.Big1}
+	setIstanbulExtra(t, genesis, &types.IstanbulExtra{Validators: vs.addrs, Seal: []byte{}, CommittedSeal: [][]byte{}})
+
+	proposer := vs.keys[vs.addrs[0]]
+	quorum := vs.signers(vs.addrs[:3]...)
+
+	// A header committed by the quorum is valid
+	header := makeSealedHeader(t, genesis, vs.addrs, proposer, quorum)
+	validators, err := VerifyCommittedHeader(genesis, header, vs.addrs, 0)
+	require.NoError(t, err)
+	assert.Equal(t, vs.addrs, validators)
+
+	author, err := HeaderAuthor(header)
+	require.NoError(t, err)
+	assert.Equal(t, vs.addrs[0], author)
+
+	// The header must follow the parent
+	_, err = VerifyCommittedHeader(header, header, vs.addrs, 0)
+	assert.ErrorIs(t, err, ErrInvalidParent)
+
+	// Two seals are short of the quorum of four validators, and of a committee
+	// of three, which requires all of its members
+	header = makeSealedHeader(t, genesis, vs.addrs, proposer, quorum[:2])
+	_, err = VerifyCommittedHeader(genesis, header, vs.addrs, 0)
+	assert.ErrorIs(t, err, ErrInvalidCommittedSeals)
+	_, err = VerifyCommittedHeader(genesis, header, vs.addrs, 3)
+	assert.ErrorIs(t, err, ErrInvalidCommittedSeals)
+
+	// A seal repeated by the same validator counts once
+	header = makeSealedHeader(t, genesis, vs.addrs, proposer, append(quorum[:2:2], quorum[0]))
+	_, err = VerifyCommittedHeader(genesis, header, vs.addrs, 0)
+	assert.ErrorIs(t, err, ErrInvalidCommittedSeals)
+
+	// The seals and the proposal of the outsiders are rejected
+	outsider := newTestValidators(t, 1)
+	header = makeSealedHeader(t, genesis, vs.addrs, proposer, append(quorum, outsider.keys[outsider.addrs[0]]))
+	_, err = VerifyCommittedHeader(genesis, header, vs.addrs, 0)
+	assert.ErrorIs(t, err, ErrInvalidCommittedSeals)
+
+	header = makeSealedHeader(t, genesis, vs.addrs, outsider.keys[outsider.addrs[0]], quorum)
+	_, err = VerifyCommittedHeader(genesis, header, vs.addrs, 0)
+	assert.ErrorIs(t, err, ErrUnauthorizedProposer)
+}
+
+func TestVerifyCommittedHeaderCommittee(t *testing.T) {
+	vs := newTestValidators(t, 7)
+	genesis := &types.Header{Number: common.Big0, Time: common.Big0, BlockScore: common.Big1}
+	setIstanbulExtra(t, genesis, &types.IstanbulExtra{Validators: vs.addrs, Seal: []byte{}, CommittedSeal: [][]byte{}})
+
+	// The quorum of a committee of four out of seven validators is three seals
+	header := makeSealedHeader(t, genesis, vs.addrs, vs.keys[vs.addrs[0]], vs.signers(vs.addrs[:3]...))
+	_, err := VerifyCommittedHeader(genesis, header, vs.addrs, 4)
+	assert.NoError(t, err)
+
+	// The quorum of the whole seven validators is five seals
+	_, err = VerifyCommittedHeader(genesis, header, vs.addrs, 0)
+	assert.ErrorIs(t, err, ErrInvalidCommittedSeals)
+	header = makeSealedHeader(t, genesis, vs.addrs, vs.keys[vs.addrs[0]], vs.signers(vs.addrs[:5]...))
+	_, err = VerifyCommittedHeader(genesis, header, vs.addrs, 0)
+	assert.NoError(t, err)
+}
+
+func TestVerifyCommittedHeaderValidatorChange(t *testing.T) {
+	var (
+		trusted  = newTestValidators(t, 4)
+		attacker = newTestValidators(t, 4)
+		genesis  = &types.Header{Number: common.Big0, Time: common.Big0, BlockScore: common.Big1}
+	)
+	setIstanbulExtra(t, genesis, &types.IstanbulExtra{Validators: trusted.addrs, Seal: []byte{}, CommittedSeal: [][]byte{}})
+
+	// A new set endorsed by more seals of the trusted committee than it may miss is accepted
+	next := append([]common.Address{}, trusted.addrs[:3]...)
+	next = append(next, attacker.addrs[0])
+	committers := append(trusted.signers(trusted.addrs[:2]...), attacker.keys[attacker.addrs[0]])
+	header := makeSealedHeader(t, genesis, next, trusted.keys[trusted.addrs[0]], committers)
+	validators, err := VerifyCommittedHeader(genesis, header, trusted.addrs, 0)
+	require.NoError(t, err)
+	assert.Equal(t, next, validators)
+
+	// A new set signed by a single trusted validator is not
+	committers = append(trusted.signers(trusted.addrs[0]), attacker.signers(attacker.addrs[0])...)
+	next = append([]common.Address{trusted.addrs[0]}, attacker.addrs...)
+	header = makeSealedHeader(t, genesis, next, trusted.keys[trusted.addrs[0]], append(committers, attacker.signers(attacker.addrs[1:3]...)...))
+	_, err = VerifyCommittedHeader(genesis, header, trusted.addrs, 0)
+	assert.ErrorIs(t, err, ErrUntrustedValidators)
+
+	// A set replaced altogether is not, though its seals are consistent
+	header = makeSealedHeader(t, genesis, attacker.addrs, attacker.keys[attacker.addrs[0]], attacker.signers(attacker.addrs...))
+	_, err = VerifyCommittedHeader(genesis, header, trusted.addrs, 0)
+	assert.ErrorIs(t, err, ErrUntrustedValidators)
+	validators, err = VerifyCommittedSeals(header, 0)
+	require.NoError(t, err)
+	assert.Equal(t, attacker.addrs, validators)
+}
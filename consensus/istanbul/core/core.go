@@ -437,6 +437,12 @@ func RequiredMessageCount(valSet istanbul.ValidatorSet) int {
 	} else {
 		size = valSet.Size()
 	}
+	return QuorumSize(size)
+}
+
+// QuorumSize returns the minimum number of consensus messages of a committee
+// of the given size to proceed
+func QuorumSize(size uint64) int {
 	// For less than 4 validators, quorum size equals validator count.
 	if size < 4 {
 		return int(size)
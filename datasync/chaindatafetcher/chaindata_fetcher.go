@@ -22,6 +22,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -31,8 +32,10 @@ import (
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/consensus"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/file"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kafka"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kas"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/postgres"
 	cfTypes "github.com/kaiachain/kaia/datasync/chaindatafetcher/types"
 	"github.com/kaiachain/kaia/event"
 	"github.com/kaiachain/kaia/log"
@@ -117,6 +120,16 @@ func NewChainDataFetcher(ctx *node.ServiceContext, cfg *ChainDataFetcherConfig)
 		if err != nil {
 			return nil, err
 		}
+	case ModeFile:
+		repo, checkpointDB, setters, err = getFileComponents(cfg.FileConfig)
+		if err != nil {
+			return nil, err
+		}
+	case ModePostgres:
+		repo, checkpointDB, setters, err = getPostgresComponents(cfg.PostgresConfig)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		logger.Error("the chaindatafetcher mode is not supported", "mode", cfg.Mode)
 		return nil, errUnsupportedMode
@@ -153,6 +166,24 @@ func getKafkaComponents(cfg *kafka.KafkaConfig) (Repository, CheckpointDB, []Com
 	return repo, checkpointDB, []ComponentSetter{repo, checkpointDB}, nil
 }
 
+func getFileComponents(cfg *file.FileConfig) (Repository, CheckpointDB, []ComponentSetter, error) {
+	repo, err := file.NewRepository(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// the checkpoint is stored in the local database as the kafka mode does.
+	checkpointDB := kafka.NewCheckpointDB()
+	return repo, checkpointDB, []ComponentSetter{repo, checkpointDB}, nil
+}
+
+func getPostgresComponents(cfg *postgres.PostgresConfig) (Repository, CheckpointDB, []ComponentSetter, error) {
+	repo, err := postgres.NewRepository(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return repo, repo, []ComponentSetter{repo}, nil
+}
+
 func (f *ChainDataFetcher) Protocols() []p2p.Protocol {
 	return []p2p.Protocol{}
 }
@@ -190,6 +221,12 @@ func (f *ChainDataFetcher) Stop() error {
 	logger.Info("wait for all goroutines to be terminated...", "numGoroutines", f.config.NumHandlers)
 	close(f.stopCh)
 	f.wg.Wait()
+	// release the resources of the repository, e.g. finalizing files being written.
+	if closer, ok := f.repo.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Error("failed to close the repository", "err", err)
+		}
+	}
 	logger.Info("chaindata fetcher is stopped")
 	return nil
 }
@@ -244,7 +281,7 @@ func (f *ChainDataFetcher) startFetching() error {
 		switch f.config.Mode {
 		case ModeKAS:
 			f.sendRequests(uint64(f.checkpoint), currentBlock, cfTypes.RequestTypeAll, true, f.fetchingStopCh)
-		case ModeKafka:
+		case ModeKafka, ModeFile, ModePostgres:
 			f.sendRequests(uint64(f.checkpoint), currentBlock, cfTypes.RequestTypeGroupAll, true, f.fetchingStopCh)
 		default:
 			logger.Error("the chaindatafetcher mode is not supported", "mode", f.config.Mode, "checkpoint", f.checkpoint, "currentBlock", currentBlock)
@@ -465,7 +502,7 @@ func (f *ChainDataFetcher) handleRequest() {
 			switch f.config.Mode {
 			case ModeKAS:
 				err = f.handleRequestByType(cfTypes.RequestTypeAll, true, ev)
-			case ModeKafka:
+			case ModeKafka, ModeFile, ModePostgres:
 				err = f.handleRequestByType(cfTypes.RequestTypeGroupAll, true, ev)
 			default:
 				logger.Error("the chaindatafetcher mode is not supported", "mode", f.config.Mode, "blockNumber", ev.Block.NumberU64())
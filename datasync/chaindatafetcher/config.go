@@ -21,8 +21,10 @@ package chaindatafetcher
 import (
 	"time"
 
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/file"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kafka"
 	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kas"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/postgres"
 )
 
 type ChainDataFetcherMode int
@@ -30,6 +32,8 @@ type ChainDataFetcherMode int
 const (
 	ModeKAS = ChainDataFetcherMode(iota)
 	ModeKafka
+	ModeFile
+	ModePostgres
 )
 
 const (
@@ -51,8 +55,10 @@ type ChainDataFetcherConfig struct {
 	BlockChannelSize        int
 	MaxProcessingDataSize   int
 
-	KasConfig   *kas.KASConfig     `json:"-"` // Deprecated: This configuration is not used anymore.
-	KafkaConfig *kafka.KafkaConfig `toml:",omitempty"`
+	KasConfig      *kas.KASConfig           `json:"-"` // Deprecated: This configuration is not used anymore.
+	KafkaConfig    *kafka.KafkaConfig       `toml:",omitempty"`
+	FileConfig     *file.FileConfig         `toml:",omitempty"`
+	PostgresConfig *postgres.PostgresConfig `toml:",omitempty"`
 }
 
 func DefaultChainDataFetcherConfig() *ChainDataFetcherConfig {
@@ -65,7 +71,9 @@ func DefaultChainDataFetcherConfig() *ChainDataFetcherConfig {
 		BlockChannelSize:        DefaultBlockChannelSize,
 		MaxProcessingDataSize:   DefaultMaxProcessingDataSize,
 
-		KasConfig:   kas.DefaultKASConfig,
-		KafkaConfig: kafka.GetDefaultKafkaConfig(),
+		KasConfig:      kas.DefaultKASConfig,
+		KafkaConfig:    kafka.GetDefaultKafkaConfig(),
+		FileConfig:     file.GetDefaultFileConfig(),
+		PostgresConfig: postgres.GetDefaultPostgresConfig(),
 	}
 }
@@ -15,7 +15,8 @@
 // along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
 
 /*
-Package chaindatafetcher implements blockchain data load to KAS-specific database, or kafka.
+Package chaindatafetcher implements blockchain data load to KAS-specific database, kafka,
+local files, or PostgreSQL database.
 Source Files
   - api.go                   : includes chaindatafetcher-related APIs
   - chaindata_fetcher.go     : implements chaindatafetcher main operations
@@ -0,0 +1,47 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package file
+
+import "time"
+
+const (
+	EventBlockGroup = "blockgroup"
+	EventTraceGroup = "tracegroup"
+)
+
+const (
+	DefaultDirName     = "chaindatafetcher"
+	DefaultMaxFileSize = 128 * 1024 * 1024 // 128 MB
+	DefaultMaxFileAge  = time.Hour
+	DefaultCompress    = true
+)
+
+type FileConfig struct {
+	Dir         string        // Dir is the directory where the output files are stored.
+	MaxFileSize int64         // MaxFileSize is the size of uncompressed data (in bytes) at which a file is rotated.
+	MaxFileAge  time.Duration // MaxFileAge is the time since a file is created at which the file is rotated.
+	Compress    bool          // Compress enables gzip compression of the output files.
+}
+
+func GetDefaultFileConfig() *FileConfig {
+	return &FileConfig{
+		Dir:         DefaultDirName,
+		MaxFileSize: DefaultMaxFileSize,
+		MaxFileAge:  DefaultMaxFileAge,
+		Compress:    DefaultCompress,
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package file implements a chaindatafetcher repository which exports block groups and
+trace groups to local files instead of a message broker.
+Source Files
+  - config.go     : includes file repository configurations
+  - repository.go : implements repository interface writing block groups and trace groups
+  - writer.go     : implements a rotating writer of compressed JSON Lines files
+*/
+
+package file
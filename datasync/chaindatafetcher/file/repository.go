@@ -0,0 +1,125 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/consensus"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kafka"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/types"
+	"github.com/kaiachain/kaia/log"
+)
+
+var logger = log.NewModuleLogger(log.ChainDataFetcher)
+
+// record is a line of an output file. It has the same format as the kafka message value,
+// so that the consumers of the kafka mode can read the files without modification.
+type record struct {
+	BlockNumber *big.Int    `json:"blockNumber"`
+	Result      interface{} `json:"result"`
+}
+
+type repository struct {
+	blockchain *blockchain.BlockChain
+	engine     consensus.Engine
+
+	blockGroup *rotatingWriter
+	traceGroup *rotatingWriter
+}
+
+func NewRepository(config *FileConfig) (*repository, error) {
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		logger.Error("Failed to create the chaindata directory", "err", err, "dir", config.Dir)
+		return nil, err
+	}
+	if err := recoverPartialFiles(config.Dir); err != nil {
+		logger.Error("Failed to recover partial chaindata files", "err", err, "dir", config.Dir)
+		return nil, err
+	}
+	return &repository{
+		blockGroup: newRotatingWriter(config, EventBlockGroup),
+		traceGroup: newRotatingWriter(config, EventTraceGroup),
+	}, nil
+}
+
+func (r *repository) SetComponent(component interface{}) {
+	switch c := component.(type) {
+	case *blockchain.BlockChain:
+		r.blockchain = c
+	case consensus.Engine:
+		r.engine = c
+	}
+}
+
+func (r *repository) HandleChainEvent(event blockchain.ChainEvent, dataType types.RequestType) error {
+	switch dataType {
+	case types.RequestTypeBlockGroup:
+		if event.Block.NumberU64() > 0 {
+			err := kafka.CheckStatesForSnapshot(r.blockchain, r.engine, event.Block.NumberU64()-1, event.Block.ParentHash())
+			if err != nil {
+				logger.Warn("skip fetching block", "number", event.Block.NumberU64(), "err", err)
+				return nil
+			}
+		}
+		cInfo, err := r.engine.GetConsensusInfo(event.Block)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve consensusinfo with the given block number: %v", event.Block.Number())
+		}
+		return r.write(r.blockGroup, &record{
+			BlockNumber: event.Block.Number(),
+			Result:      kafka.MakeBlockGroupOutput(r.blockchain, event.Block, cInfo, event.Receipts),
+		})
+	case types.RequestTypeTraceGroup:
+		if len(event.InternalTxTraces) > 0 {
+			return r.write(r.traceGroup, &record{
+				BlockNumber: event.Block.Number(),
+				Result:      event.InternalTxTraces,
+			})
+		}
+		return nil
+	default:
+		return fmt.Errorf("not supported type. [blockNumber: %v, reqType: %v]", event.Block.NumberU64(), dataType)
+	}
+}
+
+func (r *repository) write(w *rotatingWriter, rec *record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		logger.Error("Failed to marshal a chaindata record", "err", err, "blockNumber", rec.BlockNumber)
+		return err
+	}
+	if err := w.Write(data); err != nil {
+		logger.Error("Failed to write a chaindata record", "err", err, "blockNumber", rec.BlockNumber, "name", w.name)
+		return err
+	}
+	return nil
+}
+
+// Close finalizes the files being written.
+func (r *repository) Close() error {
+	blockErr := r.blockGroup.Close()
+	traceErr := r.traceGroup.Close()
+	if blockErr != nil {
+		return blockErr
+	}
+	return traceErr
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package file
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	cfTypes "github.com/kaiachain/kaia/datasync/chaindatafetcher/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_HandleChainEvent_TraceGroup(t *testing.T) {
+	config := GetDefaultFileConfig()
+	config.Dir = t.TempDir()
+	repo, err := NewRepository(config)
+	require.NoError(t, err)
+
+	from, to := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	event := blockchain.ChainEvent{
+		Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10)}),
+		InternalTxTraces: []*vm.InternalTxTrace{
+			{Type: "CALL", From: &from, To: &to, Value: "0x1", Gas: 21000},
+		},
+	}
+	require.NoError(t, repo.HandleChainEvent(event, cfTypes.RequestTypeTraceGroup))
+
+	// the event without traces is not written.
+	event.Block = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(11)})
+	event.InternalTxTraces = nil
+	require.NoError(t, repo.HandleChainEvent(event, cfTypes.RequestTypeTraceGroup))
+	require.NoError(t, repo.Close())
+
+	assert.Empty(t, globFiles(t, config.Dir, EventBlockGroup+"-*"))
+	paths := globFiles(t, config.Dir, EventTraceGroup+"-*"+jsonlFileExt+gzipFileExt)
+	require.Len(t, paths, 1)
+
+	lines := readLines(t, paths[0])
+	require.Len(t, lines, 1)
+
+	var result struct {
+		BlockNumber *big.Int              `json:"blockNumber"`
+		Result      []*vm.InternalTxTrace `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &result))
+	assert.Equal(t, big.NewInt(10), result.BlockNumber)
+	require.Len(t, result.Result, 1)
+	assert.Equal(t, "CALL", result.Result[0].Type)
+	assert.Equal(t, to, *result.Result[0].To)
+}
+
+func TestRepository_HandleChainEvent_NotSupported(t *testing.T) {
+	config := GetDefaultFileConfig()
+	config.Dir = t.TempDir()
+	repo, err := NewRepository(config)
+	require.NoError(t, err)
+
+	event := blockchain.ChainEvent{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})}
+	assert.Error(t, repo.HandleChainEvent(event, cfTypes.RequestTypeTransaction))
+}
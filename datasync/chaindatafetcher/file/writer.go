@@ -0,0 +1,261 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jsonlFileExt      = ".jsonl"
+	gzipFileExt       = ".gz"
+	partialFileSuffix = ".part"
+	fileTimeFormat    = "20060102T150405.000000000Z"
+)
+
+// rotatingWriter writes records as lines of a JSON Lines file and rotates the file when
+// the size or the age of the file exceeds the configured limit. A file has the ".part"
+// suffix while it is being written and it is renamed on rotation, so that consumers
+// only see complete files.
+type rotatingWriter struct {
+	dir         string
+	name        string
+	maxFileSize int64
+	maxFileAge  time.Duration
+	compress    bool
+
+	mu       sync.Mutex
+	file     *os.File
+	gz       *gzip.Writer
+	path     string // path of the current file without the partial suffix
+	size     int64  // size of uncompressed data written to the current file
+	openedAt time.Time
+	seq      uint64 // sequence number of the current file to avoid name collisions
+}
+
+func newRotatingWriter(config *FileConfig, name string) *rotatingWriter {
+	return &rotatingWriter{
+		dir:         config.Dir,
+		name:        name,
+		maxFileSize: config.MaxFileSize,
+		maxFileAge:  config.MaxFileAge,
+		compress:    config.Compress,
+	}
+}
+
+func (w *rotatingWriter) ext() string {
+	if w.compress {
+		return jsonlFileExt + gzipFileExt
+	}
+	return jsonlFileExt
+}
+
+// Write appends the record to the current file as a single line. The record is flushed
+// and synced to the disk before returning, so that it is persisted before the
+// chaindatafetcher checkpoint passes over it.
+func (w *rotatingWriter) Write(record []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	var out io.Writer = w.file
+	if w.gz != nil {
+		out = w.gz
+	}
+	if _, err := out.Write(record); err != nil {
+		return err
+	}
+	if _, err := out.Write([]byte{'\n'}); err != nil {
+		return err
+	}
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.size += int64(len(record) + 1)
+
+	if (w.maxFileSize > 0 && w.size >= w.maxFileSize) || (w.maxFileAge > 0 && time.Since(w.openedAt) >= w.maxFileAge) {
+		return w.closeFile()
+	}
+	return nil
+}
+
+// Close finalizes the current file if it exists.
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.closeFile()
+}
+
+func (w *rotatingWriter) open() error {
+	w.openedAt = time.Now()
+	w.seq++
+	w.path = filepath.Join(w.dir, fmt.Sprintf("%s-%s-%06d%s", w.name, w.openedAt.UTC().Format(fileTimeFormat), w.seq, w.ext()))
+	f, err := os.OpenFile(w.path+partialFileSuffix, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.size = 0
+	if w.compress {
+		w.gz = gzip.NewWriter(f)
+	}
+	logger.Debug("opened a new chaindata file", "path", w.path)
+	return nil
+}
+
+func (w *rotatingWriter) closeFile() error {
+	defer func() {
+		w.file, w.gz = nil, nil
+	}()
+
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.path+partialFileSuffix, w.path); err != nil {
+		return err
+	}
+	logger.Debug("rotated a chaindata file", "path", w.path, "size", w.size)
+	return nil
+}
+
+// recoverPartialFiles finalizes the partial files left by an unclean shutdown. Only the
+// complete lines of a partial file are kept. The records after the checkpoint are
+// written again when fetching is resumed, so they may be duplicated across files.
+func recoverPartialFiles(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+partialFileSuffix))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		n, err := recoverPartialFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to recover a partial file %s: %w", path, err)
+		}
+		logger.Info("recovered a partial chaindata file", "path", path, "numRecords", n)
+	}
+	return nil
+}
+
+func recoverPartialFile(path string) (int, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	target := strings.TrimSuffix(path, partialFileSuffix)
+	compressed := strings.HasSuffix(target, gzipFileExt)
+
+	var in io.Reader = src
+	if compressed {
+		gz, err := gzip.NewReader(src)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// nothing has been flushed to the file yet.
+			return 0, os.Remove(path)
+		} else if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	var (
+		lines  [][]byte
+		reader = bufio.NewReader(in)
+	)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == nil {
+			lines = append(lines, line)
+			continue
+		}
+		// the last line without a newline is an incomplete record.
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		return 0, err
+	}
+
+	if len(lines) == 0 {
+		return 0, os.Remove(path)
+	}
+	if err := writeLines(target, lines, compressed); err != nil {
+		return 0, err
+	}
+	return len(lines), os.Remove(path)
+}
+
+func writeLines(path string, lines [][]byte, compress bool) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		out io.Writer = f
+		gz  *gzip.Writer
+	)
+	if compress {
+		gz = gzip.NewWriter(f)
+		out = gz
+	}
+	for _, line := range lines {
+		if _, err := out.Write(line); err != nil {
+			return err
+		}
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readLines(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var in io.Reader = f
+	if strings.HasSuffix(path, gzipFileExt) {
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		defer gz.Close()
+		in = gz
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func globFiles(t *testing.T, dir, pattern string) []string {
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	require.NoError(t, err)
+	return paths
+}
+
+func TestRotatingWriter_Rotate(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		dir := t.TempDir()
+		w := newRotatingWriter(&FileConfig{Dir: dir, MaxFileSize: 10, Compress: compress}, "test")
+
+		// each pair of records exceeds the max file size, so that a file is rotated.
+		records := []string{"{\"a\":1}", "{\"b\":2}", "{\"c\":3}", "{\"d\":4}", "{\"e\":5}"}
+		for _, rec := range records {
+			require.NoError(t, w.Write([]byte(rec)))
+		}
+		assert.Len(t, globFiles(t, dir, "*"+partialFileSuffix), 1)
+		require.NoError(t, w.Close())
+		assert.Empty(t, globFiles(t, dir, "*"+partialFileSuffix))
+
+		paths := globFiles(t, dir, "test-*"+w.ext())
+		require.Len(t, paths, 3)
+
+		// file names are ordered by the creation time.
+		var lines []string
+		for _, path := range paths {
+			lines = append(lines, readLines(t, path)...)
+		}
+		assert.Equal(t, records, lines)
+	}
+}
+
+func TestRotatingWriter_MaxFileAge(t *testing.T) {
+	dir := t.TempDir()
+	w := newRotatingWriter(&FileConfig{Dir: dir, MaxFileSize: DefaultMaxFileSize, MaxFileAge: time.Nanosecond, Compress: true}, "test")
+
+	require.NoError(t, w.Write([]byte("{}")))
+	require.NoError(t, w.Write([]byte("{}")))
+
+	assert.Empty(t, globFiles(t, dir, "*"+partialFileSuffix))
+	assert.Len(t, globFiles(t, dir, "test-*"+w.ext()), 2)
+}
+
+func TestRecoverPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	// a compressed file which is not finalized.
+	w := newRotatingWriter(&FileConfig{Dir: dir, MaxFileSize: DefaultMaxFileSize, Compress: true}, "compressed")
+	require.NoError(t, w.Write([]byte("{\"a\":1}")))
+	require.NoError(t, w.Write([]byte("{\"b\":2}")))
+	defer w.file.Close()
+
+	// an uncompressed file with an incomplete record.
+	partial := filepath.Join(dir, "plain-0"+jsonlFileExt+partialFileSuffix)
+	require.NoError(t, os.WriteFile(partial, []byte("{\"c\":3}\n{\"d\""), 0o644))
+
+	// an empty file.
+	empty := filepath.Join(dir, "empty-0"+jsonlFileExt+gzipFileExt+partialFileSuffix)
+	require.NoError(t, os.WriteFile(empty, nil, 0o644))
+
+	require.NoError(t, recoverPartialFiles(dir))
+	assert.Empty(t, globFiles(t, dir, "*"+partialFileSuffix))
+
+	assert.Equal(t, []string{"{\"a\":1}", "{\"b\":2}"}, readLines(t, w.path))
+	assert.Equal(t, []string{"{\"c\":3}"}, readLines(t, strings.TrimSuffix(partial, partialFileSuffix)))
+	assert.Empty(t, globFiles(t, dir, "empty-*"))
+}
@@ -77,7 +77,7 @@ func (r *repository) HandleChainEvent(event blockchain.ChainEvent, dataType type
 	switch dataType {
 	case types.RequestTypeBlockGroup:
 		if event.Block.NumberU64() > 0 {
-			err := checkStatesForSnapshot(r.blockchain, r.engine, event.Block.NumberU64()-1, event.Block.ParentHash())
+			err := CheckStatesForSnapshot(r.blockchain, r.engine, event.Block.NumberU64()-1, event.Block.ParentHash())
 			if err != nil {
 				logger.Warn("skip fetching block", "number", event.Block.NumberU64(), "err", err)
 				return nil
@@ -89,7 +89,7 @@ func (r *repository) HandleChainEvent(event blockchain.ChainEvent, dataType type
 		}
 		result := &blockGroupResult{
 			BlockNumber: event.Block.Number(),
-			Result:      makeBlockGroupOutput(r.blockchain, event.Block, cInfo, event.Receipts),
+			Result:      MakeBlockGroupOutput(r.blockchain, event.Block, cInfo, event.Receipts),
 		}
 		return r.kafka.Publish(r.kafka.getTopicName(EventBlockGroup), result)
 	case types.RequestTypeTraceGroup:
@@ -106,7 +106,9 @@ func (r *repository) HandleChainEvent(event blockchain.ChainEvent, dataType type
 	}
 }
 
-func checkStatesForSnapshot(chain consensus.ChainReader, engine consensus.Engine, number uint64, hash common.Hash) error {
+// CheckStatesForSnapshot checks that the states required to apply the snapshot
+// at the given block are available, so that the consensus info can be retrieved.
+func CheckStatesForSnapshot(chain consensus.ChainReader, engine consensus.Engine, number uint64, hash common.Hash) error {
 	headers, err := engine.GetKaiaHeadersForSnapshotApply(chain, number, hash, nil)
 	if err != nil {
 		return err
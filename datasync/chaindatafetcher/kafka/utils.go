@@ -64,7 +64,9 @@ func sigHash(header *types.Header) (hash common.Hash, err error) {
 	return hash, nil
 }
 
-func makeBlockGroupOutput(blockchain *blockchain.BlockChain, block *types.Block, cInfo consensus.ConsensusInfo, receipts types.Receipts) map[string]interface{} {
+// MakeBlockGroupOutput returns the block group representation of the given block,
+// which is the block RPC output extended with consensus info and transaction receipts.
+func MakeBlockGroupOutput(blockchain *blockchain.BlockChain, block *types.Block, cInfo consensus.ConsensusInfo, receipts types.Receipts) map[string]interface{} {
 	head := block.Header() // copies the header once
 	hash := head.Hash()
 
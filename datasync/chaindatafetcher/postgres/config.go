@@ -0,0 +1,38 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+const (
+	DefaultDBPort  = "5432"
+	DefaultSSLMode = "disable"
+)
+
+type PostgresConfig struct {
+	DBHost     string
+	DBPort     string
+	DBName     string
+	DBUser     string
+	DBPassword string
+	SSLMode    string // SSLMode is the sslmode parameter of libpq ("disable", "require", "verify-ca" or "verify-full").
+}
+
+func GetDefaultPostgresConfig() *PostgresConfig {
+	return &PostgresConfig{
+		DBPort:  DefaultDBPort,
+		SSLMode: DefaultSSLMode,
+	}
+}
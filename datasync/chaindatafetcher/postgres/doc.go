@@ -0,0 +1,30 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package postgres implements a chaindatafetcher repository which loads block groups and
+trace groups into PostgreSQL with a normalized schema.
+Source Files
+  - config.go                : includes postgres repository configurations
+  - model.go                 : defines the rows of blocks, transactions, receipts, logs and internal traces
+  - repository.go            : implements repository interface and bulk insertion
+  - repository_blocks.go     : transforms and inserts block groups
+  - repository_checkpoint.go : implements checkpoint database in order to read and write chaindatafetcher checkpoint
+  - repository_traces.go     : transforms and inserts trace groups
+  - schema.go                : includes the database schema
+*/
+
+package postgres
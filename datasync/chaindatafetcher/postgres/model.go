@@ -0,0 +1,134 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+const (
+	BlockTableName         = "blocks"
+	TxTableName            = "transactions"
+	ReceiptTableName       = "receipts"
+	LogTableName           = "logs"
+	InternalTraceTableName = "internal_traces"
+	MetadataTableName      = "fetcher_metadata"
+)
+
+var (
+	blockColumns         = []string{"number", "hash", "parent_hash", "proposer", "reward_base", "state_root", "tx_root", "receipt_root", "block_score", "base_fee", "gas_used", "timestamp", "size", "round", "tx_count"}
+	txColumns            = []string{"hash", "block_number", "tx_index", "type_int", "from_addr", "to_addr", "fee_payer", "fee_ratio", "nonce", "gas", "gas_price", "value", "input"}
+	receiptColumns       = []string{"tx_hash", "status", "gas_used", "effective_gas_price", "contract_address", "logs_bloom"}
+	logColumns           = []string{"tx_hash", "log_index", "address", "topic0", "topic1", "topic2", "topic3", "data"}
+	internalTraceColumns = []string{"block_number", "tx_index", "trace_address", "tx_hash", "type", "from_addr", "to_addr", "value", "gas", "gas_used", "input", "output", "error", "revert_reason"}
+)
+
+type Block struct {
+	Number      int64
+	Hash        []byte
+	ParentHash  []byte
+	Proposer    []byte
+	RewardBase  []byte
+	StateRoot   []byte
+	TxRoot      []byte
+	ReceiptRoot []byte
+	BlockScore  string
+	BaseFee     *string // nil before the magma hardfork
+	GasUsed     int64
+	Timestamp   int64
+	Size        int64
+	Round       int
+	TxCount     int
+}
+
+func (b *Block) values() []interface{} {
+	return []interface{}{b.Number, b.Hash, b.ParentHash, b.Proposer, b.RewardBase, b.StateRoot, b.TxRoot, b.ReceiptRoot, b.BlockScore, b.BaseFee, b.GasUsed, b.Timestamp, b.Size, b.Round, b.TxCount}
+}
+
+type Tx struct {
+	Hash        []byte
+	BlockNumber int64
+	TxIndex     int
+	TypeInt     int
+	FromAddr    []byte
+	ToAddr      []byte // nil for contract deployments
+	FeePayer    []byte // nil if not fee delegated
+	FeeRatio    *int   // nil if the fee is not partially delegated
+	Nonce       int64
+	Gas         int64
+	GasPrice    string
+	Value       string
+	Input       []byte
+}
+
+func (tx *Tx) values() []interface{} {
+	return []interface{}{tx.Hash, tx.BlockNumber, tx.TxIndex, tx.TypeInt, tx.FromAddr, nullBytes(tx.ToAddr), nullBytes(tx.FeePayer), tx.FeeRatio, tx.Nonce, tx.Gas, tx.GasPrice, tx.Value, nullBytes(tx.Input)}
+}
+
+type Receipt struct {
+	TxHash            []byte
+	Status            int
+	GasUsed           int64
+	EffectiveGasPrice string
+	ContractAddress   []byte // nil if no contract is deployed
+	LogsBloom         []byte
+}
+
+func (r *Receipt) values() []interface{} {
+	return []interface{}{r.TxHash, r.Status, r.GasUsed, r.EffectiveGasPrice, nullBytes(r.ContractAddress), r.LogsBloom}
+}
+
+type Log struct {
+	TxHash   []byte
+	LogIndex int
+	Address  []byte
+	Topics   [4][]byte
+	Data     []byte
+}
+
+func (l *Log) values() []interface{} {
+	return []interface{}{l.TxHash, l.LogIndex, l.Address, nullBytes(l.Topics[0]), nullBytes(l.Topics[1]), nullBytes(l.Topics[2]), nullBytes(l.Topics[3]), nullBytes(l.Data)}
+}
+
+// InternalTrace is a call frame of the internal transaction trace. TraceAddress is the
+// dot-separated path of call indexes from the top-level call, which is "" for the
+// top-level call itself.
+type InternalTrace struct {
+	BlockNumber  int64
+	TxIndex      int
+	TraceAddress string
+	TxHash       []byte
+	Type         string
+	FromAddr     []byte
+	ToAddr       []byte
+	Value        *string
+	Gas          int64
+	GasUsed      int64
+	Input        []byte
+	Output       []byte
+	Error        *string
+	RevertReason *string
+}
+
+func (t *InternalTrace) values() []interface{} {
+	return []interface{}{t.BlockNumber, t.TxIndex, t.TraceAddress, t.TxHash, t.Type, nullBytes(t.FromAddr), nullBytes(t.ToAddr), t.Value, t.Gas, t.GasUsed, nullBytes(t.Input), nullBytes(t.Output), t.Error, t.RevertReason}
+}
+
+// nullBytes converts an empty byte slice to nil, so that it is stored as NULL instead of
+// an empty bytea.
+func nullBytes(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/consensus"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/types"
+	"github.com/kaiachain/kaia/log"
+	_ "github.com/lib/pq"
+)
+
+const (
+	maxPlaceholders = 65535
+
+	maxOpenConnection = 100
+	maxIdleConnection = 10
+	connMaxLifetime   = 24 * time.Hour
+	maxDBRetryCount   = 20
+	DBRetryInterval   = 1 * time.Second
+)
+
+var logger = log.NewModuleLogger(log.ChainDataFetcher)
+
+type repository struct {
+	db *sql.DB
+
+	blockchain *blockchain.BlockChain
+	engine     consensus.Engine
+}
+
+func getEndpoint(config *PostgresConfig) string {
+	endpoint := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.DBUser, config.DBPassword),
+		Host:     net.JoinHostPort(config.DBHost, config.DBPort),
+		Path:     "/" + config.DBName,
+		RawQuery: url.Values{"sslmode": []string{config.SSLMode}}.Encode(),
+	}
+	return endpoint.String()
+}
+
+func NewRepository(config *PostgresConfig) (*repository, error) {
+	db, err := sql.Open("postgres", getEndpoint(config))
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < maxDBRetryCount; i++ {
+		if err = db.Ping(); err != nil {
+			logger.Warn("Retrying to connect DB", "host", config.DBHost, "port", config.DBPort, "name", config.DBName, "err", err)
+			time.Sleep(DBRetryInterval)
+			continue
+		}
+		db.SetMaxOpenConns(maxOpenConnection)
+		db.SetMaxIdleConns(maxIdleConnection)
+		db.SetConnMaxLifetime(connMaxLifetime)
+
+		if err = createSchema(db); err != nil {
+			logger.Error("Failed to create the database schema", "err", err)
+			db.Close()
+			return nil, err
+		}
+		return &repository{db: db}, nil
+	}
+	logger.Error("Failed to connect to the database", "host", config.DBHost, "port", config.DBPort, "name", config.DBName, "err", err)
+	db.Close()
+	return nil, err
+}
+
+func createSchema(db *sql.DB) error {
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repository) SetComponent(component interface{}) {
+	switch c := component.(type) {
+	case *blockchain.BlockChain:
+		r.blockchain = c
+	case consensus.Engine:
+		r.engine = c
+	}
+}
+
+func (r *repository) HandleChainEvent(event blockchain.ChainEvent, dataType types.RequestType) error {
+	switch dataType {
+	case types.RequestTypeBlockGroup:
+		return r.InsertBlockGroup(event)
+	case types.RequestTypeTraceGroup:
+		return r.InsertTraceGroup(event)
+	default:
+		return fmt.Errorf("not supported type. [blockNumber: %v, reqType: %v]", event.Block.NumberU64(), dataType)
+	}
+}
+
+// Close closes the database connections.
+func (r *repository) Close() error {
+	return r.db.Close()
+}
+
+// withTx runs the given function in a database transaction, which is committed only if
+// the function succeeds.
+func (r *repository) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+type row interface {
+	values() []interface{}
+}
+
+// bulkInsert inserts the given rows divided into chunks because of the max number of placeholders.
+func bulkInsert[T row](tx *sql.Tx, table string, columns []string, rows []T) error {
+	chunkUnit := maxPlaceholders / len(columns)
+	for len(rows) > 0 {
+		n := min(len(rows), chunkUnit)
+		query, args := makeInsertQuery(table, columns, rows[:n])
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+// makeInsertQuery makes a statement inserting the given rows in multiple rows at once.
+func makeInsertQuery[T row](table string, columns []string, rows []T) (string, []interface{}) {
+	var (
+		valueStrings = make([]string, 0, len(rows))
+		valueArgs    = make([]interface{}, 0, len(rows)*len(columns))
+		placeholders = make([]string, len(columns))
+	)
+	for _, r := range rows {
+		for i := range placeholders {
+			placeholders[i] = "$" + strconv.Itoa(len(valueArgs)+i+1)
+		}
+		valueStrings = append(valueStrings, "("+strings.Join(placeholders, ",")+")")
+		valueArgs = append(valueArgs, r.values()...)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, strings.Join(columns, ", "), strings.Join(valueStrings, ","))
+	return query, valueArgs
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus"
+	"github.com/kaiachain/kaia/datasync/chaindatafetcher/kafka"
+	"github.com/kaiachain/kaia/params"
+)
+
+// transformToBlockGroup transforms a chain event to the rows of a block and its transactions,
+// receipts and logs.
+func transformToBlockGroup(event blockchain.ChainEvent, cInfo consensus.ConsensusInfo, config *params.ChainConfig) (*Block, []*Tx, []*Receipt, []*Log) {
+	block := event.Block
+	head := block.Header()
+	number := head.Number.Int64()
+	transactions := block.Transactions()
+
+	b := &Block{
+		Number:      number,
+		Hash:        block.Hash().Bytes(),
+		ParentHash:  head.ParentHash.Bytes(),
+		Proposer:    cInfo.Proposer.Bytes(),
+		RewardBase:  head.Rewardbase.Bytes(),
+		StateRoot:   head.Root.Bytes(),
+		TxRoot:      head.TxHash.Bytes(),
+		ReceiptRoot: head.ReceiptHash.Bytes(),
+		BlockScore:  head.BlockScore.String(),
+		GasUsed:     int64(head.GasUsed),
+		Timestamp:   head.Time.Int64(),
+		Size:        int64(block.Size()),
+		Round:       int(cInfo.Round),
+		TxCount:     len(transactions),
+	}
+	if head.BaseFee != nil {
+		baseFee := head.BaseFee.String()
+		b.BaseFee = &baseFee
+	}
+
+	var (
+		txs      = make([]*Tx, 0, len(transactions))
+		receipts = make([]*Receipt, 0, len(transactions))
+		logs     []*Log
+	)
+	for idx, rawTx := range transactions {
+		receipt := event.Receipts[idx]
+		hash := rawTx.Hash().Bytes()
+
+		// from
+		var from common.Address
+		if rawTx.IsEthereumTransaction() {
+			signer := types.LatestSignerForChainID(rawTx.ChainId())
+			from, _ = types.Sender(signer, rawTx)
+		} else {
+			from, _ = rawTx.From()
+		}
+
+		tx := &Tx{
+			Hash:        hash,
+			BlockNumber: number,
+			TxIndex:     idx,
+			TypeInt:     int(rawTx.Type()),
+			FromAddr:    from.Bytes(),
+			Nonce:       int64(rawTx.Nonce()),
+			Gas:         int64(rawTx.Gas()),
+			GasPrice:    rawTx.GasPrice().String(),
+			Value:       rawTx.Value().String(),
+			Input:       rawTx.Data(),
+		}
+		if to := rawTx.To(); to != nil {
+			tx.ToAddr = to.Bytes()
+		}
+		if rawTx.IsFeeDelegatedTransaction() {
+			payer, _ := rawTx.FeePayer()
+			tx.FeePayer = payer.Bytes()
+
+			if ratio, ok := rawTx.FeeRatio(); ok {
+				feeRatio := int(ratio)
+				tx.FeeRatio = &feeRatio
+			}
+		}
+		txs = append(txs, tx)
+
+		r := &Receipt{
+			TxHash:            hash,
+			Status:            int(receipt.Status),
+			GasUsed:           int64(receipt.GasUsed),
+			EffectiveGasPrice: rawTx.EffectiveGasPrice(head, config).String(),
+			LogsBloom:         receipt.Bloom.Bytes(),
+		}
+		if receipt.ContractAddress != (common.Address{}) {
+			r.ContractAddress = receipt.ContractAddress.Bytes()
+		}
+		receipts = append(receipts, r)
+
+		for _, l := range receipt.Logs {
+			row := &Log{
+				TxHash:   hash,
+				LogIndex: int(l.Index),
+				Address:  l.Address.Bytes(),
+				Data:     l.Data,
+			}
+			for i, topic := range l.Topics {
+				if i >= len(row.Topics) {
+					break
+				}
+				row.Topics[i] = topic.Bytes()
+			}
+			logs = append(logs, row)
+		}
+	}
+	return b, txs, receipts, logs
+}
+
+// InsertBlockGroup inserts the block, transactions, receipts and logs in the given chain event
+// into the database. The existing rows of the block are replaced.
+func (r *repository) InsertBlockGroup(event blockchain.ChainEvent) error {
+	if event.Block.NumberU64() > 0 {
+		err := kafka.CheckStatesForSnapshot(r.blockchain, r.engine, event.Block.NumberU64()-1, event.Block.ParentHash())
+		if err != nil {
+			logger.Warn("skip fetching block", "number", event.Block.NumberU64(), "err", err)
+			return nil
+		}
+	}
+	cInfo, err := r.engine.GetConsensusInfo(event.Block)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve consensusinfo with the given block number: %v", event.Block.Number())
+	}
+
+	block, txs, receipts, logs := transformToBlockGroup(event, cInfo, r.blockchain.Config())
+	if err := r.insertBlockGroup(block, txs, receipts, logs); err != nil {
+		logger.Error("Failed to insertBlockGroup", "err", err, "blockNumber", event.Block.NumberU64(), "numTxs", len(txs), "numLogs", len(logs))
+		return err
+	}
+	return nil
+}
+
+func (r *repository) insertBlockGroup(block *Block, txs []*Tx, receipts []*Receipt, logs []*Log) error {
+	return r.withTx(func(tx *sql.Tx) error {
+		// the transactions, receipts and logs of the block are deleted by cascade.
+		if _, err := tx.Exec("DELETE FROM "+BlockTableName+" WHERE number = $1", block.Number); err != nil {
+			return err
+		}
+		if err := bulkInsert(tx, BlockTableName, blockColumns, []*Block{block}); err != nil {
+			return err
+		}
+		if err := bulkInsert(tx, TxTableName, txColumns, txs); err != nil {
+			return err
+		}
+		if err := bulkInsert(tx, ReceiptTableName, receiptColumns, receipts); err != nil {
+			return err
+		}
+		return bulkInsert(tx, LogTableName, logColumns, logs)
+	})
+}
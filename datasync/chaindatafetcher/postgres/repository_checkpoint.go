@@ -0,0 +1,39 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+)
+
+const checkpointKey = "checkpoint"
+
+func (r *repository) WriteCheckpoint(checkpoint int64) error {
+	query := "INSERT INTO " + MetadataTableName + " (key, value) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value"
+	_, err := r.db.Exec(query, checkpointKey, checkpoint)
+	return err
+}
+
+func (r *repository) ReadCheckpoint() (int64, error) {
+	var checkpoint int64
+	err := r.db.QueryRow("SELECT value FROM "+MetadataTableName+" WHERE key = $1", checkpointKey).Scan(&checkpoint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return checkpoint, err
+}
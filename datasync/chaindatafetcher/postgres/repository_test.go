@@ -0,0 +1,291 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus"
+	"github.com/kaiachain/kaia/crypto"
+	cfTypes "github.com/kaiachain/kaia/datasync/chaindatafetcher/types"
+	"github.com/kaiachain/kaia/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	address  = crypto.PubkeyToAddress(key.PublicKey)
+	to       = common.HexToAddress("0x2")
+	feePayer = common.HexToAddress("0x3")
+	contract = common.HexToAddress("0x4")
+)
+
+func makeTestChainEvent(t *testing.T) blockchain.ChainEvent {
+	config := params.TestChainConfig
+	signer := types.LatestSignerForChainID(config.ChainID)
+	blockchain.InitDeriveSha(config)
+
+	legacyTx, err := types.SignTx(types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(25), nil), signer, key)
+	require.NoError(t, err)
+
+	feeDelegatedTx, err := types.NewTransactionWithMap(types.TxTypeFeeDelegatedValueTransferWithRatio, map[types.TxValueKeyType]interface{}{
+		types.TxValueKeyNonce:              uint64(1),
+		types.TxValueKeyFrom:               address,
+		types.TxValueKeyTo:                 to,
+		types.TxValueKeyAmount:             big.NewInt(2),
+		types.TxValueKeyGasLimit:           uint64(50000),
+		types.TxValueKeyGasPrice:           big.NewInt(25),
+		types.TxValueKeyFeePayer:           feePayer,
+		types.TxValueKeyFeeRatioOfFeePayer: types.FeeRatio(30),
+	})
+	require.NoError(t, err)
+
+	topic := common.HexToHash("0x1234")
+	receipts := types.Receipts{
+		{Status: types.ReceiptStatusSuccessful, GasUsed: 21000, TxHash: legacyTx.Hash()},
+		{
+			Status:          types.ReceiptStatusErrExecutionReverted,
+			GasUsed:         31000,
+			TxHash:          feeDelegatedTx.Hash(),
+			ContractAddress: contract,
+			Logs: []*types.Log{
+				{Address: contract, Topics: []common.Hash{topic}, Data: []byte{0x1}, Index: 0},
+				{Address: contract, Index: 1},
+			},
+		},
+	}
+	header := &types.Header{
+		Number:     big.NewInt(10),
+		Time:       big.NewInt(1000),
+		BlockScore: big.NewInt(1),
+		Rewardbase: common.HexToAddress("0x5"),
+		GasUsed:    52000,
+	}
+	return blockchain.ChainEvent{
+		Block:    types.NewBlock(header, types.Transactions{legacyTx, feeDelegatedTx}, receipts),
+		Receipts: receipts,
+		InternalTxTraces: []*vm.InternalTxTrace{
+			{
+				Type: "CALL", From: &address, To: &to, Value: "0x1", Gas: 21000,
+			},
+			{
+				Type: "CALL", From: &address, To: &contract, Value: "0x2", Gas: 50000, GasUsed: 31000, Input: "0x1234",
+				Error: vm.ErrExecutionReverted, RevertReason: "reason",
+				Calls: []*vm.InternalTxTrace{
+					{
+						Type: "CALL", From: &contract, To: &to, Value: "0x0",
+						Calls: []*vm.InternalTxTrace{{Type: "STATICCALL", From: &to, To: &contract}},
+					},
+					{Type: "CREATE", From: &contract, To: &feePayer, Value: "0x10", Output: "0x60"},
+				},
+			},
+		},
+	}
+}
+
+func TestMakeInsertQuery(t *testing.T) {
+	logs := []*Log{{LogIndex: 0}, {LogIndex: 1}}
+	query, args := makeInsertQuery(LogTableName, logColumns, logs)
+
+	assert.Equal(t, "INSERT INTO logs (tx_hash, log_index, address, topic0, topic1, topic2, topic3, data) "+
+		"VALUES ($1,$2,$3,$4,$5,$6,$7,$8),($9,$10,$11,$12,$13,$14,$15,$16)", query)
+	assert.Len(t, args, 2*len(logColumns))
+	assert.Equal(t, 1, args[len(logColumns)+1])
+}
+
+func TestRowValues_NumColumns(t *testing.T) {
+	assert.Len(t, (&Block{}).values(), len(blockColumns))
+	assert.Len(t, (&Tx{}).values(), len(txColumns))
+	assert.Len(t, (&Receipt{}).values(), len(receiptColumns))
+	assert.Len(t, (&Log{}).values(), len(logColumns))
+	assert.Len(t, (&InternalTrace{}).values(), len(internalTraceColumns))
+}
+
+func TestTransformToBlockGroup(t *testing.T) {
+	event := makeTestChainEvent(t)
+	cInfo := consensus.ConsensusInfo{Proposer: common.HexToAddress("0x6"), Round: 2}
+
+	block, txs, receipts, logs := transformToBlockGroup(event, cInfo, params.TestChainConfig)
+
+	assert.Equal(t, int64(10), block.Number)
+	assert.Equal(t, event.Block.Hash().Bytes(), block.Hash)
+	assert.Equal(t, cInfo.Proposer.Bytes(), block.Proposer)
+	assert.Equal(t, 2, block.Round)
+	assert.Equal(t, 2, block.TxCount)
+	assert.Equal(t, int64(1000), block.Timestamp)
+	assert.Nil(t, block.BaseFee)
+
+	require.Len(t, txs, 2)
+	assert.Equal(t, address.Bytes(), txs[0].FromAddr)
+	assert.Nil(t, txs[0].FeePayer)
+	assert.Nil(t, txs[0].FeeRatio)
+	assert.Equal(t, "1", txs[0].Value)
+
+	assert.Equal(t, 1, txs[1].TxIndex)
+	assert.Equal(t, int(types.TxTypeFeeDelegatedValueTransferWithRatio), txs[1].TypeInt)
+	assert.Equal(t, address.Bytes(), txs[1].FromAddr)
+	assert.Equal(t, to.Bytes(), txs[1].ToAddr)
+	assert.Equal(t, feePayer.Bytes(), txs[1].FeePayer)
+	require.NotNil(t, txs[1].FeeRatio)
+	assert.Equal(t, 30, *txs[1].FeeRatio)
+
+	require.Len(t, receipts, 2)
+	assert.Nil(t, receipts[0].ContractAddress)
+	assert.Equal(t, "25", receipts[0].EffectiveGasPrice)
+	assert.Equal(t, int(types.ReceiptStatusErrExecutionReverted), receipts[1].Status)
+	assert.Equal(t, contract.Bytes(), receipts[1].ContractAddress)
+
+	require.Len(t, logs, 2)
+	assert.Equal(t, txs[1].Hash, logs[0].TxHash)
+	assert.Equal(t, common.HexToHash("0x1234").Bytes(), logs[0].Topics[0])
+	assert.Nil(t, logs[0].Topics[1])
+	assert.Equal(t, 1, logs[1].LogIndex)
+}
+
+func TestTransformToInternalTraces(t *testing.T) {
+	event := makeTestChainEvent(t)
+	traces := transformToInternalTraces(event)
+
+	var (
+		addresses []string
+		txIndexes []int
+	)
+	for _, trace := range traces {
+		addresses = append(addresses, trace.TraceAddress)
+		txIndexes = append(txIndexes, trace.TxIndex)
+	}
+	assert.Equal(t, []string{"", "", "0", "0.0", "1"}, addresses)
+	assert.Equal(t, []int{0, 1, 1, 1, 1}, txIndexes)
+
+	top := traces[1]
+	assert.Equal(t, event.Block.Transactions()[1].Hash().Bytes(), top.TxHash)
+	assert.Equal(t, []byte{0x12, 0x34}, top.Input)
+	require.NotNil(t, top.Error)
+	assert.Equal(t, vm.ErrExecutionReverted.Error(), *top.Error)
+	require.NotNil(t, top.RevertReason)
+	assert.Equal(t, "reason", *top.RevertReason)
+
+	assert.Nil(t, traces[3].Value)
+	require.NotNil(t, traces[4].Value)
+	assert.Equal(t, "16", *traces[4].Value)
+	assert.Equal(t, []byte{0x60}, traces[4].Output)
+}
+
+func TestRepository_HandleChainEvent_NotSupported(t *testing.T) {
+	repo := &repository{}
+	event := blockchain.ChainEvent{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})}
+	assert.Error(t, repo.HandleChainEvent(event, cfTypes.RequestTypeTransaction))
+}
+
+type SuiteRepository struct {
+	suite.Suite
+	repo *repository
+}
+
+func (s *SuiteRepository) SetupSuite() {
+	endpoint := getEndpoint(&PostgresConfig{DBHost: "localhost", DBPort: DefaultDBPort, DBUser: "postgres", DBName: "postgres", SSLMode: DefaultSSLMode})
+	db, err := sql.Open("postgres", endpoint)
+	if err == nil {
+		err = db.Ping()
+	}
+	if err != nil {
+		s.T().Log("Failed connecting to postgres", "err", err)
+		s.T().Skip()
+	}
+
+	for _, table := range []string{InternalTraceTableName, LogTableName, ReceiptTableName, TxTableName, BlockTableName, MetadataTableName} {
+		_, err := db.Exec("DROP TABLE IF EXISTS " + table)
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(createSchema(db))
+	s.repo = &repository{db: db}
+}
+
+func (s *SuiteRepository) TearDownSuite() {
+	if s.repo != nil {
+		s.repo.Close()
+	}
+}
+
+func (s *SuiteRepository) count(table string) int {
+	var n int
+	s.Require().NoError(s.repo.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n))
+	return n
+}
+
+func (s *SuiteRepository) TestCheckpoint() {
+	_, err := s.repo.db.Exec("DELETE FROM " + MetadataTableName)
+	s.Require().NoError(err)
+
+	// ReadCheckpoint returns 0 if no checkpoint is stored.
+	checkpoint, err := s.repo.ReadCheckpoint()
+	s.NoError(err)
+	s.Equal(int64(0), checkpoint)
+
+	for _, expected := range []int64{1912, 2024} {
+		s.NoError(s.repo.WriteCheckpoint(expected))
+		actual, err := s.repo.ReadCheckpoint()
+		s.NoError(err)
+		s.Equal(expected, actual)
+	}
+}
+
+func (s *SuiteRepository) TestInsertBlockGroup_Replace() {
+	event := makeTestChainEvent(s.T())
+	block, txs, receipts, logs := transformToBlockGroup(event, consensus.ConsensusInfo{}, params.TestChainConfig)
+
+	// inserting the same block group again replaces the existing rows.
+	s.Require().NoError(s.repo.insertBlockGroup(block, txs, receipts, logs))
+	s.Require().NoError(s.repo.insertBlockGroup(block, txs, receipts, logs))
+
+	s.Equal(1, s.count(BlockTableName))
+	s.Equal(len(txs), s.count(TxTableName))
+	s.Equal(len(receipts), s.count(ReceiptTableName))
+	s.Equal(len(logs), s.count(LogTableName))
+
+	var feeRatio sql.NullInt64
+	s.Require().NoError(s.repo.db.QueryRow("SELECT fee_ratio FROM " + TxTableName + " WHERE tx_index = 0").Scan(&feeRatio))
+	s.False(feeRatio.Valid)
+}
+
+func (s *SuiteRepository) TestInsertTraceGroup_Replace() {
+	event := makeTestChainEvent(s.T())
+	traces := transformToInternalTraces(event)
+
+	s.Require().NoError(s.repo.InsertTraceGroup(event))
+	s.Require().NoError(s.repo.InsertTraceGroup(event))
+	s.Equal(len(traces), s.count(InternalTraceTableName))
+
+	var value string
+	err := s.repo.db.QueryRow("SELECT value FROM " + InternalTraceTableName + " WHERE tx_index = 1 AND trace_address = '1'").Scan(&value)
+	s.Require().False(errors.Is(err, sql.ErrNoRows))
+	s.Require().NoError(err)
+	s.Equal("16", value)
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(SuiteRepository))
+}
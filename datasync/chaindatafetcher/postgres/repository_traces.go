@@ -0,0 +1,114 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+import (
+	"database/sql"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+)
+
+// transformToInternalTraces flattens the internal transaction traces in the given chain event
+// to the rows of call frames.
+func transformToInternalTraces(event blockchain.ChainEvent) []*InternalTrace {
+	var (
+		traces []*InternalTrace
+		number = event.Block.Number().Int64()
+		txs    = event.Block.Transactions()
+	)
+	for idx, trace := range event.InternalTxTraces {
+		if idx >= len(txs) {
+			logger.Warn("the number of traces exceeds the number of transactions", "blockNumber", number, "numTraces", len(event.InternalTxTraces), "numTxs", len(txs))
+			break
+		}
+		if trace == nil {
+			continue
+		}
+		traces = flattenTrace(traces, trace, number, idx, txs[idx].Hash(), "")
+	}
+	return traces
+}
+
+func flattenTrace(traces []*InternalTrace, trace *vm.InternalTxTrace, number int64, txIdx int, txHash common.Hash, traceAddress string) []*InternalTrace {
+	t := &InternalTrace{
+		BlockNumber:  number,
+		TxIndex:      txIdx,
+		TraceAddress: traceAddress,
+		TxHash:       txHash.Bytes(),
+		Type:         trace.Type,
+		Gas:          int64(trace.Gas),
+		GasUsed:      int64(trace.GasUsed),
+		Input:        common.FromHex(trace.Input),
+		Output:       common.FromHex(trace.Output),
+	}
+	if trace.From != nil {
+		t.FromAddr = trace.From.Bytes()
+	}
+	if trace.To != nil {
+		t.ToAddr = trace.To.Bytes()
+	}
+	if value, ok := new(big.Int).SetString(strings.TrimPrefix(trace.Value, "0x"), 16); ok {
+		v := value.String()
+		t.Value = &v
+	}
+	if trace.Error != nil {
+		e := trace.Error.Error()
+		t.Error = &e
+	}
+	if trace.RevertReason != "" {
+		reason := trace.RevertReason
+		t.RevertReason = &reason
+	}
+	traces = append(traces, t)
+
+	for i, call := range trace.Calls {
+		address := strconv.Itoa(i)
+		if traceAddress != "" {
+			address = traceAddress + "." + address
+		}
+		traces = flattenTrace(traces, call, number, txIdx, txHash, address)
+	}
+	return traces
+}
+
+// InsertTraceGroup inserts the internal transaction traces in the given chain event into the
+// database. The existing rows of the block are replaced.
+func (r *repository) InsertTraceGroup(event blockchain.ChainEvent) error {
+	if len(event.InternalTxTraces) == 0 {
+		return nil
+	}
+	traces := transformToInternalTraces(event)
+	if err := r.insertInternalTraces(event.Block.Number().Int64(), traces); err != nil {
+		logger.Error("Failed to insertInternalTraces", "err", err, "blockNumber", event.Block.NumberU64(), "numTraces", len(traces))
+		return err
+	}
+	return nil
+}
+
+func (r *repository) insertInternalTraces(number int64, traces []*InternalTrace) error {
+	return r.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM "+InternalTraceTableName+" WHERE block_number = $1", number); err != nil {
+			return err
+		}
+		return bulkInsert(tx, InternalTraceTableName, internalTraceColumns, traces)
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+// schema creates the tables if they do not exist. The transactions, receipts and logs
+// of a block are deleted together with the block row, so that a block group can be
+// inserted again. Internal traces are not linked to transactions with a foreign key
+// because trace groups and block groups of the same block are handled independently.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS blocks (
+		number       BIGINT PRIMARY KEY,
+		hash         BYTEA NOT NULL UNIQUE,
+		parent_hash  BYTEA NOT NULL,
+		proposer     BYTEA NOT NULL,
+		reward_base  BYTEA NOT NULL,
+		state_root   BYTEA NOT NULL,
+		tx_root      BYTEA NOT NULL,
+		receipt_root BYTEA NOT NULL,
+		block_score  NUMERIC(78) NOT NULL,
+		base_fee     NUMERIC(78),
+		gas_used     BIGINT NOT NULL,
+		timestamp    BIGINT NOT NULL,
+		size         BIGINT NOT NULL,
+		round        SMALLINT NOT NULL,
+		tx_count     INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS blocks_timestamp_idx ON blocks (timestamp)`,
+
+	`CREATE TABLE IF NOT EXISTS transactions (
+		hash         BYTEA PRIMARY KEY,
+		block_number BIGINT NOT NULL REFERENCES blocks (number) ON DELETE CASCADE,
+		tx_index     INTEGER NOT NULL,
+		type_int     INTEGER NOT NULL,
+		from_addr    BYTEA NOT NULL,
+		to_addr      BYTEA,
+		fee_payer    BYTEA,
+		fee_ratio    SMALLINT,
+		nonce        BIGINT NOT NULL,
+		gas          BIGINT NOT NULL,
+		gas_price    NUMERIC(78) NOT NULL,
+		value        NUMERIC(78) NOT NULL,
+		input        BYTEA,
+		UNIQUE (block_number, tx_index)
+	)`,
+	`CREATE INDEX IF NOT EXISTS transactions_from_addr_idx ON transactions (from_addr)`,
+	`CREATE INDEX IF NOT EXISTS transactions_to_addr_idx ON transactions (to_addr)`,
+	`CREATE INDEX IF NOT EXISTS transactions_fee_payer_idx ON transactions (fee_payer)`,
+
+	`CREATE TABLE IF NOT EXISTS receipts (
+		tx_hash             BYTEA PRIMARY KEY REFERENCES transactions (hash) ON DELETE CASCADE,
+		status              INTEGER NOT NULL,
+		gas_used            BIGINT NOT NULL,
+		effective_gas_price NUMERIC(78) NOT NULL,
+		contract_address    BYTEA,
+		logs_bloom          BYTEA NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS receipts_contract_address_idx ON receipts (contract_address)`,
+
+	`CREATE TABLE IF NOT EXISTS logs (
+		tx_hash   BYTEA NOT NULL REFERENCES transactions (hash) ON DELETE CASCADE,
+		log_index INTEGER NOT NULL,
+		address   BYTEA NOT NULL,
+		topic0    BYTEA,
+		topic1    BYTEA,
+		topic2    BYTEA,
+		topic3    BYTEA,
+		data      BYTEA,
+		PRIMARY KEY (tx_hash, log_index)
+	)`,
+	`CREATE INDEX IF NOT EXISTS logs_address_topic0_idx ON logs (address, topic0)`,
+
+	`CREATE TABLE IF NOT EXISTS internal_traces (
+		block_number  BIGINT NOT NULL,
+		tx_index      INTEGER NOT NULL,
+		trace_address TEXT NOT NULL,
+		tx_hash       BYTEA NOT NULL,
+		type          VARCHAR(16) NOT NULL,
+		from_addr     BYTEA,
+		to_addr       BYTEA,
+		value         NUMERIC(78),
+		gas           BIGINT NOT NULL,
+		gas_used      BIGINT NOT NULL,
+		input         BYTEA,
+		output        BYTEA,
+		error         TEXT,
+		revert_reason TEXT,
+		PRIMARY KEY (block_number, tx_index, trace_address)
+	)`,
+	`CREATE INDEX IF NOT EXISTS internal_traces_tx_hash_idx ON internal_traces (tx_hash)`,
+	`CREATE INDEX IF NOT EXISTS internal_traces_from_addr_idx ON internal_traces (from_addr)`,
+	`CREATE INDEX IF NOT EXISTS internal_traces_to_addr_idx ON internal_traces (to_addr)`,
+
+	`CREATE TABLE IF NOT EXISTS fetcher_metadata (
+		key   VARCHAR(30) PRIMARY KEY,
+		value BIGINT NOT NULL
+	)`,
+}
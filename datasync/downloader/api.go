@@ -25,6 +25,7 @@ package downloader
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/kaiachain/kaia"
 	"github.com/kaiachain/kaia/event"
@@ -40,8 +41,13 @@ type PublicDownloaderAPI struct {
 	uninstallSyncSubscription chan *uninstallSyncSubscriptionRequest
 }
 
+// syncStatusInterval is the interval of the sync status notifications while
+// the node is synchronising.
+const syncStatusInterval = 5 * time.Second
+
 type downloader interface {
 	Progress() kaia.SyncProgress
+	SyncStatus() *SyncStatus
 	SyncStakingInfo(id string, from, to uint64) error
 	SyncStakingInfoStatus() *SyncingStatus
 }
@@ -131,6 +137,56 @@ func (api *PublicDownloaderAPI) Syncing(ctx context.Context) (*rpc.Subscription,
 	return rpcSub, nil
 }
 
+// SyncStatus returns the detailed synchronisation status: the current stage,
+// the throughput and the downloaded bytes of every stage, the pivot block and
+// the estimated completion time. The status of the last synchronisation is
+// returned if the node is not synchronising.
+func (api *PublicDownloaderAPI) SyncStatus() *SyncStatus {
+	return api.d.SyncStatus()
+}
+
+// SyncProgress notifies the detailed synchronisation status when the node
+// starts synchronising, periodically during the synchronisation, and when it
+// is finished or failed.
+func (api *PublicDownloaderAPI) SyncProgress(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		sub := api.mux.Subscribe(StartEvent{}, DoneEvent{}, FailedEvent{})
+		defer sub.Unsubscribe()
+
+		ticker := time.NewTicker(syncStatusInterval)
+		defer ticker.Stop()
+
+		syncing := false
+		for {
+			select {
+			case event := <-sub.Chan():
+				if event == nil {
+					return
+				}
+				_, syncing = event.Data.(StartEvent)
+				notifier.Notify(rpcSub.ID, api.d.SyncStatus())
+			case <-ticker.C:
+				if syncing {
+					notifier.Notify(rpcSub.ID, api.d.SyncStatus())
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // SyncingResult provides information about the current synchronisation status for this node.
 type SyncingResult struct {
 	Syncing bool              `json:"syncing"`
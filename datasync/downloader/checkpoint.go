@@ -0,0 +1,195 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	istanbulCore "github.com/kaiachain/kaia/consensus/istanbul/core"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/kaiax/gov"
+	"github.com/kaiachain/kaia/kaiax/gov/headergov"
+	headergov_impl "github.com/kaiachain/kaia/kaiax/gov/headergov/impl"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/rlp"
+)
+
+var (
+	errCheckpointBehind   = errors.New("remote head is behind the checkpoint")
+	errNoCheckpointSigner = errors.New("no trusted signer of the checkpoint file")
+	errUntrustedSigner    = errors.New("checkpoint not signed by a trusted signer")
+)
+
+// Checkpoint is a trusted block from which a snap sync starts. The downloader
+// pins the pivot to it, rejects the peers whose chain doesn't include it and,
+// on an Istanbul chain, verifies the committed seals of every header up to it.
+type Checkpoint struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+func (c *Checkpoint) String() string {
+	return fmt.Sprintf("%d (%x)", c.Number, c.Hash)
+}
+
+// sigHash returns the hash signed by the signer of a checkpoint file.
+func (c *Checkpoint) sigHash() common.Hash {
+	data, _ := rlp.EncodeToBytes([]interface{}{"kaia checkpoint", c.Number, c.Hash})
+	return crypto.Keccak256Hash(data)
+}
+
+// SignedCheckpoint is the content of a checkpoint file.
+type SignedCheckpoint struct {
+	Checkpoint
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// SignCheckpoint signs the checkpoint with the key of a trusted signer.
+func SignCheckpoint(cp Checkpoint, key *ecdsa.PrivateKey) (*SignedCheckpoint, error) {
+	sig, err := crypto.Sign(cp.sigHash().Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedCheckpoint{Checkpoint: cp, Signature: sig}, nil
+}
+
+// Signer returns the address which signed the checkpoint.
+func (s *SignedCheckpoint) Signer() (common.Address, error) {
+	pub, err := crypto.SigToPub(s.sigHash().Bytes(), s.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// LoadCheckpoint reads the signed checkpoint file, accepting the checkpoint
+// only if it is signed by one of the trusted signers.
+func LoadCheckpoint(file string, signers []common.Address) (*Checkpoint, error) {
+	if len(signers) == 0 {
+		return nil, errNoCheckpointSigner
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var signed SignedCheckpoint
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %v", file, err)
+	}
+	signer, err := signed.Signer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint signature: %v", err)
+	}
+	for _, trusted := range signers {
+		if signer == trusted {
+			return &signed.Checkpoint, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errUntrustedSigner, signer.Hex())
+}
+
+// sealVerifier verifies the Istanbul committed seals of the headers up to the
+// checkpoint, which would otherwise only be linked to it by their hashes. The
+// validators are followed from the local parent of the first synced header,
+// and the committee sizes from the governance data of the epoch headers.
+type sealVerifier struct {
+	config     *params.ChainConfig
+	parent     *types.Header
+	validators []common.Address
+	committees map[uint64]uint64
+}
+
+// newSealVerifier creates a verifier of the headers following the parent, a
+// header of the local chain. The committee sizes set before the parent are
+// collected from the local epoch headers.
+func newSealVerifier(config *params.ChainConfig, getHeader func(uint64) *types.Header, parent *types.Header) (*sealVerifier, error) {
+	extra, err := types.ExtractIstanbulExtra(parent)
+	if err != nil {
+		return nil, err
+	}
+	v := &sealVerifier{
+		config:     config,
+		parent:     parent,
+		validators: extra.Validators,
+		committees: make(map[uint64]uint64),
+	}
+	if epoch := config.Istanbul.Epoch; epoch > 0 {
+		for number := uint64(0); number <= parent.Number.Uint64(); number += epoch {
+			if header := getHeader(number); header != nil {
+				v.trackGovernance(header)
+			}
+		}
+	}
+	return v, nil
+}
+
+// verify checks the committed seals of the headers following the last verified one.
+func (v *sealVerifier) verify(headers []*types.Header) error {
+	for _, header := range headers {
+		validators, err := istanbulCore.VerifyCommittedHeader(v.parent, header, v.validators, v.committeeSize(header.Number.Uint64()))
+		if err != nil {
+			return fmt.Errorf("header %d: %w", header.Number, err)
+		}
+		if epoch := v.config.Istanbul.Epoch; epoch > 0 && header.Number.Uint64()%epoch == 0 {
+			v.trackGovernance(header)
+		}
+		v.parent, v.validators = header, validators
+	}
+	return nil
+}
+
+// trackGovernance records the committee size changed by an epoch header.
+func (v *sealVerifier) trackGovernance(header *types.Header) {
+	if len(header.Governance) == 0 {
+		return
+	}
+	data, err := headergov.GovBytes(header.Governance).ToGovData()
+	if err != nil {
+		logger.Warn("Failed to parse the governance data", "number", header.Number, "err", err)
+		return
+	}
+	if size, ok := data.Items()[gov.IstanbulCommitteeSize].(uint64); ok {
+		v.committees[header.Number.Uint64()] = size
+	}
+}
+
+// committeeSize returns the committee size effective at the block number.
+func (v *sealVerifier) committeeSize(number uint64) uint64 {
+	var (
+		size  = v.config.Istanbul.SubGroupSize
+		epoch = v.config.Istanbul.Epoch
+	)
+	if epoch == 0 {
+		return size
+	}
+	prev := headergov_impl.PrevEpochStart(number, epoch, v.config.IsKoreForkEnabled(new(big.Int).SetUint64(number)))
+	latest := int64(-1)
+	for num, s := range v.committees {
+		if num <= prev && int64(num) > latest {
+			latest, size = int64(num), s
+		}
+	}
+	return size
+}
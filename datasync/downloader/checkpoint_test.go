@@ -0,0 +1,174 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	istanbulCore "github.com/kaiachain/kaia/consensus/istanbul/core"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/crypto/sha3"
+	"github.com/kaiachain/kaia/kaiax/gov"
+	"github.com/kaiachain/kaia/kaiax/gov/headergov"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCheckpoint(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	cp := Checkpoint{Number: 1024, Hash: common.HexToHash("0x1234")}
+	signed, err := SignCheckpoint(cp, key)
+	require.NoError(t, err)
+	data, err := json.Marshal(signed)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "checkpoint.json")
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+
+	loaded, err := LoadCheckpoint(file, []common.Address{crypto.PubkeyToAddress(other.PublicKey), signer})
+	require.NoError(t, err)
+	assert.Equal(t, cp, *loaded)
+
+	_, err = LoadCheckpoint(file, []common.Address{crypto.PubkeyToAddress(other.PublicKey)})
+	assert.ErrorIs(t, err, errUntrustedSigner)
+
+	_, err = LoadCheckpoint(file, nil)
+	assert.ErrorIs(t, err, errNoCheckpointSigner)
+
+	// A checkpoint altered after signing is recovered to another signer
+	signed.Number++
+	data, _ = json.Marshal(signed)
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+	_, err = LoadCheckpoint(file, []common.Address{signer})
+	assert.ErrorIs(t, err, errUntrustedSigner)
+}
+
+// makeSealedHeader makes a child of the parent declaring the validators, proposed
+// by the first committer and committed by all of them.
+func makeSealedHeader(t *testing.T, parent *types.Header, validators []common.Address, committers []*ecdsa.PrivateKey, governance []byte) *types.Header {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		Time:       new(big.Int).Add(parent.Time, common.Big1),
+		BlockScore: common.Big1,
+		Governance: governance,
+	}
+	extra := &types.IstanbulExtra{Validators: validators, Seal: []byte{}, CommittedSeal: [][]byte{}}
+	setExtra := func() {
+		payload, err := rlp.EncodeToBytes(extra)
+		require.NoError(t, err)
+		header.Extra = append(make([]byte, types.IstanbulExtraVanity), payload...)
+	}
+	setExtra()
+
+	var sigHash common.Hash
+	hasher := sha3.NewKeccak256()
+	rlp.Encode(hasher, types.IstanbulFilteredHeader(header, false))
+	hasher.Sum(sigHash[:0])
+	seal, err := crypto.Sign(crypto.Keccak256(sigHash.Bytes()), committers[0])
+	require.NoError(t, err)
+	extra.Seal = seal
+	setExtra()
+
+	proposal := crypto.Keccak256(istanbulCore.PrepareCommittedSeal(header.Hash()))
+	for _, key := range committers {
+		seal, err := crypto.Sign(proposal, key)
+		require.NoError(t, err)
+		extra.CommittedSeal = append(extra.CommittedSeal, seal)
+	}
+	setExtra()
+	return header
+}
+
+func TestSealVerifier(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 7)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := crypto.PubkeyToAddress(keys[i].PublicKey), crypto.PubkeyToAddress(keys[j].PublicKey)
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+	validators := make([]common.Address, len(keys))
+	for i, key := range keys {
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	config := &params.ChainConfig{Istanbul: &params.IstanbulConfig{Epoch: 4, SubGroupSize: 7}}
+	genesis := &types.Header{Number: common.Big0, Time: common.Big0, BlockScore: common.Big1}
+	payload, _ := rlp.EncodeToBytes(&types.IstanbulExtra{Validators: validators, Seal: []byte{}, CommittedSeal: [][]byte{}})
+	genesis.Extra = append(make([]byte, types.IstanbulExtraVanity), payload...)
+
+	// The quorum of the committee of 7 validators is 5 seals
+	chain := []*types.Header{genesis}
+	for i := 1; i <= 3; i++ {
+		chain = append(chain, makeSealedHeader(t, chain[i-1], validators, keys[:5], nil))
+	}
+	getHeader := func(number uint64) *types.Header {
+		if number < uint64(len(chain)) {
+			return chain[number]
+		}
+		return nil
+	}
+	v, err := newSealVerifier(config, getHeader, genesis)
+	require.NoError(t, err)
+	require.NoError(t, v.verify(chain[1:]))
+
+	v, _ = newSealVerifier(config, getHeader, chain[3])
+	header := makeSealedHeader(t, chain[3], validators, keys[:4], nil)
+	assert.True(t, errors.Is(v.verify([]*types.Header{header}), istanbulCore.ErrInvalidCommittedSeals))
+
+	// A validator set not committed by the trusted validators is rejected
+	outsiders := make([]*ecdsa.PrivateKey, 4)
+	addrs := make([]common.Address, len(outsiders))
+	for i := range outsiders {
+		outsiders[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(outsiders[i].PublicKey)
+	}
+	header = makeSealedHeader(t, chain[3], addrs, outsiders, nil)
+	assert.True(t, errors.Is(v.verify([]*types.Header{header}), istanbulCore.ErrUntrustedValidators))
+
+	// The committee size set by an epoch header applies from the next epoch on
+	data, err := headergov.NewGovData(gov.PartialParamSet{gov.IstanbulCommitteeSize: uint64(4)}).ToGovBytes()
+	require.NoError(t, err)
+	chain = append(chain, makeSealedHeader(t, chain[3], validators, keys[:5], data))
+	for i := 5; i <= 8; i++ {
+		chain = append(chain, makeSealedHeader(t, chain[i-1], validators, keys[:5], nil))
+	}
+	chain = append(chain, makeSealedHeader(t, chain[8], validators, keys[:3], nil))
+	v, _ = newSealVerifier(config, getHeader, chain[3])
+	require.NoError(t, v.verify(chain[4:]))
+	assert.Equal(t, uint64(4), v.committeeSize(9))
+
+	v, _ = newSealVerifier(config, getHeader, chain[7])
+	assert.Equal(t, uint64(4), v.committeeSize(9))
+	assert.True(t, errors.Is(v.verify([]*types.Header{makeSealedHeader(t, chain[7], validators, keys[:2], nil)}), istanbulCore.ErrInvalidCommittedSeals))
+}
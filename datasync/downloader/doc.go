@@ -30,12 +30,14 @@ it downloads "headers", "bodies", and "receipts" in parallel, merges them throug
 
 Downloader related functions and variables are defined in the files listed below.
   - api.go              : Console APIs to get synchronization information.
+  - checkpoint.go       : A definition of the trusted block pinning the pivot of the snap sync.
   - downloader.go       : Functions and variables to sync peer and block. And modules for QoS(Quality of Service).
   - downloader_test.go  : Functions for testing the downloader package.
   - events.go           : Definitions of event types.
   - metrics.go          : Metric variables for packet transmissions and receptions.
   - modes.go            : A definition of type for SyncMode including "FullSync", "FastSync", and "LightSync".
   - peer.go             : Functions that request a packet to a peer, check, and set the network status of a peer.
+  - progress.go         : Tracking of the stages of a synchronisation for the detailed sync status.
   - queue.go            : Functions for managing and scheduling received headers, bodies, and receipts.
   - types.go            : Definitions of the types for downloaded packets.
 */
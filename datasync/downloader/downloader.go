@@ -33,6 +33,7 @@ import (
 	"github.com/kaiachain/kaia"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
 	"github.com/kaiachain/kaia/event"
 	"github.com/kaiachain/kaia/kaiax/staking"
 	"github.com/kaiachain/kaia/log"
@@ -99,6 +100,17 @@ var (
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version (need version >= 62)")
 )
 
+// IsMisbehavior reports whether the reason of dropping a peer is the invalid data sent by the peer.
+func IsMisbehavior(reason error) bool {
+	return errors.Is(reason, errInvalidChain) || errors.Is(reason, errBadPeer) ||
+		errors.Is(reason, errInvalidAncestor) || errors.Is(reason, errEmptyHeaderSet)
+}
+
+// IsTimeout reports whether the reason of dropping a peer is that the peer didn't respond in time.
+func IsTimeout(reason error) bool {
+	return errors.Is(reason, errTimeout) || errors.Is(reason, errStallingPeer)
+}
+
 type Downloader struct {
 	mode uint32         // Synchronisation mode defining the strategy used (per sync cycle), use d.getMode() to get the SyncMode
 	mux  *event.TypeMux // Event multiplexer to announce sync operation events
@@ -121,7 +133,8 @@ type Downloader struct {
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
 	syncStatsState       stateSyncStats
-	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
+	syncStatsLock        sync.RWMutex     // Lock protecting the sync stats fields
+	progress             *progressTracker // Detailed progress of the stages of the synchronisation
 
 	lightchain    LightChain
 	blockchain    BlockChain
@@ -154,6 +167,8 @@ type Downloader struct {
 	pivotHeader *types.Header
 	pivotLock   sync.RWMutex
 
+	checkpoint *Checkpoint // Trusted block pinning the snap sync pivot, nil if the pivot is taken from the peers
+
 	stateSyncStart chan *stateSync
 	trackStateReq  chan *stateReq
 	stateCh        chan dataPack // [kaia/63] Channel receiving inbound node state data
@@ -183,6 +198,9 @@ type LightChain interface {
 	// GetHeaderByHash retrieves a header from the local chain.
 	GetHeaderByHash(common.Hash) *types.Header
 
+	// GetHeaderByNumber retrieves a canonical header from the local chain.
+	GetHeaderByNumber(uint64) *types.Header
+
 	// CurrentHeader retrieves the head header from the local chain.
 	CurrentHeader() *types.Header
 
@@ -264,6 +282,7 @@ func New(mode SyncMode, stateDB database.DBManager, stateBloom *statedb.SyncBloo
 		syncStatsState: stateSyncStats{
 			processed: stateDB.ReadFastTrieProgress(),
 		},
+		progress:      newProgressTracker(),
 		trackStateReq: make(chan *stateReq),
 	}
 	go dl.qosTuner()
@@ -304,6 +323,65 @@ func (d *Downloader) Progress() kaia.SyncProgress {
 	}
 }
 
+// SyncStatus returns the detailed status of the current synchronisation, or of
+// the last one if the downloader is idle. On top of the Progress, it reports
+// the throughput of every stage, the pivot block and the estimated completion.
+func (d *Downloader) SyncStatus() *SyncStatus {
+	var (
+		progress = d.Progress()
+		mode     = d.getMode()
+		status   = &SyncStatus{
+			Syncing:       d.Synchronising() && progress.CurrentBlock < progress.HighestBlock,
+			Mode:          mode,
+			StartingBlock: hexutil.Uint64(progress.StartingBlock),
+			CurrentBlock:  hexutil.Uint64(progress.CurrentBlock),
+			HighestBlock:  hexutil.Uint64(progress.HighestBlock),
+			Stages:        make(map[SyncStage]*StageStatus),
+		}
+	)
+	if mode == FastSync || mode == SnapSync {
+		d.pivotLock.RLock()
+		if d.pivotHeader != nil {
+			pivot := hexutil.Uint64(d.pivotHeader.Number.Uint64())
+			status.PivotBlock = &pivot
+		}
+		d.pivotLock.RUnlock()
+	}
+	switch {
+	case mode == FastSync:
+		d.progress.setPending(StageState, progress.KnownStates-progress.PulledStates)
+	case mode == SnapSync && d.SnapSyncer != nil:
+		d.updateSnapProgress()
+	}
+	d.progress.status(status, time.Now())
+	return status
+}
+
+// updateSnapProgress updates the stages of the snap sync from the snap syncer,
+// once the state sync has begun.
+func (d *Downloader) updateSnapProgress() {
+	if !d.progress.begun(StageAccounts) {
+		return
+	}
+	progress, pending := d.SnapSyncer.Progress()
+	switch pending.Stage {
+	case snap.StageAccounts:
+		d.progress.resume(StageAccounts)
+	case snap.StageStorage:
+		d.progress.finish(StageAccounts)
+	case snap.StageHealing:
+		d.progress.finish(StageAccounts)
+		d.progress.finish(StageStorage)
+		d.progress.finish(StageBytecodes)
+		d.progress.begin(StageHealing)
+	}
+	d.progress.set(StageAccounts, progress.AccountSynced, progress.AccountBytes, 0, pending.Completed)
+	d.progress.set(StageStorage, progress.StorageSynced, progress.StorageBytes, 0, pending.Completed)
+	d.progress.set(StageBytecodes, progress.BytecodeSynced, progress.BytecodeBytes, 0, 0)
+	d.progress.set(StageHealing, progress.TrienodeHealSynced+progress.BytecodeHealSynced,
+		progress.TrienodeHealBytes+progress.BytecodeHealBytes, pending.TrienodeHeal+pending.BytecodeHeal, 0)
+}
+
 func (d *Downloader) getMode() SyncMode {
 	return SyncMode(atomic.LoadUint32(&d.mode))
 }
@@ -348,6 +426,15 @@ func (d *Downloader) UnregisterPeer(id string) error {
 	return nil
 }
 
+// SetCheckpoint sets the trusted block from which the snap sync starts. It
+// must be called before the first synchronisation.
+func (d *Downloader) SetCheckpoint(cp *Checkpoint) {
+	d.checkpoint = cp
+	if cp != nil {
+		logger.Info("Snap sync pinned to a checkpoint", "number", cp.Number, "hash", cp.Hash)
+	}
+}
+
 func (d *Downloader) GetSnapSyncer() *snap.Syncer {
 	return d.SnapSyncer
 }
@@ -366,7 +453,7 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 		if d.dropPeer == nil {
 			logger.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", id)
 		} else {
-			d.dropPeer(id)
+			d.dropPeer(id, err)
 		}
 		return err
 	}
@@ -379,7 +466,7 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 		if d.dropPeer == nil {
 			logger.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", id)
 		} else {
-			d.dropPeer(id)
+			d.dropPeer(id, err)
 		}
 
 	default:
@@ -499,6 +586,15 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	if err != nil {
 		return err
 	}
+	if cp := d.checkpoint; cp != nil && (mode == FastSync || mode == SnapSync) && d.blockchain.CurrentBlock().NumberU64() < cp.Number {
+		// Pin the pivot to the trusted block, instead of the one suggested by the peer
+		if latest.Number.Uint64() < cp.Number {
+			return fmt.Errorf("%w: %d < %d", errCheckpointBehind, latest.Number, cp.Number)
+		}
+		if pivot, err = d.fetchCheckpoint(p, cp); err != nil {
+			return err
+		}
+	}
 	if (mode == FastSync || mode == SnapSync) && pivot == nil {
 		// If no pivot block was returned, the head is below the min full block
 		// threshold (i.e. new chain). In that case we won't really fast sync
@@ -518,6 +614,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	}
 	d.syncStatsChainHeight = height
 	d.syncStatsLock.Unlock()
+	d.progress.reset(d.Progress().CurrentBlock)
 
 	// Ensure our origin point is below any fast sync pivot point
 	if mode == FastSync || mode == SnapSync {
@@ -540,10 +637,14 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		d.syncInitHook(origin, height)
 	}
 
+	fetchReceipts := func() error { return d.fetchReceipts(origin + 1) }
+	if mode == FastSync || mode == SnapSync {
+		fetchReceipts = d.trackStage(StageReceipts, fetchReceipts)
+	}
 	fetchers := []func() error{
-		func() error { return d.fetchHeaders(p, origin+1) },     // Headers are always retrieved
-		func() error { return d.fetchBodies(origin + 1) },       // Bodies are retrieved during normal and fast sync
-		func() error { return d.fetchReceipts(origin + 1) },     // Receipts are retrieved during fast sync
+		d.trackStage(StageHeaders, func() error { return d.fetchHeaders(p, origin+1) }), // Headers are always retrieved
+		d.trackStage(StageBodies, func() error { return d.fetchBodies(origin + 1) }),    // Bodies are retrieved during normal and fast sync
+		fetchReceipts, // Receipts are retrieved during fast sync
 		func() error { return d.fetchStakingInfos(origin + 1) }, // StakingInfos are retrieved during fast sync
 		func() error { return d.processHeaders(origin+1, td) },
 	}
@@ -558,6 +659,19 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	return d.spawnSync(fetchers, p.id)
 }
 
+// trackStage wraps the fetcher of a stage, marking the stage running until the
+// fetcher completes.
+func (d *Downloader) trackStage(stage SyncStage, fetcher func() error) func() error {
+	return func() error {
+		d.progress.begin(stage)
+		err := fetcher()
+		if err == nil {
+			d.progress.finish(stage)
+		}
+		return err
+	}
+}
+
 // spawnSync runs d.process and all given fetcher functions to completion in
 // separate goroutines, returning the first error that appears.
 func (d *Downloader) spawnSync(fetchers []func() error, peerID string) error {
@@ -805,6 +919,48 @@ func (d *Downloader) fetchHead(p *peerConnection) (head *types.Header, pivot *ty
 	}
 }
 
+// fetchCheckpoint retrieves the header of the checkpoint from a remote peer,
+// and checks that the peer's chain includes the trusted block.
+func (d *Downloader) fetchCheckpoint(p *peerConnection, cp *Checkpoint) (*types.Header, error) {
+	p.logger.Debug("Retrieving remote checkpoint header", "number", cp.Number)
+	go p.peer.RequestHeadersByNumber(cp.Number, 1, 0, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				logger.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			headers := packet.(*headerPack).headers
+			if len(headers) != 1 {
+				return nil, fmt.Errorf("%w: returned headers %d != requested 1", errBadPeer, len(headers))
+			}
+			header := headers[0]
+			if header.Number.Uint64() != cp.Number || header.Hash() != cp.Hash {
+				return nil, fmt.Errorf("%w: checkpoint %d mismatch: have %x, want %x", errInvalidChain, header.Number, header.Hash(), cp.Hash)
+			}
+			p.logger.Debug("Remote checkpoint verified", "number", cp.Number, "hash", cp.Hash)
+			return header, nil
+
+		case <-timeout:
+			p.logger.Debug("Waiting for checkpoint header timed out", "elapsed", ttl)
+			return nil, errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+		case <-d.stakingInfoCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
+
 // findAncestor tries to locate the common ancestor link of the local chain and
 // a remote peers blockchain. In the general case when our node was in sync and
 // on the correct chain, checking the top N links should already get us a match.
@@ -1130,8 +1286,9 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64) error {
 				from += uint64(len(headers))
 			}
 			// If we're still skeleton filling fast sync, check pivot staleness
-			// before continuing to the next skeleton filling
-			if skeleton && pivot > 0 {
+			// before continuing to the next skeleton filling. A pivot pinned to
+			// a checkpoint never moves.
+			if skeleton && pivot > 0 && d.checkpoint == nil {
 				getNextPivot()
 			} else {
 				getHeaders(from)
@@ -1145,7 +1302,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64) error {
 			// Header retrieval timed out, consider the peer bad and drop
 			p.logger.Debug("Header request timed out", "elapsed", ttl)
 			headerTimeoutMeter.Mark(1)
-			d.dropPeer(p.id)
+			d.dropPeer(p.id, errTimeout)
 
 			// Finish the sync gracefully instead of dumping the gathered data though
 			for _, ch := range []chan bool{d.bodyWakeCh, d.receiptWakeCh, d.stakingInfoWakeCh} {
@@ -1399,7 +1556,7 @@ func (d *Downloader) fetchParts(deliveryCh chan dataPack, deliver func(dataPack)
 						if d.dropPeer == nil {
 							logger.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", pid)
 						} else {
-							d.dropPeer(pid)
+							d.dropPeer(pid, errStallingPeer)
 
 							// If this peer was the master peer, abort sync immediately
 							d.cancelLock.RLock()
@@ -1493,6 +1650,7 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 		rollback    []*types.Header
 		rollbackErr error
 		mode        = d.getMode()
+		seals       *sealVerifier // Verifier of the headers up to the checkpoint, created by the first chunk
 	)
 	defer func() {
 		if len(rollback) > 0 {
@@ -1591,6 +1749,36 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 				}
 				chunk := headers[:limit]
 
+				// Reject the chain if it doesn't include the trusted checkpoint
+				if err := d.checkCheckpoint(chunk); err != nil {
+					rollbackErr = err
+					return err
+				}
+				// Verify the committed seals of the headers up to the checkpoint
+				if cp := d.checkpoint; cp != nil && (mode == SnapSync || mode == FastSync) && d.blockchain.Config().Istanbul != nil &&
+					chunk[0].Number.Uint64() <= cp.Number {
+					if seals == nil {
+						parent := d.lightchain.GetHeaderByHash(chunk[0].ParentHash)
+						if parent == nil {
+							rollbackErr = errInvalidAncestor
+							return errInvalidAncestor
+						}
+						verifier, err := newSealVerifier(d.blockchain.Config(), d.lightchain.GetHeaderByNumber, parent)
+						if err != nil {
+							rollbackErr = err
+							return err
+						}
+						seals = verifier
+					}
+					last := len(chunk)
+					if end := chunk[len(chunk)-1].Number.Uint64(); end > cp.Number {
+						last -= int(end - cp.Number)
+					}
+					if err := seals.verify(chunk[:last]); err != nil {
+						rollbackErr = err
+						return fmt.Errorf("%w: %v", errInvalidChain, err)
+					}
+				}
 				// In case of header only syncing, validate the chunk immediately
 				if mode == SnapSync || mode == FastSync || mode == LightSync {
 					// Collect the yet unknown headers to mark them as uncertain
@@ -1723,6 +1911,23 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 	return nil
 }
 
+// checkCheckpoint returns an error if the contiguous headers include the
+// number of the checkpoint with another hash.
+func (d *Downloader) checkCheckpoint(headers []*types.Header) error {
+	cp := d.checkpoint
+	if cp == nil || len(headers) == 0 {
+		return nil
+	}
+	first := headers[0].Number.Uint64()
+	if cp.Number < first || cp.Number >= first+uint64(len(headers)) {
+		return nil
+	}
+	if hash := headers[cp.Number-first].Hash(); hash != cp.Hash {
+		return fmt.Errorf("%w: checkpoint %d mismatch: have %x, want %x", errInvalidChain, cp.Number, hash, cp.Hash)
+	}
+	return nil
+}
+
 // processFastSyncContent takes fetch results from the queue and writes them to the
 // database. It also controls the synchronisation of state nodes of the pivot block.
 func (d *Downloader) processFastSyncContent() error {
@@ -1789,8 +1994,9 @@ func (d *Downloader) processFastSyncContent() error {
 		} else {
 			results = append(append([]*fetchResult{oldPivot}, oldTail...), results...)
 		}
-		// Split around the pivot block and process the two sides via fast/full sync
-		if atomic.LoadInt32(&d.committed) == 0 {
+		// Split around the pivot block and process the two sides via fast/full sync.
+		// A pivot pinned to a checkpoint never moves.
+		if atomic.LoadInt32(&d.committed) == 0 && d.checkpoint == nil {
 			latest := results[len(results)-1].Header
 			// If the height is above the pivot block by 2 sets, it means the pivot
 			// become stale in the network and it was garbage collected, move to a
@@ -1921,17 +2127,42 @@ func (d *Downloader) commitPivotBlock(result *fetchResult) error {
 // DeliverHeaders injects a new batch of block headers received from a remote
 // node into the download schedule.
 func (d *Downloader) DeliverHeaders(id string, headers []*types.Header) (err error) {
-	return d.deliver(id, d.headerCh, &headerPack{id, headers}, headerInMeter, headerDropMeter)
+	if err = d.deliver(id, d.headerCh, &headerPack{id, headers}, headerInMeter, headerDropMeter); err == nil {
+		size := common.StorageSize(0)
+		for _, header := range headers {
+			size += header.Size()
+		}
+		d.progress.add(StageHeaders, len(headers), size)
+	}
+	return err
 }
 
 // DeliverBodies injects a new batch of block bodies received from a remote node.
 func (d *Downloader) DeliverBodies(id string, transactions [][]*types.Transaction) (err error) {
-	return d.deliver(id, d.bodyCh, &bodyPack{id, transactions}, bodyInMeter, bodyDropMeter)
+	if err = d.deliver(id, d.bodyCh, &bodyPack{id, transactions}, bodyInMeter, bodyDropMeter); err == nil {
+		size := common.StorageSize(0)
+		for _, txs := range transactions {
+			for _, tx := range txs {
+				size += tx.Size()
+			}
+		}
+		d.progress.add(StageBodies, len(transactions), size)
+	}
+	return err
 }
 
 // DeliverReceipts injects a new batch of receipts received from a remote node.
 func (d *Downloader) DeliverReceipts(id string, receipts [][]*types.Receipt) (err error) {
-	return d.deliver(id, d.receiptCh, &receiptPack{id, receipts}, receiptInMeter, receiptDropMeter)
+	if err = d.deliver(id, d.receiptCh, &receiptPack{id, receipts}, receiptInMeter, receiptDropMeter); err == nil {
+		size := common.StorageSize(0)
+		for _, rs := range receipts {
+			for _, r := range rs {
+				size += r.Size()
+			}
+		}
+		d.progress.add(StageReceipts, len(receipts), size)
+	}
+	return err
 }
 
 // DeliverStakingInfos injects a new batch of staking information received from a remote node.
@@ -59,6 +59,7 @@ func (*FakeDownloader) Synchronise(id string, head common.Hash, td *big.Int, mod
 	return nil
 }
 func (*FakeDownloader) Progress() kaia.SyncProgress { return kaia.SyncProgress{} }
+func (*FakeDownloader) SyncStatus() *SyncStatus     { return &SyncStatus{} }
 func (*FakeDownloader) Cancel()                     {}
 
 func (*FakeDownloader) GetSnapSyncer() *snap.Syncer                      { return nil }
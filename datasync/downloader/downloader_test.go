@@ -36,6 +36,7 @@ import (
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
 	"github.com/kaiachain/kaia/consensus/gxhash"
 	"github.com/kaiachain/kaia/consensus/istanbul"
 	"github.com/kaiachain/kaia/crypto"
@@ -332,6 +333,17 @@ func (dl *downloadTester) GetHeaderByHash(hash common.Hash) *types.Header {
 	return dl.ownHeaders[hash]
 }
 
+// GetHeaderByNumber retrieves a header from the testers canonical chain by its number.
+func (dl *downloadTester) GetHeaderByNumber(number uint64) *types.Header {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if number >= uint64(len(dl.ownHashes)) {
+		return nil
+	}
+	return dl.ownHeaders[dl.ownHashes[number]]
+}
+
 // GetBlock retrieves a block from the testers canonical chain.
 func (dl *downloadTester) GetBlockByHash(hash common.Hash) *types.Block {
 	dl.lock.RLock()
@@ -572,7 +584,7 @@ func (dl *downloadTester) newSlowPeer(id string, version int, hashes []common.Ha
 }
 
 // dropPeer simulates a hard peer removal from the connection pool.
-func (dl *downloadTester) dropPeer(id string) {
+func (dl *downloadTester) dropPeer(id string, reason error) {
 	dl.lock.Lock()
 	defer dl.lock.Unlock()
 
@@ -1960,3 +1972,120 @@ func testStakingInfoSync(t *testing.T, protocol int) {
 		assert.JSONEq(t, string(expected), string(actual))
 	}
 }
+
+// Tests that a fast sync pinned to a checkpoint succeeds if the peer's chain
+// includes the trusted block, and fails otherwise.
+func TestCheckpointSynchronisation(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester(t)
+	defer tester.terminate()
+
+	targetBlocks := blockCacheMaxItems - 15
+	hashes, headers, blocks, receipts, stakingInfos := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", 65, hashes, headers, blocks, receipts, stakingInfos)
+
+	number := uint64(targetBlocks / 2)
+	tester.downloader.SetCheckpoint(&Checkpoint{Number: number, Hash: hashes[targetBlocks-int(number)]})
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	// The pivot is pinned to the checkpoint instead of the one suggested by the peer
+	tester.downloader.pivotLock.RLock()
+	pivot := tester.downloader.pivotHeader
+	tester.downloader.pivotLock.RUnlock()
+	if pivot == nil || pivot.Number.Uint64() != number || pivot.Hash() != hashes[targetBlocks-int(number)] {
+		t.Fatalf("pivot mismatch: have %v, want %d", pivot, number)
+	}
+	if hs := len(tester.ownHeaders); hs != targetBlocks+1 {
+		t.Fatalf("synchronised headers mismatch: have %v, want %v", hs, targetBlocks+1)
+	}
+	if bs := len(tester.ownBlocks); bs != targetBlocks+1 {
+		t.Fatalf("synchronised blocks mismatch: have %v, want %v", bs, targetBlocks+1)
+	}
+	if rs := len(tester.ownReceipts); rs != int(number)+1 {
+		t.Fatalf("synchronised receipts mismatch: have %v, want %v", rs, number+1)
+	}
+}
+
+func TestCheckpointMismatch(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester(t)
+	defer tester.terminate()
+
+	targetBlocks := 2 * MaxHeaderFetch
+	hashes, headers, blocks, receipts, stakingInfos := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", 65, hashes, headers, blocks, receipts, stakingInfos)
+
+	tester.downloader.SetCheckpoint(&Checkpoint{Number: uint64(MaxHeaderFetch), Hash: common.HexToHash("0xdeadbeef")})
+	if err := tester.sync("peer", nil, FastSync); !errors.Is(err, errInvalidChain) {
+		t.Fatalf("synchronisation error mismatch: have %v, want %v", err, errInvalidChain)
+	}
+	if head := tester.CurrentHeader().Number.Uint64(); head != 0 {
+		t.Fatalf("headers imported from the untrusted chain: head %d", head)
+	}
+}
+
+func TestCheckpointBehind(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester(t)
+	defer tester.terminate()
+
+	targetBlocks := MaxHeaderFetch
+	hashes, headers, blocks, receipts, stakingInfos := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", 65, hashes, headers, blocks, receipts, stakingInfos)
+
+	tester.downloader.SetCheckpoint(&Checkpoint{Number: uint64(2 * targetBlocks), Hash: common.HexToHash("0xdeadbeef")})
+	if err := tester.sync("peer", nil, FastSync); !errors.Is(err, errCheckpointBehind) {
+		t.Fatalf("synchronisation error mismatch: have %v, want %v", err, errCheckpointBehind)
+	}
+}
+
+func TestSyncStatus(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester(t)
+	defer tester.terminate()
+
+	targetBlocks := blockCacheMaxItems - 15
+	hashes, headers, blocks, receipts, stakingInfos := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", 65, hashes, headers, blocks, receipts, stakingInfos)
+
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	status := tester.downloader.SyncStatus()
+	assert.False(t, status.Syncing)
+	assert.Equal(t, FastSync, status.Mode)
+	assert.Empty(t, status.Stage)
+	assert.Equal(t, hexutil.Uint64(targetBlocks), status.HighestBlock)
+	if assert.NotNil(t, status.PivotBlock) {
+		assert.Equal(t, hexutil.Uint64(targetBlocks-fsMinFullBlocks), *status.PivotBlock)
+	}
+	for _, stage := range []SyncStage{StageHeaders, StageBodies, StageReceipts} {
+		if assert.Contains(t, status.Stages, stage) {
+			assert.True(t, status.Stages[stage].Done, stage)
+			assert.NotZero(t, status.Stages[stage].Items, stage)
+		}
+	}
+	assert.GreaterOrEqual(t, uint64(status.Stages[StageHeaders].Items), uint64(targetBlocks))
+	assert.NotZero(t, status.BytesDownloaded)
+}
+
+// Tests that the reasons of dropping peers are classified into misbehaviors and timeouts.
+func TestDropReasons(t *testing.T) {
+	for _, err := range []error{errInvalidChain, fmt.Errorf("%w: %v", errInvalidChain, errors.New("broken")), errBadPeer, errInvalidAncestor, errEmptyHeaderSet} {
+		assert.True(t, IsMisbehavior(err), err)
+		assert.False(t, IsTimeout(err), err)
+	}
+	for _, err := range []error{errTimeout, errStallingPeer} {
+		assert.False(t, IsMisbehavior(err), err)
+		assert.True(t, IsTimeout(err), err)
+	}
+	for _, err := range []error{errPeersUnavailable, errTooOld} {
+		assert.False(t, IsMisbehavior(err), err)
+		assert.False(t, IsTimeout(err), err)
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+)
+
+// SyncStage is a stage of the synchronisation pipeline.
+type SyncStage string
+
+const (
+	StageHeaders   SyncStage = "headers"   // Header retrieval
+	StageBodies    SyncStage = "bodies"    // Block body retrieval
+	StageReceipts  SyncStage = "receipts"  // Receipt retrieval of the fast and snap sync
+	StageState     SyncStage = "state"     // State trie retrieval of the fast sync
+	StageAccounts  SyncStage = "accounts"  // Account range retrieval of the snap sync
+	StageStorage   SyncStage = "storage"   // Storage range retrieval of the snap sync
+	StageBytecodes SyncStage = "bytecodes" // Bytecode retrieval of the snap sync
+	StageHealing   SyncStage = "healing"   // State healing of the snap sync
+)
+
+// syncStages lists the stages in the order of the pipeline. The current stage
+// is the first one still running.
+var syncStages = []SyncStage{StageHeaders, StageBodies, StageReceipts, StageState, StageAccounts, StageStorage, StageBytecodes, StageHealing}
+
+// SyncStatus is the detailed status of the current synchronisation, or of the
+// last one if the node is not synchronising.
+type SyncStatus struct {
+	Syncing         bool                       `json:"syncing"`
+	Mode            SyncMode                   `json:"mode"`
+	Stage           SyncStage                  `json:"stage,omitempty"`
+	StartingBlock   hexutil.Uint64             `json:"startingBlock"`
+	CurrentBlock    hexutil.Uint64             `json:"currentBlock"`
+	HighestBlock    hexutil.Uint64             `json:"highestBlock"`
+	PivotBlock      *hexutil.Uint64            `json:"pivotBlock,omitempty"`
+	BytesDownloaded hexutil.Uint64             `json:"bytesDownloaded"`
+	Elapsed         float64                    `json:"elapsed"`                       // Seconds since the synchronisation started
+	ETA             *float64                   `json:"eta,omitempty"`                 // Estimated seconds to the completion
+	Completion      *time.Time                 `json:"estimatedCompletion,omitempty"` // Estimated completion time
+	Stages          map[SyncStage]*StageStatus `json:"stages"`
+}
+
+// StageStatus is the status of a stage of the synchronisation. The throughput
+// is measured from the first time the stage is observed in the current
+// synchronisation, as the counters of the snap sync survive the restarts.
+type StageStatus struct {
+	Items          hexutil.Uint64 `json:"items"`
+	Bytes          hexutil.Uint64 `json:"bytes"` // Approximate bytes for the headers, bodies and receipts
+	Pending        hexutil.Uint64 `json:"pending,omitempty"`
+	ItemsPerSecond float64        `json:"itemsPerSecond"`
+	BytesPerSecond float64        `json:"bytesPerSecond"`
+	Done           bool           `json:"done"`
+}
+
+// stageStats is the progress of a stage tracked by progressTracker.
+type stageStats struct {
+	finished bool
+
+	items, bytes uint64
+	pending      uint64
+	completed    float64 // Estimated fraction of the stage completed, zero if unknown
+
+	// First observation of the stage, the origin of the throughput
+	marked        bool
+	markTime      time.Time
+	markItems     uint64
+	markBytes     uint64
+	markCompleted float64
+}
+
+// rate returns the items per second and the fraction completed per second
+// since the first observation.
+func (s *stageStats) rate(now time.Time) (items, bytes, completed float64) {
+	elapsed := now.Sub(s.markTime).Seconds()
+	if elapsed <= 0 || s.items < s.markItems || s.bytes < s.markBytes {
+		return 0, 0, 0
+	}
+	return float64(s.items-s.markItems) / elapsed, float64(s.bytes-s.markBytes) / elapsed, (s.completed - s.markCompleted) / elapsed
+}
+
+// eta estimates the seconds to complete the stage, from the pending items or
+// the fraction completed.
+func (s *stageStats) eta(now time.Time) (float64, bool) {
+	if s.finished {
+		return 0, true
+	}
+	items, _, completed := s.rate(now)
+	switch {
+	case s.completed > 0 && completed > 0:
+		return (1 - s.completed) / completed, true
+	case s.pending > 0 && items > 0:
+		return float64(s.pending) / items, true
+	}
+	return 0, false
+}
+
+// progressTracker tracks the stages of a synchronisation.
+type progressTracker struct {
+	lock sync.Mutex
+
+	start      time.Time
+	startBlock uint64 // Current block when the synchronisation started
+	stages     map[SyncStage]*stageStats
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{stages: make(map[SyncStage]*stageStats)}
+}
+
+// reset starts tracking a new synchronisation from the current block.
+func (t *progressTracker) reset(current uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.start = time.Now()
+	t.startBlock = current
+	t.stages = make(map[SyncStage]*stageStats)
+}
+
+// begin marks the stage running. A stage begun again keeps its counters.
+func (t *progressTracker) begin(stage SyncStage) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s, ok := t.stages[stage]; ok {
+		s.finished = false
+		return
+	}
+	t.stages[stage] = &stageStats{markTime: time.Now()}
+}
+
+// resume marks a begun stage running again.
+func (t *progressTracker) resume(stage SyncStage) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s, ok := t.stages[stage]; ok {
+		s.finished = false
+	}
+}
+
+// begun returns whether the stage has begun in the current synchronisation.
+func (t *progressTracker) begun(stage SyncStage) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	_, ok := t.stages[stage]
+	return ok
+}
+
+// finish marks the stage done.
+func (t *progressTracker) finish(stage SyncStage) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s, ok := t.stages[stage]; ok {
+		s.finished = true
+		s.pending = 0
+	}
+}
+
+// add accumulates the items retrieved by a running stage.
+func (t *progressTracker) add(stage SyncStage, items int, bytes common.StorageSize) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s, ok := t.stages[stage]; ok {
+		s.items += uint64(items)
+		s.bytes += uint64(bytes)
+	}
+}
+
+// setPending updates the number of the items known to be pending.
+func (t *progressTracker) setPending(stage SyncStage, pending uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s, ok := t.stages[stage]; ok && !s.finished {
+		s.pending = pending
+	}
+}
+
+// set updates the counters of a running stage from the cumulative ones.
+func (t *progressTracker) set(stage SyncStage, items uint64, bytes common.StorageSize, pending uint64, completed float64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s, ok := t.stages[stage]
+	if !ok {
+		return
+	}
+	if !s.marked {
+		s.marked = true
+		s.markTime, s.markItems, s.markBytes, s.markCompleted = time.Now(), items, uint64(bytes), completed
+	}
+	s.items, s.bytes, s.pending, s.completed = items, uint64(bytes), pending, completed
+}
+
+// status fills the stages of the status, and estimates the completion from
+// the block progress and the state stages.
+func (t *progressTracker) status(status *SyncStatus, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.start.IsZero() {
+		return
+	}
+	status.Elapsed = now.Sub(t.start).Seconds()
+
+	var (
+		eta       float64
+		estimated bool
+	)
+	for _, stage := range syncStages {
+		s, ok := t.stages[stage]
+		if !ok {
+			continue
+		}
+		items, bytes, _ := s.rate(now)
+		status.Stages[stage] = &StageStatus{
+			Items:          hexutil.Uint64(s.items),
+			Bytes:          hexutil.Uint64(s.bytes),
+			Pending:        hexutil.Uint64(s.pending),
+			ItemsPerSecond: items,
+			BytesPerSecond: bytes,
+			Done:           s.finished,
+		}
+		status.BytesDownloaded += hexutil.Uint64(s.bytes)
+		if status.Stage == "" && !s.finished && status.Syncing {
+			status.Stage = stage
+		}
+		if (stage == StageState || stage == StageAccounts || stage == StageStorage || stage == StageHealing) && status.Syncing {
+			if d, ok := s.eta(now); ok && d >= eta {
+				eta, estimated = d, true
+			}
+		}
+	}
+	if !status.Syncing {
+		return
+	}
+	// The blocks are imported at the rate observed from the start
+	if current, highest := uint64(status.CurrentBlock), uint64(status.HighestBlock); current > t.startBlock && highest > current {
+		rate := float64(current-t.startBlock) / status.Elapsed
+		if d := float64(highest-current) / rate; d >= eta {
+			eta, estimated = d, true
+		}
+	}
+	if estimated {
+		completion := now.Add(time.Duration(eta * float64(time.Second))).UTC()
+		status.ETA, status.Completion = &eta, &completion
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressTrackerStages(t *testing.T) {
+	tracker := newProgressTracker()
+	tracker.reset(100)
+
+	// Stages not begun are not tracked
+	tracker.add(StageHeaders, 10, 1000)
+	status := &SyncStatus{Syncing: true, Stages: make(map[SyncStage]*StageStatus)}
+	tracker.status(status, time.Now())
+	assert.Empty(t, status.Stages)
+
+	for _, stage := range []SyncStage{StageHeaders, StageBodies, StageReceipts} {
+		tracker.begin(stage)
+	}
+	tracker.add(StageHeaders, 10, 1000)
+	tracker.add(StageBodies, 5, 500)
+
+	status = &SyncStatus{Syncing: true, Stages: make(map[SyncStage]*StageStatus)}
+	tracker.status(status, time.Now())
+	assert.Equal(t, StageHeaders, status.Stage)
+	assert.Len(t, status.Stages, 3)
+	assert.EqualValues(t, 10, status.Stages[StageHeaders].Items)
+	assert.EqualValues(t, 1500, status.BytesDownloaded)
+
+	// The current stage is the first one still running
+	tracker.finish(StageHeaders)
+	status = &SyncStatus{Syncing: true, Stages: make(map[SyncStage]*StageStatus)}
+	tracker.status(status, time.Now())
+	assert.Equal(t, StageBodies, status.Stage)
+	assert.True(t, status.Stages[StageHeaders].Done)
+
+	// A stage begun again keeps its counters
+	tracker.begin(StageHeaders)
+	status = &SyncStatus{Syncing: true, Stages: make(map[SyncStage]*StageStatus)}
+	tracker.status(status, time.Now())
+	assert.Equal(t, StageHeaders, status.Stage)
+	assert.EqualValues(t, 10, status.Stages[StageHeaders].Items)
+
+	// No stage is current if the node is not syncing
+	status = &SyncStatus{Stages: make(map[SyncStage]*StageStatus)}
+	tracker.status(status, time.Now())
+	assert.Empty(t, status.Stage)
+	assert.Nil(t, status.ETA)
+}
+
+func TestProgressTrackerETA(t *testing.T) {
+	tracker := newProgressTracker()
+	tracker.reset(100)
+	start := tracker.start
+
+	// 100 blocks imported in 10 seconds, 400 blocks left
+	status := &SyncStatus{Syncing: true, CurrentBlock: 200, HighestBlock: 600, Stages: make(map[SyncStage]*StageStatus)}
+	tracker.status(status, start.Add(10*time.Second))
+	if assert.NotNil(t, status.ETA) {
+		assert.InDelta(t, 40, *status.ETA, 0.001)
+		assert.WithinDuration(t, start.Add(50*time.Second), *status.Completion, time.Millisecond)
+	}
+
+	// The snap sync estimates from the fraction of the accounts completed,
+	// measured from the first observation of the stage
+	tracker.begin(StageAccounts)
+	tracker.set(StageAccounts, 1000, 1000, 0, 0.2)
+	tracker.stages[StageAccounts].markTime = start
+	tracker.set(StageAccounts, 2000, 3000, 0, 0.3)
+
+	status = &SyncStatus{Syncing: true, CurrentBlock: 200, HighestBlock: 600, Stages: make(map[SyncStage]*StageStatus)}
+	tracker.status(status, start.Add(10*time.Second))
+	assert.Equal(t, StageAccounts, status.Stage)
+	assert.InDelta(t, 100, status.Stages[StageAccounts].ItemsPerSecond, 0.001)
+	assert.InDelta(t, 200, status.Stages[StageAccounts].BytesPerSecond, 0.001)
+	if assert.NotNil(t, status.ETA) {
+		assert.InDelta(t, 70, *status.ETA, 0.001)
+	}
+
+	// The healing estimates from the pending items
+	tracker.finish(StageAccounts)
+	tracker.begin(StageHealing)
+	tracker.set(StageHealing, 0, 0, 0, 0)
+	tracker.stages[StageHealing].markTime = start
+	tracker.set(StageHealing, 5000, 5000, 10000, 0)
+
+	status = &SyncStatus{Syncing: true, CurrentBlock: 599, HighestBlock: 600, Stages: make(map[SyncStage]*StageStatus)}
+	tracker.status(status, start.Add(10*time.Second))
+	assert.Equal(t, StageHealing, status.Stage)
+	if assert.NotNil(t, status.ETA) {
+		assert.InDelta(t, 20, *status.ETA, 0.001)
+	}
+}
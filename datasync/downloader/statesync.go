@@ -319,12 +319,27 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 // it finishes, and finally notifying any goroutines waiting for the loop to
 // finish.
 func (s *stateSync) run() {
+	stages := []SyncStage{StageState}
+	if s.d.snapSync {
+		stages = []SyncStage{StageAccounts, StageStorage, StageBytecodes}
+	}
+	for _, stage := range stages {
+		s.d.progress.begin(stage)
+	}
 	close(s.started)
 	if s.d.snapSync {
 		s.err = s.d.SnapSyncer.Sync(s.root, s.cancel)
+		if s.err == nil {
+			stages = append(stages, StageHealing)
+		}
 	} else {
 		s.err = s.loop()
 	}
+	if s.err == nil {
+		for _, stage := range stages {
+			s.d.progress.finish(stage)
+		}
+	}
 	close(s.done)
 }
 
@@ -385,7 +400,7 @@ func (s *stateSync) loop() (err error) {
 				if s.d.dropPeer == nil {
 					req.peer.logger.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", req.peer.id)
 				} else {
-					s.d.dropPeer(req.peer.id)
+					s.d.dropPeer(req.peer.id, errStallingPeer)
 
 					// If this peer was the master peer, abort sync immediately
 					s.d.cancelLock.RLock()
@@ -424,6 +439,7 @@ func (s *stateSync) commit(force bool) error {
 		return fmt.Errorf("DB write error: %v", err)
 	}
 	s.updateStats(s.numUncommitted, 0, 0, time.Since(start))
+	s.d.progress.add(StageState, s.numUncommitted, common.StorageSize(s.bytesUncommitted))
 	s.numUncommitted = 0
 	s.bytesUncommitted = 0
 	return nil
@@ -30,7 +30,8 @@ import (
 )
 
 // peerDropFn is a callback type for dropping a peer detected as malicious.
-type peerDropFn func(id string)
+// The reason is the error that caused the drop.
+type peerDropFn func(id string, reason error)
 
 // dataPack is a data message returned by a peer for some query.
 type dataPack interface {
@@ -3,10 +3,12 @@ package impl
 import (
 	"math/big"
 
+	"github.com/kaiachain/kaia/accounts/abi/bind"
 	"github.com/kaiachain/kaia/accounts/abi/bind/backends"
 	"github.com/kaiachain/kaia/common"
 	govcontract "github.com/kaiachain/kaia/contracts/contracts/system_contracts/gov"
 	"github.com/kaiachain/kaia/kaiax/gov"
+	"github.com/kaiachain/kaia/kaiax/gov/contractgov"
 )
 
 // EffectiveParamSet returns default parameter set in case of the following errors:
@@ -39,6 +41,111 @@ func (c *contractGovModule) EffectiveParamsPartial(blockNum uint64) gov.PartialP
 	return m
 }
 
+// EffectiveParamsPartialFromAddr is like EffectiveParamsPartial, but reads the given GovParam contract
+// instead of the one registered in headergov. It is used to preview a pending govparamcontract vote.
+func (c *contractGovModule) EffectiveParamsPartialFromAddr(blockNum uint64, addr common.Address) gov.PartialParamSet {
+	if common.EmptyAddress(addr) {
+		return nil
+	}
+	m, err := c.contractGetAllParamsAtFromAddr(blockNum, addr)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+// ParamCheckpoints returns the checkpoints of the given parameter stored in the GovParam contract at addr,
+// read from the latest state. The transaction that scheduled each checkpoint is found from the SetParam events.
+func (c *contractGovModule) ParamCheckpoints(addr common.Address, name gov.ParamName) ([]contractgov.ParamCheckpoint, error) {
+	if common.EmptyAddress(addr) {
+		return nil, nil
+	}
+
+	caller := backends.NewBlockchainContractBackend(c.Chain, nil, nil)
+	contract, err := govcontract.NewGovParamCaller(addr, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := contract.Checkpoints(nil, string(name))
+	if err != nil {
+		return nil, err
+	}
+
+	txHashes := c.setParamTxHashes(caller, addr, name)
+	ret := make([]contractgov.ParamCheckpoint, 0, len(checkpoints))
+	for _, ckpt := range checkpoints {
+		activation := ckpt.Activation.Uint64()
+		ckptRet := contractgov.ParamCheckpoint{Activation: activation}
+		if txHash, ok := txHashes[activation]; ok {
+			ckptRet.TxHash = &txHash
+		}
+		ret = append(ret, ckptRet)
+	}
+	return ret, nil
+}
+
+// setParamLogs caches the SetParam transactions of a GovParam contract, keyed by the parameter name
+// and the activation block. The events are filtered up to next-1 so far.
+type setParamLogs struct {
+	next     uint64
+	txHashes map[string]map[uint64]common.Hash
+}
+
+// setParamTxHashes returns the hashes of the transactions that emitted SetParam for the given parameter,
+// keyed by the activation block. If a checkpoint is set more than once, the latest transaction is kept.
+// The events are cached per contract, so each lookup only filters the blocks added since the previous one.
+// Failing to filter the events is not fatal; the checkpoints are returned without the transactions.
+func (c *contractGovModule) setParamTxHashes(backend *backends.BlockchainContractBackend, addr common.Address, name gov.ParamName) map[uint64]common.Hash {
+	c.setParamMu.Lock()
+	defer c.setParamMu.Unlock()
+
+	if c.setParamCache == nil {
+		c.setParamCache = make(map[common.Address]*setParamLogs)
+	}
+	logs, ok := c.setParamCache[addr]
+	if !ok {
+		logs = &setParamLogs{txHashes: make(map[string]map[uint64]common.Hash)}
+		c.setParamCache[addr] = logs
+	}
+	if head := c.Chain.CurrentBlock().NumberU64(); logs.next <= head {
+		if err := logs.filter(backend, addr, head); err != nil {
+			logger.Warn("Failed to filter SetParam events", "addr", addr, "err", err)
+		}
+	}
+
+	ret := make(map[uint64]common.Hash)
+	for activation, txHash := range logs.txHashes[string(name)] {
+		ret[activation] = txHash
+	}
+	return ret
+}
+
+// filter adds the SetParam events in [next, head] to the cache.
+func (l *setParamLogs) filter(backend *backends.BlockchainContractBackend, addr common.Address, head uint64) error {
+	filterer, err := govcontract.NewGovParamFilterer(addr, backend)
+	if err != nil {
+		return err
+	}
+	it, err := filterer.FilterSetParam(&bind.FilterOpts{Start: l.next, End: &head})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if l.txHashes[it.Event.Name] == nil {
+			l.txHashes[it.Event.Name] = make(map[uint64]common.Hash)
+		}
+		l.txHashes[it.Event.Name][it.Event.Activation.Uint64()] = it.Event.Raw.TxHash
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	l.next = head + 1
+	return nil
+}
+
 func (c *contractGovModule) contractGetAllParamsAt(blockNum uint64) (gov.PartialParamSet, error) {
 	addr, err := c.contractAddrAt(blockNum)
 	if err != nil {
@@ -107,3 +107,41 @@ func TestEffectiveParamSet(t *testing.T) {
 		assert.Equal(t, uint64(125), ps.UnitPrice)
 	}
 }
+
+func TestParamCheckpoints(t *testing.T) {
+	log.EnableLogForTest(log.LvlCrit, log.LvlError)
+	paramName := gov.GovernanceUnitPrice
+	accounts, sim, addr, gp := createSimulateBackend(t)
+	cgm := prepareContractGovModule(t, sim.BlockChain(), addr)
+
+	setParam := func(name gov.ParamName, activation int64) common.Hash {
+		tx, err := gp.SetParam(accounts[0], string(name), true, []byte{0, 0, 0, 0, 0, 0, 0, 25}, big.NewInt(activation))
+		require.Nil(t, err)
+		sim.Commit()
+		return tx.Hash()
+	}
+	setParam(paramName, 1000)               // overwritten by the next setParam
+	setParam(gov.RewardMintingAmount, 2000) // other parameters are ignored
+	txHash := setParam(paramName, 2000)
+
+	checkpoints, err := cgm.ParamCheckpoints(addr, paramName)
+	require.Nil(t, err)
+	require.Len(t, checkpoints, 2)
+
+	// sentinel checkpoint pushed by the contract
+	assert.Equal(t, uint64(0), checkpoints[0].Activation)
+	assert.Nil(t, checkpoints[0].TxHash)
+
+	assert.Equal(t, uint64(2000), checkpoints[1].Activation)
+	require.NotNil(t, checkpoints[1].TxHash)
+	assert.Equal(t, txHash, *checkpoints[1].TxHash)
+
+	// The events after the previous lookup are filtered on the next one.
+	txHash = setParam(paramName, 3000) // replaces the pending checkpoint
+	checkpoints, err = cgm.ParamCheckpoints(addr, paramName)
+	require.Nil(t, err)
+	require.Len(t, checkpoints, 2)
+	assert.Equal(t, uint64(3000), checkpoints[1].Activation)
+	require.NotNil(t, checkpoints[1].TxHash)
+	assert.Equal(t, txHash, *checkpoints[1].TxHash)
+}
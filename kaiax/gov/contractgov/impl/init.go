@@ -1,6 +1,8 @@
 package impl
 
 import (
+	"sync"
+
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/state"
 	"github.com/kaiachain/kaia/blockchain/types"
@@ -36,6 +38,9 @@ type InitOpts struct {
 
 type contractGovModule struct {
 	InitOpts
+
+	setParamMu    sync.Mutex
+	setParamCache map[common.Address]*setParamLogs
 }
 
 func NewContractGovModule() *contractGovModule {
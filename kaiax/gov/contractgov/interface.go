@@ -1,10 +1,18 @@
 package contractgov
 
 import (
+	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/kaiax"
 	"github.com/kaiachain/kaia/kaiax/gov"
 )
 
+// ParamCheckpoint is a checkpoint of a parameter in the GovParam contract.
+// TxHash is the transaction that scheduled the checkpoint, nil if it is not found.
+type ParamCheckpoint struct {
+	Activation uint64
+	TxHash     *common.Hash
+}
+
 //go:generate mockgen -destination=mock/contractgov_mock.go github.com/kaiachain/kaia/kaiax/gov/contractgov ContractGovModule
 type ContractGovModule interface {
 	kaiax.BaseModule
@@ -12,4 +20,6 @@ type ContractGovModule interface {
 
 	EffectiveParamSet(blockNum uint64) gov.ParamSet
 	EffectiveParamsPartial(blockNum uint64) gov.PartialParamSet
+	EffectiveParamsPartialFromAddr(blockNum uint64, addr common.Address) gov.PartialParamSet
+	ParamCheckpoints(addr common.Address, name gov.ParamName) ([]ParamCheckpoint, error)
 }
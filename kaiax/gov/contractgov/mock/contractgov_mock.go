@@ -8,7 +8,9 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	common "github.com/kaiachain/kaia/common"
 	gov "github.com/kaiachain/kaia/kaiax/gov"
+	contractgov "github.com/kaiachain/kaia/kaiax/gov/contractgov"
 	rpc "github.com/kaiachain/kaia/networks/rpc"
 )
 
@@ -77,6 +79,35 @@ func (mr *MockContractGovModuleMockRecorder) EffectiveParamsPartial(arg0 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EffectiveParamsPartial", reflect.TypeOf((*MockContractGovModule)(nil).EffectiveParamsPartial), arg0)
 }
 
+// EffectiveParamsPartialFromAddr mocks base method.
+func (m *MockContractGovModule) EffectiveParamsPartialFromAddr(arg0 uint64, arg1 common.Address) gov.PartialParamSet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EffectiveParamsPartialFromAddr", arg0, arg1)
+	ret0, _ := ret[0].(gov.PartialParamSet)
+	return ret0
+}
+
+// EffectiveParamsPartialFromAddr indicates an expected call of EffectiveParamsPartialFromAddr.
+func (mr *MockContractGovModuleMockRecorder) EffectiveParamsPartialFromAddr(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EffectiveParamsPartialFromAddr", reflect.TypeOf((*MockContractGovModule)(nil).EffectiveParamsPartialFromAddr), arg0, arg1)
+}
+
+// ParamCheckpoints mocks base method.
+func (m *MockContractGovModule) ParamCheckpoints(arg0 common.Address, arg1 gov.ParamName) ([]contractgov.ParamCheckpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParamCheckpoints", arg0, arg1)
+	ret0, _ := ret[0].([]contractgov.ParamCheckpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParamCheckpoints indicates an expected call of ParamCheckpoints.
+func (mr *MockContractGovModuleMockRecorder) ParamCheckpoints(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParamCheckpoints", reflect.TypeOf((*MockContractGovModule)(nil).ParamCheckpoints), arg0, arg1)
+}
+
 // Start mocks base method.
 func (m *MockContractGovModule) Start() error {
 	m.ctrl.T.Helper()
@@ -9,6 +9,8 @@ var (
 	ErrInvalidParamValue = errors.New("invalid param value")
 	ErrCannotSet         = errors.New("invalid field or cannot set the value")
 	ErrUnknownBlock      = errors.New("unknown block")
+	ErrNotFutureBlock    = errors.New("block number must be greater than the current block")
+	ErrInvalidBlockRange = errors.New("invalid block number range")
 
 	ErrCanonicalizeUint64        = errors.New("could not canonicalize value to uint64")
 	ErrCanonicalizeString        = errors.New("could not canonicalize value to string")
@@ -5,6 +5,7 @@ import (
 
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/kaiax/gov"
+	"github.com/kaiachain/kaia/kaiax/gov/headergov"
 	"golang.org/x/exp/maps" // TODO: use "maps"
 )
 
@@ -37,6 +38,18 @@ func (h *headerGovModule) EffectiveParamsPartial(blockNum uint64) gov.PartialPar
 	return ret
 }
 
+// PendingVotes returns the votes cast so far in the current epoch, and the next epoch block
+// in whose header they will be tallied as governance.
+func (h *headerGovModule) PendingVotes() (uint64, headergov.VotesInEpoch) {
+	epochIdx := calcEpochIdx(h.Chain.CurrentBlock().NumberU64(), h.epoch)
+	return calcEpochStartBlock(epochIdx+1, h.epoch), h.getVotesInEpoch(epochIdx)
+}
+
+// EpochVotes returns the governance votes cast in the given epoch.
+func (h *headerGovModule) EpochVotes(epochIdx uint64) headergov.VotesInEpoch {
+	return h.getVotesInEpoch(epochIdx)
+}
+
 func (h *headerGovModule) NodeAddress() common.Address {
 	return h.nodeAddress
 }
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/kaiax/gov"
 	"github.com/kaiachain/kaia/kaiax/gov/headergov"
 	"github.com/kaiachain/kaia/log"
@@ -138,3 +139,24 @@ func TestPrevEpochStart(t *testing.T) {
 		})
 	}
 }
+
+func TestPendingVotes(t *testing.T) {
+	var (
+		epoch = uint64(1000)
+		h     = newHeaderGovModule(t, &params.ChainConfig{
+			KoreCompatibleBlock: big.NewInt(0),
+			Istanbul:            &params.IstanbulConfig{Epoch: epoch},
+		})
+		paramName = string(gov.GovernanceUnitPrice)
+		v1        = headergov.NewVoteData(common.Address{1}, paramName, uint64(100))
+		v2        = headergov.NewVoteData(common.Address{2}, paramName, uint64(200))
+	)
+
+	// the current block is the genesis, so only the votes in the 0th epoch are pending.
+	h.HandleVote(500, v1)
+	h.HandleVote(1500, v2)
+
+	govBlock, votes := h.PendingVotes()
+	assert.Equal(t, epoch, govBlock)
+	assert.Equal(t, headergov.VotesInEpoch{500: v1}, votes)
+}
@@ -16,6 +16,9 @@ type HeaderGovModule interface {
 
 	EffectiveParamSet(blockNum uint64) gov.ParamSet
 	EffectiveParamsPartial(blockNum uint64) gov.PartialParamSet
+	PendingVotes() (uint64, VotesInEpoch)
+	EpochVotes(epochIdx uint64) VotesInEpoch
+	GovBlockNums() []uint64
 	NodeAddress() common.Address
 	PushMyVotes(vote VoteData)
 }
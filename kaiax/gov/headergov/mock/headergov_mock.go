@@ -81,6 +81,20 @@ func (mr *MockHeaderGovModuleMockRecorder) EffectiveParamsPartial(arg0 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EffectiveParamsPartial", reflect.TypeOf((*MockHeaderGovModule)(nil).EffectiveParamsPartial), arg0)
 }
 
+// EpochVotes mocks base method.
+func (m *MockHeaderGovModule) EpochVotes(arg0 uint64) headergov.VotesInEpoch {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EpochVotes", arg0)
+	ret0, _ := ret[0].(headergov.VotesInEpoch)
+	return ret0
+}
+
+// EpochVotes indicates an expected call of EpochVotes.
+func (mr *MockHeaderGovModuleMockRecorder) EpochVotes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EpochVotes", reflect.TypeOf((*MockHeaderGovModule)(nil).EpochVotes), arg0)
+}
+
 // FinalizeHeader mocks base method.
 func (m *MockHeaderGovModule) FinalizeHeader(arg0 *types.Header, arg1 *state.StateDB, arg2 []*types.Transaction, arg3 []*types.Receipt) error {
 	m.ctrl.T.Helper()
@@ -95,6 +109,20 @@ func (mr *MockHeaderGovModuleMockRecorder) FinalizeHeader(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinalizeHeader", reflect.TypeOf((*MockHeaderGovModule)(nil).FinalizeHeader), arg0, arg1, arg2, arg3)
 }
 
+// GovBlockNums mocks base method.
+func (m *MockHeaderGovModule) GovBlockNums() []uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GovBlockNums")
+	ret0, _ := ret[0].([]uint64)
+	return ret0
+}
+
+// GovBlockNums indicates an expected call of GovBlockNums.
+func (mr *MockHeaderGovModuleMockRecorder) GovBlockNums() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GovBlockNums", reflect.TypeOf((*MockHeaderGovModule)(nil).GovBlockNums))
+}
+
 // NodeAddress mocks base method.
 func (m *MockHeaderGovModule) NodeAddress() common.Address {
 	m.ctrl.T.Helper()
@@ -109,6 +137,21 @@ func (mr *MockHeaderGovModuleMockRecorder) NodeAddress() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeAddress", reflect.TypeOf((*MockHeaderGovModule)(nil).NodeAddress))
 }
 
+// PendingVotes mocks base method.
+func (m *MockHeaderGovModule) PendingVotes() (uint64, headergov.VotesInEpoch) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingVotes")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(headergov.VotesInEpoch)
+	return ret0, ret1
+}
+
+// PendingVotes indicates an expected call of PendingVotes.
+func (mr *MockHeaderGovModuleMockRecorder) PendingVotes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingVotes", reflect.TypeOf((*MockHeaderGovModule)(nil).PendingVotes))
+}
+
 // PostInsertBlock mocks base method.
 func (m *MockHeaderGovModule) PostInsertBlock(arg0 *types.Block) error {
 	m.ctrl.T.Helper()
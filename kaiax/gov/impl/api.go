@@ -27,6 +27,12 @@ func (g *GovModule) APIs() []rpc.API {
 	}...)
 }
 
+type ProjectedParamsResponse struct {
+	BlockNum     uint64                           `json:"blockNum"`
+	CurrentBlock uint64                           `json:"currentBlock"`
+	Params       map[gov.ParamName]ProjectedParam `json:"params"`
+}
+
 type GovAPI struct {
 	g *GovModule
 }
@@ -43,6 +49,29 @@ func (api *GovAPI) GetParams(num *rpc.BlockNumber) (gov.PartialParamSet, error)
 	return getParams(api.g, num)
 }
 
+// GetProjectedParams previews the parameter set at a future block. Pending votes of the current epoch
+// and the values scheduled in the GovParam contract are applied, and the source of each value is reported.
+func (api *GovAPI) GetProjectedParams(num rpc.BlockNumber) (*ProjectedParamsResponse, error) {
+	return getProjectedParams(api.g, num)
+}
+
+// GetParamHistory returns the changes of the parameter effective in (fromBlock, toBlock] in chronological order.
+func (api *GovAPI) GetParamHistory(name string, fromBlock, toBlock rpc.BlockNumber) ([]ParamChange, error) {
+	currentBlock := api.g.Chain.CurrentBlock().NumberU64()
+	fromNum, toNum := currentBlock, currentBlock
+	if fromBlock != rpc.LatestBlockNumber && fromBlock != rpc.PendingBlockNumber {
+		fromNum = fromBlock.Uint64()
+	}
+	if toBlock != rpc.LatestBlockNumber && toBlock != rpc.PendingBlockNumber {
+		toNum = toBlock.Uint64()
+	}
+	if fromNum > toNum {
+		return nil, gov.ErrInvalidBlockRange
+	}
+
+	return api.g.ParamHistory(gov.ParamName(name), fromNum, toNum)
+}
+
 func (api *GovAPI) NodeAddress() (common.Address, error) {
 	return api.g.Hgm.NodeAddress(), nil
 }
@@ -144,6 +173,32 @@ func getParams(g *GovModule, num *rpc.BlockNumber) (gov.PartialParamSet, error)
 	return ret, nil
 }
 
+func getProjectedParams(g *GovModule, num rpc.BlockNumber) (*ProjectedParamsResponse, error) {
+	currentBlock := g.Chain.CurrentBlock().NumberU64()
+	blockNumber := currentBlock + 1
+	if num != rpc.LatestBlockNumber && num != rpc.PendingBlockNumber {
+		blockNumber = num.Uint64()
+	}
+	if blockNumber <= currentBlock {
+		return nil, gov.ErrNotFutureBlock
+	}
+
+	rule := g.Chain.Config().Rules(new(big.Int).SetUint64(blockNumber))
+	gp, sources := g.ProjectedParamSet(blockNumber)
+	gp = patchDeprecatedParams(gp, rule)
+	for name, value := range gp.ToMap() {
+		p := sources[name]
+		p.Value = value
+		sources[name] = p
+	}
+
+	return &ProjectedParamsResponse{
+		BlockNum:     blockNumber,
+		CurrentBlock: currentBlock,
+		Params:       sources,
+	}, nil
+}
+
 func (api *KaiaAPI) NodeAddress() common.Address {
 	return api.g.Hgm.NodeAddress()
 }
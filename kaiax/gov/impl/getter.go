@@ -1,9 +1,34 @@
 package impl
 
 import (
+	"slices"
+
+	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/kaiax/gov"
+	headergov_impl "github.com/kaiachain/kaia/kaiax/gov/headergov/impl"
+	"golang.org/x/exp/maps" // TODO: use "maps"
+)
+
+// ParamSource is the governance mechanism that determined a parameter value.
+type ParamSource string
+
+const (
+	ParamSourceDefault     ParamSource = "default"
+	ParamSourceChainConfig ParamSource = "chainconfig"
+	ParamSourceHeaderGov   ParamSource = "headergov"
+	ParamSourcePendingVote ParamSource = "pendingvote"
+	ParamSourceContractGov ParamSource = "contractgov"
 )
 
+// ProjectedParam is a parameter value together with where it comes from.
+// VoteBlock and Voter are set only if the value comes from a pending vote.
+type ProjectedParam struct {
+	Value     any             `json:"value"`
+	Source    ParamSource     `json:"source"`
+	VoteBlock *uint64         `json:"voteBlock,omitempty"`
+	Voter     *common.Address `json:"voter,omitempty"`
+}
+
 func (m *GovModule) EffectiveParamSet(blockNum uint64) gov.ParamSet {
 	ret := gov.GetDefaultGovernanceParamSet()
 
@@ -26,3 +51,53 @@ func (m *GovModule) EffectiveParamSet(blockNum uint64) gov.ParamSet {
 
 	return *ret
 }
+
+// ProjectedParamSet previews the parameter set at blockNum, which is usually a future block.
+// It follows EffectiveParamSet, except that the votes cast so far in the current epoch are
+// regarded as the governance of the next epoch block, and the GovParam contract is read
+// from the projected govparamcontract. The returned map tells the source of each value.
+func (m *GovModule) ProjectedParamSet(blockNum uint64) (gov.ParamSet, map[gov.ParamName]ProjectedParam) {
+	ret := gov.GetDefaultGovernanceParamSet()
+	sources := make(map[gov.ParamName]ProjectedParam)
+	apply := func(name gov.ParamName, value any, p ProjectedParam) {
+		if err := ret.Set(name, value); err != nil {
+			return
+		}
+		p.Value = value
+		sources[name] = p
+	}
+
+	for name, param := range gov.Params {
+		sources[name] = ProjectedParam{Value: param.DefaultValue, Source: ParamSourceDefault}
+	}
+	for k, v := range m.Fallback {
+		apply(k, v, ProjectedParam{Source: ParamSourceChainConfig})
+	}
+	for k, v := range m.Hgm.EffectiveParamsPartial(blockNum) {
+		apply(k, v, ProjectedParam{Source: ParamSourceHeaderGov})
+	}
+
+	// The pending votes become effective at the same block as the governance they will be tallied into.
+	isKore := m.isKoreHF(blockNum)
+	govBlock, votes := m.Hgm.PendingVotes()
+	if headergov_impl.PrevEpochStart(blockNum, m.ChainConfig.Istanbul.Epoch, isKore) >= govBlock {
+		voteBlocks := maps.Keys(votes)
+		slices.Sort(voteBlocks)
+		for _, voteBlock := range voteBlocks {
+			var (
+				num   = voteBlock
+				vote  = votes[voteBlock]
+				voter = vote.Voter()
+			)
+			apply(vote.Name(), vote.Value(), ProjectedParam{Source: ParamSourcePendingVote, VoteBlock: &num, Voter: &voter})
+		}
+	}
+
+	if isKore {
+		for k, v := range m.Cgm.EffectiveParamsPartialFromAddr(blockNum, ret.GovParamContract) {
+			apply(k, v, ProjectedParam{Source: ParamSourceContractGov})
+		}
+	}
+
+	return *ret, sources
+}
@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	gomock "github.com/golang/mock/gomock"
+	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/kaiax/gov"
 	contractgov_mock "github.com/kaiachain/kaia/kaiax/gov/contractgov/mock"
+	"github.com/kaiachain/kaia/kaiax/gov/headergov"
 	headergov_mock "github.com/kaiachain/kaia/kaiax/gov/headergov/mock"
 	blockchain_mock "github.com/kaiachain/kaia/kaiax/gov/impl/mock"
 	"github.com/kaiachain/kaia/params"
@@ -94,3 +96,50 @@ func TestEffectiveParamSet(t *testing.T) {
 		})
 	})
 }
+
+func TestProjectedParamSet(t *testing.T) {
+	var (
+		epoch          = uint64(1000)
+		headerGovVal   = uint64(123)
+		voteVal        = uint64(456)
+		contractGovVal = uint64(789)
+		voter          = common.Address{1}
+		voteBlock      = uint64(500)
+		vote           = headergov.NewVoteData(voter, string(gov.GovernanceUnitPrice), voteVal)
+		votes          = headergov.VotesInEpoch{voteBlock: vote}
+	)
+
+	hgm, cgm, m := newGovModuleMock(t, &params.ChainConfig{
+		KoreCompatibleBlock: big.NewInt(0),
+		Istanbul:            &params.IstanbulConfig{Epoch: epoch},
+	})
+	hgm.EXPECT().EffectiveParamsPartial(gomock.Any()).Return(gov.PartialParamSet{gov.GovernanceUnitPrice: headerGovVal}).AnyTimes()
+	hgm.EXPECT().PendingVotes().Return(epoch, votes).AnyTimes()
+
+	t.Run("before pending governance", func(t *testing.T) {
+		cgm.EXPECT().EffectiveParamsPartialFromAddr(gomock.Any(), gomock.Any()).Return(nil)
+		ps, sources := m.ProjectedParamSet(1999)
+		assert.Equal(t, headerGovVal, ps.UnitPrice)
+		assert.Equal(t, ParamSourceHeaderGov, sources[gov.GovernanceUnitPrice].Source)
+		assert.Equal(t, ParamSourceDefault, sources[gov.Kip71GasTarget].Source)
+	})
+
+	t.Run("pending vote", func(t *testing.T) {
+		cgm.EXPECT().EffectiveParamsPartialFromAddr(gomock.Any(), gomock.Any()).Return(nil)
+		ps, sources := m.ProjectedParamSet(2000)
+		assert.Equal(t, voteVal, ps.UnitPrice)
+		assert.Equal(t, ProjectedParam{
+			Value:     voteVal,
+			Source:    ParamSourcePendingVote,
+			VoteBlock: &voteBlock,
+			Voter:     &voter,
+		}, sources[gov.GovernanceUnitPrice])
+	})
+
+	t.Run("contractgov", func(t *testing.T) {
+		cgm.EXPECT().EffectiveParamsPartialFromAddr(gomock.Any(), gomock.Any()).Return(gov.PartialParamSet{gov.GovernanceUnitPrice: contractGovVal})
+		ps, sources := m.ProjectedParamSet(2000)
+		assert.Equal(t, contractGovVal, ps.UnitPrice)
+		assert.Equal(t, ParamSourceContractGov, sources[gov.GovernanceUnitPrice].Source)
+	})
+}
@@ -0,0 +1,171 @@
+package impl
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/kaiax/gov"
+	"golang.org/x/exp/maps" // TODO: use "maps"
+)
+
+// VoteRef is a header governance vote that was tallied into a parameter change.
+type VoteRef struct {
+	BlockNum uint64         `json:"blockNum"`
+	Voter    common.Address `json:"voter"`
+	Value    any            `json:"value"`
+}
+
+// ParamChange is a change of a parameter value that is effective from BlockNum.
+// For header governance, GovBlock is the epoch block carrying the governance and Votes are the tallied votes.
+// For contract governance, Contract is the GovParam contract storing the value and TxHash is the transaction
+// that scheduled the checkpoint.
+type ParamChange struct {
+	BlockNum uint64          `json:"blockNum"`
+	Name     gov.ParamName   `json:"name"`
+	OldValue any             `json:"oldValue"`
+	NewValue any             `json:"newValue"`
+	Source   ParamSource     `json:"source"`
+	GovBlock *uint64         `json:"govBlock,omitempty"`
+	Votes    []VoteRef       `json:"votes,omitempty"`
+	Contract *common.Address `json:"contract,omitempty"`
+	TxHash   *common.Hash    `json:"txHash,omitempty"`
+}
+
+// ParamHistory returns the changes of the parameter that become effective in (fromBlock, toBlock]
+// in chronological order, merging header governance and contract governance.
+func (m *GovModule) ParamHistory(name gov.ParamName, fromBlock, toBlock uint64) ([]ParamChange, error) {
+	if _, ok := gov.Params[name]; !ok {
+		return nil, gov.ErrInvalidParamName
+	}
+
+	candidates, txHashes, err := m.paramChangeCandidates(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]ParamChange, 0)
+	prev := m.paramValue(name, fromBlock)
+	for _, num := range candidates {
+		if num <= fromBlock || num > toBlock {
+			continue
+		}
+
+		curr := m.paramValue(name, num)
+		if reflect.DeepEqual(prev, curr) {
+			continue
+		}
+
+		change := m.paramChangeSource(name, num, txHashes)
+		change.BlockNum = num
+		change.Name = name
+		change.OldValue = prev
+		change.NewValue = curr
+		ret = append(ret, change)
+		prev = curr
+	}
+
+	return ret, nil
+}
+
+// paramChangeCandidates returns the sorted block numbers where the parameter value can change.
+// That is, where a header governance or a GovParam checkpoint becomes effective, or where contract governance is enabled.
+// It also returns the transactions that scheduled the GovParam checkpoints, keyed by contract and activation block.
+func (m *GovModule) paramChangeCandidates(name gov.ParamName) ([]uint64, map[common.Address]map[uint64]common.Hash, error) {
+	candidates := make(map[uint64]struct{})
+	govParamAddrs := make(map[common.Address]struct{})
+	for _, govBlock := range m.Hgm.GovBlockNums() {
+		num := m.govEffectiveBlock(govBlock)
+		candidates[num] = struct{}{}
+		govParamAddrs[m.Hgm.EffectiveParamSet(num).GovParamContract] = struct{}{}
+	}
+
+	if koreBlock := m.ChainConfig.KoreCompatibleBlock; koreBlock != nil {
+		candidates[koreBlock.Uint64()] = struct{}{}
+	}
+
+	txHashes := make(map[common.Address]map[uint64]common.Hash)
+	for addr := range govParamAddrs {
+		checkpoints, err := m.Cgm.ParamCheckpoints(addr, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, ckpt := range checkpoints {
+			candidates[ckpt.Activation] = struct{}{}
+			if ckpt.TxHash != nil {
+				if txHashes[addr] == nil {
+					txHashes[addr] = make(map[uint64]common.Hash)
+				}
+				txHashes[addr][ckpt.Activation] = *ckpt.TxHash
+			}
+		}
+	}
+
+	ret := maps.Keys(candidates)
+	slices.Sort(ret)
+	return ret, txHashes, nil
+}
+
+// paramChangeSource tells which governance mechanism determined the parameter value at blockNum.
+func (m *GovModule) paramChangeSource(name gov.ParamName, blockNum uint64, txHashes map[common.Address]map[uint64]common.Hash) ParamChange {
+	if m.isKoreHF(blockNum) {
+		addr := m.Hgm.EffectiveParamSet(blockNum).GovParamContract
+		if _, ok := m.Cgm.EffectiveParamsPartialFromAddr(blockNum, addr)[name]; ok {
+			change := ParamChange{Source: ParamSourceContractGov, Contract: &addr}
+			if txHash, ok := txHashes[addr][blockNum]; ok {
+				change.TxHash = &txHash
+			}
+			return change
+		}
+	}
+
+	if _, ok := m.Hgm.EffectiveParamsPartial(blockNum)[name]; ok {
+		change := ParamChange{Source: ParamSourceHeaderGov}
+		for _, govBlock := range m.Hgm.GovBlockNums() {
+			if govBlock > 0 && m.govEffectiveBlock(govBlock) == blockNum {
+				change.GovBlock = &govBlock
+				change.Votes = m.talliedVotes(name, govBlock)
+				break
+			}
+		}
+		return change
+	}
+
+	if _, ok := m.Fallback[name]; ok {
+		return ParamChange{Source: ParamSourceChainConfig}
+	}
+	return ParamChange{Source: ParamSourceDefault}
+}
+
+// talliedVotes returns the votes for the parameter in the epoch before govBlock, in the order they were cast.
+func (m *GovModule) talliedVotes(name gov.ParamName, govBlock uint64) []VoteRef {
+	votes := m.Hgm.EpochVotes(govBlock/m.ChainConfig.Istanbul.Epoch - 1)
+	voteBlocks := maps.Keys(votes)
+	slices.Sort(voteBlocks)
+
+	ret := make([]VoteRef, 0)
+	for _, voteBlock := range voteBlocks {
+		if vote := votes[voteBlock]; vote.Name() == name {
+			ret = append(ret, VoteRef{BlockNum: voteBlock, Voter: vote.Voter(), Value: vote.Value()})
+		}
+	}
+	return ret
+}
+
+// govEffectiveBlock returns the first block where the governance in the header of govBlock is effective.
+func (m *GovModule) govEffectiveBlock(govBlock uint64) uint64 {
+	if govBlock == 0 {
+		return 0
+	}
+
+	epoch := m.ChainConfig.Istanbul.Epoch
+	if m.isKoreHF(govBlock + epoch) {
+		return govBlock + epoch
+	}
+	return govBlock + epoch + 1
+}
+
+func (m *GovModule) paramValue(name gov.ParamName, blockNum uint64) any {
+	ps := m.EffectiveParamSet(blockNum)
+	return ps.ToMap()[name]
+}
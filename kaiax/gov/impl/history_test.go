@@ -0,0 +1,105 @@
+package impl
+
+import (
+	"math/big"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/kaiax/gov"
+	"github.com/kaiachain/kaia/kaiax/gov/contractgov"
+	"github.com/kaiachain/kaia/kaiax/gov/headergov"
+	"github.com/kaiachain/kaia/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParamHistory(t *testing.T) {
+	var (
+		epoch          = uint64(1000)
+		defaultVal     = uint64(250e9)
+		headerGovVal   = uint64(123)
+		contractGovVal = uint64(456)
+		contractBlock  = uint64(3500)
+		govParamAddr   = common.HexToAddress("0x0000000000000000000000000000000000000400")
+		voter          = common.Address{1}
+		vote           = headergov.NewVoteData(voter, string(gov.GovernanceUnitPrice), headerGovVal)
+		govBlock       = uint64(1000)
+		setParamTxHash = common.HexToHash("0x1234")
+	)
+
+	hgm, cgm, m := newGovModuleMock(t, &params.ChainConfig{
+		KoreCompatibleBlock: big.NewInt(0),
+		Istanbul:            &params.IstanbulConfig{Epoch: epoch},
+	})
+
+	// governance at block 1000 (effective from 2000) sets unitprice by the vote at block 500,
+	// and GovParam overrides unitprice from block 3500.
+	headerPartial := func(num uint64) gov.PartialParamSet {
+		if num >= 2000 {
+			return gov.PartialParamSet{gov.GovernanceUnitPrice: headerGovVal}
+		}
+		return gov.PartialParamSet{}
+	}
+	contractPartial := func(num uint64) gov.PartialParamSet {
+		if num >= contractBlock {
+			return gov.PartialParamSet{gov.GovernanceUnitPrice: contractGovVal}
+		}
+		return gov.PartialParamSet{}
+	}
+	headerParamSet := *gov.GetDefaultGovernanceParamSet()
+	headerParamSet.GovParamContract = govParamAddr
+
+	hgm.EXPECT().GovBlockNums().Return([]uint64{0, govBlock}).AnyTimes()
+	hgm.EXPECT().EffectiveParamSet(gomock.Any()).Return(headerParamSet).AnyTimes()
+	hgm.EXPECT().EffectiveParamsPartial(gomock.Any()).DoAndReturn(headerPartial).AnyTimes()
+	hgm.EXPECT().EpochVotes(uint64(0)).Return(headergov.VotesInEpoch{500: vote}).AnyTimes()
+	cgm.EXPECT().ParamCheckpoints(govParamAddr, gov.GovernanceUnitPrice).Return([]contractgov.ParamCheckpoint{
+		{Activation: 0},
+		{Activation: contractBlock, TxHash: &setParamTxHash},
+	}, nil).AnyTimes()
+	cgm.EXPECT().EffectiveParamsPartial(gomock.Any()).DoAndReturn(contractPartial).AnyTimes()
+	cgm.EXPECT().EffectiveParamsPartialFromAddr(gomock.Any(), govParamAddr).DoAndReturn(
+		func(num uint64, _ common.Address) gov.PartialParamSet { return contractPartial(num) }).AnyTimes()
+
+	t.Run("all", func(t *testing.T) {
+		changes, err := m.ParamHistory(gov.GovernanceUnitPrice, 0, 10000)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+
+		assert.Equal(t, ParamChange{
+			BlockNum: 2000,
+			Name:     gov.GovernanceUnitPrice,
+			OldValue: defaultVal,
+			NewValue: headerGovVal,
+			Source:   ParamSourceHeaderGov,
+			GovBlock: &govBlock,
+			Votes:    []VoteRef{{BlockNum: 500, Voter: voter, Value: headerGovVal}},
+		}, changes[0])
+		assert.Equal(t, ParamChange{
+			BlockNum: contractBlock,
+			Name:     gov.GovernanceUnitPrice,
+			OldValue: headerGovVal,
+			NewValue: contractGovVal,
+			Source:   ParamSourceContractGov,
+			Contract: &govParamAddr,
+			TxHash:   &setParamTxHash,
+		}, changes[1])
+	})
+
+	t.Run("range", func(t *testing.T) {
+		changes, err := m.ParamHistory(gov.GovernanceUnitPrice, 2000, 3499)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+
+		changes, err = m.ParamHistory(gov.GovernanceUnitPrice, 3000, 3500)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, contractBlock, changes[0].BlockNum)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := m.ParamHistory("governance.unknown", 0, 10000)
+		assert.ErrorIs(t, err, gov.ErrInvalidParamName)
+	})
+}
@@ -17,6 +17,7 @@
 package impl
 
 import (
+	"context"
 	"runtime"
 	"sync"
 
@@ -24,7 +25,11 @@ import (
 	"github.com/kaiachain/kaia/networks/rpc"
 )
 
-var accumulatedRewardsRangeLimit = uint64(604800) // 7 days. naive resource protection
+var (
+	accumulatedRewardsRangeLimit = uint64(604800) // 7 days. naive resource protection
+	rewardLinesRangeLimit        = uint64(3600)   // 1 hour. the response grows with the number of recipients
+	rewardExportRangeLimit       = uint64(604800) // 7 days. streamed block by block
+)
 
 func (r *RewardModule) APIs() []rpc.API {
 	return []rpc.API{
@@ -74,26 +79,9 @@ func NewRewardGovAPI(r reward.RewardModule, chain blockChain) *RewardGovAPI {
 }
 
 func (api *RewardGovAPI) GetRewardsAccumulated(lower, upper rpc.BlockNumber) (*reward.AccumulatedRewardsResponse, error) {
-	// normalize block numbers
-	currentNum := api.chain.CurrentBlock().NumberU64()
-	var lowerNum uint64
-	var upperNum uint64
-	if lower == rpc.LatestBlockNumber || lower == rpc.PendingBlockNumber {
-		lowerNum = currentNum
-	} else {
-		lowerNum = lower.Uint64()
-	}
-	if upper == rpc.LatestBlockNumber || upper == rpc.PendingBlockNumber {
-		upperNum = currentNum
-	} else {
-		upperNum = upper.Uint64()
-	}
-	if lowerNum > upperNum || upperNum > currentNum {
-		return nil, reward.ErrInvalidBlockRange
-	}
-	count := upperNum - lowerNum + 1
-	if count > accumulatedRewardsRangeLimit {
-		return nil, reward.ErrBlockRangeLimit
+	lowerNum, upperNum, err := api.blockRange(lower, upper, accumulatedRewardsRangeLimit)
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch block timestamps
@@ -153,3 +141,86 @@ func (api *RewardGovAPI) accumulateRewards(lower, upper uint64) (*reward.RewardS
 	}
 	return accSpec, nil
 }
+
+// GetRewardLines returns the per-recipient reward lines of the blocks in [lower, upper].
+func (api *RewardGovAPI) GetRewardLines(lower, upper rpc.BlockNumber) ([]*reward.RewardLine, error) {
+	lowerNum, upperNum, err := api.blockRange(lower, upper, rewardLinesRangeLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]*reward.RewardLine, 0)
+	for num := lowerNum; num <= upperNum; num++ {
+		blockLines, err := api.r.GetBlockRewardLines(num)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, blockLines...)
+	}
+	return lines, nil
+}
+
+// RewardExport streams the per-recipient reward lines of the blocks in [lower, upper].
+// Each notification carries the lines of one block, in ascending block order.
+// The stream ends after the notification for the upper block, or when an error occurs.
+func (api *RewardGovAPI) RewardExport(ctx context.Context, lower, upper rpc.BlockNumber) (*rpc.Subscription, error) {
+	lowerNum, upperNum, err := api.blockRange(lower, upper, rewardExportRangeLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		for num := lowerNum; num <= upperNum; num++ {
+			select {
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			default:
+			}
+
+			lines, err := api.r.GetBlockRewardLines(num)
+			if err != nil {
+				logger.Error("Failed to export reward lines", "num", num, "err", err)
+				return
+			}
+			if err := notifier.Notify(rpcSub.ID, lines); err != nil {
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// blockRange normalizes the block numbers and checks that [lower, upper] is a valid range of at most limit blocks.
+func (api *RewardGovAPI) blockRange(lower, upper rpc.BlockNumber, limit uint64) (uint64, uint64, error) {
+	currentNum := api.chain.CurrentBlock().NumberU64()
+	var lowerNum uint64
+	var upperNum uint64
+	if lower == rpc.LatestBlockNumber || lower == rpc.PendingBlockNumber {
+		lowerNum = currentNum
+	} else {
+		lowerNum = lower.Uint64()
+	}
+	if upper == rpc.LatestBlockNumber || upper == rpc.PendingBlockNumber {
+		upperNum = currentNum
+	} else {
+		upperNum = upper.Uint64()
+	}
+	if lowerNum > upperNum || upperNum > currentNum {
+		return 0, 0, reward.ErrInvalidBlockRange
+	}
+	count := upperNum - lowerNum + 1
+	if count > limit {
+		return 0, 0, reward.ErrBlockRangeLimit
+	}
+	return lowerNum, upperNum, nil
+}
@@ -174,6 +174,40 @@ func TestAPIGetAccumulatedRewards(t *testing.T) {
 	}
 }
 
+func TestAPIGetRewardLines(t *testing.T) {
+	mockCtrl, r, chain, _, govAPI := makeTestAPI(t)
+	defer mockCtrl.Finish()
+
+	var (
+		lowerNum = rpc.BlockNumber(9)
+		upperNum = rpc.BlockNumber(10)
+
+		currBlock = types.NewBlock(&types.Header{Number: big.NewInt(int64(upperNum))}, nil, nil)
+
+		makeLines = func(num uint64) []*reward.RewardLine {
+			return []*reward.RewardLine{
+				{BlockNum: num, Category: reward.RewardCategoryMinted, Amount: big.NewInt(1e18)},
+				{BlockNum: num, Category: reward.RewardCategoryProposer, Recipient: common.HexToAddress("0xfff"), Amount: big.NewInt(1e18)},
+			}
+		}
+	)
+	chain.EXPECT().CurrentBlock().Return(currBlock).AnyTimes()
+	r.EXPECT().GetBlockRewardLines(uint64(9)).Return(makeLines(9), nil).AnyTimes()
+	r.EXPECT().GetBlockRewardLines(uint64(10)).Return(makeLines(10), nil).AnyTimes()
+
+	result, err := govAPI.GetRewardLines(lowerNum, upperNum)
+	assert.NoError(t, err)
+	assert.Equal(t, append(makeLines(9), makeLines(10)...), result)
+
+	_, err = govAPI.GetRewardLines(upperNum, lowerNum)
+	assert.ErrorIs(t, err, reward.ErrInvalidBlockRange)
+
+	defer func(limit uint64) { rewardLinesRangeLimit = limit }(rewardLinesRangeLimit)
+	rewardLinesRangeLimit = 1
+	_, err = govAPI.GetRewardLines(lowerNum, upperNum)
+	assert.ErrorIs(t, err, reward.ErrBlockRangeLimit)
+}
+
 func makeTestAPI(t *testing.T) (*gomock.Controller, *reward_mock.MockRewardModule, *mocks.MockBlockChain, *RewardKaiaAPI, *RewardGovAPI) {
 	mockCtrl := gomock.NewController(t)
 
@@ -18,6 +18,7 @@ package impl
 
 import (
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 )
 
@@ -36,7 +37,7 @@ func (r *RewardModule) FinalizeHeader(header *types.Header, state *state.StateDB
 		return err
 	}
 	for addr, amount := range spec.Rewards {
-		state.AddBalance(addr, amount)
+		state.AddBalance(addr, amount, tracing.BalanceIncreaseBlockReward)
 	}
 	return nil
 }
@@ -0,0 +1,148 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package impl
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/kaiax/reward"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBlockRewardLines(t *testing.T) {
+	line := func(category reward.RewardCategory, addr string, amount *big.Int) *reward.RewardLine {
+		var recipient common.Address
+		if addr != "" {
+			recipient = common.HexToAddress(addr)
+		}
+		return &reward.RewardLine{BlockNum: 61, Category: category, Recipient: recipient, Amount: amount}
+	}
+
+	testcases := []struct {
+		desc     string
+		simple   bool
+		deferred bool
+		prague   bool
+		expected []*reward.RewardLine
+	}{
+		{
+			"simple deferred", true, true, false,
+			[]*reward.RewardLine{
+				line(reward.RewardCategoryMinted, "", big.NewInt(6.4e18)),
+				line(reward.RewardCategoryBurntFee, "", big.NewInt(0.0188e18)),
+				line(reward.RewardCategoryProposer, "0xfff", big.NewInt(6.4188e18)),
+			},
+		},
+		{
+			"full non-deferred", false, false, false,
+			[]*reward.RewardLine{
+				line(reward.RewardCategoryMinted, "", big.NewInt(6.4e18)),
+				line(reward.RewardCategoryBurntFee, "", big.NewInt(0.0188e18)),
+				line(reward.RewardCategoryStaker, "0xc01", big.NewInt(426666666666666666)),
+				line(reward.RewardCategoryStaker, "0xc02", big.NewInt(853333333333333333)),
+				line(reward.RewardCategoryStaker, "0xc03", big.NewInt(1280000000000000000)),
+				line(reward.RewardCategoryKIF, "0xd01", big.NewInt(1.28e18)),
+				line(reward.RewardCategoryKEF, "0xd02", big.NewInt(1.92e18)),
+				line(reward.RewardCategoryProposer, "0xfff", big.NewInt(0.6588e18+1)),
+			},
+		},
+		{
+			"full deferred with CL", false, true, true,
+			[]*reward.RewardLine{
+				line(reward.RewardCategoryMinted, "", big.NewInt(6.4e18)),
+				line(reward.RewardCategoryBurntFee, "", big.NewInt(0.0376e18)),
+				line(reward.RewardCategoryStaker, "0xc01", big.NewInt(355555626666666667)),
+				line(reward.RewardCategoryStaker, "0xc02", big.NewInt(609524053333333334)),
+				line(reward.RewardCategoryStaker, "0xc03", big.NewInt(800000480000000000)),
+				line(reward.RewardCategoryStaker, "0xe01", big.NewInt(71111039999999999)),
+				line(reward.RewardCategoryStaker, "0xe02", big.NewInt(243809279999999999)),
+				line(reward.RewardCategoryStaker, "0xe03", big.NewInt(479999520000000000)),
+				line(reward.RewardCategoryKIF, "0xd01", big.NewInt(1.28e18)),
+				line(reward.RewardCategoryKEF, "0xd02", big.NewInt(1.92e18)),
+				line(reward.RewardCategoryProposer, "0xfff", big.NewInt(0.64e18+1)),
+			},
+		},
+	}
+	for _, tc := range testcases {
+		header, txs, receipts := makeTestKaiaBlock(61)
+		r := makeTestRewardModule(t, tc.simple, tc.deferred, tc.prague, header, txs, receipts)
+
+		lines, err := r.GetBlockRewardLines(header.Number.Uint64())
+		require.Nil(t, err)
+		assert.Equal(t, tc.expected, lines, tc.desc)
+
+		// The lines must add up to the block reward.
+		spec, err := r.GetBlockReward(header.Number.Uint64())
+		require.Nil(t, err)
+		sum := make(map[common.Address]*big.Int)
+		for _, l := range lines {
+			if l.Category == reward.RewardCategoryMinted || l.Category == reward.RewardCategoryBurntFee {
+				continue
+			}
+			if sum[l.Recipient] == nil {
+				sum[l.Recipient] = new(big.Int)
+			}
+			sum[l.Recipient].Add(sum[l.Recipient], l.Amount)
+		}
+		assert.Equal(t, spec.Rewards, sum, tc.desc)
+	}
+}
+
+func TestRewardLineWriter(t *testing.T) {
+	lines := []*reward.RewardLine{
+		{BlockNum: 61, Category: reward.RewardCategoryMinted, Amount: big.NewInt(6.4e18)},
+		{BlockNum: 61, Category: reward.RewardCategoryProposer, Recipient: common.HexToAddress("0xfff"), Amount: big.NewInt(6.4188e18)},
+	}
+
+	testcases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			ExportFormatCSV,
+			"blockNum,category,recipient,amount\n" +
+				"61,minted,0x0000000000000000000000000000000000000000,6400000000000000000\n" +
+				"61,proposer,0x0000000000000000000000000000000000000FfF,6418800000000000000\n" +
+				"61,minted,0x0000000000000000000000000000000000000000,6400000000000000000\n" +
+				"61,proposer,0x0000000000000000000000000000000000000FfF,6418800000000000000\n",
+		},
+		{
+			ExportFormatJSONL,
+			`{"blockNum":61,"category":"minted","recipient":"0x0000000000000000000000000000000000000000","amount":6400000000000000000}` + "\n" +
+				`{"blockNum":61,"category":"proposer","recipient":"0x0000000000000000000000000000000000000fff","amount":6418800000000000000}` + "\n" +
+				`{"blockNum":61,"category":"minted","recipient":"0x0000000000000000000000000000000000000000","amount":6400000000000000000}` + "\n" +
+				`{"blockNum":61,"category":"proposer","recipient":"0x0000000000000000000000000000000000000fff","amount":6418800000000000000}` + "\n",
+		},
+	}
+	for _, tc := range testcases {
+		var buf bytes.Buffer
+		w, err := NewRewardLineWriter(&buf, tc.format)
+		require.Nil(t, err)
+		// The CSV header is written only once.
+		require.Nil(t, w.Write(lines))
+		require.Nil(t, w.Write(lines))
+		require.Nil(t, w.Flush())
+		assert.Equal(t, tc.expected, buf.String(), tc.format)
+	}
+
+	_, err := NewRewardLineWriter(&bytes.Buffer{}, "xml")
+	assert.ErrorIs(t, err, reward.ErrUnknownExportFormat)
+}
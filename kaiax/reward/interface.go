@@ -36,4 +36,7 @@ type RewardModule interface {
 
 	// GetRewardSummary retrospectively calculates the reward summary at the given block number.
 	GetRewardSummary(num uint64) (*RewardSummary, error)
+
+	// GetBlockRewardLines retrospectively breaks down the block reward at the given block number by category and recipient.
+	GetBlockRewardLines(num uint64) ([]*RewardLine, error)
 }
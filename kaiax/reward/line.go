@@ -0,0 +1,44 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package reward
+
+import (
+	"math/big"
+
+	"github.com/kaiachain/kaia/common"
+)
+
+// RewardCategory tells which part of the block reward a RewardLine belongs to.
+type RewardCategory string
+
+const (
+	RewardCategoryMinted   RewardCategory = "minted"
+	RewardCategoryBurntFee RewardCategory = "burntFee"
+	RewardCategoryProposer RewardCategory = "proposer"
+	RewardCategoryStaker   RewardCategory = "staker"
+	RewardCategoryKIF      RewardCategory = "kif"
+	RewardCategoryKEF      RewardCategory = "kef"
+)
+
+// RewardLine is a per-block, per-recipient breakdown of a RewardSpec.
+// Minted and burntFee lines have no recipient, hence the zero address.
+type RewardLine struct {
+	BlockNum  uint64         `json:"blockNum"`
+	Category  RewardCategory `json:"category"`
+	Recipient common.Address `json:"recipient"`
+	Amount    *big.Int       `json:"amount"`
+}
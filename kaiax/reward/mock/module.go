@@ -81,6 +81,21 @@ func (mr *MockRewardModuleMockRecorder) GetBlockReward(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockReward", reflect.TypeOf((*MockRewardModule)(nil).GetBlockReward), arg0)
 }
 
+// GetBlockRewardLines mocks base method.
+func (m *MockRewardModule) GetBlockRewardLines(arg0 uint64) ([]*reward.RewardLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockRewardLines", arg0)
+	ret0, _ := ret[0].([]*reward.RewardLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlockRewardLines indicates an expected call of GetBlockRewardLines.
+func (mr *MockRewardModuleMockRecorder) GetBlockRewardLines(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockRewardLines", reflect.TypeOf((*MockRewardModule)(nil).GetBlockRewardLines), arg0)
+}
+
 // GetDeferredReward mocks base method.
 func (m *MockRewardModule) GetDeferredReward(arg0 *types.Header, arg1 []*types.Transaction, arg2 []*types.Receipt) (*reward.RewardSpec, error) {
 	m.ctrl.T.Helper()
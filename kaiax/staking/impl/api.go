@@ -17,12 +17,15 @@
 package impl
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/kaiachain/kaia/kaiax/staking"
 	"github.com/kaiachain/kaia/networks/rpc"
 )
 
+const stakingInfoDeltaRangeLimit = uint64(604800) // 7 days. naive resource protection
+
 func (s *StakingModule) APIs() []rpc.API {
 	return []rpc.API{
 		{
@@ -68,3 +71,70 @@ func (api *stakingAPI) GetStakingInfo(num rpc.BlockNumber) (*staking.StakingInfo
 	// Calculate Gini coefficient regardless of useGini flag
 	return si.ToResponse(useGini, api.s.stakingInterval), nil
 }
+
+// GetStakingInfoDelta returns the changes from the staking info used for the block `from`
+// to the one used for the block `to`.
+func (api *stakingAPI) GetStakingInfoDelta(from, to rpc.BlockNumber) (*staking.StakingInfoDelta, error) {
+	fromNum, toNum, err := api.blockRange(from, to, stakingInfoDeltaRangeLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.s.GetStakingInfoDelta(fromNum, toNum)
+}
+
+// blockRange normalizes the block numbers and checks that [lower, upper] is a valid range of at most limit blocks.
+func (api *stakingAPI) blockRange(lower, upper rpc.BlockNumber, limit uint64) (uint64, uint64, error) {
+	currentNum := api.s.Chain.CurrentBlock().NumberU64()
+	var lowerNum uint64
+	var upperNum uint64
+	if lower == rpc.LatestBlockNumber || lower == rpc.PendingBlockNumber {
+		lowerNum = currentNum
+	} else {
+		lowerNum = lower.Uint64()
+	}
+	if upper == rpc.LatestBlockNumber || upper == rpc.PendingBlockNumber {
+		upperNum = currentNum
+	} else {
+		upperNum = upper.Uint64()
+	}
+	if lowerNum > upperNum || upperNum > currentNum {
+		return 0, 0, staking.ErrInvalidBlockRange
+	}
+	count := upperNum - lowerNum + 1
+	if count > limit {
+		return 0, 0, staking.ErrBlockRangeLimit
+	}
+	return lowerNum, upperNum, nil
+}
+
+// StakingInfoDelta creates a subscription that fires when the staking info for the next block
+// differs from the one for the newly inserted block.
+func (api *stakingAPI) StakingInfoDelta(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		deltas := make(chan *staking.StakingInfoDelta)
+		deltasSub := api.s.SubscribeStakingInfoDelta(deltas)
+
+		for {
+			select {
+			case delta := <-deltas:
+				notifier.Notify(rpcSub.ID, delta)
+			case <-rpcSub.Err():
+				deltasSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				deltasSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
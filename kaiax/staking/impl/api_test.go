@@ -0,0 +1,80 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package impl
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/kaiax/staking"
+	"github.com/kaiachain/kaia/networks/rpc"
+	"github.com/kaiachain/kaia/work/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIGetStakingInfoDelta(t *testing.T) {
+	var (
+		mockCtrl  = gomock.NewController(t)
+		chain     = mocks.NewMockBlockChain(mockCtrl)
+		currNum   = uint64(1_000_000)
+		currBlock = types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(currNum)})
+		n1        = common.HexToAddress("0xa1")
+		s1        = common.HexToAddress("0xb1")
+		r1        = common.HexToAddress("0xc1")
+		si        = &staking.StakingInfo{NodeIds: []common.Address{n1}, StakingContracts: []common.Address{s1}, RewardAddrs: []common.Address{r1}, StakingAmounts: []uint64{100}}
+	)
+	defer mockCtrl.Finish()
+	chain.EXPECT().CurrentBlock().Return(currBlock).AnyTimes()
+
+	// After Kaia, the staking info for block num is drawn from num-1 which is cached.
+	mStaking := NewStakingModule()
+	mStaking.InitOpts = InitOpts{ChainConfig: testPragueForkChainConfig(nil), Chain: chain}
+	for _, num := range []uint64{0, currNum - 2, currNum - 1} {
+		mStaking.stakingInfoCache.Add(num, si)
+	}
+	api := newStakingAPI(mStaking)
+
+	testcases := []struct {
+		from, to     rpc.BlockNumber
+		expectedFrom uint64
+		expectedTo   uint64
+		expectedErr  error
+	}{
+		{rpc.LatestBlockNumber, rpc.LatestBlockNumber, currNum, currNum, nil},
+		{rpc.PendingBlockNumber, rpc.PendingBlockNumber, currNum, currNum, nil},
+		{rpc.BlockNumber(currNum - 1), rpc.PendingBlockNumber, currNum - 1, currNum, nil},
+		{rpc.EarliestBlockNumber, rpc.EarliestBlockNumber, 0, 0, nil},
+		{rpc.BlockNumber(currNum), rpc.BlockNumber(currNum - 1), 0, 0, staking.ErrInvalidBlockRange},
+		{rpc.LatestBlockNumber, rpc.BlockNumber(currNum + 1), 0, 0, staking.ErrInvalidBlockRange},
+		{rpc.PendingBlockNumber, rpc.EarliestBlockNumber, 0, 0, staking.ErrInvalidBlockRange},
+		{rpc.EarliestBlockNumber, rpc.LatestBlockNumber, 0, 0, staking.ErrBlockRangeLimit},
+	}
+	for i, tc := range testcases {
+		delta, err := api.GetStakingInfoDelta(tc.from, tc.to)
+		if tc.expectedErr != nil {
+			assert.ErrorIs(t, err, tc.expectedErr, i)
+			continue
+		}
+		require.NoError(t, err, i)
+		assert.Equal(t, tc.expectedFrom, delta.FromBlockNum, i)
+		assert.Equal(t, tc.expectedTo, delta.ToBlockNum, i)
+	}
+}
@@ -30,9 +30,26 @@ func (s *StakingModule) PostInsertBlock(block *types.Block) error {
 			return err
 		}
 	}
+
+	if s.deltaScope.Count() > 0 {
+		s.sendStakingInfoDelta(block.NumberU64())
+	}
 	return nil
 }
 
+// sendStakingInfoDelta notifies the subscribers of the staking info changes from the block num to the next block.
+// Errors are only logged because the subscription must not affect block insertion.
+func (s *StakingModule) sendStakingInfoDelta(num uint64) {
+	delta, err := s.GetStakingInfoDelta(num, num+1)
+	if err != nil {
+		logger.Warn("Failed to compute staking info delta", "num", num, "err", err)
+		return
+	}
+	if !delta.Empty() {
+		s.deltaFeed.Send(delta)
+	}
+}
+
 func (s *StakingModule) RewindTo(newBlock *types.Block) {
 	// Nothing to do
 }
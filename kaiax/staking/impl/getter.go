@@ -77,6 +77,18 @@ func (s *StakingModule) GetStakingInfo(num uint64) (*staking.StakingInfo, error)
 	return si, nil
 }
 
+func (s *StakingModule) GetStakingInfoDelta(fromNum, toNum uint64) (*staking.StakingInfoDelta, error) {
+	from, err := s.GetStakingInfo(fromNum)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetStakingInfo(toNum)
+	if err != nil {
+		return nil, err
+	}
+	return staking.NewStakingInfoDelta(fromNum, toNum, from, to), nil
+}
+
 // Read the staking status from the blockchain state.
 func (s *StakingModule) getFromStateByNumber(num uint64) (*staking.StakingInfo, error) {
 	header := s.Chain.GetHeaderByNumber(num)
@@ -23,6 +23,7 @@ import (
 	"github.com/kaiachain/kaia/accounts/abi/bind/backends"
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/system"
+	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/kaiax/staking"
 	"github.com/kaiachain/kaia/log"
@@ -275,3 +276,37 @@ func TestSourceBlockNum(t *testing.T) {
 		assert.Equal(t, tc.expected, actual, i)
 	}
 }
+
+func TestStakingInfoDeltaSubscription(t *testing.T) {
+	var (
+		config = testPragueForkChainConfig(nil)
+		n1     = common.HexToAddress("0xa1")
+		s1     = common.HexToAddress("0xb1")
+		r1     = common.HexToAddress("0xc1")
+		si9    = &staking.StakingInfo{SourceBlockNum: 9, NodeIds: []common.Address{n1}, StakingContracts: []common.Address{s1}, RewardAddrs: []common.Address{r1}, StakingAmounts: []uint64{100}}
+		si10   = &staking.StakingInfo{SourceBlockNum: 10, NodeIds: []common.Address{n1}, StakingContracts: []common.Address{s1}, RewardAddrs: []common.Address{r1}, StakingAmounts: []uint64{150}}
+	)
+
+	// After Kaia, the staking info for block num is drawn from num-1 which is already cached.
+	mStaking := NewStakingModule()
+	mStaking.InitOpts = InitOpts{ChainConfig: config}
+	mStaking.stakingInfoCache.Add(uint64(9), si9)
+	mStaking.stakingInfoCache.Add(uint64(10), si10)
+	mStaking.stakingInfoCache.Add(uint64(11), si10)
+
+	deltas := make(chan *staking.StakingInfoDelta, 1)
+	sub := mStaking.SubscribeStakingInfoDelta(deltas)
+	defer sub.Unsubscribe()
+
+	block10 := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10)})
+	assert.NoError(t, mStaking.PostInsertBlock(block10))
+	delta := <-deltas
+	assert.Equal(t, uint64(10), delta.FromBlockNum)
+	assert.Equal(t, uint64(11), delta.ToBlockNum)
+	assert.Equal(t, []staking.StakingAmountChange{{NodeId: n1, StakingContract: s1, From: 100, To: 150, Delta: 50}}, delta.StakingAmountChanges)
+
+	// Empty deltas are not sent.
+	block11 := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(11)})
+	assert.NoError(t, mStaking.PostInsertBlock(block11))
+	assert.Empty(t, deltas)
+}
@@ -21,6 +21,7 @@ import (
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/kaiachain/kaia/accounts/abi/bind/backends"
+	"github.com/kaiachain/kaia/event"
 	"github.com/kaiachain/kaia/kaiax/staking"
 	"github.com/kaiachain/kaia/log"
 	"github.com/kaiachain/kaia/params"
@@ -49,6 +50,10 @@ type StakingModule struct {
 
 	stakingInfoCache *lru.ARCCache // cached by sourceNum
 	preloadBuffer    *PreloadBuffer
+
+	// Staking info changes are computed only while there are subscribers.
+	deltaFeed  event.Feed
+	deltaScope event.SubscriptionScope
 }
 
 func NewStakingModule() *StakingModule {
@@ -86,3 +91,9 @@ func (s *StakingModule) Start() error {
 
 func (s *StakingModule) Stop() {
 }
+
+// SubscribeStakingInfoDelta registers a subscription for the staking info changes
+// between consecutive blocks, sent after each block is inserted.
+func (s *StakingModule) SubscribeStakingInfoDelta(ch chan<- *staking.StakingInfoDelta) event.Subscription {
+	return s.deltaScope.Track(s.deltaFeed.Subscribe(ch))
+}
@@ -33,6 +33,10 @@ type StakingModule interface {
 	// This is the most commonly used getter.
 	GetStakingInfo(num uint64) (*StakingInfo, error)
 
+	// GetStakingInfoDelta returns the changes from the staking info used for fromNum
+	// to the one used for toNum.
+	GetStakingInfoDelta(fromNum, toNum uint64) (*StakingInfoDelta, error)
+
 	// Directly access the database.
 	// Note that db access is only effective before Kaia hardfork.
 	// The given number indicates the number that the staking info is measured from, not when the staking info is used.
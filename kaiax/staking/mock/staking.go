@@ -93,6 +93,21 @@ func (mr *MockStakingModuleMockRecorder) GetStakingInfo(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStakingInfo", reflect.TypeOf((*MockStakingModule)(nil).GetStakingInfo), arg0)
 }
 
+// GetStakingInfoDelta mocks base method.
+func (m *MockStakingModule) GetStakingInfoDelta(arg0, arg1 uint64) (*staking.StakingInfoDelta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStakingInfoDelta", arg0, arg1)
+	ret0, _ := ret[0].(*staking.StakingInfoDelta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStakingInfoDelta indicates an expected call of GetStakingInfoDelta.
+func (mr *MockStakingModuleMockRecorder) GetStakingInfoDelta(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStakingInfoDelta", reflect.TypeOf((*MockStakingModule)(nil).GetStakingInfoDelta), arg0, arg1)
+}
+
 // GetStakingInfoFromDB mocks base method.
 func (m *MockStakingModule) GetStakingInfoFromDB(arg0 uint64) *staking.StakingInfo {
 	m.ctrl.T.Helper()
@@ -0,0 +1,192 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package staking
+
+import (
+	"github.com/kaiachain/kaia/common"
+)
+
+// CouncilNode is an AddressBook entry with its staking amount.
+type CouncilNode struct {
+	NodeId          common.Address `json:"nodeId"`
+	StakingContract common.Address `json:"stakingContract"`
+	RewardAddr      common.Address `json:"rewardAddr"`
+	StakingAmount   uint64         `json:"stakingAmount"`
+}
+
+// StakingAmountChange is the change of the staking amount of an AddressBook entry, in KAIA.
+type StakingAmountChange struct {
+	NodeId          common.Address `json:"nodeId"`
+	StakingContract common.Address `json:"stakingContract"`
+	From            uint64         `json:"from"`
+	To              uint64         `json:"to"`
+	Delta           int64          `json:"delta"`
+}
+
+// RewardAddrChange is the change of the reward address of an AddressBook entry.
+type RewardAddrChange struct {
+	NodeId          common.Address `json:"nodeId"`
+	StakingContract common.Address `json:"stakingContract"`
+	From            common.Address `json:"from"`
+	To              common.Address `json:"to"`
+}
+
+// AddrChange is the change of a treasury fund address.
+type AddrChange struct {
+	From common.Address `json:"from"`
+	To   common.Address `json:"to"`
+}
+
+// CLStakingChange is the change of a CLStakingInfo identified by CLNodeId.
+// From is nil if the CL staking is newly added, and To is nil if it is removed.
+type CLStakingChange struct {
+	CLNodeId common.Address `json:"clNodeId"`
+	From     *CLStakingInfo `json:"from"`
+	To       *CLStakingInfo `json:"to"`
+}
+
+// StakingInfoDelta is the difference between the staking infos used for two blocks.
+// AddressBook entries are identified by the (NodeId, StakingContract) pair.
+type StakingInfoDelta struct {
+	FromBlockNum  uint64 `json:"fromBlockNum"`
+	ToBlockNum    uint64 `json:"toBlockNum"`
+	FromSourceNum uint64 `json:"fromSourceNum"`
+	ToSourceNum   uint64 `json:"toSourceNum"`
+
+	AddedNodes           []CouncilNode         `json:"addedNodes"`
+	RemovedNodes         []CouncilNode         `json:"removedNodes"`
+	StakingAmountChanges []StakingAmountChange `json:"stakingAmountChanges"`
+	RewardAddrChanges    []RewardAddrChange    `json:"rewardAddrChanges"`
+	KEFAddrChange        *AddrChange           `json:"kefAddrChange,omitempty"`
+	KIFAddrChange        *AddrChange           `json:"kifAddrChange,omitempty"`
+	CLStakingChanges     []CLStakingChange     `json:"clStakingChanges"`
+}
+
+type councilNodeKey struct {
+	nodeId          common.Address
+	stakingContract common.Address
+}
+
+// NewStakingInfoDelta computes the difference from the staking info used for fromNum
+// to the one used for toNum. The changes are ordered as they appear in the staking infos.
+func NewStakingInfoDelta(fromNum, toNum uint64, from, to *StakingInfo) *StakingInfoDelta {
+	delta := &StakingInfoDelta{
+		FromBlockNum:         fromNum,
+		ToBlockNum:           toNum,
+		FromSourceNum:        from.SourceBlockNum,
+		ToSourceNum:          to.SourceBlockNum,
+		AddedNodes:           []CouncilNode{},
+		RemovedNodes:         []CouncilNode{},
+		StakingAmountChanges: []StakingAmountChange{},
+		RewardAddrChanges:    []RewardAddrChange{},
+		CLStakingChanges:     []CLStakingChange{},
+	}
+
+	fromNodes := from.councilNodes()
+	toNodes := to.councilNodes()
+	fromIdx := make(map[councilNodeKey]CouncilNode, len(fromNodes))
+	toIdx := make(map[councilNodeKey]CouncilNode, len(toNodes))
+	for _, n := range fromNodes {
+		fromIdx[councilNodeKey{n.NodeId, n.StakingContract}] = n
+	}
+	for _, n := range toNodes {
+		toIdx[councilNodeKey{n.NodeId, n.StakingContract}] = n
+	}
+
+	for _, n := range toNodes {
+		prev, ok := fromIdx[councilNodeKey{n.NodeId, n.StakingContract}]
+		if !ok {
+			delta.AddedNodes = append(delta.AddedNodes, n)
+			continue
+		}
+		if prev.StakingAmount != n.StakingAmount {
+			delta.StakingAmountChanges = append(delta.StakingAmountChanges, StakingAmountChange{
+				NodeId:          n.NodeId,
+				StakingContract: n.StakingContract,
+				From:            prev.StakingAmount,
+				To:              n.StakingAmount,
+				Delta:           int64(n.StakingAmount) - int64(prev.StakingAmount),
+			})
+		}
+		if prev.RewardAddr != n.RewardAddr {
+			delta.RewardAddrChanges = append(delta.RewardAddrChanges, RewardAddrChange{
+				NodeId:          n.NodeId,
+				StakingContract: n.StakingContract,
+				From:            prev.RewardAddr,
+				To:              n.RewardAddr,
+			})
+		}
+	}
+	for _, n := range fromNodes {
+		if _, ok := toIdx[councilNodeKey{n.NodeId, n.StakingContract}]; !ok {
+			delta.RemovedNodes = append(delta.RemovedNodes, n)
+		}
+	}
+
+	if from.KEFAddr != to.KEFAddr {
+		delta.KEFAddrChange = &AddrChange{From: from.KEFAddr, To: to.KEFAddr}
+	}
+	if from.KIFAddr != to.KIFAddr {
+		delta.KIFAddrChange = &AddrChange{From: from.KIFAddr, To: to.KIFAddr}
+	}
+
+	fromCL := make(map[common.Address]*CLStakingInfo, len(from.CLStakingInfos))
+	toCL := make(map[common.Address]*CLStakingInfo, len(to.CLStakingInfos))
+	for _, cl := range from.CLStakingInfos {
+		fromCL[cl.CLNodeId] = cl
+	}
+	for _, cl := range to.CLStakingInfos {
+		toCL[cl.CLNodeId] = cl
+	}
+	for _, cl := range from.CLStakingInfos {
+		if next, ok := toCL[cl.CLNodeId]; !ok || *next != *cl {
+			delta.CLStakingChanges = append(delta.CLStakingChanges, CLStakingChange{CLNodeId: cl.CLNodeId, From: cl, To: next})
+		}
+	}
+	for _, cl := range to.CLStakingInfos {
+		if _, ok := fromCL[cl.CLNodeId]; !ok {
+			delta.CLStakingChanges = append(delta.CLStakingChanges, CLStakingChange{CLNodeId: cl.CLNodeId, To: cl})
+		}
+	}
+
+	return delta
+}
+
+// Empty returns true if there is no change between the two staking infos.
+func (d *StakingInfoDelta) Empty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 &&
+		len(d.StakingAmountChanges) == 0 && len(d.RewardAddrChanges) == 0 &&
+		d.KEFAddrChange == nil && d.KIFAddrChange == nil && len(d.CLStakingChanges) == 0
+}
+
+// councilNodes returns the AddressBook entries. Malformed staking info yields no entries.
+func (si *StakingInfo) councilNodes() []CouncilNode {
+	if len(si.NodeIds) != len(si.StakingContracts) || len(si.NodeIds) != len(si.RewardAddrs) || len(si.NodeIds) != len(si.StakingAmounts) {
+		return nil
+	}
+
+	nodes := make([]CouncilNode, len(si.NodeIds))
+	for i := range si.NodeIds {
+		nodes[i] = CouncilNode{
+			NodeId:          si.NodeIds[i],
+			StakingContract: si.StakingContracts[i],
+			RewardAddr:      si.RewardAddrs[i],
+			StakingAmount:   si.StakingAmounts[i],
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package staking
+
+import (
+	"testing"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStakingInfoDelta(t *testing.T) {
+	var (
+		n1, n2, n3 = common.HexToAddress("0xa1"), common.HexToAddress("0xa2"), common.HexToAddress("0xa3")
+		s1, s2, s3 = common.HexToAddress("0xb1"), common.HexToAddress("0xb2"), common.HexToAddress("0xb3")
+		r1, r2, r3 = common.HexToAddress("0xc1"), common.HexToAddress("0xc2"), common.HexToAddress("0xc3")
+		r2New      = common.HexToAddress("0xc4")
+		kef, kif   = common.HexToAddress("0xd1"), common.HexToAddress("0xd2")
+		kefNew     = common.HexToAddress("0xd3")
+		cl1, cl2   = common.HexToAddress("0xe1"), common.HexToAddress("0xe2")
+	)
+
+	from := &StakingInfo{
+		SourceBlockNum:   99,
+		NodeIds:          []common.Address{n1, n2},
+		StakingContracts: []common.Address{s1, s2},
+		RewardAddrs:      []common.Address{r1, r2},
+		KEFAddr:          kef,
+		KIFAddr:          kif,
+		StakingAmounts:   []uint64{5_000_000, 7_000_000},
+		CLStakingInfos: CLStakingInfos{
+			{CLNodeId: cl1, CLPoolAddr: cl1, CLRewardAddr: cl1, CLStakingAmount: 100},
+		},
+	}
+	to := &StakingInfo{
+		SourceBlockNum:   100,
+		NodeIds:          []common.Address{n2, n3},
+		StakingContracts: []common.Address{s2, s3},
+		RewardAddrs:      []common.Address{r2New, r3},
+		KEFAddr:          kefNew,
+		KIFAddr:          kif,
+		StakingAmounts:   []uint64{6_000_000, 5_000_000},
+		CLStakingInfos: CLStakingInfos{
+			{CLNodeId: cl1, CLPoolAddr: cl1, CLRewardAddr: cl1, CLStakingAmount: 200},
+			{CLNodeId: cl2, CLPoolAddr: cl2, CLRewardAddr: cl2, CLStakingAmount: 300},
+		},
+	}
+
+	delta := NewStakingInfoDelta(100, 101, from, to)
+	assert.False(t, delta.Empty())
+	assert.Equal(t, uint64(99), delta.FromSourceNum)
+	assert.Equal(t, uint64(100), delta.ToSourceNum)
+	assert.Equal(t, []CouncilNode{{NodeId: n3, StakingContract: s3, RewardAddr: r3, StakingAmount: 5_000_000}}, delta.AddedNodes)
+	assert.Equal(t, []CouncilNode{{NodeId: n1, StakingContract: s1, RewardAddr: r1, StakingAmount: 5_000_000}}, delta.RemovedNodes)
+	assert.Equal(t, []StakingAmountChange{{NodeId: n2, StakingContract: s2, From: 7_000_000, To: 6_000_000, Delta: -1_000_000}}, delta.StakingAmountChanges)
+	assert.Equal(t, []RewardAddrChange{{NodeId: n2, StakingContract: s2, From: r2, To: r2New}}, delta.RewardAddrChanges)
+	assert.Equal(t, &AddrChange{From: kef, To: kefNew}, delta.KEFAddrChange)
+	assert.Nil(t, delta.KIFAddrChange)
+	assert.Equal(t, []CLStakingChange{
+		{CLNodeId: cl1, From: from.CLStakingInfos[0], To: to.CLStakingInfos[0]},
+		{CLNodeId: cl2, To: to.CLStakingInfos[1]},
+	}, delta.CLStakingChanges)
+
+	// no change
+	assert.True(t, NewStakingInfoDelta(100, 101, to, to).Empty())
+	assert.True(t, NewStakingInfoDelta(0, 1, &StakingInfo{}, &StakingInfo{SourceBlockNum: 1}).Empty())
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package impl
+
+import (
+	"context"
+
+	"github.com/kaiachain/kaia/kaiax/statediff"
+	"github.com/kaiachain/kaia/networks/rpc"
+)
+
+func (s *StateDiffModule) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   newStateDiffAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+type stateDiffAPI struct {
+	s *StateDiffModule
+}
+
+func newStateDiffAPI(s *StateDiffModule) *stateDiffAPI {
+	return &stateDiffAPI{s: s}
+}
+
+// GetStateDiff returns the accounts, storage slots and codes changed by the block.
+func (api *stateDiffAPI) GetStateDiff(num rpc.BlockNumber) (*statediff.StateDiffResponse, error) {
+	var blockNum uint64
+	if num == rpc.LatestBlockNumber || num == rpc.PendingBlockNumber {
+		blockNum = api.s.Chain.CurrentBlock().NumberU64()
+	} else {
+		blockNum = num.Uint64()
+	}
+
+	sd, err := api.s.GetStateDiff(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	return sd.ToResponse()
+}
+
+// StateDiff creates a subscription that fires with the state changes of each inserted canonical block.
+func (api *stateDiffAPI) StateDiff(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		diffs := make(chan *statediff.StateDiff)
+		diffsSub := api.s.SubscribeStateDiff(diffs)
+
+		for {
+			select {
+			case sd := <-diffs:
+				res, err := sd.ToResponse()
+				if err != nil {
+					logger.Warn("Failed to decode state diff", "num", sd.BlockNumber, "err", err)
+					continue
+				}
+				notifier.Notify(rpcSub.ID, res)
+			case <-rpcSub.Err():
+				diffsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				diffsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
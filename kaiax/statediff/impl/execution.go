@@ -0,0 +1,81 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package impl
+
+import (
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/kaiax/statediff"
+)
+
+// PostInsertBlock persists the state diff committed by the block and deletes
+// the ones out of the retention window. Because the state diffs are indexed by
+// the block number, non-canonical blocks are ignored.
+func (s *StateDiffModule) PostInsertBlock(block *types.Block) error {
+	num, hash := block.NumberU64(), block.Hash()
+	if header := s.Chain.GetHeaderByNumber(num); header == nil || header.Hash() != hash {
+		return nil
+	}
+
+	diff := s.Chain.StateDiff(hash)
+	if diff == nil {
+		logger.Warn("State diff is not recorded", "num", num, "hash", hash)
+		return nil
+	}
+	sd := statediff.NewStateDiff(num, hash, diff)
+	WriteStateDiff(s.ChainKv, sd)
+	s.deleteExpired(num)
+
+	if s.diffScope.Count() > 0 {
+		s.diffFeed.Send(sd)
+	}
+	return nil
+}
+
+// deleteExpired deletes the state diffs out of the retention window, which ends at head.
+func (s *StateDiffModule) deleteExpired(head uint64) {
+	tail := ReadStateDiffTail(s.ChainKv)
+	if tail == nil {
+		// The first state diff is recorded. There is nothing to delete below it.
+		WriteStateDiffTail(s.ChainKv, head)
+		return
+	}
+	if s.Retention == 0 || head < s.Retention {
+		return
+	}
+
+	// Keep the state diffs of the blocks (head-Retention, head].
+	newTail := head - s.Retention + 1
+	if *tail >= newTail {
+		return
+	}
+	for num := *tail; num < newTail; num++ {
+		DeleteStateDiff(s.ChainKv, num)
+	}
+	WriteStateDiffTail(s.ChainKv, newTail)
+}
+
+func (s *StateDiffModule) RewindTo(newBlock *types.Block) {
+	// Start over from the next recorded block if every state diff has been rewound.
+	if tail := ReadStateDiffTail(s.ChainKv); tail != nil && *tail > newBlock.NumberU64() {
+		DeleteStateDiffTail(s.ChainKv)
+	}
+}
+
+func (s *StateDiffModule) RewindDelete(hash common.Hash, num uint64) {
+	DeleteStateDiff(s.ChainKv, num)
+}
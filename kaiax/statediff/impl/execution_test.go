@@ -0,0 +1,177 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package impl
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/kaiax/statediff"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChain is a canonical chain of empty blocks, each of which updates one account.
+type testChain struct {
+	blocks []*types.Block
+	diffs  map[common.Hash]*state.StateDiff
+}
+
+func newTestChain(n int) *testChain {
+	c := &testChain{diffs: make(map[common.Hash]*state.StateDiff)}
+	for i := 0; i < n; i++ {
+		c.blocks = append(c.blocks, c.newBlock(uint64(i), 0))
+	}
+	return c
+}
+
+// newBlock creates a block with a recorded state diff. A non-zero extra makes a sibling block.
+func (c *testChain) newBlock(num uint64, extra byte) *types.Block {
+	block := types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(num), Extra: []byte{extra}})
+	addr := common.BigToAddress(new(big.Int).SetUint64(num + 1))
+	c.diffs[block.Hash()] = &state.StateDiff{
+		Destructs: map[common.Address]struct{}{},
+		Accounts:  map[common.Address][]byte{addr: {byte(num)}},
+		Storage:   map[common.Address]map[common.Hash][]byte{},
+		Codes:     map[common.Hash][]byte{},
+	}
+	return block
+}
+
+func (c *testChain) CurrentBlock() *types.Block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *testChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.blocks)) {
+		return nil
+	}
+	return c.blocks[number].Header()
+}
+
+func (c *testChain) StateDiff(hash common.Hash) *state.StateDiff {
+	return c.diffs[hash]
+}
+
+func newTestModule(t *testing.T, chain *testChain, retention uint64) (*StateDiffModule, database.Database) {
+	db := database.NewMemDB()
+	s := NewStateDiffModule()
+	require.NoError(t, s.Init(&InitOpts{ChainKv: db, Chain: chain, Retention: retention}))
+	return s, db
+}
+
+func TestPostInsertBlock(t *testing.T) {
+	chain := newTestChain(10)
+	s, db := newTestModule(t, chain, 4)
+
+	for _, block := range chain.blocks {
+		require.NoError(t, s.PostInsertBlock(block))
+	}
+
+	// Only the last 4 blocks are kept.
+	assert.Equal(t, uint64(6), *ReadStateDiffTail(db))
+	for num := uint64(0); num < 10; num++ {
+		sd := ReadStateDiff(db, num)
+		if num < 6 {
+			assert.Nil(t, sd, num)
+			continue
+		}
+		require.NotNil(t, sd, num)
+		assert.Equal(t, chain.blocks[num].Hash(), sd.BlockHash)
+		assert.Equal(t, common.BigToAddress(new(big.Int).SetUint64(num+1)), sd.Accounts[0].Address)
+	}
+
+	// Non-canonical blocks are not recorded.
+	require.NoError(t, s.PostInsertBlock(chain.newBlock(5, 1)))
+	assert.Nil(t, ReadStateDiff(db, 5))
+
+	_, err := s.GetStateDiff(9)
+	assert.NoError(t, err)
+	_, err = s.GetStateDiff(5)
+	assert.ErrorIs(t, err, statediff.ErrNoStateDiff)
+}
+
+func TestPostInsertBlock_NoRetention(t *testing.T) {
+	chain := newTestChain(10)
+	s, db := newTestModule(t, chain, 0)
+
+	for _, block := range chain.blocks {
+		require.NoError(t, s.PostInsertBlock(block))
+	}
+	assert.Equal(t, uint64(0), *ReadStateDiffTail(db))
+	for num := uint64(0); num < 10; num++ {
+		assert.NotNil(t, ReadStateDiff(db, num), num)
+	}
+}
+
+func TestRewind(t *testing.T) {
+	chain := newTestChain(10)
+	s, db := newTestModule(t, chain, 4)
+	for _, block := range chain.blocks {
+		require.NoError(t, s.PostInsertBlock(block))
+	}
+
+	// Rewind to block 7.
+	for num := uint64(9); num > 7; num-- {
+		s.RewindDelete(chain.blocks[num].Hash(), num)
+	}
+	s.RewindTo(chain.blocks[7])
+	assert.Equal(t, uint64(6), *ReadStateDiffTail(db))
+	assert.NotNil(t, ReadStateDiff(db, 7))
+	assert.Nil(t, ReadStateDiff(db, 8))
+
+	// Rewind below the tail.
+	for num := uint64(7); num > 3; num-- {
+		s.RewindDelete(chain.blocks[num].Hash(), num)
+	}
+	s.RewindTo(chain.blocks[3])
+	assert.Nil(t, ReadStateDiffTail(db))
+
+	// Recording starts over from the next block.
+	chain.blocks = chain.blocks[:4]
+	chain.blocks = append(chain.blocks, chain.newBlock(4, 0))
+	require.NoError(t, s.PostInsertBlock(chain.blocks[4]))
+	assert.Equal(t, uint64(4), *ReadStateDiffTail(db))
+	assert.NotNil(t, ReadStateDiff(db, 4))
+}
+
+func TestSubscribeStateDiff(t *testing.T) {
+	chain := newTestChain(3)
+	s, _ := newTestModule(t, chain, 0)
+
+	ch := make(chan *statediff.StateDiff, 3)
+	sub := s.SubscribeStateDiff(ch)
+	defer sub.Unsubscribe()
+
+	for _, block := range chain.blocks {
+		require.NoError(t, s.PostInsertBlock(block))
+	}
+	for _, block := range chain.blocks {
+		select {
+		case sd := <-ch:
+			assert.Equal(t, block.NumberU64(), sd.BlockNumber)
+			assert.Equal(t, block.Hash(), sd.BlockHash)
+		case <-time.After(time.Second):
+			t.Fatal("state diff is not delivered")
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package impl
+
+import (
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/event"
+	"github.com/kaiachain/kaia/kaiax/statediff"
+	"github.com/kaiachain/kaia/log"
+	"github.com/kaiachain/kaia/storage/database"
+)
+
+var (
+	_ statediff.StateDiffModule = &StateDiffModule{}
+
+	logger = log.NewModuleLogger(log.KaiaxStateDiff)
+)
+
+type blockChain interface {
+	CurrentBlock() *types.Block
+	GetHeaderByNumber(number uint64) *types.Header
+	StateDiff(hash common.Hash) *state.StateDiff
+}
+
+type InitOpts struct {
+	ChainKv database.Database
+	Chain   blockChain
+	// Number of recent blocks whose state diffs are kept. If zero, state diffs are never deleted.
+	Retention uint64
+}
+
+type StateDiffModule struct {
+	InitOpts
+
+	diffFeed  event.Feed
+	diffScope event.SubscriptionScope
+}
+
+func NewStateDiffModule() *StateDiffModule {
+	return &StateDiffModule{}
+}
+
+func (s *StateDiffModule) Init(opts *InitOpts) error {
+	if opts == nil || opts.ChainKv == nil || opts.Chain == nil {
+		return statediff.ErrInitUnexpectedNil
+	}
+	s.InitOpts = *opts
+	return nil
+}
+
+func (s *StateDiffModule) Start() error {
+	logger.Info("State diff recording is enabled", "retention", s.Retention)
+	return nil
+}
+
+func (s *StateDiffModule) Stop() {
+}
+
+// SubscribeStateDiff registers a subscription for the state changes of the
+// canonical blocks, sent after each block is inserted.
+func (s *StateDiffModule) SubscribeStateDiff(ch chan<- *statediff.StateDiff) event.Subscription {
+	return s.diffScope.Track(s.diffFeed.Subscribe(ch))
+}
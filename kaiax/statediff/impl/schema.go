@@ -0,0 +1,87 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package impl
+
+import (
+	"encoding/binary"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/kaiax/statediff"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/kaiachain/kaia/storage/database"
+)
+
+var (
+	stateDiffPrefix  = []byte("stateDiff")
+	stateDiffTailKey = []byte("stateDiffTail")
+)
+
+func stateDiffKey(num uint64) []byte {
+	return append(stateDiffPrefix, common.Int64ToByteBigEndian(num)...)
+}
+
+func ReadStateDiff(db database.Database, num uint64) *statediff.StateDiff {
+	b, err := db.Get(stateDiffKey(num))
+	if err != nil || len(b) == 0 {
+		return nil
+	}
+	sd := new(statediff.StateDiff)
+	if err := rlp.DecodeBytes(b, sd); err != nil {
+		logger.Error("Malformed state diff", "num", num, "err", err)
+		return nil
+	}
+	return sd
+}
+
+func WriteStateDiff(db database.Database, sd *statediff.StateDiff) {
+	b, err := rlp.EncodeToBytes(sd)
+	if err != nil {
+		logger.Crit("Failed to serialize state diff", "num", sd.BlockNumber, "err", err)
+	}
+	if err := db.Put(stateDiffKey(sd.BlockNumber), b); err != nil {
+		logger.Crit("Failed to write state diff", "num", sd.BlockNumber, "err", err)
+	}
+}
+
+func DeleteStateDiff(db database.Database, num uint64) {
+	if err := db.Delete(stateDiffKey(num)); err != nil {
+		logger.Crit("Failed to delete state diff", "num", num, "err", err)
+	}
+}
+
+// ReadStateDiffTail returns the lowest block number whose state diff may be stored,
+// or nil if no state diff has been recorded.
+func ReadStateDiffTail(db database.Database) *uint64 {
+	b, err := db.Get(stateDiffTailKey)
+	if err != nil || len(b) != 8 {
+		return nil
+	}
+	tail := binary.BigEndian.Uint64(b)
+	return &tail
+}
+
+func WriteStateDiffTail(db database.Database, num uint64) {
+	if err := db.Put(stateDiffTailKey, common.Int64ToByteBigEndian(num)); err != nil {
+		logger.Crit("Failed to write state diff tail", "num", num, "err", err)
+	}
+}
+
+func DeleteStateDiffTail(db database.Database) {
+	if err := db.Delete(stateDiffTailKey); err != nil {
+		logger.Crit("Failed to delete state diff tail", "err", err)
+	}
+}
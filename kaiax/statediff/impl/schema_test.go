@@ -0,0 +1,59 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package impl
+
+import (
+	"testing"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/kaiax/statediff"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(t *testing.T) {
+	sd := &statediff.StateDiff{
+		BlockNumber: 100,
+		BlockHash:   common.HexToHash("0x4d2c6bea6bb9b6a0b6a4dbb6d6c8a7a1b0ae7d1b1bd4dbd8e6e2f20b2c1f5c9e"),
+		Destructs:   []common.Address{common.HexToAddress("0x159ae5ccda31b77475c64d88d4499c86f77b7ecc")},
+		Accounts: []statediff.AccountDiff{
+			{
+				Address: common.HexToAddress("0x70e051c46ea76b9af9977407bb32192319907f9e"),
+				Account: []byte{0x01, 0x02, 0x03},
+				Storage: []statediff.StorageDiff{
+					{Key: common.HexToHash("0x01"), Value: []byte{0x2a}},
+					{Key: common.HexToHash("0x02"), Value: []byte{}},
+				},
+			},
+		},
+		Codes: [][]byte{{0x60, 0x00}},
+	}
+
+	db := database.NewMemDB()
+
+	assert.Nil(t, ReadStateDiff(db, 100))
+	WriteStateDiff(db, sd)
+	assert.Equal(t, sd, ReadStateDiff(db, 100))
+	DeleteStateDiff(db, 100)
+	assert.Nil(t, ReadStateDiff(db, 100))
+
+	assert.Nil(t, ReadStateDiffTail(db))
+	WriteStateDiffTail(db, 100)
+	assert.Equal(t, uint64(100), *ReadStateDiffTail(db))
+	DeleteStateDiffTail(db)
+	assert.Nil(t, ReadStateDiffTail(db))
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package statediff
+
+import (
+	"github.com/kaiachain/kaia/event"
+	"github.com/kaiachain/kaia/kaiax"
+)
+
+type StateDiffModule interface {
+	kaiax.BaseModule
+	kaiax.JsonRpcModule
+	kaiax.ExecutionModule
+	kaiax.RewindableModule
+
+	// GetStateDiff returns the state changes made by the canonical block num.
+	// Returns ErrNoStateDiff if it is not recorded or out of the retention window.
+	GetStateDiff(num uint64) (*StateDiff, error)
+
+	// SubscribeStateDiff registers a subscription for the state changes of the
+	// canonical blocks, sent after each block is inserted.
+	SubscribeStateDiff(ch chan<- *StateDiff) event.Subscription
+}
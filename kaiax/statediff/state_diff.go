@@ -0,0 +1,165 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package statediff
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types/account"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/rlp"
+)
+
+// DefaultRetention is the default number of recent blocks whose state diffs are kept.
+const DefaultRetention = 86400
+
+// StateDiff is the state changes made by a block. It is also the persistent format,
+// so that the entries are sorted and the values are kept in their state encodings.
+type StateDiff struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	Destructs   []common.Address // Accounts deleted in the block, sorted
+	Accounts    []AccountDiff    // Accounts updated in the block, sorted by address
+	Codes       [][]byte         // Codes deployed in the block, sorted by code hash
+}
+
+type AccountDiff struct {
+	Address common.Address
+	Account []byte        // Serialized account (see account.AccountSerializer)
+	Storage []StorageDiff // Updated storage slots, sorted by key
+}
+
+type StorageDiff struct {
+	Key   common.Hash
+	Value []byte // RLP encoded value. Empty if deleted.
+}
+
+// NewStateDiff converts the state diff committed by the block into the sorted form.
+func NewStateDiff(num uint64, hash common.Hash, diff *state.StateDiff) *StateDiff {
+	sd := &StateDiff{
+		BlockNumber: num,
+		BlockHash:   hash,
+		Destructs:   make([]common.Address, 0, len(diff.Destructs)),
+		Accounts:    make([]AccountDiff, 0, len(diff.Accounts)),
+		Codes:       make([][]byte, 0, len(diff.Codes)),
+	}
+	for addr := range diff.Destructs {
+		sd.Destructs = append(sd.Destructs, addr)
+	}
+	sort.Slice(sd.Destructs, func(i, j int) bool {
+		return bytes.Compare(sd.Destructs[i][:], sd.Destructs[j][:]) < 0
+	})
+
+	// Storage changes always come with the account update of the new storage root.
+	addrs := make([]common.Address, 0, len(diff.Accounts))
+	for addr := range diff.Accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	for _, addr := range addrs {
+		ad := AccountDiff{Address: addr, Account: diff.Accounts[addr]}
+		for key, value := range diff.Storage[addr] {
+			ad.Storage = append(ad.Storage, StorageDiff{Key: key, Value: value})
+		}
+		sort.Slice(ad.Storage, func(i, j int) bool {
+			return bytes.Compare(ad.Storage[i].Key[:], ad.Storage[j].Key[:]) < 0
+		})
+		sd.Accounts = append(sd.Accounts, ad)
+	}
+
+	hashes := make([]common.Hash, 0, len(diff.Codes))
+	for hash := range diff.Codes {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	for _, hash := range hashes {
+		sd.Codes = append(sd.Codes, diff.Codes[hash])
+	}
+	return sd
+}
+
+type StateDiffResponse struct {
+	BlockNumber uint64                                  `json:"blockNumber"`
+	BlockHash   common.Hash                             `json:"blockHash"`
+	Destructs   []common.Address                        `json:"destructs"`
+	Accounts    map[common.Address]*AccountDiffResponse `json:"accounts"`
+	Codes       map[common.Hash]hexutil.Bytes           `json:"codes"`
+}
+
+type AccountDiffResponse struct {
+	AccountType uint8                       `json:"accType"`
+	Nonce       hexutil.Uint64              `json:"nonce"`
+	Balance     *hexutil.Big                `json:"balance"`
+	CodeHash    *common.Hash                `json:"codeHash,omitempty"`
+	StorageRoot *common.Hash                `json:"storageRoot,omitempty"`
+	Storage     map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// ToResponse decodes the accounts and the storage values of the state diff.
+func (sd *StateDiff) ToResponse() (*StateDiffResponse, error) {
+	res := &StateDiffResponse{
+		BlockNumber: sd.BlockNumber,
+		BlockHash:   sd.BlockHash,
+		Destructs:   sd.Destructs,
+		Accounts:    make(map[common.Address]*AccountDiffResponse, len(sd.Accounts)),
+		Codes:       make(map[common.Hash]hexutil.Bytes, len(sd.Codes)),
+	}
+	for _, ad := range sd.Accounts {
+		serializer := account.NewAccountSerializer()
+		if err := rlp.DecodeBytes(ad.Account, serializer); err != nil {
+			return nil, ErrMalformedAccount
+		}
+		acc := serializer.GetAccount()
+		accRes := &AccountDiffResponse{
+			AccountType: uint8(acc.Type()),
+			Nonce:       hexutil.Uint64(acc.GetNonce()),
+			Balance:     (*hexutil.Big)(acc.GetBalance()),
+		}
+		if pa := account.GetProgramAccount(acc); pa != nil {
+			codeHash := common.BytesToHash(pa.GetCodeHash())
+			storageRoot := pa.GetStorageRoot().Unextend()
+			accRes.CodeHash, accRes.StorageRoot = &codeHash, &storageRoot
+		}
+		if len(ad.Storage) > 0 {
+			accRes.Storage = make(map[common.Hash]common.Hash, len(ad.Storage))
+			for _, slot := range ad.Storage {
+				var value []byte
+				if len(slot.Value) > 0 {
+					_, content, _, err := rlp.Split(slot.Value)
+					if err != nil {
+						return nil, err
+					}
+					value = content
+				}
+				accRes.Storage[slot.Key] = common.BytesToHash(value)
+			}
+		}
+		res.Accounts[ad.Address] = accRes
+	}
+	for _, code := range sd.Codes {
+		res.Codes[crypto.Keccak256Hash(code)] = code
+	}
+	return res, nil
+}
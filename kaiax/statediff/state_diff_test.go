@@ -0,0 +1,120 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package statediff
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types/account"
+	"github.com/kaiachain/kaia/blockchain/types/accountkey"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeAccount(t *testing.T, accType account.AccountType, values map[account.AccountValueKeyType]interface{}) []byte {
+	acc, err := account.NewAccountWithMap(accType, values)
+	require.NoError(t, err)
+	b, err := rlp.EncodeToBytes(account.NewAccountSerializerWithAccount(acc))
+	require.NoError(t, err)
+	return b
+}
+
+func TestStateDiff(t *testing.T) {
+	var (
+		eoa      = common.HexToAddress("0x2000")
+		sca      = common.HexToAddress("0x1000")
+		deleted  = common.HexToAddress("0x3000")
+		code     = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+		codeHash = crypto.Keccak256Hash(code)
+		root     = common.HexToHash("0xabcd")
+		hash     = common.HexToHash("0x1234")
+	)
+
+	diff := &state.StateDiff{
+		Destructs: map[common.Address]struct{}{deleted: {}},
+		Accounts: map[common.Address][]byte{
+			eoa: encodeAccount(t, account.ExternallyOwnedAccountType, map[account.AccountValueKeyType]interface{}{
+				account.AccountValueKeyNonce:   uint64(3),
+				account.AccountValueKeyBalance: big.NewInt(100),
+			}),
+			sca: encodeAccount(t, account.SmartContractAccountType, map[account.AccountValueKeyType]interface{}{
+				account.AccountValueKeyNonce:       uint64(1),
+				account.AccountValueKeyBalance:     big.NewInt(0),
+				account.AccountValueKeyAccountKey:  accountkey.NewAccountKeyFail(),
+				account.AccountValueKeyStorageRoot: root,
+				account.AccountValueKeyCodeHash:    codeHash.Bytes(),
+				account.AccountValueKeyCodeInfo:    params.NewCodeInfo(params.CodeFormatEVM, params.VmVersion0),
+			}),
+		},
+		Storage: map[common.Address]map[common.Hash][]byte{
+			sca: {
+				common.HexToHash("0x02"): nil,
+				common.HexToHash("0x01"): {0x2a},
+			},
+		},
+		Codes: map[common.Hash][]byte{codeHash: code},
+	}
+
+	sd := NewStateDiff(7, hash, diff)
+	assert.Equal(t, uint64(7), sd.BlockNumber)
+	assert.Equal(t, hash, sd.BlockHash)
+	assert.Equal(t, []common.Address{deleted}, sd.Destructs)
+	require.Len(t, sd.Accounts, 2)
+	assert.Equal(t, sca, sd.Accounts[0].Address)
+	assert.Equal(t, eoa, sd.Accounts[1].Address)
+	assert.Equal(t, []StorageDiff{
+		{Key: common.HexToHash("0x01"), Value: []byte{0x2a}},
+		{Key: common.HexToHash("0x02"), Value: nil},
+	}, sd.Accounts[0].Storage)
+	assert.Empty(t, sd.Accounts[1].Storage)
+	assert.Equal(t, [][]byte{code}, sd.Codes)
+
+	res, err := sd.ToResponse()
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{deleted}, res.Destructs)
+
+	eoaRes := res.Accounts[eoa]
+	require.NotNil(t, eoaRes)
+	assert.Equal(t, uint8(account.ExternallyOwnedAccountType), eoaRes.AccountType)
+	assert.Equal(t, uint64(3), uint64(eoaRes.Nonce))
+	assert.Equal(t, big.NewInt(100), eoaRes.Balance.ToInt())
+	assert.Equal(t, crypto.Keccak256Hash(nil), *eoaRes.CodeHash)
+	assert.Nil(t, eoaRes.Storage)
+
+	scaRes := res.Accounts[sca]
+	require.NotNil(t, scaRes)
+	assert.Equal(t, uint8(account.SmartContractAccountType), scaRes.AccountType)
+	assert.Equal(t, codeHash, *scaRes.CodeHash)
+	assert.Equal(t, root, *scaRes.StorageRoot)
+	assert.Equal(t, map[common.Hash]common.Hash{
+		common.HexToHash("0x01"): common.HexToHash("0x2a"),
+		common.HexToHash("0x02"): {},
+	}, scaRes.Storage)
+
+	assert.Equal(t, code, []byte(res.Codes[codeHash]))
+
+	// Accounts of unknown types are rejected.
+	sd.Accounts[1].Account = []byte{0x7f} // unknown account type
+	_, err = sd.ToResponse()
+	assert.ErrorIs(t, err, ErrMalformedAccount)
+}
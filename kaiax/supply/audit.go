@@ -0,0 +1,46 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package supply
+
+import (
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+)
+
+// SupplyAudit is the total supply recomputed from the state at a block, compared against the tracked total supply.
+type SupplyAudit struct {
+	// Block number and state root of the audited state.
+	Number    *hexutil.Big `json:"number"`
+	StateRoot common.Hash  `json:"stateRoot"`
+
+	// The number of accounts in the state.
+	NumAccounts uint64 `json:"numAccounts"`
+	// Sum of the balances of all accounts in the state, including the canonical burn addresses.
+	StateBalance *hexutil.Big `json:"stateBalance"`
+	// StateBalance minus the balances of the canonical burn addresses (0x0, 0xdead). Comparable to the tracked total supply.
+	StateTotalSupply *hexutil.Big `json:"stateTotalSupply"`
+	// The balances of the canonical burn addresses in the state.
+	ZeroBurn *hexutil.Big `json:"zeroBurn"`
+	DeadBurn *hexutil.Big `json:"deadBurn"`
+	// The balances of the addresses zeroed or allocated by the KIP-103 and KIP-160 rebalances, if executed.
+	RebalanceFunds map[common.Address]*hexutil.Big `json:"rebalanceFunds"`
+
+	// The total supply tracked by the supply module, with partial results.
+	Tracked *TotalSupplyResponse `json:"tracked"`
+	// StateTotalSupply - Tracked.TotalSupply. Zero if the two agree. It is null if the tracked total supply is partial.
+	Discrepancy *hexutil.Big `json:"discrepancy"`
+}
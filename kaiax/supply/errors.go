@@ -27,6 +27,7 @@ var (
 	ErrNoRebalanceMemo    = errors.New("rebalance memo empty")
 	ErrSupplyModuleQuit   = errors.New("supply module quit")
 	ErrNoSupplyCheckpoint = errors.New("supply checkpoint not found")
+	ErrMalformedAccount   = errors.New("malformed account in state")
 )
 
 func ErrNoCanonicalBurn(err error) error {
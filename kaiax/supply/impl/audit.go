@@ -0,0 +1,175 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package supply
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types/account"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/kaiax/supply"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/kaiachain/kaia/storage/statedb"
+)
+
+var auditLogInterval = uint64(1000000) // Periodic log in sumBalances().
+
+// AuditTotalSupply recomputes the total supply by traversing all accounts in the state at the given block number,
+// and compares it against GetTotalSupply. Returns (nil, err) if the state is missing or GetTotalSupply fails
+// to read the essential components.
+func (s *SupplyModule) AuditTotalSupply(num uint64) (*supply.SupplyAudit, error) {
+	header := s.Chain.GetHeaderByNumber(num)
+	if header == nil {
+		return nil, supply.ErrNoBlock
+	}
+	stateDB, err := s.Chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, tsErr := s.GetTotalSupply(num)
+	if ts == nil {
+		return nil, tsErr
+	}
+
+	stateBalance, numAccounts, err := sumBalances(stateDB.Database(), header.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		zeroBurn         = stateDB.GetBalance(zeroBurnAddress)
+		deadBurn         = stateDB.GetBalance(deadBurnAddress)
+		stateTotalSupply = new(big.Int).Sub(stateBalance, zeroBurn)
+		config           = s.ChainConfig
+	)
+	stateTotalSupply.Sub(stateTotalSupply, deadBurn)
+
+	funds := make(map[common.Address]*hexutil.Big)
+	for _, rebalance := range []struct {
+		forkNum *big.Int
+		addr    common.Address
+	}{
+		{config.Kip103CompatibleBlock, config.Kip103ContractAddress},
+		{config.Kip160CompatibleBlock, config.Kip160ContractAddress},
+	} {
+		for _, fund := range s.getRebalanceFunds(num, rebalance.forkNum, rebalance.addr) {
+			funds[fund] = (*hexutil.Big)(stateDB.GetBalance(fund))
+		}
+	}
+
+	var discrepancy *big.Int
+	if ts.TotalSupply != nil {
+		discrepancy = new(big.Int).Sub(stateTotalSupply, ts.TotalSupply)
+	}
+
+	return &supply.SupplyAudit{
+		Number:           (*hexutil.Big)(new(big.Int).SetUint64(num)),
+		StateRoot:        header.Root,
+		NumAccounts:      numAccounts,
+		StateBalance:     (*hexutil.Big)(stateBalance),
+		StateTotalSupply: (*hexutil.Big)(stateTotalSupply),
+		ZeroBurn:         (*hexutil.Big)(zeroBurn),
+		DeadBurn:         (*hexutil.Big)(deadBurn),
+		RebalanceFunds:   funds,
+		Tracked:          ts.ToResponse(num, tsErr),
+		Discrepancy:      (*hexutil.Big)(discrepancy),
+	}, nil
+}
+
+// sumBalances traverses the account trie at the given root and returns the sum of the balances and the number of accounts.
+// Unlike totalSupplyFromState, it does not hold the accounts in memory.
+func sumBalances(db state.Database, root common.Hash) (*big.Int, uint64, error) {
+	tr, err := db.OpenTrie(root, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		total = new(big.Int)
+		count = uint64(0)
+		it    = statedb.NewIterator(tr.NodeIterator(nil))
+	)
+	for it.Next() {
+		serializer := account.NewAccountSerializer()
+		if err := rlp.DecodeBytes(it.Value, serializer); err != nil {
+			return nil, 0, supply.ErrMalformedAccount
+		}
+		total.Add(total, serializer.GetAccount().GetBalance())
+		count++
+
+		if count%auditLogInterval == 0 {
+			logger.Info("Traversing accounts for supply audit", "root", root, "accounts", count, "balance", total.String())
+		}
+	}
+	if it.Err != nil {
+		return nil, 0, it.Err
+	}
+	return total, count, nil
+}
+
+// getRebalanceFunds returns the addresses zeroed or allocated by the rebalance, read from the rebalance memo.
+// The result may contain duplicates.
+// Returns nil if the rebalance is not configured, the fork block is not reached, or the memo is unavailable.
+func (s *SupplyModule) getRebalanceFunds(num uint64, forkNum *big.Int, addr common.Address) []common.Address {
+	if forkNum == nil || forkNum.Sign() == 0 || (addr == common.Address{}) || forkNum.Uint64() > num {
+		return nil
+	}
+
+	memo, err := s.readRebalanceMemo(addr)
+	if err != nil || memo == "" {
+		return nil
+	}
+
+	result := struct {
+		// KIP-103 memo format.
+		Retirees []struct {
+			Retired common.Address `json:"retired"`
+		} `json:"retirees"`
+		Newbies []struct {
+			Newbie common.Address `json:"newbie"`
+		} `json:"newbies"`
+		// KIP-160 memo format. See system.rebalanceResult struct.
+		Before struct {
+			Zeroed    map[common.Address]*big.Int `json:"zeroed"`
+			Allocated map[common.Address]*big.Int `json:"allocated"`
+		} `json:"before"`
+	}{}
+	if err := json.Unmarshal([]byte(memo), &result); err != nil {
+		// e.g. the Kairos KIP-103 memo is not in the JSON format.
+		logger.Warn("Cannot read rebalance funds from memo", "contract", addr, "err", err)
+		return nil
+	}
+
+	funds := make([]common.Address, 0)
+	for _, retiree := range result.Retirees {
+		funds = append(funds, retiree.Retired)
+	}
+	for _, newbie := range result.Newbies {
+		funds = append(funds, newbie.Newbie)
+	}
+	for fund := range result.Before.Zeroed {
+		funds = append(funds, fund)
+	}
+	for fund := range result.Before.Allocated {
+		funds = append(funds, fund)
+	}
+	return funds
+}
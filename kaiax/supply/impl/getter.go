@@ -158,16 +158,7 @@ func (s *SupplyModule) getRebalanceBurn(num uint64, forkNum *big.Int, addr commo
 		return burnt.(*big.Int), nil
 	}
 
-	// Load the state at latest block, not the rebalance fork block.
-	// The memo is manually stored in the contract after-the-fact by calling the finalizeContract function.
-	// Therefore it's safest to read from the latest state.
-	backend := backends.NewBlockchainContractBackend(s.Chain, nil, nil)
-	caller, err := rebalance.NewTreasuryRebalanceV2Caller(addr, backend)
-	if err != nil {
-		// 4. contract call failed.
-		return nil, supply.ErrNoRebalanceBurn(err)
-	}
-	memo, err := caller.Memo(&bind.CallOpts{BlockNumber: nil}) // call at the latest block
+	memo, err := s.readRebalanceMemo(addr)
 	if err != nil {
 		// 3a. the contract reverted or the contract is not there.
 		// 4. contract call failed for other unknown reasons.
@@ -199,6 +190,19 @@ func (s *SupplyModule) getRebalanceBurn(num uint64, forkNum *big.Int, addr commo
 	return result.Burnt, nil
 }
 
+// readRebalanceMemo reads the memo of the rebalance contract at addr.
+func (s *SupplyModule) readRebalanceMemo(addr common.Address) (string, error) {
+	// Load the state at latest block, not the rebalance fork block.
+	// The memo is manually stored in the contract after-the-fact by calling the finalizeContract function.
+	// Therefore it's safest to read from the latest state.
+	backend := backends.NewBlockchainContractBackend(s.Chain, nil, nil)
+	caller, err := rebalance.NewTreasuryRebalanceV2Caller(addr, backend)
+	if err != nil {
+		return "", err
+	}
+	return caller.Memo(&bind.CallOpts{BlockNumber: nil}) // call at the latest block
+}
+
 // accumulateRewards accumulates the reward increments from `fromNum` to `toNum`, inclusive.
 // If `write` is true, the intermediate results at checkpointInterval will be written to the database.
 func (s *SupplyModule) accumulateRewards(fromNum, toNum uint64, fromAccReward *supply.AccReward, write bool) (*supply.AccReward, error) {
@@ -32,4 +32,8 @@ type SupplyModule interface {
 	// Returns (ts, err) if partial components (e.g. canonical burn amounts) are missing.
 	// Otherwise, returns (ts, nil).
 	GetTotalSupply(num uint64) (*TotalSupply, error)
+
+	// AuditTotalSupply recomputes the total supply from all account balances in the state at the given block number,
+	// and compares it against GetTotalSupply.
+	AuditTotalSupply(num uint64) (*SupplyAudit, error)
 }
@@ -51,6 +51,21 @@ func (mr *MockSupplyModuleMockRecorder) APIs() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIs", reflect.TypeOf((*MockSupplyModule)(nil).APIs))
 }
 
+// AuditTotalSupply mocks base method.
+func (m *MockSupplyModule) AuditTotalSupply(arg0 uint64) (*supply.SupplyAudit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditTotalSupply", arg0)
+	ret0, _ := ret[0].(*supply.SupplyAudit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuditTotalSupply indicates an expected call of AuditTotalSupply.
+func (mr *MockSupplyModuleMockRecorder) AuditTotalSupply(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditTotalSupply", reflect.TypeOf((*MockSupplyModule)(nil).AuditTotalSupply), arg0)
+}
+
 // GetTotalSupply mocks base method.
 func (m *MockSupplyModule) GetTotalSupply(arg0 uint64) (*supply.TotalSupply, error) {
 	m.ctrl.T.Helper()
@@ -134,6 +134,7 @@ const (
 
 	// 61~70
 	KaiaxGov
+	KaiaxStateDiff
 
 	// ModuleNameLen should be placed at the end of the list.
 	ModuleNameLen
@@ -217,4 +218,5 @@ var moduleNames = [ModuleNameLen]string{
 
 	// 61~70
 	"kaiax/gov",
+	"kaiax/statediff",
 }
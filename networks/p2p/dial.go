@@ -104,12 +104,15 @@ type dialstate struct {
 	lookupBuf          []*discover.Node // current discovery lookup results
 	randomNodes        []*discover.Node // filled from Table
 	static             map[discover.NodeID]*dialTask
+	dnsNodes           map[discover.NodeID]struct{} // static nodes added from the DNS node lists
 	hist               *dialHistory
 
 	start     time.Time        // time when the dialer was first used
 	bootnodes []*discover.Node // default dials when there are no peers
 
 	tsMap map[dialType]typedStatic // tsMap holds typedStaticDial per dialType(discovery name)
+
+	isBanned func(discover.NodeID) bool // reports whether a node is banned, if set
 }
 
 // the dial history remembers recent dials.
@@ -202,6 +205,17 @@ func convertDialT2NodeT(dt dialType) discover.NodeType {
 	return discover.NodeTypeUnknown
 }
 
+func convertNodeT2DialT(nt discover.NodeType) (dialType, bool) {
+	switch nt {
+	case discover.NodeTypeCN:
+		return DT_CN, true
+	case discover.NodeTypePN:
+		return DT_PN, true
+	default:
+		return "", false
+	}
+}
+
 func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab discover.Discovery, maxdyn int,
 	netrestrict *netutil.Netlist, privateKey *ecdsa.PrivateKey, tsMap map[dialType]typedStatic,
 ) *dialstate {
@@ -215,6 +229,7 @@ func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab disc
 		ntab:               ntab,
 		netrestrict:        netrestrict,
 		static:             make(map[discover.NodeID]*dialTask),
+		dnsNodes:           make(map[discover.NodeID]struct{}),
 		dialing:            make(map[discover.NodeID]connFlag),
 		bootnodes:          make([]*discover.Node, len(bootnodes)),
 		randomNodes:        make([]*discover.Node, maxdyn/2),
@@ -244,6 +259,8 @@ func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab disc
 }
 
 func (s *dialstate) addStatic(n *discover.Node) {
+	// A node added explicitly is kept even if it leaves the DNS node lists.
+	delete(s.dnsNodes, n.ID)
 	s.addTypedStatic(n, DT_UNLIMITED)
 }
 
@@ -264,11 +281,46 @@ func (s *dialstate) addTypedStatic(n *discover.Node, dType dialType) {
 func (s *dialstate) removeStatic(n *discover.Node) {
 	// This removes a task so future attempts to connect will not be made.
 	delete(s.static, n.ID)
+	delete(s.dnsNodes, n.ID)
 	// This removes a previous dial timestamp so that application
 	// can force a server to reconnect with chosen peer immediately.
 	s.hist.remove(n.ID)
 }
 
+// addDNSNodes syncs the dial candidates with the nodes of the DNS node lists.
+// CNs and PNs are added as typed static nodes, and ENs are used for dynamic dials.
+// The other nodes are ignored. The static nodes added by the previous sync that
+// are no longer in the lists are removed. The DNS nodes are not counted against
+// the maxNodeCount of their dial type, which limits the discovered typed static nodes.
+func (s *dialstate) addDNSNodes(nodes []*discover.Node) {
+	synced := make(map[discover.NodeID]struct{})
+	for _, n := range nodes {
+		if dt, ok := convertNodeT2DialT(n.NType); ok {
+			if _, exists := s.static[n.ID]; exists {
+				// Keep the nodes added by the previous sync, not the ones added otherwise.
+				if _, isDNS := s.dnsNodes[n.ID]; isDNS {
+					synced[n.ID] = struct{}{}
+				}
+				continue
+			}
+			s.addTypedStatic(n, dt)
+			synced[n.ID] = struct{}{}
+		} else if n.NType == discover.NodeTypeEN && len(s.lookupBuf) < s.maxDynDials {
+			s.lookupBuf = append(s.lookupBuf, n)
+		}
+	}
+
+	for id := range s.dnsNodes {
+		if _, ok := synced[id]; !ok {
+			logger.Debug("[Dial] Removing static node removed from the DNS node lists", "id", id)
+			if t := s.static[id]; t != nil {
+				s.removeStatic(t.dest)
+			}
+		}
+	}
+	s.dnsNodes = synced
+}
+
 func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now time.Time) []task {
 	if s.start.IsZero() {
 		s.start = now
@@ -305,9 +357,12 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	}
 
 	addStaticDialTasks := func() {
+		// The DNS nodes are not limited by maxNodeCount.
 		cnt := make(map[dialType]int)
-		for _, t := range s.static {
-			cnt[t.dialType]++
+		for id, t := range s.static {
+			if _, isDNS := s.dnsNodes[id]; !isDNS {
+				cnt[t.dialType]++
+			}
 		}
 
 		checkStaticDial := func(dt *dialTask, peers map[discover.NodeID]*Peer) error {
@@ -334,26 +389,31 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 				return errExpired
 			}
 
-			if cnt[dt.dialType] > s.tsMap[dt.dialType].maxNodeCount {
+			if _, isDNS := s.dnsNodes[dt.dest.ID]; !isDNS && cnt[dt.dialType] > typeSpec.maxNodeCount {
 				return errExceedMaxTypedDial
 			}
 			return nil
 		}
 
 		for id, t := range s.static {
+			_, isDNS := s.dnsNodes[id]
 			err := checkStaticDial(t, peers)
 			switch err {
 			case errNotWhitelisted, errSelf:
 				logger.Info("[Dial] Removing static dial candidate from static nodes", "id",
 					t.dest.ID, "addr", &net.TCPAddr{IP: t.dest.IP, Port: int(t.dest.TCP)}, "err", err)
 				delete(s.static, t.dest.ID)
-				cnt[t.dialType]--
+				if !isDNS {
+					cnt[t.dialType]--
+				}
 			case errExpired:
 				logger.Info("[Dial] Removing expired dial candidate from static nodes", "id",
 					t.dest.ID, "addr", &net.TCPAddr{IP: t.dest.IP, Port: int(t.dest.TCP)}, "dialType", t.dialType,
 					"dialCount", cnt[t.dialType], "err", err)
 				delete(s.static, t.dest.ID)
-				cnt[t.dialType]--
+				if !isDNS {
+					cnt[t.dialType]--
+				}
 			case errExceedMaxTypedDial:
 				logger.Info("[Dial] Removing exceeded dial candidate from static nodes", "id",
 					t.dest.ID, "addr", &net.TCPAddr{IP: t.dest.IP, Port: int(t.dest.TCP)}, "dialType", t.dialType,
@@ -396,7 +456,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	// Use random nodes from the table for half of the necessary
 	// dynamic dials.
 	randomCandidates := needDynDials / 2
-	if randomCandidates > 0 {
+	if randomCandidates > 0 && s.ntab != nil {
 		n := s.ntab.ReadRandomNodes(s.randomNodes, discover.NodeTypeEN)
 		for i := 0; i < randomCandidates && i < n; i++ {
 			if addDialTask(dynDialedConn, s.randomNodes[i]) {
@@ -414,7 +474,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	}
 	s.lookupBuf = s.lookupBuf[:copy(s.lookupBuf, s.lookupBuf[i:])]
 	// Launch a discovery lookup if more candidates are needed.
-	if len(s.lookupBuf) < needDynDials && !s.lookupRunning {
+	if len(s.lookupBuf) < needDynDials && !s.lookupRunning && s.ntab != nil {
 		s.lookupRunning = true
 		newtasks = append(newtasks, &discoverTask{})
 	}
@@ -439,6 +499,7 @@ var (
 	errExpired            = errors.New("is expired")
 	errExceedMaxTypedDial = errors.New("exceeded max typed dial")
 	errUpdateDial         = errors.New("updated to be multichannel peer")
+	errBanned             = errors.New("is banned")
 )
 
 func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer) error {
@@ -454,6 +515,8 @@ func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer)
 		return errNotWhitelisted
 	case s.hist.contains(n.ID):
 		return errRecentlyDialed
+	case s.isBanned != nil && s.isBanned(n.ID):
+		return errBanned
 	}
 	return nil
 }
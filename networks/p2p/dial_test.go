@@ -34,6 +34,7 @@ import (
 	"github.com/kaiachain/kaia/common/math"
 	"github.com/kaiachain/kaia/networks/p2p/discover"
 	"github.com/kaiachain/kaia/networks/p2p/netutil"
+	"github.com/stretchr/testify/assert"
 )
 
 func init() {
@@ -131,6 +132,10 @@ func (t fakeTable) GetAuthorizedNodes() []*discover.Node         { return nil }
 func (t fakeTable) PutAuthorizedNodes(nodes []*discover.Node)    {}
 func (t fakeTable) DeleteAuthorizedNodes(nodes []*discover.Node) {}
 
+func (t fakeTable) GetBans() map[discover.NodeID]time.Time           { return nil }
+func (t fakeTable) PutBan(id discover.NodeID, until time.Time) error { return nil }
+func (t fakeTable) DeleteBan(id discover.NodeID) error               { return nil }
+
 // This test checks that dynamic dials are launched from discovery results.
 func TestDialStateDynDial(t *testing.T) {
 	runDialTest(t, dialtest{
@@ -391,6 +396,31 @@ func TestDialStateNetRestrict(t *testing.T) {
 	})
 }
 
+// This test checks that banned candidates are not dialed.
+func TestDialStateBanned(t *testing.T) {
+	table := fakeTable{
+		{ID: uintID(1), IP: net.ParseIP("127.0.0.1")},
+		{ID: uintID(2), IP: net.ParseIP("127.0.0.2")},
+		{ID: uintID(3), IP: net.ParseIP("127.0.0.3")},
+		{ID: uintID(4), IP: net.ParseIP("127.0.0.4")},
+	}
+	ds := newDialState(nil, nil, table, 10, nil, nil, nil)
+	ds.isBanned = func(id discover.NodeID) bool { return id == uintID(1) || id == uintID(3) }
+
+	runDialTest(t, dialtest{
+		init: ds,
+		rounds: []round{
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: table[1]},
+					&dialTask{flags: dynDialedConn, dest: table[3]},
+					&discoverTask{},
+				},
+			},
+		},
+	})
+}
+
 // This test checks that static dials are launched.
 func TestDialStateStaticDial(t *testing.T) {
 	wantStatic := []*discover.Node{
@@ -685,6 +715,65 @@ func TestDialStateTypedStaticMaxConn3(t *testing.T) {
 	runDialTest(t, dt)
 }
 
+// This test checks that the nodes of the DNS node lists are dialed according to their node type.
+func TestDialStateDNSNodes(t *testing.T) {
+	tsMap := make(map[dialType]typedStatic)
+	tsMap[DT_PN] = typedStatic{maxNodeCount: 1, maxTry: 3}
+
+	nodes := []*discover.Node{
+		{ID: uintID(1), NType: discover.NodeTypePN},
+		{ID: uintID(2), NType: discover.NodeTypePN}, // not limited by the typed static limit
+		{ID: uintID(3), NType: discover.NodeTypeEN},
+		{ID: uintID(4), NType: discover.NodeTypeEN},
+		{ID: uintID(5), NType: discover.NodeTypeEN}, // exceeds the dynamic dial limit
+		{ID: uintID(6), NType: discover.NodeTypeBN},
+	}
+	ds := newDialState(nil, nil, nil, 2, nil, nil, tsMap)
+	ds.addDNSNodes(nodes)
+	ds.addDNSNodes(nodes)
+
+	runDialTest(t, dialtest{
+		init: ds,
+		rounds: []round{
+			{
+				new: []task{
+					&dialTask{flags: staticDialedConn | trustedConn, dest: nodes[0], dialType: DT_PN},
+					&dialTask{flags: staticDialedConn | trustedConn, dest: nodes[1], dialType: DT_PN},
+					&dialTask{flags: dynDialedConn, dest: nodes[2]},
+					&dialTask{flags: dynDialedConn, dest: nodes[3]},
+				},
+			},
+		},
+	})
+}
+
+// This test checks that the static nodes removed from the DNS node lists are removed at the next sync,
+// and the static nodes added otherwise are kept.
+func TestDialStateDNSNodesResync(t *testing.T) {
+	var (
+		pn1    = &discover.Node{ID: uintID(1), NType: discover.NodeTypePN}
+		pn2    = &discover.Node{ID: uintID(2), NType: discover.NodeTypePN}
+		static = &discover.Node{ID: uintID(3), NType: discover.NodeTypePN}
+	)
+	ds := newDialState([]*discover.Node{static}, nil, nil, 0, nil, nil, nil)
+
+	ds.addDNSNodes([]*discover.Node{pn1, pn2, static})
+	assert.Len(t, ds.static, 3)
+	assert.Equal(t, map[discover.NodeID]struct{}{pn1.ID: {}, pn2.ID: {}}, ds.dnsNodes)
+
+	ds.addDNSNodes([]*discover.Node{pn2})
+	assert.NotContains(t, ds.static, pn1.ID)
+	assert.Contains(t, ds.static, pn2.ID)
+	assert.Contains(t, ds.static, static.ID)
+	assert.Equal(t, map[discover.NodeID]struct{}{pn2.ID: {}}, ds.dnsNodes)
+
+	// A DNS node added explicitly is kept.
+	ds.addStatic(pn2)
+	ds.addDNSNodes(nil)
+	assert.Contains(t, ds.static, pn2.ID)
+	assert.Empty(t, ds.dnsNodes)
+}
+
 // This test checks if static dials can ignore adding self ID to static node list.
 func TestDialStateAddingSelfNode(t *testing.T) {
 	privateKey := newkey()
@@ -968,3 +1057,15 @@ func (t *resolveMock) PutAuthorizedNodes(nodes []*discover.Node) {
 func (t *resolveMock) DeleteAuthorizedNodes(nodes []*discover.Node) {
 	panic("implement me")
 }
+
+func (t *resolveMock) GetBans() map[discover.NodeID]time.Time {
+	panic("implement me")
+}
+
+func (t *resolveMock) PutBan(id discover.NodeID, until time.Time) error {
+	panic("implement me")
+}
+
+func (t *resolveMock) DeleteBan(id discover.NodeID) error {
+	panic("implement me")
+}
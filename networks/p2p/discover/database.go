@@ -63,6 +63,7 @@ type nodeDB struct {
 var (
 	nodeDBVersionKey = []byte("version") // Version of the database to flush if changes
 	nodeDBItemPrefix = []byte("n:")      // Identifier to prefix node entries with
+	nodeDBBanPrefix  = []byte("b:")      // Identifier to prefix peer bans with, kept apart from expiring node entries
 
 	nodeDBDiscoverRoot      = ":discover"
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
@@ -311,6 +312,50 @@ func (db *nodeDB) updateFindFails(id NodeID, fails int) error {
 	return db.storeInt64(makeKey(id, nodeDBDiscoverFindFails), int64(fails))
 }
 
+// banExpiry retrieves the time until which the node is banned.
+func (db *nodeDB) banExpiry(id NodeID) time.Time {
+	return time.Unix(db.fetchInt64(append(nodeDBBanPrefix, id[:]...)), 0)
+}
+
+// updateBan bans the node until the given time.
+func (db *nodeDB) updateBan(id NodeID, until time.Time) error {
+	return db.storeInt64(append(nodeDBBanPrefix, id[:]...), until.Unix())
+}
+
+// deleteBan lifts the ban of the node.
+func (db *nodeDB) deleteBan(id NodeID) error {
+	return db.lvl.Delete(append(nodeDBBanPrefix, id[:]...), nil)
+}
+
+// bans retrieves the banned nodes and the times until which they are banned.
+// The expired bans are removed from the database.
+func (db *nodeDB) bans() map[NodeID]time.Time {
+	var (
+		now  = time.Now()
+		bans = make(map[NodeID]time.Time)
+		it   = db.lvl.NewIterator(util.BytesPrefix(nodeDBBanPrefix), nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		var id NodeID
+		if len(it.Key()) != len(nodeDBBanPrefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(nodeDBBanPrefix):])
+		val, read := binary.Varint(it.Value())
+		if read <= 0 {
+			continue
+		}
+		if until := time.Unix(val, 0); until.After(now) {
+			bans[id] = until
+		} else {
+			db.deleteBan(id)
+		}
+	}
+	return bans
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
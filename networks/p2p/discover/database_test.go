@@ -400,3 +400,50 @@ func TestNodeDBSelfExpiration(t *testing.T) {
 		t.Errorf("self not evacuated")
 	}
 }
+
+func TestNodeDBBans(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	var (
+		id1 = MustHexID("0x1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439")
+		id2 = MustHexID("0x2dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439")
+		now = time.Now()
+	)
+	if err := db.updateBan(id1, now.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to store ban: %v", err)
+	}
+	if err := db.updateBan(id2, now.Add(-time.Second)); err != nil {
+		t.Fatalf("failed to store ban: %v", err)
+	}
+	if until := db.banExpiry(id1); until.Unix() != now.Add(time.Hour).Unix() {
+		t.Errorf("ban expiry mismatch: have %v, want %v", until, now.Add(time.Hour))
+	}
+
+	// Expired bans are dropped.
+	bans := db.bans()
+	if len(bans) != 1 || bans[id1].Unix() != now.Add(time.Hour).Unix() {
+		t.Errorf("bans mismatch: have %v", bans)
+	}
+	if until := db.banExpiry(id2); until.Unix() != 0 {
+		t.Errorf("expired ban not removed: %v", until)
+	}
+
+	// Bans survive the expiration of the node entries.
+	if err := db.updateNode(NewNode(id1, nil, 0, 0, nil, NodeTypeEN)); err != nil {
+		t.Fatalf("failed to store node: %v", err)
+	}
+	if err := db.expireNodes(); err != nil {
+		t.Fatalf("failed to expire nodes: %v", err)
+	}
+	if _, ok := db.bans()[id1]; !ok {
+		t.Errorf("ban removed by node expiration")
+	}
+
+	if err := db.deleteBan(id1); err != nil {
+		t.Fatalf("failed to delete ban: %v", err)
+	}
+	if len(db.bans()) != 0 {
+		t.Errorf("ban not deleted")
+	}
+}
@@ -20,6 +20,7 @@ package discover
 
 import (
 	"errors"
+	"time"
 )
 
 func (tab *Table) Name() string { return "TableDiscovery" }
@@ -110,3 +111,18 @@ func (tab *Table) DeleteAuthorizedNodes(nodes []*Node) {
 		}
 	}
 }
+
+// GetBans returns the banned peers and the times until which they are banned.
+func (tab *Table) GetBans() map[NodeID]time.Time {
+	return tab.db.bans()
+}
+
+// PutBan stores the ban of a peer in the node database.
+func (tab *Table) PutBan(id NodeID, until time.Time) error {
+	return tab.db.updateBan(id, until)
+}
+
+// DeleteBan removes the ban of a peer from the node database.
+func (tab *Table) DeleteBan(id NodeID) error {
+	return tab.db.deleteBan(id)
+}
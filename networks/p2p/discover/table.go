@@ -83,6 +83,10 @@ type Discovery interface {
 	GetAuthorizedNodes() []*Node
 	PutAuthorizedNodes(nodes []*Node)
 	DeleteAuthorizedNodes(nodes []*Node)
+
+	GetBans() map[NodeID]time.Time
+	PutBan(id NodeID, until time.Time) error
+	DeleteBan(id NodeID) error
 }
 
 type Table struct {
@@ -0,0 +1,247 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/log"
+	"github.com/kaiachain/kaia/networks/p2p/discover"
+)
+
+var logger = log.NewModuleLogger(log.NetworksP2PDiscover)
+
+// Config holds the configuration of a Client.
+type Config struct {
+	Timeout    time.Duration // timeout used for DNS lookups (default 5s)
+	CacheLimit int           // maximum number of cached records (default 1000)
+	Resolver   Resolver      // the DNS resolver to use (defaults to system DNS)
+}
+
+// Resolver is a DNS resolver that can query TXT records.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+func (cfg Config) withDefaults() Config {
+	const (
+		defaultTimeout = 5 * time.Second
+		defaultCache   = 1000
+	)
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.CacheLimit == 0 {
+		cfg.CacheLimit = defaultCache
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = new(net.Resolver)
+	}
+	return cfg
+}
+
+// Client discovers nodes by querying DNS servers.
+// Since tree entries are content-addressed, they are cached and only the roots
+// are resolved again when a tree is synced repeatedly.
+type Client struct {
+	cfg     Config
+	entries *lru.Cache
+}
+
+// NewClient creates a client.
+func NewClient(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	cache, err := lru.New(cfg.CacheLimit)
+	if err != nil {
+		panic(err)
+	}
+	return &Client{cfg: cfg, entries: cache}
+}
+
+// SyncTree downloads the entire node tree at the given URL.
+// The linked trees are not downloaded.
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	le, err := parseLink(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kaiatree URL: %v", err)
+	}
+	return c.syncTree(context.Background(), le)
+}
+
+// Nodes returns the nodes in the trees at the given URLs and the trees linked from them.
+// A failure to sync a tree doesn't discard the nodes of the others; the nodes found are
+// returned along with the first error.
+func (c *Client) Nodes(ctx context.Context, urls []string) ([]*discover.Node, error) {
+	var (
+		firstErr error
+		queue    []*linkEntry
+		visited  = make(map[string]bool)
+		seen     = make(map[discover.NodeID]bool)
+		nodes    []*discover.Node
+	)
+	for _, url := range urls {
+		le, err := parseLink(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kaiatree URL %q: %v", url, err)
+		}
+		queue = append(queue, le)
+	}
+
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+		if visited[loc.str] {
+			continue
+		}
+		visited[loc.str] = true
+
+		t, err := c.syncTree(ctx, loc)
+		if err != nil {
+			logger.Debug("Failed to sync DNS node list", "domain", loc.domain, "err", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, n := range t.Nodes() {
+			if !seen[n.ID] {
+				seen[n.ID] = true
+				nodes = append(nodes, n)
+			}
+		}
+		for _, link := range t.Links() {
+			le, err := parseLink(linkPrefix + link)
+			if err == nil {
+				queue = append(queue, le)
+			}
+		}
+	}
+	return nodes, firstErr
+}
+
+func (c *Client) syncTree(ctx context.Context, loc *linkEntry) (*Tree, error) {
+	root, err := c.resolveRoot(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{root: &root, entries: make(map[string]entry)}
+	if err := c.syncSubtree(ctx, loc.domain, root.nroot, false, t.entries); err != nil {
+		return nil, err
+	}
+	if err := c.syncSubtree(ctx, loc.domain, root.lroot, true, t.entries); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncSubtree downloads the subtree at the given hash. The link subtree must consist of
+// branches and links, and the node subtree must consist of branches and nodes.
+func (c *Client) syncSubtree(ctx context.Context, domain, hash string, link bool, entries map[string]entry) error {
+	e, err := c.resolveEntry(ctx, domain, hash)
+	if err != nil {
+		return err
+	}
+	entries[hash] = e
+
+	switch e := e.(type) {
+	case *branchEntry:
+		for _, child := range e.children {
+			if err := c.syncSubtree(ctx, domain, child, link, entries); err != nil {
+				return err
+			}
+		}
+	case *linkEntry:
+		if !link {
+			return nameError{hash + "." + domain, errLinkInNodeTree}
+		}
+	case *nodeEntry:
+		if link {
+			return nameError{hash + "." + domain, errNodeInLinkTree}
+		}
+	}
+	return nil
+}
+
+// resolveRoot retrieves a root entry via DNS and verifies its signature.
+func (c *Client) resolveRoot(ctx context.Context, loc *linkEntry) (rootEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	txts, err := c.cfg.Resolver.LookupTXT(ctx, loc.domain)
+	if err != nil {
+		return rootEntry{}, err
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			e, err := parseRoot(txt)
+			if err != nil {
+				return e, nameError{loc.domain, err}
+			}
+			if !e.verifySignature(loc.pubkey) {
+				return e, nameError{loc.domain, entryError{typ: "root", err: errInvalidSig}}
+			}
+			return e, nil
+		}
+	}
+	return rootEntry{}, nameError{loc.domain, errNoRoot}
+}
+
+// resolveEntry retrieves an entry from the cache or fetches it from the network
+// if it isn't cached.
+func (c *Client) resolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	cacheKey := hash + "." + domain
+	if e, ok := c.entries.Get(cacheKey); ok {
+		return e.(entry), nil
+	}
+
+	wantHash, err := b32format.DecodeString(hash)
+	if err != nil {
+		return nil, nameError{cacheKey, errInvalidChild}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	txts, err := c.cfg.Resolver.LookupTXT(ctx, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		e, err := parseEntry(txt)
+		if err == errUnknownEntry {
+			continue
+		}
+		if !bytes.HasPrefix(crypto.Keccak256([]byte(txt)), wantHash) {
+			err = nameError{cacheKey, errHashMismatch}
+		} else if err != nil {
+			err = nameError{cacheKey, err}
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.entries.Add(cacheKey, e)
+		return e, nil
+	}
+	return nil, nameError{cacheKey, errNoEntry}
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+
+	"github.com/kaiachain/kaia/networks/p2p/discover"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapResolver is an in-process DNS resolver serving the records of a map.
+type mapResolver struct {
+	records map[string]string
+	lookups map[string]int
+}
+
+func newMapResolver(maps ...map[string]string) *mapResolver {
+	r := &mapResolver{records: make(map[string]string), lookups: make(map[string]int)}
+	for _, m := range maps {
+		r.add(m)
+	}
+	return r
+}
+
+func (r *mapResolver) add(m map[string]string) {
+	for name, txt := range m {
+		r.records[name] = txt
+	}
+}
+
+func (r *mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	r.lookups[name]++
+	if txt, ok := r.records[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func signedTree(t *testing.T, key *ecdsa.PrivateKey, domain string, seq uint, nodes []*discover.Node, links []string) (*Tree, string) {
+	tree, err := MakeTree(seq, nodes, links)
+	require.NoError(t, err)
+	url, err := tree.Sign(key, domain)
+	require.NoError(t, err)
+	return tree, url
+}
+
+func nodeIDs(nodes []*discover.Node) map[discover.NodeID]discover.NodeType {
+	ids := make(map[discover.NodeID]discover.NodeType)
+	for _, n := range nodes {
+		ids[n.ID] = n.NType
+	}
+	return ids
+}
+
+func TestClientSyncTree(t *testing.T) {
+	var (
+		key       = testKey(t)
+		nodes     = testNodes(t, 30, discover.NodeTypePN)
+		tree, url = signedTree(t, key, "n", 1, nodes, nil)
+		r         = newMapResolver(tree.ToTXT("n"))
+		c         = NewClient(Config{Resolver: r})
+	)
+	synced, err := c.SyncTree(url)
+	require.NoError(t, err)
+	assert.Equal(t, nodeIDs(nodes), nodeIDs(synced.Nodes()))
+	assert.Equal(t, tree.ToTXT("n"), synced.ToTXT("n"))
+
+	// Syncing again only resolves the root, the other entries are cached.
+	for name := range r.lookups {
+		r.lookups[name] = 0
+	}
+	_, err = c.SyncTree(url)
+	require.NoError(t, err)
+	for name, n := range r.lookups {
+		if name == "n" {
+			assert.Equal(t, 1, n)
+		} else {
+			assert.Zero(t, n, name)
+		}
+	}
+}
+
+func TestClientSyncTreeBadSignature(t *testing.T) {
+	var (
+		key, other = testKey(t), testKey(t)
+		tree, _    = signedTree(t, key, "n", 1, testNodes(t, 3, discover.NodeTypeEN), nil)
+		_, url     = signedTree(t, other, "n", 1, testNodes(t, 1, discover.NodeTypeEN), nil)
+		c          = NewClient(Config{Resolver: newMapResolver(tree.ToTXT("n"))})
+	)
+	_, err := c.SyncTree(url)
+	assert.Equal(t, nameError{"n", entryError{"root", errInvalidSig}}, err)
+}
+
+func TestClientSyncTreeHashMismatch(t *testing.T) {
+	var (
+		key       = testKey(t)
+		nodes     = testNodes(t, 1, discover.NodeTypeEN)
+		tree, url = signedTree(t, key, "n", 1, nodes, nil)
+		records   = tree.ToTXT("n")
+		r         = newMapResolver(records)
+		c         = NewClient(Config{Resolver: r})
+	)
+	// Replace the node record with another node.
+	name := subdomain(&nodeEntry{nodes[0]}) + ".n"
+	r.records[name] = testNodes(t, 1, discover.NodeTypeEN)[0].String()
+
+	_, err := c.SyncTree(url)
+	assert.Equal(t, nameError{name, errHashMismatch}, err)
+}
+
+func TestClientNodeInLinkTree(t *testing.T) {
+	var (
+		key   = testKey(t)
+		nodes = testNodes(t, 1, discover.NodeTypeEN)
+		tree  = &Tree{entries: map[string]entry{}}
+		ne    = &nodeEntry{nodes[0]}
+	)
+	tree.entries[subdomain(ne)] = ne
+	tree.root = &rootEntry{seq: 1, nroot: subdomain(ne), lroot: subdomain(ne)}
+	url, err := tree.Sign(key, "n")
+	require.NoError(t, err)
+
+	c := NewClient(Config{Resolver: newMapResolver(tree.ToTXT("n"))})
+	_, err = c.SyncTree(url)
+	assert.Equal(t, nameError{subdomain(ne) + ".n", errNodeInLinkTree}, err)
+}
+
+func TestClientNodes(t *testing.T) {
+	var (
+		key1, key2, key3 = testKey(t), testKey(t), testKey(t)
+		pns              = testNodes(t, 5, discover.NodeTypePN)
+		ens              = testNodes(t, 15, discover.NodeTypeEN)
+
+		// tree3 has a broken signature and is skipped.
+		tree3, _    = signedTree(t, key3, "n3", 1, testNodes(t, 2, discover.NodeTypeEN), nil)
+		url3        = newLinkEntry("n3", &key1.PublicKey).String()
+		tree2, url2 = signedTree(t, key2, "n2", 1, append(ens, pns[0]), []string{url3})
+		tree1, url1 = signedTree(t, key1, "n1", 1, pns, []string{url2, url3})
+
+		r = newMapResolver(tree1.ToTXT("n1"), tree2.ToTXT("n2"), tree3.ToTXT("n3"))
+		c = NewClient(Config{Resolver: r})
+	)
+	nodes, err := c.Nodes(context.Background(), []string{url1})
+	assert.Equal(t, nameError{"n3", entryError{"root", errInvalidSig}}, err)
+
+	// The nodes of the linked tree are included, without duplicates.
+	assert.Len(t, nodes, 20)
+	assert.Equal(t, nodeIDs(append(pns, ens...)), nodeIDs(nodes))
+
+	// Each tree is resolved once even if linked multiple times.
+	assert.Equal(t, 1, r.lookups["n3"])
+
+	_, err = c.Nodes(context.Background(), []string{"kaiatree://n1"})
+	assert.Error(t, err)
+}
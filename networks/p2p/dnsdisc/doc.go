@@ -0,0 +1,28 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dnsdisc implements node discovery via DNS, similar to EIP-1459.
+//
+// A node list is a merkle tree of TXT records signed by the list operator. The root
+// record at the list domain has the form
+//
+//	kaiatree-root:v1 n=<nodes-root> l=<links-root> seq=<sequence-number> sig=<signature>
+//
+// and the other records are found at <base32-hash>.<domain>, each of which is either
+// a branch (kaiatree-branch:<h1>,<h2>,...), a node URL (kni://<id>@<ip>:<port>?ntype=<type>)
+// or a link to another list (kaiatree://<base32-pubkey>@<domain>).
+// Unlike EIP-1459, the leaves are Kaia node URLs carrying the node type.
+package dnsdisc
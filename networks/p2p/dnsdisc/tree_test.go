@@ -0,0 +1,151 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"crypto/ecdsa"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/networks/p2p/discover"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return key
+}
+
+func testNodes(t *testing.T, n int, ntype discover.NodeType) []*discover.Node {
+	nodes := make([]*discover.Node, n)
+	for i := range nodes {
+		key := testKey(t)
+		ip := net.IPv4(10, 0, byte(i>>8), byte(i))
+		nodes[i] = discover.NewNode(discover.PubkeyID(&key.PublicKey), ip, 32323, 32323, nil, ntype)
+	}
+	return nodes
+}
+
+func TestTreeRoundTrip(t *testing.T) {
+	var (
+		key     = testKey(t)
+		nodes   = append(testNodes(t, 20, discover.NodeTypePN), testNodes(t, 10, discover.NodeTypeEN)...)
+		linkKey = testKey(t)
+		link    = newLinkEntry("other.example.org", &linkKey.PublicKey).String()
+	)
+	tree, err := MakeTree(3, nodes, []string{link})
+	require.NoError(t, err)
+
+	url, err := tree.Sign(key, "nodes.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), tree.Seq())
+
+	domain, pubkey, err := ParseURL(url)
+	require.NoError(t, err)
+	assert.Equal(t, "nodes.example.org", domain)
+	assert.Equal(t, key.PublicKey, *pubkey)
+
+	// The records parse back to the same entries, and the node types are kept.
+	records := tree.ToTXT("")
+	root, err := parseRoot(records[""])
+	require.NoError(t, err)
+	assert.True(t, root.verifySignature(&key.PublicKey))
+	assert.Equal(t, tree.root.String(), root.String())
+
+	types := make(map[discover.NodeType]int)
+	for name, txt := range records {
+		if name == "" {
+			continue
+		}
+		e, err := parseEntry(txt)
+		require.NoError(t, err, txt)
+		assert.Equal(t, name, subdomain(e))
+		if ne, ok := e.(*nodeEntry); ok {
+			types[ne.node.NType]++
+		}
+	}
+	assert.Equal(t, map[discover.NodeType]int{discover.NodeTypePN: 20, discover.NodeTypeEN: 10}, types)
+	assert.Len(t, tree.Nodes(), 30)
+	assert.Equal(t, []string{link[len(linkPrefix):]}, tree.Links())
+}
+
+func TestTreeSetSignature(t *testing.T) {
+	key, other := testKey(t), testKey(t)
+	tree, err := MakeTree(1, testNodes(t, 2, discover.NodeTypeCN), nil)
+	require.NoError(t, err)
+	_, err = tree.Sign(key, "n")
+	require.NoError(t, err)
+
+	sig := tree.Signature()
+	assert.Equal(t, errInvalidSig, tree.SetSignature(&other.PublicKey, sig))
+	assert.Equal(t, errInvalidSig, tree.SetSignature(&key.PublicKey, "invalid"))
+	assert.NoError(t, tree.SetSignature(&key.PublicKey, sig))
+}
+
+func TestMakeTreeIncompleteNode(t *testing.T) {
+	key := testKey(t)
+	n := discover.NewNode(discover.PubkeyID(&key.PublicKey), nil, 0, 0, nil, discover.NodeTypePN)
+	_, err := MakeTree(1, []*discover.Node{n}, nil)
+	assert.Error(t, err)
+}
+
+func TestParseEntry(t *testing.T) {
+	testcases := []struct {
+		input string
+		err   error
+	}{
+		{"kaiatree-branch:", nil},
+		{"kaiatree-branch:2XS2367YHAXJFGLZHVAWLQD4ZY", nil},
+		{"kaiatree-branch:2XS2367YHAXJFGLZHVAWLQD4ZY,MHTDO6TMUBRIA2XWG5LUDACK24", nil},
+		{"kaiatree-branch:1XS2367YHAXJFGLZHVAWLQD4ZY", entryError{"branch", errInvalidChild}},
+		{"kaiatree-branch:2XS2367YHAX", entryError{"branch", errInvalidChild}},
+		{"kaiatree://nodes.example.org", entryError{"link", errNoPubkey}},
+		{"kaiatree://AP62DT7WOTEQZGQZOU474PP3KMEGVTTE7A7NPRXKX3DUD57@nodes.example.org", entryError{"link", errBadPubkey}},
+		{"kni://1234@127.0.0.1:32323", entryError{"node", nil}},
+		{"kni://" + strings.Repeat("ab", 64), entryError{"node", errIncompleteNode}},
+		{"foo", errUnknownEntry},
+	}
+	for _, tc := range testcases {
+		_, err := parseEntry(tc.input)
+		switch want := tc.err.(type) {
+		case nil:
+			assert.NoError(t, err, tc.input)
+		case entryError:
+			got, ok := err.(entryError)
+			if assert.True(t, ok, tc.input) && want.err != nil {
+				assert.Equal(t, want, got, tc.input)
+			}
+		default:
+			assert.Equal(t, want, err, tc.input)
+		}
+	}
+}
+
+func TestParseRoot(t *testing.T) {
+	_, err := parseRoot("kaiatree-root:v1 n=foo")
+	assert.Equal(t, entryError{"root", errSyntax}, err)
+
+	_, err = parseRoot("kaiatree-root:v1 n=TO4Q75OQ2N7DX4EOOR7X66A6OM l=J3BG6NH3C2AHI4HNCKEZSQ2NT4 seq=3 sig=Zm9v")
+	assert.Equal(t, entryError{"root", errInvalidSig}, err)
+
+	_, err = parseRoot("kaiatree-root:v1 n=TO4Q l=J3BG6NH3C2AHI4HNCKEZSQ2NT4 seq=3 sig=Zm9v")
+	assert.Equal(t, entryError{"root", errInvalidChild}, err)
+}
@@ -52,6 +52,9 @@ var (
 	dialFailCounter = metrics.NewRegisteredCounter("p2p/DialFailCounter", nil)
 
 	writeMsgTimeOutCounter = metrics.NewRegisteredCounter("p2p/WriteMsgTimeOutCounter", nil)
+
+	peerPenaltyCounter = metrics.NewRegisteredCounter("p2p/PeerPenaltyCounter", nil)
+	peerBanCounter     = metrics.NewRegisteredCounter("p2p/PeerBanCounter", nil)
 )
 
 // meteredConn is a wrapper around a network TCP connection that meters both the
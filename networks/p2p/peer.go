@@ -125,6 +125,9 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// reputation bans the peer on misbehaviors if set
+	reputation *reputation
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -185,6 +188,23 @@ func (p *Peer) Disconnect(reason DiscReason) {
 	}
 }
 
+// Penalize reports a misbehavior of the peer. The peer is banned and disconnected
+// when its accumulated penalty reaches the ban threshold. Trusted peers are not banned.
+func (p *Peer) Penalize(penalty int, reason string) {
+	if p == nil || p.reputation == nil {
+		return
+	}
+	peerPenaltyCounter.Inc(int64(penalty))
+	p.logger.Debug("Penalized peer", "penalty", penalty, "reason", reason)
+	if p.rws[ConnDefault].is(trustedConn) {
+		return
+	}
+	if p.reputation.penalize(p.ID(), penalty) {
+		p.logger.Warn("Banned misbehaving peer", "reason", reason, "duration", p.reputation.banDuration)
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
 // String implements fmt.Stringer.
 func (p *Peer) String() string {
 	return fmt.Sprintf("Peer %x %v", p.rws[ConnDefault].id[:8], p.RemoteAddr())
@@ -219,6 +239,12 @@ func newPeer(conns []*conn, protocols []Protocol, tc RWTimerConfig) (*Peer, erro
 		msgReadWriters[i] = c
 	}
 	protomap := matchProtocols(protocols, conns[ConnDefault].caps, msgReadWriters, tc)
+	peerType := ConvertConnTypeToString(conns[ConnDefault].conntype)
+	for _, rws := range protomap {
+		for _, rw := range rws {
+			rw.traffic = newProtoTraffic(rw.Protocol, peerType)
+		}
+	}
 	p := &Peer{
 		rws:      conns,
 		running:  protomap,
@@ -604,12 +630,15 @@ type protoRW struct {
 	w      MsgWriter
 	count  uint64 // count the number of WriteMsg calls
 	tc     RWTimerConfig
+
+	traffic protoTraffic // messages and bytes by code, for admin_peers and the metrics
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	if msg.Code >= rw.Length {
 		return newPeerError(errInvalidMsgCode, "not handled, (code %x) (size %d)", msg.Code, msg.Size)
 	}
+	rw.traffic.markOut(msg.Code, msg.Size)
 	msg.Code += rw.offset
 	rwCount := atomic.AddUint64(&rw.count, 1)
 	if rwCount%rw.tc.Interval == 0 {
@@ -649,6 +678,7 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 	select {
 	case msg := <-rw.in:
 		msg.Code -= rw.offset
+		rw.traffic.markIn(msg.Code, msg.Size)
 		return msg, nil
 	case <-rw.closed:
 		return Msg{}, io.EOF
@@ -674,6 +704,9 @@ type PeerInfo struct {
 	Caps      []string               `json:"caps"`      // Sum-protocols advertised by this particular peer
 	Networks  []NetworkInfo          `json:"networks"`  // Networks is all the NetworkInfo associated with the peer
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+
+	// Traffic is the messages exchanged with the peer by protocol name and message code
+	Traffic map[string]*ProtocolTraffic `json:"traffic"`
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -689,6 +722,7 @@ func (p *Peer) Info() *PeerInfo {
 		Name:      p.Name(),
 		Caps:      caps,
 		Protocols: make(map[string]interface{}),
+		Traffic:   p.Traffic(),
 	}
 
 	for _, rw := range p.rws {
@@ -172,6 +172,42 @@ func TestPeerProtoEncodeMsg(t *testing.T) {
 	}
 }
 
+func TestPeerTraffic(t *testing.T) {
+	done := make(chan struct{})
+	proto := Protocol{
+		Name:   "a",
+		Length: 3,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			defer close(done)
+			for i := 0; i < 2; i++ {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				msg.Discard()
+			}
+			return SendItems(rw, 2, "foo")
+		},
+	}
+	closer, rw, peer, _ := testPeer([]Protocol{proto})
+	defer closer()
+
+	assert.NoError(t, Send(rw, baseProtocolLength+1, []uint{1}))
+	assert.NoError(t, Send(rw, baseProtocolLength+1, []uint{2, 3}))
+	assert.NoError(t, ExpectMsg(rw, baseProtocolLength+2, []string{"foo"}))
+	<-done
+
+	traffic := peer.Traffic()["a"]
+	if !assert.NotNil(t, traffic) {
+		return
+	}
+	assert.Equal(t, &MsgTraffic{InMsgs: 2, InBytes: 5}, traffic.Msgs[1])
+	assert.Equal(t, &MsgTraffic{OutMsgs: 1, OutBytes: 5}, traffic.Msgs[2])
+	assert.Nil(t, traffic.Msgs[0])
+	assert.Equal(t, MsgTraffic{InMsgs: 2, InBytes: 5, OutMsgs: 1, OutBytes: 5}, traffic.MsgTraffic)
+	assert.Equal(t, traffic, peer.Info().Traffic["a"])
+}
+
 func TestPeerPing(t *testing.T) {
 	closer, rw, _, _ := testPeer(nil)
 	defer closer()
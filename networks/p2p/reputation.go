@@ -0,0 +1,213 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kaiachain/kaia/networks/p2p/discover"
+)
+
+// Penalties of the peer misbehaviors reported by the protocols.
+// A peer is banned when its accumulated penalty reaches the ban threshold.
+const (
+	PenaltySlowResponse = 10 // The peer didn't respond to a request in time
+	PenaltyBadMessage   = 50 // The peer sent an undecodable or unexpected message
+	PenaltyInvalidData  = 50 // The peer sent an invalid block or transaction
+)
+
+const (
+	defaultPeerBanThreshold = 100
+	defaultPeerBanDuration  = time.Hour
+
+	// penaltyHalfLife is the time it takes for the accumulated penalty of a peer to halve.
+	penaltyHalfLife = 10 * time.Minute
+
+	// maxTrackedPenalties is the number of peers with penalties above which the
+	// negligible penalties are forgotten.
+	maxTrackedPenalties = 1024
+)
+
+// BannedPeerInfo represents a banned peer.
+type BannedPeerInfo struct {
+	ID    discover.NodeID `json:"id"`
+	Until time.Time       `json:"until"`
+}
+
+// banStore persists the peer bans.
+type banStore interface {
+	GetBans() map[discover.NodeID]time.Time
+	PutBan(id discover.NodeID, until time.Time) error
+	DeleteBan(id discover.NodeID) error
+}
+
+// peerPenalty is the accumulated penalty of a peer, decaying over time.
+type peerPenalty struct {
+	value   float64
+	updated time.Time
+}
+
+func (p *peerPenalty) decayed(now time.Time) float64 {
+	elapsed := now.Sub(p.updated)
+	if elapsed <= 0 {
+		return p.value
+	}
+	return p.value * math.Pow(0.5, float64(elapsed)/float64(penaltyHalfLife))
+}
+
+// reputation keeps track of the peer misbehaviors and bans the peers whose
+// accumulated penalty reaches the threshold.
+type reputation struct {
+	threshold   float64
+	banDuration time.Duration
+	store       banStore // nil if the bans are not persisted
+	now         func() time.Time
+
+	mu        sync.Mutex
+	penalties map[discover.NodeID]*peerPenalty
+	bans      map[discover.NodeID]time.Time
+}
+
+func newReputation(threshold int, banDuration time.Duration, store banStore) *reputation {
+	if threshold <= 0 {
+		threshold = defaultPeerBanThreshold
+	}
+	if banDuration <= 0 {
+		banDuration = defaultPeerBanDuration
+	}
+	r := &reputation{
+		threshold:   float64(threshold),
+		banDuration: banDuration,
+		store:       store,
+		now:         time.Now,
+		penalties:   make(map[discover.NodeID]*peerPenalty),
+		bans:        make(map[discover.NodeID]time.Time),
+	}
+	if store != nil {
+		for id, until := range store.GetBans() {
+			r.bans[id] = until
+		}
+	}
+	return r
+}
+
+// penalize adds the penalty to the peer and bans it if the accumulated penalty
+// reaches the threshold. It returns true if the peer got banned.
+func (r *reputation) penalize(id discover.NodeID, penalty int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	p := r.penalties[id]
+	if p == nil {
+		if len(r.penalties) >= maxTrackedPenalties {
+			r.forgetNegligible(now)
+		}
+		p = &peerPenalty{}
+		r.penalties[id] = p
+	}
+	p.value = p.decayed(now) + float64(penalty)
+	p.updated = now
+	if p.value < r.threshold {
+		return false
+	}
+	r.banUntil(id, now.Add(r.banDuration))
+	return true
+}
+
+// forgetNegligible removes the penalties that decayed below 1.
+func (r *reputation) forgetNegligible(now time.Time) {
+	for id, p := range r.penalties {
+		if p.decayed(now) < 1 {
+			delete(r.penalties, id)
+		}
+	}
+}
+
+// ban bans the peer for the given duration. Zero duration means the default ban duration.
+func (r *reputation) ban(id discover.NodeID, duration time.Duration) {
+	if duration <= 0 {
+		duration = r.banDuration
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.banUntil(id, r.now().Add(duration))
+}
+
+func (r *reputation) banUntil(id discover.NodeID, until time.Time) {
+	delete(r.penalties, id)
+	r.bans[id] = until
+	peerBanCounter.Inc(1)
+	if r.store != nil {
+		if err := r.store.PutBan(id, until); err != nil {
+			logger.Warn("Failed to store the peer ban", "id", id, "err", err)
+		}
+	}
+}
+
+// unban lifts the ban of the peer. It returns false if the peer is not banned.
+func (r *reputation) unban(id discover.NodeID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.bans[id]
+	delete(r.bans, id)
+	delete(r.penalties, id)
+	if r.store != nil {
+		if err := r.store.DeleteBan(id); err != nil {
+			logger.Warn("Failed to delete the peer ban", "id", id, "err", err)
+		}
+	}
+	return ok
+}
+
+// isBanned returns true if the peer is banned.
+func (r *reputation) isBanned(id discover.NodeID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.bans[id]
+	if !ok {
+		return false
+	}
+	if r.now().Before(until) {
+		return true
+	}
+	delete(r.bans, id)
+	return false
+}
+
+// banned returns the banned peers sorted by the ban expiry.
+func (r *reputation) banned() []*BannedPeerInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	banned := make([]*BannedPeerInfo, 0, len(r.bans))
+	for id, until := range r.bans {
+		if now.Before(until) {
+			banned = append(banned, &BannedPeerInfo{ID: id, Until: until})
+		} else {
+			delete(r.bans, id)
+		}
+	}
+	sort.Slice(banned, func(i, j int) bool { return banned[i].Until.Before(banned[j].Until) })
+	return banned
+}
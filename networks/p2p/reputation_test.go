@@ -0,0 +1,118 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaiachain/kaia/networks/p2p/discover"
+	"github.com/stretchr/testify/assert"
+)
+
+type memBanStore map[discover.NodeID]time.Time
+
+func (s memBanStore) GetBans() map[discover.NodeID]time.Time { return s }
+
+func (s memBanStore) PutBan(id discover.NodeID, until time.Time) error {
+	s[id] = until
+	return nil
+}
+
+func (s memBanStore) DeleteBan(id discover.NodeID) error {
+	delete(s, id)
+	return nil
+}
+
+func TestReputation(t *testing.T) {
+	var (
+		now   = time.Unix(1700000000, 0)
+		store = memBanStore{}
+		id1   = uintID(1)
+		id2   = uintID(2)
+	)
+	r := newReputation(0, 0, store)
+	r.now = func() time.Time { return now }
+
+	// Penalties accumulate up to the threshold.
+	assert.False(t, r.penalize(id1, PenaltyBadMessage))
+	assert.False(t, r.isBanned(id1))
+	assert.True(t, r.penalize(id1, PenaltyInvalidData))
+	assert.True(t, r.isBanned(id1))
+	assert.Equal(t, now.Add(defaultPeerBanDuration), store[id1])
+
+	// Penalties decay over time.
+	assert.False(t, r.penalize(id2, PenaltyBadMessage))
+	now = now.Add(penaltyHalfLife)
+	assert.False(t, r.penalize(id2, PenaltyBadMessage))
+	assert.False(t, r.isBanned(id2))
+
+	// Bans expire.
+	now = now.Add(defaultPeerBanDuration)
+	assert.False(t, r.isBanned(id1))
+	assert.Empty(t, r.banned())
+}
+
+func TestReputationBanUnban(t *testing.T) {
+	var (
+		now   = time.Unix(1700000000, 0)
+		store = memBanStore{}
+		id1   = uintID(1)
+		id2   = uintID(2)
+	)
+	r := newReputation(10, time.Minute, store)
+	r.now = func() time.Time { return now }
+
+	r.ban(id1, time.Hour)
+	r.ban(id2, 0)
+	assert.Equal(t, []*BannedPeerInfo{
+		{ID: id2, Until: now.Add(time.Minute)},
+		{ID: id1, Until: now.Add(time.Hour)},
+	}, r.banned())
+
+	// The bans are restored from the store.
+	restored := newReputation(10, time.Minute, store)
+	restored.now = r.now
+	assert.True(t, restored.isBanned(id1))
+	assert.True(t, restored.isBanned(id2))
+
+	assert.True(t, r.unban(id1))
+	assert.False(t, r.unban(id1))
+	assert.False(t, r.isBanned(id1))
+	assert.NotContains(t, store, id1)
+}
+
+func TestPeerPenalize(t *testing.T) {
+	r := newReputation(PenaltyBadMessage, time.Minute, nil)
+
+	p := NewPeer(uintID(1), "peer", nil)
+	p.reputation = r
+	p.Penalize(PenaltyBadMessage, "test")
+	assert.True(t, r.isBanned(p.ID()))
+
+	// Trusted peers are not banned.
+	trusted := NewPeer(uintID(2), "trusted", nil)
+	trusted.rws[ConnDefault].flags |= trustedConn
+	trusted.reputation = r
+	trusted.Penalize(PenaltyBadMessage, "test")
+	assert.False(t, r.isBanned(trusted.ID()))
+
+	// Peers without reputation are ignored.
+	var nilPeer *Peer
+	nilPeer.Penalize(PenaltyBadMessage, "test")
+	NewPeer(uintID(3), "peer", nil).Penalize(PenaltyBadMessage, "test")
+}
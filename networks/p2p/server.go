@@ -23,9 +23,11 @@
 package p2p
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"strconv"
 	"strings"
@@ -37,6 +39,7 @@ import (
 	"github.com/kaiachain/kaia/event"
 	"github.com/kaiachain/kaia/log"
 	"github.com/kaiachain/kaia/networks/p2p/discover"
+	"github.com/kaiachain/kaia/networks/p2p/dnsdisc"
 	"github.com/kaiachain/kaia/networks/p2p/nat"
 	"github.com/kaiachain/kaia/networks/p2p/netutil"
 )
@@ -55,6 +58,9 @@ const (
 
 	// Maximum amount of time allowed for writing a complete message.
 	frameWriteTimeout = 20 * time.Second
+
+	// Interval of re-syncing the DNS node lists.
+	dnsRecheckInterval = 30 * time.Minute
 )
 
 var errServerStopped = errors.New("server stopped")
@@ -101,6 +107,14 @@ type Config struct {
 	//// protocol.
 	//BootstrapNodesV5 []*discv5.Node `toml:",omitempty"`
 
+	// DNSDiscovery is a list of kaiatree:// URLs of DNS node lists.
+	// The nodes of the lists are added to the dial candidates according to their node type.
+	DNSDiscovery []string `toml:",omitempty"`
+
+	// DNSResolver is the resolver used to query the DNS node lists.
+	// If it is nil, the system resolver is used.
+	DNSResolver dnsdisc.Resolver `toml:"-"`
+
 	// Static nodes are used as pre-configured connections which are always
 	// maintained and re-connected on disconnects.
 	StaticNodes []*discover.Node
@@ -152,6 +166,14 @@ type Config struct {
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
+	// PeerBanThreshold is the accumulated penalty of peer misbehaviors at which the
+	// peer is banned. Setting it to zero defaults it to 100.
+	PeerBanThreshold int `toml:",omitempty"`
+
+	// PeerBanDuration is the time a misbehaving peer is banned for.
+	// Setting it to zero defaults it to an hour.
+	PeerBanDuration time.Duration `toml:",omitempty"`
+
 	// If EnableMsgEvents is set then the server will emit PeerEvents
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
@@ -267,6 +289,15 @@ type Server interface {
 	// Disconnect tries to disconnect peer.
 	Disconnect(destID discover.NodeID)
 
+	// BanPeer bans the peer for the given duration and disconnects it.
+	BanPeer(id discover.NodeID, duration time.Duration)
+
+	// UnbanPeer lifts the ban of the peer.
+	UnbanPeer(id discover.NodeID) bool
+
+	// BannedPeers returns the banned peers.
+	BannedPeers() []*BannedPeerInfo
+
 	// GetListenAddress returns the listen address list of the server.
 	GetListenAddress() []string
 
@@ -325,6 +356,7 @@ func (srv *MultiChannelServer) Start() (err error) {
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 	srv.discpeer = make(chan discover.NodeID)
+	srv.dnsNodes = make(chan []*discover.Node)
 
 	var (
 		conn      *net.UDPConn
@@ -376,7 +408,9 @@ func (srv *MultiChannelServer) Start() (err error) {
 		srv.ntab = ntab
 	}
 
+	srv.reputation = srv.newReputation()
 	dialer := newDialState(srv.StaticNodes, srv.BootstrapNodes, srv.ntab, srv.maxDialedConns(), srv.NetRestrict, srv.PrivateKey, srv.getTypeStatics())
+	dialer.isBanned = srv.reputation.isBanned
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name(), ID: discover.PubkeyID(&srv.PrivateKey.PublicKey), Multichannel: true}
@@ -408,6 +442,10 @@ func (srv *MultiChannelServer) Start() (err error) {
 
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
+	if len(srv.DNSDiscovery) > 0 && !srv.NoDial {
+		srv.loopWG.Add(1)
+		go srv.dnsDiscoveryLoop()
+	}
 	srv.running = true
 	srv.logger.Info("Started P2P server", "id", discover.PubkeyID(&srv.PrivateKey.PublicKey), "multichannel", true)
 	return nil
@@ -665,6 +703,11 @@ running:
 			// it will keep the node connected.
 			srv.logger.Debug("Adding static node", "node", n)
 			dialstate.addStatic(n)
+		case nodes := <-srv.dnsNodes:
+			// This channel is used by dnsDiscoveryLoop to hand over
+			// the nodes of the DNS node lists.
+			srv.logger.Debug("Adding DNS discovered nodes", "count", len(nodes))
+			dialstate.addDNSNodes(nodes)
 		case n := <-srv.removestatic:
 			// This channel is used by RemovePeer to send a
 			// disconnect request to a peer and begin the
@@ -740,6 +783,7 @@ running:
 					if srv.EnableMsgEvents {
 						p.events = &srv.peerFeed
 					}
+					p.reputation = srv.reputation
 					name := truncateName(c.name)
 					srv.logger.Debug("Adding p2p peer", "name", name, "addr", c.fd.RemoteAddr(), "peers", len(peers)+1)
 					go srv.runPeer(p)
@@ -920,6 +964,7 @@ type BaseServer struct {
 	running bool
 
 	ntab         discover.Discovery
+	reputation   *reputation
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
@@ -937,6 +982,7 @@ type BaseServer struct {
 	addpeer       chan *conn
 	delpeer       chan peerDrop
 	discpeer      chan discover.NodeID
+	dnsNodes      chan []*discover.Node
 	loopWG        sync.WaitGroup // loop, listenLoop
 	peerFeed      event.Feed
 	logger        log.Logger
@@ -1230,6 +1276,7 @@ func (srv *BaseServer) Start() (err error) {
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 	srv.discpeer = make(chan discover.NodeID)
+	srv.dnsNodes = make(chan []*discover.Node)
 
 	var (
 		conn      *net.UDPConn
@@ -1286,7 +1333,9 @@ func (srv *BaseServer) Start() (err error) {
 		srv.ntab = ntab
 	}
 
+	srv.reputation = srv.newReputation()
 	dialer := newDialState(srv.StaticNodes, srv.BootstrapNodes, srv.ntab, srv.maxDialedConns(), srv.NetRestrict, srv.PrivateKey, srv.getTypeStatics())
+	dialer.isBanned = srv.reputation.isBanned
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name(), ID: discover.PubkeyID(&srv.PrivateKey.PublicKey), Multichannel: false}
@@ -1309,6 +1358,10 @@ func (srv *BaseServer) Start() (err error) {
 
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
+	if len(srv.DNSDiscovery) > 0 && !srv.NoDial {
+		srv.loopWG.Add(1)
+		go srv.dnsDiscoveryLoop()
+	}
 	srv.running = true
 	srv.logger.Info("Started P2P server", "id", discover.PubkeyID(&srv.PrivateKey.PublicKey), "multichannel", false)
 	return nil
@@ -1341,6 +1394,7 @@ type dialer interface {
 	taskDone(task, time.Time)
 	addStatic(*discover.Node)
 	removeStatic(*discover.Node)
+	addDNSNodes([]*discover.Node)
 }
 
 func (srv *BaseServer) run(dialstate dialer) {
@@ -1404,6 +1458,11 @@ running:
 			// it will keep the node connected.
 			srv.logger.Debug("Adding static node", "node", n)
 			dialstate.addStatic(n)
+		case nodes := <-srv.dnsNodes:
+			// This channel is used by dnsDiscoveryLoop to hand over
+			// the nodes of the DNS node lists.
+			srv.logger.Debug("Adding DNS discovered nodes", "count", len(nodes))
+			dialstate.addDNSNodes(nodes)
 		case n := <-srv.removestatic:
 			// This channel is used by RemovePeer to send a
 			// disconnect request to a peer and begin the
@@ -1453,6 +1512,7 @@ running:
 					if srv.EnableMsgEvents {
 						p.events = &srv.peerFeed
 					}
+					p.reputation = srv.reputation
 					name := truncateName(c.name)
 					srv.logger.Debug("Adding p2p peer", "name", name, "addr", c.fd.RemoteAddr(), "peers", len(peers)+1)
 					go srv.runPeer(p)
@@ -1530,6 +1590,8 @@ func (srv *BaseServer) protoHandshakeChecks(peers map[discover.NodeID]*Peer, inb
 
 func (srv *BaseServer) encHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCount int, c *conn) error {
 	switch {
+	case srv.reputation != nil && srv.reputation.isBanned(c.id):
+		return DiscUselessPeer
 	case !c.is(trustedConn|staticDialedConn) && len(peers) >= srv.Config.MaxPhysicalConnections:
 		return DiscTooManyPeers
 	case !c.is(trustedConn) && c.is(inboundConn) && inboundCount >= srv.maxInboundConns():
@@ -1554,7 +1616,8 @@ func (srv *BaseServer) maxDialedConns() int {
 	case common.PROXYNODE:
 		return 0
 	case common.ENDPOINTNODE:
-		if srv.NoDiscovery || srv.NoDial {
+		// Dynamic dials are fed by the discovery table or the DNS node lists.
+		if (srv.NoDiscovery && len(srv.DNSDiscovery) == 0) || srv.NoDial {
 			return 0
 		}
 		r := srv.DialRatio
@@ -1592,6 +1655,48 @@ func (srv *BaseServer) getTypeStatics() map[dialType]typedStatic {
 	}
 }
 
+// dnsDiscoveryLoop periodically syncs the DNS node lists and hands the nodes
+// over to the dialer.
+func (srv *BaseServer) dnsDiscoveryLoop() {
+	defer srv.loopWG.Done()
+
+	client := dnsdisc.NewClient(dnsdisc.Config{Resolver: srv.DNSResolver})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-srv.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-srv.quit:
+			return
+		case <-timer.C:
+		}
+
+		nodes, err := client.Nodes(ctx, srv.DNSDiscovery)
+		if err != nil {
+			srv.logger.Warn("Failed to sync DNS node lists", "err", err)
+		}
+		if len(nodes) > 0 {
+			rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+			srv.logger.Info("Synced DNS node lists", "nodes", len(nodes))
+			select {
+			case srv.dnsNodes <- nodes:
+			case <-srv.quit:
+				return
+			}
+		}
+		timer.Reset(dnsRecheckInterval)
+	}
+}
+
 type tempError interface {
 	Temporary() bool
 }
@@ -1857,6 +1962,36 @@ func (srv *BaseServer) Disconnect(destID discover.NodeID) {
 	srv.discpeer <- destID
 }
 
+// newReputation creates the peer reputation, restoring the bans from the node database
+// if discovery is enabled.
+func (srv *BaseServer) newReputation() *reputation {
+	var store banStore
+	if srv.ntab != nil {
+		store = srv.ntab
+	}
+	return newReputation(srv.PeerBanThreshold, srv.PeerBanDuration, store)
+}
+
+// BanPeer bans the peer for the given duration and disconnects it.
+// Zero duration means the configured ban duration.
+func (srv *BaseServer) BanPeer(id discover.NodeID, duration time.Duration) {
+	srv.reputation.ban(id, duration)
+	select {
+	case srv.discpeer <- id:
+	case <-srv.quit:
+	}
+}
+
+// UnbanPeer lifts the ban of the peer. It returns false if the peer is not banned.
+func (srv *BaseServer) UnbanPeer(id discover.NodeID) bool {
+	return srv.reputation.unban(id)
+}
+
+// BannedPeers returns the banned peers.
+func (srv *BaseServer) BannedPeers() []*BannedPeerInfo {
+	return srv.reputation.banned()
+}
+
 // CheckNilNetworkTable returns whether network table is nil.
 func (srv *BaseServer) CheckNilNetworkTable() bool {
 	return srv.ntab == nil
@@ -451,6 +451,9 @@ func (tg taskgen) addStatic(*discover.Node) {
 func (tg taskgen) removeStatic(*discover.Node) {
 }
 
+func (tg taskgen) addDNSNodes([]*discover.Node) {
+}
+
 type testTask struct {
 	index  int
 	called bool
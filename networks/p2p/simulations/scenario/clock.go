@@ -0,0 +1,114 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package scenario
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// genesisTime is the fixed timestamp of the genesis block, so that a scenario
+// builds the same genesis block every time.
+var genesisTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock is the time source of the runner. The events are applied, the latencies
+// are injected and the blocks and the transactions are timed by it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// systemClock is the wall clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+// SimClock is a clock which only advances when Run is called. It starts at the
+// genesis time. The waits on the clock are released in order of their deadline.
+type SimClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*simWaiter
+}
+
+type simWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func NewSimClock() *SimClock {
+	c := &SimClock{now: genesisTime}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current simulated time.
+func (c *SimClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the simulated time once the clock is advanced by d.
+func (c *SimClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &simWaiter{at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- c.now
+		return w.ch
+	}
+	c.waiters = append(c.waiters, w)
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+	c.cond.Broadcast()
+	return w.ch
+}
+
+// Sleep blocks until the clock is advanced by d.
+func (c *SimClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// Run advances the clock by d, releasing the waits whose deadline has passed.
+func (c *SimClock) Run(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	for len(c.waiters) > 0 && !c.waiters[0].at.After(end) {
+		w := c.waiters[0]
+		c.waiters = c.waiters[1:]
+		c.now = w.at
+		w.ch <- w.at
+	}
+	c.now = end
+}
+
+// WaitForWaiters blocks until at least n waits are pending on the clock.
+func (c *SimClock) WaitForWaiters(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package scenario runs declarative fault injection scenarios against in-process
+Kaia networks.
+
+A scenario, written in YAML or JSON, describes the numbers of CNs, PNs and ENs,
+the events applied at given times of the run and the assertions checked at the
+end of it:
+
+	name: en-partition
+	cn: 4
+	pn: 1
+	en: 2
+	duration: 45s
+	events:
+	  - at: 5s
+	    action: partition
+	    groups:
+	      - [en1]
+	  - at: 12s
+	    action: heal
+	assert:
+	  minHeight: 35
+	  finality: true
+
+The nodes are connected by in-memory pipes, through which the partitions and
+the latencies are applied. The Runner starts the network, applies the events
+and returns a Report with the head blocks of the nodes, the block intervals,
+the forks and the latencies of the sent transactions.
+*/
+package scenario
@@ -0,0 +1,174 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package scenario
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+var errPartitioned = errors.New("nodes are partitioned")
+
+// dialTimeout is how long a dial across the partition waits for the partition
+// to be healed, as a TCP dial would do until its timeout.
+const dialTimeout = 15 * time.Second
+
+// faults keeps the partition and the latencies of the simulated links, and
+// applies them to the connections between the nodes.
+type faults struct {
+	clock     Clock
+	mu        sync.Mutex
+	groups    map[string]int // node name -> partition group, nil if not partitioned
+	latencies map[string]time.Duration
+	conns     map[*faultConn]struct{}
+	healed    chan struct{} // closed when the partition is healed
+}
+
+func newFaults(clock Clock) *faults {
+	return &faults{
+		clock:     clock,
+		latencies: make(map[string]time.Duration),
+		conns:     make(map[*faultConn]struct{}),
+		healed:    make(chan struct{}),
+	}
+}
+
+// partition splits the nodes into the groups and closes the connections across them.
+// The nodes not listed in any group are put in another group.
+func (f *faults) partition(groups [][]string) {
+	f.mu.Lock()
+	f.groups = make(map[string]int)
+	for i, group := range groups {
+		for _, name := range group {
+			f.groups[name] = i + 1
+		}
+	}
+	var cut []*faultConn
+	for c := range f.conns {
+		if f.blocked(c.local, c.remote) {
+			cut = append(cut, c)
+		}
+	}
+	f.mu.Unlock()
+
+	for _, c := range cut {
+		c.Close()
+	}
+}
+
+// heal removes the partition.
+func (f *faults) heal() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.groups != nil {
+		f.groups = nil
+		close(f.healed)
+		f.healed = make(chan struct{})
+	}
+}
+
+// setLatency sets the delay of the messages sent by the node.
+func (f *faults) setLatency(name string, latency time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if latency > 0 {
+		f.latencies[name] = latency
+	} else {
+		delete(f.latencies, name)
+	}
+}
+
+// reachable returns true if the nodes are in the same partition group.
+func (f *faults) reachable(a, b string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.blocked(a, b)
+}
+
+// waitReachable waits until the nodes are in the same partition group or the timeout expires.
+func (f *faults) waitReachable(a, b string, timeout time.Duration) bool {
+	expired := f.clock.After(timeout)
+	for {
+		f.mu.Lock()
+		blocked, healed := f.blocked(a, b), f.healed
+		f.mu.Unlock()
+		if !blocked {
+			return true
+		}
+		select {
+		case <-healed:
+		case <-expired:
+			return false
+		}
+	}
+}
+
+func (f *faults) blocked(a, b string) bool {
+	return f.groups != nil && f.groups[a] != f.groups[b]
+}
+
+func (f *faults) latency(name string) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.latencies[name]
+}
+
+// wrap returns the connection from the local node to the remote node, subject to the faults.
+func (f *faults) wrap(conn net.Conn, local, remote string) *faultConn {
+	c := &faultConn{Conn: conn, faults: f, local: local, remote: remote}
+	f.mu.Lock()
+	f.conns[c] = struct{}{}
+	f.mu.Unlock()
+	return c
+}
+
+func (f *faults) remove(c *faultConn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.conns, c)
+}
+
+// faultConn is a connection which delays the writes by the latency of the local
+// node, and fails once the nodes are partitioned.
+type faultConn struct {
+	net.Conn
+	faults        *faults
+	local, remote string
+	closeOnce     sync.Once
+}
+
+func (c *faultConn) Write(b []byte) (int, error) {
+	if !c.faults.reachable(c.local, c.remote) {
+		c.Close()
+		return 0, errPartitioned
+	}
+	if d := c.faults.latency(c.local); d > 0 {
+		c.faults.clock.Sleep(d)
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *faultConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.faults.remove(c)
+		err = c.Conn.Close()
+	})
+	return err
+}
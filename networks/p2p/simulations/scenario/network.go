@@ -0,0 +1,369 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package scenario
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"path/filepath"
+	"sync"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus/istanbul"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/crypto/bls"
+	"github.com/kaiachain/kaia/event"
+	"github.com/kaiachain/kaia/networks/p2p"
+	"github.com/kaiachain/kaia/networks/p2p/discover"
+	"github.com/kaiachain/kaia/networks/p2p/simulations/pipes"
+	"github.com/kaiachain/kaia/node"
+	"github.com/kaiachain/kaia/node/cn"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/kaiachain/kaia/work"
+)
+
+var (
+	errNotRunning     = errors.New("node not running")
+	errNoMultiChannel = errors.New("multichannel is not supported by the simulation")
+)
+
+// chainConfig is the chain config of the simulated networks. SubGroupSize is
+// set to the number of CNs.
+var chainConfig = &params.ChainConfig{
+	ChainID:       big.NewInt(31337),
+	DeriveShaImpl: 2,
+	UnitPrice:     25 * params.Gkei,
+	Governance: &params.GovernanceConfig{
+		GovernanceMode: "none",
+		Reward: &params.RewardConfig{
+			MintingAmount:          big.NewInt(params.KAIA * 6.4),
+			Ratio:                  "100/0/0",
+			Kip82Ratio:             "20/80",
+			UseGiniCoeff:           false,
+			DeferredTxFee:          true,
+			StakingUpdateInterval:  60,
+			ProposerUpdateInterval: 30,
+			MinimumStake:           big.NewInt(5_000_000),
+		},
+	},
+	Istanbul: &params.IstanbulConfig{
+		Epoch:          120,
+		ProposerPolicy: uint64(istanbul.RoundRobin),
+	},
+}
+
+// simNode is an in-process Kaia node of the simulated network.
+type simNode struct {
+	name     string
+	connType common.ConnType
+	key      *ecdsa.PrivateKey
+	statics  []*discover.Node
+
+	mu   sync.Mutex
+	node *node.Node
+	cn   *cn.CN
+	sub  event.Subscription
+}
+
+// network is a simulated network of Kaia nodes connected by in-memory pipes.
+type network struct {
+	name    string
+	genesis *blockchain.Genesis
+	faults  *faults
+	tracker *tracker
+
+	nodes  []*simNode
+	byName map[string]*simNode
+	byID   map[discover.NodeID]*simNode
+}
+
+// deriveKey derives a deterministic key from the scenario name and the label,
+// so that the scenario runs with the same node IDs and validators every time.
+func deriveKey(scenario, label string) *ecdsa.PrivateKey {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte(scenario + "/" + label)))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// newNetwork creates the nodes of the scenario with their data in the workspace.
+// The nodes are started by start.
+func newNetwork(s *Scenario, workspace string, alloc blockchain.GenesisAlloc, clock Clock) (*network, error) {
+	nw := &network{
+		name:    s.Name,
+		faults:  newFaults(clock),
+		tracker: newTracker(clock),
+		byName:  make(map[string]*simNode),
+		byID:    make(map[discover.NodeID]*simNode),
+	}
+	var cns, pns []*discover.Node
+	for _, name := range s.NodeNames() {
+		n := &simNode{
+			name:     name,
+			connType: p2p.ConvertStringToConnType(name[:2]),
+			key:      deriveKey(s.Name, name),
+		}
+		id := discover.PubkeyID(&n.key.PublicKey)
+		kni := discover.NewNode(id, net.IP{127, 0, 0, 1}, 32323, 0, nil, p2p.ConvertNodeType(n.connType))
+		switch n.connType {
+		case common.CONSENSUSNODE:
+			cns = append(cns, kni)
+		case common.PROXYNODE:
+			pns = append(pns, kni)
+		}
+		nw.nodes = append(nw.nodes, n)
+		nw.byName[name] = n
+		nw.byID[id] = n
+	}
+
+	var validators []common.Address
+	for _, n := range nw.nodes {
+		switch n.connType {
+		case common.CONSENSUSNODE:
+			validators = append(validators, crypto.PubkeyToAddress(n.key.PublicKey))
+			n.statics = excludeNode(cns, discover.PubkeyID(&n.key.PublicKey))
+		case common.PROXYNODE:
+			n.statics = cns
+		case common.ENDPOINTNODE:
+			n.statics = pns
+		}
+	}
+	genesis, err := makeGenesis(validators, alloc)
+	if err != nil {
+		return nil, err
+	}
+	nw.genesis = genesis
+
+	for _, n := range nw.nodes {
+		if err := nw.setupNode(n, filepath.Join(workspace, n.name)); err != nil {
+			return nil, fmt.Errorf("failed to set up %s: %v", n.name, err)
+		}
+	}
+	return nw, nil
+}
+
+func excludeNode(nodes []*discover.Node, id discover.NodeID) []*discover.Node {
+	others := make([]*discover.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.ID != id {
+			others = append(others, n)
+		}
+	}
+	return others
+}
+
+// makeGenesis returns the genesis of an Istanbul network with the validators.
+func makeGenesis(validators []common.Address, alloc blockchain.GenesisAlloc) (*blockchain.Genesis, error) {
+	extra, err := rlp.EncodeToBytes(&types.IstanbulExtra{
+		Validators:    validators,
+		Seal:          []byte{},
+		CommittedSeal: [][]byte{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	config := chainConfig.Copy()
+	config.Istanbul.SubGroupSize = uint64(len(validators))
+	return &blockchain.Genesis{
+		Config:     config,
+		Timestamp:  uint64(genesisTime.Unix()),
+		ExtraData:  append(make([]byte, types.IstanbulExtraVanity), extra...),
+		BlockScore: common.Big1,
+		Alloc:      alloc,
+	}, nil
+}
+
+// setupNode creates the node running the CN service. The node dials its
+// static nodes through the simulated links.
+func (nw *network) setupNode(n *simNode, datadir string) error {
+	blsKey, err := bls.DeriveFromECDSA(n.key)
+	if err != nil {
+		return err
+	}
+	stack, err := node.New(&node.Config{
+		Name:              n.name,
+		DataDir:           datadir,
+		UseLightweightKDF: true,
+		P2P: p2p.Config{
+			PrivateKey:             n.key,
+			MaxPhysicalConnections: 100,
+			ConnectionType:         n.connType,
+			NoDiscovery:            true,
+			StaticNodes:            n.statics,
+			Dialer:                 &dialer{nw: nw, local: n.name},
+		},
+		BlsKey:          blsKey,
+		Logger:          logger.NewWith("node", n.name),
+		NtpRemoteServer: "",
+	})
+	if err != nil {
+		return err
+	}
+	cnConf := cn.GetDefaultConfig()
+	cnConf.NetworkId = nw.genesis.Config.ChainID.Uint64()
+	cnConf.Genesis = nw.genesis
+	cnConf.Rewardbase = crypto.PubkeyToAddress(n.key.PublicKey)
+	cnConf.TxResendInterval = cn.DefaultTxResendInterval
+	cnConf.TxResendCount = cn.DefaultMaxResendTxCount
+	err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		return cn.New(ctx, cnConf)
+	})
+	if err != nil {
+		return err
+	}
+	n.node = stack
+	return nil
+}
+
+// start starts all the nodes.
+func (nw *network) start() error {
+	for _, n := range nw.nodes {
+		if err := n.start(nw.tracker); err != nil {
+			return fmt.Errorf("failed to start %s: %v", n.name, err)
+		}
+	}
+	return nil
+}
+
+// stop stops all the running nodes.
+func (nw *network) stop() {
+	for _, n := range nw.nodes {
+		if err := n.stop(); err != nil && err != errNotRunning {
+			logger.Warn("Failed to stop the simulated node", "node", n.name, "err", err)
+		}
+	}
+}
+
+// running returns the running nodes.
+func (nw *network) running() []*simNode {
+	var nodes []*simNode
+	for _, n := range nw.nodes {
+		if n.chain() != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// start starts the node, and the block production if the node is a CN.
+func (n *simNode) start(t *tracker) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err := n.node.Start(); err != nil {
+		return err
+	}
+	var service *cn.CN
+	if err := n.node.Service(&service); err != nil {
+		n.node.Stop()
+		return err
+	}
+	if n.connType == common.CONSENSUSNODE {
+		if err := service.StartMining(false); err != nil {
+			n.node.Stop()
+			return err
+		}
+	}
+	n.cn = service
+	n.sub = t.watch(n.name, service.BlockChain())
+	return nil
+}
+
+// stop stops the node as a crash would do, dropping its connections.
+func (n *simNode) stop() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.cn == nil {
+		return errNotRunning
+	}
+	n.sub.Unsubscribe()
+	n.cn, n.sub = nil, nil
+	return n.node.Stop()
+}
+
+// chain returns the blockchain of the node, or nil if the node is not running.
+func (n *simNode) chain() work.BlockChain {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.cn == nil {
+		return nil
+	}
+	return n.cn.BlockChain()
+}
+
+// txPool returns the tx pool of the node, or nil if the node is not running.
+func (n *simNode) txPool() work.TxPool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.cn == nil {
+		return nil
+	}
+	return n.cn.TxPool()
+}
+
+// server returns the p2p server of the node, or nil if the node is not running.
+func (n *simNode) server() p2p.Server {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.cn == nil {
+		return nil
+	}
+	return n.node.Server()
+}
+
+// dialer implements the p2p.NodeDialer interface by connecting the local node
+// to the simulated nodes with in-memory pipes subject to the faults.
+type dialer struct {
+	nw    *network
+	local string
+}
+
+func (d *dialer) Dial(dest *discover.Node) (net.Conn, error) {
+	remote, ok := d.nw.byID[dest.ID]
+	if !ok {
+		return nil, fmt.Errorf("unknown node: %s", dest.ID)
+	}
+	if !d.nw.faults.waitReachable(d.local, remote.name, dialTimeout) {
+		return nil, errPartitioned
+	}
+	srv := remote.server()
+	if srv == nil {
+		return nil, fmt.Errorf("%s: %w", remote.name, errNotRunning)
+	}
+	local, other, err := pipes.NetPipe()
+	if err != nil {
+		return nil, err
+	}
+	// Simulate the listening side of the remote node.
+	go srv.SetupConn(d.nw.faults.wrap(other, remote.name, d.local), 0, nil)
+	return d.nw.faults.wrap(local, d.local, remote.name), nil
+}
+
+func (d *dialer) DialMulti(dest *discover.Node) ([]net.Conn, error) {
+	return nil, errNoMultiChannel
+}
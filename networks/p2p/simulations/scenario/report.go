@@ -0,0 +1,262 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/event"
+	"github.com/kaiachain/kaia/work"
+)
+
+// tracker records when the blocks and the transactions appear on the nodes.
+type tracker struct {
+	clock      Clock
+	mu         sync.Mutex
+	blockTimes map[uint64]time.Time                 // first appearance of each block number
+	txSent     map[common.Hash]time.Time            // sending time of each transaction
+	txIncluded map[common.Hash]map[string]time.Time // inclusion time of each transaction per node
+	produced   chan struct{}                        // closed when the first block is recorded
+}
+
+func newTracker(clock Clock) *tracker {
+	return &tracker{
+		clock:      clock,
+		blockTimes: make(map[uint64]time.Time),
+		txSent:     make(map[common.Hash]time.Time),
+		txIncluded: make(map[common.Hash]map[string]time.Time),
+		produced:   make(chan struct{}),
+	}
+}
+
+// watch records the blocks inserted in the chain of the node until the
+// returned subscription is unsubscribed.
+func (t *tracker) watch(name string, chain work.BlockChain) event.Subscription {
+	ch := make(chan blockchain.ChainEvent, 64)
+	sub := chain.SubscribeChainEvent(ch)
+	go func() {
+		for {
+			select {
+			case ev := <-ch:
+				t.recordBlock(name, ev.Block, t.clock.Now())
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub
+}
+
+func (t *tracker) recordBlock(name string, block *types.Block, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.blockTimes[block.NumberU64()]; !ok {
+		if len(t.blockTimes) == 0 {
+			close(t.produced)
+		}
+		t.blockTimes[block.NumberU64()] = now
+	}
+	for _, tx := range block.Transactions() {
+		included := t.txIncluded[tx.Hash()]
+		if included == nil {
+			included = make(map[string]time.Time)
+			t.txIncluded[tx.Hash()] = included
+		}
+		if _, ok := included[name]; !ok {
+			included[name] = now
+		}
+	}
+}
+
+func (t *tracker) recordTx(hash common.Hash, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.txSent[hash] = now
+}
+
+// maxBlockInterval returns the longest time without a new block in the network
+// between start and end.
+func (t *tracker) maxBlockInterval(start, end time.Time) time.Duration {
+	t.mu.Lock()
+	times := make([]time.Time, 0, len(t.blockTimes))
+	for _, at := range t.blockTimes {
+		if at.After(start) && at.Before(end) {
+			times = append(times, at)
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	var (
+		longest time.Duration
+		last    = start
+	)
+	for _, at := range append(times, end) {
+		if d := at.Sub(last); d > longest {
+			longest = d
+		}
+		last = at
+	}
+	return longest
+}
+
+// txLatencies returns the time taken by each sent transaction to be included
+// in the chains of all the nodes, and the number of the transactions missing
+// from any of them.
+func (t *tracker) txLatencies(nodes []*simNode) (latencies []time.Duration, missing int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for hash, sent := range t.txSent {
+		var (
+			latency  time.Duration
+			included = t.txIncluded[hash]
+		)
+		for _, n := range nodes {
+			at, ok := included[n.name]
+			if !ok {
+				missing++
+				latency = -1
+				break
+			}
+			if d := at.Sub(sent); d > latency {
+				latency = d
+			}
+		}
+		if latency >= 0 {
+			latencies = append(latencies, latency)
+		}
+	}
+	return latencies, missing
+}
+
+// Report is the result of a scenario run.
+type Report struct {
+	Scenario string `json:"scenario"`
+
+	// Heights are the head block numbers of the nodes running at the end.
+	Heights map[string]uint64 `json:"heights"`
+
+	// MaxBlockInterval is the longest time without a new block in the network.
+	MaxBlockInterval time.Duration `json:"maxBlockInterval"`
+
+	// Forks are the block numbers where the running nodes have different blocks.
+	Forks []uint64 `json:"forks,omitempty"`
+
+	// TxSent is the number of the sent transactions, of which TxMissing are not
+	// included in the chain of every running node.
+	TxSent    int `json:"txSent"`
+	TxMissing int `json:"txMissing"`
+
+	// MaxTxLatency is the longest time taken by a transaction to be included
+	// in the chain of every running node.
+	MaxTxLatency time.Duration `json:"maxTxLatency"`
+
+	// Failures are the assertions not met.
+	Failures []string `json:"failures,omitempty"`
+}
+
+// Err returns an error listing the failed assertions, or nil if all were met.
+func (r *Report) Err() error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(r.Failures, "; "))
+}
+
+// makeReport measures the network from start to end and checks the assertions.
+func (nw *network) makeReport(s *Scenario, start, end time.Time) *Report {
+	var (
+		nodes = nw.running()
+		r     = &Report{
+			Scenario:         s.Name,
+			Heights:          make(map[string]uint64),
+			MaxBlockInterval: nw.tracker.maxBlockInterval(start, end),
+		}
+	)
+	var maxHeight uint64
+	for _, n := range nodes {
+		height := n.chain().CurrentHeader().Number.Uint64()
+		r.Heights[n.name] = height
+		if height > maxHeight {
+			maxHeight = height
+		}
+	}
+	r.Forks = findForks(nodes, maxHeight)
+
+	latencies, missing := nw.tracker.txLatencies(nodes)
+	r.TxSent, r.TxMissing = len(latencies)+missing, missing
+	for _, latency := range latencies {
+		if latency > r.MaxTxLatency {
+			r.MaxTxLatency = latency
+		}
+	}
+
+	a := s.Assert
+	if a.MinHeight > 0 {
+		for _, n := range nodes {
+			if r.Heights[n.name] < a.MinHeight {
+				r.Failures = append(r.Failures, fmt.Sprintf("%s is at block %d, below %d", n.name, r.Heights[n.name], a.MinHeight))
+			}
+		}
+	}
+	if a.MaxBlockInterval > 0 && r.MaxBlockInterval > time.Duration(a.MaxBlockInterval) {
+		r.Failures = append(r.Failures, fmt.Sprintf("no block for %v, above %v", r.MaxBlockInterval, a.MaxBlockInterval))
+	}
+	if a.Finality && len(r.Forks) > 0 {
+		r.Failures = append(r.Failures, fmt.Sprintf("conflicting blocks at %v", r.Forks))
+	}
+	if a.MaxTxLatency > 0 {
+		if r.TxMissing > 0 {
+			r.Failures = append(r.Failures, fmt.Sprintf("%d of %d txs not included in all nodes", r.TxMissing, r.TxSent))
+		}
+		if r.MaxTxLatency > time.Duration(a.MaxTxLatency) {
+			r.Failures = append(r.Failures, fmt.Sprintf("tx included in %v, above %v", r.MaxTxLatency, a.MaxTxLatency))
+		}
+	}
+	return r
+}
+
+// findForks returns the block numbers up to maxHeight where the nodes have different blocks.
+func findForks(nodes []*simNode, maxHeight uint64) []uint64 {
+	var forks []uint64
+	for num := uint64(1); num <= maxHeight; num++ {
+		var hash common.Hash
+		for _, n := range nodes {
+			header := n.chain().GetHeaderByNumber(num)
+			if header == nil {
+				continue
+			}
+			if hash == (common.Hash{}) {
+				hash = header.Hash()
+			} else if header.Hash() != hash {
+				forks = append(forks, num)
+				break
+			}
+		}
+	}
+	return forks
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package scenario
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/log"
+	"github.com/kaiachain/kaia/params"
+)
+
+var logger = log.NewModuleLogger(log.NetworksP2PSimulations)
+
+var errNoBlock = errors.New("no block produced")
+
+// startTimeout is how long the runner waits for the first block. The genesis block
+// is in the past, so the first block is proposed as soon as the CNs start and may
+// take a round change if the CNs are not connected to each other yet.
+const startTimeout = time.Minute
+
+// txSender is the account sending the transactions to a node.
+type txSender struct {
+	key   *ecdsa.PrivateKey
+	nonce uint64
+}
+
+// Runner runs a scenario in a simulated network.
+type Runner struct {
+	scenario *Scenario
+	clock    Clock
+	nw       *network
+	senders  map[string]*txSender // node name -> account sending txs to the node
+	signer   types.Signer
+}
+
+// NewRunner creates the nodes of the scenario, keeping their data in the workspace directory.
+// The scenario is timed by the clock, or by the wall clock if it is nil. The nodes run in
+// real time, so a SimClock must be advanced along with them by the caller.
+func NewRunner(s *Scenario, workspace string, clock Clock) (*Runner, error) {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	r := &Runner{
+		scenario: s,
+		clock:    clock,
+		senders:  make(map[string]*txSender),
+	}
+	alloc := make(blockchain.GenesisAlloc)
+	balance := new(big.Int).Mul(big.NewInt(params.KAIA), big.NewInt(10_000_000))
+	for _, name := range s.NodeNames() {
+		key := deriveKey(s.Name, "tx/"+name)
+		r.senders[name] = &txSender{key: key}
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = blockchain.GenesisAccount{Balance: balance}
+	}
+	nw, err := newNetwork(s, workspace, alloc, clock)
+	if err != nil {
+		return nil, err
+	}
+	r.nw = nw
+	r.signer = types.LatestSignerForChainID(nw.genesis.Config.ChainID)
+	return r, nil
+}
+
+// Run starts the network, applies the events on time and checks the
+// assertions at the end of the scenario. The network is stopped on return.
+func (r *Runner) Run(ctx context.Context) (*Report, error) {
+	defer r.nw.stop()
+
+	if err := r.nw.start(); err != nil {
+		return nil, err
+	}
+	// The scenario starts once the network produces blocks.
+	select {
+	case <-r.nw.tracker.produced:
+	case <-r.clock.After(startTimeout):
+		return nil, errNoBlock
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	logger.Info("Started the scenario", "name", r.scenario.Name, "nodes", len(r.nw.nodes), "events", len(r.scenario.Events))
+
+	start, end, err := r.play(ctx, r.apply)
+	if err != nil {
+		return nil, err
+	}
+	report := r.nw.makeReport(r.scenario, start, end)
+	logger.Info("Finished the scenario", "name", r.scenario.Name, "heights", report.Heights, "failures", len(report.Failures))
+	return report, nil
+}
+
+// play applies the events on time by the clock and waits for the end of the scenario.
+// It returns the start and the end time of the scenario.
+func (r *Runner) play(ctx context.Context, apply func(*Event) error) (start, end time.Time, err error) {
+	start = r.clock.Now()
+	for _, e := range r.scenario.Events {
+		if err := r.sleepUntil(ctx, start.Add(time.Duration(e.At))); err != nil {
+			return start, end, err
+		}
+		if err := apply(e); err != nil {
+			return start, end, fmt.Errorf("%s at %v: %v", e.Action, e.At, err)
+		}
+	}
+	end = start.Add(time.Duration(r.scenario.Duration))
+	return start, end, r.sleepUntil(ctx, end)
+}
+
+func (r *Runner) sleepUntil(ctx context.Context, t time.Time) error {
+	select {
+	case <-r.clock.After(t.Sub(r.clock.Now())):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// apply applies the event to the network.
+func (r *Runner) apply(e *Event) error {
+	logger.Info("Applying the scenario event", "at", e.At, "action", e.Action, "nodes", e.Nodes, "groups", e.Groups)
+	switch e.Action {
+	case ActionPartition:
+		r.nw.faults.partition(e.Groups)
+	case ActionHeal:
+		r.nw.faults.heal()
+	case ActionLatency:
+		for _, name := range e.Nodes {
+			r.nw.faults.setLatency(name, time.Duration(e.Latency))
+		}
+	case ActionCrash:
+		for _, name := range e.Nodes {
+			if err := r.nw.byName[name].stop(); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	case ActionRestart:
+		for _, name := range e.Nodes {
+			if err := r.nw.byName[name].start(r.nw.tracker); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	case ActionSendTxs:
+		for _, name := range e.Nodes {
+			if err := r.sendTxs(name, e.Count); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sendTxs submits value transfer transactions to the tx pool of the node.
+func (r *Runner) sendTxs(name string, count int) error {
+	pool := r.nw.byName[name].txPool()
+	if pool == nil {
+		return errNotRunning
+	}
+	var (
+		sender   = r.senders[name]
+		to       = common.BytesToAddress(crypto.Keccak256([]byte(name)))
+		gasPrice = new(big.Int).SetUint64(r.nw.genesis.Config.UnitPrice)
+	)
+	for i := 0; i < count; i++ {
+		tx := types.NewTransaction(sender.nonce, to, common.Big1, params.TxGas, gasPrice, nil)
+		signed, err := types.SignTx(tx, r.signer, sender.key)
+		if err != nil {
+			return err
+		}
+		if err := pool.AddLocal(signed); err != nil {
+			return err
+		}
+		r.nw.tracker.recordTx(signed.Hash(), r.clock.Now())
+		sender.nonce++
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Actions of the scenario events.
+const (
+	ActionPartition = "partition" // Split the nodes into the groups, which can't reach each other
+	ActionHeal      = "heal"      // Remove the partition
+	ActionLatency   = "latency"   // Delay the messages sent by the nodes
+	ActionCrash     = "crash"     // Stop the nodes
+	ActionRestart   = "restart"   // Restart the crashed nodes
+	ActionSendTxs   = "sendTxs"   // Send value transfer transactions to the nodes
+)
+
+var (
+	errNoCN          = errors.New("scenario needs at least one CN")
+	errNoPN          = errors.New("scenario with ENs needs at least one PN")
+	errNoDuration    = errors.New("scenario duration must be positive")
+	errUnknownFormat = errors.New("unknown scenario format")
+)
+
+// Duration is a time.Duration decoded from a string such as "1m30s".
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(input []byte) error {
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+// Scenario describes a simulated network and the faults injected into it.
+type Scenario struct {
+	Name string `json:"name" yaml:"name"`
+
+	// The numbers of the nodes. The nodes are named after their type and index,
+	// e.g. cn0, cn1, pn0 and en0. The CNs are fully connected, every PN connects
+	// to all the CNs and every EN connects to all the PNs.
+	CN int `json:"cn" yaml:"cn"`
+	PN int `json:"pn" yaml:"pn"`
+	EN int `json:"en" yaml:"en"`
+
+	// Duration is how long the scenario runs. The assertions are checked at the end.
+	Duration Duration `json:"duration" yaml:"duration"`
+
+	Events []*Event    `json:"events" yaml:"events"`
+	Assert *Assertions `json:"assert" yaml:"assert"`
+}
+
+// Event is a fault or an action applied to the network at a point of the scenario.
+type Event struct {
+	// At is the time from the start of the scenario.
+	At     Duration `json:"at" yaml:"at"`
+	Action string   `json:"action" yaml:"action"`
+
+	// Nodes are the target nodes of the latency, crash, restart and sendTxs actions.
+	Nodes []string `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+
+	// Groups are the node groups of the partition action. The nodes not
+	// listed in any group form another group.
+	Groups [][]string `json:"groups,omitempty" yaml:"groups,omitempty"`
+
+	// Latency is the delay of every message sent by the nodes. Zero removes the delay.
+	Latency Duration `json:"latency,omitempty" yaml:"latency,omitempty"`
+
+	// Count is the number of transactions sent to each node.
+	Count int `json:"count,omitempty" yaml:"count,omitempty"`
+}
+
+// Assertions are the conditions checked at the end of the scenario.
+// Zero values are not checked.
+type Assertions struct {
+	// MinHeight is the block number every running node must reach (liveness).
+	MinHeight uint64 `json:"minHeight,omitempty" yaml:"minHeight,omitempty"`
+
+	// MaxBlockInterval is the longest time allowed between the first
+	// appearances of two consecutive blocks in the network (liveness).
+	MaxBlockInterval Duration `json:"maxBlockInterval,omitempty" yaml:"maxBlockInterval,omitempty"`
+
+	// Finality requires every node to have the same block at every height.
+	Finality bool `json:"finality,omitempty" yaml:"finality,omitempty"`
+
+	// MaxTxLatency is the longest time allowed for a sent transaction to be
+	// included in the chain of every running node (tx propagation).
+	MaxTxLatency Duration `json:"maxTxLatency,omitempty" yaml:"maxTxLatency,omitempty"`
+}
+
+// Load reads the scenario file. The format is chosen by the file extension,
+// .yaml and .yml for YAML and .json for JSON.
+func Load(file string) (*Scenario, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return Parse(data, "yaml")
+	case ".json":
+		return Parse(data, "json")
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownFormat, file)
+	}
+}
+
+// Parse decodes and validates the scenario in the format, "yaml" or "json".
+func Parse(data []byte, format string) (*Scenario, error) {
+	s := new(Scenario)
+	switch format {
+	case "yaml":
+		if err := yaml.Unmarshal(data, s); err != nil {
+			return nil, err
+		}
+	case "json":
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownFormat, format)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NodeNames returns the names of the nodes in the order of CNs, PNs and ENs.
+func (s *Scenario) NodeNames() []string {
+	names := make([]string, 0, s.CN+s.PN+s.EN)
+	for _, t := range []struct {
+		prefix string
+		count  int
+	}{{"cn", s.CN}, {"pn", s.PN}, {"en", s.EN}} {
+		for i := 0; i < t.count; i++ {
+			names = append(names, fmt.Sprintf("%s%d", t.prefix, i))
+		}
+	}
+	return names
+}
+
+// Validate checks the topology and the events, and sorts the events by time.
+func (s *Scenario) Validate() error {
+	if s.CN < 1 {
+		return errNoCN
+	}
+	if s.EN > 0 && s.PN < 1 {
+		return errNoPN
+	}
+	if s.Duration <= 0 {
+		return errNoDuration
+	}
+	if s.Assert == nil {
+		s.Assert = new(Assertions)
+	}
+	known := make(map[string]bool)
+	for _, name := range s.NodeNames() {
+		known[name] = true
+	}
+	checkNodes := func(i int, nodes []string) error {
+		for _, name := range nodes {
+			if !known[name] {
+				return fmt.Errorf("event %d: unknown node %q", i, name)
+			}
+		}
+		return nil
+	}
+	for i, e := range s.Events {
+		if e.At < 0 || e.At > s.Duration {
+			return fmt.Errorf("event %d: time %v out of the scenario duration", i, e.At)
+		}
+		switch e.Action {
+		case ActionPartition:
+			if len(e.Groups) == 0 {
+				return fmt.Errorf("event %d: partition without groups", i)
+			}
+			seen := make(map[string]bool)
+			for _, group := range e.Groups {
+				if err := checkNodes(i, group); err != nil {
+					return err
+				}
+				for _, name := range group {
+					if seen[name] {
+						return fmt.Errorf("event %d: node %q in multiple groups", i, name)
+					}
+					seen[name] = true
+				}
+			}
+		case ActionHeal:
+		case ActionLatency:
+			if e.Latency < 0 {
+				return fmt.Errorf("event %d: negative latency", i)
+			}
+			fallthrough
+		case ActionCrash, ActionRestart:
+			if len(e.Nodes) == 0 {
+				return fmt.Errorf("event %d: %s without nodes", i, e.Action)
+			}
+			if err := checkNodes(i, e.Nodes); err != nil {
+				return err
+			}
+		case ActionSendTxs:
+			if len(e.Nodes) == 0 || e.Count <= 0 {
+				return fmt.Errorf("event %d: sendTxs needs nodes and a positive count", i)
+			}
+			if err := checkNodes(i, e.Nodes); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("event %d: unknown action %q", i, e.Action)
+		}
+	}
+	sort.SliceStable(s.Events, func(i, j int) bool { return s.Events[i].At < s.Events[j].At })
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package scenario
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load("testdata/en_partition.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "en-partition", s.Name)
+	assert.Equal(t, []string{"cn0", "cn1", "cn2", "cn3", "pn0", "en0", "en1"}, s.NodeNames())
+	assert.Equal(t, Duration(45*time.Second), s.Duration)
+	assert.Equal(t, [][]string{{"en1"}}, s.Events[1].Groups)
+	assert.Equal(t, Duration(100*time.Millisecond), s.Events[3].Latency)
+	assert.Equal(t, &Assertions{
+		MinHeight:        35,
+		MaxBlockInterval: Duration(5 * time.Second),
+		Finality:         true,
+		MaxTxLatency:     Duration(40 * time.Second),
+	}, s.Assert)
+
+	s, err = Load("testdata/cn_crash.json")
+	require.NoError(t, err)
+	assert.Equal(t, Duration(2*time.Minute), s.Duration)
+	assert.Equal(t, ActionCrash, s.Events[1].Action)
+	assert.Equal(t, []string{"cn3"}, s.Events[1].Nodes)
+
+	_, err = Parse([]byte(`name = "x"`), "toml")
+	assert.ErrorIs(t, err, errUnknownFormat)
+}
+
+func TestParseSortsEvents(t *testing.T) {
+	s, err := Parse([]byte(`{"name":"x","cn":1,"duration":"10s","events":[
+		{"at":"5s","action":"heal"},
+		{"at":"1s","action":"crash","nodes":["cn0"]}]}`), "json")
+	require.NoError(t, err)
+	assert.Equal(t, ActionCrash, s.Events[0].Action)
+	assert.Equal(t, ActionHeal, s.Events[1].Action)
+	assert.NotNil(t, s.Assert)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"no CN", "cn: 0\nduration: 1s", errNoCN.Error()},
+		{"EN without PN", "cn: 1\nen: 1\nduration: 1s", errNoPN.Error()},
+		{"no duration", "cn: 1", errNoDuration.Error()},
+		{"bad duration", "cn: 1\nduration: soon", "invalid duration"},
+		{"event after the end", "cn: 1\nduration: 1s\nevents: [{at: 2s, action: heal}]", "out of the scenario duration"},
+		{"unknown node", "cn: 1\nduration: 1s\nevents: [{at: 0s, action: crash, nodes: [cn1]}]", `unknown node "cn1"`},
+		{"partition without groups", "cn: 2\nduration: 1s\nevents: [{at: 0s, action: partition}]", "partition without groups"},
+		{"node in two groups", "cn: 2\nduration: 1s\nevents: [{at: 0s, action: partition, groups: [[cn0], [cn0, cn1]]}]", "multiple groups"},
+		{"negative latency", "cn: 1\nduration: 1s\nevents: [{at: 0s, action: latency, nodes: [cn0], latency: -1s}]", "negative latency"},
+		{"no tx count", "cn: 1\nduration: 1s\nevents: [{at: 0s, action: sendTxs, nodes: [cn0]}]", "positive count"},
+		{"unknown action", "cn: 1\nduration: 1s\nevents: [{at: 0s, action: explode}]", "unknown action"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.input), "yaml")
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestFaultsPartition(t *testing.T) {
+	clock := NewSimClock()
+	f := newFaults(clock)
+	a, b := net.Pipe()
+	ca, cb := f.wrap(a, "en0", "pn0"), f.wrap(b, "pn0", "en0")
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := cb.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+	_, err := ca.Write([]byte{1})
+	require.NoError(t, err)
+
+	// The partition closes the connections across the groups.
+	f.partition([][]string{{"en0"}})
+	assert.False(t, f.reachable("en0", "pn0"))
+	assert.True(t, f.reachable("pn0", "cn0"))
+	_, err = ca.Write([]byte{1})
+	assert.Error(t, err)
+	assert.Empty(t, f.conns)
+
+	done := make(chan bool)
+	go func() { done <- f.waitReachable("en0", "pn0", dialTimeout) }()
+	clock.WaitForWaiters(1)
+	clock.Run(dialTimeout)
+	assert.False(t, <-done)
+
+	go func() { done <- f.waitReachable("en0", "pn0", dialTimeout) }()
+	clock.WaitForWaiters(1)
+	f.heal()
+	assert.True(t, <-done)
+}
+
+func TestFaultsLatency(t *testing.T) {
+	clock := NewSimClock()
+	f := newFaults(clock)
+	a, b := net.Pipe()
+	ca := f.wrap(a, "cn0", "cn1")
+	defer ca.Close()
+	defer b.Close()
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := b.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	f.setLatency("cn0", 50*time.Millisecond)
+	written := make(chan error)
+	go func() {
+		_, err := ca.Write([]byte{1})
+		written <- err
+	}()
+	// The write is held until the latency passes.
+	clock.WaitForWaiters(1)
+	clock.Run(49 * time.Millisecond)
+	select {
+	case <-written:
+		t.Fatal("written before the latency")
+	default:
+	}
+	clock.Run(time.Millisecond)
+	require.NoError(t, <-written)
+
+	f.setLatency("cn0", 0)
+	assert.Zero(t, f.latency("cn0"))
+}
+
+func TestRunnerPlay(t *testing.T) {
+	s, err := Load("testdata/en_partition.yaml")
+	require.NoError(t, err)
+	clock := NewSimClock()
+	r := &Runner{scenario: s, clock: clock}
+
+	// Advance the clock a second at a time as long as the runner waits on it.
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		clock.After(time.Second) // wakes up the loop to return
+	}()
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			clock.WaitForWaiters(1)
+			clock.Run(time.Second)
+		}
+	}()
+
+	var applied []time.Duration
+	start, end, err := r.play(context.Background(), func(e *Event) error {
+		assert.Equal(t, genesisTime.Add(time.Duration(e.At)), clock.Now())
+		applied = append(applied, clock.Now().Sub(genesisTime))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, genesisTime, start)
+	assert.Equal(t, genesisTime.Add(45*time.Second), end)
+	assert.Equal(t, end, clock.Now())
+	assert.Equal(t, []time.Duration{2 * time.Second, 5 * time.Second, 6 * time.Second, 8 * time.Second, 12 * time.Second, 12 * time.Second}, applied)
+}
+
+func TestRunENPartition(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the network simulation in short mode")
+	}
+	s, err := Load("testdata/en_partition.yaml")
+	require.NoError(t, err)
+	r, err := NewRunner(s, t.TempDir(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(genesisTime.Unix()), r.nw.genesis.Timestamp)
+
+	report, err := r.Run(context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, report.Err())
+	assert.Equal(t, 40, report.TxSent)
+	assert.Zero(t, report.TxMissing)
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	metricutils "github.com/kaiachain/kaia/metrics/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The message counters exported to Prometheus, labeled by the protocol, the
+// message code, the direction ("in" or "out") and the type of the peer. The
+// peers are not labeled individually to keep the number of the series bounded;
+// the per-peer numbers are available through admin_peers.
+var (
+	msgTrafficLabels = []string{"protocol", "code", "direction", "peer"}
+
+	msgCountVec = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricutils.MetricNamespace,
+		Subsystem: "p2p",
+		Name:      "messages_total",
+		Help:      "The number of the messages exchanged with the peers",
+	}, msgTrafficLabels)
+	msgBytesVec = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricutils.MetricNamespace,
+		Subsystem: "p2p",
+		Name:      "message_bytes_total",
+		Help:      "The bytes of the messages exchanged with the peers",
+	}, msgTrafficLabels)
+
+	registerTrafficOnce sync.Once
+)
+
+func registerTrafficMetrics() {
+	registerTrafficOnce.Do(func() {
+		prometheus.DefaultRegisterer.MustRegister(msgCountVec, msgBytesVec)
+	})
+}
+
+// MsgTraffic is the number of the messages exchanged with a peer and their bytes.
+type MsgTraffic struct {
+	InMsgs   uint64 `json:"inMsgs"`
+	InBytes  uint64 `json:"inBytes"`
+	OutMsgs  uint64 `json:"outMsgs"`
+	OutBytes uint64 `json:"outBytes"`
+}
+
+func (t *MsgTraffic) add(o MsgTraffic) {
+	t.InMsgs += o.InMsgs
+	t.InBytes += o.InBytes
+	t.OutMsgs += o.OutMsgs
+	t.OutBytes += o.OutBytes
+}
+
+// ProtocolTraffic is the traffic of a protocol with a peer, in total and by message code.
+type ProtocolTraffic struct {
+	MsgTraffic
+	Msgs map[uint64]*MsgTraffic `json:"msgs"`
+}
+
+// msgCounters counts the messages of a code in both directions.
+type msgCounters struct {
+	inMsgs, inBytes, outMsgs, outBytes uint64 // accessed atomically
+
+	// Prometheus counters shared by the peers of the same type, nil if the metrics are disabled.
+	promInMsgs, promInBytes, promOutMsgs, promOutBytes prometheus.Counter
+}
+
+// protoTraffic counts the messages of a protocol running on a connection, indexed by the message code.
+type protoTraffic []msgCounters
+
+func newProtoTraffic(proto Protocol, peerType string) protoTraffic {
+	t := make(protoTraffic, proto.Length)
+	if metricutils.Enabled {
+		registerTrafficMetrics()
+		for code := range t {
+			c := &t[code]
+			label := strconv.Itoa(code)
+			c.promInMsgs = msgCountVec.WithLabelValues(proto.Name, label, "in", peerType)
+			c.promInBytes = msgBytesVec.WithLabelValues(proto.Name, label, "in", peerType)
+			c.promOutMsgs = msgCountVec.WithLabelValues(proto.Name, label, "out", peerType)
+			c.promOutBytes = msgBytesVec.WithLabelValues(proto.Name, label, "out", peerType)
+		}
+	}
+	return t
+}
+
+// markIn counts a received message. The code is relative to the protocol offset.
+func (t protoTraffic) markIn(code uint64, size uint32) {
+	if code >= uint64(len(t)) {
+		return
+	}
+	c := &t[code]
+	atomic.AddUint64(&c.inMsgs, 1)
+	atomic.AddUint64(&c.inBytes, uint64(size))
+	if c.promInMsgs != nil {
+		c.promInMsgs.Inc()
+		c.promInBytes.Add(float64(size))
+	}
+}
+
+// markOut counts a sent message. The code is relative to the protocol offset.
+func (t protoTraffic) markOut(code uint64, size uint32) {
+	if code >= uint64(len(t)) {
+		return
+	}
+	c := &t[code]
+	atomic.AddUint64(&c.outMsgs, 1)
+	atomic.AddUint64(&c.outBytes, uint64(size))
+	if c.promOutMsgs != nil {
+		c.promOutMsgs.Inc()
+		c.promOutBytes.Add(float64(size))
+	}
+}
+
+// collect adds the counted messages to the protocol traffic, skipping the codes without any message.
+func (t protoTraffic) collect(info *ProtocolTraffic) {
+	for code := range t {
+		c := &t[code]
+		msg := MsgTraffic{
+			InMsgs:   atomic.LoadUint64(&c.inMsgs),
+			InBytes:  atomic.LoadUint64(&c.inBytes),
+			OutMsgs:  atomic.LoadUint64(&c.outMsgs),
+			OutBytes: atomic.LoadUint64(&c.outBytes),
+		}
+		if msg.InMsgs == 0 && msg.OutMsgs == 0 {
+			continue
+		}
+		if info.Msgs[uint64(code)] == nil {
+			info.Msgs[uint64(code)] = new(MsgTraffic)
+		}
+		info.Msgs[uint64(code)].add(msg)
+		info.add(msg)
+	}
+}
+
+// Traffic returns the messages exchanged with the peer over all its connections,
+// by protocol name and message code.
+func (p *Peer) Traffic() map[string]*ProtocolTraffic {
+	traffic := make(map[string]*ProtocolTraffic, len(p.running))
+	for name, rws := range p.running {
+		info := &ProtocolTraffic{Msgs: make(map[uint64]*MsgTraffic)}
+		for _, rw := range rws {
+			rw.traffic.collect(info)
+		}
+		traffic[name] = info
+	}
+	return traffic
+}
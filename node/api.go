@@ -93,6 +93,50 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
+// BanPeer bans a remote node for the given seconds and disconnects it.
+// The default ban duration of the node is used if the seconds are not given.
+func (api *PrivateAdminAPI) BanPeer(url string, seconds *uint64) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid kni: %v", err)
+	}
+	var duration time.Duration
+	if seconds != nil {
+		duration = time.Duration(*seconds) * time.Second
+	}
+	server.BanPeer(node.ID, duration)
+	return true, nil
+}
+
+// UnbanPeer lifts the ban of a remote node. It returns false if the node is not banned.
+func (api *PrivateAdminAPI) UnbanPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid kni: %v", err)
+	}
+	return server.UnbanPeer(node.ID), nil
+}
+
+// BannedPeers returns the banned remote nodes with their ban expiry.
+func (api *PrivateAdminAPI) BannedPeers() ([]*p2p.BannedPeerInfo, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.BannedPeers(), nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
@@ -35,6 +35,7 @@ import (
 
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/stateless"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/common/hexutil"
@@ -406,6 +407,21 @@ func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hex
 	return nil, errors.New("unknown preimage")
 }
 
+// ExecutionWitness re-executes the given block and returns the trie nodes, contract codes
+// and headers accessed during the execution, which are enough to execute the block
+// again on top of the parent state root without a database.
+func (api *PrivateDebugAPI) ExecutionWitness(ctx context.Context, number rpc.BlockNumber) (*stateless.Witness, error) {
+	block, err := api.cn.APIBackend.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	// The staking info read by the block rewards after Kaia is captured from the parent state.
+	return api.cn.blockchain.ExecutionWitness(block, api.cn.stakingModule)
+}
+
 // TODO-Kaia: Rearrange PublicDebugAPI and PrivateDebugAPI receivers
 // GetBadBLocks returns a list of the last 'bad blocks' that the client has seen on the network
 // and returns them as a JSON list of block-hashes
@@ -433,7 +449,7 @@ func (api *PrivateDebugAPI) StorageRangeAt(ctx context.Context, blockHash common
 	if block == nil {
 		return StorageRangeResult{}, fmt.Errorf("block %#x not found", blockHash)
 	}
-	_, _, _, statedb, release, err := api.cn.stateAtTransaction(block, txIndex, 0, nil, true, false)
+	_, _, _, statedb, release, err := api.cn.stateAtTransaction(ctx, block, txIndex, 0, nil, true, false)
 	if err != nil {
 		return StorageRangeResult{}, err
 	}
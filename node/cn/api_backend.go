@@ -24,6 +24,7 @@ package cn
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -43,6 +44,7 @@ import (
 	"github.com/kaiachain/kaia/node/cn/tracers"
 	"github.com/kaiachain/kaia/params"
 	"github.com/kaiachain/kaia/storage/database"
+	"github.com/kaiachain/kaia/storage/statedb"
 	"github.com/kaiachain/kaia/work"
 )
 
@@ -186,7 +188,7 @@ func (b *CNAPIBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.B
 	if header == nil || err != nil {
 		return nil, nil, err
 	}
-	stateDb, err := b.cn.BlockChain().StateAt(header.Root)
+	stateDb, err := b.stateAtHeader(ctx, header)
 	return stateDb, header, err
 }
 
@@ -199,12 +201,48 @@ func (b *CNAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNr
 		if header == nil {
 			return nil, nil, fmt.Errorf("header for hash not found")
 		}
-		stateDb, err := b.cn.BlockChain().StateAt(header.Root)
+		stateDb, err := b.stateAtHeader(ctx, header)
 		return stateDb, header, err
 	}
 	return nil, nil, fmt.Errorf("invalid arguments; neither block nor hash specified")
 }
 
+// stateAtHeader returns the state of the given header from the live database. If the
+// state has been pruned, it is regenerated by the state regenerator and kept alive
+// until the request context is done.
+func (b *CNAPIBackend) stateAtHeader(ctx context.Context, header *types.Header) (*state.StateDB, error) {
+	stateDb, err := b.cn.BlockChain().StateAt(header.Root)
+	if err == nil || b.cn.stateRegenerator == nil || b.cn.config.StateRegenReexec == 0 {
+		return stateDb, err
+	}
+	var missingNodeErr *statedb.MissingNodeError
+	if !errors.As(err, &missingNodeErr) {
+		return stateDb, err
+	}
+	block := b.cn.blockchain.GetBlock(header.Hash(), header.Number.Uint64())
+	if block == nil {
+		return stateDb, err
+	}
+	// Unlike the debug APIs, the regeneration for the state APIs is always time limited.
+	timeLimit := b.cn.config.StateRegenerationTimeLimit
+	if timeLimit <= 0 {
+		timeLimit = defaultStateRegenTimeLimit
+	}
+	regenerated, release, regenErr := b.cn.stateRegenerator.StateAt(ctx, block, b.cn.config.StateRegenReexec, timeLimit)
+	if regenErr != nil {
+		logger.Debug("Failed to regenerate historical state", "number", header.Number, "err", regenErr)
+		return stateDb, err
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(regeneratedStateHoldLimit):
+		}
+		release()
+	}()
+	return regenerated, nil
+}
+
 func (b *CNAPIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
 	block := b.cn.blockchain.GetBlockByHash(hash)
 	if block == nil {
@@ -382,11 +420,11 @@ func (b *CNAPIBackend) Engine() consensus.Engine {
 }
 
 func (b *CNAPIBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (*state.StateDB, tracers.StateReleaseFunc, error) {
-	return b.cn.stateAtBlock(block, reexec, base, readOnly, preferDisk)
+	return b.cn.stateAtBlock(ctx, block, reexec, base, readOnly, preferDisk)
 }
 
 func (b *CNAPIBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (blockchain.Message, vm.BlockContext, vm.TxContext, *state.StateDB, tracers.StateReleaseFunc, error) {
-	return b.cn.stateAtTransaction(block, txIndex, reexec, base, readOnly, preferDisk)
+	return b.cn.stateAtTransaction(ctx, block, txIndex, reexec, base, readOnly, preferDisk)
 }
 
 func (b *CNAPIBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
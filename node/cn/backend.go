@@ -23,6 +23,7 @@
 package cn
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -52,6 +53,7 @@ import (
 	reward_impl "github.com/kaiachain/kaia/kaiax/reward/impl"
 	"github.com/kaiachain/kaia/kaiax/staking"
 	staking_impl "github.com/kaiachain/kaia/kaiax/staking/impl"
+	statediff_impl "github.com/kaiachain/kaia/kaiax/statediff/impl"
 	supply_impl "github.com/kaiachain/kaia/kaiax/supply/impl"
 	"github.com/kaiachain/kaia/networks/p2p"
 	"github.com/kaiachain/kaia/networks/rpc"
@@ -59,6 +61,7 @@ import (
 	"github.com/kaiachain/kaia/node/cn/filters"
 	"github.com/kaiachain/kaia/node/cn/gasprice"
 	"github.com/kaiachain/kaia/node/cn/tracers"
+	"github.com/kaiachain/kaia/node/cn/tracers/live"
 	"github.com/kaiachain/kaia/params"
 	"github.com/kaiachain/kaia/reward"
 	"github.com/kaiachain/kaia/rlp"
@@ -152,6 +155,8 @@ type CN struct {
 	baseModules    []kaiax.BaseModule
 	jsonRpcModules []kaiax.JsonRpcModule
 	stakingModule  staking.StakingModule // TODO-kaiax: temporary for governance/api.go. Remove it after having kaiax/reward.
+
+	stateRegenerator *stateRegenerator // nil if the regeneration cache is disabled
 }
 
 func (s *CN) AddLesServer(ls LesServer) {
@@ -285,6 +290,15 @@ func New(ctx *node.ServiceContext, config *Config) (*CN, error) {
 		}
 	)
 
+	if config.VMTrace != "" {
+		hooks, err := live.New(config.VMTrace, json.RawMessage(config.VMTraceJsonConfig))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create live tracer %s: %v", config.VMTrace, err)
+		}
+		vmConfig.LiveTracer = hooks
+		logger.Info("Enabled live tracer", "name", config.VMTrace)
+	}
+
 	bc, err := blockchain.NewBlockChain(chainDB, cacheConfig, cn.chainConfig, cn.engine, vmConfig)
 	if err != nil {
 		return nil, err
@@ -428,6 +442,13 @@ func New(ctx *node.ServiceContext, config *Config) (*CN, error) {
 		logger.Error("Failed to setup kaiax modules", "err", err)
 	}
 
+	if config.StateRegenCacheSize > 0 {
+		// Create an ephemeral trie.Database for isolating the live one. Otherwise
+		// the internal junks created by regeneration will be persisted into the disk.
+		database := state.NewDatabaseWithExistingCache(cn.ChainDB(), cn.blockchain.StateCache().TrieDB().TrieNodeCache())
+		cn.stateRegenerator = newStateRegenerator(cn.blockchain, cn.stakingModule, database, common.StorageSize(config.StateRegenCacheSize)*1024*1024)
+	}
+
 	if config.AutoRestartFlag {
 		daemonPath := config.DaemonPathFlag
 		restartInterval := config.RestartTimeOutFlag
@@ -593,6 +614,23 @@ func (s *CN) SetupKaiaxModules() error {
 	}
 	s.protocolManager.RegisterStakingModule(mStaking)
 
+	if s.config.StateDiff {
+		mStateDiff := statediff_impl.NewStateDiffModule()
+		if err := mStateDiff.Init(&statediff_impl.InitOpts{
+			ChainKv:   s.chainDB.GetMiscDB(),
+			Chain:     s.blockchain,
+			Retention: s.config.StateDiffRetention,
+		}); err != nil {
+			return err
+		}
+		s.blockchain.EnableStateDiff()
+		s.RegisterBaseModules(mStateDiff)
+		s.RegisterJsonRpcModules(mStateDiff)
+		s.miner.RegisterExecutionModule(mStateDiff)
+		s.blockchain.RegisterExecutionModule(mStateDiff)
+		s.blockchain.RegisterRewindableModule(mStateDiff)
+	}
+
 	s.stakingModule = mStaking
 	return nil
 }
@@ -34,6 +34,7 @@ import (
 	"github.com/kaiachain/kaia/common/hexutil"
 	"github.com/kaiachain/kaia/consensus/istanbul"
 	"github.com/kaiachain/kaia/datasync/downloader"
+	"github.com/kaiachain/kaia/kaiax/statediff"
 	"github.com/kaiachain/kaia/log"
 	"github.com/kaiachain/kaia/node/cn/gasprice"
 	"github.com/kaiachain/kaia/params"
@@ -56,6 +57,7 @@ func GetDefaultConfig() *Config {
 		TrieNodeCacheConfig:     *statedb.GetEmptyTrieNodeCacheConfig(),
 		TriesInMemory:           blockchain.DefaultTriesInMemory,
 		LivePruningRetention:    blockchain.DefaultPruningRetention,
+		StateDiffRetention:      statediff.DefaultRetention,
 		TxPruningRetention:      blockchain.DefaultPruningRetention,
 		ReceiptPruningRetention: blockchain.DefaultPruningRetention,
 		GasPrice:                big.NewInt(18 * params.Gkei),
@@ -72,6 +74,8 @@ func GetDefaultConfig() *Config {
 
 		Istanbul:      *istanbul.DefaultConfig,
 		RPCEVMTimeout: 5 * time.Second,
+
+		StateRegenCacheSize: 256,
 	}
 }
 
@@ -97,6 +101,9 @@ type Config struct {
 	NoPruning     bool
 	WorkerDisable bool // disables worker and does not start istanbul
 
+	// SyncCheckpoint is the trusted block pinning the snap sync pivot
+	SyncCheckpoint *downloader.Checkpoint `toml:",omitempty"`
+
 	// KES options
 	DownloaderDisable bool
 	FetcherDisable    bool
@@ -107,8 +114,7 @@ type Config struct {
 	SentChainTxsLimit  uint64          // Number of chain transactions stored for resending. Default value is 1000.
 
 	// Light client options
-	// LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
-	// LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
+	LightServe bool `toml:",omitempty"` // Serve the klight protocol to the light nodes
 
 	OverwriteGenesis bool
 	StartBlockNumber uint64
@@ -131,6 +137,8 @@ type Config struct {
 	TriesInMemory           uint64
 	LivePruning             bool
 	LivePruningRetention    uint64
+	StateDiff               bool
+	StateDiffRetention      uint64
 	TxPruning               bool
 	TxPruningRetention      uint64
 	ReceiptPruning          bool
@@ -141,6 +149,10 @@ type Config struct {
 	SnapshotCacheSize       int
 	SnapshotAsyncGen        bool
 
+	// Snap serving quota of each peer, unlimited if zero
+	SnapServeBytesPerSec uint64
+	SnapServeMaxRequests int
+
 	// Mining-related options
 	ServiceChainSigner common.Address `toml:",omitempty"`
 	ExtraData          []byte         `toml:",omitempty"`
@@ -161,6 +173,11 @@ type Config struct {
 	EnableInternalTxTracing bool
 	// Enables collecting and printing opcode execution time when node stops
 	EnableOpDebug bool
+	// Enables executing the transactions of the imported blocks in parallel
+	ParallelExecution bool
+	// Name and JSON configuration of the live tracer of the imported blocks
+	VMTrace           string `toml:",omitempty"`
+	VMTraceJsonConfig string `toml:",omitempty"`
 
 	// Istanbul options
 	Istanbul istanbul.Config
@@ -200,6 +217,10 @@ type Config struct {
 	// Disable option for unsafe debug APIs
 	DisableUnsafeDebug         bool          `toml:",omitempty"`
 	StateRegenerationTimeLimit time.Duration `toml:",omitempty"`
+
+	// Historical state regeneration options
+	StateRegenCacheSize int    // Memory budget (MB) of the regenerated states cache. Zero disables the cache
+	StateRegenReexec    uint64 // Maximum number of blocks re-executed for state queries of API. Zero disables it
 }
 
 type configMarshaling struct {
@@ -211,5 +232,6 @@ func (c *Config) getVMConfig() vm.Config {
 		EnablePreimageRecording: c.EnablePreimageRecording,
 		EnableInternalTxTracing: c.EnableInternalTxTracing,
 		EnableOpDebug:           c.EnableOpDebug,
+		ParallelExecution:       c.ParallelExecution,
 	}
 }
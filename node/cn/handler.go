@@ -88,8 +88,33 @@ var (
 	errUnsupportedEnginePolicy = errors.New("unsupported engine or policy")
 )
 
+// protocolError is an error caused by a message of the peer.
+type protocolError struct {
+	code errCode
+	msg  string
+}
+
+func (e *protocolError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.msg)
+}
+
 func errResp(code errCode, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &protocolError{code: code, msg: fmt.Sprintf(format, v...)}
+}
+
+// misbehaviorPenalty returns the reputation penalty of the peer whose message caused the error.
+// Zero is returned if the error is not a misbehavior of the peer.
+func misbehaviorPenalty(err error) int {
+	var perr *protocolError
+	if !errors.As(err, &perr) {
+		return 0
+	}
+	switch perr.code {
+	case ErrMsgTooLarge, ErrDecode, ErrInvalidMsgCode, ErrExtraStatusMsg, ErrUnexpectedTxType:
+		return p2p.PenaltyBadMessage
+	default:
+		return 0
+	}
 }
 
 type ProtocolManager struct {
@@ -106,7 +131,9 @@ type ProtocolManager struct {
 
 	downloader ProtocolManagerDownloader
 	fetcher    ProtocolManagerFetcher
+	txFetcher  *txFetcher
 	peers      PeerSet
+	snapServer *snap.Server // serves the snap peers within the serving quota
 
 	SubProtocols []p2p.Protocol
 
@@ -162,6 +189,10 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		engine:            engine,
 		nodetype:          nodetype,
 		txResendUseLegacy: cnconfig.TxResendUseLegacy,
+		snapServer: snap.NewServer(snap.ServeConfig{
+			BytesPerSec: cnconfig.SnapServeBytesPerSec,
+			MaxRequests: cnconfig.SnapServeMaxRequests,
+		}),
 	}
 
 	// istanbul BFT
@@ -279,8 +310,8 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 						return manager.NodeInfo()
 					},
 					PeerInfo: func(id discover.NodeID) interface{} {
-						if p := manager.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
-							return p.Info()
+						if info := manager.snapServer.PeerInfo(fmt.Sprintf("%x", id[:8])); info != nil {
+							return info
 						}
 						return nil
 					},
@@ -313,7 +344,9 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		if config.Istanbul != nil {
 			proposerPolicy = config.Istanbul.ProposerPolicy
 		}
-		manager.downloader = downloader.New(mode, chainDB, stateBloom, manager.eventMux, blockchain, nil, manager.stakingModule, manager.removePeer, proposerPolicy)
+		dl := downloader.New(mode, chainDB, stateBloom, manager.eventMux, blockchain, nil, manager.stakingModule, manager.removeSyncPeer, proposerPolicy)
+		dl.SetCheckpoint(cnconfig.SyncCheckpoint)
+		manager.downloader = dl
 	}
 
 	// Create and set fetcher
@@ -335,8 +368,11 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 			atomic.StoreUint32(&manager.acceptTxs, 1) // Mark initial sync done on any fetcher import
 			return manager.blockchain.InsertChain(blocks)
 		}
-		manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, manager.BroadcastBlockHash, heighter, inserter, manager.removePeer)
+		manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, manager.BroadcastBlockHash, heighter, inserter, manager.penalizingRemovePeer(p2p.PenaltyInvalidData, "propagated an invalid block"))
 	}
+	manager.txFetcher = newTxFetcher(func(hash common.Hash) bool {
+		return manager.txpool.Get(hash) != nil
+	})
 
 	if manager.useTxResend() {
 		go manager.txResendLoop(cnconfig.TxResendInterval, cnconfig.TxResendCount)
@@ -357,6 +393,33 @@ func (pm *ProtocolManager) getWSEndPoint() string {
 	return pm.wsendpoint
 }
 
+// penalizingRemovePeer returns a function penalizing the peer with the reason before removing it.
+// It is used by the sync components dropping the peers which misbehaved.
+func (pm *ProtocolManager) penalizingRemovePeer(penalty int, reason string) func(id string) {
+	return func(id string) {
+		if peer := pm.peers.Peer(id); peer != nil {
+			peer.GetP2PPeer().Penalize(penalty, reason)
+		}
+		pm.removePeer(id)
+	}
+}
+
+// removeSyncPeer penalizes the peer dropped by the downloader according to the reason before removing it.
+// Invalid data is penalized as a misbehavior and a timeout as a slow response.
+func (pm *ProtocolManager) removeSyncPeer(id string, reason error) {
+	var penalty int
+	switch {
+	case downloader.IsMisbehavior(reason):
+		penalty = p2p.PenaltyInvalidData
+	case downloader.IsTimeout(reason):
+		penalty = p2p.PenaltySlowResponse
+	}
+	if peer := pm.peers.Peer(id); peer != nil && penalty > 0 {
+		peer.GetP2PPeer().Penalize(penalty, reason.Error())
+	}
+	pm.removePeer(id)
+}
+
 func (pm *ProtocolManager) removePeer(id string) {
 	// Short circuit if the peer was already removed
 	peer := pm.peers.Peer(id)
@@ -368,8 +431,9 @@ func (pm *ProtocolManager) removePeer(id string) {
 		pm.downloader.GetSnapSyncer().Unregister(id)
 	}
 
-	// Unregister the peer from the downloader and peer set
+	// Unregister the peer from the downloader, tx fetcher and peer set
 	pm.downloader.UnregisterPeer(id)
+	pm.requestPooledTxs(nil, pm.txFetcher.Drop(id))
 	if err := pm.peers.Unregister(id); err != nil {
 		logger.Error("Peer removal failed", "peer", id, "err", err)
 	}
@@ -399,6 +463,7 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	// start sync handlers
 	go pm.syncer()
 	go pm.txsyncLoop()
+	go pm.txFetchLoop()
 }
 
 func (pm *ProtocolManager) Stop() {
@@ -416,7 +481,7 @@ func (pm *ProtocolManager) Stop() {
 		pm.quitResendCh <- struct{}{}
 	}
 
-	// Quit fetcher, txsyncLoop.
+	// Quit fetcher, txsyncLoop, txFetchLoop.
 	close(pm.quitSync)
 
 	// Disconnect existing sessions.
@@ -469,7 +534,7 @@ func (pm *ProtocolManager) handleSnapPeer(peer *snap.Peer) error {
 		return err
 	}
 
-	return snap.Handle(pm.blockchain, pm.downloader, peer)
+	return pm.snapServer.Handle(pm.blockchain, pm.downloader, peer)
 }
 
 // handle is the callback invoked to manage the life cycle of a Kaia peer. When
@@ -576,6 +641,7 @@ func (pm *ProtocolManager) handle(p Peer) error {
 		if msg.Size > ProtocolMaxMsgSize {
 			err := errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
 			p.GetP2PPeer().Log().Warn("ProtocolManager over max msg size", "err", err)
+			p.GetP2PPeer().Penalize(p2p.PenaltyBadMessage, err.Error())
 			return err
 		}
 
@@ -613,6 +679,9 @@ func (pm *ProtocolManager) processMsg(msgCh <-chan p2p.Msg, p Peer, addr common.
 		for msg := range msgCh {
 			if err := pm.handleMsg(p, addr, msg); err != nil {
 				p.GetP2PPeer().Log().Error("ProtocolManager failed to handle message", "msg", msg, "err", err)
+				if penalty := misbehaviorPenalty(err); penalty > 0 {
+					p.GetP2PPeer().Penalize(penalty, err.Error())
+				}
 				errCh <- err
 				return
 			}
@@ -760,6 +829,21 @@ func (pm *ProtocolManager) handleMsg(p Peer, addr common.Address, msg p2p.Msg) e
 			return err
 		}
 
+	case p.GetVersion() >= kaia66 && msg.Code == NewPooledTransactionHashesMsg:
+		if err := handleNewPooledTransactionHashesMsg(pm, p, msg); err != nil {
+			return err
+		}
+
+	case p.GetVersion() >= kaia66 && msg.Code == PooledTransactionsRequestMsg:
+		if err := handlePooledTransactionsRequestMsg(pm, p, msg); err != nil {
+			return err
+		}
+
+	case p.GetVersion() >= kaia66 && msg.Code == PooledTransactionsMsg:
+		if err := handlePooledTransactionsMsg(pm, p, msg); err != nil {
+			return err
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -1241,6 +1325,109 @@ func handleTxMsg(pm *ProtocolManager, p Peer, msg p2p.Msg) error {
 	if err := msg.Decode(&txs); err != nil {
 		return errResp(ErrDecode, "msg %v: %v", msg, err)
 	}
+	return handleTxs(pm, p, txs)
+}
+
+// handleNewPooledTransactionHashesMsg handles transaction hashes announcement message.
+func handleNewPooledTransactionHashesMsg(pm *ProtocolManager, p Peer, msg p2p.Msg) error {
+	// Transactions arrived, make sure we have a valid and fresh chain to handle them
+	if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+		return nil
+	}
+	var hashes []common.Hash
+	if err := msg.Decode(&hashes); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	for _, hash := range hashes {
+		p.AddToKnownTxs(hash)
+	}
+	// Request the unknown transactions which are not being fetched from other peers
+	return pm.requestPooledTxs(p, pm.txFetcher.Notify(p.GetID(), hashes))
+}
+
+// requestPooledTxs sends the requests scheduled by the tx fetcher. The error of the
+// request to the given peer is returned, and the ones to other peers are logged.
+func (pm *ProtocolManager) requestPooledTxs(p Peer, requests map[string][]common.Hash) error {
+	var err error
+	for id, hashes := range requests {
+		if p != nil && id == p.GetID() {
+			err = p.RequestPooledTransactions(hashes)
+			continue
+		}
+		alt := pm.peers.Peer(id)
+		if alt == nil {
+			continue // the request times out and is rescheduled
+		}
+		if altErr := alt.RequestPooledTransactions(hashes); altErr != nil {
+			logger.Debug("Failed to request pooled transactions", "peer", id, "err", altErr)
+		}
+	}
+	return err
+}
+
+// handlePooledTransactionsRequestMsg handles pooled transactions request message.
+func handlePooledTransactionsRequestMsg(pm *ProtocolManager, p Peer, msg p2p.Msg) error {
+	// Decode the retrieval message
+	msgStream := rlp.NewStream(msg.Payload, uint64(msg.Size))
+	if _, err := msgStream.List(); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	// Gather transactions until the fetch or network limits is reached
+	var (
+		hash  common.Hash
+		bytes int
+		count int
+		txs   []rlp.RawValue
+	)
+	for ; bytes < softResponseLimit && count < maxTxFetchBatch; count++ {
+		// Retrieve the hash of the next transaction
+		if err := msgStream.Decode(&hash); err == rlp.EOL {
+			break
+		} else if err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// Retrieve the requested transaction, skipping if unknown to us
+		tx := pm.txpool.Get(hash)
+		if tx == nil {
+			continue
+		}
+		// If known, encode and queue for response packet
+		if encoded, err := rlp.EncodeToBytes(tx); err != nil {
+			logger.Error("Failed to encode transaction", "err", err)
+		} else {
+			txs = append(txs, encoded)
+			bytes += len(encoded)
+		}
+	}
+	return p.SendPooledTransactionsRLP(txs)
+}
+
+// handlePooledTransactionsMsg handles pooled transactions response message.
+func handlePooledTransactionsMsg(pm *ProtocolManager, p Peer, msg p2p.Msg) error {
+	var txs types.Transactions
+	if err := msg.Decode(&txs); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	// The reply frees the peer for the next request, and the missing transactions are
+	// requested from the other peers that announced them.
+	hashes := make([]common.Hash, 0, len(txs))
+	for _, tx := range txs {
+		if tx != nil {
+			hashes = append(hashes, tx.Hash())
+		}
+	}
+	if err := pm.requestPooledTxs(p, pm.txFetcher.Deliver(p.GetID(), hashes)); err != nil {
+		return err
+	}
+	// Transactions arrived, make sure we have a valid and fresh chain to handle them
+	if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+		return nil
+	}
+	return handleTxs(pm, p, txs)
+}
+
+// handleTxs marks the transactions received from the peer as known and delivers them to the pool.
+func handleTxs(pm *ProtocolManager, p Peer, txs types.Transactions) error {
 	// Only valid txs should be pushed into the pool.
 	validTxs := make(types.Transactions, 0, len(txs))
 	var err error
@@ -1379,20 +1566,22 @@ func (pm *ProtocolManager) broadcastTxsFromPN(txs types.Transactions) {
 
 	propTxPeersGauge.Update(int64(len(peersWithoutTxs) + len(cnPeersWithoutTxs)))
 	sendTransactions(cnPeersWithoutTxs)
-	sendTransactions(peersWithoutTxs)
+	sendOrAnnounceTransactions(peersWithoutTxs)
 }
 
 func (pm *ProtocolManager) broadcastTxsFromEN(txs types.Transactions) {
+	cnPeersWithoutTxs := make(map[Peer]types.Transactions)
 	peersWithoutTxs := make(map[Peer]types.Transactions)
 	for _, tx := range txs {
-		pm.peers.UpdateTypePeersWithoutTxs(tx, common.CONSENSUSNODE, peersWithoutTxs)
+		pm.peers.UpdateTypePeersWithoutTxs(tx, common.CONSENSUSNODE, cnPeersWithoutTxs)
 		pm.peers.UpdateTypePeersWithoutTxs(tx, common.PROXYNODE, peersWithoutTxs)
 		pm.peers.UpdateTypePeersWithoutTxs(tx, common.ENDPOINTNODE, peersWithoutTxs)
 		txSendCounter.Inc(1)
 	}
 
-	propTxPeersGauge.Update(int64(len(peersWithoutTxs)))
-	sendTransactions(peersWithoutTxs)
+	propTxPeersGauge.Update(int64(len(peersWithoutTxs) + len(cnPeersWithoutTxs)))
+	sendTransactions(cnPeersWithoutTxs)
+	sendOrAnnounceTransactions(peersWithoutTxs)
 }
 
 // ReBroadcastTxs sends transactions, not considering whether the peer has the transaction or not.
@@ -1432,6 +1621,48 @@ func sendTransactions(txsSet map[Peer]types.Transactions) {
 	}
 }
 
+// sendOrAnnounceTransactions sends each transaction in full to the square root of its
+// recipients and only announces its hash to the others, which fetch it on demand.
+func sendOrAnnounceTransactions(txsSet map[Peer]types.Transactions) {
+	direct, announce := splitTxPropagation(txsSet)
+	sendTransactions(direct)
+	for peer, txs := range announce {
+		if err := peer.AnnounceTransactions(txs); err != nil {
+			logger.Error("Failed to announce txs", "peer", peer.GetAddr(), "peerType", peer.ConnType(), "numTxs", len(txs), "err", err)
+		}
+	}
+}
+
+// splitTxPropagation divides the given transactions of each peer into the ones to be sent
+// in full and the ones to be announced. Since the map is iterated in random order, the
+// recipients of the full transaction are chosen randomly. The order of transactions is kept.
+func splitTxPropagation(txsSet map[Peer]types.Transactions) (direct, announce map[Peer]types.Transactions) {
+	var (
+		numRecipients = make(map[common.Hash]int)
+		ranks         = make(map[Peer][]int, len(txsSet))
+	)
+	for peer, txs := range txsSet {
+		ranks[peer] = make([]int, len(txs))
+		for i, tx := range txs {
+			ranks[peer][i] = numRecipients[tx.Hash()]
+			numRecipients[tx.Hash()]++
+		}
+	}
+
+	direct = make(map[Peer]types.Transactions)
+	announce = make(map[Peer]types.Transactions)
+	for peer, txs := range txsSet {
+		for i, tx := range txs {
+			if ranks[peer][i] < int(math.Sqrt(float64(numRecipients[tx.Hash()]))) {
+				direct[peer] = append(direct[peer], tx)
+			} else {
+				announce[peer] = append(announce[peer], tx)
+			}
+		}
+	}
+	return direct, announce
+}
+
 func samplingPeers(peers []Peer, pickSize int) []Peer {
 	if len(peers) <= pickSize {
 		return peers
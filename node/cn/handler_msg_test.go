@@ -281,6 +281,87 @@ func TestHandleTxMsg(t *testing.T) {
 	}
 }
 
+func prepareTxFetch(t *testing.T) (*gomock.Controller, *mocks.MockTxPool, *MockPeer, *ProtocolManager) {
+	mockCtrl := gomock.NewController(t)
+	mockTxPool := mocks.NewMockTxPool(mockCtrl)
+	mockPeer := NewMockPeer(mockCtrl)
+	mockPeer.EXPECT().GetVersion().Return(kaia66).AnyTimes()
+	mockPeer.EXPECT().GetID().Return(nodeids[0].String()).AnyTimes()
+
+	pm := &ProtocolManager{txpool: mockTxPool}
+	pm.txFetcher = newTxFetcher(func(hash common.Hash) bool { return pm.txpool.Get(hash) != nil })
+	atomic.StoreUint32(&pm.acceptTxs, 1)
+	return mockCtrl, mockTxPool, mockPeer, pm
+}
+
+func TestHandleNewPooledTransactionHashesMsg(t *testing.T) {
+	hashes := []common.Hash{tx1.Hash(), hash1}
+
+	// Unknown transactions are requested, and known ones are not.
+	{
+		mockCtrl, mockTxPool, mockPeer, pm := prepareTxFetch(t)
+		mockTxPool.EXPECT().Get(tx1.Hash()).Return(tx1).Times(1)
+		mockTxPool.EXPECT().Get(hash1).Return(nil).AnyTimes()
+		mockPeer.EXPECT().AddToKnownTxs(gomock.Any()).Times(len(hashes))
+		mockPeer.EXPECT().RequestPooledTransactions([]common.Hash{hash1}).Return(nil).Times(1)
+
+		assert.NoError(t, pm.handleMsg(mockPeer, addrs[0], generateMsg(t, NewPooledTransactionHashesMsg, hashes)))
+
+		// The transaction being fetched is not requested again.
+		mockPeer.EXPECT().AddToKnownTxs(hash1).Times(1)
+		assert.NoError(t, pm.handleMsg(mockPeer, addrs[0], generateMsg(t, NewPooledTransactionHashesMsg, []common.Hash{hash1})))
+		mockCtrl.Finish()
+	}
+
+	// A peer before kaia/66 cannot announce transactions.
+	{
+		mockCtrl := gomock.NewController(t)
+		mockPeer := NewMockPeer(mockCtrl)
+		mockPeer.EXPECT().GetVersion().Return(kaia65).AnyTimes()
+
+		pm := &ProtocolManager{}
+		assert.Error(t, pm.handleMsg(mockPeer, addrs[0], generateMsg(t, NewPooledTransactionHashesMsg, hashes)))
+		mockCtrl.Finish()
+	}
+}
+
+func TestHandlePooledTransactionsRequestMsg(t *testing.T) {
+	mockCtrl, mockTxPool, mockPeer, pm := prepareTxFetch(t)
+	defer mockCtrl.Finish()
+
+	encoded, err := rlp.EncodeToBytes(tx1)
+	assert.NoError(t, err)
+
+	mockTxPool.EXPECT().Get(tx1.Hash()).Return(tx1).Times(1)
+	mockTxPool.EXPECT().Get(hash1).Return(nil).Times(1)
+	mockPeer.EXPECT().SendPooledTransactionsRLP([]rlp.RawValue{encoded}).Return(nil).Times(1)
+
+	msg := generateMsg(t, PooledTransactionsRequestMsg, []common.Hash{tx1.Hash(), hash1})
+	assert.NoError(t, pm.handleMsg(mockPeer, addrs[0], msg))
+
+	// A request that is not a list fails to decode.
+	msg = generateMsg(t, PooledTransactionsRequestMsg, uint64(1))
+	err = pm.handleMsg(mockPeer, addrs[0], msg)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), errCode(ErrDecode).String()))
+}
+
+func TestHandlePooledTransactionsMsg(t *testing.T) {
+	mockCtrl, mockTxPool, mockPeer, pm := prepareTxFetch(t)
+	defer mockCtrl.Finish()
+
+	mockTxPool.EXPECT().Get(tx1.Hash()).Return(nil).AnyTimes()
+	pm.txFetcher.Notify(nodeids[0].String(), []common.Hash{tx1.Hash()})
+
+	mockPeer.EXPECT().AddToKnownTxs(tx1.Hash()).Times(1)
+	mockTxPool.EXPECT().HandleTxMsg(gomock.Any()).Times(1)
+
+	msg := generateMsg(t, PooledTransactionsMsg, types.Transactions{tx1})
+	assert.NoError(t, pm.handleMsg(mockPeer, addrs[0], msg))
+	assert.Empty(t, pm.txFetcher.fetching)
+	assert.Empty(t, pm.txFetcher.requests)
+}
+
 func prepareTestHandleBlockHeaderFetchRequestMsg(t *testing.T) (*gomock.Controller, *MockPeer, *mocks.MockBlockChain, *ProtocolManager) {
 	mockCtrl := gomock.NewController(t)
 	mockPeer := NewMockPeer(mockCtrl)
@@ -215,7 +215,7 @@ func TestProtocolManager_removePeer(t *testing.T) {
 	peerID := nodeids[0].String()
 
 	{
-		pm := &ProtocolManager{}
+		pm := &ProtocolManager{txFetcher: newTxFetcher(nil)}
 		mockCtrl := gomock.NewController(t)
 
 		mockPeerSet := NewMockPeerSet(mockCtrl)
@@ -228,7 +228,7 @@ func TestProtocolManager_removePeer(t *testing.T) {
 	}
 
 	{
-		pm := &ProtocolManager{}
+		pm := &ProtocolManager{txFetcher: newTxFetcher(nil)}
 		mockCtrl := gomock.NewController(t)
 
 		mockPeerSet := NewMockPeerSet(mockCtrl)
@@ -254,7 +254,7 @@ func TestProtocolManager_removePeer(t *testing.T) {
 	}
 
 	{
-		pm := &ProtocolManager{}
+		pm := &ProtocolManager{txFetcher: newTxFetcher(nil)}
 		mockCtrl := gomock.NewController(t)
 
 		mockPeerSet := NewMockPeerSet(mockCtrl)
@@ -318,6 +318,16 @@ func TestProtocolManager_processMsg_panicRecover(t *testing.T) {
 	assert.Equal(t, errUnknownProcessingError, err)
 }
 
+func TestMisbehaviorPenalty(t *testing.T) {
+	assert.Equal(t, p2p.PenaltyBadMessage, misbehaviorPenalty(errResp(ErrDecode, "msg %v", 1)))
+	assert.Equal(t, p2p.PenaltyBadMessage, misbehaviorPenalty(fmt.Errorf("wrapped: %w", errResp(ErrInvalidMsgCode, "%v", 0x30))))
+	assert.Equal(t, 0, misbehaviorPenalty(errResp(ErrNetworkIdMismatch, "%d (!= %d)", 1, 2)))
+	assert.Equal(t, 0, misbehaviorPenalty(errUnknownProcessingError))
+
+	// The error message is kept.
+	assert.Equal(t, "Invalid message - msg 1", errResp(ErrDecode, "msg %v", 1).Error())
+}
+
 func TestSampleSize(t *testing.T) {
 	peers := make([]Peer, minNumPeersToSendBlock-1)
 	assert.Equal(t, len(peers), sampleSize(peers))
@@ -335,6 +345,54 @@ func TestSamplingPeers(t *testing.T) {
 	assert.Equal(t, peers[:5], samplingPeers(peers, 5))
 }
 
+func TestSplitTxPropagation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tx2 := types.NewTransaction(222, addrs[0], big.NewInt(222), 222, big.NewInt(222), addrs[0][:])
+	txsSet := make(map[Peer]types.Transactions)
+	for i := 0; i < 9; i++ {
+		txsSet[NewMockPeer(mockCtrl)] = types.Transactions{tx1, tx2}
+	}
+	lonePeer := NewMockPeer(mockCtrl)
+	txsSet[lonePeer] = types.Transactions{tx2}
+
+	direct, announce := splitTxPropagation(txsSet)
+
+	count := func(set map[Peer]types.Transactions, hash common.Hash) int {
+		n := 0
+		for _, txs := range set {
+			for _, tx := range txs {
+				if tx.Hash() == hash {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	// tx1 has 9 recipients and tx2 has 10, so each is sent in full to 3 peers.
+	assert.Equal(t, 3, count(direct, tx1.Hash()))
+	assert.Equal(t, 6, count(announce, tx1.Hash()))
+	assert.Equal(t, 3, count(direct, tx2.Hash()))
+	assert.Equal(t, 7, count(announce, tx2.Hash()))
+
+	// Every peer receives each of its transactions exactly once, in the original order.
+	for peer, txs := range txsSet {
+		assert.Equal(t, len(txs), len(direct[peer])+len(announce[peer]))
+	}
+	for _, set := range []map[Peer]types.Transactions{direct, announce} {
+		for _, txs := range set {
+			if len(txs) == 2 {
+				assert.Equal(t, tx1.Hash(), txs[0].Hash())
+			}
+		}
+	}
+
+	// A transaction with a single recipient is always sent in full.
+	_, lone := splitTxPropagation(map[Peer]types.Transactions{lonePeer: {tx2}})
+	assert.Empty(t, lone)
+}
+
 func TestBroadcastBlock_NoParentExists(t *testing.T) {
 	pm := &ProtocolManager{}
 	pm.nodetype = common.ENDPOINTNODE
@@ -578,11 +636,23 @@ func TestBroadcastTxsFromEN_ALL_Exists(t *testing.T) {
 	pnPeer.EXPECT().KnowsTx(tx1.Hash()).Return(false).Times(1)
 	enPeer.EXPECT().KnowsTx(tx1.Hash()).Return(false).Times(1)
 
+	// CN receives the full transactions, and one of PN and EN receives only the announcement.
 	cnPeer.EXPECT().SendTransactions(gomock.Eq(txs)).Times(1)
-	pnPeer.EXPECT().SendTransactions(gomock.Eq(txs)).Times(1)
-	enPeer.EXPECT().SendTransactions(gomock.Eq(txs)).Times(1)
+	sent, announced := 0, 0
+	for _, peer := range []*MockPeer{pnPeer, enPeer} {
+		peer.EXPECT().SendTransactions(gomock.Eq(txs)).DoAndReturn(func(types.Transactions) error {
+			sent++
+			return nil
+		}).MaxTimes(1)
+		peer.EXPECT().AnnounceTransactions(gomock.Eq(txs)).DoAndReturn(func(types.Transactions) error {
+			announced++
+			return nil
+		}).MaxTimes(1)
+	}
 
 	pm.BroadcastTxs(txs)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 1, announced)
 }
 
 func TestBroadcastTxsFrom_DefaultCase(t *testing.T) {
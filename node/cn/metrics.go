@@ -35,6 +35,10 @@ var (
 	propTxnOutPacketsMeter               = metrics.NewRegisteredMeter("klay/prop/txns/out/packets", nil)
 	propTxnOutTrafficMeter               = metrics.NewRegisteredMeter("klay/prop/txns/out/traffic", nil)
 	propTxPeersGauge                     = metrics.NewRegisteredGauge("klay/prop/tx/peers/gauge", nil)
+	propTxHashInPacketsMeter             = metrics.NewRegisteredMeter("klay/prop/txhashes/in/packets", nil)
+	propTxHashInTrafficMeter             = metrics.NewRegisteredMeter("klay/prop/txhashes/in/traffic", nil)
+	propTxHashOutPacketsMeter            = metrics.NewRegisteredMeter("klay/prop/txhashes/out/packets", nil)
+	propTxHashOutTrafficMeter            = metrics.NewRegisteredMeter("klay/prop/txhashes/out/traffic", nil)
 	propHashInPacketsMeter               = metrics.NewRegisteredMeter("klay/prop/hashes/in/packets", nil)
 	propHashInTrafficMeter               = metrics.NewRegisteredMeter("klay/prop/hashes/in/traffic", nil)
 	propHashOutPacketsMeter              = metrics.NewRegisteredMeter("klay/prop/hashes/out/packets", nil)
@@ -67,6 +71,8 @@ var (
 	txResendCounter                      = metrics.NewRegisteredCounter("klay/tx/resend/counter", nil)
 	txSendCounter                        = metrics.NewRegisteredCounter("klay/tx/send/counter", nil)
 	txResendRoutineGauge                 = metrics.NewRegisteredGauge("klay/tx/resend/routine/gauge", nil)
+	txAnnounceDropCounter                = metrics.NewRegisteredCounter("klay/tx/announce/drop/counter", nil)
+	txFetchTimeoutCounter                = metrics.NewRegisteredCounter("klay/tx/fetch/timeout/counter", nil)
 	cnPeerCountGauge                     = metrics.NewRegisteredGauge("p2p/CNPeerCountGauge", nil)
 	pnPeerCountGauge                     = metrics.NewRegisteredGauge("p2p/PNPeerCountGauge", nil)
 	enPeerCountGauge                     = metrics.NewRegisteredGauge("p2p/ENPeerCountGauge", nil)
@@ -123,6 +129,10 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 		packets, traffic = propBlockInPacketsMeter, propBlockInTrafficMeter
 	case msg.Code == TxMsg:
 		packets, traffic = propTxnInPacketsMeter, propTxnInTrafficMeter
+	case rw.version >= kaia66 && msg.Code == PooledTransactionsMsg:
+		packets, traffic = propTxnInPacketsMeter, propTxnInTrafficMeter
+	case rw.version >= kaia66 && msg.Code == NewPooledTransactionHashesMsg:
+		packets, traffic = propTxHashInPacketsMeter, propTxHashInTrafficMeter
 	case msg.Code == backend.IstanbulMsg:
 		packets, traffic = propConsensusIstanbulInPacketsMeter, propConsensusIstanbulInTrafficMeter
 	}
@@ -152,6 +162,10 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 		packets, traffic = propBlockOutPacketsMeter, propBlockOutTrafficMeter
 	case msg.Code == TxMsg:
 		packets, traffic = propTxnOutPacketsMeter, propTxnOutTrafficMeter
+	case rw.version >= kaia66 && msg.Code == PooledTransactionsMsg:
+		packets, traffic = propTxnOutPacketsMeter, propTxnOutTrafficMeter
+	case rw.version >= kaia66 && msg.Code == NewPooledTransactionHashesMsg:
+		packets, traffic = propTxHashOutPacketsMeter, propTxHashOutTrafficMeter
 	case msg.Code == backend.IstanbulMsg:
 		packets, traffic = propConsensusIstanbulOutPacketsMeter, propConsensusIstanbulOutTrafficMeter
 	}
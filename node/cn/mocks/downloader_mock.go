@@ -206,6 +206,20 @@ func (mr *MockProtocolManagerDownloaderMockRecorder) SyncStakingInfoStatus() *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStakingInfoStatus", reflect.TypeOf((*MockProtocolManagerDownloader)(nil).SyncStakingInfoStatus))
 }
 
+// SyncStatus mocks base method.
+func (m *MockProtocolManagerDownloader) SyncStatus() *downloader.SyncStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncStatus")
+	ret0, _ := ret[0].(*downloader.SyncStatus)
+	return ret0
+}
+
+// SyncStatus indicates an expected call of SyncStatus.
+func (mr *MockProtocolManagerDownloaderMockRecorder) SyncStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStatus", reflect.TypeOf((*MockProtocolManagerDownloader)(nil).SyncStatus))
+}
+
 // Synchronise mocks base method.
 func (m *MockProtocolManagerDownloader) Synchronise(arg0 string, arg1 common.Hash, arg2 *big.Int, arg3 downloader.SyncMode) error {
 	m.ctrl.T.Helper()
@@ -125,6 +125,19 @@ type Peer interface {
 	// AsyncSendTransactions sends transactions asynchronously to the peer.
 	AsyncSendTransactions(txs types.Transactions)
 
+	// AnnounceTransactions announces the hashes of transactions to the peer and includes
+	// the hashes in its transaction hash set for future reference.
+	// If the peer does not support kaia/66, the transactions are sent in full instead.
+	AnnounceTransactions(txs types.Transactions) error
+
+	// SendPooledTransactionsRLP sends a batch of pooled transactions, corresponding to the
+	// ones requested from an already RLP encoded format.
+	SendPooledTransactionsRLP(txs []rlp.RawValue) error
+
+	// RequestPooledTransactions fetches a batch of pooled transactions corresponding to
+	// the announced hashes.
+	RequestPooledTransactions(hashes []common.Hash) error
+
 	// SendNewBlockHashes announces the availability of a number of blocks through
 	// a hash notification.
 	SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error
@@ -326,6 +339,11 @@ var ChannelOfMessage = map[uint64]int{
 	// Protocol messages belonging to kaia/65
 	StakingInfoRequestMsg: p2p.ConnDefault,
 	StakingInfoMsg:        p2p.ConnDefault,
+
+	// Protocol messages belonging to kaia/66
+	NewPooledTransactionHashesMsg: p2p.ConnTxMsg,
+	PooledTransactionsRequestMsg:  p2p.ConnTxMsg,
+	PooledTransactionsMsg:         p2p.ConnTxMsg,
 }
 
 var ConcurrentOfChannel = []int{
@@ -478,6 +496,34 @@ func (p *basePeer) AsyncSendTransactions(txs types.Transactions) {
 	}
 }
 
+// AnnounceTransactions announces the hashes of transactions to the peer and includes
+// the hashes in its transaction hash set for future reference.
+// If the peer does not support kaia/66, the transactions are sent in full instead.
+func (p *basePeer) AnnounceTransactions(txs types.Transactions) error {
+	if p.version < kaia66 {
+		return p.SendTransactions(txs)
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+		p.AddToKnownTxs(hashes[i])
+	}
+	return p2p.Send(p.rw, NewPooledTransactionHashesMsg, hashes)
+}
+
+// SendPooledTransactionsRLP sends a batch of pooled transactions, corresponding to the
+// ones requested from an already RLP encoded format.
+func (p *basePeer) SendPooledTransactionsRLP(txs []rlp.RawValue) error {
+	return p2p.Send(p.rw, PooledTransactionsMsg, txs)
+}
+
+// RequestPooledTransactions fetches a batch of pooled transactions corresponding to
+// the announced hashes.
+func (p *basePeer) RequestPooledTransactions(hashes []common.Hash) error {
+	p.Log().Trace("Fetching batch of pooled transactions", "count", len(hashes))
+	return p2p.Send(p.rw, PooledTransactionsRequestMsg, hashes)
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *basePeer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -858,6 +904,34 @@ func (p *multiChannelPeer) ReSendTransactions(txs types.Transactions) error {
 	return p.msgSender(TxMsg, txs)
 }
 
+// AnnounceTransactions announces the hashes of transactions to the peer and includes
+// the hashes in its transaction hash set for future reference.
+// If the peer does not support kaia/66, the transactions are sent in full instead.
+func (p *multiChannelPeer) AnnounceTransactions(txs types.Transactions) error {
+	if p.version < kaia66 {
+		return p.SendTransactions(txs)
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+		p.AddToKnownTxs(hashes[i])
+	}
+	return p.msgSender(NewPooledTransactionHashesMsg, hashes)
+}
+
+// SendPooledTransactionsRLP sends a batch of pooled transactions, corresponding to the
+// ones requested from an already RLP encoded format.
+func (p *multiChannelPeer) SendPooledTransactionsRLP(txs []rlp.RawValue) error {
+	return p.msgSender(PooledTransactionsMsg, txs)
+}
+
+// RequestPooledTransactions fetches a batch of pooled transactions corresponding to
+// the announced hashes.
+func (p *multiChannelPeer) RequestPooledTransactions(hashes []common.Hash) error {
+	p.Log().Trace("Fetching batch of pooled transactions", "count", len(hashes))
+	return p.msgSender(PooledTransactionsRequestMsg, hashes)
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *multiChannelPeer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -76,6 +76,20 @@ func (mr *MockPeerMockRecorder) AddToKnownTxs(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToKnownTxs", reflect.TypeOf((*MockPeer)(nil).AddToKnownTxs), arg0)
 }
 
+// AnnounceTransactions mocks base method
+func (m *MockPeer) AnnounceTransactions(arg0 types.Transactions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnnounceTransactions", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnnounceTransactions indicates an expected call of AnnounceTransactions
+func (mr *MockPeerMockRecorder) AnnounceTransactions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnounceTransactions", reflect.TypeOf((*MockPeer)(nil).AnnounceTransactions), arg0)
+}
+
 // AsyncSendNewBlock mocks base method
 func (m *MockPeer) AsyncSendNewBlock(arg0 *types.Block, arg1 *big.Int) {
 	m.ctrl.T.Helper()
@@ -471,6 +485,20 @@ func (mr *MockPeerMockRecorder) RequestNodeData(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestNodeData", reflect.TypeOf((*MockPeer)(nil).RequestNodeData), arg0)
 }
 
+// RequestPooledTransactions mocks base method
+func (m *MockPeer) RequestPooledTransactions(arg0 []common.Hash) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPooledTransactions", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPooledTransactions indicates an expected call of RequestPooledTransactions
+func (mr *MockPeerMockRecorder) RequestPooledTransactions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPooledTransactions", reflect.TypeOf((*MockPeer)(nil).RequestPooledTransactions), arg0)
+}
+
 // RequestReceipts mocks base method
 func (m *MockPeer) RequestReceipts(arg0 []common.Hash) error {
 	m.ctrl.T.Helper()
@@ -639,6 +667,20 @@ func (mr *MockPeerMockRecorder) SendNodeData(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendNodeData", reflect.TypeOf((*MockPeer)(nil).SendNodeData), arg0)
 }
 
+// SendPooledTransactionsRLP mocks base method
+func (m *MockPeer) SendPooledTransactionsRLP(arg0 []rlp.RawValue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendPooledTransactionsRLP", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendPooledTransactionsRLP indicates an expected call of SendPooledTransactionsRLP
+func (mr *MockPeerMockRecorder) SendPooledTransactionsRLP(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPooledTransactionsRLP", reflect.TypeOf((*MockPeer)(nil).SendPooledTransactionsRLP), arg0)
+}
+
 // SendReceiptsRLP mocks base method
 func (m *MockPeer) SendReceiptsRLP(arg0 []rlp.RawValue) error {
 	m.ctrl.T.Helper()
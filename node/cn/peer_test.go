@@ -152,6 +152,73 @@ func TestBasePeer_AsyncSendTransactions(t *testing.T) {
 	assert.False(t, basePeer.KnowsTx(lastTxs[0].Hash()))
 }
 
+func TestBasePeer_AnnounceTransactions(t *testing.T) {
+	sentTxs := types.Transactions{tx1}
+
+	// A kaia/66 peer receives the hashes of the transactions.
+	{
+		pipe1, pipe2 := p2p.MsgPipe()
+		basePeer := newPeer(kaia66, p2pPeers[0], pipe1)
+		go func(t *testing.T) {
+			if err := basePeer.AnnounceTransactions(sentTxs); err != nil {
+				t.Error(err)
+			}
+		}(t)
+		receivedMsg, err := pipe2.ReadMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var receivedHashes []common.Hash
+		assert.Equal(t, uint64(NewPooledTransactionHashesMsg), receivedMsg.Code)
+		assert.NoError(t, receivedMsg.Decode(&receivedHashes))
+		assert.Equal(t, []common.Hash{tx1.Hash()}, receivedHashes)
+		assert.True(t, basePeer.KnowsTx(tx1.Hash()))
+	}
+
+	// An older peer receives the full transactions.
+	{
+		basePeer, _, oppositePipe := newBasePeer()
+		go func(t *testing.T) {
+			if err := basePeer.AnnounceTransactions(sentTxs); err != nil {
+				t.Error(err)
+			}
+		}(t)
+		receivedMsg, err := oppositePipe.ReadMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var receivedTxs types.Transactions
+		assert.Equal(t, uint64(TxMsg), receivedMsg.Code)
+		assert.NoError(t, receivedMsg.Decode(&receivedTxs))
+		assert.Equal(t, 1, len(receivedTxs))
+		assert.Equal(t, tx1.Hash(), receivedTxs[0].Hash())
+		assert.True(t, basePeer.KnowsTx(tx1.Hash()))
+	}
+}
+
+func TestBasePeer_RequestPooledTransactions(t *testing.T) {
+	pipe1, pipe2 := p2p.MsgPipe()
+	basePeer := newPeer(kaia66, p2pPeers[0], pipe1)
+	sentHashes := []common.Hash{hash1}
+
+	go func(t *testing.T) {
+		if err := basePeer.RequestPooledTransactions(sentHashes); err != nil {
+			t.Error(err)
+		}
+	}(t)
+	receivedMsg, err := pipe2.ReadMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var receivedHashes []common.Hash
+	assert.Equal(t, uint64(PooledTransactionsRequestMsg), receivedMsg.Code)
+	assert.NoError(t, receivedMsg.Decode(&receivedHashes))
+	assert.Equal(t, sentHashes, receivedHashes)
+}
+
 func TestBasePeer_ConnType(t *testing.T) {
 	basePeer, _, _ := newBasePeer()
 	assert.Equal(t, common.CONSENSUSNODE, basePeer.ConnType())
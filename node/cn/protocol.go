@@ -43,6 +43,7 @@ import (
 const (
 	kaia63 = 63
 	kaia65 = 65
+	kaia66 = 66
 )
 
 const ProtocolMaxMsgSize = 12 * 1024 * 1024 // Maximum cap on the size of a protocol message
@@ -78,7 +79,12 @@ const (
 	StakingInfoRequestMsg = 0x12
 	StakingInfoMsg        = 0x13
 
-	MsgCodeEnd = 0x14
+	// Protocol messages belonging to kaia/66
+	NewPooledTransactionHashesMsg = 0x14
+	PooledTransactionsRequestMsg  = 0x15
+	PooledTransactionsMsg         = 0x16
+
+	MsgCodeEnd = 0x17
 )
 
 type errCode int
@@ -137,6 +143,7 @@ type ProtocolManagerDownloader interface {
 	Terminate()
 	Synchronise(id string, head common.Hash, td *big.Int, mode downloader.SyncMode) error
 	Progress() kaia.SyncProgress
+	SyncStatus() *downloader.SyncStatus
 	Cancel()
 
 	GetSnapSyncer() *snap.Syncer
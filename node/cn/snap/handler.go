@@ -103,7 +103,6 @@ func HandleMessage(reader SnapshotReader, downloader SnapshotDownloader, peer *P
 	}
 	defer msg.Discard()
 	start := time.Now()
-	// TODO-Kaia-SnapSync add metric to track the amount of time it takes to serve the request and run the manager
 	// Handle the message depending on its contents
 	switch {
 	case msg.Code == GetAccountRangeMsg:
@@ -113,13 +112,14 @@ func HandleMessage(reader SnapshotReader, downloader SnapshotDownloader, peer *P
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		// Service the request, potentially returning nothing in case of errors
-		accounts, proofs := ServiceGetAccountRangeQuery(reader, &req)
-
-		// Send back anything accumulated (or empty in case of errors)
-		return p2p.Send(peer.rw, AccountRangeMsg, &AccountRangePacket{
-			ID:       req.ID,
-			Accounts: accounts,
-			Proof:    proofs,
+		// or if the peer is over its quota
+		return serveRequest(peer, AccountRangeMsg, &req.Bytes, &AccountRangePacket{ID: req.ID}, func() (interface{}, error) {
+			accounts, proofs := ServiceGetAccountRangeQuery(reader, &req)
+			return &AccountRangePacket{
+				ID:       req.ID,
+				Accounts: accounts,
+				Proof:    proofs,
+			}, nil
 		})
 
 	case msg.Code == AccountRangeMsg:
@@ -145,13 +145,14 @@ func HandleMessage(reader SnapshotReader, downloader SnapshotDownloader, peer *P
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		// Service the request, potentially returning nothing in case of errors
-		slots, proofs := ServiceGetStorageRangesQuery(reader, &req)
-
-		// Send back anything accumulated (or empty in case of errors)
-		return p2p.Send(peer.rw, StorageRangesMsg, &StorageRangesPacket{
-			ID:    req.ID,
-			Slots: slots,
-			Proof: proofs,
+		// or if the peer is over its quota
+		return serveRequest(peer, StorageRangesMsg, &req.Bytes, &StorageRangesPacket{ID: req.ID}, func() (interface{}, error) {
+			slots, proofs := ServiceGetStorageRangesQuery(reader, &req)
+			return &StorageRangesPacket{
+				ID:    req.ID,
+				Slots: slots,
+				Proof: proofs,
+			}, nil
 		})
 
 	case msg.Code == StorageRangesMsg:
@@ -179,12 +180,12 @@ func HandleMessage(reader SnapshotReader, downloader SnapshotDownloader, peer *P
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		// Service the request, potentially returning nothing in case of errors
-		codes := ServiceGetByteCodesQuery(reader, &req)
-
-		// Send back anything accumulated (or empty in case of errors)
-		return p2p.Send(peer.rw, ByteCodesMsg, &ByteCodesPacket{
-			ID:    req.ID,
-			Codes: codes,
+		// or if the peer is over its quota
+		return serveRequest(peer, ByteCodesMsg, &req.Bytes, &ByteCodesPacket{ID: req.ID}, func() (interface{}, error) {
+			return &ByteCodesPacket{
+				ID:    req.ID,
+				Codes: ServiceGetByteCodesQuery(reader, &req),
+			}, nil
 		})
 
 	case msg.Code == ByteCodesMsg:
@@ -204,14 +205,16 @@ func HandleMessage(reader SnapshotReader, downloader SnapshotDownloader, peer *P
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		// Service the request, potentially returning nothing in case of errors
-		nodes, err := ServiceGetTrieNodesQuery(reader, &req, start)
-		if err != nil {
-			return err
-		}
-		// Send back anything accumulated (or empty in case of errors)
-		return p2p.Send(peer.rw, TrieNodesMsg, &TrieNodesPacket{
-			ID:    req.ID,
-			Nodes: nodes,
+		// or if the peer is over its quota
+		return serveRequest(peer, TrieNodesMsg, &req.Bytes, &TrieNodesPacket{ID: req.ID}, func() (interface{}, error) {
+			nodes, err := ServiceGetTrieNodesQuery(reader, &req, start)
+			if err != nil {
+				return nil, err
+			}
+			return &TrieNodesPacket{
+				ID:    req.ID,
+				Nodes: nodes,
+			}, nil
 		})
 
 	case msg.Code == TrieNodesMsg:
@@ -229,6 +232,67 @@ func HandleMessage(reader SnapshotReader, downloader SnapshotDownloader, peer *P
 	}
 }
 
+// serveRequest sends back the response assembled by the query within the quota
+// of the peer. The byte limit of the request is lowered to the budget left to
+// the peer, and the empty response is sent if the peer is over its quota.
+// With a limit of concurrent requests, the query runs in the background and
+// its failure disconnects the peer.
+func serveRequest(peer *Peer, code uint64, bytes *uint64, empty interface{}, query func() (interface{}, error)) error {
+	if peer.quota == nil {
+		return sendResponse(peer, code, query)
+	}
+	if *bytes > softResponseLimit {
+		*bytes = softResponseLimit
+	}
+	limit, ok := peer.quota.acquire(*bytes)
+	if !ok {
+		peer.Log().Trace("Snap request over the quota", "code", code, "bytes", *bytes)
+		return p2p.Send(peer.rw, code, empty)
+	}
+	*bytes = limit
+
+	serve := func() error {
+		served := uint64(0)
+		defer func() { peer.quota.release(limit, served) }()
+
+		start := time.Now()
+		res, err := query()
+		snapServeTimer.UpdateSince(start)
+		if err != nil {
+			return err
+		}
+		size, r, err := rlp.EncodeToReader(res)
+		if err != nil {
+			return err
+		}
+		served = uint64(size)
+		return peer.rw.WriteMsg(p2p.Msg{Code: code, Size: uint32(size), Payload: r})
+	}
+	if peer.quota.config.MaxRequests == 0 {
+		return serve()
+	}
+	go func() {
+		if err := serve(); err != nil {
+			peer.Log().Debug("Failed to serve snap request", "code", code, "err", err)
+			if peer.Peer != nil {
+				peer.Disconnect(p2p.DiscSubprotocolError)
+			}
+		}
+	}()
+	return nil
+}
+
+// sendResponse sends back the response assembled by the query, measuring the serving time.
+func sendResponse(peer *Peer, code uint64, query func() (interface{}, error)) error {
+	start := time.Now()
+	res, err := query()
+	snapServeTimer.UpdateSince(start)
+	if err != nil {
+		return err
+	}
+	return p2p.Send(peer.rw, code, res)
+}
+
 // ServiceGetAccountRangeQuery assembles the response to an account range query.
 // It is exposed to allow external packages to test protocol behavior.
 func ServiceGetAccountRangeQuery(chain SnapshotReader, req *GetAccountRangePacket) ([]*AccountData, [][]byte) {
@@ -40,8 +40,13 @@ type testMsgRW struct {
 	writer func(msg p2p.Msg) error
 }
 
-func (rw *testMsgRW) ReadMsg() (p2p.Msg, error)  { return rw.reader() }
-func (rw *testMsgRW) WriteMsg(msg p2p.Msg) error { return nil }
+func (rw *testMsgRW) ReadMsg() (p2p.Msg, error) { return rw.reader() }
+func (rw *testMsgRW) WriteMsg(msg p2p.Msg) error {
+	if rw.writer != nil {
+		return rw.writer(msg)
+	}
+	return nil
+}
 
 type testDownloader struct{}
 
@@ -35,10 +35,18 @@ type Peer struct {
 	*p2p.Peer                   // The embedded P2P package peer
 	rw        p2p.MsgReadWriter // Input/output streams for snap
 	version   uint              // Protocol version negotiated
+	quota     *servingQuota     // Quota of the served requests, nil if unlimited
 
 	logger log.Logger // Contextual logger with the peer id injected
 }
 
+// PeerInfo represents a short summary of the `snap` sub-protocol metadata known
+// about a connected peer.
+type PeerInfo struct {
+	Version uint        `json:"version"`           // Snap protocol version negotiated
+	Serving *ServeStats `json:"serving,omitempty"` // Requests served to the peer within its quota
+}
+
 // NewPeer create a wrapper for a network connection and negotiated  protocol
 // version.
 func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
@@ -72,6 +80,16 @@ func (p *Peer) Version() uint {
 	return p.version
 }
 
+// Info gathers and returns the `snap` metadata known about the peer.
+func (p *Peer) Info() *PeerInfo {
+	info := &PeerInfo{Version: p.version}
+	if p.quota != nil {
+		stats := p.quota.Stats()
+		info.Serving = &stats
+	}
+	return info
+}
+
 // Log overrides the P2P logget with the higher level one containing only the id.
 func (p *Peer) Log() log.Logger {
 	return p.logger
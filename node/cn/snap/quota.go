@@ -0,0 +1,180 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kaiachain/kaia/common/mclock"
+)
+
+// ServeConfig is the quota of the snap requests served to each peer.
+type ServeConfig struct {
+	// BytesPerSec is the size of the responses served to a peer per second.
+	// The ranges are truncated once the peer has spent most of its budget,
+	// and empty once it has spent all. Zero disables the limit.
+	BytesPerSec uint64
+
+	// MaxRequests is the number of requests served concurrently to a peer.
+	// The requests beyond it are answered with empty responses. Zero serves
+	// the requests one at a time in their arrival order.
+	MaxRequests int
+}
+
+// ServeStats are the snap requests served to a peer.
+type ServeStats struct {
+	Requests  uint64 `json:"requests"`  // Requests served, including the truncated ones
+	Truncated uint64 `json:"truncated"` // Requests served with a range cut down by the byte budget
+	Rejected  uint64 `json:"rejected"`  // Requests answered with an empty response over the quota
+	Bytes     uint64 `json:"bytes"`     // Size of the served responses
+	InFlight  int    `json:"inFlight"`  // Requests being served
+}
+
+// servingQuota tracks the byte budget and the concurrent requests of a peer.
+// The budget is a token bucket refilled at BytesPerSec up to one second of it.
+type servingQuota struct {
+	config ServeConfig
+	now    func() mclock.AbsTime
+
+	lock    sync.Mutex
+	budget  float64 // Bytes the peer can be served now, negative if overspent
+	updated mclock.AbsTime
+	stats   ServeStats
+}
+
+func newServingQuota(config ServeConfig, now func() mclock.AbsTime) *servingQuota {
+	return &servingQuota{
+		config:  config,
+		now:     now,
+		budget:  float64(config.BytesPerSec),
+		updated: now(),
+	}
+}
+
+// refill adds the budget earned since the last update. The caller must hold the lock.
+func (q *servingQuota) refill() {
+	now := q.now()
+	elapsed := time.Duration(now - q.updated).Seconds()
+	q.updated = now
+
+	capacity := float64(q.config.BytesPerSec)
+	q.budget += elapsed * capacity
+	if q.budget > capacity {
+		q.budget = capacity
+	}
+}
+
+// acquire reserves the budget of a response of the requested bytes. It returns
+// the bytes the response may take, or false if the request must be answered
+// with an empty response.
+func (q *servingQuota) acquire(requested uint64) (uint64, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.config.MaxRequests > 0 && q.stats.InFlight >= q.config.MaxRequests {
+		q.stats.Rejected++
+		snapServeRejectedCounter.Inc(1)
+		return 0, false
+	}
+	limit := requested
+	if q.config.BytesPerSec > 0 {
+		q.refill()
+		if q.budget < 1 {
+			q.stats.Rejected++
+			snapServeRejectedCounter.Inc(1)
+			return 0, false
+		}
+		if available := uint64(q.budget); available < limit {
+			limit = available
+			q.stats.Truncated++
+			snapServeTruncatedCounter.Inc(1)
+		}
+		q.budget -= float64(limit)
+	}
+	q.stats.Requests++
+	q.stats.InFlight++
+	snapServeRequestCounter.Inc(1)
+	return limit, true
+}
+
+// release ends the request which reserved the limit and took the served bytes.
+// The unused budget is returned, and the bytes beyond the limit are owed.
+func (q *servingQuota) release(limit, served uint64) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.config.BytesPerSec > 0 {
+		q.refill()
+		q.budget += float64(limit) - float64(served)
+	}
+	q.stats.Bytes += served
+	q.stats.InFlight--
+	snapServeBytesMeter.Mark(int64(served))
+}
+
+// Stats returns the requests served so far.
+func (q *servingQuota) Stats() ServeStats {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.stats
+}
+
+// Server serves the snap peers within their quotas and keeps their serving stats.
+type Server struct {
+	config ServeConfig
+
+	lock  sync.RWMutex
+	peers map[string]*Peer
+}
+
+// NewServer creates a server applying the quota to each peer.
+func NewServer(config ServeConfig) *Server {
+	return &Server{
+		config: config,
+		peers:  make(map[string]*Peer),
+	}
+}
+
+// Handle is the callback invoked to manage the life cycle of a `snap` peer,
+// serving its requests within the quota. When this function terminates, the
+// peer is disconnected.
+func (s *Server) Handle(reader SnapshotReader, downloader SnapshotDownloader, peer *Peer) error {
+	peer.quota = newServingQuota(s.config, mclock.Now)
+
+	s.lock.Lock()
+	s.peers[peer.id] = peer
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.peers, peer.id)
+		s.lock.Unlock()
+	}()
+	return Handle(reader, downloader, peer)
+}
+
+// PeerInfo returns the `snap` metadata of the peer, or nil if the peer isn't handled.
+func (s *Server) PeerInfo(id string) *PeerInfo {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if peer, ok := s.peers[id]; ok {
+		return peer.Info()
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain/types/account"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/mclock"
+	"github.com/kaiachain/kaia/networks/p2p"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServingQuota_Budget(t *testing.T) {
+	var now mclock.AbsTime
+	q := newServingQuota(ServeConfig{BytesPerSec: 1000}, func() mclock.AbsTime { return now })
+
+	limit, ok := q.acquire(600)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(600), limit)
+
+	// The second request is truncated to the remaining budget.
+	limit, ok = q.acquire(600)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(400), limit)
+
+	_, ok = q.acquire(1)
+	assert.False(t, ok)
+
+	// The unused budget is returned when the request ends.
+	q.release(600, 300)
+	limit, ok = q.acquire(600)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(300), limit)
+	q.release(400, 400)
+	q.release(300, 300)
+
+	// The budget is refilled over time, up to one second of it.
+	now += mclock.AbsTime(250 * time.Millisecond)
+	limit, _ = q.acquire(1000)
+	assert.Equal(t, uint64(250), limit)
+	q.release(250, 250)
+	now += mclock.AbsTime(10 * time.Second)
+	limit, _ = q.acquire(2000)
+	assert.Equal(t, uint64(1000), limit)
+	q.release(1000, 1000)
+
+	assert.Equal(t, ServeStats{Requests: 5, Truncated: 4, Rejected: 1, Bytes: 2250}, q.Stats())
+}
+
+func TestServingQuota_MaxRequests(t *testing.T) {
+	q := newServingQuota(ServeConfig{MaxRequests: 2}, mclock.Now)
+
+	for i := 0; i < 2; i++ {
+		limit, ok := q.acquire(100)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(100), limit)
+	}
+	_, ok := q.acquire(100)
+	assert.False(t, ok)
+	assert.Equal(t, 2, q.Stats().InFlight)
+
+	q.release(100, 10)
+	_, ok = q.acquire(100)
+	assert.True(t, ok)
+	assert.Equal(t, ServeStats{Requests: 3, Rejected: 1, Bytes: 10, InFlight: 2}, q.Stats())
+}
+
+// quotaPeer returns a peer with the quota, requesting the whole account range
+// of the root and recording the responses.
+func quotaPeer(root common.Hash, config ServeConfig, responses chan<- p2p.Msg) *Peer {
+	rw := &testMsgRW{
+		reader: func() (p2p.Msg, error) {
+			return createMsg(GetAccountRangeMsg, &GetAccountRangePacket{
+				ID:    1,
+				Root:  root,
+				Limit: common.MaxHash,
+				Bytes: softResponseLimit,
+			})
+		},
+		writer: func(msg p2p.Msg) error {
+			responses <- msg
+			return nil
+		},
+	}
+	peer := NewFakePeer(1, common.BytesToHash([]byte{0x1}).String(), rw)
+	peer.quota = newServingQuota(config, mclock.Now)
+	return peer
+}
+
+func TestHandleMessage_ServingQuota(t *testing.T) {
+	var items []*testKV
+	for i := uint64(1); i <= 100; i++ {
+		acc, _ := genExternallyOwnedAccount(1, big.NewInt(1))
+		serializer := account.NewAccountSerializerWithAccount(acc)
+		bytes, _ := rlp.EncodeToBytes(serializer)
+		items = append(items, &testKV{key32(i), bytes})
+	}
+	reader, root := NewTestSnapshotReader(items)
+
+	serve := func(peer *Peer, responses <-chan p2p.Msg) *AccountRangePacket {
+		assert.NoError(t, HandleMessage(reader, &testDownloader{}, peer))
+		select {
+		case msg := <-responses:
+			assert.Equal(t, uint64(AccountRangeMsg), msg.Code)
+			res := new(AccountRangePacket)
+			assert.NoError(t, msg.Decode(res))
+			return res
+		case <-time.After(time.Second):
+			t.Fatal("no response")
+			return nil
+		}
+	}
+
+	// Within the quota, the whole range is served.
+	responses := make(chan p2p.Msg, 1)
+	peer := quotaPeer(root, ServeConfig{BytesPerSec: softResponseLimit}, responses)
+	res := serve(peer, responses)
+	assert.Len(t, res.Accounts, 100)
+
+	// With a small budget, the range is truncated and then empty.
+	peer = quotaPeer(root, ServeConfig{BytesPerSec: 500}, responses)
+	res = serve(peer, responses)
+	assert.NotEmpty(t, res.Accounts)
+	assert.Less(t, len(res.Accounts), 100)
+	res = serve(peer, responses)
+	assert.Equal(t, uint64(1), res.ID)
+	assert.Empty(t, res.Accounts)
+	assert.Empty(t, res.Proof)
+
+	stats := peer.Info().Serving
+	assert.Equal(t, uint64(1), stats.Requests)
+	assert.Equal(t, uint64(1), stats.Truncated)
+	assert.Equal(t, uint64(1), stats.Rejected)
+	assert.NotZero(t, stats.Bytes)
+
+	// With a limit of concurrent requests, the requests are served in the background.
+	peer = quotaPeer(root, ServeConfig{MaxRequests: 1}, responses)
+	res = serve(peer, responses)
+	assert.Len(t, res.Accounts, 100)
+	assert.Eventually(t, func() bool { return peer.Info().Serving.InFlight == 0 }, time.Second, 10*time.Millisecond)
+}
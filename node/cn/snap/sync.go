@@ -40,6 +40,7 @@ import (
 	"github.com/kaiachain/kaia/common/math"
 	"github.com/kaiachain/kaia/event"
 	"github.com/kaiachain/kaia/log"
+	"github.com/kaiachain/kaia/networks/p2p"
 	"github.com/kaiachain/kaia/networks/p2p/msgrate"
 	"github.com/kaiachain/kaia/rlp"
 	"github.com/kaiachain/kaia/storage/database"
@@ -350,8 +351,19 @@ type SyncProgress struct {
 type SyncPending struct {
 	TrienodeHeal uint64 // Number of state trie nodes pending
 	BytecodeHeal uint64 // Number of bytecodes pending
+
+	Stage     string  // Current stage of the sync, one of StageAccounts, StageStorage and StageHealing
+	Completed float64 // Estimated fraction of the account ranges (with their storage) downloaded
 }
 
+// Stages of a snap sync reported in SyncPending. The storage stage means that
+// only the storage ranges are being retrieved, while the accounts are done.
+const (
+	StageAccounts = "accounts"
+	StageStorage  = "storage"
+	StageHealing  = "healing"
+)
+
 // SyncPeer abstracts out the methods required for a peer to be synced against
 // with the goal of allowing the construction of mock peers without the full
 // blown networking.
@@ -379,6 +391,20 @@ type SyncPeer interface {
 	Log() log.Logger
 }
 
+// penalizer is implemented by the sync peers backed by a p2p peer, whose
+// misbehaviors lower the peer reputation.
+type penalizer interface {
+	Penalize(penalty int, reason string)
+}
+
+// penalizeSlowPeer lowers the reputation of a peer that failed to respond within
+// the timeout estimated from the message rates.
+func penalizeSlowPeer(peer SyncPeer) {
+	if p, ok := peer.(penalizer); ok {
+		p.Penalize(p2p.PenaltySlowResponse, "snap request timed out")
+	}
+}
+
 // Syncer is an Kaia account and storage trie syncer based on snapshots and
 // the snap protocol. It's purpose is to download all the accounts and storage
 // slots from remote peers and reassemble chunks of the state trie, on top of
@@ -421,7 +447,8 @@ type Syncer struct {
 	storageSynced  uint64             // Number of storage slots downloaded
 	storageBytes   common.StorageSize // Number of storage trie bytes persisted to disk
 
-	extProgress *SyncProgress // progress that can be exposed to external caller.
+	extProgress  *SyncProgress // progress that can be exposed to external caller.
+	extCompleted float64       // estimated fraction of the account ranges downloaded, exposed to external caller.
 
 	// Request tracking during healing phase
 	trienodeHealIdlers map[string]struct{} // Peers that aren't serving trie node requests
@@ -651,6 +678,7 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 			BytecodeHealSynced: s.bytecodeHealSynced,
 			BytecodeHealBytes:  s.bytecodeHealBytes,
 		}
+		s.extCompleted = s.completed()
 		s.lock.Unlock()
 		// Wait for something to happen
 		select {
@@ -810,11 +838,17 @@ func (s *Syncer) saveSyncStatus() {
 func (s *Syncer) Progress() (*SyncProgress, *SyncPending) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	pending := new(SyncPending)
+	pending := &SyncPending{Stage: StageAccounts, Completed: s.extCompleted}
 	if s.healer != nil {
 		pending.TrienodeHeal = uint64(len(s.healer.trieTasks))
 		pending.BytecodeHeal = uint64(len(s.healer.codeTasks))
 	}
+	switch {
+	case s.snapped:
+		pending.Stage, pending.Completed = StageHealing, 1
+	case len(s.accountReqs) == 0 && len(s.storageReqs) > 0:
+		pending.Stage = StageStorage
+	}
 	return s.extProgress, pending
 }
 
@@ -948,6 +982,7 @@ func (s *Syncer) assignAccountTasks(success chan *accountResponse, fail chan *ac
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Account range request timed out", "reqid", reqid)
 			s.rates.Update(idle, AccountRangeMsg, 0, 0)
+			penalizeSlowPeer(peer)
 			s.scheduleRevertAccountRequest(req)
 		})
 		s.accountReqs[reqid] = req
@@ -1059,6 +1094,7 @@ func (s *Syncer) assignBytecodeTasks(success chan *bytecodeResponse, fail chan *
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Bytecode request timed out", "reqid", reqid)
 			s.rates.Update(idle, ByteCodesMsg, 0, 0)
+			penalizeSlowPeer(peer)
 			s.scheduleRevertBytecodeRequest(req)
 		})
 		s.bytecodeReqs[reqid] = req
@@ -1206,6 +1242,7 @@ func (s *Syncer) assignStorageTasks(success chan *storageResponse, fail chan *st
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Storage request timed out", "reqid", reqid)
 			s.rates.Update(idle, StorageRangesMsg, 0, 0)
+			penalizeSlowPeer(peer)
 			s.scheduleRevertStorageRequest(req)
 		})
 		s.storageReqs[reqid] = req
@@ -1339,6 +1376,7 @@ func (s *Syncer) assignTrienodeHealTasks(success chan *trienodeHealResponse, fai
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Trienode heal request timed out", "reqid", reqid)
 			s.rates.Update(idle, TrieNodesMsg, 0, 0)
+			penalizeSlowPeer(peer)
 			s.scheduleRevertTrienodeHealRequest(req)
 		})
 		s.trienodeHealReqs[reqid] = req
@@ -1455,6 +1493,7 @@ func (s *Syncer) assignBytecodeHealTasks(success chan *bytecodeHealResponse, fai
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Bytecode heal request timed out", "reqid", reqid)
 			s.rates.Update(idle, ByteCodesMsg, 0, 0)
+			penalizeSlowPeer(peer)
 			s.scheduleRevertBytecodeHealRequest(req)
 		})
 		s.bytecodeHealReqs[reqid] = req
@@ -2797,6 +2836,22 @@ func (s *Syncer) report(force bool) {
 	s.reportHealProgress(force)
 }
 
+// completed estimates the fraction of the account ranges downloaded, along with
+// their storage slots and bytecodes, assuming the accounts are evenly distributed.
+func (s *Syncer) completed() float64 {
+	if len(s.tasks) == 0 {
+		return 1
+	}
+	accountGaps := new(big.Int)
+	for _, task := range s.tasks {
+		if gap := new(big.Int).Sub(task.Last.Big(), task.Next.Big()); gap.Sign() > 0 {
+			accountGaps.Add(accountGaps, gap)
+		}
+	}
+	fills, _ := new(big.Float).Quo(new(big.Float).SetInt(new(big.Int).Sub(hashSpace, accountGaps)), new(big.Float).SetInt(hashSpace)).Float64()
+	return fills
+}
+
 // reportSyncProgress calculates various status reports and provides it to the user.
 func (s *Syncer) reportSyncProgress(force bool) {
 	// Don't report all the events, just occasionally
@@ -21,6 +21,7 @@
 package cn
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -59,7 +60,7 @@ var noopReleaser = tracers.StateReleaseFunc(func() {})
 //   - preferDisk: this arg can be used by the caller to signal that even though the 'base' is
 //     provided, it would be preferable to start from a fresh state, if we have it
 //     on disk.
-func (cn *CN) stateAtBlock(block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (statedb *state.StateDB, release tracers.StateReleaseFunc, err error) {
+func (cn *CN) stateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (statedb *state.StateDB, release tracers.StateReleaseFunc, err error) {
 	var (
 		current  *types.Block
 		database state.Database
@@ -78,6 +79,15 @@ func (cn *CN) stateAtBlock(block *types.Block, reexec uint64, base *state.StateD
 				statedb.Database().TrieDB().Dereference(block.Root())
 			}, nil
 		}
+		// Regenerate the state over the shared regeneration cache, so that the
+		// states of the same or nearby blocks can be reused by other requests.
+		if base == nil && cn.stateRegenerator != nil {
+			var timeLimit time.Duration
+			if cn.config.DisableUnsafeDebug {
+				timeLimit = cn.config.StateRegenerationTimeLimit
+			}
+			return cn.stateRegenerator.StateAt(ctx, block, reexec, timeLimit)
+		}
 	}
 	// The state is both for reading and writing, or it's unavailable in disk,
 	// try to construct/recover the state over an ephemeral trie.Database for
@@ -198,7 +208,7 @@ func (cn *CN) stateAtBlock(block *types.Block, reexec uint64, base *state.StateD
 }
 
 // stateAtTransaction returns the execution environment of a certain transaction.
-func (cn *CN) stateAtTransaction(block *types.Block, txIndex int, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (blockchain.Message, vm.BlockContext, vm.TxContext, *state.StateDB, tracers.StateReleaseFunc, error) {
+func (cn *CN) stateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (blockchain.Message, vm.BlockContext, vm.TxContext, *state.StateDB, tracers.StateReleaseFunc, error) {
 	// Short circuit if it's genesis block.
 	if block.NumberU64() == 0 {
 		return nil, vm.BlockContext{}, vm.TxContext{}, nil, nil, errors.New("no transaction in genesis")
@@ -210,7 +220,7 @@ func (cn *CN) stateAtTransaction(block *types.Block, txIndex int, reexec uint64,
 	}
 	// Lookup the statedb of parent block from the live database,
 	// otherwise regenerate it on the flight.
-	statedb, release, err := cn.stateAtBlock(parent, reexec, base, readOnly, preferDisk)
+	statedb, release, err := cn.stateAtBlock(ctx, parent, reexec, base, readOnly, preferDisk)
 	if err != nil {
 		return nil, vm.BlockContext{}, vm.TxContext{}, nil, nil, err
 	}
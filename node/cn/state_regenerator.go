@@ -0,0 +1,389 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package cn
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/node/cn/tracers"
+	statedb2 "github.com/kaiachain/kaia/storage/statedb"
+)
+
+const (
+	// regeneratedStateHoldLimit bounds how long a regenerated state handed out to an
+	// API request is kept referenced, in case the request context is never done.
+	regeneratedStateHoldLimit = time.Minute
+
+	// defaultStateRegenTimeLimit is the regeneration time limit of the state APIs
+	// if no limit is configured.
+	defaultStateRegenTimeLimit = time.Minute
+)
+
+// regenChain is the subset of the blockchain used to replay historical blocks.
+type regenChain interface {
+	GetBlock(hash common.Hash, number uint64) *types.Block
+	GetBlockByNumber(number uint64) *types.Block
+	Processor() blockchain.Processor
+}
+
+// statePreloader remembers the staking info of replayed blocks,
+// which is needed by engine.Finalize() post-Kaia.
+type statePreloader interface {
+	AllocPreloadRef() uint64
+	FreePreloadRef(refId uint64)
+	PreloadFromState(refId uint64, header *types.Header, statedb *state.StateDB) error
+}
+
+// regenEntry is a regenerated state root pinned in the shared trie database.
+type regenEntry struct {
+	root   common.Hash
+	number uint64
+	refs   int           // number of outstanding StateDBs handed out on top of the root
+	elem   *list.Element // position in the LRU list
+}
+
+// regenKey identifies the regenerations that can be shared. Requests for the same root
+// with different limits are not coalesced, since the result may depend on the limits.
+type regenKey struct {
+	root      common.Hash
+	reexec    uint64
+	timeLimit time.Duration
+}
+
+// regenTask is an in-flight regeneration that identical requests wait for.
+type regenTask struct {
+	root    common.Hash
+	done    chan struct{}
+	waiters int         // number of waiting requests, guarded by the lock
+	entry   *regenEntry // acquired once per waiter, nil if the state is on disk
+	err     error
+}
+
+// stateRegenerator regenerates historical states by re-executing blocks on top of
+// the nearest available state. Regenerated roots, including the intermediate ones,
+// are kept in a single ephemeral trie database isolated from the live one, so that
+// subsequent requests for the same or nearby blocks can start from them.
+//
+// Every StateDB handed out holds a reference to its root until released. Unreferenced
+// roots are dereferenced in LRU order once the trie database exceeds the memory budget.
+// Concurrent requests for the same root and limits are coalesced into a single regeneration.
+type stateRegenerator struct {
+	chain     regenChain
+	preloader statePreloader
+	database  state.Database
+	budget    common.StorageSize
+
+	mu       sync.Mutex
+	entries  map[common.Hash]*regenEntry
+	lru      *list.List // front is the most recently used
+	inflight map[regenKey]*regenTask
+}
+
+func newStateRegenerator(chain regenChain, preloader statePreloader, database state.Database, budget common.StorageSize) *stateRegenerator {
+	return &stateRegenerator{
+		chain:     chain,
+		preloader: preloader,
+		database:  database,
+		budget:    budget,
+		entries:   make(map[common.Hash]*regenEntry),
+		lru:       list.New(),
+		inflight:  make(map[regenKey]*regenTask),
+	}
+}
+
+// StateAt returns the state of the given block, regenerating it by re-executing at most
+// reexec blocks within the time limit (zero means no limit) if it is neither cached nor
+// available on disk. The regeneration stops once the context is done. The returned release
+// function must be called once the state is no longer needed.
+func (s *stateRegenerator) StateAt(ctx context.Context, block *types.Block, reexec uint64, timeLimit time.Duration) (*state.StateDB, tracers.StateReleaseFunc, error) {
+	root := block.Root()
+	key := regenKey{root: root, reexec: reexec, timeLimit: timeLimit}
+	s.mu.Lock()
+	if entry := s.acquireLocked(root); entry != nil {
+		s.mu.Unlock()
+		return s.open(entry)
+	}
+	if task, ok := s.inflight[key]; ok {
+		// The same state is being regenerated by another request; wait for its result.
+		task.waiters++
+		s.mu.Unlock()
+		statedb, release, err := s.wait(ctx, task)
+		if err != nil && ctx.Err() == nil && isContextErr(err) {
+			// The regenerating request is done while this one is not; regenerate on its own.
+			return s.StateAt(ctx, block, reexec, timeLimit)
+		}
+		return statedb, release, err
+	}
+	task := &regenTask{root: root, done: make(chan struct{})}
+	s.inflight[key] = task
+	s.mu.Unlock()
+
+	statedb, entry, err := s.regenerate(ctx, block, reexec, timeLimit)
+
+	// Hand the result over to the waiters, acquiring the entry once for each of them.
+	s.mu.Lock()
+	delete(s.inflight, key)
+	task.entry, task.err = entry, err
+	if entry != nil {
+		entry.refs += task.waiters
+	}
+	close(task.done)
+	s.mu.Unlock()
+
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry == nil {
+		return statedb, noopReleaser, nil
+	}
+	return statedb, func() { s.release(entry) }, nil
+}
+
+// wait returns the result of the in-flight regeneration the caller is counted as a waiter of.
+func (s *stateRegenerator) wait(ctx context.Context, task *regenTask) (*state.StateDB, tracers.StateReleaseFunc, error) {
+	select {
+	case <-task.done:
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-task.done:
+			// The entry has been acquired for this waiter already.
+			if task.entry != nil {
+				task.entry.refs--
+				s.evictLocked()
+			}
+		default:
+			task.waiters--
+		}
+		s.mu.Unlock()
+		return nil, nil, ctx.Err()
+	}
+	if task.err != nil {
+		return nil, nil, task.err
+	}
+	if task.entry == nil {
+		statedb, err := state.New(task.root, s.database, nil, nil)
+		return statedb, noopReleaser, err
+	}
+	return s.open(task.entry)
+}
+
+// isContextErr tells whether the error is caused by a done context.
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// regenerate finds the nearest ancestor whose state is cached or on disk and
+// re-executes the blocks on top of it up to the given block.
+// The returned entry is acquired once, or nil if the state is on disk.
+func (s *stateRegenerator) regenerate(ctx context.Context, block *types.Block, reexec uint64, timeLimit time.Duration) (*state.StateDB, *regenEntry, error) {
+	var (
+		current = block
+		origin  = block.NumberU64()
+		held    *regenEntry // the entry of the current state, if any
+		statedb *state.StateDB
+		err     error
+	)
+	for i := uint64(0); ; i++ {
+		s.mu.Lock()
+		held = s.acquireLocked(current.Root())
+		s.mu.Unlock()
+		if held != nil {
+			statedb, err = state.New(current.Root(), s.database, nil, nil)
+			if err != nil {
+				s.release(held)
+			}
+			break
+		}
+		if statedb, err = state.New(current.Root(), s.database, nil, nil); err == nil {
+			break
+		}
+		if i >= reexec {
+			break
+		}
+		if current.NumberU64() == 0 {
+			return nil, nil, errors.New("genesis state is missing")
+		}
+		parent := s.chain.GetBlock(current.ParentHash(), current.NumberU64()-1)
+		if parent == nil {
+			return nil, nil, fmt.Errorf("missing block %v %d", current.ParentHash(), current.NumberU64()-1)
+		}
+		current = parent
+	}
+	if err != nil {
+		switch err.(type) {
+		case *statedb2.MissingNodeError:
+			return nil, nil, fmt.Errorf("historical state unavailable. tried regeneration but not possible, possibly due to state migration/pruning or global state saving interval is bigger than reexec value (reexec=%d)", reexec)
+		default:
+			return nil, nil, err
+		}
+	}
+	if current.NumberU64() == origin {
+		// The state is already available, either cached or on disk.
+		return statedb, held, nil
+	}
+
+	var (
+		start  = time.Now()
+		logged time.Time
+	)
+	preloadRef := s.preloader.AllocPreloadRef()
+	defer s.preloader.FreePreloadRef(preloadRef)
+
+	fail := func(err error) (*state.StateDB, *regenEntry, error) {
+		if held != nil {
+			s.release(held)
+		}
+		return nil, nil, err
+	}
+	for current.NumberU64() < origin {
+		// Print progress logs if long enough time elapsed
+		if time.Since(logged) > 8*time.Second {
+			logger.Info("Regenerating historical state", "block", current.NumberU64()+1, "target", origin, "remaining", origin-current.NumberU64()-1, "elapsed", time.Since(start))
+			logged = time.Now()
+		}
+		// Quit the state regeneration if time limit exceeds or the request is done
+		if timeLimit > 0 && time.Since(start) > timeLimit {
+			return fail(fmt.Errorf("this request has queried old states too long since it exceeds the state regeneration time limit(%s)", timeLimit.String()))
+		}
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+		// Make StakingModule remember the current block state. Needed for next block's engine.Finalize() post-Kaia.
+		s.preloader.PreloadFromState(preloadRef, current.Header(), statedb)
+		// Retrieve the next block to regenerate and process it
+		next := current.NumberU64() + 1
+		if current = s.chain.GetBlockByNumber(next); current == nil {
+			return fail(fmt.Errorf("block #%d not found", next))
+		}
+		if _, _, _, _, _, err := s.chain.Processor().Process(current, statedb, vm.Config{}); err != nil {
+			return fail(fmt.Errorf("processing block %d failed: %v", current.NumberU64(), err))
+		}
+		// Finalize the state so any modifications are written to the trie
+		root, err := statedb.Commit(true)
+		if err != nil {
+			return fail(err)
+		}
+		if current.Header().Root != root {
+			err = fmt.Errorf("mistmatching state root block expected %x reexecuted %x", current.Header().Root, root)
+			// Logging here because something went wrong when the state roots disagree even if the execution was successful.
+			logger.Error("incorrectly regenerated historical state", "block", current.NumberU64(), "err", err)
+			return fail(fmt.Errorf("incorrectly regenerated historical state for block %d: %v", current.NumberU64(), err))
+		}
+		// Pin the new root before unpinning the previous one, as they share most of the nodes.
+		entry := s.insert(root, current.NumberU64())
+		if held != nil {
+			s.release(held)
+		}
+		held = entry
+		if statedb, err = state.New(root, s.database, nil, nil); err != nil {
+			return fail(fmt.Errorf("state reset after block %d failed: %v", current.NumberU64(), err))
+		}
+	}
+	nodes, _, imgs := s.database.TrieDB().Size()
+	logger.Info("Historical state regenerated", "block", current.NumberU64(), "elapsed", time.Since(start), "nodes", nodes, "preimages", imgs)
+
+	return statedb, held, nil
+}
+
+// open creates a fresh StateDB on top of an acquired entry.
+func (s *stateRegenerator) open(entry *regenEntry) (*state.StateDB, tracers.StateReleaseFunc, error) {
+	statedb, err := state.New(entry.root, s.database, nil, nil)
+	if err != nil {
+		s.release(entry)
+		return nil, nil, err
+	}
+	return statedb, func() { s.release(entry) }, nil
+}
+
+// acquireLocked returns the cached entry of the given root with its reference
+// count increased, or nil if the root is not cached. The lock must be held.
+func (s *stateRegenerator) acquireLocked(root common.Hash) *regenEntry {
+	entry, ok := s.entries[root]
+	if !ok {
+		return nil
+	}
+	entry.refs++
+	s.lru.MoveToFront(entry.elem)
+	return entry
+}
+
+// insert caches a freshly committed root and returns its entry acquired once.
+func (s *stateRegenerator) insert(root common.Hash, number uint64) *regenEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry := s.acquireLocked(root); entry != nil {
+		return entry
+	}
+	s.database.TrieDB().ReferenceRoot(root)
+	entry := &regenEntry{root: root, number: number, refs: 1}
+	entry.elem = s.lru.PushFront(entry)
+	s.entries[root] = entry
+	return entry
+}
+
+// release drops a reference of the entry and evicts unreferenced entries
+// if the memory budget is exceeded.
+func (s *stateRegenerator) release(entry *regenEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.refs <= 0 {
+		logger.Error("Released a regenerated state more than acquired", "root", entry.root, "number", entry.number)
+		return
+	}
+	entry.refs--
+	s.evictLocked()
+}
+
+// evictLocked dereferences the least recently used unreferenced entries until
+// the trie database fits in the memory budget. The lock must be held.
+func (s *stateRegenerator) evictLocked() {
+	elem := s.lru.Back()
+	for elem != nil {
+		if size, _, _ := s.database.TrieDB().Size(); size <= s.budget {
+			return
+		}
+		prev := elem.Prev()
+		if entry := elem.Value.(*regenEntry); entry.refs == 0 {
+			s.lru.Remove(elem)
+			delete(s.entries, entry.root)
+			s.database.TrieDB().Dereference(entry.root)
+		}
+		elem = prev
+	}
+}
+
+// Size returns the number of cached roots and the memory used by the trie database.
+func (s *stateRegenerator) Size() (int, common.StorageSize) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size, _, _ := s.database.TrieDB().Size()
+	return len(s.entries), size
+}
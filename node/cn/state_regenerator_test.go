@@ -0,0 +1,352 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package cn
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus/gxhash"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/networks/rpc"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingChain counts the blocks processed during regeneration.
+// If gate is set, processing blocks until it is closed.
+type countingChain struct {
+	*blockchain.BlockChain
+	processed atomic.Int64
+	gate      chan struct{}
+}
+
+func (c *countingChain) Processor() blockchain.Processor { return c }
+
+func (c *countingChain) Process(block *types.Block, stateDB *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, []*vm.InternalTxTrace, blockchain.ProcessStats, error) {
+	c.processed.Add(1)
+	if c.gate != nil {
+		<-c.gate
+	}
+	return c.BlockChain.Processor().Process(block, stateDB, cfg)
+}
+
+type noopPreloader struct{}
+
+func (noopPreloader) AllocPreloadRef() uint64                                      { return 0 }
+func (noopPreloader) FreePreloadRef(uint64)                                        {}
+func (noopPreloader) PreloadFromState(uint64, *types.Header, *state.StateDB) error { return nil }
+
+// newRegenTestChain creates a non-archive chain of n blocks transferring 1 kei per block,
+// so that only the genesis state is available on disk.
+func newRegenTestChain(t *testing.T, n int) (*countingChain, database.DBManager, common.Address) {
+	var (
+		key, _  = crypto.GenerateKey()
+		from    = crypto.PubkeyToAddress(key.PublicKey)
+		to      = common.HexToAddress("0x1234")
+		config  = params.TestChainConfig
+		engine  = gxhash.NewFaker()
+		db      = database.NewMemoryDBManager()
+		gendb   = database.NewMemoryDBManager()
+		genesis = &blockchain.Genesis{Config: config, Alloc: blockchain.GenesisAlloc{from: {Balance: big.NewInt(params.KAIA)}}}
+		signer  = types.LatestSignerForChainID(config.ChainID)
+	)
+	blocks, _ := blockchain.GenerateChain(config, genesis.MustCommit(gendb), engine, gendb, n, func(i int, b *blockchain.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(from), to, big.NewInt(1), params.TxGas, big.NewInt(0), nil), signer, key)
+		require.NoError(t, err)
+		b.AddTx(tx)
+	})
+	genesis.MustCommit(db)
+	chain, err := blockchain.NewBlockChain(db, nil, config, engine, vm.Config{})
+	require.NoError(t, err)
+	t.Cleanup(chain.Stop)
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+	return &countingChain{BlockChain: chain}, db, to
+}
+
+func newTestStateRegenerator(chain *countingChain, db database.DBManager, budget common.StorageSize) *stateRegenerator {
+	stateDB := state.NewDatabaseWithExistingCache(db, chain.StateCache().TrieDB().TrieNodeCache())
+	return newStateRegenerator(chain, noopPreloader{}, stateDB, budget)
+}
+
+func TestStateRegenerator_StateAt(t *testing.T) {
+	chain, db, to := newRegenTestChain(t, 10)
+	regen := newTestStateRegenerator(chain, db, 256*1024*1024)
+
+	// The state of block 5 is not on disk, so it is regenerated from the genesis.
+	block := chain.GetBlockByNumber(5)
+	_, err := state.New(block.Root(), regen.database, nil, nil)
+	require.Error(t, err)
+
+	statedb, release, err := regen.StateAt(context.Background(), block, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), statedb.GetBalance(to))
+	assert.Equal(t, int64(5), chain.processed.Load())
+
+	// Intermediate roots are cached as well.
+	entries, _ := regen.Size()
+	assert.Equal(t, 5, entries)
+	assert.Equal(t, 1, regen.entries[block.Root()].refs)
+	release()
+	assert.Equal(t, 0, regen.entries[block.Root()].refs)
+
+	// A cached state is served without re-execution.
+	statedb, release, err = regen.StateAt(context.Background(), chain.GetBlockByNumber(3), 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(3), statedb.GetBalance(to))
+	assert.Equal(t, int64(5), chain.processed.Load())
+	release()
+
+	// A later state starts from the nearest cached ancestor, within a small reexec.
+	statedb, release, err = regen.StateAt(context.Background(), chain.GetBlockByNumber(8), 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(8), statedb.GetBalance(to))
+	assert.Equal(t, int64(8), chain.processed.Load())
+	release()
+
+	// The state on disk is returned as is.
+	statedb, release, err = regen.StateAt(context.Background(), chain.Genesis(), 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), statedb.GetBalance(to))
+	release()
+}
+
+func TestStateRegenerator_Reexec(t *testing.T) {
+	chain, db, _ := newRegenTestChain(t, 10)
+	regen := newTestStateRegenerator(chain, db, 256*1024*1024)
+
+	_, _, err := regen.StateAt(context.Background(), chain.GetBlockByNumber(5), 4, 0)
+	assert.ErrorContains(t, err, "historical state unavailable")
+
+	_, release, err := regen.StateAt(context.Background(), chain.GetBlockByNumber(5), 5, 0)
+	require.NoError(t, err)
+	release()
+}
+
+func TestStateRegenerator_Abort(t *testing.T) {
+	chain, db, _ := newRegenTestChain(t, 10)
+	regen := newTestStateRegenerator(chain, db, 256*1024*1024)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := regen.StateAt(ctx, chain.GetBlockByNumber(5), 10, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, _, err = regen.StateAt(context.Background(), chain.GetBlockByNumber(5), 10, time.Nanosecond)
+	assert.ErrorContains(t, err, "time limit")
+
+	// Nothing is left referenced by the aborted regenerations.
+	for _, entry := range regen.entries {
+		assert.Equal(t, 0, entry.refs)
+	}
+	assert.Empty(t, regen.inflight)
+}
+
+func TestStateRegenerator_Evict(t *testing.T) {
+	chain, db, to := newRegenTestChain(t, 10)
+	// Nothing fits in the budget, so every unreferenced root is evicted.
+	regen := newTestStateRegenerator(chain, db, 1)
+
+	statedb, release, err := regen.StateAt(context.Background(), chain.GetBlockByNumber(5), 10, 0)
+	require.NoError(t, err)
+	entries, _ := regen.Size()
+	assert.Equal(t, 1, entries)
+
+	// The referenced state remains readable.
+	assert.Equal(t, big.NewInt(5), statedb.GetBalance(to))
+
+	release()
+	entries, size := regen.Size()
+	assert.Equal(t, 0, entries)
+	assert.Equal(t, common.StorageSize(0), size)
+}
+
+func TestStateRegenerator_Coalesce(t *testing.T) {
+	chain, db, to := newRegenTestChain(t, 10)
+	regen := newTestStateRegenerator(chain, db, 256*1024*1024)
+
+	var (
+		block    = chain.GetBlockByNumber(10)
+		wg       sync.WaitGroup
+		releases = make([]func(), 8)
+	)
+	for i := range releases {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statedb, release, err := regen.StateAt(context.Background(), block, 10, 0)
+			if assert.NoError(t, err) {
+				assert.Equal(t, big.NewInt(10), statedb.GetBalance(to))
+				releases[i] = release
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int64(10), chain.processed.Load())
+	assert.Equal(t, len(releases), regen.entries[block.Root()].refs)
+
+	for _, release := range releases {
+		release()
+	}
+	assert.Equal(t, 0, regen.entries[block.Root()].refs)
+}
+
+func TestStateRegenerator_Wait(t *testing.T) {
+	chain, db, to := newRegenTestChain(t, 10)
+	chain.gate = make(chan struct{})
+	regen := newTestStateRegenerator(chain, db, 256*1024*1024)
+
+	var (
+		block   = chain.GetBlockByNumber(5)
+		results = make(chan error, 2)
+		waiters = func() int {
+			regen.mu.Lock()
+			defer regen.mu.Unlock()
+			if task := regen.inflight[regenKey{root: block.Root(), reexec: 10}]; task != nil {
+				return task.waiters
+			}
+			return -1
+		}
+		stateAt = func(ctx context.Context) {
+			statedb, release, err := regen.StateAt(ctx, block, 10, 0)
+			if err == nil {
+				assert.Equal(t, big.NewInt(5), statedb.GetBalance(to))
+				release()
+			}
+			results <- err
+		}
+	)
+	go stateAt(context.Background())
+	require.Eventually(t, func() bool { return waiters() == 0 }, time.Second, time.Millisecond)
+
+	// A waiter leaving early is not counted anymore.
+	ctx, cancel := context.WithCancel(context.Background())
+	go stateAt(ctx)
+	require.Eventually(t, func() bool { return waiters() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-results, context.Canceled)
+	assert.Equal(t, 0, waiters())
+
+	// A waiter gets the result of the regeneration without starting another one.
+	go stateAt(context.Background())
+	require.Eventually(t, func() bool { return waiters() == 1 }, time.Second, time.Millisecond)
+	close(chain.gate)
+	assert.NoError(t, <-results)
+	assert.NoError(t, <-results)
+	assert.Equal(t, int64(5), chain.processed.Load())
+	assert.Equal(t, 0, regen.entries[block.Root()].refs)
+}
+
+func TestStateRegenerator_WaitLimits(t *testing.T) {
+	chain, db, to := newRegenTestChain(t, 10)
+	chain.gate = make(chan struct{})
+	regen := newTestStateRegenerator(chain, db, 256*1024*1024)
+
+	var (
+		block    = chain.GetBlockByNumber(5)
+		results  = make(chan error, 3)
+		inflight = func() (int, int) {
+			regen.mu.Lock()
+			defer regen.mu.Unlock()
+			waiters := 0
+			for _, task := range regen.inflight {
+				waiters += task.waiters
+			}
+			return len(regen.inflight), waiters
+		}
+		stateAt = func(ctx context.Context, reexec uint64) {
+			statedb, release, err := regen.StateAt(ctx, block, reexec, 0)
+			if err == nil {
+				assert.Equal(t, big.NewInt(5), statedb.GetBalance(to))
+				release()
+			}
+			results <- err
+		}
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	go stateAt(ctx, 10)
+	require.Eventually(t, func() bool { tasks, _ := inflight(); return tasks == 1 }, time.Second, time.Millisecond)
+
+	// A request with other limits doesn't wait for the regeneration.
+	go stateAt(context.Background(), 20)
+	require.Eventually(t, func() bool { tasks, waiters := inflight(); return tasks == 2 && waiters == 0 }, time.Second, time.Millisecond)
+
+	// A waiter regenerates the state on its own if the regenerating request is done.
+	go stateAt(context.Background(), 10)
+	require.Eventually(t, func() bool { _, waiters := inflight(); return waiters == 1 }, time.Second, time.Millisecond)
+	cancel()
+	close(chain.gate)
+
+	var errs []error
+	for i := 0; i < 3; i++ {
+		if err := <-results; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], context.Canceled)
+	assert.Empty(t, regen.inflight)
+	for _, entry := range regen.entries {
+		assert.Equal(t, 0, entry.refs)
+	}
+}
+
+func TestCNAPIBackend_StateAndHeaderByNumber_Regenerate(t *testing.T) {
+	chain, db, to := newRegenTestChain(t, 10)
+	regen := newTestStateRegenerator(chain, db, 256*1024*1024)
+	cn := &CN{blockchain: chain.BlockChain, config: &Config{}, stateRegenerator: regen}
+	api := &CNAPIBackend{cn: cn}
+
+	// Pruned states are not served if the regeneration is disabled.
+	chain.StateCache().TrieDB().Dereference(chain.GetBlockByNumber(5).Root())
+	_, _, err := api.StateAndHeaderByNumber(context.Background(), rpc.BlockNumber(5))
+	require.Error(t, err)
+
+	cn.config.StateRegenReexec = 10
+	ctx, cancel := context.WithCancel(context.Background())
+	statedb, header, err := api.StateAndHeaderByNumber(ctx, rpc.BlockNumber(5))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), header.Number.Uint64())
+	assert.Equal(t, big.NewInt(5), statedb.GetBalance(to))
+	assert.Equal(t, 1, regen.entries[header.Root].refs)
+
+	// The regenerated state is released once the request is done.
+	cancel()
+	assert.Eventually(t, func() bool {
+		regen.mu.Lock()
+		defer regen.mu.Unlock()
+		return regen.entries[header.Root].refs == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// The regeneration is time limited regardless of the unsafe debug option.
+	cn.config.StateRegenerationTimeLimit = time.Nanosecond
+	chain.StateCache().TrieDB().Dereference(chain.GetBlockByNumber(8).Root())
+	_, _, err = api.StateAndHeaderByNumber(context.Background(), rpc.BlockNumber(8))
+	assert.Error(t, err)
+}
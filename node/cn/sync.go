@@ -141,6 +141,22 @@ func (pm *ProtocolManager) txsyncLoop() {
 	}
 }
 
+// txFetchLoop periodically requests the announced transactions whose fetch timed out
+// or was held by the rate limit.
+func (pm *ProtocolManager) txFetchLoop() {
+	ticker := time.NewTicker(txFetchTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pm.requestPooledTxs(nil, pm.txFetcher.Expire())
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
 // syncer is responsible for periodically synchronising with the network, both
 // downloading hashes and blocks as well as handling the announcement handler.
 func (pm *ProtocolManager) syncer() {
@@ -37,6 +37,7 @@ import (
 	kaiaapi "github.com/kaiachain/kaia/api"
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
@@ -73,6 +74,10 @@ const (
 	// fastCallTracer is the go-version callTracer which is lighter and faster than
 	// Javascript version.
 	fastCallTracer = "fastCallTracer"
+
+	// profileTracer is the go-version tracer which aggregates gas, computation cost
+	// and wall time per opcode, precompiled contract and contract function.
+	profileTracer = "profileTracer"
 )
 
 var (
@@ -913,7 +918,7 @@ func (api *CommonAPI) TraceCall(ctx context.Context, args kaiaapi.CallArgs, bloc
 	}
 
 	// Add gas fee to sender for estimating gasLimit/computing cost or calling a function by insufficient balance sender.
-	statedb.AddBalance(msg.ValidatedSender(), new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), basefee))
+	statedb.AddBalance(msg.ValidatedSender(), new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), basefee), tracing.BalanceChangeUnspecified)
 
 	txCtx := blockchain.NewEVMTxContext(msg, block.Header(), api.backend.ChainConfig())
 	blockCtx := blockchain.NewEVMBlockContext(block.Header(), newChainContext(ctx, api.backend), nil)
@@ -942,6 +947,8 @@ func (api *CommonAPI) traceTx(ctx context.Context, message blockchain.Message, b
 
 		if *config.Tracer == "fastCallTracer" || *config.Tracer == "callTracer" {
 			tracer = vm.NewCallTracer()
+		} else if *config.Tracer == profileTracer {
+			tracer = vm.NewProfileTracer()
 		} else {
 			// Construct the JavaScript tracer to execute with
 			if tracer, err = New(*config.Tracer, new(Context), api.unsafeTrace); err != nil {
@@ -960,6 +967,8 @@ func (api *CommonAPI) traceTx(ctx context.Context, message blockchain.Message, b
 					t.Stop(errors.New("execution timeout"))
 				case *vm.CallTracer:
 					t.Stop(errors.New("execution timeout"))
+				case *vm.ProfileTracer:
+					t.Stop(errors.New("execution timeout"))
 				default:
 					logger.Warn("unknown tracer type", "type", reflect.TypeOf(t).String())
 				}
@@ -1006,6 +1015,8 @@ func (api *CommonAPI) traceTx(ctx context.Context, message blockchain.Message, b
 		return tracer.GetResult()
 	case *vm.CallTracer:
 		return tracer.GetResult()
+	case *vm.ProfileTracer:
+		return tracer.GetResult()
 
 	default:
 		panic(fmt.Sprintf("bad tracer type %T", tracer))
@@ -23,5 +23,6 @@ Source Files
   - tracer.go  : implementation of Tracer
   - tracers.go : provides managing functions of tracers
   - api.go     : provides private debug API related to trace chain, block and state
+  - profile.go : provides private debug API profiling the cost of a transaction and block
 */
 package tracers
@@ -0,0 +1,77 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package live contains the live tracers, which observe every state change of
+// the blocks imported by the node. A live tracer is enabled with the --vmtrace flag
+// and configured with the JSON given by --vmtrace.jsonconfig.
+//
+// Additional tracers are plugged in by registering their constructor with
+// Register from the init function of a package linked into the node.
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kaiachain/kaia/blockchain/tracing"
+)
+
+// Constructor creates the hooks of a live tracer from its JSON configuration.
+type Constructor func(config json.RawMessage) (*tracing.Hooks, error)
+
+var (
+	mu           sync.RWMutex
+	constructors = make(map[string]Constructor)
+)
+
+// Register makes a live tracer available by the given name.
+// It panics if a tracer is already registered by the name.
+func Register(name string, ctor Constructor) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := constructors[name]; ok {
+		panic(fmt.Sprintf("live tracer %q is already registered", name))
+	}
+	constructors[name] = ctor
+}
+
+// New creates the live tracer registered by the given name.
+func New(name string, config json.RawMessage) (*tracing.Hooks, error) {
+	mu.RLock()
+	ctor, ok := constructors[name]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown live tracer %q, available: %v", name, Names())
+	}
+	return ctor(config)
+}
+
+// Names returns the sorted names of the registered live tracers.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(constructors))
+	for name := range constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
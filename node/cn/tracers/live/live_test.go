@@ -0,0 +1,83 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/tracing"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	assert.Contains(t, Names(), "stateStream")
+	assert.Panics(t, func() { Register("stateStream", newStateStream) })
+
+	_, err := New("unknown", nil)
+	assert.Error(t, err)
+
+	_, err = New("stateStream", nil)
+	assert.Error(t, err, "path is required")
+
+	hooks, err := New("stateStream", json.RawMessage(`{"path":"`+filepath.Join(t.TempDir(), "state.jsonl")+`"}`))
+	require.NoError(t, err)
+	assert.NotNil(t, hooks.OnBalanceChange)
+}
+
+func TestStateStream(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		hooks = newStateStreamWithWriter(&buf).hooks()
+		addr  = common.HexToAddress("0x1000")
+		tx    = types.NewTransaction(0, addr, big.NewInt(1), 21000, big.NewInt(1), nil)
+		block = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(7)})
+	)
+	hooks.OnBlockStart(block)
+	hooks.OnTxStart(tx)
+	hooks.OnBalanceChange(addr, big.NewInt(0), big.NewInt(1), tracing.BalanceChangeTransfer)
+	hooks.OnNonceChange(addr, 0, 1)
+	hooks.OnStorageChange(addr, common.Hash{1}, common.Hash{}, common.Hash{2})
+	hooks.OnTxEnd(&types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash(), GasUsed: 21000}, nil)
+	hooks.OnBlockEnd(nil)
+
+	var events []map[string]interface{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var ev map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		events = append(events, ev)
+	}
+	require.Len(t, events, 7)
+
+	var names []string
+	for _, ev := range events {
+		names = append(names, ev["event"].(string))
+		assert.Equal(t, float64(7), ev["block"])
+	}
+	assert.Equal(t, []string{"blockStart", "txStart", "balance", "nonce", "storage", "txEnd", "blockEnd"}, names)
+	assert.Equal(t, tx.Hash().Hex(), events[2]["tx"])
+	assert.Equal(t, "Transfer", events[2]["reason"])
+	assert.Nil(t, events[6]["tx"], "block events are not bound to a transaction")
+}
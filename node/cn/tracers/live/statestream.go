@@ -0,0 +1,205 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/kaiachain/kaia/blockchain/tracing"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/log"
+)
+
+var logger = log.NewModuleLogger(log.NodeCNTracers)
+
+func init() {
+	Register("stateStream", newStateStream)
+}
+
+// stateStreamConfig is the configuration of the stateStream tracer.
+type stateStreamConfig struct {
+	Path string `json:"path"` // File the events are appended to
+}
+
+// stateEvent is a line written by the stateStream tracer.
+type stateEvent struct {
+	Event   string          `json:"event"`
+	Block   uint64          `json:"block"`
+	Hash    *common.Hash    `json:"hash,omitempty"`
+	Tx      *common.Hash    `json:"tx,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Slot    *common.Hash    `json:"slot,omitempty"`
+	Prev    interface{}     `json:"prev,omitempty"`
+	New     interface{}     `json:"new,omitempty"`
+	Code    hexutil.Bytes   `json:"code,omitempty"`
+	Reason  string          `json:"reason,omitempty"`
+	Log     *types.Log      `json:"log,omitempty"`
+	Status  *hexutil.Uint   `json:"status,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// stateStream writes every block, transaction and state change as a line of
+// JSON, so that the state of the chain can be followed by an external process.
+type stateStream struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	block uint64
+	tx    *common.Hash
+}
+
+func newStateStream(config json.RawMessage) (*tracing.Hooks, error) {
+	var cfg stateStreamConfig
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Path == "" {
+		return nil, errors.New("stateStream: path is not given")
+	}
+	f, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return newStateStreamWithWriter(f).hooks(), nil
+}
+
+func newStateStreamWithWriter(out io.Writer) *stateStream {
+	return &stateStream{enc: json.NewEncoder(out)}
+}
+
+func (s *stateStream) hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnBlockStart:    s.onBlockStart,
+		OnBlockEnd:      s.onBlockEnd,
+		OnTxStart:       s.onTxStart,
+		OnTxEnd:         s.onTxEnd,
+		OnBalanceChange: s.onBalanceChange,
+		OnNonceChange:   s.onNonceChange,
+		OnCodeChange:    s.onCodeChange,
+		OnStorageChange: s.onStorageChange,
+		OnLog:           s.onLog,
+	}
+}
+
+// write writes the event of the current block and transaction. It must be
+// called with the lock held.
+func (s *stateStream) write(ev *stateEvent) {
+	ev.Block = s.block
+	if ev.Tx == nil {
+		ev.Tx = s.tx
+	}
+	if err := s.enc.Encode(ev); err != nil {
+		logger.Warn("Failed to write the state change", "event", ev.Event, "err", err)
+	}
+}
+
+func (s *stateStream) onBlockStart(block *types.Block) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash := block.Hash()
+	s.block, s.tx = block.NumberU64(), nil
+	s.write(&stateEvent{Event: "blockStart", Hash: &hash})
+}
+
+func (s *stateStream) onBlockEnd(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tx = nil
+	ev := &stateEvent{Event: "blockEnd"}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	s.write(ev)
+}
+
+func (s *stateStream) onTxStart(tx *types.Transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash := tx.Hash()
+	s.tx = &hash
+	s.write(&stateEvent{Event: "txStart"})
+}
+
+func (s *stateStream) onTxEnd(receipt *types.Receipt, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ev := &stateEvent{Event: "txEnd"}
+	if receipt != nil {
+		status, gasUsed := hexutil.Uint(receipt.Status), hexutil.Uint64(receipt.GasUsed)
+		ev.Status, ev.GasUsed = &status, &gasUsed
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	s.write(ev)
+	// The state changes after the transaction belong to the block.
+	s.tx = nil
+}
+
+func (s *stateStream) onBalanceChange(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write(&stateEvent{
+		Event:   "balance",
+		Address: &addr,
+		Prev:    (*hexutil.Big)(prev),
+		New:     (*hexutil.Big)(new),
+		Reason:  reason.String(),
+	})
+}
+
+func (s *stateStream) onNonceChange(addr common.Address, prev, new uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write(&stateEvent{Event: "nonce", Address: &addr, Prev: hexutil.Uint64(prev), New: hexutil.Uint64(new)})
+}
+
+func (s *stateStream) onCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write(&stateEvent{Event: "code", Address: &addr, Prev: prevCodeHash, New: codeHash, Code: code})
+}
+
+func (s *stateStream) onStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write(&stateEvent{Event: "storage", Address: &addr, Slot: &slot, Prev: prev, New: new})
+}
+
+func (s *stateStream) onLog(l *types.Log) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write(&stateEvent{Event: "log", Log: l})
+}
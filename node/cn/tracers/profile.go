@@ -0,0 +1,129 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/networks/rpc"
+)
+
+const (
+	// profileFormatJSON returns the profile as a structured JSON object.
+	profileFormatJSON = "json"
+	// profileFormatPprof returns the profile as a gzipped pprof protobuf.
+	profileFormatPprof = "pprof"
+)
+
+// ProfileConfig holds extra parameters to profile functions.
+type ProfileConfig struct {
+	Format  *string // "json" (default) or "pprof"
+	Timeout *string
+	Reexec  *uint64
+}
+
+func (config *ProfileConfig) format() (string, error) {
+	if config == nil || config.Format == nil {
+		return profileFormatJSON, nil
+	}
+	switch *config.Format {
+	case profileFormatJSON, profileFormatPprof:
+		return *config.Format, nil
+	default:
+		return "", fmt.Errorf("unsupported profile format: %s", *config.Format)
+	}
+}
+
+func (config *ProfileConfig) traceConfig() *TraceConfig {
+	tracer := profileTracer
+	traceConfig := &TraceConfig{Tracer: &tracer}
+	if config != nil {
+		traceConfig.Timeout = config.Timeout
+		traceConfig.Reexec = config.Reexec
+	}
+	return traceConfig
+}
+
+// formatProfile returns the result in the requested format.
+func formatProfile(result *vm.ProfileResult, format string) (interface{}, error) {
+	if format == profileFormatJSON {
+		return result, nil
+	}
+	var buf bytes.Buffer
+	if err := result.WritePprof(&buf); err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(buf.Bytes()), nil
+}
+
+// ProfileTransaction returns the gas, computation cost and wall time spent by each
+// opcode, precompiled contract and contract function during the execution of the transaction.
+func (api *API) ProfileTransaction(ctx context.Context, hash common.Hash, config *ProfileConfig) (interface{}, error) {
+	format, err := config.format()
+	if err != nil {
+		return nil, err
+	}
+	res, err := api.TraceTransaction(ctx, hash, config.traceConfig())
+	if err != nil {
+		return nil, err
+	}
+	return formatProfile(res.(*vm.ProfileResult), format)
+}
+
+// ProfileBlockByNumber returns the aggregated profile of all transactions in the block.
+func (api *API) ProfileBlockByNumber(ctx context.Context, number rpc.BlockNumber, config *ProfileConfig) (interface{}, error) {
+	block, err := api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.profileBlock(ctx, block, config)
+}
+
+// ProfileBlockByHash returns the aggregated profile of all transactions in the block.
+func (api *API) ProfileBlockByHash(ctx context.Context, hash common.Hash, config *ProfileConfig) (interface{}, error) {
+	block, err := api.blockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return api.profileBlock(ctx, block, config)
+}
+
+// profileBlock traces all transactions in the block with the profileTracer and merges the results.
+func (api *CommonAPI) profileBlock(ctx context.Context, block *types.Block, config *ProfileConfig) (interface{}, error) {
+	format, err := config.format()
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.traceBlock(ctx, block, config.traceConfig())
+	if err != nil {
+		return nil, err
+	}
+	profile := vm.NewProfileResult()
+	for _, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("profiling tx %s failed: %s", res.TxHash.Hex(), res.Error)
+		}
+		profile.Merge(res.Result.(*vm.ProfileResult))
+	}
+	return formatProfile(profile, format)
+}
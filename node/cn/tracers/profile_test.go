@@ -0,0 +1,135 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	"github.com/kaiachain/kaia/networks/rpc"
+	"github.com/kaiachain/kaia/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProfileTestAPI returns an API over a chain whose single block calls a contract twice.
+// The contract stores a slot and calls the sha256 precompiled contract.
+func newProfileTestAPI(t *testing.T) (*API, common.Address, []common.Hash) {
+	var (
+		accounts = newAccounts(1)
+		contract = common.HexToAddress("0x00000000000000000000000000000000000c0de0")
+		// PUSH1 0x2a PUSH1 0x00 SSTORE
+		// PUSH1 0x20 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x02 GAS STATICCALL POP STOP
+		code   = common.FromHex("602a600055602060006000600060025afa5000")
+		signer = types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+		hashes []common.Hash
+	)
+	genesis := &blockchain.Genesis{Alloc: blockchain.GenesisAlloc{
+		accounts[0].addr: {Balance: big.NewInt(params.KAIA)},
+		contract:         {Balance: common.Big0, Code: code},
+	}}
+	api := NewAPI(newTestBackend(t, 1, genesis, func(i int, b *blockchain.BlockGen) {
+		for nonce := uint64(0); nonce < 2; nonce++ {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, contract, common.Big0, 100000, big.NewInt(1), common.FromHex("0xdeadbeef")), signer, accounts[0].key)
+			b.AddTx(tx)
+			hashes = append(hashes, tx.Hash())
+		}
+	}))
+	return api, contract, hashes
+}
+
+func checkProfileResult(t *testing.T, res *vm.ProfileResult, contract common.Address, txs uint64) {
+	assert.Equal(t, txs, res.Txs)
+	assert.Equal(t, txs, res.Opcodes["SSTORE"].Count)
+	assert.Equal(t, txs, res.Opcodes["STATICCALL"].Count)
+
+	sha256 := common.BytesToAddress([]byte{2})
+	require.Contains(t, res.Precompiles, sha256)
+	assert.Equal(t, txs, res.Precompiles[sha256].Count)
+
+	require.Contains(t, res.Contracts, contract)
+	fn := res.Contracts[contract]["0xdeadbeef"]
+	require.NotNil(t, fn)
+	assert.Equal(t, txs, fn.Count)
+
+	// The self costs of the opcodes and the precompiled contract add up to the cost of the call.
+	var gas, cc uint64
+	for _, s := range res.Opcodes {
+		gas += s.Gas
+		cc += s.ComputationCost
+	}
+	gas += res.Precompiles[sha256].Gas
+	cc += res.Precompiles[sha256].ComputationCost
+	assert.Equal(t, fn.Gas, gas)
+	assert.Equal(t, fn.ComputationCost, cc)
+	assert.Equal(t, res.ComputationCost, cc)
+	assert.Greater(t, res.GasUsed, fn.Gas+txs*params.TxGas)
+}
+
+func TestProfileTransaction(t *testing.T) {
+	t.Parallel()
+
+	api, contract, hashes := newProfileTestAPI(t)
+
+	res, err := api.ProfileTransaction(context.Background(), hashes[0], nil)
+	require.NoError(t, err)
+	checkProfileResult(t, res.(*vm.ProfileResult), contract, 1)
+
+	format := "pprof"
+	res, err = api.ProfileTransaction(context.Background(), hashes[0], &ProfileConfig{Format: &format})
+	require.NoError(t, err)
+	p, err := profile.Parse(bytes.NewReader(res.(hexutil.Bytes)))
+	require.NoError(t, err)
+	names := make(map[string]bool)
+	for _, s := range p.Sample {
+		names[s.Location[0].Line[0].Function.Name] = true
+		assert.Equal(t, contract.Hex()+":0xdeadbeef", s.Location[len(s.Location)-1].Line[0].Function.Name)
+	}
+	assert.True(t, names["SSTORE"])
+	assert.True(t, names[common.BytesToAddress([]byte{2}).Hex()])
+
+	format = "svg"
+	_, err = api.ProfileTransaction(context.Background(), hashes[0], &ProfileConfig{Format: &format})
+	assert.Error(t, err)
+}
+
+func TestProfileBlock(t *testing.T) {
+	t.Parallel()
+
+	api, contract, _ := newProfileTestAPI(t)
+
+	res, err := api.ProfileBlockByNumber(context.Background(), rpc.BlockNumber(1), nil)
+	require.NoError(t, err)
+	checkProfileResult(t, res.(*vm.ProfileResult), contract, 2)
+
+	block, err := api.blockByNumber(context.Background(), rpc.BlockNumber(1))
+	require.NoError(t, err)
+	assert.Equal(t, block.GasUsed(), res.(*vm.ProfileResult).GasUsed)
+
+	res, err = api.ProfileBlockByHash(context.Background(), block.Hash(), nil)
+	require.NoError(t, err)
+	checkProfileResult(t, res.(*vm.ProfileResult), contract, 2)
+	assert.Equal(t, block.GasUsed(), res.(*vm.ProfileResult).GasUsed)
+}
@@ -0,0 +1,269 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package cn
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kaiachain/kaia/common"
+)
+
+const (
+	// maxTxFetchBatch is the maximum number of transactions requested or served
+	// in a single pooled transactions message.
+	maxTxFetchBatch = 256
+
+	// maxTxFetchAnnouncePerPeer is the maximum number of announced transactions
+	// waiting to be fetched from a peer. Announcements beyond it are dropped.
+	maxTxFetchAnnouncePerPeer = 4096
+
+	// maxTxFetchRatePerPeer is the maximum number of transactions requested from a
+	// peer per txFetchRateWindow. The rest waits for the next window or another peer.
+	maxTxFetchRatePerPeer = 1024
+	txFetchRateWindow     = time.Second
+
+	// txFetchTimeout is the time after which an unanswered request is considered
+	// failed, so that the transactions can be requested from other peers.
+	txFetchTimeout = 5 * time.Second
+
+	// txFetchTick is the interval of rescheduling the timed out and rate limited fetches.
+	txFetchTick = 500 * time.Millisecond
+)
+
+// txFetchRequest is a request of transactions sent to a peer.
+type txFetchRequest struct {
+	hashes   []common.Hash
+	time     time.Time
+	timedOut bool // The hashes were rescheduled, but the peer stays busy until it replies
+}
+
+// txFetchRate counts the transactions requested from a peer in the current window.
+type txFetchRate struct {
+	start time.Time
+	count int
+}
+
+// txFetcher schedules the retrieval of the transactions announced by hash.
+// A transaction is requested from one peer at a time, and the other peers that announced
+// it are kept as alternates. If the request times out or the reply misses the transaction,
+// it is requested from an alternate. A peer has at most one request in flight, and the
+// number of transactions requested from it is rate limited by maxTxFetchRatePerPeer.
+//
+// The methods return the requests to send, keyed by peer id.
+type txFetcher struct {
+	hasTx func(hash common.Hash) bool // Checks if a transaction is already in the pool
+	now   func() time.Time
+
+	mu        sync.Mutex
+	announces map[string][]common.Hash            // Announced hashes waiting to be requested, per peer
+	announced map[common.Hash]map[string]struct{} // Peers that announced the hash and were not tried yet
+	fetching  map[common.Hash]string              // Peer the hash is being fetched from
+	requests  map[string]*txFetchRequest          // Request in flight, per peer
+	rates     map[string]*txFetchRate             // Request rate, per peer
+}
+
+func newTxFetcher(hasTx func(hash common.Hash) bool) *txFetcher {
+	return &txFetcher{
+		hasTx:     hasTx,
+		now:       time.Now,
+		announces: make(map[string][]common.Hash),
+		announced: make(map[common.Hash]map[string]struct{}),
+		fetching:  make(map[common.Hash]string),
+		requests:  make(map[string]*txFetchRequest),
+		rates:     make(map[string]*txFetchRate),
+	}
+}
+
+// Notify records the transactions announced by the peer and returns the requests to send.
+// Known transactions are skipped, and the ones being fetched from other peers are kept
+// as alternates.
+func (f *txFetcher) Notify(peer string, hashes []common.Hash) map[string][]common.Hash {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, hash := range hashes {
+		if len(f.announces[peer]) >= maxTxFetchAnnouncePerPeer {
+			txAnnounceDropCounter.Inc(int64(len(hashes) - i))
+			break
+		}
+		if _, ok := f.announced[hash][peer]; ok {
+			continue
+		}
+		if f.fetching[hash] == peer {
+			continue
+		}
+		if f.hasTx(hash) {
+			continue
+		}
+		if f.announced[hash] == nil {
+			f.announced[hash] = make(map[string]struct{})
+		}
+		f.announced[hash][peer] = struct{}{}
+		f.announces[peer] = append(f.announces[peer], hash)
+	}
+	return f.schedule(map[string]struct{}{peer: {}})
+}
+
+// Deliver handles the reply of the peer and returns the requests to send. The delivered
+// transactions are marked as fetched, and the requested ones missing in the reply are
+// requested from alternates.
+func (f *txFetcher) Deliver(peer string, hashes []common.Hash) map[string][]common.Hash {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delivered := make(map[common.Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		delivered[hash] = struct{}{}
+		f.forget(hash)
+	}
+
+	peers := map[string]struct{}{peer: {}}
+	if req := f.requests[peer]; req != nil {
+		delete(f.requests, peer)
+		for _, hash := range req.hashes {
+			if _, ok := delivered[hash]; !ok && f.fetching[hash] == peer {
+				f.reschedule(hash, peers)
+			}
+		}
+	}
+	return f.schedule(peers)
+}
+
+// Drop forgets the peer and returns the requests of its transactions to send to alternates.
+func (f *txFetcher) Drop(peer string) map[string][]common.Hash {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, hash := range f.announces[peer] {
+		f.unannounce(hash, peer)
+	}
+	delete(f.announces, peer)
+	delete(f.rates, peer)
+
+	peers := make(map[string]struct{})
+	if req := f.requests[peer]; req != nil {
+		delete(f.requests, peer)
+		for _, hash := range req.hashes {
+			if f.fetching[hash] == peer {
+				f.reschedule(hash, peers)
+			}
+		}
+	}
+	delete(peers, peer)
+	return f.schedule(peers)
+}
+
+// Expire reschedules the transactions of the requests older than txFetchTimeout to
+// alternates, and returns the requests to send including the ones held by the rate limit.
+// It is called every txFetchTick.
+func (f *txFetcher) Expire() map[string][]common.Hash {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	peers := make(map[string]struct{})
+	for peer, req := range f.requests {
+		if req.timedOut || now.Sub(req.time) < txFetchTimeout {
+			continue
+		}
+		req.timedOut = true
+		txFetchTimeoutCounter.Inc(1)
+		for _, hash := range req.hashes {
+			if f.fetching[hash] == peer {
+				f.reschedule(hash, peers)
+			}
+		}
+	}
+	for peer := range f.announces {
+		peers[peer] = struct{}{}
+	}
+	return f.schedule(peers)
+}
+
+// schedule builds the requests to the given peers that are idle and under the rate limit.
+func (f *txFetcher) schedule(peers map[string]struct{}) map[string][]common.Hash {
+	var (
+		now      = f.now()
+		requests = make(map[string][]common.Hash)
+	)
+	for peer := range peers {
+		if f.requests[peer] != nil || len(f.announces[peer]) == 0 {
+			continue
+		}
+		rate := f.rates[peer]
+		if rate == nil || now.Sub(rate.start) >= txFetchRateWindow {
+			rate = &txFetchRate{start: now}
+			f.rates[peer] = rate
+		}
+		limit := min(maxTxFetchBatch, maxTxFetchRatePerPeer-rate.count)
+
+		var (
+			request []common.Hash
+			waiting = f.announces[peer][:0]
+		)
+		for _, hash := range f.announces[peer] {
+			if _, ok := f.announced[hash][peer]; !ok {
+				continue // fetched or dropped
+			}
+			if f.hasTx(hash) {
+				f.forget(hash)
+				continue
+			}
+			if _, ok := f.fetching[hash]; ok || len(request) >= limit {
+				waiting = append(waiting, hash)
+				continue
+			}
+			f.unannounce(hash, peer)
+			f.fetching[hash] = peer
+			request = append(request, hash)
+		}
+		if len(waiting) == 0 {
+			delete(f.announces, peer)
+		} else {
+			f.announces[peer] = waiting
+		}
+		if len(request) > 0 {
+			rate.count += len(request)
+			f.requests[peer] = &txFetchRequest{hashes: request, time: now}
+			requests[peer] = request
+		}
+	}
+	return requests
+}
+
+// reschedule stops fetching the hash and adds its alternates to peers.
+func (f *txFetcher) reschedule(hash common.Hash, peers map[string]struct{}) {
+	delete(f.fetching, hash)
+	for alt := range f.announced[hash] {
+		peers[alt] = struct{}{}
+	}
+}
+
+// forget removes the hash from the fetcher. The stale entries in announces are
+// skipped and removed in schedule.
+func (f *txFetcher) forget(hash common.Hash) {
+	delete(f.fetching, hash)
+	delete(f.announced, hash)
+}
+
+func (f *txFetcher) unannounce(hash common.Hash, peer string) {
+	delete(f.announced[hash], peer)
+	if len(f.announced[hash]) == 0 {
+		delete(f.announced, hash)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package cn
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kaiachain/kaia/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxFetcher(t *testing.T) {
+	var (
+		known  = common.Hash{0x1}
+		hashA  = common.Hash{0xa}
+		hashB  = common.Hash{0xb}
+		peer1  = "peer1"
+		peer2  = "peer2"
+		peer3  = "peer3"
+		now    = time.Unix(1700000000, 0)
+		hasTx  = func(hash common.Hash) bool { return hash == known }
+		newFet = func() *txFetcher {
+			f := newTxFetcher(hasTx)
+			f.now = func() time.Time { return now }
+			return f
+		}
+		hashes = func(n int) []common.Hash {
+			ret := make([]common.Hash, n)
+			for i := range ret {
+				ret[i] = common.BigToHash(big.NewInt(int64(i + 0x100)))
+			}
+			return ret
+		}
+	)
+
+	t.Run("dedup", func(t *testing.T) {
+		f := newFet()
+		// Known transactions are not requested.
+		assert.Equal(t, map[string][]common.Hash{peer1: {hashA, hashB}}, f.Notify(peer1, []common.Hash{known, hashA, hashB}))
+		// Transactions being fetched from another peer are not requested again.
+		assert.Empty(t, f.Notify(peer2, []common.Hash{hashA, hashB}))
+		// Once delivered, the alternates are forgotten.
+		assert.Empty(t, f.Deliver(peer1, []common.Hash{hashA, hashB}))
+		assert.Empty(t, f.announced)
+		assert.Empty(t, f.fetching)
+		assert.Empty(t, f.requests)
+	})
+
+	t.Run("one request per peer", func(t *testing.T) {
+		f := newFet()
+		assert.Equal(t, map[string][]common.Hash{peer1: {hashA}}, f.Notify(peer1, []common.Hash{hashA}))
+		// The peer is busy until it replies.
+		assert.Empty(t, f.Notify(peer1, []common.Hash{hashB}))
+		assert.Equal(t, map[string][]common.Hash{peer1: {hashB}}, f.Deliver(peer1, []common.Hash{hashA}))
+	})
+
+	t.Run("missing in reply", func(t *testing.T) {
+		f := newFet()
+		f.Notify(peer1, []common.Hash{hashA, hashB})
+		f.Notify(peer2, []common.Hash{hashB})
+		// hashB is missing in the reply, so it is requested from the alternate.
+		assert.Equal(t, map[string][]common.Hash{peer2: {hashB}}, f.Deliver(peer1, []common.Hash{hashA}))
+		// The alternate misses it too, and there is no other alternate.
+		assert.Empty(t, f.Deliver(peer2, nil))
+		assert.Empty(t, f.fetching)
+		// It can be requested again once announced.
+		assert.Equal(t, map[string][]common.Hash{peer1: {hashB}}, f.Notify(peer1, []common.Hash{hashB}))
+	})
+
+	t.Run("deliver from other peer", func(t *testing.T) {
+		f := newFet()
+		f.Notify(peer1, []common.Hash{hashA})
+		f.Notify(peer2, []common.Hash{hashA})
+		f.Deliver(peer2, []common.Hash{hashA})
+		assert.Empty(t, f.fetching)
+		// The late reply of peer1 without hashA does not reschedule it.
+		assert.Empty(t, f.Deliver(peer1, nil))
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		f := newFet()
+		f.Notify(peer1, []common.Hash{hashA, hashB})
+		f.Notify(peer2, []common.Hash{hashA})
+		f.Notify(peer3, []common.Hash{hashB})
+		assert.Equal(t, map[string][]common.Hash{peer2: {hashA}, peer3: {hashB}}, f.Drop(peer1))
+		assert.NotContains(t, f.requests, peer1)
+
+		f.Drop(peer2)
+		f.Drop(peer3)
+		assert.Empty(t, f.announces)
+		assert.Empty(t, f.announced)
+		assert.Empty(t, f.fetching)
+		assert.Empty(t, f.requests)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		f := newFet()
+		f.Notify(peer1, []common.Hash{hashA})
+		f.Notify(peer2, []common.Hash{hashA})
+
+		now = now.Add(txFetchTimeout - time.Second)
+		assert.Empty(t, f.Expire())
+
+		// The expired request is rescheduled to the alternate.
+		now = now.Add(time.Second)
+		assert.Equal(t, map[string][]common.Hash{peer2: {hashA}}, f.Expire())
+
+		// The timed out peer stays busy until it replies.
+		assert.Empty(t, f.Notify(peer1, []common.Hash{hashB}))
+		assert.Equal(t, map[string][]common.Hash{peer1: {hashB}}, f.Deliver(peer1, nil))
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		f := newFet()
+		announced := hashes(maxTxFetchRatePerPeer + maxTxFetchBatch)
+		// Each reply frees the peer for the next request until the rate limit is reached.
+		var requested []common.Hash
+		for req := f.Notify(peer1, announced)[peer1]; len(req) > 0; req = f.Deliver(peer1, req)[peer1] {
+			assert.LessOrEqual(t, len(req), maxTxFetchBatch)
+			requested = append(requested, req...)
+		}
+		assert.Equal(t, announced[:maxTxFetchRatePerPeer], requested)
+
+		// The rest is requested in the next window.
+		now = now.Add(txFetchRateWindow)
+		assert.Equal(t, map[string][]common.Hash{peer1: announced[maxTxFetchRatePerPeer:]}, f.Expire())
+	})
+
+	t.Run("announce limit", func(t *testing.T) {
+		f := newFet()
+		announced := hashes(maxTxFetchAnnouncePerPeer + maxTxFetchBatch + 10)
+		assert.Len(t, f.Notify(peer1, announced)[peer1], maxTxFetchBatch)
+		assert.Len(t, f.announces[peer1], maxTxFetchAnnouncePerPeer-maxTxFetchBatch)
+
+		// Other peers are not limited by peer1.
+		assert.Len(t, f.Notify(peer2, announced[maxTxFetchAnnouncePerPeer:])[peer2], maxTxFetchBatch)
+	})
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/kaiachain/kaia/api"
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/common/hexutil"
+	istanbulCore "github.com/kaiachain/kaia/consensus/istanbul/core"
+	"github.com/kaiachain/kaia/networks/rpc"
+	"github.com/kaiachain/kaia/params"
+)
+
+var errUnknownBlock = errors.New("unknown block")
+
+// PublicLightAPI provides the APIs of a light node. The state is retrieved
+// from the full nodes with the Merkle proofs against the verified headers.
+type PublicLightAPI struct {
+	l *LightKaia
+}
+
+// NewPublicLightAPI creates the APIs of a light node.
+func NewPublicLightAPI(l *LightKaia) *PublicLightAPI {
+	return &PublicLightAPI{l}
+}
+
+// BlockNumber returns the number of the latest verified header.
+func (s *PublicLightAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.l.chain.CurrentHeader().Number.Uint64())
+}
+
+// ChainId returns the chain ID of the chain.
+func (s *PublicLightAPI) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.l.chainConfig.ChainID)
+}
+
+// Syncing returns false if the head is the highest announced by the peers,
+// or an object with the sync progress otherwise.
+func (s *PublicLightAPI) Syncing() (interface{}, error) {
+	current := s.l.chain.CurrentHeader().Number.Uint64()
+	highest := current
+	if p := s.l.peers.best(); p != nil {
+		if _, number := p.Head(); number > highest {
+			highest = number
+		}
+	}
+	if current >= highest {
+		return false, nil
+	}
+	return map[string]interface{}{
+		"currentBlock": hexutil.Uint64(current),
+		"highestBlock": hexutil.Uint64(highest),
+	}, nil
+}
+
+// GetBalance returns the balance of the account at the block.
+func (s *PublicLightAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	st, _, err := s.stateAndHeader(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	balance := st.GetBalance(address)
+	return (*hexutil.Big)(balance), st.Error()
+}
+
+// GetTransactionCount returns the nonce of the account at the block.
+func (s *PublicLightAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	st, _, err := s.stateAndHeader(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	nonce := hexutil.Uint64(st.GetNonce(address))
+	return &nonce, st.Error()
+}
+
+// GetCode returns the code of the account at the block.
+func (s *PublicLightAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	st, _, err := s.stateAndHeader(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	code := st.GetCode(address)
+	return code, st.Error()
+}
+
+// GetStorageAt returns the storage slot of the account at the block.
+func (s *PublicLightAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	st, _, err := s.stateAndHeader(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	value := st.GetState(address, common.HexToHash(key))
+	return value[:], st.Error()
+}
+
+// Call executes the given transaction on the state of the block, retrieving
+// the accessed accounts, slots and codes on demand.
+func (s *PublicLightAPI) Call(ctx context.Context, args api.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	result, err := s.doCall(ctx, args, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Revert()) > 0 {
+		return nil, blockchain.NewRevertError(result)
+	}
+	return result.Return(), result.Unwrap()
+}
+
+func (s *PublicLightAPI) doCall(ctx context.Context, args api.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (*blockchain.ExecutionResult, error) {
+	// The timeout covers the state retrieval as well as the execution
+	if timeout := s.l.config.RPCEVMTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	st, header, err := s.stateAndHeader(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	config := s.l.chainConfig
+	intrinsicGas, err := types.IntrinsicGas(args.InputData(), args.GetAccessList(), nil, args.To == nil, config.Rules(header.Number))
+	if err != nil {
+		return nil, err
+	}
+	baseFee := new(big.Int).SetUint64(params.ZeroBaseFee)
+	if header.BaseFee != nil {
+		baseFee = header.BaseFee
+	}
+	gasCap := uint64(0)
+	if s.l.config.RPCGasCap != nil {
+		gasCap = s.l.config.RPCGasCap.Uint64()
+	}
+	msg, err := args.ToMessage(gasCap, baseFee, intrinsicGas)
+	if err != nil {
+		return nil, err
+	}
+	// Add gas fee to sender for calling a function by insufficient balance sender.
+	st.AddBalance(msg.ValidatedSender(), new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), msg.EffectiveGasPrice(header, config)), tracing.BalanceChangeUnspecified)
+	if msg.Gas() < intrinsicGas {
+		return nil, fmt.Errorf("%w: msg.gas %d, want %d", blockchain.ErrIntrinsicGas, msg.Gas(), intrinsicGas)
+	}
+	proposer, err := istanbulCore.HeaderAuthor(header)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		txContext    = blockchain.NewEVMTxContext(msg, header, config)
+		blockContext = blockchain.NewEVMBlockContext(header, s.l.chain, &proposer)
+		evm          = vm.NewEVM(blockContext, txContext, st, config, &vm.Config{ComputationCostLimit: params.OpcodeComputationCostLimitInfinite})
+	)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel(vm.CancelByCtxDone)
+	}()
+	result, err := blockchain.ApplyMessage(evm, msg)
+	// A failed retrieval of the state makes the result meaningless
+	if err := st.Error(); err != nil {
+		return nil, err
+	}
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", s.l.config.RPCEVMTimeout)
+	}
+	if err != nil {
+		return result, fmt.Errorf("err: %w (supplied gas %d)", err, msg.Gas())
+	}
+	return result, nil
+}
+
+// stateAndHeader returns the state retrieving the data on demand, and the
+// header of the block.
+func (s *PublicLightAPI) stateAndHeader(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	var header *types.Header
+	if number, ok := blockNrOrHash.Number(); ok {
+		if number < 0 {
+			header = s.l.chain.CurrentHeader()
+		} else {
+			header = s.l.chain.GetHeaderByNumber(uint64(number))
+		}
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		header = s.l.chain.GetHeaderByHash(hash)
+	}
+	if header == nil {
+		return nil, nil, errUnknownBlock
+	}
+	st, err := state.New(header.Root, NewOdrDatabase(ctx, s.l), nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return st, header, nil
+}
@@ -0,0 +1,413 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kaiachain/kaia/api"
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/log"
+	"github.com/kaiachain/kaia/networks/p2p"
+	"github.com/kaiachain/kaia/networks/rpc"
+	"github.com/kaiachain/kaia/node"
+	"github.com/kaiachain/kaia/node/cn"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/kaiachain/kaia/storage/statedb"
+)
+
+var logger = log.NewModuleLogger(log.Node)
+
+// forceSyncCycle is the interval of the header synchronisation without a new
+// head announced.
+const forceSyncCycle = 10 * time.Second
+
+var (
+	errNoPeers            = errors.New("no light server peers")
+	errCheckpointMismatch = errors.New("checkpoint header mismatch")
+)
+
+// LightKaia implements the light node service. It keeps only the verified
+// headers and retrieves the state from the full nodes on demand.
+type LightKaia struct {
+	config      *cn.Config
+	chainConfig *params.ChainConfig
+	chainDB     database.DBManager
+	chain       *LightChain
+	peers       *peerSet
+	networkId   uint64
+
+	netRPCService *api.PublicNetAPI
+
+	syncCh chan struct{} // Wakes up the header synchronisation
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New creates a light node service.
+func New(ctx *node.ServiceContext, config *cn.Config) (*LightKaia, error) {
+	chainDB := cn.CreateDB(ctx, config, "lightchaindata")
+
+	chainConfig, genesisHash, genesisErr := blockchain.SetupGenesisBlock(chainDB, config.Genesis, config.NetworkId, config.IsPrivate, false)
+	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+		return nil, genesisErr
+	}
+	if chainConfig.Istanbul == nil {
+		return nil, errNoIstanbul
+	}
+	types.EngineType = types.Engine_IBFT
+	chainConfig.SetDefaults()
+	logger.Info("Initialised light chain configuration", "config", chainConfig)
+
+	genesis := chainDB.ReadBlock(genesisHash, 0)
+	if genesis == nil {
+		return nil, fmt.Errorf("missing genesis block %x", genesisHash)
+	}
+	chain, err := NewLightChain(chainDB, chainConfig, genesis)
+	if err != nil {
+		return nil, err
+	}
+	return newLightKaia(config, chainConfig, chainDB, chain), nil
+}
+
+func newLightKaia(config *cn.Config, chainConfig *params.ChainConfig, chainDB database.DBManager, chain *LightChain) *LightKaia {
+	return &LightKaia{
+		config:      config,
+		chainConfig: chainConfig,
+		chainDB:     chainDB,
+		chain:       chain,
+		peers:       newPeerSet(),
+		networkId:   config.NetworkId,
+		syncCh:      make(chan struct{}, 1),
+		quit:        make(chan struct{}),
+	}
+}
+
+// LightChain returns the chain of the verified headers.
+func (l *LightKaia) LightChain() *LightChain {
+	return l.chain
+}
+
+// Protocols implements node.Service, returning the `klight` protocols.
+func (l *LightKaia) Protocols() []p2p.Protocol {
+	protocols := make([]p2p.Protocol, 0, len(ProtocolVersions))
+	for _, version := range ProtocolVersions {
+		version := version // Closure
+		protocols = append(protocols, p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[version],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				return l.handle(newPeer(version, p, rw))
+			},
+			RunWithRWs: func(p *p2p.Peer, rws []p2p.MsgReadWriter) error {
+				return l.handle(newPeer(version, p, rws[p2p.ConnDefault]))
+			},
+		})
+	}
+	return protocols
+}
+
+// APIs implements node.Service, returning the APIs served by the light node.
+func (l *LightKaia) APIs() []rpc.API {
+	publicLightAPI := NewPublicLightAPI(l)
+	return []rpc.API{
+		{
+			Namespace: "kaia",
+			Version:   "1.0",
+			Service:   publicLightAPI,
+			Public:    true,
+		}, {
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   publicLightAPI,
+			Public:    true,
+		}, {
+			Namespace: "net",
+			Version:   "1.0",
+			Service:   l.netRPCService,
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service, starting the header synchronisation.
+func (l *LightKaia) Start(srvr p2p.Server) error {
+	l.netRPCService = api.NewPublicNetAPI(srvr, l.networkId)
+
+	l.wg.Add(1)
+	go l.syncLoop()
+	return nil
+}
+
+// Stop implements node.Service, terminating the header synchronisation.
+func (l *LightKaia) Stop() error {
+	close(l.quit)
+	l.wg.Wait()
+	l.chainDB.Close()
+	logger.Info("Light node stopped")
+	return nil
+}
+
+// Components implements node.Service. The light node shares no components
+// with the other services.
+func (l *LightKaia) Components() []interface{} {
+	return nil
+}
+
+// SetComponents implements node.Service.
+func (l *LightKaia) SetComponents(components []interface{}) {}
+
+// handle is the callback invoked to manage the life cycle of a light server
+// peer. When this function terminates, the peer is disconnected.
+func (l *LightKaia) handle(p *peer) error {
+	head := l.chain.CurrentHeader()
+	if err := p.Handshake(l.networkId, l.chain.Genesis().Hash(), head.Hash(), head.Number.Uint64()); err != nil {
+		p.logger.Debug("Light handshake failed", "err", err)
+		return err
+	}
+	if err := l.peers.register(p); err != nil {
+		return err
+	}
+	defer func() {
+		l.peers.unregister(p.id)
+		p.close()
+	}()
+	p.logger.Debug("Light server connected", "name", p.Name())
+	l.wakeSync()
+
+	for {
+		if err := l.handleMsg(p); err != nil {
+			p.logger.Debug("Message handling failed in `klight`", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg delivers a response or an announcement of a light server.
+func (l *LightKaia) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > maxMessageSize {
+		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
+	}
+	defer msg.Discard()
+
+	var (
+		id  uint64
+		res interface{}
+	)
+	switch msg.Code {
+	case AnnounceMsg:
+		var ann AnnouncePacket
+		if err := msg.Decode(&ann); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		if p.setHead(ann.Hash, ann.Number) {
+			l.wakeSync()
+		}
+		return nil
+
+	case HeadersMsg:
+		packet := new(HeadersPacket)
+		if err := msg.Decode(packet); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		id, res = packet.ID, packet
+
+	case ProofsMsg:
+		packet := new(ProofsPacket)
+		if err := msg.Decode(packet); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		id, res = packet.ID, packet
+
+	case CodeMsg:
+		packet := new(CodePacket)
+		if err := msg.Decode(packet); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		id, res = packet.ID, packet
+
+	default:
+		return fmt.Errorf("%w: %v", errInvalidMsgCode, msg.Code)
+	}
+	if err := p.deliver(id, res); err != nil {
+		// The request may have timed out already
+		p.logger.Debug("Dropped a light response", "err", err)
+	}
+	return nil
+}
+
+func (l *LightKaia) wakeSync() {
+	select {
+	case l.syncCh <- struct{}{}:
+	default:
+	}
+}
+
+func (l *LightKaia) syncLoop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(forceSyncCycle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.syncCh:
+		case <-ticker.C:
+		case <-l.quit:
+			return
+		}
+		if p := l.peers.best(); p != nil {
+			if err := l.synchronise(p); err != nil {
+				p.logger.Debug("Light synchronisation failed", "err", err)
+			}
+		}
+	}
+}
+
+// synchronise downloads and verifies the headers up to the head of the peer.
+// A peer serving invalid headers is penalized and disconnected.
+func (l *LightKaia) synchronise(p *peer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-l.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	if err := l.syncCheckpoint(ctx, p); err != nil {
+		return err
+	}
+	for {
+		_, target := p.Head()
+		head := l.chain.CurrentHeader().Number.Uint64()
+		if target <= head {
+			return nil
+		}
+		headers, err := p.RequestHeaders(ctx, head+1, MaxHeaderFetch)
+		if err != nil {
+			if errors.Is(err, errRequestTimeout) {
+				p.Penalize(p2p.PenaltySlowResponse, err.Error())
+			}
+			return err
+		}
+		if len(headers) == 0 {
+			return nil
+		}
+		if _, err := l.chain.InsertHeaders(headers); err != nil {
+			p.Penalize(p2p.PenaltyInvalidData, err.Error())
+			p.Disconnect(p2p.DiscUselessPeer)
+			return err
+		}
+		last := headers[len(headers)-1]
+		logger.Info("Imported new light headers", "count", len(headers), "number", last.Number, "hash", last.Hash())
+	}
+}
+
+// syncCheckpoint moves the head to the configured checkpoint if it is behind.
+func (l *LightKaia) syncCheckpoint(ctx context.Context, p *peer) error {
+	cp := l.config.SyncCheckpoint
+	if cp == nil || l.chain.CurrentHeader().Number.Uint64() >= cp.Number {
+		return nil
+	}
+	if _, number := p.Head(); number < cp.Number {
+		return fmt.Errorf("peer head %d behind the checkpoint %d", number, cp.Number)
+	}
+	headers, err := p.RequestHeaders(ctx, cp.Number, 1)
+	if err != nil {
+		return err
+	}
+	if len(headers) != 1 || headers[0].Hash() != cp.Hash {
+		p.Penalize(p2p.PenaltyInvalidData, errCheckpointMismatch.Error())
+		p.Disconnect(p2p.DiscUselessPeer)
+		return errCheckpointMismatch
+	}
+	// Retrieve the skipped epoch headers setting the committee sizes
+	var epochs []*types.Header
+	if epoch := l.chain.Config().Istanbul.Epoch; epoch > 0 {
+		for number := l.chain.CurrentHeader().Number.Uint64()/epoch*epoch + epoch; number <= cp.Number; number += epoch {
+			epochHeaders, err := p.RequestHeaders(ctx, number, 1)
+			if err != nil {
+				return err
+			}
+			if len(epochHeaders) != 1 || epochHeaders[0].Number.Uint64() != number {
+				p.Penalize(p2p.PenaltyInvalidData, errInvalidEpoch.Error())
+				p.Disconnect(p2p.DiscUselessPeer)
+				return errInvalidEpoch
+			}
+			epochs = append(epochs, epochHeaders[0])
+		}
+	}
+	if err := l.chain.SetCheckpoint(headers[0], epochs); err != nil {
+		p.Penalize(p2p.PenaltyInvalidData, err.Error())
+		p.Disconnect(p2p.DiscUselessPeer)
+		return err
+	}
+	logger.Info("Light chain moved to the checkpoint", "number", cp.Number, "hash", cp.Hash)
+	return nil
+}
+
+// RetrieveProof implements OdrBackend, retrieving a proof from the peers until
+// a valid one is found.
+func (l *LightKaia) RetrieveProof(ctx context.Context, req ProofRequest, db database.DBManager) error {
+	err := errNoPeers
+	for _, p := range l.peers.all() {
+		var nodes [][]byte
+		if nodes, err = p.RequestProofs(ctx, []ProofRequest{req}); err != nil || len(nodes) == 0 {
+			continue
+		}
+		for _, node := range nodes {
+			db.WriteMerkleProof(database.TrieNodeKey(common.BytesToExtHash(crypto.Keccak256(node))), node)
+		}
+		if _, err, _ = statedb.VerifyProof(req.Root.Unextend(), req.Key, db); err == nil {
+			return nil
+		}
+		p.Penalize(p2p.PenaltyInvalidData, err.Error())
+	}
+	return err
+}
+
+// RetrieveCode implements OdrBackend, retrieving a code from the peers until
+// the one of the hash is found.
+func (l *LightKaia) RetrieveCode(ctx context.Context, hash common.Hash) ([]byte, error) {
+	err := errNoPeers
+	for _, p := range l.peers.all() {
+		var codes [][]byte
+		if codes, err = p.RequestCode(ctx, []common.Hash{hash}); err != nil {
+			continue
+		}
+		if len(codes) == 1 && crypto.Keccak256Hash(codes[0]) == hash {
+			return codes[0], nil
+		}
+		err = errCodeMismatch
+		p.Penalize(p2p.PenaltyInvalidData, err.Error())
+	}
+	return nil, err
+}
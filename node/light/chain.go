@@ -0,0 +1,264 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus"
+	istanbulCore "github.com/kaiachain/kaia/consensus/istanbul/core"
+	"github.com/kaiachain/kaia/kaiax/gov"
+	"github.com/kaiachain/kaia/kaiax/gov/headergov"
+	headergov_impl "github.com/kaiachain/kaia/kaiax/gov/headergov/impl"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/storage/database"
+)
+
+var (
+	errNoIstanbul     = errors.New("light client requires the istanbul consensus")
+	errMissingHead    = errors.New("missing head header")
+	errNotCanonical   = errors.New("checkpoint below the head")
+	errUnknownHeaders = errors.New("headers not following the head")
+	errInvalidEpoch   = errors.New("not an epoch header before the checkpoint")
+)
+
+// LightChain is the chain of the verified headers. It keeps the validator set
+// and the committee sizes needed to verify the next headers.
+type LightChain struct {
+	db      database.DBManager
+	config  *params.ChainConfig
+	genesis *types.Header
+
+	mu         sync.RWMutex
+	head       *types.Header
+	validators []common.Address  // Validators declared by the head, trusted to verify the next header
+	committees map[uint64]uint64 // Committee sizes set by the governance data of the epoch headers
+}
+
+// NewLightChain loads the header chain from the database. The genesis block
+// must be already written in the database.
+func NewLightChain(db database.DBManager, config *params.ChainConfig, genesis *types.Block) (*LightChain, error) {
+	if config.Istanbul == nil {
+		return nil, errNoIstanbul
+	}
+	lc := &LightChain{
+		db:         db,
+		config:     config,
+		genesis:    genesis.Header(),
+		head:       genesis.Header(),
+		committees: make(map[uint64]uint64),
+	}
+	if hash := db.ReadHeadHeaderHash(); hash != (common.Hash{}) {
+		number := db.ReadHeaderNumber(hash)
+		if number == nil {
+			return nil, errMissingHead
+		}
+		if lc.head = db.ReadHeader(hash, *number); lc.head == nil {
+			return nil, errMissingHead
+		}
+	}
+	if err := lc.loadValidators(lc.head); err != nil {
+		return nil, err
+	}
+	// Collect the committee sizes from the stored epoch headers
+	if epoch := config.Istanbul.Epoch; epoch > 0 {
+		for number := uint64(0); number <= lc.head.Number.Uint64(); number += epoch {
+			if header := lc.GetHeaderByNumber(number); header != nil {
+				lc.trackGovernance(header)
+			}
+		}
+	}
+	return lc, nil
+}
+
+// loadValidators trusts the validators declared by a trusted header.
+func (lc *LightChain) loadValidators(header *types.Header) error {
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return err
+	}
+	lc.validators = extra.Validators
+	return nil
+}
+
+// trackGovernance records the committee size changed by an epoch header.
+func (lc *LightChain) trackGovernance(header *types.Header) {
+	if len(header.Governance) == 0 {
+		return
+	}
+	data, err := headergov.GovBytes(header.Governance).ToGovData()
+	if err != nil {
+		logger.Warn("Failed to parse the governance data", "number", header.Number, "err", err)
+		return
+	}
+	if size, ok := data.Items()[gov.IstanbulCommitteeSize].(uint64); ok {
+		lc.committees[header.Number.Uint64()] = size
+	}
+}
+
+// committeeSize returns the committee size effective at the block number.
+func (lc *LightChain) committeeSize(number uint64) uint64 {
+	var (
+		size  = lc.config.Istanbul.SubGroupSize
+		epoch = lc.config.Istanbul.Epoch
+	)
+	if epoch == 0 {
+		return size
+	}
+	prev := headergov_impl.PrevEpochStart(number, epoch, lc.config.IsKoreForkEnabled(new(big.Int).SetUint64(number)))
+	latest := int64(-1)
+	for num, s := range lc.committees {
+		if num <= prev && int64(num) > latest {
+			latest, size = int64(num), s
+		}
+	}
+	return size
+}
+
+// SetCheckpoint moves the head to a trusted header, skipping the headers
+// before it. The validators declared in the header are trusted.
+//
+// The committee sizes of the next headers are set by the governance data of
+// the skipped epoch headers, given in ascending order. They can't be linked to
+// the checkpoint without the headers between them, so they are only checked
+// to be committed by the validators declared in them, and are stored for the
+// committee sizes to be collected again on restart.
+func (lc *LightChain) SetCheckpoint(header *types.Header, epochs []*types.Header) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if header.Number.Uint64() <= lc.head.Number.Uint64() {
+		return errNotCanonical
+	}
+	committees := make(map[uint64]uint64, len(lc.committees))
+	for num, size := range lc.committees {
+		committees[num] = size
+	}
+	if err := lc.trackEpochs(header, epochs); err != nil {
+		lc.committees = committees
+		return err
+	}
+	if err := lc.loadValidators(header); err != nil {
+		lc.committees = committees
+		return err
+	}
+	for _, h := range epochs {
+		lc.db.WriteHeader(h)
+		lc.db.WriteCanonicalHash(h.Hash(), h.Number.Uint64())
+	}
+	lc.writeHead(header)
+	return nil
+}
+
+// trackEpochs records the committee sizes changed by the epoch headers skipped
+// to the checkpoint.
+func (lc *LightChain) trackEpochs(checkpoint *types.Header, epochs []*types.Header) error {
+	epoch := lc.config.Istanbul.Epoch
+	for _, h := range epochs {
+		number := h.Number.Uint64()
+		if epoch == 0 || number%epoch != 0 || number <= lc.head.Number.Uint64() || number > checkpoint.Number.Uint64() {
+			return fmt.Errorf("%w: %d", errInvalidEpoch, number)
+		}
+		if _, err := istanbulCore.VerifyCommittedSeals(h, lc.committeeSize(number)); err != nil {
+			return fmt.Errorf("epoch header %d: %w", number, err)
+		}
+		lc.trackGovernance(h)
+	}
+	return nil
+}
+
+// InsertHeaders verifies and writes the headers following the head. It
+// returns the number of the inserted headers.
+func (lc *LightChain) InsertHeaders(headers []*types.Header) (int, error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	for i, header := range headers {
+		if header.Number.Uint64() != lc.head.Number.Uint64()+1 {
+			return i, errUnknownHeaders
+		}
+		validators, err := istanbulCore.VerifyCommittedHeader(lc.head, header, lc.validators, lc.committeeSize(header.Number.Uint64()))
+		if err != nil {
+			return i, err
+		}
+		lc.validators = validators
+		if epoch := lc.config.Istanbul.Epoch; epoch > 0 && header.Number.Uint64()%epoch == 0 {
+			lc.trackGovernance(header)
+		}
+		lc.writeHead(header)
+	}
+	return len(headers), nil
+}
+
+func (lc *LightChain) writeHead(header *types.Header) {
+	hash := header.Hash()
+	lc.db.WriteHeader(header)
+	lc.db.WriteCanonicalHash(hash, header.Number.Uint64())
+	lc.db.WriteHeadHeaderHash(hash)
+	lc.head = header
+}
+
+// Genesis returns the genesis header.
+func (lc *LightChain) Genesis() *types.Header {
+	return lc.genesis
+}
+
+// Config returns the chain configuration.
+func (lc *LightChain) Config() *params.ChainConfig {
+	return lc.config
+}
+
+// CurrentHeader returns the head of the verified headers.
+func (lc *LightChain) CurrentHeader() *types.Header {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.head
+}
+
+// GetHeader returns the header by its hash and number.
+func (lc *LightChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return lc.db.ReadHeader(hash, number)
+}
+
+// GetHeaderByHash returns the header by its hash.
+func (lc *LightChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	number := lc.db.ReadHeaderNumber(hash)
+	if number == nil {
+		return nil
+	}
+	return lc.db.ReadHeader(hash, *number)
+}
+
+// GetHeaderByNumber returns the canonical header by its number.
+func (lc *LightChain) GetHeaderByNumber(number uint64) *types.Header {
+	hash := lc.db.ReadCanonicalHash(number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return lc.db.ReadHeader(hash, number)
+}
+
+// Engine implements blockchain.ChainContext. The light chain has no consensus
+// engine, so the author of a block must be given to the EVM explicitly.
+func (lc *LightChain) Engine() consensus.Engine {
+	return nil
+}
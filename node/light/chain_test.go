@@ -0,0 +1,149 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	istanbulCore "github.com/kaiachain/kaia/consensus/istanbul/core"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/crypto/sha3"
+	"github.com/kaiachain/kaia/kaiax/gov"
+	"github.com/kaiachain/kaia/kaiax/gov/headergov"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testValidators struct {
+	keys  map[common.Address]*ecdsa.PrivateKey
+	addrs []common.Address
+}
+
+func newTestValidators(t *testing.T, n int) *testValidators {
+	vs := &testValidators{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		vs.keys[addr] = key
+		vs.addrs = append(vs.addrs, addr)
+	}
+	sort.Slice(vs.addrs, func(i, j int) bool { return bytes.Compare(vs.addrs[i][:], vs.addrs[j][:]) < 0 })
+	return vs
+}
+
+func setIstanbulExtra(t *testing.T, header *types.Header, extra *types.IstanbulExtra) {
+	payload, err := rlp.EncodeToBytes(extra)
+	require.NoError(t, err)
+	header.Extra = append(make([]byte, types.IstanbulExtraVanity), payload...)
+}
+
+// makeSealedHeader makes a child of the parent declaring the validators,
+// proposed by the proposer and committed by the committers.
+func makeSealedHeader(t *testing.T, parent *types.Header, validators []common.Address, proposer *ecdsa.PrivateKey, committers []*ecdsa.PrivateKey) *types.Header {
+	return makeGovernanceHeader(t, parent, validators, proposer, committers, nil)
+}
+
+// makeGovernanceHeader makes a sealed header carrying the governance data.
+func makeGovernanceHeader(t *testing.T, parent *types.Header, validators []common.Address, proposer *ecdsa.PrivateKey, committers []*ecdsa.PrivateKey, governance []byte) *types.Header {
+	header := &types.Header{
+		Governance: governance,
+		ParentHash: parent.Hash(),
+		Root:       parent.Root,
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		Time:       new(big.Int).Add(parent.Time, common.Big1),
+		BlockScore: common.Big1,
+	}
+	extra := &types.IstanbulExtra{Validators: validators, Seal: []byte{}, CommittedSeal: [][]byte{}}
+	setIstanbulExtra(t, header, extra)
+
+	var sigHash common.Hash
+	hasher := sha3.NewKeccak256()
+	rlp.Encode(hasher, types.IstanbulFilteredHeader(header, false))
+	hasher.Sum(sigHash[:0])
+	seal, err := crypto.Sign(crypto.Keccak256(sigHash.Bytes()), proposer)
+	require.NoError(t, err)
+	extra.Seal = seal
+	setIstanbulExtra(t, header, extra)
+
+	proposal := crypto.Keccak256(istanbulCore.PrepareCommittedSeal(header.Hash()))
+	for _, key := range committers {
+		seal, err := crypto.Sign(proposal, key)
+		require.NoError(t, err)
+		extra.CommittedSeal = append(extra.CommittedSeal, seal)
+	}
+	setIstanbulExtra(t, header, extra)
+	return header
+}
+
+func (vs *testValidators) signers(addrs ...common.Address) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, 0, len(addrs))
+	for _, addr := range addrs {
+		keys = append(keys, vs.keys[addr])
+	}
+	return keys
+}
+
+func TestSetCheckpoint(t *testing.T) {
+	var (
+		vs    = newTestValidators(t, 7)
+		gspec = newTestGenesis(t, vs)
+		all   = vs.signers(vs.addrs...)
+	)
+	gspec.Config.Istanbul.Epoch = 4
+
+	// The epoch header 4 reduces the committee to four validators from the block 9 on
+	data, err := headergov.NewGovData(gov.PartialParamSet{gov.IstanbulCommitteeSize: uint64(4)}).ToGovBytes()
+	require.NoError(t, err)
+	db := database.NewMemoryDBManager()
+	genesis := gspec.MustCommit(db)
+	headers := []*types.Header{genesis.Header()}
+	for i := 1; i <= 8; i++ {
+		var governance []byte
+		if i == 4 {
+			governance = data
+		}
+		headers = append(headers, makeGovernanceHeader(t, headers[i-1], vs.addrs, vs.keys[vs.addrs[0]], all, governance))
+	}
+	next := makeSealedHeader(t, headers[8], vs.addrs, vs.keys[vs.addrs[0]], vs.signers(vs.addrs[:3]...))
+
+	lc, err := NewLightChain(db, gspec.Config, genesis)
+	require.NoError(t, err)
+	assert.ErrorIs(t, lc.SetCheckpoint(headers[8], []*types.Header{headers[3]}), errInvalidEpoch)
+	assert.ErrorIs(t, lc.SetCheckpoint(headers[8], []*types.Header{makeSealedHeader(t, headers[3], vs.addrs, vs.keys[vs.addrs[0]], all[:2])}), istanbulCore.ErrInvalidCommittedSeals)
+	assert.Equal(t, uint64(22), lc.committeeSize(9))
+
+	require.NoError(t, lc.SetCheckpoint(headers[8], []*types.Header{headers[4], headers[8]}))
+	assert.Equal(t, uint64(4), lc.committeeSize(9))
+	n, err := lc.InsertHeaders([]*types.Header{next})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// The committee sizes are collected again from the stored epoch headers
+	lc, err = NewLightChain(db, gspec.Config, genesis)
+	require.NoError(t, err)
+	assert.Equal(t, next.Hash(), lc.CurrentHeader().Hash())
+	assert.Equal(t, uint64(4), lc.committeeSize(10))
+}
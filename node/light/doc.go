@@ -0,0 +1,44 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package light implements the header-only light client of Kaia and the server
+side of its `klight` protocol.
+
+A light node (ken with --syncmode light) keeps only the block headers. It
+downloads them from the full nodes serving the `klight` protocol and verifies
+the Istanbul proposer seal and committed seals of every header. The validator
+set is taken from the trusted genesis (or checkpoint) header and followed
+through the verified headers, and the committee size is followed through the
+governance data of the epoch headers.
+
+The state is not stored locally. When an API such as kaia_getBalance or
+eth_call reads an account, a storage slot or a contract code, it is retrieved
+on demand from a full node with a Merkle proof against the state root of a
+verified header.
+
+# Source Files
+
+  - api.go : provides the kaia and eth namespace APIs served by the light node.
+  - backend.go : implements the light node service and its header synchronisation.
+  - chain.go : stores the verified header chain and tracks the validator set and committee size.
+  - odr.go : implements the state database retrieving the trie nodes and codes on demand.
+  - peer.go : implements the `klight` peer and the request/response matching.
+  - protocol.go : defines the messages of the `klight` protocol.
+  - server.go : serves the `klight` protocol on a full node.
+  - verifier.go : verifies the Istanbul seals of the headers.
+*/
+package light
@@ -0,0 +1,194 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	istanbulCore "github.com/kaiachain/kaia/consensus/istanbul/core"
+	"github.com/kaiachain/kaia/event"
+	"github.com/kaiachain/kaia/networks/p2p"
+	"github.com/kaiachain/kaia/networks/p2p/discover"
+	"github.com/kaiachain/kaia/networks/rpc"
+	"github.com/kaiachain/kaia/node/cn"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/rlp"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAccount  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testContract = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testCode     = common.FromHex("0x6080604052600080fd")
+	testSlot     = common.HexToHash("0x01")
+	testValue    = common.HexToHash("0xc0ffee")
+)
+
+// testServerChain is a full chain of the sealed headers over the genesis state.
+type testServerChain struct {
+	genesis *types.Block
+	headers []*types.Header
+	state   state.Database
+	feed    event.Feed
+}
+
+func (c *testServerChain) Genesis() *types.Block        { return c.genesis }
+func (c *testServerChain) CurrentHeader() *types.Header { return c.headers[len(c.headers)-1] }
+func (c *testServerChain) StateCache() state.Database   { return c.state }
+func (c *testServerChain) ContractCode(hash common.Hash) ([]byte, error) {
+	return c.state.ContractCode(hash)
+}
+
+func (c *testServerChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+func (c *testServerChain) SubscribeChainHeadEvent(ch chan<- blockchain.ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+func newTestGenesis(t *testing.T, vs *testValidators) *blockchain.Genesis {
+	config := params.TestChainConfig.Copy()
+	config.Istanbul = &params.IstanbulConfig{Epoch: 30000, ProposerPolicy: uint64(params.RoundRobin), SubGroupSize: 22}
+
+	payload, err := rlp.EncodeToBytes(&types.IstanbulExtra{Validators: vs.addrs, Seal: []byte{}, CommittedSeal: [][]byte{}})
+	require.NoError(t, err)
+	return &blockchain.Genesis{
+		Config:     config,
+		BlockScore: common.Big1,
+		ExtraData:  append(make([]byte, types.IstanbulExtraVanity), payload...),
+		Alloc: blockchain.GenesisAlloc{
+			testAccount:  {Balance: big.NewInt(1000), Nonce: 3},
+			testContract: {Balance: common.Big0, Code: testCode, Storage: map[common.Hash]common.Hash{testSlot: testValue}},
+		},
+	}
+}
+
+// newTestServerChain makes a chain of the headers sealed by the validators.
+// If from is positive, the headers from it on are committed by the signers only.
+func newTestServerChain(t *testing.T, gspec *blockchain.Genesis, vs *testValidators, length int, from int, signers []common.Address) *testServerChain {
+	db := database.NewMemoryDBManager()
+	genesis := gspec.MustCommit(db)
+
+	chain := &testServerChain{genesis: genesis, headers: []*types.Header{genesis.Header()}, state: state.NewDatabase(db)}
+	for i := 1; i <= length; i++ {
+		committers := vs.signers(vs.addrs...)
+		if from > 0 && i >= from {
+			committers = vs.signers(signers...)
+		}
+		parent := chain.headers[len(chain.headers)-1]
+		chain.headers = append(chain.headers, makeSealedHeader(t, parent, vs.addrs, vs.keys[vs.addrs[i%len(vs.addrs)]], committers))
+	}
+	return chain
+}
+
+// newTestLightNode connects a light node to a server of the chain.
+func newTestLightNode(t *testing.T, gspec *blockchain.Genesis, chain *testServerChain) (*LightKaia, *peer) {
+	db := database.NewMemoryDBManager()
+	genesis := gspec.MustCommit(db)
+	lc, err := NewLightChain(db, gspec.Config, genesis)
+	require.NoError(t, err)
+	l := newLightKaia(&cn.Config{NetworkId: 1}, gspec.Config, db, lc)
+
+	var (
+		srv       = NewServer(chain, 1)
+		app, net  = p2p.MsgPipe()
+		serverErr = make(chan error, 1)
+	)
+	go func() {
+		serverErr <- srv.handle(newPeer(KLIGHT1, p2p.NewPeer(discover.NodeID{1}, "light", nil), app))
+	}()
+	go l.handle(newPeer(KLIGHT1, p2p.NewPeer(discover.NodeID{2}, "server", nil), net))
+	t.Cleanup(func() {
+		app.Close()
+		net.Close()
+		<-serverErr
+		srv.Stop()
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for l.peers.len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("light node not connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return l, l.peers.best()
+}
+
+func TestLightSyncAndState(t *testing.T) {
+	var (
+		vs    = newTestValidators(t, 4)
+		gspec = newTestGenesis(t, vs)
+		chain = newTestServerChain(t, gspec, vs, 2*MaxHeaderFetch+10, 0, nil)
+	)
+	l, p := newTestLightNode(t, gspec, chain)
+	require.NoError(t, l.synchronise(p))
+	assert.Equal(t, chain.CurrentHeader().Hash(), l.chain.CurrentHeader().Hash())
+	assert.Equal(t, chain.headers[100].Hash(), l.chain.GetHeaderByNumber(100).Hash())
+
+	var (
+		ctx    = context.Background()
+		api    = NewPublicLightAPI(l)
+		latest = rpc.NewBlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	balance, err := api.GetBalance(ctx, testAccount, latest)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), balance.ToInt().Int64())
+
+	nonce, err := api.GetTransactionCount(ctx, testAccount, latest)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), uint64(*nonce))
+
+	code, err := api.GetCode(ctx, testContract, latest)
+	require.NoError(t, err)
+	assert.Equal(t, testCode, []byte(code))
+
+	value, err := api.GetStorageAt(ctx, testContract, testSlot.Hex(), latest)
+	require.NoError(t, err)
+	assert.Equal(t, testValue.Bytes(), []byte(value))
+
+	// The missing accounts are proven absent
+	balance, err = api.GetBalance(ctx, common.HexToAddress("0xdead"), latest)
+	require.NoError(t, err)
+	assert.Zero(t, balance.ToInt().Sign())
+}
+
+func TestLightSyncInvalidSeals(t *testing.T) {
+	var (
+		vs    = newTestValidators(t, 4)
+		gspec = newTestGenesis(t, vs)
+		chain = newTestServerChain(t, gspec, vs, 20, 10, vs.addrs[:2])
+	)
+	l, p := newTestLightNode(t, gspec, chain)
+	assert.ErrorIs(t, l.synchronise(p), istanbulCore.ErrInvalidCommittedSeals)
+
+	// The verified headers before the invalid one are kept
+	assert.Equal(t, uint64(9), l.chain.CurrentHeader().Number.Uint64())
+}
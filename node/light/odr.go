@@ -0,0 +1,250 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/kaiachain/kaia/storage/statedb"
+)
+
+var errCodeMismatch = errors.New("retrieved code hash mismatch")
+
+// OdrBackend retrieves the state from the full nodes on demand.
+type OdrBackend interface {
+	// RetrieveProof writes the nodes of the verified Merkle proof to the database.
+	RetrieveProof(ctx context.Context, req ProofRequest, db database.DBManager) error
+
+	// RetrieveCode returns the contract code of the hash.
+	RetrieveCode(ctx context.Context, hash common.Hash) ([]byte, error)
+}
+
+// odrDatabase is a state.Database which retrieves the missing trie nodes and
+// the contract codes from the full nodes. The retrieved data is kept in memory
+// for the life of the database, which is created for each API request.
+type odrDatabase struct {
+	ctx     context.Context
+	backend OdrBackend
+	db      database.DBManager
+	trieDB  *statedb.Database
+
+	codeLock sync.Mutex
+	codes    map[common.Hash][]byte
+}
+
+// NewOdrDatabase returns a state database retrieving the state on demand.
+func NewOdrDatabase(ctx context.Context, backend OdrBackend) state.Database {
+	db := database.NewMemoryDBManager()
+	return &odrDatabase{
+		ctx:     ctx,
+		backend: backend,
+		db:      db,
+		trieDB:  statedb.NewDatabase(db),
+		codes:   make(map[common.Hash][]byte),
+	}
+}
+
+func (odr *odrDatabase) OpenTrie(root common.Hash, opts *statedb.TrieOpts) (state.Trie, error) {
+	return &odrTrie{db: odr, root: root.ExtendZero(), opts: opts}, nil
+}
+
+func (odr *odrDatabase) OpenStorageTrie(root common.ExtHash, opts *statedb.TrieOpts) (state.Trie, error) {
+	return &odrTrie{db: odr, root: root, storage: true, opts: opts}, nil
+}
+
+func (odr *odrDatabase) CopyTrie(t state.Trie) state.Trie {
+	switch t := t.(type) {
+	case *odrTrie:
+		cpy := *t
+		if t.trie != nil {
+			cpy.trie = t.trie.Copy()
+		}
+		return &cpy
+	default:
+		panic(fmt.Errorf("unknown trie type %T", t))
+	}
+}
+
+func (odr *odrDatabase) ContractCode(codeHash common.Hash) ([]byte, error) {
+	if codeHash == types.EmptyCodeHash {
+		return nil, nil
+	}
+	odr.codeLock.Lock()
+	code, ok := odr.codes[codeHash]
+	odr.codeLock.Unlock()
+	if ok {
+		return code, nil
+	}
+	code, err := odr.backend.RetrieveCode(odr.ctx, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	if hash := crypto.Keccak256Hash(code); hash != codeHash {
+		return nil, fmt.Errorf("%w: have %x, want %x", errCodeMismatch, hash, codeHash)
+	}
+	odr.codeLock.Lock()
+	odr.codes[codeHash] = code
+	odr.codeLock.Unlock()
+	return code, nil
+}
+
+func (odr *odrDatabase) DeleteCode(codeHash common.Hash) {
+	odr.codeLock.Lock()
+	delete(odr.codes, codeHash)
+	odr.codeLock.Unlock()
+}
+
+func (odr *odrDatabase) ContractCodeSize(codeHash common.Hash) (int, error) {
+	code, err := odr.ContractCode(codeHash)
+	return len(code), err
+}
+
+func (odr *odrDatabase) TrieDB() *statedb.Database {
+	return odr.trieDB
+}
+
+func (odr *odrDatabase) RLockGCCachedNode() {}
+
+func (odr *odrDatabase) RUnlockGCCachedNode() {}
+
+// odrTrie is a secure trie which retrieves the Merkle proof of a key when a
+// node on its path is missing. The trie is opened on the first access since
+// even its root node may be missing.
+type odrTrie struct {
+	db      *odrDatabase
+	root    common.ExtHash
+	storage bool
+	opts    *statedb.TrieOpts
+	trie    *statedb.SecureTrie
+}
+
+// do runs fn on the trie, retrieving the proof of the hashed key once if a
+// node is missing.
+func (t *odrTrie) do(hashKey []byte, fn func() error) error {
+	for retrieved := false; ; retrieved = true {
+		err := t.open()
+		if err == nil {
+			err = fn()
+		}
+		var missing *statedb.MissingNodeError
+		if !errors.As(err, &missing) || retrieved {
+			return err
+		}
+		req := ProofRequest{Root: t.root, Storage: t.storage, Key: hashKey}
+		if err := t.db.backend.RetrieveProof(t.db.ctx, req, t.db.db); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *odrTrie) open() error {
+	if t.trie != nil {
+		return nil
+	}
+	var err error
+	if t.storage {
+		// The retrieved nodes are stored with the plain hashes
+		t.trie, err = statedb.NewSecureStorageTrie(t.root.Unextend().ExtendZero(), t.db.trieDB, t.opts)
+	} else {
+		t.trie, err = statedb.NewSecureTrie(t.root.Unextend(), t.db.trieDB, t.opts)
+	}
+	return err
+}
+
+func (t *odrTrie) GetKey(shaKey []byte) []byte {
+	if t.trie == nil {
+		return nil
+	}
+	return t.trie.GetKey(shaKey)
+}
+
+func (t *odrTrie) TryGet(key []byte) (value []byte, err error) {
+	err = t.do(crypto.Keccak256(key), func() error {
+		value, err = t.trie.TryGet(key)
+		return err
+	})
+	return value, err
+}
+
+func (t *odrTrie) TryUpdate(key, value []byte) error {
+	return t.do(crypto.Keccak256(key), func() error {
+		return t.trie.TryUpdate(key, value)
+	})
+}
+
+func (t *odrTrie) TryUpdateWithKeys(key, hashKey, hexKey, value []byte) error {
+	return t.do(hashKey, func() error {
+		return t.trie.TryUpdateWithKeys(key, hashKey, hexKey, value)
+	})
+}
+
+func (t *odrTrie) TryDelete(key []byte) error {
+	return t.do(crypto.Keccak256(key), func() error {
+		return t.trie.TryDelete(key)
+	})
+}
+
+func (t *odrTrie) Hash() common.Hash {
+	if t.trie == nil {
+		return t.root.Unextend()
+	}
+	return t.trie.Hash()
+}
+
+func (t *odrTrie) HashExt() common.ExtHash {
+	if t.trie == nil {
+		return t.root
+	}
+	return t.trie.HashExt()
+}
+
+func (t *odrTrie) Commit(onleaf statedb.LeafCallback) (common.Hash, error) {
+	if t.trie == nil {
+		return t.root.Unextend(), nil
+	}
+	return t.trie.Commit(onleaf)
+}
+
+func (t *odrTrie) CommitExt(onleaf statedb.LeafCallback) (common.ExtHash, error) {
+	if t.trie == nil {
+		return t.root, nil
+	}
+	return t.trie.CommitExt(onleaf)
+}
+
+// NodeIterator iterates only the retrieved nodes of the trie.
+func (t *odrTrie) NodeIterator(startKey []byte) statedb.NodeIterator {
+	if err := t.open(); err != nil {
+		empty, _ := statedb.NewSecureTrie(common.Hash{}, t.db.trieDB, nil)
+		return empty.NodeIterator(startKey)
+	}
+	return t.trie.NodeIterator(startKey)
+}
+
+func (t *odrTrie) Prove(key []byte, fromLevel uint, proofDb database.DBManager) error {
+	return t.do(crypto.Keccak256(key), func() error {
+		return t.trie.Prove(key, fromLevel, proofDb)
+	})
+}
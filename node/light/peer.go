@@ -0,0 +1,308 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/log"
+	"github.com/kaiachain/kaia/networks/p2p"
+)
+
+const (
+	handshakeTimeout = 5 * time.Second
+	requestTimeout   = 10 * time.Second
+)
+
+var (
+	errPeerClosed      = errors.New("peer closed")
+	errRequestTimeout  = errors.New("request timed out")
+	errAlreadyRegister = errors.New("peer is already registered")
+	errNotRegistered   = errors.New("peer is not registered")
+)
+
+// peer is a collection of relevant information we have about a `klight` peer.
+type peer struct {
+	id string // Unique ID for the peer, cached
+
+	*p2p.Peer                   // The embedded P2P package peer
+	rw        p2p.MsgReadWriter // Input/output streams for klight
+	version   uint              // Protocol version negotiated
+
+	headLock sync.RWMutex
+	head     common.Hash // Latest head announced by the peer
+	number   uint64      // Number of the latest head announced by the peer
+
+	reqLock sync.Mutex
+	nextID  uint64                      // ID of the next request
+	pending map[uint64]chan interface{} // Channels waiting for the responses of the requests
+	closed  chan struct{}               // Closed when the peer is disconnected
+
+	logger log.Logger // Contextual logger with the peer id injected
+}
+
+func newPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
+	id := p.ID().String()
+	return &peer{
+		id:      id[:16],
+		Peer:    p,
+		rw:      rw,
+		version: version,
+		pending: make(map[uint64]chan interface{}),
+		closed:  make(chan struct{}),
+		logger:  logger.NewWith("peer", id[:16]),
+	}
+}
+
+// Head returns the latest head announced by the peer.
+func (p *peer) Head() (common.Hash, uint64) {
+	p.headLock.RLock()
+	defer p.headLock.RUnlock()
+	return p.head, p.number
+}
+
+// setHead updates the head of the peer, ignoring an older one.
+func (p *peer) setHead(hash common.Hash, number uint64) bool {
+	p.headLock.Lock()
+	defer p.headLock.Unlock()
+	if number < p.number {
+		return false
+	}
+	p.head, p.number = hash, number
+	return true
+}
+
+// Handshake exchanges the status with the remote peer, and checks that both
+// are on the same chain.
+func (p *peer) Handshake(networkId uint64, genesis, head common.Hash, number uint64) error {
+	errc := make(chan error, 2)
+	var status StatusPacket
+
+	go func() {
+		errc <- p2p.Send(p.rw, StatusMsg, &StatusPacket{
+			ProtocolVersion: uint32(p.version),
+			NetworkId:       networkId,
+			Genesis:         genesis,
+			Head:            head,
+			Number:          number,
+		})
+	}()
+	go func() {
+		errc <- p.readStatus(networkId, genesis, &status)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return p2p.DiscReadTimeout
+		}
+	}
+	p.setHead(status.Head, status.Number)
+	return nil
+}
+
+func (p *peer) readStatus(networkId uint64, genesis common.Hash, status *StatusPacket) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+	if msg.Code != StatusMsg {
+		return fmt.Errorf("%w: first msg has code %x (!= %x)", errNoStatusMsg, msg.Code, StatusMsg)
+	}
+	if msg.Size > maxMessageSize {
+		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
+	}
+	if err := msg.Decode(status); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	if status.Genesis != genesis {
+		return fmt.Errorf("%w: %x (!= %x)", errGenesisMismatch, status.Genesis, genesis)
+	}
+	if status.NetworkId != networkId {
+		return fmt.Errorf("%w: %d (!= %d)", errNetworkMismatch, status.NetworkId, networkId)
+	}
+	if uint(status.ProtocolVersion) != p.version {
+		return fmt.Errorf("%w: %d (!= %d)", errVersionMismatch, status.ProtocolVersion, p.version)
+	}
+	return nil
+}
+
+// Announce sends a new head block to the peer.
+func (p *peer) Announce(hash common.Hash, number uint64) error {
+	return p2p.Send(p.rw, AnnounceMsg, &AnnouncePacket{Hash: hash, Number: number})
+}
+
+// request sends a request built with a new request ID, and waits for its response.
+func (p *peer) request(ctx context.Context, code uint64, build func(id uint64) interface{}) (interface{}, error) {
+	resCh := make(chan interface{}, 1)
+
+	p.reqLock.Lock()
+	id := p.nextID
+	p.nextID++
+	p.pending[id] = resCh
+	p.reqLock.Unlock()
+
+	defer func() {
+		p.reqLock.Lock()
+		delete(p.pending, id)
+		p.reqLock.Unlock()
+	}()
+	if err := p2p.Send(p.rw, code, build(id)); err != nil {
+		return nil, err
+	}
+	timeout := time.NewTimer(requestTimeout)
+	defer timeout.Stop()
+
+	select {
+	case res := <-resCh:
+		return res, nil
+	case <-timeout.C:
+		return nil, errRequestTimeout
+	case <-p.closed:
+		return nil, errPeerClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// deliver passes a response to the request waiting for it.
+func (p *peer) deliver(id uint64, res interface{}) error {
+	p.reqLock.Lock()
+	resCh, ok := p.pending[id]
+	p.reqLock.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %d", errUnknownRequest, id)
+	}
+	select {
+	case resCh <- res:
+	default:
+	}
+	return nil
+}
+
+// close aborts the requests waiting for their responses.
+func (p *peer) close() {
+	close(p.closed)
+}
+
+// RequestHeaders retrieves at most amount canonical headers from the origin number.
+func (p *peer) RequestHeaders(ctx context.Context, origin, amount uint64) ([]*types.Header, error) {
+	p.logger.Trace("Fetching headers", "origin", origin, "amount", amount)
+	res, err := p.request(ctx, GetHeadersMsg, func(id uint64) interface{} {
+		return &GetHeadersPacket{ID: id, Origin: origin, Amount: amount}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*HeadersPacket).Headers, nil
+}
+
+// RequestProofs retrieves the merged nodes of the Merkle proofs.
+func (p *peer) RequestProofs(ctx context.Context, reqs []ProofRequest) ([][]byte, error) {
+	p.logger.Trace("Fetching proofs", "count", len(reqs))
+	res, err := p.request(ctx, GetProofsMsg, func(id uint64) interface{} {
+		return &GetProofsPacket{ID: id, Reqs: reqs}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*ProofsPacket).Nodes, nil
+}
+
+// RequestCode retrieves the contract codes by their hashes.
+func (p *peer) RequestCode(ctx context.Context, hashes []common.Hash) ([][]byte, error) {
+	p.logger.Trace("Fetching codes", "count", len(hashes))
+	res, err := p.request(ctx, GetCodeMsg, func(id uint64) interface{} {
+		return &GetCodePacket{ID: id, Hashes: hashes}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*CodePacket).Codes, nil
+}
+
+// peerSet represents the collection of the `klight` peers.
+type peerSet struct {
+	peers map[string]*peer
+	lock  sync.RWMutex
+}
+
+func newPeerSet() *peerSet {
+	return &peerSet{peers: make(map[string]*peer)}
+}
+
+func (ps *peerSet) register(p *peer) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if _, ok := ps.peers[p.id]; ok {
+		return errAlreadyRegister
+	}
+	ps.peers[p.id] = p
+	return nil
+}
+
+func (ps *peerSet) unregister(id string) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if _, ok := ps.peers[id]; !ok {
+		return errNotRegistered
+	}
+	delete(ps.peers, id)
+	return nil
+}
+
+func (ps *peerSet) all() []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
+func (ps *peerSet) len() int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+	return len(ps.peers)
+}
+
+// best returns the peer announcing the highest head.
+func (ps *peerSet) best() *peer {
+	var (
+		best   *peer
+		number uint64
+	)
+	for _, p := range ps.all() {
+		if _, n := p.Head(); best == nil || n > number {
+			best, number = p, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	KLIGHT1 = 1
+)
+
+// ProtocolName is the official short name of the `klight` protocol used during
+// devp2p capability negotiation.
+const ProtocolName = "klight"
+
+// ProtocolVersions are the supported versions of the `klight` protocol (first
+// is primary).
+var ProtocolVersions = []uint{KLIGHT1}
+
+// ProtocolLengths are the number of implemented message corresponding to
+// different protocol versions.
+var ProtocolLengths = map[uint]uint64{KLIGHT1: 8}
+
+// maxMessageSize is the maximum cap on the size of a protocol message.
+const maxMessageSize = 10 * 1024 * 1024
+
+const (
+	// MaxHeaderFetch is the maximum number of headers served per request.
+	MaxHeaderFetch = 192
+
+	// MaxProofFetch is the maximum number of proofs served per request.
+	MaxProofFetch = 64
+
+	// MaxCodeFetch is the maximum number of contract codes served per request.
+	MaxCodeFetch = 64
+)
+
+const (
+	StatusMsg     = 0x00
+	AnnounceMsg   = 0x01
+	GetHeadersMsg = 0x02
+	HeadersMsg    = 0x03
+	GetProofsMsg  = 0x04
+	ProofsMsg     = 0x05
+	GetCodeMsg    = 0x06
+	CodeMsg       = 0x07
+)
+
+var (
+	errMsgTooLarge     = errors.New("message too long")
+	errDecode          = errors.New("invalid message")
+	errInvalidMsgCode  = errors.New("invalid message code")
+	errNoStatusMsg     = errors.New("no status message")
+	errNetworkMismatch = errors.New("network id mismatch")
+	errGenesisMismatch = errors.New("genesis block mismatch")
+	errVersionMismatch = errors.New("protocol version mismatch")
+	errTooManyRequests = errors.New("too many items requested")
+	errUnknownRequest  = errors.New("response to an unknown request")
+)
+
+// StatusPacket is exchanged right after the connection to check that both
+// peers are on the same chain, and to announce the head of the server.
+type StatusPacket struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	Genesis         common.Hash
+	Head            common.Hash
+	Number          uint64
+}
+
+// AnnouncePacket announces a new head block of the server.
+type AnnouncePacket struct {
+	Hash   common.Hash
+	Number uint64
+}
+
+// GetHeadersPacket requests the canonical headers from the origin number.
+type GetHeadersPacket struct {
+	ID     uint64 // Request ID to match up responses with
+	Origin uint64 // Number of the first header to retrieve
+	Amount uint64 // Maximum number of headers to retrieve
+}
+
+// HeadersPacket is the response to GetHeadersPacket.
+type HeadersPacket struct {
+	ID      uint64
+	Headers []*types.Header
+}
+
+// ProofRequest requests the Merkle proof of a key in a trie.
+type ProofRequest struct {
+	Root    common.ExtHash // Root of the trie, the state root or a storage root
+	Storage bool           // Whether the root is a storage root
+	Key     []byte         // Hashed key of the trie entry to prove
+}
+
+// GetProofsPacket requests the Merkle proofs of the trie entries.
+type GetProofsPacket struct {
+	ID   uint64
+	Reqs []ProofRequest
+}
+
+// ProofsPacket is the response to GetProofsPacket. The nodes of all the
+// requested proofs are merged into one set.
+type ProofsPacket struct {
+	ID    uint64
+	Nodes [][]byte
+}
+
+// GetCodePacket requests the contract codes by their hashes.
+type GetCodePacket struct {
+	ID     uint64
+	Hashes []common.Hash
+}
+
+// CodePacket is the response to GetCodePacket, an empty code for a missing one.
+type CodePacket struct {
+	ID    uint64
+	Codes [][]byte
+}
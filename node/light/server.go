@@ -0,0 +1,276 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/event"
+	"github.com/kaiachain/kaia/networks/p2p"
+	"github.com/kaiachain/kaia/storage/statedb"
+)
+
+// softResponseLimit is the target maximum size of the proofs and codes served.
+const softResponseLimit = 2 * 1024 * 1024
+
+// BlockChain is the part of the full chain used to serve the light nodes.
+type BlockChain interface {
+	Genesis() *types.Block
+	CurrentHeader() *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+	StateCache() state.Database
+	ContractCode(hash common.Hash) ([]byte, error)
+	SubscribeChainHeadEvent(ch chan<- blockchain.ChainHeadEvent) event.Subscription
+}
+
+// Server serves the `klight` protocol to the light nodes from a full node.
+type Server struct {
+	chain     BlockChain
+	networkId uint64
+	peers     *peerSet
+
+	headSub event.Subscription
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewServer creates a server of the `klight` protocol.
+func NewServer(chain BlockChain, networkId uint64) *Server {
+	return &Server{
+		chain:     chain,
+		networkId: networkId,
+		peers:     newPeerSet(),
+		quit:      make(chan struct{}),
+	}
+}
+
+// Protocols returns the `klight` protocols served to the light nodes.
+func (s *Server) Protocols() []p2p.Protocol {
+	protocols := make([]p2p.Protocol, 0, len(ProtocolVersions))
+	for _, version := range ProtocolVersions {
+		version := version // Closure
+		protocols = append(protocols, p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[version],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				return s.handle(newPeer(version, p, rw))
+			},
+			RunWithRWs: func(p *p2p.Peer, rws []p2p.MsgReadWriter) error {
+				return s.handle(newPeer(version, p, rws[p2p.ConnDefault]))
+			},
+		})
+	}
+	return protocols
+}
+
+// Start starts announcing the new heads to the light nodes.
+func (s *Server) Start(srvr p2p.Server) {
+	heads := make(chan blockchain.ChainHeadEvent, 16)
+	s.headSub = s.chain.SubscribeChainHeadEvent(heads)
+
+	s.wg.Add(1)
+	go s.announceLoop(heads)
+}
+
+// Stop terminates the server.
+func (s *Server) Stop() {
+	close(s.quit)
+	if s.headSub != nil {
+		s.headSub.Unsubscribe()
+	}
+	s.wg.Wait()
+	logger.Info("Light server stopped")
+}
+
+// SetBloomBitsIndexer implements cn.LesServer. The bloom bits are not served.
+func (s *Server) SetBloomBitsIndexer(bbIndexer *blockchain.ChainIndexer) {}
+
+func (s *Server) announceLoop(heads chan blockchain.ChainHeadEvent) {
+	defer s.wg.Done()
+	for {
+		select {
+		case ev := <-heads:
+			hash, number := ev.Block.Hash(), ev.Block.NumberU64()
+			for _, p := range s.peers.all() {
+				if err := p.Announce(hash, number); err != nil {
+					p.logger.Debug("Failed to announce the head", "number", number, "err", err)
+				}
+			}
+		case <-s.headSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// handle is the callback invoked to manage the life cycle of a light node.
+// When this function terminates, the peer is disconnected.
+func (s *Server) handle(p *peer) error {
+	s.wg.Add(1)
+	defer s.wg.Done()
+
+	head := s.chain.CurrentHeader()
+	if err := p.Handshake(s.networkId, s.chain.Genesis().Hash(), head.Hash(), head.Number.Uint64()); err != nil {
+		p.logger.Debug("Light handshake failed", "err", err)
+		return err
+	}
+	if err := s.peers.register(p); err != nil {
+		return err
+	}
+	defer s.peers.unregister(p.id)
+
+	p.logger.Debug("Light node connected", "name", p.Name())
+	for {
+		select {
+		case <-s.quit:
+			return p2p.DiscQuitting
+		default:
+		}
+		if err := s.handleMsg(p); err != nil {
+			p.logger.Debug("Message handling failed in `klight`", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg serves a request of a light node.
+func (s *Server) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > maxMessageSize {
+		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case GetHeadersMsg:
+		var req GetHeadersPacket
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		return p2p.Send(p.rw, HeadersMsg, &HeadersPacket{ID: req.ID, Headers: s.serveHeaders(&req)})
+
+	case GetProofsMsg:
+		var req GetProofsPacket
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		if len(req.Reqs) > MaxProofFetch {
+			return fmt.Errorf("%w: %d proofs", errTooManyRequests, len(req.Reqs))
+		}
+		return p2p.Send(p.rw, ProofsMsg, &ProofsPacket{ID: req.ID, Nodes: s.serveProofs(req.Reqs)})
+
+	case GetCodeMsg:
+		var req GetCodePacket
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		if len(req.Hashes) > MaxCodeFetch {
+			return fmt.Errorf("%w: %d codes", errTooManyRequests, len(req.Hashes))
+		}
+		return p2p.Send(p.rw, CodeMsg, &CodePacket{ID: req.ID, Codes: s.serveCode(req.Hashes)})
+
+	default:
+		return fmt.Errorf("%w: %v", errInvalidMsgCode, msg.Code)
+	}
+}
+
+func (s *Server) serveHeaders(req *GetHeadersPacket) []*types.Header {
+	amount := req.Amount
+	if amount > MaxHeaderFetch {
+		amount = MaxHeaderFetch
+	}
+	headers := make([]*types.Header, 0, amount)
+	for number := req.Origin; uint64(len(headers)) < amount; number++ {
+		header := s.chain.GetHeaderByNumber(number)
+		if header == nil {
+			break
+		}
+		headers = append(headers, header)
+	}
+	return headers
+}
+
+// proofSet collects the distinct nodes of the Merkle proofs.
+type proofSet struct {
+	nodes map[string][]byte
+	order [][]byte
+	size  int
+}
+
+func (ps *proofSet) WriteMerkleProof(key, value []byte) {
+	if _, ok := ps.nodes[string(key)]; ok {
+		return
+	}
+	ps.nodes[string(key)] = value
+	ps.order = append(ps.order, value)
+	ps.size += len(value)
+}
+
+func (s *Server) serveProofs(reqs []ProofRequest) [][]byte {
+	var (
+		triedb = s.chain.StateCache().TrieDB()
+		proofs = &proofSet{nodes: make(map[string][]byte)}
+	)
+	for _, req := range reqs {
+		var (
+			tr  *statedb.Trie
+			err error
+		)
+		if req.Storage {
+			tr, err = statedb.NewStorageTrie(req.Root, triedb, nil)
+		} else {
+			tr, err = statedb.NewTrie(req.Root.Unextend(), triedb, nil)
+		}
+		if err != nil {
+			logger.Debug("Failed to open the trie to prove", "root", req.Root, "err", err)
+			continue
+		}
+		if err := tr.Prove(req.Key, 0, proofs); err != nil {
+			logger.Debug("Failed to prove the key", "root", req.Root, "key", common.Bytes2Hex(req.Key), "err", err)
+			continue
+		}
+		if proofs.size > softResponseLimit {
+			break
+		}
+	}
+	return proofs.order
+}
+
+func (s *Server) serveCode(hashes []common.Hash) [][]byte {
+	var (
+		codes = make([][]byte, 0, len(hashes))
+		size  = 0
+	)
+	for _, hash := range hashes {
+		code, _ := s.chain.ContractCode(hash)
+		codes = append(codes, code)
+		if size += len(code); size > softResponseLimit {
+			break
+		}
+	}
+	return codes
+}
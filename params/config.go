@@ -233,6 +233,10 @@ type ChainConfig struct {
 	RandaoCompatibleBlock *big.Int        `json:"randaoCompatibleBlock,omitempty"` // RandaoCompatible activate block (nil = no fork)
 	RandaoRegistry        *RegistryConfig `json:"randaoRegistry,omitempty"`        // Registry initial states
 
+	// CustomPrecompiles are the chain-specific precompiled contracts of a service chain.
+	// They are not allowed on the mainnet and the testnet.
+	CustomPrecompiles []*CustomPrecompileConfig `json:"customPrecompiles,omitempty"`
+
 	// Various consensus engines
 	Gxhash   *GxhashConfig   `json:"gxhash,omitempty"` // (deprecated) not supported engine
 	Clique   *CliqueConfig   `json:"clique,omitempty"`
@@ -297,6 +301,15 @@ type RegistryConfig struct {
 	Owner   common.Address            `json:"owner"`
 }
 
+// CustomPrecompileConfig activates the precompiled contract registered under Name
+// at Address from Block. The implementation must be registered to the vm package
+// before the chain is set up.
+type CustomPrecompileConfig struct {
+	Name    string         `json:"name"`
+	Address common.Address `json:"address"`
+	Block   *big.Int       `json:"block"`
+}
+
 // GxhashConfig is the consensus engine configs for proof-of-work based sealing.
 // Deprecated: Use IstanbulConfig or CliqueConfig.
 type GxhashConfig struct{}
@@ -438,6 +451,22 @@ func (c *ChainConfig) IsPragueForkEnabled(num *big.Int) bool {
 	return isForked(c.PragueCompatibleBlock, num)
 }
 
+// ActiveCustomPrecompiles returns the names of the custom precompiled contracts
+// enabled at num, keyed by their addresses. It returns nil if none is enabled.
+func (c *ChainConfig) ActiveCustomPrecompiles(num *big.Int) map[common.Address]string {
+	var active map[common.Address]string
+	for _, p := range c.CustomPrecompiles {
+		if p == nil || !isForked(p.Block, num) {
+			continue
+		}
+		if active == nil {
+			active = make(map[common.Address]string)
+		}
+		active[p.Address] = p.Name
+	}
+	return active
+}
+
 // IsKIP103ForkBlock returns whether num is equal to the kip103 block.
 func (c *ChainConfig) IsKIP103ForkBlock(num *big.Int) bool {
 	return isForkBlock(c.Kip103CompatibleBlock, num)
@@ -523,6 +552,37 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 	return nil
 }
 
+// CheckCustomPrecompiles checks that the custom precompiled contracts are allowed on the chain
+// and that each of them has a name, an activation block and a distinct address in the
+// precompiled contract address range.
+func (c *ChainConfig) CheckCustomPrecompiles() error {
+	if len(c.CustomPrecompiles) == 0 {
+		return nil
+	}
+	if c.ChainID != nil && c.ChainID.IsUint64() {
+		if id := c.ChainID.Uint64(); id == MainnetNetworkId || id == KairosNetworkId {
+			return fmt.Errorf("custom precompiles are not allowed on chain id %d", id)
+		}
+	}
+	seen := make(map[common.Address]bool)
+	for i, p := range c.CustomPrecompiles {
+		switch {
+		case p == nil:
+			return fmt.Errorf("custom precompile #%d is empty", i)
+		case p.Name == "":
+			return fmt.Errorf("custom precompile at %s has no name", p.Address.Hex())
+		case p.Block == nil:
+			return fmt.Errorf("custom precompile %q has no activation block", p.Name)
+		case !common.IsPrecompiledContractAddress(p.Address):
+			return fmt.Errorf("custom precompile %q: address %s is out of the precompiled contract address range", p.Name, p.Address.Hex())
+		case seen[p.Address]:
+			return fmt.Errorf("custom precompile %q: duplicated address %s", p.Name, p.Address.Hex())
+		}
+		seen[p.Address] = true
+	}
+	return nil
+}
+
 func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
 	if isForkIncompatible(c.IstanbulCompatibleBlock, newcfg.IstanbulCompatibleBlock, head) {
 		return newCompatError("Istanbul Block", c.IstanbulCompatibleBlock, newcfg.IstanbulCompatibleBlock)
@@ -556,6 +616,43 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.PragueCompatibleBlock, newcfg.PragueCompatibleBlock, head) {
 		return newCompatError("Prague Block", c.PragueCompatibleBlock, newcfg.PragueCompatibleBlock)
 	}
+	if err := checkCustomPrecompilesCompatible(c.CustomPrecompiles, newcfg.CustomPrecompiles, head); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkCustomPrecompilesCompatible returns an error if a custom precompiled contract
+// activated before head is moved, renamed or removed.
+func checkCustomPrecompilesCompatible(stored, newcfg []*CustomPrecompileConfig, head *big.Int) *ConfigCompatError {
+	index := func(ps []*CustomPrecompileConfig) map[common.Address]*CustomPrecompileConfig {
+		m := make(map[common.Address]*CustomPrecompileConfig, len(ps))
+		for _, p := range ps {
+			if p != nil {
+				m[p.Address] = p
+			}
+		}
+		return m
+	}
+	storedMap, newMap := index(stored), index(newcfg)
+	blockOf := func(p *CustomPrecompileConfig) *big.Int {
+		if p == nil {
+			return nil
+		}
+		return p.Block
+	}
+	for _, ps := range []map[common.Address]*CustomPrecompileConfig{storedMap, newMap} {
+		for addr := range ps {
+			s, n := storedMap[addr], newMap[addr]
+			what := "Custom Precompile " + addr.Hex()
+			if isForkIncompatible(blockOf(s), blockOf(n), head) {
+				return newCompatError(what, blockOf(s), blockOf(n))
+			}
+			if s != nil && n != nil && s.Name != n.Name && isForked(s.Block, head) {
+				return newCompatError(what, s.Block, n.Block)
+			}
+		}
+	}
 	return nil
 }
 
@@ -695,6 +792,9 @@ type Rules struct {
 	IsKaia      bool
 	IsRandao    bool
 	IsPrague    bool
+
+	// CustomPrecompiles holds the names of the custom precompiled contracts keyed by their addresses.
+	CustomPrecompiles map[common.Address]string
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -715,6 +815,8 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsKaia:      c.IsKaiaForkEnabled(num),
 		IsRandao:    c.IsRandaoForkEnabled(num),
 		IsPrague:    c.IsPragueForkEnabled(num),
+
+		CustomPrecompiles: c.ActiveCustomPrecompiles(num),
 	}
 }
 
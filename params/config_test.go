@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/kaiachain/kaia/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -78,3 +79,55 @@ func BenchmarkChainConfig_Copy(b *testing.B) {
 		a.Copy()
 	}
 }
+
+func TestChainConfig_CheckCustomPrecompiles(t *testing.T) {
+	addr := common.BytesToAddress([]byte{3, 0})
+	newConfig := func(chainID uint64, ps ...*CustomPrecompileConfig) *ChainConfig {
+		return &ChainConfig{ChainID: new(big.Int).SetUint64(chainID), CustomPrecompiles: ps}
+	}
+
+	assert.Nil(t, newConfig(MainnetNetworkId).CheckCustomPrecompiles())
+	assert.Nil(t, newConfig(ServiceChainDefaultNetworkId, &CustomPrecompileConfig{"oracle", addr, common.Big0}).CheckCustomPrecompiles())
+
+	for _, tc := range []*ChainConfig{
+		newConfig(MainnetNetworkId, &CustomPrecompileConfig{"oracle", addr, common.Big0}),
+		newConfig(KairosNetworkId, &CustomPrecompileConfig{"oracle", addr, common.Big0}),
+		newConfig(ServiceChainDefaultNetworkId, nil),
+		newConfig(ServiceChainDefaultNetworkId, &CustomPrecompileConfig{"", addr, common.Big0}),
+		newConfig(ServiceChainDefaultNetworkId, &CustomPrecompileConfig{"oracle", addr, nil}),
+		newConfig(ServiceChainDefaultNetworkId, &CustomPrecompileConfig{"oracle", common.Address{}, common.Big0}),
+		newConfig(ServiceChainDefaultNetworkId, &CustomPrecompileConfig{"oracle", common.BytesToAddress([]byte{4, 0}), common.Big0}),
+		newConfig(ServiceChainDefaultNetworkId,
+			&CustomPrecompileConfig{"oracle", addr, common.Big0},
+			&CustomPrecompileConfig{"hash", addr, common.Big1}),
+	} {
+		assert.Error(t, tc.CheckCustomPrecompiles())
+	}
+}
+
+func TestChainConfig_CustomPrecompilesRules(t *testing.T) {
+	oracle, hash := common.BytesToAddress([]byte{3, 0}), common.BytesToAddress([]byte{3, 1})
+	config := &ChainConfig{CustomPrecompiles: []*CustomPrecompileConfig{
+		{"oracle", oracle, big.NewInt(5)},
+		{"hash", hash, big.NewInt(10)},
+	}}
+
+	assert.Nil(t, config.Rules(big.NewInt(4)).CustomPrecompiles)
+	assert.Equal(t, map[common.Address]string{oracle: "oracle"}, config.Rules(big.NewInt(5)).CustomPrecompiles)
+	assert.Equal(t, map[common.Address]string{oracle: "oracle", hash: "hash"}, config.Rules(big.NewInt(10)).CustomPrecompiles)
+
+	// Moving or renaming an activated precompile is incompatible, while rescheduling a future one is not.
+	moved := config.Copy()
+	moved.CustomPrecompiles[0].Block = big.NewInt(6)
+	assert.NotNil(t, config.CheckCompatible(moved, 7))
+	assert.Nil(t, config.CheckCompatible(moved, 4))
+
+	renamed := config.Copy()
+	renamed.CustomPrecompiles[0].Name = "oracle2"
+	assert.NotNil(t, config.CheckCompatible(renamed, 5))
+
+	removed := config.Copy()
+	removed.CustomPrecompiles = removed.CustomPrecompiles[:1]
+	assert.NotNil(t, config.CheckCompatible(removed, 10))
+	assert.Nil(t, config.CheckCompatible(removed, 9))
+}
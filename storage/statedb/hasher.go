@@ -314,3 +314,38 @@ func unextendNode(original node, preserveExtHash bool) node {
 		return n
 	}
 }
+
+// NodeHash returns the merkle hash of an encoded trie node as stored in the database.
+// The stored encoding may contain ExtHash references and extended account serializations,
+// which are stripped before hashing as done when the node was committed.
+func NodeHash(enc []byte) (common.Hash, error) {
+	n, err := decodeNode(nil, enc)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	h := newHasher(nil)
+	defer returnHasherToPool(h)
+
+	h.nodeForHashing(compactNode(n)).encode(h.encbuf)
+	return common.BytesToExtHash(h.hashData(h.encodedBytes(), false)).Unextend(), nil
+}
+
+// compactNode converts the keys of a decoded node and its embedded children
+// back to the compact encoding.
+func compactNode(n node) node {
+	switch n := n.(type) {
+	case *shortNode:
+		compacted := n.copy()
+		compacted.Key = hexToCompact(n.Key)
+		compacted.Val = compactNode(n.Val)
+		return compacted
+	case *fullNode:
+		compacted := n.copy()
+		for i, child := range n.Children {
+			compacted.Children[i] = compactNode(child)
+		}
+		return compacted
+	default:
+		return n
+	}
+}
@@ -84,3 +84,21 @@ func TestHasherHashTC(t *testing.T) {
 		checkHasherHash(t, name, tc, optsStorage, false)
 	}
 }
+
+func TestNodeHash(t *testing.T) {
+	tcSets := []map[string]testNodeEncodingTC{
+		collapsedNodeTCs_unext(), resolvedNodeTCs_unext(),
+		collapsedNodeTCs_extroot(), resolvedNodeTCs_extroot(),
+		collapsedNodeTCs_exthash(), resolvedNodeTCs_exthash(),
+	}
+	for _, tcs := range tcSets {
+		for name, tc := range tcs {
+			hash, err := NodeHash(tc.encoded)
+			require.NoError(t, err, name)
+			assert.Equal(t, common.BytesToExtHash(tc.hash).Unextend(), hash, name)
+		}
+	}
+
+	_, err := NodeHash([]byte{0xc0})
+	assert.Error(t, err)
+}
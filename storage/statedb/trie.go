@@ -39,6 +39,10 @@ type TrieOpts struct {
 	// will schedule obsolete nodes to be pruned when the given block number becomes obsolete.
 	// This option is only viable when the pruning is enabled on database.
 	PruningBlockNumber uint64
+
+	// If NodeRecorder is set, it is called with the hash of every trie node
+	// resolved from the database. It is used to collect execution witnesses.
+	NodeRecorder func(hash common.ExtHash)
 }
 
 // LeafCallback is a callback type invoked when a trie operation reaches a leaf
@@ -542,6 +546,9 @@ func (t *Trie) resolveHash(n hashNode, prefix []byte) (node, error) {
 		memcacheCleanPrefetchMissMeter.Mark(1)
 	}
 	if node != nil {
+		if t.NodeRecorder != nil {
+			t.NodeRecorder(hash)
+		}
 		return node, nil
 	}
 	return nil, &MissingNodeError{NodeHash: hash.Unextend(), Path: prefix}
@@ -54,6 +54,16 @@ import (
 // genesis.json, b1.rlp, and b2.rlp has raw data of genesis, and consecutive two blocks after the genesis block.
 // If anything is failed, it can be considered that a hard fork occurs.
 func TestHardForkBlock(t *testing.T) {
+	testHardForkBlock(t, vm.Config{})
+}
+
+// TestHardForkBlockParallel tests that the blocks executed in parallel result in
+// the same state and receipts as recorded in the headers.
+func TestHardForkBlockParallel(t *testing.T) {
+	testHardForkBlock(t, vm.Config{ParallelExecution: true})
+}
+
+func testHardForkBlock(t *testing.T, vmConfig vm.Config) {
 	log.EnableLogForTest(log.LvlCrit, log.LvlTrace)
 	var genesis blockchain.Genesis
 
@@ -112,7 +122,7 @@ func TestHardForkBlock(t *testing.T) {
 		GovModule:      govModule,
 		NodeType:       common.CONSENSUSNODE,
 	})
-	chain, err := blockchain.NewBlockChain(chainDb, nil, chainConfig, engine, vm.Config{})
+	chain, err := blockchain.NewBlockChain(chainDb, nil, chainConfig, engine, vmConfig)
 	require.NoError(t, err)
 
 	mStaking := staking_impl.NewStakingModule()
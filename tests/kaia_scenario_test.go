@@ -31,6 +31,7 @@ import (
 
 	"github.com/kaiachain/kaia/accounts/abi"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/types/accountkey"
 	"github.com/kaiachain/kaia/blockchain/vm"
@@ -1693,11 +1694,11 @@ func TestValidateSender(t *testing.T) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(database.NewMemoryDBManager()), nil, nil)
 	statedb.CreateEOA(anon.Addr, false, anon.AccKey)
 	statedb.SetNonce(anon.Addr, nonce)
-	statedb.SetBalance(anon.Addr, initialBalance)
+	statedb.SetBalance(anon.Addr, initialBalance, tracing.BalanceChangeUnspecified)
 
 	statedb.CreateEOA(decoupled.Addr, false, decoupled.AccKey)
 	statedb.SetNonce(decoupled.Addr, rand.Uint64())
-	statedb.SetBalance(decoupled.Addr, initialBalance)
+	statedb.SetBalance(decoupled.Addr, initialBalance, tracing.BalanceChangeUnspecified)
 
 	signer := types.MakeSigner(params.BFTTestChainConfig, big.NewInt(32))
 	gasPrice := new(big.Int).SetUint64(0)
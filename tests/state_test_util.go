@@ -33,6 +33,7 @@ import (
 
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
@@ -314,7 +315,7 @@ func (t *StateTest) RunNoVerify(subtest StateSubtest, vmconfig vm.Config, isTest
 	// - the coinbase self-destructed, or
 	// - there are only 'bad' transactions, which aren't executed. In those cases,
 	//   the coinbase gets no txfee, so isn't created, and thus needs to be touched
-	st.AddBalance(block.Rewardbase(), new(big.Int))
+	st.AddBalance(block.Rewardbase(), new(big.Int), tracing.BalanceChangeUnspecified)
 	// And _now_ get the state root
 	root = st.IntermediateRoot(true)
 
@@ -349,7 +350,7 @@ func MakePreState(db database.DBManager, accounts blockchain.GenesisAlloc, isTes
 			statedb.SetState(addr, k, v)
 		}
 		statedb.SetNonce(addr, a.Nonce)
-		statedb.SetBalance(addr, a.Balance)
+		statedb.SetBalance(addr, a.Balance, tracing.BalanceIncreaseGenesisBalance)
 	}
 	// Commit and re-open to start with a clean state.
 	root, _ := statedb.Commit(false)
@@ -521,7 +522,7 @@ func useEthMiningReward(statedb *state.StateDB, evm *vm.EVM, tx *stTransaction,
 
 	fee := new(big.Int).SetUint64(usedGas)
 	fee.Mul(fee, effectiveTip)
-	statedb.AddBalance(evm.Context.Coinbase, fee)
+	statedb.AddBalance(evm.Context.Coinbase, fee, tracing.BalanceIncreaseRewardTransactionFee)
 }
 
 func useEthStateRoot(statedb *state.StateDB) (common.Hash, error) {
@@ -0,0 +1,293 @@
+// Copyright 2024 The Kaia Authors
+// This file is part of the Kaia library.
+//
+// The Kaia library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Kaia library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Kaia library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/kaiachain/kaia/blockchain"
+	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/tracing"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/blockchain/types/accountkey"
+	"github.com/kaiachain/kaia/blockchain/vm"
+	"github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/consensus/gxhash"
+	"github.com/kaiachain/kaia/kerrors"
+	"github.com/kaiachain/kaia/params"
+	"github.com/kaiachain/kaia/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The fuzz targets below only run their seed inputs in a normal test run. To fuzz, run e.g.
+//
+//	go test -run '^$' -fuzz '^FuzzTxValidate$' ./tests
+//
+// A failing input is written under testdata/fuzz/<target>. Commit it along with the fix
+// so that it is replayed as a seed input afterwards.
+
+const (
+	fuzzSenderKey   = "a5c9a50938a089618167c9d67dbebc0deaffc3c76ddc6b40c2777ae59438e989"
+	fuzzFeePayerKey = "ed580f5bd71a2ee4dae5cb43e331b7d0318596e561e6add7844271ed94156b20"
+)
+
+// fuzzValidateTxTypes are the transaction types whose Validate does not depend on the tx data.
+var fuzzValidateTxTypes = []types.TxType{
+	types.TxTypeLegacyTransaction,
+	types.TxTypeValueTransfer, types.TxTypeFeeDelegatedValueTransfer, types.TxTypeFeeDelegatedValueTransferWithRatio,
+	types.TxTypeValueTransferMemo, types.TxTypeFeeDelegatedValueTransferMemo, types.TxTypeFeeDelegatedValueTransferMemoWithRatio,
+	types.TxTypeAccountUpdate, types.TxTypeFeeDelegatedAccountUpdate, types.TxTypeFeeDelegatedAccountUpdateWithRatio,
+	types.TxTypeSmartContractDeploy, types.TxTypeFeeDelegatedSmartContractDeploy, types.TxTypeFeeDelegatedSmartContractDeployWithRatio,
+	types.TxTypeSmartContractExecution, types.TxTypeFeeDelegatedSmartContractExecution, types.TxTypeFeeDelegatedSmartContractExecutionWithRatio,
+	types.TxTypeCancel, types.TxTypeFeeDelegatedCancel, types.TxTypeFeeDelegatedCancelWithRatio,
+	types.TxTypeChainDataAnchoring, types.TxTypeFeeDelegatedChainDataAnchoring, types.TxTypeFeeDelegatedChainDataAnchoringWithRatio,
+	types.TxTypeEthereumAccessList, types.TxTypeEthereumDynamicFee, types.TxTypeEthereumSetCode,
+}
+
+// fuzzExecutionTxTypes are the transaction types that only move the value and pay the fee.
+var fuzzExecutionTxTypes = []types.TxType{
+	types.TxTypeLegacyTransaction,
+	types.TxTypeValueTransfer, types.TxTypeFeeDelegatedValueTransfer, types.TxTypeFeeDelegatedValueTransferWithRatio,
+	types.TxTypeValueTransferMemo, types.TxTypeFeeDelegatedValueTransferMemo, types.TxTypeFeeDelegatedValueTransferMemoWithRatio,
+	types.TxTypeCancel, types.TxTypeFeeDelegatedCancel, types.TxTypeFeeDelegatedCancelWithRatio,
+}
+
+// setFuzzRecipient overrides the recipient of the value map, whichever type it is.
+func setFuzzRecipient(values txValueMap, to common.Address) {
+	switch values[types.TxValueKeyTo].(type) {
+	case common.Address:
+		values[types.TxValueKeyTo] = to
+	case *common.Address:
+		values[types.TxValueKeyTo] = &to
+	}
+}
+
+// FuzzTxValidate tests that TxInternalData.Validate does not modify the state, gives the same result
+// for a decoded transaction, and checks the recipient of value transfers and contract executions.
+func FuzzTxValidate(f *testing.F) {
+	for i := range fuzzValidateTxTypes {
+		for kind := uint8(0); kind < 4; kind++ {
+			f.Add(uint8(i), kind, []byte{0x12, 0x34}, uint64(100000), []byte("1234"), uint8(30))
+		}
+	}
+
+	var (
+		sender, _   = createAnonymousAccount(fuzzSenderKey)
+		feePayer, _ = createAnonymousAccount(fuzzFeePayerKey)
+		recipient   = common.HexToAddress("0x7b65B75d204aBed71587c9E519a89277766EE1d0")
+		contract    = common.HexToAddress("0x000000000000000000000000000000000000c0de")
+		rules       = params.TestChainConfig.Rules(common.Big0)
+		signer      = types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+	)
+
+	f.Fuzz(func(t *testing.T, typeIdx, toKind uint8, toBytes []byte, amount uint64, data []byte, feeRatio uint8) {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(database.NewMemoryDBManager()), nil, nil)
+		statedb.AddBalance(sender.Addr, big.NewInt(params.KAIA), tracing.BalanceChangeUnspecified)
+		statedb.AddBalance(feePayer.Addr, big.NewInt(params.KAIA), tracing.BalanceChangeUnspecified)
+		statedb.AddBalance(recipient, big.NewInt(params.KAIA), tracing.BalanceChangeUnspecified)
+		statedb.CreateSmartContractAccount(contract, params.CodeFormatEVM, rules)
+		statedb.SetCode(contract, common.FromHex(code))
+		root := statedb.IntermediateRoot(true)
+
+		var to common.Address
+		switch toKind % 4 {
+		case 0:
+			to = recipient
+		case 1:
+			to = contract
+		case 2:
+			// One of the precompiled contract addresses
+			to = common.BytesToAddress([]byte{byte(amount%10) + 1})
+		default:
+			to = common.BytesToAddress(toBytes)
+		}
+
+		txType := fuzzValidateTxTypes[int(typeIdx)%len(fuzzValidateTxTypes)]
+		sender.Nonce = 0
+		values, _ := genMapForTxTypes(sender, &TestAccountType{Addr: to}, txType)
+		setFuzzRecipient(values, to)
+		if _, ok := values[types.TxValueKeyAmount]; ok {
+			values[types.TxValueKeyAmount] = new(big.Int).SetUint64(amount)
+		}
+		if _, ok := values[types.TxValueKeyData]; ok {
+			values[types.TxValueKeyData] = data
+		}
+		if _, ok := values[types.TxValueKeyAccountKey]; ok {
+			values[types.TxValueKeyAccountKey] = accountkey.NewAccountKeyPublicWithValue(&feePayer.Keys[0].PublicKey)
+		}
+		if txType.IsFeeDelegatedTransaction() {
+			values[types.TxValueKeyFeePayer] = feePayer.Addr
+		}
+		if txType.IsFeeDelegatedWithRatioTransaction() {
+			values[types.TxValueKeyFeeRatioOfFeePayer] = types.FeeRatio(feeRatio)
+		}
+		tx, err := types.NewTransactionWithMap(txType, values)
+		if err != nil {
+			return
+		}
+		require.NoError(t, tx.SignWithKeys(signer, sender.Keys))
+		if txType.IsFeeDelegatedTransaction() {
+			require.NoError(t, tx.SignFeePayerWithKeys(signer, feePayer.Keys))
+		}
+
+		err = tx.Validate(statedb, 0)
+		assert.Equal(t, root, statedb.IntermediateRoot(true), "Validate must not modify the state")
+
+		b, err2 := tx.MarshalBinary()
+		require.NoError(t, err2)
+		dec := new(types.Transaction)
+		require.NoError(t, dec.UnmarshalBinary(b))
+		assert.Equal(t, err, dec.Validate(statedb, 0))
+
+		switch basicType := toBasicType(txType); basicType {
+		case types.TxTypeValueTransfer, types.TxTypeValueTransferMemo:
+			switch {
+			case common.IsPrecompiledContractAddress(to):
+				assert.ErrorIs(t, err, kerrors.ErrPrecompiledContractAddress)
+			case to == contract:
+				assert.ErrorIs(t, err, kerrors.ErrNotEOAWithoutCode)
+			default:
+				assert.NoError(t, err)
+			}
+		case types.TxTypeSmartContractExecution:
+			if to == contract {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, kerrors.ErrNotProgramAccount)
+			}
+		}
+	})
+}
+
+// FuzzFeeDelegatedExecution applies a block of value transfers through the StateProcessor, and
+// compares the resulting balances with the ones computed from the receipts and the fee ratios.
+// Each 4 bytes of ops describe a transaction: the type, the recipient, the fee ratio and the amount.
+func FuzzFeeDelegatedExecution(f *testing.F) {
+	for i := range fuzzExecutionTxTypes {
+		f.Add([]byte{byte(i), 0, 30, 100})
+	}
+	f.Add([]byte{2, 1, 0, 1, 5, 2, 99, 2, 9, 0, 50, 255})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		if len(ops) < 4 || len(ops) > 64 {
+			return
+		}
+
+		var (
+			sender, _   = createAnonymousAccount(fuzzSenderKey)
+			feePayer, _ = createAnonymousAccount(fuzzFeePayerKey)
+			recipient   = common.HexToAddress("0x7b65B75d204aBed71587c9E519a89277766EE1d0")
+			accounts    = []common.Address{recipient, sender.Addr, feePayer.Addr}
+			balance     = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.KAIA))
+			gasPrice    = big.NewInt(25 * params.Gkei)
+
+			config = params.TestChainConfig.Copy()
+			gspec  = &blockchain.Genesis{Config: config, Alloc: blockchain.GenesisAlloc{
+				sender.Addr:   {Balance: balance},
+				feePayer.Addr: {Balance: balance},
+			}}
+			db      = database.NewMemoryDBManager()
+			genesis = gspec.MustCommit(db)
+			signer  = types.LatestSignerForChainID(config.ChainID)
+			engine  = gxhash.NewFaker()
+		)
+
+		var txs types.Transactions
+		for i := 0; i+4 <= len(ops); i += 4 {
+			txType := fuzzExecutionTxTypes[int(ops[i])%len(fuzzExecutionTxTypes)]
+			to := accounts[int(ops[i+1])%len(accounts)]
+
+			values, _ := genMapForTxTypes(sender, &TestAccountType{Addr: to}, txType)
+			if _, ok := values[types.TxValueKeyAmount]; ok {
+				values[types.TxValueKeyAmount] = new(big.Int).Mul(big.NewInt(int64(ops[i+3])), big.NewInt(params.KAIA))
+			}
+			values[types.TxValueKeyGasLimit] = uint64(1000000)
+			values[types.TxValueKeyGasPrice] = gasPrice
+			if txType.IsFeeDelegatedTransaction() {
+				values[types.TxValueKeyFeePayer] = feePayer.Addr
+			}
+			if txType.IsFeeDelegatedWithRatioTransaction() {
+				values[types.TxValueKeyFeeRatioOfFeePayer] = types.FeeRatio(ops[i+2]%99 + 1)
+			}
+			tx, err := types.NewTransactionWithMap(txType, values)
+			require.NoError(t, err)
+			require.NoError(t, tx.SignWithKeys(signer, sender.Keys))
+			if txType.IsFeeDelegatedTransaction() {
+				require.NoError(t, tx.SignFeePayerWithKeys(signer, feePayer.Keys))
+			}
+			txs = append(txs, tx)
+			sender.AddNonce()
+		}
+
+		blocks, _ := blockchain.GenerateChain(config, genesis, engine, db, 1, func(i int, gen *blockchain.BlockGen) {
+			for _, tx := range txs {
+				gen.AddTx(tx)
+			}
+		})
+
+		chain, err := blockchain.NewBlockChain(db, nil, config, engine, vm.Config{})
+		require.NoError(t, err)
+		defer chain.Stop()
+		_, err = chain.InsertChain(blocks)
+		require.NoError(t, err)
+
+		// Compute the expected balances from the receipts.
+		receipts := chain.GetReceiptsByBlockHash(blocks[0].Hash())
+		require.Len(t, receipts, len(txs))
+		expected := map[common.Address]*big.Int{
+			recipient:     new(big.Int),
+			sender.Addr:   new(big.Int).Set(balance),
+			feePayer.Addr: new(big.Int).Set(balance),
+		}
+		totalFee := new(big.Int)
+		for i, tx := range txs {
+			receipt := receipts[i]
+			// Cancel transactions have no recipient.
+			if to := tx.To(); to != nil && receipt.Status == types.ReceiptStatusSuccessful {
+				expected[sender.Addr].Sub(expected[sender.Addr], tx.Value())
+				expected[*to].Add(expected[*to], tx.Value())
+			}
+
+			fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), gasPrice)
+			totalFee.Add(totalFee, fee)
+			if feeRatio, ok := tx.FeeRatio(); ok {
+				feeByFeePayer, feeBySender := types.CalcFeeWithRatio(feeRatio, fee)
+				expected[feePayer.Addr].Sub(expected[feePayer.Addr], feeByFeePayer)
+				expected[sender.Addr].Sub(expected[sender.Addr], feeBySender)
+			} else if tx.IsFeeDelegatedTransaction() {
+				expected[feePayer.Addr].Sub(expected[feePayer.Addr], fee)
+			} else {
+				expected[sender.Addr].Sub(expected[sender.Addr], fee)
+			}
+		}
+
+		statedb, err := chain.State()
+		require.NoError(t, err)
+		sum := new(big.Int)
+		for addr, want := range expected {
+			assert.Equal(t, want, statedb.GetBalance(addr), addr.Hex())
+			sum.Add(sum, statedb.GetBalance(addr))
+		}
+		assert.Equal(t, uint64(len(txs)), statedb.GetNonce(sender.Addr))
+		assert.Zero(t, statedb.GetNonce(feePayer.Addr))
+
+		// The accounts lose exactly the fees in total.
+		assert.Equal(t, new(big.Int).Sub(new(big.Int).Mul(balance, common.Big2), totalFee), sum)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	blockchain "github.com/kaiachain/kaia/blockchain"
 	state "github.com/kaiachain/kaia/blockchain/state"
+	stateless "github.com/kaiachain/kaia/blockchain/stateless"
 	types "github.com/kaiachain/kaia/blockchain/types"
 	vm "github.com/kaiachain/kaia/blockchain/vm"
 	common "github.com/kaiachain/kaia/common"
@@ -199,6 +200,18 @@ func (mr *MockBlockChainMockRecorder) CurrentHeader() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentHeader", reflect.TypeOf((*MockBlockChain)(nil).CurrentHeader))
 }
 
+// EnableStateDiff mocks base method.
+func (m *MockBlockChain) EnableStateDiff() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EnableStateDiff")
+}
+
+// EnableStateDiff indicates an expected call of EnableStateDiff.
+func (mr *MockBlockChainMockRecorder) EnableStateDiff() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableStateDiff", reflect.TypeOf((*MockBlockChain)(nil).EnableStateDiff))
+}
+
 // Engine mocks base method.
 func (m *MockBlockChain) Engine() consensus.Engine {
 	m.ctrl.T.Helper()
@@ -213,6 +226,26 @@ func (mr *MockBlockChainMockRecorder) Engine() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Engine", reflect.TypeOf((*MockBlockChain)(nil).Engine))
 }
 
+// ExecutionWitness mocks base method.
+func (m *MockBlockChain) ExecutionWitness(arg0 *types.Block, arg1 ...blockchain.StatePreloader) (*stateless.Witness, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecutionWitness", varargs...)
+	ret0, _ := ret[0].(*stateless.Witness)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecutionWitness indicates an expected call of ExecutionWitness.
+func (mr *MockBlockChainMockRecorder) ExecutionWitness(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecutionWitness", reflect.TypeOf((*MockBlockChain)(nil).ExecutionWitness), varargs...)
+}
+
 // Export mocks base method.
 func (m *MockBlockChain) Export(arg0 io.Writer) error {
 	m.ctrl.T.Helper()
@@ -936,6 +969,20 @@ func (mr *MockBlockChainMockRecorder) StateCache() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCache", reflect.TypeOf((*MockBlockChain)(nil).StateCache))
 }
 
+// StateDiff mocks base method.
+func (m *MockBlockChain) StateDiff(arg0 common.Hash) *state.StateDiff {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDiff", arg0)
+	ret0, _ := ret[0].(*state.StateDiff)
+	return ret0
+}
+
+// StateDiff indicates an expected call of StateDiff.
+func (mr *MockBlockChainMockRecorder) StateDiff(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDiff", reflect.TypeOf((*MockBlockChain)(nil).StateDiff), arg0)
+}
+
 // StateMigrationStatus mocks base method.
 func (m *MockBlockChain) StateMigrationStatus() (bool, uint64, int, int, int, float64, error) {
 	m.ctrl.T.Helper()
@@ -31,6 +31,7 @@ import (
 	"github.com/kaiachain/kaia/accounts"
 	"github.com/kaiachain/kaia/blockchain"
 	"github.com/kaiachain/kaia/blockchain/state"
+	"github.com/kaiachain/kaia/blockchain/stateless"
 	"github.com/kaiachain/kaia/blockchain/types"
 	"github.com/kaiachain/kaia/blockchain/vm"
 	"github.com/kaiachain/kaia/common"
@@ -318,6 +319,13 @@ type BlockChain interface {
 	// Save trie node cache to this
 	SaveTrieNodeCacheToDisk() error
 
+	// Execution witness
+	ExecutionWitness(block *types.Block, preloaders ...blockchain.StatePreloader) (*stateless.Witness, error)
+
+	// State diff
+	EnableStateDiff()
+	StateDiff(hash common.Hash) *state.StateDiff
+
 	// KES
 	BlockSubscriptionLoop(pool *blockchain.TxPool)
 	CloseBlockSubscriptionLoop()